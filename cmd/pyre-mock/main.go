@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jp2195/pyre/internal/mockpanos"
+)
+
+func main() {
+	var (
+		addr     = flag.String("listen", "127.0.0.1:8443", "Address to serve the XML API on")
+		panorama = flag.Bool("panorama", false, "Simulate a Panorama with managed firewalls")
+		data     = flag.String("data", "", "YAML dataset overlaying the built-in one")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "pyre-mock - simulated PAN-OS XML API for demos and development\n\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "  pyre-mock [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nThen, in another terminal:\n")
+		fmt.Fprintf(os.Stderr, "  pyre --host 127.0.0.1:8443 --user admin --insecure   # password: admin\n")
+	}
+	flag.Parse()
+
+	ds := mockpanos.Builtin(*panorama)
+	if *data != "" {
+		var err error
+		if ds, err = mockpanos.LoadDataset(*data, ds); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	srv, err := mockpanos.New(ds)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	inst, err := mockpanos.Start(srv, *addr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	kind := "firewall"
+	if ds.Panorama {
+		kind = "Panorama"
+	}
+	fmt.Printf("Simulated %s %s listening on https://%s\n", kind, ds.System.Hostname, inst.Addr())
+	fmt.Printf("  login:   %s / %s\n", ds.Username, ds.Password)
+	fmt.Printf("  api key: %s\n", ds.APIKey)
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	<-sig
+	_ = inst.Close() //nolint:errcheck // exiting
+}
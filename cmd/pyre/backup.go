@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jp2195/pyre/internal/backup"
+	"github.com/jp2195/pyre/internal/config"
+	"github.com/jp2195/pyre/internal/devicefile"
+	"github.com/jp2195/pyre/internal/models"
+)
+
+// backupTimeout bounds fetching one device's running config.
+const backupTimeout = 2 * time.Minute
+
+const backupUsage = `Usage:
+  pyre backup [flags] [HOST...]       Save the running config of each HOST
+                                      (default: every connection in the config)
+  pyre backup list [HOST]             List stored versions, or devices with backups
+  pyre backup diff HOST [OLD [NEW]]   Diff two versions (default: the two newest)
+
+Backups are kept under ~/.pyre/backups/<host>/, one file per version, and
+pruned to settings.backup.keep / max_age_days after every save. The API key
+comes from --api-key, PYRE_API_KEY, PYRE_<HOST>_API_KEY, or the connection's
+api_key_command.
+
+Flags:
+`
+
+// runBackup implements `pyre backup` and returns the process exit code.
+func runBackup(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	apiKey := fs.String("api-key", "", "API key (default: PYRE_API_KEY or PYRE_<HOST>_API_KEY)")
+	insecure := fs.Bool("insecure", false, "Skip TLS certificate verification")
+	configPath := fs.String("config", "", "Path to config file (default: ~/.pyre.yaml)")
+	format := fs.String("format", "set", "Diff format: set or xml")
+	fs.Usage = func() {
+		fmt.Fprint(stderr, backupUsage)
+		fs.PrintDefaults()
+	}
+
+	sub := "save"
+	if len(args) > 0 && (args[0] == "list" || args[0] == "diff") {
+		sub, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	cfg, err := config.LoadWithFlags(config.CLIFlags{Config: *configPath})
+	if err != nil {
+		fmt.Fprintf(stderr, "Error loading config: %v\n", err)
+		return 1
+	}
+	store, retention, err := backup.FromSettings(cfg.Settings.Backup)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	switch sub {
+	case "list":
+		err = backupList(stdout, store, fs.Args())
+	case "diff":
+		if *format != "set" && *format != "xml" {
+			err = fmt.Errorf("unknown format %q (want set or xml)", *format)
+			break
+		}
+		err = backupDiff(stdout, store, fs.Args(), *format == "xml")
+	default:
+		hosts := fs.Args()
+		if len(hosts) == 0 {
+			hosts = cfg.ConnectionHosts()
+			slices.Sort(hosts)
+		}
+		if len(hosts) == 0 {
+			fs.Usage()
+			return 2
+		}
+		failed := 0
+		for _, host := range hosts {
+			if err := backupSave(stdout, cfg, store, retention, host, *apiKey, *insecure); err != nil {
+				fmt.Fprintf(stderr, "%s: %v\n", host, err)
+				failed++
+			}
+		}
+		if failed > 0 {
+			return 1
+		}
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// backupSave fetches host's running config, stores it, and applies the
+// retention policy.
+func backupSave(w io.Writer, cfg *config.Config, store *backup.Store, r backup.Retention, host, apiKey string, insecure bool) error {
+	client, err := headlessClient(cfg, host, apiKey, insecure)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), backupTimeout)
+	defer cancel()
+	doc, err := client.GetRunningConfig(ctx, "")
+	if err != nil {
+		return untrustedHostHint(err, host)
+	}
+	v, err := store.Save(devicefile.Device(host, ""), doc, time.Now())
+	if err != nil {
+		return err
+	}
+	removed, err := store.Prune(v.Device, r, time.Now())
+	if err != nil {
+		return fmt.Errorf("saved %s, but pruning failed: %w", v.Path, err)
+	}
+	fmt.Fprintf(w, "%s: saved %s (%s)", host, v.Path, formatSize(v.Size))
+	if len(removed) > 0 {
+		fmt.Fprintf(w, ", pruned %d old version(s)", len(removed))
+	}
+	fmt.Fprintln(w)
+	return nil
+}
+
+func backupList(w io.Writer, store *backup.Store, args []string) error {
+	if len(args) == 0 {
+		devices, err := store.Devices()
+		if err != nil {
+			return err
+		}
+		if len(devices) == 0 {
+			fmt.Fprintf(w, "No backups in %s\n", store.Dir())
+		}
+		for _, d := range devices {
+			versions, err := store.List(d)
+			if err != nil {
+				return err
+			}
+			latest := "-"
+			if len(versions) > 0 {
+				latest = versions[0].Time.Local().Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%-40s %4d version(s)  latest %s\n", d, len(versions), latest)
+		}
+		return nil
+	}
+
+	versions, err := store.List(args[0])
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		return fmt.Errorf("no backups for %s", args[0])
+	}
+	for _, v := range versions {
+		fmt.Fprintf(w, "%s  %s  %8s\n", v.Name(), v.Time.Local().Format("2006-01-02 15:04:05"), formatSize(v.Size))
+	}
+	return nil
+}
+
+// backupDiff prints the diff between two versions of a device. Without
+// names it compares the two newest; with one, that version against the
+// newest.
+func backupDiff(w io.Writer, store *backup.Store, args []string, xml bool) error {
+	if len(args) == 0 || len(args) > 3 {
+		return errors.New("usage: pyre backup diff HOST [OLD [NEW]]")
+	}
+	device := args[0]
+	versions, err := store.List(device)
+	if err != nil {
+		return err
+	}
+
+	var older, newer backup.Version
+	switch len(args) {
+	case 1:
+		if len(versions) < 2 {
+			return fmt.Errorf("%s has %d backup(s); need two to diff", device, len(versions))
+		}
+		older, newer = versions[1], versions[0]
+	case 2:
+		if older, err = store.Find(device, args[1]); err != nil {
+			return err
+		}
+		newer = versions[0]
+	case 3:
+		if older, err = store.Find(device, args[1]); err != nil {
+			return err
+		}
+		if newer, err = store.Find(device, args[2]); err != nil {
+			return err
+		}
+	}
+
+	hunks, err := store.Diff(older, newer)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "--- %s %s\n+++ %s %s\n", device, older.Name(), device, newer.Name())
+	if len(hunks) == 0 {
+		fmt.Fprintln(w, "No differences")
+		return nil
+	}
+	for _, h := range hunks {
+		fmt.Fprintf(w, "\n%s %s\n", h.Kind, h.XPath)
+		lines := h.Set
+		if xml {
+			lines = h.XML
+		}
+		writeDiffLines(w, lines)
+	}
+	return nil
+}
+
+func writeDiffLines(w io.Writer, lines []models.ConfigDiffLine) {
+	for _, l := range lines {
+		fmt.Fprintf(w, "%c %s%s\n", l.Op, strings.Repeat("  ", l.Depth), l.Text)
+	}
+}
+
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jp2195/pyre/internal/mockpanos"
+)
+
+// startDemo brings up an in-process fake device on a loopback port and
+// returns it with the API key that device accepts. The caller points the
+// normal connection flow at inst.Addr(), so demo mode exercises exactly the
+// same code paths as a real firewall.
+func startDemo(panorama bool, dataPath string) (*mockpanos.Instance, string, error) {
+	ds := mockpanos.Builtin(panorama)
+	if dataPath != "" {
+		var err error
+		if ds, err = mockpanos.LoadDataset(dataPath, ds); err != nil {
+			return nil, "", err
+		}
+	}
+	srv, err := mockpanos.New(ds)
+	if err != nil {
+		return nil, "", fmt.Errorf("demo dataset: %w", err)
+	}
+	inst, err := mockpanos.Start(srv, "127.0.0.1:0")
+	if err != nil {
+		return nil, "", err
+	}
+	return inst, ds.APIKey, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/config"
+	"github.com/jp2195/pyre/internal/exporter"
+)
+
+const exporterUsage = `Usage:
+  pyre exporter [flags] [HOST...]     Serve Prometheus metrics for each HOST
+                                      (default: every connection in the config)
+
+Scrape /metrics?target=HOST, adding &serial=SERIAL to read a firewall
+through the Panorama at HOST. Only the listed hosts can be scraped. With a
+single host, target may be left out. Responses are cached for --cache
+(licenses and certificates for an hour, disks for five minutes), so
+several Prometheus servers can scrape the same device without adding load.
+
+The API key comes from --api-key, PYRE_API_KEY, PYRE_<HOST>_API_KEY, or the
+connection's api_key_command.
+
+Flags:
+`
+
+// runExporter implements `pyre exporter` and returns the process exit code.
+func runExporter(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("exporter", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	listen := fs.String("listen", ":9733", "Address to serve metrics on")
+	apiKey := fs.String("api-key", "", "API key (default: PYRE_API_KEY or PYRE_<HOST>_API_KEY)")
+	insecure := fs.Bool("insecure", false, "Skip TLS certificate verification")
+	configPath := fs.String("config", "", "Path to config file (default: ~/.pyre.yaml)")
+	cacheTTL := fs.Duration("cache", exporter.DefaultCacheTTL, "How long scraped responses are reused")
+	timeout := fs.Duration("timeout", exporter.DefaultTimeout, "Longest a scrape may take")
+	fs.Usage = func() {
+		fmt.Fprint(stderr, exporterUsage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	cfg, err := config.LoadWithFlags(config.CLIFlags{Config: *configPath})
+	if err != nil {
+		fmt.Fprintf(stderr, "Error loading config: %v\n", err)
+		return 1
+	}
+	hosts := fs.Args()
+	if len(hosts) == 0 {
+		hosts = cfg.ConnectionHosts()
+		slices.Sort(hosts)
+	}
+	if len(hosts) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	handler := exporter.New(exporter.Options{
+		Hosts: hosts,
+		Dial: func(host string) (*api.Client, error) {
+			c, err := headlessClient(cfg, host, *apiKey, *insecure)
+			return c, untrustedHostHint(err, host)
+		},
+		CacheTTL: *cacheTTL,
+		Timeout:  *timeout,
+	})
+
+	ln, err := net.Listen("tcp", *listen)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	srv := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	fmt.Fprintf(stdout, "Serving metrics for %d device(s) on http://%s/metrics\n", len(hosts), ln.Addr())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdown)
+	}()
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/auth"
+	"github.com/jp2195/pyre/internal/config"
+)
+
+// headlessClient returns a client for host for a subcommand that runs
+// without a terminal to prompt on. The API key comes from apiKey,
+// PYRE_API_KEY / PYRE_<HOST>_API_KEY, or the connection's api_key_command.
+func headlessClient(cfg *config.Config, host, apiKey string, insecure bool) (*api.Client, error) {
+	if msg := auth.ValidateHost(host); msg != "" {
+		return nil, fmt.Errorf("invalid host: %s", msg)
+	}
+	conn, _ := cfg.GetConnection(host)
+	if apiKey == "" {
+		apiKey = auth.EnvAPIKey(host)
+	}
+	if apiKey == "" && conn.APIKeyCommand != "" {
+		key, err := auth.CommandAPIKey(context.Background(), host, conn.APIKeyCommand)
+		if err != nil {
+			return nil, err
+		}
+		apiKey = key
+	}
+	if apiKey == "" {
+		return nil, errors.New("no API key: pass --api-key, set PYRE_API_KEY / PYRE_<HOST>_API_KEY, or configure api_key_command")
+	}
+	conn.Insecure = conn.Insecure || insecure
+	opts, err := auth.ClientOptions(&conn)
+	if err != nil {
+		return nil, err
+	}
+	return api.NewClient(host, apiKey, opts)
+}
+
+// untrustedHostHint adds how to trust host to err when the client refused
+// an unknown host key. Headless commands have no one to ask.
+func untrustedHostHint(err error, host string) error {
+	var unknown *api.UnknownHostError
+	if errors.As(err, &unknown) {
+		return fmt.Errorf("%w; run `pyre -c %s` once to check and trust it", err, host)
+	}
+	return err
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/auth"
+	"github.com/jp2195/pyre/internal/config"
+)
+
+// hostKeyTimeout bounds the handshake confirmHostKey makes.
+const hostKeyTimeout = 15 * time.Second
+
+// confirmHostKey checks a pinned connection's certificate before the TUI
+// starts with an API key, which skips the login form and so the trust
+// prompt on it. Like ssh, it asks on in before recording an unknown
+// certificate, and refuses a changed one outright.
+func confirmHostKey(host string, conn config.ConnectionConfig, in io.Reader, out io.Writer) error {
+	opts, err := auth.ClientOptions(&conn)
+	if err != nil || opts.KnownHosts == nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), hostKeyTimeout)
+	defer cancel()
+	err = api.CheckHostKey(ctx, host, opts)
+	var changed *api.HostKeyChangedError
+	if errors.As(err, &changed) {
+		return err
+	}
+	var unknown *api.UnknownHostError
+	if !errors.As(err, &unknown) {
+		// Trusted, or unreachable: the TUI reports connection errors.
+		return nil
+	}
+
+	fmt.Fprintf(out, "The certificate of %s is not in known_hosts.\n", host)
+	fmt.Fprintf(out, "Fingerprint: %s\n", unknown.Fingerprint)
+	fmt.Fprint(out, "Trust it and continue connecting (yes/no)? ")
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	if a := strings.ToLower(strings.TrimSpace(answer)); a != "yes" && a != "y" {
+		return fmt.Errorf("certificate of %s was not trusted", host)
+	}
+	return opts.KnownHosts.Trust(unknown.Host, unknown.Fingerprint)
+}
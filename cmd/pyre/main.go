@@ -22,6 +22,19 @@ var (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "backup" {
+		os.Exit(runBackup(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "exporter" {
+		os.Exit(runExporter(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "report" {
+		os.Exit(runReport(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "watch" {
+		os.Exit(runWatch(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	var (
 		host       = flag.String("host", "", "Firewall hostname or IP address")
 		user       = flag.String("user", "", "Username for authentication (prompts for password)")
@@ -29,7 +42,11 @@ func main() {
 		insecure   = flag.Bool("insecure", false, "Skip TLS certificate verification (for self-signed certs)")
 		configPath = flag.String("config", "", "Path to config file (default: ~/.pyre.yaml)")
 		connection = flag.String("c", "", "Connect to a named connection from config")
+		allowWrite = flag.Bool("allow-write", false, "Permit validate and commit on the --host connection")
 		debug      = flag.Bool("debug", false, "Enable debug logging to ~/.pyre/logs/debug.log")
+		demo       = flag.Bool("demo", false, "Explore pyre against a built-in simulated firewall")
+		demoPano   = flag.Bool("demo-panorama", false, "Like --demo, but simulate a Panorama with managed firewalls")
+		demoData   = flag.String("demo-data", "", "YAML dataset for demo mode (implies --demo)")
 		showHelp   = flag.Bool("help", false, "Show help message")
 		showVer    = flag.Bool("version", false, "Show version")
 	)
@@ -37,7 +54,11 @@ func main() {
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "pyre - Palo Alto Firewall TUI\n\n")
 		fmt.Fprintf(os.Stderr, "Usage:\n")
-		fmt.Fprintf(os.Stderr, "  pyre [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "  pyre [flags]\n")
+		fmt.Fprintf(os.Stderr, "  pyre backup [flags] [HOST...]  (see pyre backup --help)\n")
+		fmt.Fprintf(os.Stderr, "  pyre exporter [flags] [HOST...]  (see pyre exporter --help)\n")
+		fmt.Fprintf(os.Stderr, "  pyre report -c HOST [flags]  (see pyre report --help)\n")
+		fmt.Fprintf(os.Stderr, "  pyre watch [flags] [HOST...]  (see pyre watch --help)\n\n")
 		fmt.Fprintf(os.Stderr, "Flags:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nEnvironment Variables:\n")
@@ -53,6 +74,11 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  pyre --host fw.example.com --api-key LUFRPT...\n")
 		fmt.Fprintf(os.Stderr, "  PYRE_HOST=10.0.0.1 PYRE_API_KEY=LUFRPT... pyre\n")
 		fmt.Fprintf(os.Stderr, "  pyre --debug                            # Enable debug logging\n")
+		fmt.Fprintf(os.Stderr, "  pyre --demo                             # Try pyre without a firewall\n")
+		fmt.Fprintf(os.Stderr, "  pyre backup fw.example.com              # Save its running config to ~/.pyre/backups\n")
+		fmt.Fprintf(os.Stderr, "  pyre exporter --listen :9733            # Serve Prometheus metrics for configured connections\n")
+		fmt.Fprintf(os.Stderr, "  pyre report -c myfw --format html -o q3.html  # Write a health report\n")
+		fmt.Fprintf(os.Stderr, "  pyre watch --once fw.example.com        # Print its threshold breaches as JSON\n")
 	}
 
 	flag.Parse()
@@ -97,6 +123,24 @@ func main() {
 		Insecure:   *insecure,
 		Config:     *configPath,
 		Connection: *connection,
+		AllowWrite: *allowWrite,
+	}
+
+	// Demo mode: serve a fake device on loopback and connect to it exactly
+	// as --host/--api-key/--insecure would.
+	if *demo || *demoPano || *demoData != "" {
+		inst, key, err := startDemo(*demoPano, *demoData)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error starting demo: %v\n", err)
+			os.Exit(1)
+		}
+		defer inst.Close() //nolint:errcheck // process is exiting
+		flags.Host = inst.Addr()
+		flags.APIKey = key
+		flags.Insecure = true
+		flags.Connection = ""
+		// The simulated device has nothing to break, so commits are on.
+		flags.AllowWrite = true
 	}
 
 	cfg, err := config.LoadWithFlags(flags)
@@ -136,7 +180,18 @@ func main() {
 		// Set up credentials for the specified connection
 		creds.Host = flags.Connection // Host is now the key
 		creds.Insecure = conn.Insecure
-		creds.PromptForPassword = true
+		creds.PromptForPassword = !creds.HasAPIKey()
+	}
+
+	// An API key skips the login form, and with it the TUI's prompt to
+	// trust a pinned certificate, so ask here.
+	if creds.HasAPIKey() && creds.HasHost() {
+		if conn, ok := cfg.GetConnection(creds.Host); ok {
+			if err := confirmHostKey(creds.Host, conn, os.Stdin, os.Stderr); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
 	}
 
 	model, err := tui.NewModel(cfg, state, creds, startView)
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jp2195/pyre/internal/auth"
+	"github.com/jp2195/pyre/internal/config"
+	"github.com/jp2195/pyre/internal/report"
+)
+
+const reportUsage = `Usage:
+  pyre report -c HOST[/SERIAL] [flags]   Write a health report for HOST
+
+The report covers system info, HA, resources, licenses, certificates,
+content versions, disk usage, environmentals, interfaces with errors, down
+IPsec tunnels, BGP and OSPF state, pending changes, and rule-hygiene
+findings, with what needs attention summarized at the top. It is one
+self-contained Markdown or HTML file. HOST/SERIAL reports on a firewall
+through the Panorama at HOST.
+
+Sections the device can't provide say why; the rest of the report is still
+written. Licenses and certificates expiring within settings.alerts.cert_days
+(30 when unset or 0) are called out.
+
+The API key comes from --api-key, PYRE_API_KEY, PYRE_<HOST>_API_KEY, or the
+connection's api_key_command.
+
+Flags:
+`
+
+// runReport implements `pyre report` and returns the process exit code.
+func runReport(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	device := fs.String("c", "", "Connection (HOST, or HOST/SERIAL for a Panorama-managed firewall)")
+	format := fs.String("format", "md", "Output format: md or html")
+	output := fs.String("o", "", "Write the report to this file (default: stdout)")
+	timeout := fs.Duration("timeout", 2*time.Minute, "Time allowed for reading the device")
+	apiKey := fs.String("api-key", "", "API key (default: PYRE_API_KEY or PYRE_<HOST>_API_KEY)")
+	insecure := fs.Bool("insecure", false, "Skip TLS certificate verification")
+	configPath := fs.String("config", "", "Path to config file (default: ~/.pyre.yaml)")
+	fs.Usage = func() {
+		fmt.Fprint(stderr, reportUsage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if *device == "" && fs.NArg() == 1 {
+		*device = fs.Arg(0)
+	} else if *device == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+	var write func(io.Writer, *report.Report) error
+	switch *format {
+	case "md", "markdown":
+		write = report.WriteMarkdown
+	case "html":
+		write = report.WriteHTML
+	default:
+		fmt.Fprintf(stderr, "Error: unknown format %q (want md or html)\n", *format)
+		return 2
+	}
+	host, serial, _ := strings.Cut(*device, "/")
+	if serial != "" {
+		if err := auth.ValidateSerial(serial); err != nil {
+			fmt.Fprintf(stderr, "Error: %s: %v\n", *device, err)
+			return 2
+		}
+	}
+
+	cfg, err := config.LoadWithFlags(config.CLIFlags{Config: *configPath})
+	if err != nil {
+		fmt.Fprintf(stderr, "Error loading config: %v\n", err)
+		return 1
+	}
+	client, err := headlessClient(cfg, host, *apiKey, *insecure)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	opts := report.Options{ExpiryDays: cmp.Or(cfg.Settings.Alerts.CertDays, config.DefaultAlertSettings.CertDays)}
+	r, err := report.Collect(ctx, client, host, serial, opts)
+	if r.System == nil {
+		// Without even the system info the device wasn't reached; a
+		// report of nothing but errors helps no one.
+		fmt.Fprintf(stderr, "Error: %s: %v\n", *device, untrustedHostHint(r.Errors["system"], host))
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Warning: %s: some sections could not be read:\n  %s\n", *device, strings.ReplaceAll(err.Error(), "\n", "\n  "))
+	}
+
+	if *output == "" {
+		if err := write(stdout, r); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	f, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	if err := errors.Join(write(f, r), f.Close()); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stderr, "%s: wrote %s\n", *device, *output)
+	return 0
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jp2195/pyre/internal/alerts"
+	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/auth"
+	"github.com/jp2195/pyre/internal/config"
+	"github.com/jp2195/pyre/internal/devicefile"
+	"github.com/jp2195/pyre/internal/history"
+	"github.com/jp2195/pyre/internal/models"
+)
+
+const watchUsage = `Usage:
+  pyre watch [flags] [HOST[/SERIAL]...]   Check each device against the alert rules
+                                          (default: every connection in the config)
+
+The rules are the alerts section of the config's settings. Each breach,
+recovery, and HA state change is written to stdout as one JSON object per
+line, or POSTed to --webhook. A device that can't be read is reported on
+stderr and tried again on the next check. HOST/SERIAL reads a firewall
+through the Panorama at HOST.
+
+With settings.history enabled, each device is also sampled for the
+dashboard's trend charts, so the history keeps growing while the TUI is
+closed.
+
+The API key comes from --api-key, PYRE_API_KEY, PYRE_<HOST>_API_KEY, or the
+connection's api_key_command.
+
+Flags:
+`
+
+// watchTarget is one device pyre watch checks.
+type watchTarget struct {
+	host, serial string
+	client       *api.Client // nil until the first successful dial
+	sampler      history.Sampler
+
+	checked, sampled time.Time // last alert check and history sample
+}
+
+// runWatch implements `pyre watch` and returns the process exit code.
+func runWatch(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	interval := fs.Duration("interval", 0, "Time between checks (default: settings.alerts.interval_seconds)")
+	webhook := fs.String("webhook", "", "POST events to this URL instead of writing them to stdout (default: settings.alerts.webhook)")
+	apiKey := fs.String("api-key", "", "API key (default: PYRE_API_KEY or PYRE_<HOST>_API_KEY)")
+	insecure := fs.Bool("insecure", false, "Skip TLS certificate verification")
+	configPath := fs.String("config", "", "Path to config file (default: ~/.pyre.yaml)")
+	once := fs.Bool("once", false, "Check once, report what is breached, and exit")
+	fs.Usage = func() {
+		fmt.Fprint(stderr, watchUsage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	cfg, err := config.LoadWithFlags(config.CLIFlags{Config: *configPath})
+	if err != nil {
+		fmt.Fprintf(stderr, "Error loading config: %v\n", err)
+		return 1
+	}
+	settings := cfg.Settings.Alerts
+	if *webhook != "" {
+		settings.Webhook = *webhook
+	}
+	rules, every, hookURL, err := alerts.FromSettings(settings)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	store, sampleEvery, err := history.FromSettings(cfg.Settings.History)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	if !rules.Any() && store == nil {
+		fmt.Fprintln(stderr, "Error: no alert rules are set under settings.alerts, and settings.history is off")
+		return 1
+	}
+	if *interval > 0 {
+		every = *interval
+	}
+	var engine *alerts.Engine
+	tick := sampleEvery
+	if rules.Any() {
+		engine = alerts.NewEngine(rules)
+		tick = every
+		if store != nil {
+			tick = min(every, sampleEvery)
+		}
+	}
+	// due reports whether a job last run at last, every d, should run now.
+	// Half a tick of slack keeps timer jitter from skipping a run.
+	due := func(last time.Time, d time.Duration, now time.Time) bool {
+		return last.IsZero() || now.Sub(last) >= d-tick/2
+	}
+
+	hosts := fs.Args()
+	if len(hosts) == 0 {
+		hosts = cfg.ConnectionHosts()
+		slices.Sort(hosts)
+	}
+	if len(hosts) == 0 {
+		fs.Usage()
+		return 2
+	}
+	targets := make([]*watchTarget, 0, len(hosts))
+	for _, h := range hosts {
+		host, serial, _ := strings.Cut(h, "/")
+		if serial != "" {
+			if err := auth.ValidateSerial(serial); err != nil {
+				fmt.Fprintf(stderr, "Error: %s: %v\n", h, err)
+				return 2
+			}
+		}
+		targets = append(targets, &watchTarget{host: host, serial: serial})
+	}
+
+	emit := func(ctx context.Context, ev models.AlertEvent) {
+		if hookURL == "" {
+			_ = json.NewEncoder(stdout).Encode(ev)
+			return
+		}
+		if err := (alerts.Webhook{URL: hookURL}).Send(ctx, ev); err != nil {
+			fmt.Fprintf(stderr, "%s: webhook: %v\n", ev.Device, err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		failed := false
+		now := time.Now()
+		for _, t := range targets {
+			if err := t.dial(cfg, *apiKey, *insecure); err != nil {
+				failed = true
+				fmt.Fprintf(stderr, "%s: %v\n", devicefile.Device(t.host, t.serial), err)
+				continue
+			}
+			if engine != nil && due(t.checked, every, now) {
+				t.checked = now
+				events, err := t.check(ctx, engine, every)
+				if err != nil {
+					failed = true
+					fmt.Fprintf(stderr, "%s: %v\n", devicefile.Device(t.host, t.serial), err)
+				}
+				for _, ev := range events {
+					emit(ctx, ev)
+				}
+			}
+			if store != nil && due(t.sampled, sampleEvery, now) {
+				t.sampled = now
+				if err := t.sample(ctx, store, sampleEvery); err != nil {
+					failed = true
+					fmt.Fprintf(stderr, "%s: history: %v\n", devicefile.Device(t.host, t.serial), err)
+				}
+			}
+		}
+		if *once {
+			if failed {
+				return 1
+			}
+			return 0
+		}
+		select {
+		case <-ctx.Done():
+			return 0
+		case <-ticker.C:
+		}
+	}
+}
+
+// dial connects to t's host, unless it already has.
+func (t *watchTarget) dial(cfg *config.Config, apiKey string, insecure bool) error {
+	if t.client != nil {
+		return nil
+	}
+	c, err := headlessClient(cfg, t.host, apiKey, insecure)
+	if err != nil {
+		return untrustedHostHint(err, t.host)
+	}
+	t.client = c
+	return nil
+}
+
+// check reads t once and returns the events engine raised for it. Reads
+// that fail are reported but don't stop the rest from being checked.
+func (t *watchTarget) check(ctx context.Context, engine *alerts.Engine, every time.Duration) ([]models.AlertEvent, error) {
+	// A check that outlasts the interval would only pile up behind the next.
+	// Its responses are cached for the history sample that may follow.
+	ctx, cancel := context.WithTimeout(api.WithCache(ctx, every/2), every)
+	defer cancel()
+	s, err := alerts.Collect(ctx, t.client, t.serial, engine.Rules())
+	events := engine.Observe(devicefile.Device(t.host, t.serial), s, time.Now())
+	return events, err
+}
+
+// sample appends a sample of t to its history, reusing the responses the
+// alert check just read. What could be read is saved even if some reads
+// failed.
+func (t *watchTarget) sample(ctx context.Context, store *history.Store, every time.Duration) error {
+	ctx, cancel := context.WithTimeout(api.WithCache(ctx, every/2), every)
+	defer cancel()
+	r, err := history.Collect(ctx, t.client, t.serial)
+	if saveErr := store.Append(devicefile.Device(t.host, t.serial), t.sampler.Sample(r)); saveErr != nil {
+		return saveErr
+	}
+	return err
+}
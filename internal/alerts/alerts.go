@@ -0,0 +1,305 @@
+// Package alerts checks device state against threshold rules and reports
+// when a breach starts and when it ends.
+package alerts
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jp2195/pyre/internal/config"
+	"github.com/jp2195/pyre/internal/models"
+)
+
+// Rules are the thresholds and conditions checked. A zero threshold or a
+// false switch turns its rule off.
+type Rules struct {
+	CPUPercent     float64 // management or dataplane CPU above this
+	SessionPercent float64 // active sessions above this share of the maximum
+	NATPoolPercent float64 // a NAT pool above this utilization
+	CertDays       int     // a certificate expiring in fewer days
+	TunnelDown     bool    // an IPSec tunnel not up
+	BGPDown        bool    // a BGP peer not Established
+	HAChange       bool    // the local HA state changing
+}
+
+// Any reports whether any rule is on.
+func (r Rules) Any() bool {
+	return r != Rules{}
+}
+
+// DefaultInterval is how often rules are checked unless the config says
+// otherwise.
+const DefaultInterval = time.Minute
+
+// minInterval keeps a misconfigured interval from hammering the device.
+const minInterval = 10 * time.Second
+
+// FromSettings returns the rules, check interval, and webhook URL the
+// config describes.
+func FromSettings(s config.AlertSettings) (Rules, time.Duration, string, error) {
+	if s.CPUPercent < 0 || s.SessionPercent < 0 || s.NATPoolPercent < 0 || s.CertDays < 0 {
+		return Rules{}, 0, "", errors.New("alerts: thresholds must not be negative")
+	}
+	if s.Webhook != "" {
+		u, err := url.Parse(s.Webhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return Rules{}, 0, "", fmt.Errorf("alerts: webhook %q is not an http(s) URL", s.Webhook)
+		}
+	}
+	interval := DefaultInterval
+	if s.IntervalSeconds > 0 {
+		interval = max(time.Duration(s.IntervalSeconds)*time.Second, minInterval)
+	}
+	if s.Disabled {
+		return Rules{}, interval, s.Webhook, nil
+	}
+	return Rules{
+		CPUPercent:     s.CPUPercent,
+		SessionPercent: s.SessionPercent,
+		NATPoolPercent: s.NATPoolPercent,
+		CertDays:       s.CertDays,
+		TunnelDown:     s.TunnelDown,
+		BGPDown:        s.BGPDown,
+		HAChange:       s.HAChange,
+	}, interval, s.Webhook, nil
+}
+
+// Rule names, as reported in models.AlertEvent.Rule.
+const (
+	RuleCPU         = "cpu"
+	RuleSessions    = "sessions"
+	RuleNATPool     = "nat_pool"
+	RuleCertificate = "certificate"
+	RuleTunnel      = "tunnel"
+	RuleBGP         = "bgp"
+	RuleHA          = "ha"
+)
+
+// Snapshot is what was read from a device. A nil field was not read, so
+// the rules that need it are not checked; an empty slice that was read
+// means there are none.
+type Snapshot struct {
+	Resources    *models.Resources
+	DataPlaneCPU *float64
+	Sessions     *models.SessionInfo
+	HA           *models.HAStatus
+	Tunnels      []models.IPSecTunnel
+	BGPPeers     []models.BGPNeighbor
+	Certificates []models.Certificate
+	NATPools     []models.NATPoolInfo
+}
+
+// breach is one condition currently met.
+type breach struct {
+	rule, subject    string
+	value, threshold float64
+	message          string
+}
+
+func (b breach) key() string { return b.rule + "\x00" + b.subject }
+
+// Engine remembers the breaches active on each device, so that each is
+// reported once when it starts and once when it ends. It is not safe for
+// concurrent use.
+type Engine struct {
+	rules   Rules
+	devices map[string]*deviceState
+}
+
+type deviceState struct {
+	active  map[string]activeBreach // by breach key
+	haState string                  // last local HA state seen; "" before the first
+}
+
+// activeBreach is a breach reported as firing, and the check that found
+// it: only that check, run again, can resolve it.
+type activeBreach struct {
+	scope string
+	event models.AlertEvent
+}
+
+// NewEngine returns an Engine checking rules.
+func NewEngine(rules Rules) *Engine {
+	return &Engine{rules: rules, devices: make(map[string]*deviceState)}
+}
+
+// Rules returns the rules the engine checks.
+func (e *Engine) Rules() Rules {
+	return e.rules
+}
+
+// Observe checks a snapshot of device taken at now and returns the
+// breaches that started or ended, and any changes, since the last one.
+func (e *Engine) Observe(device string, s Snapshot, now time.Time) []models.AlertEvent {
+	st, ok := e.devices[device]
+	if !ok {
+		st = &deviceState{active: make(map[string]activeBreach)}
+		e.devices[device] = st
+	}
+
+	var events []models.AlertEvent
+	for scope, found := range e.check(s) {
+		current := make(map[string]breach, len(found))
+		for _, b := range found {
+			current[b.key()] = b
+		}
+		for _, b := range found {
+			ev := models.AlertEvent{
+				Time: now, Device: device, Rule: b.rule, Subject: b.subject, State: models.AlertFiring,
+				Value: b.value, Threshold: b.threshold, Message: b.message,
+			}
+			if prev, ok := st.active[b.key()]; ok {
+				// Still breached: keep when it started, update the rest.
+				ev.Time = prev.event.Time
+				st.active[b.key()] = activeBreach{scope, ev}
+				continue
+			}
+			st.active[b.key()] = activeBreach{scope, ev}
+			events = append(events, ev)
+		}
+		for key, a := range st.active {
+			if _, ok := current[key]; ok || a.scope != scope {
+				continue
+			}
+			prev := a.event
+			delete(st.active, key)
+			events = append(events, models.AlertEvent{
+				Time: now, Device: device, Rule: prev.Rule, Subject: prev.Subject, State: models.AlertResolved,
+				Threshold: prev.Threshold, Message: "Resolved: " + prev.Message,
+			})
+		}
+	}
+
+	if e.rules.HAChange && s.HA != nil {
+		state := haState(s.HA)
+		if st.haState != "" && state != st.haState {
+			events = append(events, models.AlertEvent{
+				Time: now, Device: device, Rule: RuleHA, State: models.AlertChanged,
+				Message: fmt.Sprintf("HA state changed from %s to %s", st.haState, state),
+			})
+		}
+		st.haState = state
+	}
+
+	sortEvents(events)
+	return events
+}
+
+// Active returns the breaches currently active on every device, oldest
+// first.
+func (e *Engine) Active() []models.AlertEvent {
+	var out []models.AlertEvent
+	for _, st := range e.devices {
+		for _, a := range st.active {
+			out = append(out, a.event)
+		}
+	}
+	sortEvents(out)
+	return out
+}
+
+func sortEvents(events []models.AlertEvent) {
+	slices.SortFunc(events, func(a, b models.AlertEvent) int {
+		return cmp.Or(a.Time.Compare(b.Time), strings.Compare(a.Device, b.Device),
+			strings.Compare(a.Rule, b.Rule), strings.Compare(a.Subject, b.Subject))
+	})
+}
+
+// check returns the breaches found by each check that could be run
+// against s, keyed by the check's scope: a rule, or for CPU a rule and
+// plane, since the two are read separately. A scope with an entry and no
+// breaches has none active.
+func (e *Engine) check(s Snapshot) map[string][]breach {
+	r := e.rules
+	found := make(map[string][]breach)
+
+	if r.CPUPercent > 0 && s.Resources != nil {
+		found[RuleCPU+" management"] = cpuBreach("management", s.Resources.CPUPercent, r.CPUPercent)
+	}
+	if r.CPUPercent > 0 && s.DataPlaneCPU != nil {
+		found[RuleCPU+" dataplane"] = cpuBreach("dataplane", *s.DataPlaneCPU, r.CPUPercent)
+	}
+	if r.SessionPercent > 0 && s.Sessions != nil && s.Sessions.MaxCount > 0 {
+		used := float64(s.Sessions.ActiveCount) / float64(s.Sessions.MaxCount) * 100
+		var bs []breach
+		if used > r.SessionPercent {
+			bs = append(bs, breach{rule: RuleSessions, value: used, threshold: r.SessionPercent,
+				message: fmt.Sprintf("Sessions at %.0f%% of capacity (%d of %d)", used, s.Sessions.ActiveCount, s.Sessions.MaxCount)})
+		}
+		found[RuleSessions] = bs
+	}
+	if r.NATPoolPercent > 0 && s.NATPools != nil {
+		var bs []breach
+		for _, p := range s.NATPools {
+			if p.Percent > r.NATPoolPercent {
+				bs = append(bs, breach{rule: RuleNATPool, subject: p.RuleName, value: p.Percent, threshold: r.NATPoolPercent,
+					message: fmt.Sprintf("NAT pool %s at %.0f%%", p.RuleName, p.Percent)})
+			}
+		}
+		found[RuleNATPool] = bs
+	}
+	if r.CertDays > 0 && s.Certificates != nil {
+		var bs []breach
+		for _, c := range s.Certificates {
+			if c.DaysLeft < r.CertDays {
+				msg := fmt.Sprintf("Certificate %s expires in %d days", c.Name, c.DaysLeft)
+				if c.DaysLeft < 0 {
+					msg = fmt.Sprintf("Certificate %s expired %d days ago", c.Name, -c.DaysLeft)
+				}
+				bs = append(bs, breach{rule: RuleCertificate, subject: c.Name, value: float64(c.DaysLeft),
+					threshold: float64(r.CertDays), message: msg})
+			}
+		}
+		found[RuleCertificate] = bs
+	}
+	if r.TunnelDown && s.Tunnels != nil {
+		var bs []breach
+		for _, t := range s.Tunnels {
+			if !strings.EqualFold(t.State, "up") {
+				bs = append(bs, breach{rule: RuleTunnel, subject: t.Name,
+					message: fmt.Sprintf("IPSec tunnel %s to %s is %s", t.Name, t.Gateway, cmp.Or(t.State, "down"))})
+			}
+		}
+		found[RuleTunnel] = bs
+	}
+	if r.BGPDown && s.BGPPeers != nil {
+		var bs []breach
+		for _, p := range s.BGPPeers {
+			if !strings.EqualFold(p.State, "established") {
+				bs = append(bs, breach{rule: RuleBGP, subject: p.VirtualRouter + " " + p.PeerAddress,
+					message: fmt.Sprintf("BGP peer %s (AS %d) is %s", p.PeerAddress, p.PeerAS, cmp.Or(p.State, "down"))})
+			}
+		}
+		found[RuleBGP] = bs
+	}
+	return found
+}
+
+func cpuBreach(plane string, percent, threshold float64) []breach {
+	if percent <= threshold {
+		return nil
+	}
+	return []breach{{rule: RuleCPU, subject: plane, value: percent, threshold: threshold,
+		message: fmt.Sprintf("%s CPU at %.0f%%", capitalize(plane), percent)}}
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// haState is the local HA state an HA status reports, "disabled" if HA is
+// off.
+func haState(ha *models.HAStatus) string {
+	if !ha.Enabled {
+		return "disabled"
+	}
+	return cmp.Or(strings.ToLower(ha.State), "unknown")
+}
@@ -0,0 +1,201 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/config"
+	"github.com/jp2195/pyre/internal/models"
+	"github.com/jp2195/pyre/internal/testutil"
+)
+
+var t0 = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func defaultRules(t *testing.T) Rules {
+	t.Helper()
+	r, _, _, err := FromSettings(config.DefaultAlertSettings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// summary renders events as "state rule subject" lines for comparison.
+func summary(events []models.AlertEvent) string {
+	var lines []string
+	for _, ev := range events {
+		lines = append(lines, strings.TrimSpace(string(ev.State)+" "+ev.Rule+" "+ev.Subject))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestEngine_FiresOnceAndResolves(t *testing.T) {
+	e := NewEngine(defaultRules(t))
+	hot := Snapshot{
+		Resources: &models.Resources{CPUPercent: 93},
+		Sessions:  &models.SessionInfo{ActiveCount: 950, MaxCount: 1000},
+		Tunnels:   []models.IPSecTunnel{{Name: "to-branch", Gateway: "gw1", State: "down"}, {Name: "to-dc", State: "up"}},
+	}
+
+	got := e.Observe("fw1", hot, t0)
+	if want := "firing cpu management\nfiring sessions\nfiring tunnel to-branch"; summary(got) != want {
+		t.Fatalf("first observation:\n%s\nwant:\n%s", summary(got), want)
+	}
+	if !strings.Contains(got[0].Message, "93%") || got[0].Threshold != 80 {
+		t.Errorf("cpu event = %+v", got[0])
+	}
+
+	// Still breached: nothing new, and the breach keeps its start time.
+	if got := e.Observe("fw1", hot, t0.Add(time.Minute)); len(got) != 0 {
+		t.Errorf("repeat observation reported %s", summary(got))
+	}
+	if active := e.Active(); len(active) != 3 || !active[0].Time.Equal(t0) {
+		t.Errorf("active = %+v", active)
+	}
+
+	cool := Snapshot{
+		Resources: &models.Resources{CPUPercent: 20},
+		Tunnels:   []models.IPSecTunnel{{Name: "to-branch", State: "up"}, {Name: "to-dc", State: "up"}},
+	}
+	got = e.Observe("fw1", cool, t0.Add(2*time.Minute))
+	// Sessions were not read, so that breach stays open.
+	if want := "resolved cpu management\nresolved tunnel to-branch"; summary(got) != want {
+		t.Errorf("after recovery:\n%s\nwant:\n%s", summary(got), want)
+	}
+	if active := e.Active(); len(active) != 1 || active[0].Rule != RuleSessions {
+		t.Errorf("active = %+v, want just sessions", active)
+	}
+}
+
+func TestEngine_PlanesAndDevicesAreSeparate(t *testing.T) {
+	e := NewEngine(defaultRules(t))
+	dp := 95.0
+	e.Observe("fw1", Snapshot{DataPlaneCPU: &dp}, t0)
+	// A read of the management plane alone doesn't resolve the dataplane.
+	if got := e.Observe("fw1", Snapshot{Resources: &models.Resources{CPUPercent: 10}}, t0); len(got) != 0 {
+		t.Errorf("management read reported %s", summary(got))
+	}
+	if got := e.Observe("fw2", Snapshot{DataPlaneCPU: &dp}, t0); summary(got) != "firing cpu dataplane" || got[0].Device != "fw2" {
+		t.Errorf("second device: %+v", got)
+	}
+}
+
+func TestEngine_HAChange(t *testing.T) {
+	e := NewEngine(defaultRules(t))
+	active := Snapshot{HA: &models.HAStatus{Enabled: true, State: "active"}}
+	if got := e.Observe("fw1", active, t0); len(got) != 0 {
+		t.Errorf("first HA state reported %s", summary(got))
+	}
+	got := e.Observe("fw1", Snapshot{HA: &models.HAStatus{Enabled: true, State: "passive"}}, t0)
+	if summary(got) != "changed ha" || got[0].Message != "HA state changed from active to passive" {
+		t.Errorf("failover: %+v", got)
+	}
+	if len(e.Active()) != 0 {
+		t.Errorf("a change should not stay active: %+v", e.Active())
+	}
+}
+
+func TestEngine_ThresholdRules(t *testing.T) {
+	e := NewEngine(defaultRules(t))
+	got := e.Observe("fw1", Snapshot{
+		Certificates: []models.Certificate{{Name: "web", DaysLeft: 12}, {Name: "old", DaysLeft: -3}, {Name: "ok", DaysLeft: 300}},
+		NATPools:     []models.NATPoolInfo{{RuleName: "outbound", Percent: 97}, {RuleName: "quiet", Percent: 5}},
+		BGPPeers: []models.BGPNeighbor{
+			{PeerAddress: "10.0.0.1", PeerAS: 65001, State: "Active", VirtualRouter: "default"},
+			{PeerAddress: "10.0.0.2", State: "Established", VirtualRouter: "default"},
+		},
+	}, t0)
+	want := "firing bgp default 10.0.0.1\nfiring certificate old\nfiring certificate web\nfiring nat_pool outbound"
+	if summary(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", summary(got), want)
+	}
+	for _, ev := range got {
+		if ev.Subject == "old" && ev.Message != "Certificate old expired 3 days ago" {
+			t.Errorf("expired certificate message = %q", ev.Message)
+		}
+	}
+}
+
+func TestFromSettings(t *testing.T) {
+	s := config.DefaultAlertSettings
+	s.CPUPercent, s.TunnelDown, s.IntervalSeconds = 0, false, 1
+	r, interval, _, err := FromSettings(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.CPUPercent != 0 || r.TunnelDown || !r.BGPDown || interval != minInterval {
+		t.Errorf("rules = %+v, interval %s", r, interval)
+	}
+
+	s.Disabled = true
+	if r, _, _, _ := FromSettings(s); r.Any() {
+		t.Errorf("disabled settings gave rules %+v", r)
+	}
+
+	for _, bad := range []config.AlertSettings{{CPUPercent: -1}, {Webhook: "ftp://example.com"}, {Webhook: "localhost:9000"}} {
+		if _, _, _, err := FromSettings(bad); err == nil {
+			t.Errorf("FromSettings(%+v) accepted", bad)
+		}
+	}
+}
+
+func TestCollect(t *testing.T) {
+	mock := testutil.NewMockPANOS()
+	defer mock.Close()
+	client, err := api.NewClient(mock.Host(), "test-api-key", api.ClientOptions{Insecure: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Collect(context.Background(), client, "", defaultRules(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Resources == nil || s.DataPlaneCPU == nil || s.Sessions == nil || s.HA == nil ||
+		s.Tunnels == nil || s.BGPPeers == nil || s.Certificates == nil || s.NATPools == nil {
+		t.Errorf("snapshot is missing reads: %+v", s)
+	}
+
+	// Only what the rules need is read.
+	before := len(client.Calls())
+	s, err = Collect(context.Background(), client, "", Rules{TunnelDown: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(client.Calls()) - before; n != 1 || s.Resources != nil {
+		t.Errorf("tunnel-only collect made %d calls, snapshot %+v", n, s)
+	}
+}
+
+func TestWebhook_Send(t *testing.T) {
+	var got models.AlertEvent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &got); err != nil || got.Rule == "fail" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	ev := models.AlertEvent{Time: t0, Device: "fw1", Rule: RuleTunnel, Subject: "to-branch", State: models.AlertFiring, Message: "down"}
+	if err := (Webhook{URL: srv.URL}).Send(context.Background(), ev); err != nil {
+		t.Fatal(err)
+	}
+	if got.Subject != "to-branch" || got.State != models.AlertFiring || !got.Time.Equal(t0) {
+		t.Errorf("delivered %+v", got)
+	}
+	if err := (Webhook{URL: srv.URL}).Send(context.Background(), models.AlertEvent{Rule: "fail"}); err == nil {
+		t.Error("a 400 should fail the delivery")
+	}
+}
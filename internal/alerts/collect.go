@@ -0,0 +1,89 @@
+package alerts
+
+import (
+	"context"
+
+	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/models"
+)
+
+// Collect reads what r needs from the device target behind c (or c's own
+// device for ""), concurrently. Reads that fail are left out of the
+// snapshot, so their rules keep their state, and are returned joined.
+func Collect(ctx context.Context, c *api.Client, target string, r Rules) (Snapshot, error) {
+	var (
+		s  Snapshot
+		rd api.Reads
+	)
+	if r.CPUPercent > 0 {
+		rd.Go("resources", func() error {
+			res, err := c.GetSystemResources(ctx, target)
+			if err == nil {
+				rd.Set(func() { s.Resources = res })
+			}
+			return err
+		})
+		rd.Go("dataplane", func() error {
+			cpu, err := c.GetDataPlaneResources(ctx, target)
+			if err == nil {
+				rd.Set(func() { s.DataPlaneCPU = &cpu })
+			}
+			return err
+		})
+	}
+	if r.SessionPercent > 0 {
+		rd.Go("sessions", func() error {
+			info, err := c.GetSessionInfo(ctx, target)
+			if err == nil {
+				rd.Set(func() { s.Sessions = info })
+			}
+			return err
+		})
+	}
+	if r.HAChange {
+		rd.Go("ha", func() error {
+			ha, err := c.GetHAStatus(ctx, target)
+			if err == nil {
+				rd.Set(func() { s.HA = ha })
+			}
+			return err
+		})
+	}
+	if r.TunnelDown {
+		rd.Go("ipsec", func() error {
+			tunnels, err := c.GetIPSecTunnels(ctx, target)
+			if err == nil {
+				rd.Set(func() { s.Tunnels = append([]models.IPSecTunnel{}, tunnels...) })
+			}
+			return err
+		})
+	}
+	if r.BGPDown {
+		rd.Go("bgp", func() error {
+			peers, err := c.GetBGPNeighbors(ctx, target)
+			if err == nil {
+				rd.Set(func() { s.BGPPeers = append([]models.BGPNeighbor{}, peers...) })
+			}
+			return err
+		})
+	}
+	if r.CertDays > 0 {
+		rd.Go("certificates", func() error {
+			certs, err := c.GetCertificates(ctx, target)
+			if err == nil {
+				rd.Set(func() { s.Certificates = append([]models.Certificate{}, certs...) })
+			}
+			return err
+		})
+	}
+	if r.NATPoolPercent > 0 {
+		rd.Go("nat pools", func() error {
+			pools, err := c.GetNATPoolInfo(ctx, target)
+			if err == nil {
+				rd.Set(func() { s.NATPools = append([]models.NATPoolInfo{}, pools...) })
+			}
+			return err
+		})
+	}
+	return s, rd.Wait()
+}
@@ -0,0 +1,47 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jp2195/pyre/internal/models"
+)
+
+// webhookTimeout bounds one delivery.
+const webhookTimeout = 10 * time.Second
+
+// Webhook delivers events by POSTing each as a JSON object to a URL.
+type Webhook struct {
+	URL    string
+	Client *http.Client // nil for a client with webhookTimeout
+}
+
+// Send delivers ev. Any 2xx status counts as delivered.
+func (w Webhook) Send(ctx context.Context, ev models.AlertEvent) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := w.Client
+	if client == nil {
+		client = &http.Client{Timeout: webhookTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ErrNotCached is returned by a request made under FromCache when the
+// client holds no response for it.
+var ErrNotCached = errors.New("response not cached")
+
+// Bounds on the response cache. The largest responses, whole rulebases
+// and object lists, run to a few MB on a big firewall; maxCacheBytes keeps
+// several of those. A response is kept for staleFor past the TTL it was
+// cached with, to be served stale under FromCache, and then dropped.
+const (
+	maxCacheBytes = 32 << 20
+	staleFor      = 30 * time.Minute
+)
+
+// responseCache holds the client's successful read responses, keyed by the
+// request's parameters (type, action, xpath or cmd, and target). Nothing
+// is cached unless the caller's context asks for it with WithCache. It
+// holds at most maxCacheBytes of response bodies, evicting the least
+// recently used.
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	size    int    // bytes of response bodies held
+	clock   uint64 // ticks on every get and put, to order entries by use
+	// gen counts invalidations, so a response requested before one is not
+	// stored after it.
+	gen uint64
+}
+
+type cacheEntry struct {
+	resp    XMLResponse
+	fetched time.Time
+	expires time.Time
+	used    uint64 // clock at the last get or put
+}
+
+func (c *responseCache) get(key string) (*XMLResponse, time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || !time.Now().Before(e.expires) {
+		return nil, time.Time{}, false
+	}
+	c.clock++
+	e.used = c.clock
+	c.entries[key] = e
+	resp := e.resp
+	return &resp, e.fetched, true
+}
+
+func (c *responseCache) generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+// put stores resp, cached with ttl, under key, unless the cache was
+// invalidated since gen. It first drops expired entries, then the least recently used until the
+// bodies fit in maxCacheBytes. A response bigger than that is not kept.
+func (c *responseCache) put(gen uint64, key string, resp *XMLResponse, fetched time.Time, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+	if c.entries == nil {
+		c.entries = make(map[string]cacheEntry)
+	}
+	c.remove(key)
+	n := len(resp.Result.Inner)
+	if n > maxCacheBytes {
+		return
+	}
+	now := time.Now()
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			c.remove(k)
+		}
+	}
+	for c.size+n > maxCacheBytes {
+		oldest := ""
+		for k, e := range c.entries {
+			if oldest == "" || e.used < c.entries[oldest].used {
+				oldest = k
+			}
+		}
+		c.remove(oldest)
+	}
+	c.clock++
+	c.entries[key] = cacheEntry{resp: *resp, fetched: fetched, expires: fetched.Add(ttl + staleFor), used: c.clock}
+	c.size += n
+}
+
+// remove drops the entry for key, if there is one. c.mu must be held.
+func (c *responseCache) remove(key string) {
+	if e, ok := c.entries[key]; ok {
+		c.size -= len(e.resp.Result.Inner)
+		delete(c.entries, key)
+	}
+}
+
+func (c *responseCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = nil
+	c.size = 0
+	c.gen++
+}
+
+// InvalidateCache drops every cached response. Call it when what the
+// responses describe has changed: another device was targeted, or a
+// commit went through.
+func (c *Client) InvalidateCache() {
+	c.cache.invalidate()
+}
+
+// CacheInfo records when the cached responses a FromCache request was
+// served were fetched. It is safe for concurrent use.
+type CacheInfo struct {
+	mu      sync.Mutex
+	fetched time.Time
+}
+
+// FetchedAt returns when the oldest response served was fetched, or the
+// zero time if none was.
+func (i *CacheInfo) FetchedAt() time.Time {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.fetched
+}
+
+func (i *CacheInfo) record(t time.Time) {
+	if i == nil {
+		return
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.fetched.IsZero() || t.Before(i.fetched) {
+		i.fetched = t
+	}
+}
+
+type cacheCtxKey struct{}
+
+type cacheMode struct {
+	ttl  time.Duration
+	only bool       // never touch the network
+	info *CacheInfo // FromCache only
+}
+
+// WithCache returns a context whose read requests are answered from the
+// client's cache when the cached response is younger than ttl, and
+// otherwise fetched and cached. A ttl of 0 always fetches, refreshing the
+// cache.
+func WithCache(ctx context.Context, ttl time.Duration) context.Context {
+	return context.WithValue(ctx, cacheCtxKey{}, cacheMode{ttl: ttl})
+}
+
+// FromCache returns a context whose read requests are answered only from
+// the client's cache, however old the response (up to staleFor past its
+// TTL), failing with ErrNotCached for one it does not hold. info, if not nil, records when the responses
+// served were fetched.
+func FromCache(ctx context.Context, info *CacheInfo) context.Context {
+	return context.WithValue(ctx, cacheCtxKey{}, cacheMode{only: true, info: info})
+}
+
+// cacheable reports whether a request's response may be cached: config
+// reads and show commands. Log queries are jobs, answered once.
+func cacheable(params url.Values) bool {
+	return params.Get("type") != "log" && idempotent(params)
+}
+
+// cached runs fetch under the cache mode of ctx, if it has one and the
+// request is cacheable.
+func (c *Client) cached(ctx context.Context, params url.Values, fetch func() (*XMLResponse, error)) (*XMLResponse, error) {
+	mode, ok := ctx.Value(cacheCtxKey{}).(cacheMode)
+	if !ok || !cacheable(params) {
+		if ok && mode.only {
+			return nil, ErrNotCached
+		}
+		return fetch()
+	}
+
+	key := params.Encode()
+	if resp, fetched, hit := c.cache.get(key); hit && (mode.only || time.Since(fetched) < mode.ttl) {
+		debugf("[API Request] served from cache (fetched %s ago)", time.Since(fetched).Round(time.Second))
+		mode.info.record(fetched)
+		return resp, nil
+	}
+	if mode.only {
+		return nil, ErrNotCached
+	}
+
+	gen := c.cache.generation()
+	start := time.Now()
+	resp, err := fetch()
+	if err == nil && resp.IsSuccess() {
+		c.cache.put(gen, key, resp, start, mode.ttl)
+	}
+	return resp, err
+}
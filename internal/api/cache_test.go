@@ -0,0 +1,169 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache_ServesWithinTTL(t *testing.T) {
+	var hits atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		_, _ = w.Write([]byte(`<response status="success"><result><n>1</n></result></response>`))
+	})
+	ctx := context.Background()
+
+	// Without WithCache nothing is cached.
+	if _, err := c.request(ctx, opParams(), ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.request(FromCache(ctx, nil), opParams(), ""); !errors.Is(err, ErrNotCached) {
+		t.Fatalf("FromCache before caching: err = %v, want ErrNotCached", err)
+	}
+
+	cached := WithCache(ctx, time.Minute)
+	for range 3 {
+		if _, err := c.request(cached, opParams(), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("device hit %d times, want 2 (one uncached, one to fill the cache)", n)
+	}
+
+	// A different target is a different entry.
+	if _, err := c.request(cached, opParams(), "012345678901"); err != nil {
+		t.Fatal(err)
+	}
+	if n := hits.Load(); n != 3 {
+		t.Errorf("device hit %d times after a new target, want 3", n)
+	}
+
+	// A ttl of 0 refreshes.
+	if _, err := c.request(WithCache(ctx, 0), opParams(), ""); err != nil {
+		t.Fatal(err)
+	}
+	if n := hits.Load(); n != 4 {
+		t.Errorf("device hit %d times after a refresh, want 4", n)
+	}
+}
+
+func TestCache_FromCacheReportsAge(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<response status="success"><result/></response>`))
+	})
+	before := time.Now()
+	if _, err := c.request(WithCache(context.Background(), time.Minute), opParams(), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	var info CacheInfo
+	if _, err := c.request(FromCache(context.Background(), &info), opParams(), ""); err != nil {
+		t.Fatalf("FromCache: %v", err)
+	}
+	if at := info.FetchedAt(); at.Before(before) || at.After(time.Now()) {
+		t.Errorf("FetchedAt = %v, want the time of the first request", at)
+	}
+
+	c.InvalidateCache()
+	if _, err := c.request(FromCache(context.Background(), nil), opParams(), ""); !errors.Is(err, ErrNotCached) {
+		t.Errorf("after InvalidateCache: err = %v, want ErrNotCached", err)
+	}
+}
+
+func TestCache_OnlySuccessfulReads(t *testing.T) {
+	var hits atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.Query().Get("xpath") == "/bad" {
+			_, _ = w.Write([]byte(`<response status="error" code="7"><msg><line>no such node</line></msg></response>`))
+			return
+		}
+		_, _ = w.Write([]byte(`<response status="success"><result/></response>`))
+	})
+	ctx := WithCache(context.Background(), time.Minute)
+
+	requests := []url.Values{
+		{"type": {"config"}, "action": {"get"}, "xpath": {"/bad"}},
+		{"type": {"op"}, "cmd": {"<request><restart><system/></restart></request>"}},
+	}
+	for _, params := range requests {
+		for range 2 {
+			_, _ = c.request(ctx, params, "")
+		}
+	}
+	if n := hits.Load(); n != 4 {
+		t.Errorf("device hit %d times, want 4: errors and non-show commands are never cached", n)
+	}
+}
+
+func TestCache_InvalidateDropsInFlight(t *testing.T) {
+	arrived, release := make(chan struct{}), make(chan struct{})
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		close(arrived)
+		<-release
+		_, _ = w.Write([]byte(`<response status="success"><result/></response>`))
+	})
+
+	done := make(chan error)
+	go func() {
+		_, err := c.request(WithCache(context.Background(), time.Minute), opParams(), "")
+		done <- err
+	}()
+	<-arrived
+	c.InvalidateCache()
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.request(FromCache(context.Background(), nil), opParams(), ""); !errors.Is(err, ErrNotCached) {
+		t.Errorf("a response requested before InvalidateCache was cached: err = %v", err)
+	}
+}
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	var c responseCache
+	body := func(n int) *XMLResponse {
+		r := &XMLResponse{Status: "success"}
+		r.Result.Inner = make([]byte, n)
+		return r
+	}
+	now := time.Now()
+	third := maxCacheBytes / 3
+	c.put(0, "a", body(third), now, time.Minute)
+	c.put(0, "b", body(third), now, time.Minute)
+	c.put(0, "c", body(third), now, time.Minute)
+	c.get("a") // b is now the least recently used
+	c.put(0, "d", body(third), now, time.Minute)
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true, "d": true} {
+		if _, _, ok := c.get(key); ok != want {
+			t.Errorf("%s cached = %v, want %v", key, ok, want)
+		}
+	}
+	if c.size > maxCacheBytes {
+		t.Errorf("size = %d, over the %d cap", c.size, maxCacheBytes)
+	}
+
+	c.put(0, "huge", body(maxCacheBytes+1), now, time.Minute)
+	if _, _, ok := c.get("huge"); ok {
+		t.Error("a response bigger than the cap was cached")
+	}
+}
+
+func TestCache_SweepsExpiredOnPut(t *testing.T) {
+	var c responseCache
+	c.put(0, "old", &XMLResponse{Status: "success"}, time.Now().Add(-time.Minute-staleFor), time.Minute)
+	if _, _, ok := c.get("old"); ok {
+		t.Error("an expired response was served")
+	}
+	c.put(0, "new", &XMLResponse{Status: "success"}, time.Now(), time.Minute)
+	if _, ok := c.entries["old"]; ok || len(c.entries) != 1 {
+		t.Errorf("entries = %v, want the expired one swept", c.entries)
+	}
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jp2195/pyre/internal/models"
+)
+
+const (
+	// maxCalls is how many recent calls a client keeps for the inspector.
+	maxCalls = 200
+	// maxCallBody caps the response kept per call: a preview, so the log
+	// stays under a MB however large the responses.
+	maxCallBody = 4 * 1024
+)
+
+// secretElements matches the text of config elements that hold secrets:
+// password hashes, pre-shared keys, SNMP and RADIUS secrets. secretFields
+// matches the same in REST API JSON. The cut variants match a secret that
+// a truncated preview ends inside.
+var (
+	secretElements = regexp.MustCompile(
+		`(<(?:phash|password|key|secret|pre-shared-key|auth-password|priv-password|passphrase)(?:\s[^>]*)?>)[^<]+(</)`)
+	secretFields = regexp.MustCompile(
+		`("(?:phash|password|key|secret|pre-shared-key|auth-password|priv-password|passphrase)"\s*:\s*")(?:[^"\\]|\\.)*(")`)
+	cutSecretElement = regexp.MustCompile(
+		`(<(?:phash|password|key|secret|pre-shared-key|auth-password|priv-password|passphrase)(?:\s[^>]*)?>)[^<]+$`)
+	cutSecretField = regexp.MustCompile(
+		`("(?:phash|password|key|secret|pre-shared-key|auth-password|priv-password|passphrase)"\s*:\s*")(?:[^"\\]|\\.)*\\?$`)
+)
+
+// maskSecrets masks the secrets in a response body, XML or JSON.
+func maskSecrets(text string) string {
+	text = secretElements.ReplaceAllString(text, "${1}"+redactedValue+"${2}")
+	return secretFields.ReplaceAllString(text, "${1}"+redactedValue+"${2}")
+}
+
+// callLog keeps a client's most recent calls, oldest overwritten first.
+type callLog struct {
+	mu    sync.Mutex
+	calls []models.APICall
+	next  int // where the next call goes once calls is full
+}
+
+func (l *callLog) add(call models.APICall) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.calls) < maxCalls {
+		l.calls = append(l.calls, call)
+		return
+	}
+	l.calls[l.next] = call
+	l.next = (l.next + 1) % maxCalls
+}
+
+// Calls returns the client's most recent requests to the device, newest
+// first. Answers served from the response cache are not included.
+func (c *Client) Calls() []models.APICall {
+	l := &c.calls
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]models.APICall, 0, len(l.calls))
+	for i := range l.calls {
+		// Walk back from the newest entry, just before next.
+		out = append(out, l.calls[(l.next-1-i+2*len(l.calls))%len(l.calls)])
+	}
+	return out
+}
+
+// newCall starts the record of a request with params, sent after waiting
+// wait for the client's request limits.
+func newCall(params url.Values, target string, wait time.Duration) models.APICall {
+	path := params.Get("xpath")
+	switch params.Get("type") {
+	case "op", "commit":
+		path = strings.TrimSpace(params.Get("cmd"))
+	case "rest":
+		q := url.Values{"location": params["location"], "vsys": params["vsys"]}
+		path = params.Get("path") + "?" + q.Encode()
+	case "log":
+		if job := params.Get("job-id"); job != "" {
+			path = "job " + job
+		} else {
+			path = params.Get("log-type")
+		}
+	}
+	return models.APICall{
+		Time:   time.Now(),
+		Type:   params.Get("type"),
+		Action: params.Get("action"),
+		Path:   path,
+		Target: target,
+		Wait:   wait,
+	}
+}
+
+// finishCall completes call with the response do received (body, which
+// may be nil) and its outcome. The API key is masked wherever the device
+// echoed it.
+func finishCall(call models.APICall, body []byte, resp *XMLResponse, err error, apiKey string) models.APICall {
+	call.Size = len(body)
+	if len(body) > maxCallBody {
+		body = body[:maxCallBody]
+		call.Truncated = true
+	}
+	text := SanitizeForDisplay(string(body))
+	if apiKey != "" {
+		text = strings.ReplaceAll(text, apiKey, redactedValue)
+	}
+	text = maskSecrets(text)
+	if call.Truncated {
+		text = cutSecretElement.ReplaceAllString(text, "${1}"+redactedValue)
+		text = cutSecretField.ReplaceAllString(text, "${1}"+redactedValue)
+	}
+	call.Body = text
+
+	var status *HTTPStatusError
+	switch {
+	case errors.As(err, &status):
+		call.Status = fmt.Sprintf("HTTP %d", status.StatusCode)
+	case errors.Is(err, ErrUnauthorized):
+		call.Status = "unauthorized"
+	case err != nil:
+		call.Status = "failed"
+	case resp.IsSuccess():
+		call.Status = resp.Status
+	default:
+		call.Status = strings.TrimSpace(resp.Status + " " + resp.Code)
+	}
+	if err != nil {
+		call.Err = SanitizeForDisplay(err.Error())
+	} else if !resp.IsSuccess() {
+		call.Err = resp.Error()
+	}
+	return call
+}
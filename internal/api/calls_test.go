@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCalls_NewestFirstAndCapped(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<response status="success"><result/></response>`))
+	})
+	ctx := context.Background()
+	for i := range maxCalls + 5 {
+		params := url.Values{"type": {"config"}, "action": {"get"}, "xpath": {"/config/n" + strings.Repeat("x", i%3)}}
+		if i == maxCalls+4 {
+			params = url.Values{"type": {"op"}, "cmd": {"<show><clock/></show>"}}
+		}
+		if _, err := c.request(ctx, params, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	calls := c.Calls()
+	if len(calls) != maxCalls {
+		t.Fatalf("kept %d calls, want %d", len(calls), maxCalls)
+	}
+	if got := calls[0]; got.Type != "op" || got.Path != "<show><clock/></show>" || got.Status != "success" {
+		t.Errorf("newest call = %+v, want the op command", got)
+	}
+	if got := calls[1]; got.Type != "config" || got.Action != "get" || !strings.HasPrefix(got.Path, "/config/n") {
+		t.Errorf("second call = %+v, want a config get", got)
+	}
+	for i := 1; i < len(calls); i++ {
+		if calls[i].Time.After(calls[i-1].Time) {
+			t.Fatalf("calls[%d] is newer than calls[%d]", i, i-1)
+		}
+	}
+}
+
+func TestCalls_MasksSecrets(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<response status="success"><result><entry name="admin">` +
+			`<phash>$5$abc$hash</phash><key>K</key><pre-shared-key>hunter2</pre-shared-key>` +
+			`</entry></result></response>`))
+	})
+	if _, err := c.request(context.Background(), opParams(), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	call := c.Calls()[0]
+	for _, secret := range []string{"$5$abc$hash", "hunter2", "<key>K</key>"} {
+		if strings.Contains(call.Body, secret) {
+			t.Errorf("body leaks %q:\n%s", secret, call.Body)
+		}
+	}
+	if !strings.Contains(call.Body, `<entry name="admin">`) {
+		t.Errorf("body lost non-secret content:\n%s", call.Body)
+	}
+	if call.Size == 0 || call.Truncated {
+		t.Errorf("Size = %d, Truncated = %v; want the full response size", call.Size, call.Truncated)
+	}
+}
+
+func TestCalls_RecordsFailures(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("xpath") == "/bad" {
+			_, _ = w.Write([]byte(`<response status="error" code="7"><msg><line>no such node</line></msg></response>`))
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	ctx := context.Background()
+	_, _ = c.request(ctx, url.Values{"type": {"config"}, "action": {"get"}, "xpath": {"/bad"}}, "")
+	_, _ = c.request(ctx, url.Values{"type": {"log"}, "action": {"get"}, "job-id": {"12"}}, "")
+
+	calls := c.Calls()
+	if len(calls) == 0 {
+		t.Fatal("no calls recorded")
+	}
+	if got := calls[0]; got.Status != "HTTP 503" || got.Path != "job 12" || got.Err == "" {
+		t.Errorf("503 call = %+v, want status HTTP 503 for job 12 with an error", got)
+	}
+	if got := calls[len(calls)-1]; got.Status != "error 7" || !strings.Contains(got.Err, "no such node") {
+		t.Errorf("error call = %+v, want the device's error", got)
+	}
+}
+
+func TestCalls_TruncatesToPreview(t *testing.T) {
+	padding := strings.Repeat("<entry/>", maxCallBody/8)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<response status="success"><result>` + padding[:(maxCallBody-60)/8*8] +
+			`<pre-shared-key>hunter2-and-then-some-more-of-the-secret</pre-shared-key></result></response>`))
+	})
+	if _, err := c.request(context.Background(), opParams(), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	call := c.Calls()[0]
+	if !call.Truncated || call.Size <= maxCallBody || len(call.Body) > maxCallBody+len(redactedValue) {
+		t.Errorf("Size = %d, Truncated = %v, kept %d bytes; want a %d-byte preview", call.Size, call.Truncated, len(call.Body), maxCallBody)
+	}
+	if strings.Contains(call.Body, "hunter2") {
+		t.Errorf("preview leaks the secret it was cut inside:\n...%s", call.Body[len(call.Body)-80:])
+	}
+}
@@ -0,0 +1,227 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cassettes capture Client.request traffic as JSON Lines, one Interaction
+// per line, so a misbehaving PAN-OS build can be reproduced offline. The API
+// key never reaches the file: it travels in the X-PAN-KEY header (not
+// recorded), credential-looking query parameters are dropped, and any echo
+// of the key in a response body is masked. So are the secrets a config
+// holds (see secretElements), as cassettes are meant for bug reports.
+
+// Interaction is one recorded request/response pair.
+type Interaction struct {
+	Host  string `json:"host"`
+	Query string `json:"query"`
+	// Path is set for REST API requests, whose path names the resource;
+	// XML API requests all go to /api/.
+	Path       string `json:"path,omitempty"`
+	Status     int    `json:"status"`
+	Body       string `json:"body"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// redactedParams are query parameters never written to a cassette.
+var redactedParams = []string{"key", "password"}
+
+const redactedValue = "REDACTED"
+
+// cassetteQuery canonicalises a request URL's query for recording and
+// matching: credentials removed, keys sorted (url.Values.Encode sorts).
+func cassetteQuery(u *url.URL) string {
+	q := u.Query()
+	for _, p := range redactedParams {
+		q.Del(p)
+	}
+	return q.Encode()
+}
+
+// cassettePath returns the path recorded for a request URL: empty for the
+// XML API.
+func cassettePath(u *url.URL) string {
+	if strings.HasPrefix(u.Path, "/api") {
+		return ""
+	}
+	return u.Path
+}
+
+// key is what a replayed request is matched on.
+func (in Interaction) key() string {
+	return in.Path + "?" + in.Query
+}
+
+// cassetteWriter serialises appends to one cassette file. Every client
+// recording to the same path in this process shares one writer, so
+// concurrent fetches (and several connections) interleave whole lines.
+type cassetteWriter struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+var (
+	cassetteWritersMu sync.Mutex
+	cassetteWriters   = map[string]*cassetteWriter{}
+)
+
+// openCassetteWriter returns the process-wide writer for path, truncating
+// the file on first use so a recording starts clean.
+func openCassetteWriter(path string) (*cassetteWriter, error) {
+	cassetteWritersMu.Lock()
+	defer cassetteWritersMu.Unlock()
+	if w, ok := cassetteWriters[path]; ok {
+		return w, nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC|os.O_APPEND, 0o600) // #nosec G304 -- path is a user-chosen recording target
+	if err != nil {
+		return nil, fmt.Errorf("opening cassette %q: %w", path, err)
+	}
+	w := &cassetteWriter{f: f}
+	cassetteWriters[path] = w
+	return w, nil
+}
+
+func (w *cassetteWriter) append(in Interaction) error {
+	line, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err = w.f.Write(append(line, '\n'))
+	return err
+}
+
+// recordingTransport passes requests through to next and appends each
+// exchange to a cassette.
+type recordingTransport struct {
+	next http.RoundTripper
+	w    *cassetteWriter
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	// Buffer the body so it can be both recorded and handed back. The
+	// Client's own size cap still applies when it reads the copy.
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	_ = resp.Body.Close() //nolint:errcheck // replaced below
+	if err != nil {
+		return nil, fmt.Errorf("reading response for cassette: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	recorded := string(body)
+	if key := req.Header.Get("X-PAN-KEY"); key != "" {
+		recorded = strings.ReplaceAll(recorded, key, redactedValue)
+	}
+	recorded = maskSecrets(recorded)
+	if err := t.w.append(Interaction{
+		Host:       req.URL.Host,
+		Query:      cassetteQuery(req.URL),
+		Path:       cassettePath(req.URL),
+		Status:     resp.StatusCode,
+		Body:       recorded,
+		DurationMS: time.Since(start).Milliseconds(),
+	}); err != nil {
+		debugf("[API Warning] cassette write failed: %v", err)
+	}
+	return resp, nil
+}
+
+func (t *recordingTransport) CloseIdleConnections() {
+	if c, ok := t.next.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+// replayTransport answers requests from a cassette without touching the
+// network. Requests match on their canonical query (and path, for REST); repeated identical
+// requests are served the recorded responses in order, and the last one is
+// reused once they run out (dashboards refresh the same calls forever).
+type replayTransport struct {
+	mu      sync.Mutex
+	byQuery map[string][]Interaction
+	served  map[string]int
+}
+
+// LoadCassette reads a cassette file written by a recording client.
+func LoadCassette(path string) ([]Interaction, error) {
+	f, err := os.Open(path) // #nosec G304 -- path is a user-chosen cassette
+	if err != nil {
+		return nil, fmt.Errorf("opening cassette %q: %w", path, err)
+	}
+	defer func() { _ = f.Close() }() //nolint:errcheck // read-only
+
+	var out []Interaction
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), maxResponseSize+1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var in Interaction
+		if err := json.Unmarshal(line, &in); err != nil {
+			return nil, fmt.Errorf("cassette %q line %d: %w", path, n, err)
+		}
+		out = append(out, in)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading cassette %q: %w", path, err)
+	}
+	return out, nil
+}
+
+func newReplayTransport(interactions []Interaction) *replayTransport {
+	t := &replayTransport{
+		byQuery: make(map[string][]Interaction),
+		served:  make(map[string]int),
+	}
+	for _, in := range interactions {
+		t.byQuery[in.key()] = append(t.byQuery[in.key()], in)
+	}
+	return t
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	q := Interaction{Query: cassetteQuery(req.URL), Path: cassettePath(req.URL)}.key()
+
+	t.mu.Lock()
+	recorded := t.byQuery[q]
+	i := t.served[q]
+	if i < len(recorded) {
+		t.served[q] = i + 1
+	}
+	t.mu.Unlock()
+
+	if len(recorded) == 0 {
+		return nil, fmt.Errorf("replay: no recorded response for %s", truncateLog(q, 200))
+	}
+	in := recorded[min(i, len(recorded)-1)]
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", in.Status, http.StatusText(in.Status)),
+		StatusCode:    in.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/xml"}},
+		Body:          io.NopCloser(strings.NewReader(in.Body)),
+		ContentLength: int64(len(in.Body)),
+		Request:       req,
+	}, nil
+}
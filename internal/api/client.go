@@ -5,6 +5,7 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -12,7 +13,10 @@ import (
 	"net/url"
 	"os"
 	"strconv"
+	"sync"
 	"time"
+
+	"github.com/jp2195/pyre/internal/models"
 )
 
 // maxResponseSize is the maximum allowed response body size (50 MB).
@@ -35,16 +39,29 @@ func debugf(format string, args ...any) {
 }
 
 // Client represents a PAN-OS API client.
-// Fields are ordered for optimal memory alignment on 64-bit systems.
 //
 // The client is stateless with respect to Panorama target routing: each
 // request method accepts an explicit target serial argument rather than
 // consulting shared mutable state. This eliminates cross-goroutine bleed
 // when multiple fetches run concurrently with different targets.
+//
+// The API key can change under a live client: when the device rejects it,
+// requests wait for a re-login (see reauth.go) and retry with the new key.
 type Client struct {
-	baseURL    string       // 16 bytes (string header)
-	apiKey     string       // 16 bytes (string header)
-	httpClient *http.Client // 8 bytes (pointer)
+	baseURL    string
+	httpClient *http.Client
+	vsys       string     // the virtual system policies and objects are read from
+	mu         sync.Mutex // guards the fields below
+	apiKey     string
+	reauth     ReauthFunc    // nil disables re-login
+	reauthWait chan struct{} // closed when the pending re-login ends
+	abandoned  string        // a key whose re-login was given up
+	limiter    *limiter      // enforces policy
+	policy     RequestPolicy // concurrency, rate, and retry limits
+	cache      responseCache // responses kept for WithCache callers
+	calls      callLog       // recent requests, for the inspector
+	rest       restState     // whether policies and objects are read over REST
+	allowWrite bool
 }
 
 // ClientOptions carries optional knobs for NewClient. Zero value is safe:
@@ -59,25 +76,82 @@ type ClientOptions struct {
 	// certificates, NewClient returns an error rather than silently
 	// falling back to system roots.
 	CACertPath string
+	// ClientCertPath and ClientKeyPath are a PEM certificate and private
+	// key presented to the device for mutual TLS. Both or neither must be
+	// set.
+	ClientCertPath string
+	ClientKeyPath  string
+	// KnownHosts, when set, pins the device's certificate: chain
+	// verification is replaced by a fingerprint check against the store,
+	// and an unrecorded or changed certificate fails the handshake with
+	// *UnknownHostError or *HostKeyChangedError. Insecure takes precedence;
+	// CACertPath is ignored.
+	KnownHosts *KnownHosts
+	// KnownHost is the name the device is recorded under in KnownHosts.
+	// NewClient defaults it to the host it connects to.
+	KnownHost string
+	// Proxy is the URL of an http, https, or socks5 proxy to reach the
+	// device through, credentials in the userinfo. If empty, the standard
+	// proxy environment variables apply.
+	Proxy string
+	// RecordPath, when set, appends every request/response exchange to a
+	// cassette file at this path (see cassette.go). Defaults to $PYRE_RECORD.
+	RecordPath string
+	// ReplayPath, when set, serves responses from a previously recorded
+	// cassette instead of the network. Defaults to $PYRE_REPLAY.
+	ReplayPath string
+	// Vsys is the virtual system whose policies and objects the client
+	// reads, as "vsys2". Defaults to DefaultVsys.
+	Vsys string
+	// AllowWrite permits type=commit requests. Without it the client is
+	// read-only and Commit returns ErrReadOnly without touching the device.
+	AllowWrite bool
+	// Policy limits concurrency and request rate and sets the retries for
+	// idempotent requests (see RequestPolicy). The zero value has no limits.
+	Policy RequestPolicy
 }
 
 // NewTransport builds an *http.Transport with a hardened TLS config
-// (MinVersion = TLS 1.2) applied from opts. It returns an error if
-// opts.CACertPath is set but the CA bundle cannot be loaded, so
-// configuration mistakes surface at connect time rather than as opaque TLS
-// handshake failures on the first request.
+// (MinVersion = TLS 1.2) and proxy applied from opts. It returns an error
+// if opts.CACertPath or the client certificate is set but cannot be
+// loaded, or opts.Proxy is not a usable proxy URL, so configuration
+// mistakes surface at connect time rather than as opaque TLS handshake
+// failures on the first request.
 //
 // It is exported so the keygen flow in internal/auth shares the exact same
-// TLS construction (and fail-closed CA handling) as the main API client.
+// TLS and proxy construction (and fail-closed CA handling) as the main API
+// client.
 // Each call returns a transport owned by its caller.
 func NewTransport(opts ClientOptions) (*http.Transport, error) {
 	tlsCfg := &tls.Config{
 		MinVersion: tls.VersionTLS12,
 	}
-	if opts.Insecure {
+	proxy, err := proxyFunc(opts.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	if opts.ClientCertPath != "" || opts.ClientKeyPath != "" {
+		if opts.ClientCertPath == "" || opts.ClientKeyPath == "" {
+			return nil, errors.New("client certificate and key must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(opts.ClientCertPath, opts.ClientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate %q: %w", opts.ClientCertPath, err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	switch {
+	case opts.Insecure:
 		// #nosec G402 -- InsecureSkipVerify required for self-signed firewall certificates when user opts in
 		tlsCfg.InsecureSkipVerify = true //nolint:gosec
-	} else if opts.CACertPath != "" {
+	case opts.KnownHosts != nil:
+		// The pin replaces chain verification: lab gear's self-signed
+		// certificate is trusted because it is the one recorded, not
+		// because anyone signed it.
+		// #nosec G402 -- VerifyConnection checks the pinned fingerprint
+		tlsCfg.InsecureSkipVerify = true //nolint:gosec
+		tlsCfg.VerifyConnection = pinVerifier(opts.KnownHosts, opts.KnownHost)
+	case opts.CACertPath != "":
 		pem, err := os.ReadFile(opts.CACertPath) // #nosec G304 -- path comes from user config
 		if err != nil {
 			return nil, fmt.Errorf("reading CA bundle %q: %w", opts.CACertPath, err)
@@ -89,6 +163,7 @@ func NewTransport(opts ClientOptions) (*http.Transport, error) {
 		tlsCfg.RootCAs = pool
 	}
 	return &http.Transport{
+		Proxy:                 proxy,
 		TLSClientConfig:       tlsCfg,
 		ForceAttemptHTTP2:     true,
 		MaxIdleConns:          10,
@@ -105,18 +180,60 @@ func NewTransport(opts ClientOptions) (*http.Transport, error) {
 // An error is returned when opts.CACertPath is set but the CA bundle cannot
 // be loaded (unreadable file or PEM contains zero certificates). When
 // CACertPath is empty, NewClient uses system roots and never fails.
+//
+// Record and replay paths left empty fall back to $PYRE_RECORD and
+// $PYRE_REPLAY; setting both is an error.
 func NewClient(host, apiKey string, opts ClientOptions) (*Client, error) {
-	tr, err := NewTransport(opts)
-	if err != nil {
-		return nil, err
+	if opts.KnownHost == "" {
+		opts.KnownHost = host
+	}
+	if opts.RecordPath == "" && opts.ReplayPath == "" {
+		opts.RecordPath = os.Getenv("PYRE_RECORD")
+		opts.ReplayPath = os.Getenv("PYRE_REPLAY")
+	}
+	if opts.RecordPath != "" && opts.ReplayPath != "" {
+		return nil, fmt.Errorf("cannot record and replay a cassette at the same time")
+	}
+	if opts.Vsys == "" {
+		opts.Vsys = DefaultVsys
+	}
+	if !vsysName.MatchString(opts.Vsys) {
+		return nil, fmt.Errorf("invalid vsys %q: want a name such as vsys1", opts.Vsys)
+	}
+
+	var rt http.RoundTripper
+	if opts.ReplayPath != "" {
+		interactions, err := LoadCassette(opts.ReplayPath)
+		if err != nil {
+			return nil, err
+		}
+		rt = newReplayTransport(interactions)
+	} else {
+		tr, err := NewTransport(opts)
+		if err != nil {
+			return nil, err
+		}
+		rt = tr
+		if opts.RecordPath != "" {
+			w, err := openCassetteWriter(opts.RecordPath)
+			if err != nil {
+				return nil, err
+			}
+			rt = &recordingTransport{next: tr, w: w}
+		}
 	}
+
 	return &Client{
 		baseURL: fmt.Sprintf("https://%s/api/", host),
 		apiKey:  apiKey,
 		httpClient: &http.Client{
-			Transport: tr,
+			Transport: rt,
 			Timeout:   30 * time.Second,
 		},
+		limiter:    newLimiter(opts.Policy),
+		policy:     opts.Policy,
+		vsys:       opts.Vsys,
+		allowWrite: opts.AllowWrite,
 	}, nil
 }
 
@@ -126,7 +243,7 @@ func (c *Client) Close() error {
 	if c.httpClient == nil {
 		return nil
 	}
-	if tr, ok := c.httpClient.Transport.(*http.Transport); ok {
+	if tr, ok := c.httpClient.Transport.(interface{ CloseIdleConnections() }); ok {
 		tr.CloseIdleConnections()
 	}
 	return nil
@@ -158,19 +275,51 @@ func (r *XMLResponse) Error() string {
 // device serial the call should be routed to; pass "" for standalone
 // firewalls and Panorama-local queries. Target is per-request to avoid the
 // races that come with client-scoped mutable state.
+//
+// A call the device rejects for its API key waits for a re-login and is
+// retried once with the new key; see reauthenticate. Under WithCache or
+// FromCache, a read may be answered from the client's cache instead.
 func (c *Client) request(ctx context.Context, params url.Values, target string) (*XMLResponse, error) {
-	start := time.Now()
-
 	// Inject target parameter for Panorama routing
 	if target != "" {
 		params.Set("target", target)
 	}
 
+	return c.cached(ctx, params, func() (*XMLResponse, error) {
+		key := c.key()
+		resp, err := c.send(ctx, params, key, target)
+		if !errors.Is(err, ErrUnauthorized) {
+			return resp, err
+		}
+		newKey, reauthErr := c.reauthenticate(ctx, key)
+		if reauthErr != nil {
+			return nil, err
+		}
+		debugf("[API Request] retrying with the new API key")
+		return c.send(ctx, params, newKey, target)
+	})
+}
+
+// do sends one request with apiKey. A rejected API key, whether reported
+// by HTTP status or by the response's error code, is returned as an error
+// wrapping ErrUnauthorized. The exchange is completed in call and added to
+// the client's recent calls.
+func (c *Client) do(ctx context.Context, params url.Values, apiKey, target string, call *models.APICall) (xmlResp *XMLResponse, err error) {
+	start := time.Now()
+	var body []byte
+	defer func() {
+		if call.Duration == 0 {
+			call.Duration = time.Since(start)
+		}
+		c.calls.add(finishCall(*call, body, xmlResp, err, apiKey))
+	}()
+
 	// Log request (sanitized - no API key). Gated behind PYRE_DEBUG.
-	debugf("[API Request] type=%s action=%s xpath=%s target=%s",
+	debugf("[API Request] type=%s action=%s xpath=%s path=%s target=%s",
 		params.Get("type"),
 		params.Get("action"),
 		params.Get("xpath"),
+		params.Get("path"),
 		target,
 	)
 	if cmd := params.Get("cmd"); cmd != "" {
@@ -178,6 +327,9 @@ func (c *Client) request(ctx context.Context, params url.Values, target string)
 	}
 
 	reqURL := c.baseURL + "?" + params.Encode()
+	if params.Get("type") == "rest" {
+		reqURL = c.restURL(params)
+	}
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
 		log.Printf("[API Error] creating request: %v", err)
@@ -186,32 +338,54 @@ func (c *Client) request(ctx context.Context, params url.Values, target string)
 
 	// Use X-PAN-KEY header instead of query parameter (PAN-OS 8.0+)
 	// This prevents API key from appearing in server/proxy logs
-	req.Header.Set("X-PAN-KEY", c.apiKey) // NOT logged
+	req.Header.Set("X-PAN-KEY", apiKey) // NOT logged
 
 	resp, err := c.httpClient.Do(req)
 	duration := time.Since(start)
 
 	if err != nil {
 		log.Printf("[API Error] request failed after %dms: %v", duration.Milliseconds(), err)
-		return nil, fmt.Errorf("executing request: %w", err)
+		return nil, &transportError{fmt.Errorf("executing request: %w", err)}
 	}
 	defer func() { _ = resp.Body.Close() }() //nolint:errcheck // best effort cleanup
 
 	// Read one byte past the cap so an at-limit response is distinguishable
 	// from an over-limit one, and report the latter explicitly instead of
 	// letting truncated XML surface as a confusing parse error.
-	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	body, err = io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	call.Duration = time.Since(start)
 	if err != nil {
 		log.Printf("[API Error] reading response: %v", err)
-		return nil, fmt.Errorf("reading response: %w", err)
+		return nil, &transportError{fmt.Errorf("reading response: %w", err)}
 	}
 	if len(body) > maxResponseSize {
 		log.Printf("[API Error] response exceeded %d byte limit", maxResponseSize)
 		return nil, fmt.Errorf("response exceeds %dMB limit", maxResponseSize/(1024*1024))
 	}
 
-	var xmlResp XMLResponse
-	if err := decodeXML(bytes.NewReader(body), &xmlResp); err != nil {
+	if params.Get("type") == "rest" {
+		xmlResp, err = decodeREST(resp.StatusCode, body)
+		if err != nil {
+			log.Printf("[API Error] REST response (HTTP %d) after %dms: %v", resp.StatusCode, duration.Milliseconds(), err)
+			return nil, err
+		}
+		debugf("[API Response] status=%s code=%s duration=%dms size=%d bytes",
+			xmlResp.Status, xmlResp.Code, duration.Milliseconds(), len(body))
+		return xmlResp, nil
+	}
+
+	xmlResp = &XMLResponse{}
+	decodeErr := decodeXML(bytes.NewReader(body), xmlResp)
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden || xmlResp.Code == codeUnauthorized {
+		log.Printf("[API Error] API key rejected (HTTP %d, code %s)", resp.StatusCode, xmlResp.Code)
+		return nil, &APIError{Status: "error", Code: codeUnauthorized, Message: authMessage(xmlResp)}
+	}
+	// An overloaded management plane answers with an error page, not XML.
+	if (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500) && (decodeErr != nil || xmlResp.Status == "") {
+		log.Printf("[API Error] HTTP %d after %dms", resp.StatusCode, duration.Milliseconds())
+		return nil, &HTTPStatusError{StatusCode: resp.StatusCode, RetryAfter: retryAfter(resp.Header.Get("Retry-After"))}
+	}
+	if err := decodeErr; err != nil {
 		log.Printf("[API Error] parsing XML after %dms: %v", duration.Milliseconds(), err)
 		log.Printf("[API Error] body preview: %s", truncateLog(string(body), 500))
 		return nil, fmt.Errorf("parsing response: %w", err)
@@ -231,7 +405,7 @@ func (c *Client) request(ctx context.Context, params url.Values, target string)
 		debugf("[API Response] body preview: %s", truncateLog(string(xmlResp.Result.Inner), 1000))
 	}
 
-	return &xmlResp, nil
+	return xmlResp, nil
 }
 
 // truncateLog truncates a string to maxLen characters, appending a truncation indicator if needed.
@@ -302,9 +476,20 @@ func (e *APIError) Error() string {
 	if e.Message != "" {
 		return e.Message
 	}
+	if e.Code == codeUnauthorized {
+		return ErrUnauthorized.Error()
+	}
 	return fmt.Sprintf("API error: status=%s code=%s", e.Status, e.Code)
 }
 
+// Unwrap makes errors.Is(err, ErrUnauthorized) hold for a rejected API key.
+func (e *APIError) Unwrap() error {
+	if e.Code == codeUnauthorized {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
 func CheckResponse(resp *XMLResponse) error {
 	if resp.IsSuccess() {
 		return nil
@@ -198,3 +198,44 @@ func TestGetInterfaces(t *testing.T) {
 		t.Errorf("expected interface 4 state down, got %s", downIface.State)
 	}
 }
+
+func TestGetDefaultSecurityRules(t *testing.T) {
+	mock := testutil.NewMockPANOS()
+	defer mock.Close()
+
+	client, _ := api.NewClient(mock.Host(), "test-api-key", api.ClientOptions{Insecure: true})
+
+	rules, err := client.GetDefaultSecurityRules(context.Background(), "")
+	if err != nil {
+		t.Fatalf("GetDefaultSecurityRules failed: %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("expected 2 default rules, got %d", len(rules))
+	}
+	intra, inter := rules[0], rules[1]
+	if intra.Name != "intrazone-default" || intra.Action != "allow" || intra.LogEnd {
+		t.Errorf("intrazone-default should keep its built-in behavior: %+v", intra)
+	}
+	if inter.Name != "interzone-default" || inter.Action != "deny" || !inter.LogEnd {
+		t.Errorf("interzone-default should have its override applied: %+v", inter)
+	}
+}
+
+func TestGetAppsSeen(t *testing.T) {
+	mock := testutil.NewMockPanorama()
+	defer mock.Close()
+
+	client, _ := api.NewClient(mock.Host(), "test-api-key", api.ClientOptions{Insecure: true})
+
+	seen, err := client.GetAppsSeen(context.Background(), []string{"legacy-erp", "users-to-hq"}, 30, "007200001001")
+	if err != nil {
+		t.Fatalf("GetAppsSeen failed: %v", err)
+	}
+	if len(seen) != 1 {
+		t.Fatalf("expected apps for 1 rule, got %v", seen)
+	}
+	apps := seen["legacy-erp"]
+	if len(apps) != 3 || apps[0].Name != "ssl" || apps[0].Bytes == 0 || apps[0].LastSeen.IsZero() {
+		t.Errorf("expected 3 apps, most traffic first, got %+v", apps)
+	}
+}
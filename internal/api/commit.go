@@ -0,0 +1,115 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// ErrReadOnly is returned by Commit when the client was built without
+// ClientOptions.AllowWrite.
+var ErrReadOnly = errors.New("connection is read-only (set allow_write: true to enable commits)")
+
+// ErrNoChanges is returned by StartCommit when the device reports that the
+// candidate config has nothing to commit.
+var ErrNoChanges = errors.New("there are no changes to commit")
+
+// partialScope limits a commit or validate to the changes made by admins.
+type partialScope struct {
+	Admins []string `xml:"admin>member"`
+}
+
+type commitCmd struct {
+	XMLName     xml.Name      `xml:"commit"`
+	Description string        `xml:"description,omitempty"`
+	Partial     *partialScope `xml:"partial,omitempty"`
+}
+
+type validateCmd struct {
+	XMLName xml.Name      `xml:"validate"`
+	Full    *struct{}     `xml:"full,omitempty"`
+	Partial *partialScope `xml:"partial,omitempty"`
+}
+
+// scope returns the partial-commit scope for admins, or nil for a full
+// commit.
+func scope(admins []string) *partialScope {
+	if len(admins) == 0 {
+		return nil
+	}
+	return &partialScope{Admins: admins}
+}
+
+// Commit issues a type=commit request with the given XML cmd. It fails with
+// ErrReadOnly unless the client allows writes.
+func (c *Client) Commit(ctx context.Context, cmd, target string) (*XMLResponse, error) {
+	if !c.allowWrite {
+		return nil, ErrReadOnly
+	}
+	params := url.Values{}
+	params.Set("type", "commit")
+	params.Set("cmd", cmd)
+	return c.request(ctx, params, target)
+}
+
+// StartCommit enqueues a commit of the candidate config and returns the
+// job ID to poll with GetJob. A non-empty admins list makes it a partial
+// commit of only those administrators' changes.
+func (c *Client) StartCommit(ctx context.Context, description string, admins []string, target string) (int, error) {
+	cmd, err := xml.Marshal(commitCmd{Description: description, Partial: scope(admins)})
+	if err != nil {
+		return 0, fmt.Errorf("building commit command: %w", err)
+	}
+	resp, err := c.Commit(ctx, string(cmd), target)
+	if err != nil {
+		return 0, err
+	}
+	if err := CheckResponse(resp); err != nil {
+		return 0, err
+	}
+	id, ok := enqueuedJob(resp)
+	if !ok {
+		return 0, ErrNoChanges
+	}
+	return id, nil
+}
+
+// StartValidate enqueues a validation of the candidate config (or, with
+// admins, of just their changes) and returns the job ID to poll. Validation
+// doesn't change the device, but it runs the same checks a commit would.
+func (c *Client) StartValidate(ctx context.Context, admins []string, target string) (int, error) {
+	v := validateCmd{Partial: scope(admins)}
+	if v.Partial == nil {
+		v.Full = &struct{}{}
+	}
+	cmd, err := xml.Marshal(v)
+	if err != nil {
+		return 0, fmt.Errorf("building validate command: %w", err)
+	}
+	resp, err := c.Op(ctx, string(cmd), target)
+	if err != nil {
+		return 0, err
+	}
+	if err := CheckResponse(resp); err != nil {
+		return 0, err
+	}
+	id, ok := enqueuedJob(resp)
+	if !ok {
+		return 0, fmt.Errorf("validate did not return a job")
+	}
+	return id, nil
+}
+
+// enqueuedJob extracts the job ID from a "job enqueued" response.
+func enqueuedJob(resp *XMLResponse) (int, bool) {
+	var result struct {
+		Job int `xml:"job"`
+	}
+	if err := decodeXML(bytes.NewReader(WrapInner(resp.Result.Inner)), &result); err != nil || result.Job == 0 {
+		return 0, false
+	}
+	return result.Job, true
+}
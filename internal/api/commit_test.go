@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jp2195/pyre/internal/models"
+	"github.com/jp2195/pyre/internal/testutil"
+)
+
+func TestCommit_ReadOnlyClientNeverSends(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("read-only client sent %s", r.URL.Query().Get("type"))
+	})
+	if _, err := c.StartCommit(context.Background(), "", nil, ""); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("StartCommit err = %v, want ErrReadOnly", err)
+	}
+}
+
+func TestStartCommit_PartialCommand(t *testing.T) {
+	var got string
+	c := newWriteClient(t, func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query().Get("cmd")
+		fmt.Fprint(w, `<response status="success" code="19"><result><msg><line>Commit job enqueued with jobid 7</line></msg><job>7</job></result></response>`)
+	})
+
+	id, err := c.StartCommit(context.Background(), "fix <db>", []string{"netops"}, "")
+	if err != nil || id != 7 {
+		t.Fatalf("StartCommit = %d, %v; want 7", id, err)
+	}
+	want := `<commit><description>fix &lt;db&gt;</description><partial><admin><member>netops</member></admin></partial></commit>`
+	if got != want {
+		t.Errorf("cmd =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestStartCommit_NoChanges(t *testing.T) {
+	c := newWriteClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<response status="success" code="19"><msg>There are no changes to commit.</msg></response>`)
+	})
+	if _, err := c.StartCommit(context.Background(), "", nil, ""); !errors.Is(err, ErrNoChanges) {
+		t.Fatalf("err = %v, want ErrNoChanges", err)
+	}
+}
+
+func TestCommit_PartialAgainstMock(t *testing.T) {
+	mock := testutil.NewMockPANOS()
+	defer mock.Close()
+	c, err := NewClient(mock.Host(), "k", ClientOptions{Insecure: true, AllowWrite: true})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	ctx := context.Background()
+
+	id, err := c.StartValidate(ctx, nil, "")
+	if err != nil {
+		t.Fatalf("StartValidate: %v", err)
+	}
+	job := waitJob(t, c, id)
+	if job.Result != "OK" || job.Type != "Validate" || len(job.Warnings) == 0 {
+		t.Errorf("validate job = %+v, want OK with warnings", job)
+	}
+
+	id, err = c.StartCommit(ctx, "", []string{"netops"}, "")
+	if err != nil {
+		t.Fatalf("StartCommit: %v", err)
+	}
+	if job := waitJob(t, c, id); job.Result != "OK" {
+		t.Fatalf("commit job = %+v", job)
+	}
+
+	hunks, err := c.GetConfigDiff(ctx, "")
+	if err != nil {
+		t.Fatalf("GetConfigDiff: %v", err)
+	}
+	if len(hunks) != 1 || hunks[0].Admins[0] != "admin" {
+		t.Errorf("after netops' partial commit, hunks = %+v; want only admin's change", hunks)
+	}
+
+	if _, err := c.StartCommit(ctx, "", []string{"netops"}, ""); !errors.Is(err, ErrNoChanges) {
+		t.Errorf("second partial commit err = %v, want ErrNoChanges", err)
+	}
+}
+
+func newWriteClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	c := newTestClient(t, handler)
+	c.allowWrite = true
+	return c
+}
+
+// waitJob polls a job until it finishes. The mock finishes jobs within a
+// few polls.
+func waitJob(t *testing.T, c *Client, id int) models.Job {
+	t.Helper()
+	for range 10 {
+		job, err := c.GetJob(context.Background(), id, "")
+		if err != nil {
+			t.Fatalf("GetJob(%d): %v", id, err)
+		}
+		if job.Status == "FIN" {
+			return job
+		}
+	}
+	t.Fatalf("job %d did not finish", id)
+	return models.Job{}
+}
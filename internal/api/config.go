@@ -3,9 +3,15 @@ package api
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/jp2195/pyre/internal/models"
+	"github.com/jp2195/pyre/internal/pancfg"
 )
 
 // GetPendingChanges retrieves pending configuration changes
@@ -273,3 +279,141 @@ func (c *Client) GetNATPoolInfo(ctx context.Context, target string) ([]models.NA
 
 	return pools, nil
 }
+
+// GetConfigDiff compares the running and candidate configurations — the
+// changes a commit would apply — and returns one hunk per changed object.
+// The diff is the device's own `show config diff`, whose hunks carry only
+// as much of their XPath as the diff's context shows (see
+// pancfg.ParseUnifiedDiff). PAN-OS versions without the command get a diff
+// computed client-side from both documents instead. Each hunk lists the
+// admins whose journal entries (see GetPendingChanges) touch it; the
+// journal is best-effort and leaves Admins empty when unavailable.
+func (c *Client) GetConfigDiff(ctx context.Context, target string) ([]models.ConfigHunk, error) {
+	var (
+		hunks   []models.ConfigHunk
+		diffErr error
+		changes []models.PendingChange
+	)
+	var wg sync.WaitGroup
+	wg.Go(func() { hunks, diffErr = c.showConfigDiff(ctx, target) })
+	wg.Go(func() {
+		var err error
+		if changes, err = c.GetPendingChanges(ctx, target); err != nil {
+			log.Printf("[API Warning] failed to fetch config journal: %v", err)
+		}
+	})
+	wg.Wait()
+	var apiErr *APIError
+	if errors.As(diffErr, &apiErr) {
+		log.Printf("[API Warning] show config diff unavailable, comparing configs instead: %v", diffErr)
+		hunks, diffErr = c.compareConfigs(ctx, target)
+	}
+	if diffErr != nil {
+		return nil, diffErr
+	}
+
+	for i := range hunks {
+		for _, ch := range changes {
+			if ch.User != "" && pancfg.Overlaps(ch.Location, hunks[i].XPath) && !slices.Contains(hunks[i].Admins, ch.User) {
+				hunks[i].Admins = append(hunks[i].Admins, ch.User)
+			}
+		}
+	}
+	sanitizeAllStrings(&hunks)
+	return hunks, nil
+}
+
+// showConfigDiff reads the device's diff of running and candidate.
+func (c *Client) showConfigDiff(ctx context.Context, target string) ([]models.ConfigHunk, error) {
+	resp, err := c.Op(ctx, "<show><config><diff></diff></config></show>", target)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckResponse(resp); err != nil {
+		return nil, err
+	}
+	return pancfg.ParseUnifiedDiff(InnerText(resp.Result.Inner)), nil
+}
+
+// compareConfigs diffs the running and candidate configs client-side.
+func (c *Client) compareConfigs(ctx context.Context, target string) ([]models.ConfigHunk, error) {
+	var (
+		running, candidate *pancfg.Node
+		runErr, candErr    error
+	)
+	var wg sync.WaitGroup
+	wg.Go(func() { running, runErr = c.getConfigTree(ctx, "running", target) })
+	wg.Go(func() { candidate, candErr = c.getConfigTree(ctx, "candidate", target) })
+	wg.Wait()
+	if runErr != nil {
+		return nil, runErr
+	}
+	if candErr != nil {
+		return nil, candErr
+	}
+	return pancfg.Diff(running, candidate), nil
+}
+
+// getConfigTree fetches a whole configuration ("running" or "candidate").
+func (c *Client) getConfigTree(ctx context.Context, which, target string) (*pancfg.Node, error) {
+	resp, err := c.Op(ctx, fmt.Sprintf("<show><config><%s></%s></config></show>", which, which), target)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckResponse(resp); err != nil {
+		return nil, err
+	}
+	root, err := pancfg.Parse(string(resp.Result.Inner))
+	if err != nil {
+		return nil, fmt.Errorf("%s config: %w", which, err)
+	}
+	return root, nil
+}
+
+// GetRunningConfig returns the running configuration as the device sends
+// it, a <config> document, for saving as a backup. It is checked to parse
+// so a truncated or error response is never stored.
+func (c *Client) GetRunningConfig(ctx context.Context, target string) ([]byte, error) {
+	resp, err := c.Op(ctx, "<show><config><running></running></config></show>", target)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckResponse(resp); err != nil {
+		return nil, err
+	}
+	doc := bytes.TrimSpace(resp.Result.Inner)
+	if _, err := pancfg.Parse(string(doc)); err != nil {
+		return nil, fmt.Errorf("running config: %w", err)
+	}
+	return append(doc, '\n'), nil
+}
+
+// GetConfigElement fetches the single config element at xpath, with its
+// subtree: from the candidate config (action=get) or the running one
+// (action=show). An xpath that matches nothing is an error, as is one that
+// matches several elements; address entries by name.
+func (c *Client) GetConfigElement(ctx context.Context, xpath string, candidate bool, target string) (*pancfg.Node, error) {
+	fetch := c.Show
+	if candidate {
+		fetch = c.Get
+	}
+	resp, err := fetch(ctx, xpath, target)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckResponse(resp); err != nil {
+		return nil, err
+	}
+	result, err := pancfg.ParseElement("<result>" + string(resp.Result.Inner) + "</result>")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", xpath, err)
+	}
+	switch len(result.Children) {
+	case 0:
+		return nil, fmt.Errorf("no config at %s", xpath)
+	case 1:
+		return result.Children[0], nil
+	default:
+		return nil, fmt.Errorf("%s matches %d elements", xpath, len(result.Children))
+	}
+}
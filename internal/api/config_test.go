@@ -0,0 +1,155 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jp2195/pyre/internal/pancfg"
+	"github.com/jp2195/pyre/internal/testutil"
+)
+
+func TestGetConfigDiff_AttributesAdmins(t *testing.T) {
+	mock := testutil.NewMockPANOS()
+	defer mock.Close()
+
+	c, err := NewClient(mock.Host(), "k", ClientOptions{Insecure: true})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	hunks, err := c.GetConfigDiff(context.Background(), "")
+	if err != nil {
+		t.Fatalf("GetConfigDiff: %v", err)
+	}
+
+	want := []struct{ kind, suffix, admin string }{
+		{"modified", "/address/entry[@name='db-primary']", "admin"},
+		{"added", "/rules/entry[@name='allow-partner-ssh']", "netops"},
+		{"removed", "/rules/entry[@name='deprecated-rule']", "netops"},
+	}
+	if len(hunks) != len(want) {
+		t.Fatalf("got %d hunks, want %d", len(hunks), len(want))
+	}
+	for i, w := range want {
+		h := hunks[i]
+		if h.Kind != w.kind || !strings.HasSuffix(h.XPath, w.suffix) {
+			t.Errorf("hunk %d = %s %s, want %s ...%s", i, h.Kind, h.XPath, w.kind, w.suffix)
+		}
+		if !slices.Equal(h.Admins, []string{w.admin}) {
+			t.Errorf("hunk %d admins = %v, want [%s]", i, h.Admins, w.admin)
+		}
+	}
+}
+
+func TestGetConfigDiff_DeviceDiff(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		cmd := r.URL.Query().Get("cmd")
+		switch {
+		case strings.Contains(cmd, "<diff>"):
+			fmt.Fprint(w, `<response status="success"><result><![CDATA[@@ -10,3 +10,3 @@
+   <address>
+     <entry name="db-primary">
+-      <ip-netmask>10.0.0.5</ip-netmask>
++      <ip-netmask>10.0.0.6</ip-netmask>
+]]></result></response>`)
+		case strings.Contains(cmd, "<changes>"):
+			fmt.Fprint(w, `<response status="success"><result><journal><entry><admin>admin</admin>`+
+				`<xpath>/config/shared/address/entry[@name='db-primary']</xpath></entry></journal></result></response>`)
+		default:
+			t.Errorf("unexpected command %s: the device diff needs no configs", cmd)
+			fmt.Fprint(w, `<response status="error"><msg><line>unexpected</line></msg></response>`)
+		}
+	})
+	hunks, err := c.GetConfigDiff(context.Background(), "")
+	if err != nil {
+		t.Fatalf("GetConfigDiff: %v", err)
+	}
+	if len(hunks) != 1 || hunks[0].Kind != "modified" || hunks[0].XPath != pancfg.TailPrefix+"address/entry[@name='db-primary']" {
+		t.Fatalf("hunks = %+v", hunks)
+	}
+	if !slices.Equal(hunks[0].Admins, []string{"admin"}) {
+		t.Errorf("admins = %v, want [admin]", hunks[0].Admins)
+	}
+}
+
+func TestGetConfigDiff_CandidateError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		if cmd := r.URL.Query().Get("cmd"); strings.Contains(cmd, "<candidate>") || strings.Contains(cmd, "<diff>") {
+			fmt.Fprint(w, `<response status="error" code="403"><msg><line>access denied</line></msg></response>`)
+			return
+		}
+		fmt.Fprint(w, `<response status="success"><result><config/></result></response>`)
+	})
+	if _, err := c.GetConfigDiff(context.Background(), ""); err == nil {
+		t.Fatal("expected an error when the candidate config is unavailable")
+	}
+}
+
+func TestGetRunningConfig(t *testing.T) {
+	mock := testutil.NewMockPANOS()
+	defer mock.Close()
+
+	c, err := NewClient(mock.Host(), "k", ClientOptions{Insecure: true})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	doc, err := c.GetRunningConfig(context.Background(), "")
+	if err != nil {
+		t.Fatalf("GetRunningConfig: %v", err)
+	}
+	if !bytes.HasPrefix(doc, []byte("<config")) || !strings.Contains(string(doc), "deprecated-rule") {
+		t.Errorf("running config = %.80q...", doc)
+	}
+}
+
+func TestGetRunningConfig_RejectsGarbage(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<response status="success"><result>not a config</result></response>`)
+	})
+	if _, err := c.GetRunningConfig(context.Background(), ""); err == nil {
+		t.Error("expected an unparseable config to be rejected")
+	}
+}
+
+func TestGetConfigElement(t *testing.T) {
+	mock := testutil.NewMockPANOS()
+	defer mock.Close()
+
+	c, err := NewClient(mock.Host(), "k", ClientOptions{Insecure: true})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	ctx := context.Background()
+	xpath := "/config/devices/entry[@name='localhost.localdomain']/vsys/entry[@name='vsys1']/rulebase/security/rules"
+
+	running, err := c.GetConfigElement(ctx, xpath, false, "")
+	if err != nil {
+		t.Fatalf("GetConfigElement(running): %v", err)
+	}
+	candidate, err := c.GetConfigElement(ctx, xpath, true, "")
+	if err != nil {
+		t.Fatalf("GetConfigElement(candidate): %v", err)
+	}
+	has := func(n *pancfg.Node, name string) bool {
+		return slices.ContainsFunc(n.Children, func(c *pancfg.Node) bool { return c.Name() == name })
+	}
+	if running.XMLName.Local != "rules" || !has(running, "deprecated-rule") || has(running, "allow-partner-ssh") {
+		t.Errorf("running rules = %s", running.Marshal())
+	}
+	if !has(candidate, "allow-partner-ssh") || has(candidate, "deprecated-rule") {
+		t.Errorf("candidate rules = %s", candidate.Marshal())
+	}
+
+	if _, err := c.GetConfigElement(ctx, "/config/no-such-thing", false, ""); err == nil {
+		t.Error("expected an error for an xpath that matches nothing")
+	}
+	if _, err := c.GetConfigElement(ctx, "/config/devices/entry/vsys/entry/rulebase/security/rules/entry", false, ""); err == nil {
+		t.Error("expected an error for an xpath that matches several elements")
+	}
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newPinnedServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<response status="success"><result/></response>`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, strings.TrimPrefix(srv.URL, "https://")
+}
+
+func TestKnownHosts_TrustOnFirstUse(t *testing.T) {
+	srv, host := newPinnedServer(t)
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	known, err := LoadKnownHosts(path)
+	if err != nil {
+		t.Fatalf("LoadKnownHosts: %v", err)
+	}
+
+	c, err := NewClient(host, "K", ClientOptions{KnownHosts: known})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer func() { _ = c.Close() }()
+
+	_, err = c.request(context.Background(), opParams(), "")
+	var unknown *UnknownHostError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("first connect: err = %v, want *UnknownHostError", err)
+	}
+	if want := Fingerprint(srv.Certificate()); unknown.Fingerprint != want || unknown.Host != host {
+		t.Fatalf("UnknownHostError = %+v, want host %s fingerprint %s", unknown, host, want)
+	}
+
+	if err := known.Trust(unknown.Host, unknown.Fingerprint); err != nil {
+		t.Fatalf("Trust: %v", err)
+	}
+	if _, err := c.request(context.Background(), opParams(), ""); err != nil {
+		t.Fatalf("after trusting: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("known_hosts mode = %#o, want 0600", info.Mode().Perm())
+	}
+	reloaded, err := LoadKnownHosts(path)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if fp, ok := reloaded.Lookup(host); !ok || fp != unknown.Fingerprint {
+		t.Errorf("reloaded Lookup = %q, %v", fp, ok)
+	}
+}
+
+func TestKnownHosts_ChangedCertificateRefused(t *testing.T) {
+	_, host := newPinnedServer(t)
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	stale := "SHA256:" + strings.Repeat("00:", 31) + "00"
+	if err := os.WriteFile(path, []byte("# pinned\n"+host+" "+stale+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	known, err := LoadKnownHosts(path)
+	if err != nil {
+		t.Fatalf("LoadKnownHosts: %v", err)
+	}
+
+	err = CheckHostKey(context.Background(), host, ClientOptions{KnownHosts: known})
+	var changed *HostKeyChangedError
+	if !errors.As(err, &changed) {
+		t.Fatalf("err = %v, want *HostKeyChangedError", err)
+	}
+	if msg := changed.Error(); changed.Known != stale || !strings.HasPrefix(msg, "WARNING") || !strings.Contains(msg, path) {
+		t.Errorf("error = %q", msg)
+	}
+}
+
+func TestKnownHosts_InsecureWinsOverPin(t *testing.T) {
+	_, host := newPinnedServer(t)
+	known, err := LoadKnownHosts(filepath.Join(t.TempDir(), "known_hosts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckHostKey(context.Background(), host, ClientOptions{Insecure: true, KnownHosts: known}); err != nil {
+		t.Fatalf("insecure connection checked the pin: %v", err)
+	}
+}
+
+func TestKnownHostKey(t *testing.T) {
+	tests := map[string]string{
+		"FW1.example":   "fw1.example",
+		"fw1:443":       "fw1",
+		"fw1:8443":      "fw1:8443",
+		"[2001:db8::1]": "[2001:db8::1]",
+		"[::1]:443":     "[::1]",
+	}
+	for in, want := range tests {
+		if got := knownHostKey(in); got != want {
+			t.Errorf("knownHostKey(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNewTransport_ClientCertificate(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<response status="success"><result/></response>`))
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert, MinVersion: tls.VersionTLS12}
+	srv.StartTLS()
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "https://")
+
+	// The test server's own certificate doubles as the client's.
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "client.crt")
+	keyPath := filepath.Join(dir, "client.key")
+	leaf := srv.TLS.Certificates[0]
+	keyDER, err := x509.MarshalPKCS8PrivateKey(leaf.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf.Certificate[0]}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	without, err := NewClient(host, "K", ClientOptions{Insecure: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = without.Close() }()
+	if _, err := without.request(context.Background(), opParams(), ""); err == nil {
+		t.Fatal("request without a client certificate succeeded")
+	}
+
+	with, err := NewClient(host, "K", ClientOptions{Insecure: true, ClientCertPath: certPath, ClientKeyPath: keyPath})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer func() { _ = with.Close() }()
+	if _, err := with.request(context.Background(), opParams(), ""); err != nil {
+		t.Fatalf("request with a client certificate: %v", err)
+	}
+}
+
+func TestNewTransport_ClientCertificateErrors(t *testing.T) {
+	if _, err := NewTransport(ClientOptions{ClientCertPath: "client.crt"}); err == nil {
+		t.Error("certificate without a key: expected an error")
+	}
+	if _, err := NewTransport(ClientOptions{ClientCertPath: "/nonexistent.crt", ClientKeyPath: "/nonexistent.key"}); err == nil {
+		t.Error("unreadable certificate: expected an error")
+	}
+}
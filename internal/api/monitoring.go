@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"cmp"
 	"context"
+	"fmt"
 	"log"
 	"slices"
 	"strconv"
@@ -106,17 +107,18 @@ func (c *Client) GetGlobalProtectInfo(ctx context.Context, target string) (*mode
 
 // jobEntry is the shared XML structure for job entries across different PAN-OS response formats.
 type jobEntry struct {
-	ID        int    `xml:"id"`
-	Type      string `xml:"type"`
-	Status    string `xml:"status"`
-	Result    string `xml:"result"`
-	Progress  string `xml:"progress"`
-	Details   string `xml:"details>line"`
-	TEnq      string `xml:"tenq"` // Time enqueued
-	TDeq      string `xml:"tdeq"` // Time dequeued (started)
-	Tfin      string `xml:"tfin"` // Time finished
-	User      string `xml:"user"`
-	Stoppable string `xml:"stoppable"`
+	ID        int      `xml:"id"`
+	Type      string   `xml:"type"`
+	Status    string   `xml:"status"`
+	Result    string   `xml:"result"`
+	Progress  string   `xml:"progress"`
+	Details   []string `xml:"details>line"`
+	Warnings  []string `xml:"warnings>line"`
+	TEnq      string   `xml:"tenq"` // Time enqueued
+	TDeq      string   `xml:"tdeq"` // Time dequeued (started)
+	Tfin      string   `xml:"tfin"` // Time finished
+	User      string   `xml:"user"`
+	Stoppable string   `xml:"stoppable"`
 }
 
 // parseJobTimestamp tries multiple time layouts to parse a PAN-OS job timestamp.
@@ -131,7 +133,36 @@ func parseJobTimestamp(s string) time.Time {
 }
 
 func (c *Client) GetJobs(ctx context.Context, target string) ([]models.Job, error) {
-	resp, err := c.Op(ctx, "<show><jobs><all></all></jobs></show>", target)
+	jobs, err := c.showJobs(ctx, "<show><jobs><all></all></jobs></show>", target)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sort by ID descending (most recent first).
+	slices.SortFunc(jobs, func(a, b models.Job) int {
+		return cmp.Compare(b.ID, a.ID)
+	})
+	return jobs, nil
+}
+
+// GetJob retrieves a single job by ID. Commit and validate callers poll it
+// until Status is FIN.
+func (c *Client) GetJob(ctx context.Context, id int, target string) (models.Job, error) {
+	jobs, err := c.showJobs(ctx, fmt.Sprintf("<show><jobs><id>%d</id></jobs></show>", id), target)
+	if err != nil {
+		return models.Job{}, err
+	}
+	for _, j := range jobs {
+		if j.ID == id {
+			return j, nil
+		}
+	}
+	return models.Job{}, fmt.Errorf("job %d not found", id)
+}
+
+// showJobs runs a show jobs variant and parses whatever jobs it returns.
+func (c *Client) showJobs(ctx context.Context, cmd, target string) ([]models.Job, error) {
+	resp, err := c.Op(ctx, cmd, target)
 	if err != nil {
 		return nil, err
 	}
@@ -166,12 +197,16 @@ func (c *Client) GetJobs(ctx context.Context, target string) ([]models.Job, erro
 	jobs := make([]models.Job, 0, len(entries))
 	for _, e := range entries {
 		job := models.Job{
-			ID:      e.ID,
-			Type:    e.Type,
-			Status:  e.Status,
-			Result:  e.Result,
-			Message: e.Details,
-			User:    e.User,
+			ID:       e.ID,
+			Type:     e.Type,
+			Status:   e.Status,
+			Result:   e.Result,
+			User:     e.User,
+			Details:  e.Details,
+			Warnings: e.Warnings,
+		}
+		if len(e.Details) > 0 {
+			job.Message = e.Details[0]
 		}
 
 		if e.Progress != "" {
@@ -184,11 +219,6 @@ func (c *Client) GetJobs(ctx context.Context, target string) ([]models.Job, erro
 		jobs = append(jobs, job)
 	}
 
-	// Sort by ID descending (most recent first).
-	slices.SortFunc(jobs, func(a, b models.Job) int {
-		return cmp.Compare(b.ID, a.ID)
-	})
-
 	sanitizeAllStrings(&jobs)
 	return jobs, nil
 }
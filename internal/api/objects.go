@@ -4,21 +4,23 @@ import (
 	"bytes"
 	"context"
 	"log"
+	"strings"
 
 	"github.com/jp2195/pyre/internal/models"
 )
 
-// addressEntry mirrors the PAN-OS XML <entry> shape under /address.
+// addressEntry mirrors the PAN-OS XML <entry> shape under /address, and
+// the REST API's JSON for it.
 type addressEntry struct {
-	Name        string `xml:"name,attr"`
-	IPNetmask   string `xml:"ip-netmask"`
-	IPRange     string `xml:"ip-range"`
-	FQDN        string `xml:"fqdn"`
-	IPWildcard  string `xml:"ip-wildcard"`
-	Description string `xml:"description"`
+	Name        string `xml:"name,attr" json:"@name"`
+	IPNetmask   string `xml:"ip-netmask" json:"ip-netmask"`
+	IPRange     string `xml:"ip-range" json:"ip-range"`
+	FQDN        string `xml:"fqdn" json:"fqdn"`
+	IPWildcard  string `xml:"ip-wildcard" json:"ip-wildcard"`
+	Description string `xml:"description" json:"description"`
 	Tag         struct {
-		Member []string `xml:"member"`
-	} `xml:"tag"`
+		Member []string `xml:"member" json:"member"`
+	} `xml:"tag" json:"tag"`
 }
 
 func parseAddressEntries(inner []byte) []addressEntry {
@@ -73,6 +75,18 @@ func fetchObjectsFromPath[T any](
 	return entries, nil
 }
 
+// fetchObjects fetches the entries of a REST resource at location ("vsys"
+// or "shared"), or of the matching xpath over the XML API when the client
+// doesn't read target over REST or the REST request fails.
+func fetchObjects[T any](
+	c *Client, ctx context.Context, resource, location, xpath, target string, parse func([]byte) []T,
+) ([]T, error) {
+	if entries, ok := viaREST[T](c, ctx, target, resource, location); ok {
+		return entries, nil
+	}
+	return fetchObjectsFromPath(c, ctx, xpath, target, parse)
+}
+
 func convertAddressEntry(e addressEntry) (models.AddressObject, bool) {
 	var typ, value string
 	switch {
@@ -97,18 +111,17 @@ func convertAddressEntry(e addressEntry) (models.AddressObject, bool) {
 	}, true
 }
 
-// GetAddresses fetches address objects from vsys1 and shared, concatenated.
+// GetAddresses fetches address objects from the client's vsys and shared,
+// concatenated.
 func (c *Client) GetAddresses(ctx context.Context, target string) ([]models.AddressObject, error) {
-	const (
-		vsysXPath   = "/config/devices/entry[@name='localhost.localdomain']/vsys/entry[@name='vsys1']/address"
-		sharedXPath = "/config/shared/address"
-	)
+	const sharedXPath = "/config/shared/address"
+	vsysXPath := VsysXPath(c.vsys) + "/address"
 
-	vsysEntries, err := fetchObjectsFromPath(c, ctx, vsysXPath, target, parseAddressEntries)
+	vsysEntries, err := fetchObjects(c, ctx, "Objects/Addresses", "vsys", vsysXPath, target, parseAddressEntries)
 	if err != nil {
 		return nil, err
 	}
-	sharedEntries, err := fetchObjectsFromPath(c, ctx, sharedXPath, target, parseAddressEntries)
+	sharedEntries, err := fetchObjects(c, ctx, "Objects/Addresses", "shared", sharedXPath, target, parseAddressEntries)
 	if err != nil {
 		return nil, err
 	}
@@ -127,23 +140,24 @@ func (c *Client) GetAddresses(ctx context.Context, target string) ([]models.Addr
 	return out, nil
 }
 
-// serviceEntry mirrors the PAN-OS XML <entry> shape under /service.
+// serviceEntry mirrors the PAN-OS XML <entry> shape under /service, and
+// the REST API's JSON for it.
 type serviceEntry struct {
-	Name     string `xml:"name,attr"`
+	Name     string `xml:"name,attr" json:"@name"`
 	Protocol struct {
 		TCP struct {
-			Port       string `xml:"port"`
-			SourcePort string `xml:"source-port"`
-		} `xml:"tcp"`
+			Port       string `xml:"port" json:"port"`
+			SourcePort string `xml:"source-port" json:"source-port"`
+		} `xml:"tcp" json:"tcp"`
 		UDP struct {
-			Port       string `xml:"port"`
-			SourcePort string `xml:"source-port"`
-		} `xml:"udp"`
-	} `xml:"protocol"`
-	Description string `xml:"description"`
+			Port       string `xml:"port" json:"port"`
+			SourcePort string `xml:"source-port" json:"source-port"`
+		} `xml:"udp" json:"udp"`
+	} `xml:"protocol" json:"protocol"`
+	Description string `xml:"description" json:"description"`
 	Tag         struct {
-		Member []string `xml:"member"`
-	} `xml:"tag"`
+		Member []string `xml:"member" json:"member"`
+	} `xml:"tag" json:"tag"`
 }
 
 func parseServiceEntries(inner []byte) []serviceEntry {
@@ -183,18 +197,17 @@ func convertServiceEntry(e serviceEntry) (models.ServiceObject, bool) {
 	}, true
 }
 
-// GetServices fetches service objects from vsys1 and shared, concatenated.
+// GetServices fetches service objects from the client's vsys and shared,
+// concatenated.
 func (c *Client) GetServices(ctx context.Context, target string) ([]models.ServiceObject, error) {
-	const (
-		vsysXPath   = "/config/devices/entry[@name='localhost.localdomain']/vsys/entry[@name='vsys1']/service"
-		sharedXPath = "/config/shared/service"
-	)
+	const sharedXPath = "/config/shared/service"
+	vsysXPath := VsysXPath(c.vsys) + "/service"
 
-	vsysEntries, err := fetchObjectsFromPath(c, ctx, vsysXPath, target, parseServiceEntries)
+	vsysEntries, err := fetchObjects(c, ctx, "Objects/Services", "vsys", vsysXPath, target, parseServiceEntries)
 	if err != nil {
 		return nil, err
 	}
-	sharedEntries, err := fetchObjectsFromPath(c, ctx, sharedXPath, target, parseServiceEntries)
+	sharedEntries, err := fetchObjects(c, ctx, "Objects/Services", "shared", sharedXPath, target, parseServiceEntries)
 	if err != nil {
 		return nil, err
 	}
@@ -212,3 +225,166 @@ func (c *Client) GetServices(ctx context.Context, target string) ([]models.Servi
 	}
 	return out, nil
 }
+
+// parseEntries decodes the <entry> elements of a config response, whether
+// they sit inside the element the xpath ends at or directly in <result>.
+func parseEntries[T any](inner []byte) []T {
+	var result struct {
+		Entry   []T `xml:"entry"`
+		Wrapper struct {
+			Entry []T `xml:"entry"`
+		} `xml:",any"`
+	}
+	if decodeXML(bytes.NewReader(WrapInner(inner)), &result) != nil {
+		return nil
+	}
+	if len(result.Entry) > 0 {
+		return result.Entry
+	}
+	return result.Wrapper.Entry
+}
+
+// fetchScopedObjects fetches the entries of the config element (e.g.
+// "address-group") from the client's vsys and shared, concatenated,
+// converting each with convert and dropping those it rejects.
+func fetchScopedObjects[E, O any](
+	c *Client, ctx context.Context, resource, element, target string, convert func(E) (O, bool),
+) ([]O, error) {
+	vsysXPath := VsysXPath(c.vsys) + "/" + element
+	sharedXPath := "/config/shared/" + element
+
+	vsysEntries, err := fetchObjects(c, ctx, resource, "vsys", vsysXPath, target, parseEntries[E])
+	if err != nil {
+		return nil, err
+	}
+	sharedEntries, err := fetchObjects(c, ctx, resource, "shared", sharedXPath, target, parseEntries[E])
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]O, 0, len(vsysEntries)+len(sharedEntries))
+	for _, e := range append(vsysEntries, sharedEntries...) {
+		if o, ok := convert(e); ok {
+			out = append(out, o)
+		}
+	}
+	return out, nil
+}
+
+// addressGroupEntry mirrors the PAN-OS XML <entry> shape under
+// /address-group, and the REST API's JSON for it.
+type addressGroupEntry struct {
+	Name   string `xml:"name,attr" json:"@name"`
+	Static struct {
+		Member []string `xml:"member" json:"member"`
+	} `xml:"static" json:"static"`
+	Dynamic struct {
+		Filter string `xml:"filter" json:"filter"`
+	} `xml:"dynamic" json:"dynamic"`
+	Description string `xml:"description" json:"description"`
+	Tag         struct {
+		Member []string `xml:"member" json:"member"`
+	} `xml:"tag" json:"tag"`
+}
+
+func convertAddressGroupEntry(e addressGroupEntry) (models.AddressGroup, bool) {
+	return models.AddressGroup{
+		Name:        e.Name,
+		Members:     append([]string(nil), e.Static.Member...),
+		Filter:      strings.TrimSpace(e.Dynamic.Filter),
+		Description: e.Description,
+		Tags:        append([]string(nil), e.Tag.Member...),
+	}, true
+}
+
+// GetAddressGroups fetches address groups from the client's vsys and shared.
+func (c *Client) GetAddressGroups(ctx context.Context, target string) ([]models.AddressGroup, error) {
+	return fetchScopedObjects(c, ctx, "Objects/AddressGroups", "address-group", target, convertAddressGroupEntry)
+}
+
+// serviceGroupEntry mirrors the PAN-OS XML <entry> shape under
+// /service-group, and the REST API's JSON for it.
+type serviceGroupEntry struct {
+	Name    string `xml:"name,attr" json:"@name"`
+	Members struct {
+		Member []string `xml:"member" json:"member"`
+	} `xml:"members" json:"members"`
+	Tag struct {
+		Member []string `xml:"member" json:"member"`
+	} `xml:"tag" json:"tag"`
+}
+
+func convertServiceGroupEntry(e serviceGroupEntry) (models.ServiceGroup, bool) {
+	return models.ServiceGroup{
+		Name:    e.Name,
+		Members: append([]string(nil), e.Members.Member...),
+		Tags:    append([]string(nil), e.Tag.Member...),
+	}, true
+}
+
+// GetServiceGroups fetches service groups from the client's vsys and shared.
+func (c *Client) GetServiceGroups(ctx context.Context, target string) ([]models.ServiceGroup, error) {
+	return fetchScopedObjects(c, ctx, "Objects/ServiceGroups", "service-group", target, convertServiceGroupEntry)
+}
+
+// edlSource is the source of one external dynamic list type.
+type edlSource struct {
+	URL string `xml:"url" json:"url"`
+}
+
+// externalListEntry mirrors the PAN-OS XML <entry> shape under
+// /external-list, and the REST API's JSON for it.
+type externalListEntry struct {
+	Name string `xml:"name,attr" json:"@name"`
+	Type struct {
+		IP            *edlSource `xml:"ip" json:"ip"`
+		Domain        *edlSource `xml:"domain" json:"domain"`
+		URL           *edlSource `xml:"url" json:"url"`
+		PredefinedIP  *edlSource `xml:"predefined-ip" json:"predefined-ip"`
+		PredefinedURL *edlSource `xml:"predefined-url" json:"predefined-url"`
+	} `xml:"type" json:"type"`
+}
+
+func convertExternalListEntry(e externalListEntry) (models.ExternalList, bool) {
+	for _, t := range []struct {
+		name string
+		src  *edlSource
+	}{
+		{"ip", e.Type.IP},
+		{"domain", e.Type.Domain},
+		{"url", e.Type.URL},
+		{"predefined-ip", e.Type.PredefinedIP},
+		{"predefined-url", e.Type.PredefinedURL},
+	} {
+		if t.src != nil {
+			return models.ExternalList{Name: e.Name, Type: t.name, Source: t.src.URL}, true
+		}
+	}
+	log.Printf("api: external list %q has no recognized type element; skipping", e.Name)
+	return models.ExternalList{}, false
+}
+
+// GetExternalLists fetches external dynamic lists from the client's vsys
+// and shared.
+func (c *Client) GetExternalLists(ctx context.Context, target string) ([]models.ExternalList, error) {
+	return fetchScopedObjects(c, ctx, "Objects/ExternalDynamicLists", "external-list", target, convertExternalListEntry)
+}
+
+// regionEntry mirrors the PAN-OS XML <entry> shape under /region, and the
+// REST API's JSON for it.
+type regionEntry struct {
+	Name    string `xml:"name,attr" json:"@name"`
+	Address struct {
+		Member []string `xml:"member" json:"member"`
+	} `xml:"address" json:"address"`
+}
+
+func convertRegionEntry(e regionEntry) (models.Region, bool) {
+	return models.Region{Name: e.Name, Addresses: append([]string(nil), e.Address.Member...)}, true
+}
+
+// GetRegions fetches custom regions from the client's vsys and shared.
+// Countries are predefined regions and are not returned.
+func (c *Client) GetRegions(ctx context.Context, target string) ([]models.Region, error) {
+	return fetchScopedObjects(c, ctx, "Objects/Regions", "region", target, convertRegionEntry)
+}
@@ -183,3 +183,82 @@ func TestGetServices_TCPAndUDP_PortRange(t *testing.T) {
 		}
 	}
 }
+
+func TestGetAddressGroups_StaticAndDynamic(t *testing.T) {
+	mock := testutil.NewMockPANOS()
+	defer mock.Close()
+
+	client, err := NewClient(mock.Host(), "test-key", ClientOptions{Insecure: true})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	got, err := client.GetAddressGroups(context.Background(), "")
+	if err != nil {
+		t.Fatalf("GetAddressGroups: %v", err)
+	}
+	byName := make(map[string]int)
+	for i, g := range got {
+		byName[g.Name] = i
+	}
+
+	static, ok := byName["prod-servers"]
+	if !ok {
+		t.Fatalf("missing address group prod-servers in %+v", got)
+	}
+	if m := got[static].Members; len(m) != 2 || m[0] != "web-servers" || m[1] != "db-primary" {
+		t.Errorf("prod-servers: Members=%v", m)
+	}
+	dynamic, ok := byName["tagged-web"]
+	if !ok {
+		t.Fatalf("missing address group tagged-web in %+v", got)
+	}
+	if g := got[dynamic]; g.Filter != "'web'" || len(g.Members) != 0 {
+		t.Errorf("tagged-web: Filter=%q Members=%v", g.Filter, g.Members)
+	}
+}
+
+func TestGetServiceGroupsExternalListsRegions(t *testing.T) {
+	mock := testutil.NewMockPANOS()
+	defer mock.Close()
+
+	client, err := NewClient(mock.Host(), "test-key", ClientOptions{Insecure: true})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	ctx := context.Background()
+
+	groups, err := client.GetServiceGroups(ctx, "")
+	if err != nil {
+		t.Fatalf("GetServiceGroups: %v", err)
+	}
+	if len(groups) != 1 || groups[0].Name != "web-and-sql" || len(groups[0].Members) != 2 {
+		t.Errorf("service groups = %+v", groups)
+	}
+
+	lists, err := client.GetExternalLists(ctx, "")
+	if err != nil {
+		t.Fatalf("GetExternalLists: %v", err)
+	}
+	if len(lists) != 1 || lists[0].Type != "ip" || lists[0].Source != "https://feeds.example.com/blocked-ips.txt" {
+		t.Errorf("external lists = %+v", lists)
+	}
+
+	regions, err := client.GetRegions(ctx, "")
+	if err != nil {
+		t.Fatalf("GetRegions: %v", err)
+	}
+	if len(regions) != 1 || regions[0].Name != "hq-campus" || len(regions[0].Addresses) != 1 {
+		t.Errorf("regions = %+v", regions)
+	}
+}
+
+func TestParseEntries_WithAndWithoutWrapper(t *testing.T) {
+	wrapped := parseEntries[serviceGroupEntry]([]byte(`<service-group><entry name="a"><members><member>x</member></members></entry></service-group>`))
+	bare := parseEntries[serviceGroupEntry]([]byte(`<entry name="a"><members><member>x</member></members></entry>`))
+	for _, got := range [][]serviceGroupEntry{wrapped, bare} {
+		if len(got) != 1 || got[0].Name != "a" || len(got[0].Members.Member) != 1 {
+			t.Errorf("parseEntries = %+v", got)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// readOnlyVerbs are the op commands a read-only client may run. Every
+// other verb (request, clear, debug, test, set, ...) can change state or
+// load the device and needs ClientOptions.AllowWrite.
+var readOnlyVerbs = map[string]bool{"show": true}
+
+// valueKeywords take a value as their next word even when it looks like a
+// keyword: "show interface all" is <interface>all</interface>, "show
+// routing route type static" is <type>static</type>.
+var valueKeywords = map[string]bool{
+	"interface": true, "vsys": true, "zone": true, "name": true, "type": true,
+	"severity": true, "source": true, "destination": true, "source-port": true,
+	"destination-port": true, "protocol": true, "from": true, "to": true,
+	"rule": true, "virtual-router": true,
+}
+
+// opElem is one element of an op command.
+type opElem struct {
+	name     string
+	text     string
+	children []*opElem
+}
+
+func (e *opElem) write(b *strings.Builder) {
+	b.WriteString("<" + e.name + ">")
+	_ = xml.EscapeText(b, []byte(e.text)) //nolint:errcheck // strings.Builder never fails
+	for _, c := range e.children {
+		c.write(b)
+	}
+	b.WriteString("</" + e.name + ">")
+}
+
+// ParseOpCommand translates a command in PAN-OS CLI syntax into the XML
+// form the op API takes. Each word opens an element nested in the one
+// before, until the first value, which becomes the text of the element
+// before it. The words after that are keyword/value pairs, each a sibling
+// of the first: filters such as "source 10.0.0.1 destination 10.0.0.2".
+// A quoted word is always a value, as in pan-python; so is a word that
+// cannot be an element name (ethernet1/1, 10.0.0.1, 42), yes/no, the word
+// after one of valueKeywords, and the second word of a pair. Pipes
+// ("| match") are not supported.
+//
+//	show system info             → <show><system><info></info></system></show>
+//	show interface ethernet1/1   → <show><interface>ethernet1/1</interface></show>
+//	show counter global filter delta yes severity drop
+//	  → <show><counter><global><filter><delta>yes</delta><severity>drop</severity></filter></global></counter></show>
+func ParseOpCommand(cli string) (string, error) {
+	words, quoted, err := splitCLI(cli)
+	if err != nil {
+		return "", err
+	}
+	if len(words) == 0 {
+		return "", fmt.Errorf("empty command")
+	}
+
+	var (
+		root  *opElem
+		chain []*opElem // the elements opened so far, until the first value
+		last  *opElem   // the latest keyword
+		pairs bool      // a value was given: the rest are keyword/value pairs
+	)
+	for i, w := range words {
+		isValue := quoted[i] || !isElementName(w) || w == "yes" || w == "no" ||
+			(last != nil && last.text == "" && (pairs || valueKeywords[last.name]))
+		switch {
+		case i == 0 && isValue:
+			return "", fmt.Errorf("%q is not a command", w)
+		case i == 0:
+			root = &opElem{name: w}
+			chain, last = []*opElem{root}, root
+		case isValue && last == nil:
+			return "", fmt.Errorf("unexpected %q after value", w)
+		case isValue:
+			last.text = w
+			last = nil
+			pairs = true
+		case pairs && len(chain) < 2:
+			return "", fmt.Errorf("unexpected %q after value %q", w, root.text)
+		case pairs:
+			// A sibling of the element that took the first value.
+			parent := chain[len(chain)-2]
+			last = &opElem{name: w}
+			parent.children = append(parent.children, last)
+		default:
+			last = &opElem{name: w}
+			chain[len(chain)-1].children = append(chain[len(chain)-1].children, last)
+			chain = append(chain, last)
+		}
+	}
+	if pairs && last != nil {
+		return "", fmt.Errorf("%q needs a value", last.name)
+	}
+
+	var b strings.Builder
+	root.write(&b)
+	return b.String(), nil
+}
+
+// splitCLI splits a command line into words, honouring single and double
+// quotes. quoted[i] reports whether word i was quoted.
+func splitCLI(cli string) (words []string, quoted []bool, err error) {
+	var cur strings.Builder
+	var quote rune
+	inWord, wasQuoted := false, false
+	flush := func() {
+		if inWord {
+			words = append(words, cur.String())
+			quoted = append(quoted, wasQuoted)
+		}
+		cur.Reset()
+		inWord, wasQuoted = false, false
+	}
+	for _, r := range cli {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inWord, wasQuoted = true, true
+		case r == '|':
+			return nil, nil, fmt.Errorf("pipes are not supported")
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, nil, fmt.Errorf("unterminated quote")
+	}
+	flush()
+	return words, quoted, nil
+}
+
+// isElementName reports whether w can be a command keyword: a letter
+// followed by letters, digits, '-' or '_'.
+func isElementName(w string) bool {
+	for i, r := range w {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '-' || r == '_'):
+		default:
+			return false
+		}
+	}
+	return w != ""
+}
+
+// IsReadOnlyOp reports whether cli starts with a verb a read-only client
+// may run.
+func IsReadOnlyOp(cli string) bool {
+	return readOnlyVerbs[opVerb(cli)]
+}
+
+func opVerb(cli string) string {
+	if words := strings.Fields(cli); len(words) > 0 {
+		return words[0]
+	}
+	return ""
+}
+
+// RunOpCommand runs a command typed in CLI syntax (see ParseOpCommand) and
+// returns the inner XML of its result. Commands other than show fail with
+// ErrReadOnly unless the client allows writes.
+func (c *Client) RunOpCommand(ctx context.Context, cli, target string) (string, error) {
+	cmd, err := ParseOpCommand(cli)
+	if err != nil {
+		return "", err
+	}
+	if !c.allowWrite && !IsReadOnlyOp(cli) {
+		return "", fmt.Errorf("%q is not a read-only command: %w", opVerb(cli), ErrReadOnly)
+	}
+	resp, err := c.Op(ctx, cmd, target)
+	if err != nil {
+		return "", err
+	}
+	if err := CheckResponse(resp); err != nil {
+		return "", err
+	}
+	return string(bytes.TrimSpace(resp.Result.Inner)), nil
+}
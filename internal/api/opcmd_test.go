@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jp2195/pyre/internal/testutil"
+)
+
+func TestParseOpCommand(t *testing.T) {
+	tests := []struct {
+		cli, want string
+	}{
+		{"show system info", "<show><system><info></info></system></show>"},
+		{"  show   clock ", "<show><clock></clock></show>"},
+		{"show interface ethernet1/1", "<show><interface>ethernet1/1</interface></show>"},
+		{"show interface all", "<show><interface>all</interface></show>"},
+		{"show session id 1234", "<show><session><id>1234</id></session></show>"},
+		{"show counter global filter delta yes",
+			"<show><counter><global><filter><delta>yes</delta></filter></global></counter></show>"},
+		{"show counter global filter delta yes severity drop",
+			"<show><counter><global><filter><delta>yes</delta><severity>drop</severity></filter></global></counter></show>"},
+		{"show session all filter source 10.0.0.1 destination 10.0.0.2",
+			"<show><session><all><filter><source>10.0.0.1</source><destination>10.0.0.2</destination></filter></all></session></show>"},
+		{"show session all filter from trust to untrust application ssl",
+			"<show><session><all><filter><from>trust</from><to>untrust</to><application>ssl</application></filter></all></session></show>"},
+		{"show routing route type static",
+			"<show><routing><route><type>static</type></route></routing></show>"},
+		{"show routing route type static virtual-router default",
+			"<show><routing><route><type>static</type><virtual-router>default</virtual-router></route></routing></show>"},
+		{`show user group name "cn=net ops,dc=example"`,
+			"<show><user><group><name>cn=net ops,dc=example</name></group></user></show>"},
+		{`show object "a<b"`, "<show><object>a&lt;b</object></show>"},
+	}
+	for _, tt := range tests {
+		got, err := ParseOpCommand(tt.cli)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseOpCommand(%q) = %q, %v; want %q", tt.cli, got, err, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "   ", "10.0.0.1", `show "unterminated`, "show session all | match x", "show interface a b",
+		"show counter global filter delta yes severity", "show 42 system"} {
+		if got, err := ParseOpCommand(bad); err == nil {
+			t.Errorf("ParseOpCommand(%q) = %q; want an error", bad, got)
+		}
+	}
+}
+
+func TestRunOpCommand(t *testing.T) {
+	mock := testutil.NewMockPANOS()
+	defer mock.Close()
+
+	c, err := NewClient(mock.Host(), "k", ClientOptions{Insecure: true})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	out, err := c.RunOpCommand(context.Background(), "show system info", "")
+	if err != nil {
+		t.Fatalf("RunOpCommand: %v", err)
+	}
+	if !strings.HasPrefix(out, "<system>") || !strings.Contains(out, "<hostname>") {
+		t.Errorf("result = %.80q", out)
+	}
+}
+
+func TestRunOpCommand_ReadOnlyAllowList(t *testing.T) {
+	var sent []string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		sent = append(sent, r.URL.Query().Get("cmd"))
+		w.Write([]byte(`<response status="success"><result>ok</result></response>`))
+	}
+
+	ro := newTestClient(t, handler)
+	for _, cli := range []string{"request restart system", "clear session all", "debug software restart process mgmtsrvr"} {
+		if _, err := ro.RunOpCommand(context.Background(), cli, ""); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("%q on a read-only client: err = %v, want ErrReadOnly", cli, err)
+		}
+	}
+	if len(sent) != 0 {
+		t.Fatalf("read-only client sent %v", sent)
+	}
+
+	rw := newWriteClient(t, handler)
+	if _, err := rw.RunOpCommand(context.Background(), "clear session all", ""); err != nil {
+		t.Fatalf("write-enabled client: %v", err)
+	}
+	if len(sent) != 1 || sent[0] != "<clear><session><all></all></session></clear>" {
+		t.Errorf("sent %v", sent)
+	}
+}
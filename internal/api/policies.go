@@ -2,9 +2,12 @@ package api
 
 import (
 	"bytes"
+	"cmp"
 	"context"
+	"encoding/xml"
 	"fmt"
 	"log"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -13,70 +16,71 @@ import (
 	"github.com/jp2195/pyre/internal/models"
 )
 
-// securityRuleEntry defines the XML structure for security rule parsing
+// securityRuleEntry defines the XML structure for security rule parsing,
+// and the JSON the REST API returns for one.
 type securityRuleEntry struct {
-	Name        string `xml:"name,attr"`
-	Disabled    string `xml:"disabled"`
-	Description string `xml:"description"`
-	RuleType    string `xml:"rule-type"`
-	Action      string `xml:"action"`
+	Name        string `xml:"name,attr" json:"@name"`
+	Disabled    string `xml:"disabled" json:"disabled"`
+	Description string `xml:"description" json:"description"`
+	RuleType    string `xml:"rule-type" json:"rule-type"`
+	Action      string `xml:"action" json:"action"`
 	Tag         struct {
-		Member []string `xml:"member"`
-	} `xml:"tag"`
+		Member []string `xml:"member" json:"member"`
+	} `xml:"tag" json:"tag"`
 	From struct {
-		Member []string `xml:"member"`
-	} `xml:"from"`
+		Member []string `xml:"member" json:"member"`
+	} `xml:"from" json:"from"`
 	To struct {
-		Member []string `xml:"member"`
-	} `xml:"to"`
+		Member []string `xml:"member" json:"member"`
+	} `xml:"to" json:"to"`
 	Source struct {
-		Member []string `xml:"member"`
-	} `xml:"source"`
+		Member []string `xml:"member" json:"member"`
+	} `xml:"source" json:"source"`
 	SourceUser struct {
-		Member []string `xml:"member"`
-	} `xml:"source-user"`
-	NegateSource string `xml:"negate-source"`
+		Member []string `xml:"member" json:"member"`
+	} `xml:"source-user" json:"source-user"`
+	NegateSource string `xml:"negate-source" json:"negate-source"`
 	Destination  struct {
-		Member []string `xml:"member"`
-	} `xml:"destination"`
-	NegateDest  string `xml:"negate-destination"`
+		Member []string `xml:"member" json:"member"`
+	} `xml:"destination" json:"destination"`
+	NegateDest  string `xml:"negate-destination" json:"negate-destination"`
 	Application struct {
-		Member []string `xml:"member"`
-	} `xml:"application"`
+		Member []string `xml:"member" json:"member"`
+	} `xml:"application" json:"application"`
 	Service struct {
-		Member []string `xml:"member"`
-	} `xml:"service"`
+		Member []string `xml:"member" json:"member"`
+	} `xml:"service" json:"service"`
 	Category struct {
-		Member []string `xml:"member"`
-	} `xml:"category"`
-	LogStart       string `xml:"log-start"`
-	LogEnd         string `xml:"log-end"`
-	LogSetting     string `xml:"log-setting"`
+		Member []string `xml:"member" json:"member"`
+	} `xml:"category" json:"category"`
+	LogStart       string `xml:"log-start" json:"log-start"`
+	LogEnd         string `xml:"log-end" json:"log-end"`
+	LogSetting     string `xml:"log-setting" json:"log-setting"`
 	ProfileSetting struct {
 		Group struct {
-			Member []string `xml:"member"`
-		} `xml:"group"`
+			Member []string `xml:"member" json:"member"`
+		} `xml:"group" json:"group"`
 		Profiles struct {
 			Virus struct {
-				Member []string `xml:"member"`
-			} `xml:"virus"`
+				Member []string `xml:"member" json:"member"`
+			} `xml:"virus" json:"virus"`
 			Spyware struct {
-				Member []string `xml:"member"`
-			} `xml:"spyware"`
+				Member []string `xml:"member" json:"member"`
+			} `xml:"spyware" json:"spyware"`
 			Vulnerability struct {
-				Member []string `xml:"member"`
-			} `xml:"vulnerability"`
+				Member []string `xml:"member" json:"member"`
+			} `xml:"vulnerability" json:"vulnerability"`
 			URLFiltering struct {
-				Member []string `xml:"member"`
-			} `xml:"url-filtering"`
+				Member []string `xml:"member" json:"member"`
+			} `xml:"url-filtering" json:"url-filtering"`
 			FileBlocking struct {
-				Member []string `xml:"member"`
-			} `xml:"file-blocking"`
+				Member []string `xml:"member" json:"member"`
+			} `xml:"file-blocking" json:"file-blocking"`
 			WildFireAnalysis struct {
-				Member []string `xml:"member"`
-			} `xml:"wildfire-analysis"`
-		} `xml:"profiles"`
-	} `xml:"profile-setting"`
+				Member []string `xml:"member" json:"member"`
+			} `xml:"wildfire-analysis" json:"wildfire-analysis"`
+		} `xml:"profiles" json:"profiles"`
+	} `xml:"profile-setting" json:"profile-setting"`
 }
 
 // parseSecurityRuleEntries parses XML response into security rule entries
@@ -245,22 +249,22 @@ func fetchRulesFromPaths[T any](c *Client, ctx context.Context, xpaths []string,
 	return nil
 }
 
-// rulebasePaths returns the candidate XPaths for one rulebase location
+// RulebasePaths returns the candidate XPaths for one rulebase location
 // ("pre-rulebase", "rulebase", or "post-rulebase") of the given policy kind
-// ("security" or "nat"). The plain local rulebase has an extra vsys-less
-// fallback; pre/post instead have the Panorama-pushed path.
-func rulebasePaths(location, kind string) []string {
+// ("security" or "nat") in vsys. The plain local rulebase has an extra
+// vsys-less fallback; pre/post instead have the Panorama-pushed path.
+func RulebasePaths(vsys, location, kind string) []string {
 	if location == "rulebase" {
 		return []string{
-			fmt.Sprintf("/config/devices/entry[@name='localhost.localdomain']/vsys/entry[@name='vsys1']/rulebase/%s/rules", kind),
-			fmt.Sprintf("/config/devices/entry/vsys/entry[@name='vsys1']/rulebase/%s/rules", kind),
+			fmt.Sprintf("%s/rulebase/%s/rules", VsysXPath(vsys), kind),
+			fmt.Sprintf("/config/devices/entry/vsys/entry[@name='%s']/rulebase/%s/rules", vsys, kind),
 			fmt.Sprintf("/config/devices/entry/vsys/entry/rulebase/%s/rules", kind),
 		}
 	}
 	return []string{
-		fmt.Sprintf("/config/devices/entry[@name='localhost.localdomain']/vsys/entry[@name='vsys1']/%s/%s/rules", location, kind),
-		fmt.Sprintf("/config/devices/entry/vsys/entry[@name='vsys1']/%s/%s/rules", location, kind),
-		fmt.Sprintf("/config/panorama/vsys/entry[@name='vsys1']/%s/%s/rules", location, kind),
+		fmt.Sprintf("%s/%s/%s/rules", VsysXPath(vsys), location, kind),
+		fmt.Sprintf("/config/devices/entry/vsys/entry[@name='%s']/%s/%s/rules", vsys, location, kind),
+		fmt.Sprintf("/config/panorama/vsys/entry[@name='%s']/%s/%s/rules", vsys, location, kind),
 	}
 }
 
@@ -268,7 +272,9 @@ func rulebasePaths(location, kind string) []string {
 type rulebaseSpec[TEntry, TModel any] struct {
 	// kind is the xpath segment and hit-count rule-base name: "security" or
 	// "nat". Compile-time constant — never user input.
-	kind     string
+	kind string
+	// resource is the REST API resource for the local rulebase.
+	resource string
 	parse    func([]byte) []TEntry
 	convert  func(TEntry, int, models.RuleBase) TModel
 	ruleName func(TModel) string
@@ -286,11 +292,19 @@ func fetchRulebase[TEntry, TModel any](c *Client, ctx context.Context, target st
 	var pre, local, post []TEntry
 	var wg sync.WaitGroup
 	wg.Go(func() {
-		pre = fetchRulesFromPaths(c, ctx, rulebasePaths("pre-rulebase", spec.kind), target, spec.parse)
+		pre = fetchRulesFromPaths(c, ctx, RulebasePaths(c.vsys, "pre-rulebase", spec.kind), target, spec.parse)
 	})
-	wg.Go(func() { local = fetchRulesFromPaths(c, ctx, rulebasePaths("rulebase", spec.kind), target, spec.parse) })
 	wg.Go(func() {
-		post = fetchRulesFromPaths(c, ctx, rulebasePaths("post-rulebase", spec.kind), target, spec.parse)
+		// Rules pushed from Panorama stay on the XML API: REST lists them
+		// all under one location, without saying which are pre and post.
+		if entries, ok := viaREST[TEntry](c, ctx, target, spec.resource, "vsys"); ok {
+			local = entries
+			return
+		}
+		local = fetchRulesFromPaths(c, ctx, RulebasePaths(c.vsys, "rulebase", spec.kind), target, spec.parse)
+	})
+	wg.Go(func() {
+		post = fetchRulesFromPaths(c, ctx, RulebasePaths(c.vsys, "post-rulebase", spec.kind), target, spec.parse)
 	})
 	wg.Wait()
 
@@ -313,7 +327,7 @@ func fetchRulebase[TEntry, TModel any](c *Client, ctx context.Context, target st
 		return []TModel{}, nil
 	}
 
-	cmd := fmt.Sprintf("<show><rule-hit-count><vsys><vsys-name><entry name='vsys1'><rule-base><entry name='%s'><rules><all/></rules></entry></rule-base></entry></vsys-name></vsys></rule-hit-count></show>", spec.kind)
+	cmd := fmt.Sprintf("<show><rule-hit-count><vsys><vsys-name><entry name='%s'><rule-base><entry name='%s'><rules><all/></rules></entry></rule-base></entry></vsys-name></vsys></rule-hit-count></show>", c.vsys, spec.kind)
 	hitCountResp, err := c.Op(ctx, cmd, target)
 	switch {
 	case err != nil:
@@ -336,6 +350,7 @@ func fetchRulebase[TEntry, TModel any](c *Client, ctx context.Context, target st
 func (c *Client) GetSecurityPolicies(ctx context.Context, target string) ([]models.SecurityRule, error) {
 	return fetchRulebase(c, ctx, target, rulebaseSpec[securityRuleEntry, models.SecurityRule]{
 		kind:     "security",
+		resource: "Policies/SecurityRules",
 		parse:    parseSecurityRuleEntries,
 		convert:  convertSecurityRuleEntry,
 		ruleName: func(r models.SecurityRule) string { return r.Name },
@@ -345,60 +360,158 @@ func (c *Client) GetSecurityPolicies(ctx context.Context, target string) ([]mode
 	})
 }
 
-// natRuleEntry defines the XML structure for NAT rule parsing
+// GetDefaultSecurityRules returns the predefined intrazone-default and
+// interzone-default rules, with any override of their action, logging, or
+// profiles applied. A device with no overrides, or whose overrides can't
+// be read, gets the built-in behavior: intrazone allowed, interzone
+// denied, neither logged.
+func (c *Client) GetDefaultSecurityRules(ctx context.Context, target string) ([]models.SecurityRule, error) {
+	rules := []models.SecurityRule{
+		{Name: "intrazone-default", RuleType: models.RuleTypeIntrazone, Action: "allow"},
+		{Name: "interzone-default", RuleType: models.RuleTypeInterzone, Action: "deny"},
+	}
+	paths := append(RulebasePaths(c.vsys, "rulebase", "default-security-rules"), RulebasePaths(c.vsys, "post-rulebase", "default-security-rules")...)
+	for _, e := range fetchRulesFromPaths(c, ctx, paths, target, parseSecurityRuleEntries) {
+		for i := range rules {
+			if rules[i].Name != e.Name {
+				continue
+			}
+			o := convertSecurityRuleEntry(e, 0, models.RuleBaseLocal)
+			if o.Action != "" {
+				rules[i].Action = o.Action
+			}
+			rules[i].LogStart, rules[i].LogEnd, rules[i].LogForwarding = o.LogStart, o.LogEnd, o.LogForwarding
+			rules[i].Profile, rules[i].ProfileType = o.Profile, o.ProfileType
+			rules[i].AntivirusProfile, rules[i].VulnerabilityProfile = o.AntivirusProfile, o.VulnerabilityProfile
+			rules[i].SpywareProfile, rules[i].URLFilteringProfile = o.SpywareProfile, o.URLFilteringProfile
+			rules[i].FileBlockingProfile, rules[i].WildFireProfile = o.FileBlockingProfile, o.WildFireProfile
+		}
+	}
+	for i := range rules {
+		rules[i].SourceZones, rules[i].DestZones = []string{"any"}, []string{"any"}
+		rules[i].Sources, rules[i].Destinations = []string{"any"}, []string{"any"}
+		rules[i].Applications, rules[i].Services = []string{"any"}, []string{"any"}
+	}
+	return rules, nil
+}
+
+// GetAppsSeen returns the applications the Policy Optimizer saw match each
+// of the named security rules over the last days days, most traffic first.
+// Rules that matched nothing are absent from the map.
+func (c *Client) GetAppsSeen(ctx context.Context, rules []string, days int, target string) (map[string][]models.AppSeen, error) {
+	if len(rules) == 0 {
+		return map[string][]models.AppSeen{}, nil
+	}
+	var b strings.Builder
+	b.WriteString("<show><policy-app-details><rules>")
+	for _, name := range rules {
+		b.WriteString("<member>")
+		_ = xml.EscapeText(&b, []byte(name)) //nolint:errcheck // strings.Builder never fails
+		b.WriteString("</member>")
+	}
+	fmt.Fprintf(&b, "</rules><resultfilter><apps-seen/><all/></resultfilter><vsysName>%s</vsysName>"+
+		"<trafficTimeframe>%d</trafficTimeframe><appTimeframe>%d</appTimeframe><mode>get-all</mode><type>security</type>"+
+		"</policy-app-details></show>", c.vsys, days, days)
+
+	resp, err := c.Op(ctx, b.String(), target)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Rule []struct {
+			Name string `xml:"name,attr"`
+			App  []struct {
+				Name        string `xml:"name,attr"`
+				Application string `xml:"application"`
+				Bytes       int64  `xml:"bytes"`
+				FirstSeen   string `xml:"first-seen"`
+				LastSeen    string `xml:"last-seen"`
+			} `xml:"apps-seen>entry"`
+		} `xml:"rules>entry"`
+	}
+	if err := decodeXML(bytes.NewReader(WrapInner(resp.Result.Inner)), &result); err != nil {
+		return nil, fmt.Errorf("parsing apps seen: %w", err)
+	}
+	seen := make(map[string][]models.AppSeen, len(result.Rule))
+	for _, r := range result.Rule {
+		apps := make([]models.AppSeen, 0, len(r.App))
+		for _, a := range r.App {
+			apps = append(apps, models.AppSeen{
+				Name:      cmp.Or(a.Name, a.Application),
+				Bytes:     a.Bytes,
+				FirstSeen: parseUnixTimestamp(a.FirstSeen),
+				LastSeen:  parseUnixTimestamp(a.LastSeen),
+			})
+		}
+		if len(apps) == 0 {
+			continue
+		}
+		sanitizeAllStrings(&apps)
+		slices.SortStableFunc(apps, func(a, b models.AppSeen) int { return cmp.Compare(b.Bytes, a.Bytes) })
+		seen[r.Name] = apps
+	}
+	return seen, nil
+}
+
+// natRuleEntry defines the XML structure for NAT rule parsing, and the
+// JSON the REST API returns for one.
 type natRuleEntry struct {
-	Name        string `xml:"name,attr"`
-	Disabled    string `xml:"disabled"`
-	Description string `xml:"description"`
-	NATType     string `xml:"nat-type"`
+	Name        string `xml:"name,attr" json:"@name"`
+	Disabled    string `xml:"disabled" json:"disabled"`
+	Description string `xml:"description" json:"description"`
+	NATType     string `xml:"nat-type" json:"nat-type"`
 	Tag         struct {
-		Member []string `xml:"member"`
-	} `xml:"tag"`
+		Member []string `xml:"member" json:"member"`
+	} `xml:"tag" json:"tag"`
 	From struct {
-		Member []string `xml:"member"`
-	} `xml:"from"`
+		Member []string `xml:"member" json:"member"`
+	} `xml:"from" json:"from"`
 	To struct {
-		Member []string `xml:"member"`
-	} `xml:"to"`
+		Member []string `xml:"member" json:"member"`
+	} `xml:"to" json:"to"`
 	Source struct {
-		Member []string `xml:"member"`
-	} `xml:"source"`
+		Member []string `xml:"member" json:"member"`
+	} `xml:"source" json:"source"`
 	Destination struct {
-		Member []string `xml:"member"`
-	} `xml:"destination"`
-	Service           string `xml:"service"`
-	ToInterface       string `xml:"to-interface"`
+		Member []string `xml:"member" json:"member"`
+	} `xml:"destination" json:"destination"`
+	Service           string `xml:"service" json:"service"`
+	ToInterface       string `xml:"to-interface" json:"to-interface"`
 	SourceTranslation struct {
 		DynamicIPAndPort struct {
 			InterfaceAddress struct {
-				Interface string `xml:"interface"`
-				IP        string `xml:"ip"`
-			} `xml:"interface-address"`
+				Interface string `xml:"interface" json:"interface"`
+				IP        string `xml:"ip" json:"ip"`
+			} `xml:"interface-address" json:"interface-address"`
 			TranslatedAddress struct {
-				Member []string `xml:"member"`
-			} `xml:"translated-address"`
-		} `xml:"dynamic-ip-and-port"`
+				Member []string `xml:"member" json:"member"`
+			} `xml:"translated-address" json:"translated-address"`
+		} `xml:"dynamic-ip-and-port" json:"dynamic-ip-and-port"`
 		DynamicIP struct {
 			TranslatedAddress struct {
-				Member []string `xml:"member"`
-			} `xml:"translated-address"`
+				Member []string `xml:"member" json:"member"`
+			} `xml:"translated-address" json:"translated-address"`
 			Fallback struct {
 				Interface struct {
-					Interface string `xml:"interface"`
-					IP        string `xml:"ip"`
-				} `xml:"interface-address"`
-			} `xml:"fallback"`
-		} `xml:"dynamic-ip"`
+					Interface string `xml:"interface" json:"interface"`
+					IP        string `xml:"ip" json:"ip"`
+				} `xml:"interface-address" json:"interface-address"`
+			} `xml:"fallback" json:"fallback"`
+		} `xml:"dynamic-ip" json:"dynamic-ip"`
 		StaticIP struct {
-			TranslatedAddress string `xml:"translated-address"`
-			BiDirectional     string `xml:"bi-directional"`
-		} `xml:"static-ip"`
-	} `xml:"source-translation"`
+			TranslatedAddress string `xml:"translated-address" json:"translated-address"`
+			BiDirectional     string `xml:"bi-directional" json:"bi-directional"`
+		} `xml:"static-ip" json:"static-ip"`
+	} `xml:"source-translation" json:"source-translation"`
 	DestinationTranslation struct {
-		TranslatedAddress string `xml:"translated-address"`
-		TranslatedPort    string `xml:"translated-port"`
-	} `xml:"destination-translation"`
-	ActiveActiveDeviceBinding string `xml:"active-active-device-binding"`
+		TranslatedAddress string `xml:"translated-address" json:"translated-address"`
+		TranslatedPort    string `xml:"translated-port" json:"translated-port"`
+	} `xml:"destination-translation" json:"destination-translation"`
+	ActiveActiveDeviceBinding string `xml:"active-active-device-binding" json:"active-active-device-binding"`
 }
 
 // parseNATRuleEntries parses XML response into NAT rule entries
@@ -476,6 +589,7 @@ func convertNATRuleEntry(e natRuleEntry, position int, ruleBase models.RuleBase)
 func (c *Client) GetNATRules(ctx context.Context, target string) ([]models.NATRule, error) {
 	return fetchRulebase(c, ctx, target, rulebaseSpec[natRuleEntry, models.NATRule]{
 		kind:     "nat",
+		resource: "Policies/NatRules",
 		parse:    parseNATRuleEntries,
 		convert:  convertNATRuleEntry,
 		ruleName: func(r models.NATRule) string { return r.Name },
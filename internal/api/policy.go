@@ -0,0 +1,263 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RequestPolicy bounds the load a Client puts on a device and how it rides
+// out an overloaded one. Older management planes answer a dashboard's
+// burst of concurrent requests with 5xx errors or time out; a limit on
+// requests in flight, a request rate, and a few retries turn that into a
+// slower dashboard instead of a broken one.
+//
+// The zero value imposes no limits and never retries.
+type RequestPolicy struct {
+	// MaxConcurrent caps the requests in flight at once, across every
+	// Panorama target the client serves. 0 means no cap.
+	MaxConcurrent int
+	// Rate is the sustained requests per second allowed, with bursts of up
+	// to Burst (at least 1). 0 means no rate limit.
+	Rate  float64
+	Burst int
+	// Retries is how many times an idempotent request is retried after a
+	// transport error, a 5xx, or a 429. Commits and state-changing op
+	// commands are never retried.
+	Retries int
+	// RetryBackoff is the base delay before the first retry; each later
+	// retry doubles it, with full jitter. Defaults to 500ms.
+	RetryBackoff time.Duration
+}
+
+// DefaultRequestPolicy is what pyre applies to a connection unless its
+// config says otherwise.
+var DefaultRequestPolicy = RequestPolicy{MaxConcurrent: 6, Retries: 2}
+
+const (
+	defaultRetryBackoff = 500 * time.Millisecond
+	maxRetryDelay       = 10 * time.Second
+	// maxRetryAfter caps how long a device's Retry-After can hold a
+	// request back.
+	maxRetryAfter = 30 * time.Second
+)
+
+// HTTPStatusError is a response with an HTTP error status the client
+// cannot read as an API answer, such as a 503 from an overloaded
+// management plane.
+type HTTPStatusError struct {
+	StatusCode int
+	RetryAfter time.Duration // from the Retry-After header, if any
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("device returned HTTP %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// transportError marks a failure to exchange a request with the device at
+// all, as opposed to an answer the client didn't like.
+type transportError struct{ err error }
+
+func (e *transportError) Error() string { return e.err.Error() }
+func (e *transportError) Unwrap() error { return e.err }
+
+// limiter enforces a RequestPolicy's concurrency cap and rate.
+type limiter struct {
+	slots  chan struct{} // nil for no cap
+	bucket *tokenBucket  // nil for no rate limit
+}
+
+func newLimiter(p RequestPolicy) *limiter {
+	l := &limiter{}
+	if p.MaxConcurrent > 0 {
+		l.slots = make(chan struct{}, p.MaxConcurrent)
+	}
+	if p.Rate > 0 {
+		l.bucket = newTokenBucket(p.Rate, p.Burst)
+	}
+	return l
+}
+
+// acquire waits for a free slot and a token, and returns the func that
+// frees the slot.
+func (l *limiter) acquire(ctx context.Context) (func(), error) {
+	release := func() {}
+	if l == nil {
+		return release, nil
+	}
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+			release = func() { <-l.slots }
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if l.bucket != nil {
+		if err := l.bucket.wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+	return release, nil
+}
+
+// tokenBucket allows rate events per second on average, in bursts of up
+// to burst.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	b := float64(max(burst, 1))
+	return &tokenBucket{rate: rate, burst: b, tokens: b, last: time.Now()}
+}
+
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// send runs one logical request under the client's policy: each attempt
+// waits its turn with the limiter, and an idempotent request that failed
+// for a transient reason is retried after a jittered backoff.
+func (c *Client) send(ctx context.Context, params url.Values, apiKey, target string) (*XMLResponse, error) {
+	retries := 0
+	if idempotent(params) {
+		retries = c.policy.Retries
+	}
+	for attempt := 0; ; attempt++ {
+		queued := time.Now()
+		release, err := c.limiter.acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		call := newCall(params, target, time.Since(queued))
+		resp, err := c.do(ctx, params, apiKey, target, &call)
+		release()
+
+		delay, ok := retryDelay(ctx, err, attempt, c.policy.RetryBackoff)
+		if !ok || attempt >= retries {
+			return resp, err
+		}
+		debugf("[API Request] retrying in %s after: %v", delay.Round(time.Millisecond), err)
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return nil, err
+		}
+	}
+}
+
+// checkPendingChangesCmd asks whether the candidate config has uncommitted
+// changes; the answer is "yes" or "no".
+const checkPendingChangesCmd = "<check><pending-changes></pending-changes></check>"
+
+// readOnlyOps are op commands outside <show> that only read.
+var readOnlyOps = []string{
+	"<request><license><info></info></license></request>",
+	checkPendingChangesCmd,
+}
+
+// idempotent reports whether a request can be sent twice without effect:
+// config reads, log queries, show commands and readOnlyOps, and REST
+// requests (the client only reads over REST). Commits and every other op
+// command (request, clear, validate, ...) are not retried.
+func idempotent(params url.Values) bool {
+	switch params.Get("type") {
+	case "config":
+		switch params.Get("action") {
+		case "get", "show":
+			return true
+		}
+	case "log", "rest":
+		return true
+	case "op":
+		cmd := strings.TrimSpace(params.Get("cmd"))
+		return strings.HasPrefix(cmd, "<show>") || strings.HasPrefix(cmd, "<show/>") || slices.Contains(readOnlyOps, cmd)
+	}
+	return false
+}
+
+// retryDelay reports whether err is worth retrying and how long to wait
+// before attempt+1: base doubled per attempt with full jitter, or longer
+// if the device asked with Retry-After.
+func retryDelay(ctx context.Context, err error, attempt int, base time.Duration) (time.Duration, bool) {
+	if err == nil || ctx.Err() != nil {
+		return 0, false
+	}
+	var floor time.Duration
+	var status *HTTPStatusError
+	var transport *transportError
+	switch {
+	case errors.As(err, &status):
+		if status.StatusCode != http.StatusTooManyRequests && status.StatusCode < 500 {
+			return 0, false
+		}
+		floor = min(status.RetryAfter, maxRetryAfter)
+	case errors.As(err, &transport):
+		// A certificate the client won't accept stays unacceptable.
+		var verify *tls.CertificateVerificationError
+		var unknown *UnknownHostError
+		var changed *HostKeyChangedError
+		if errors.As(err, &verify) || errors.As(err, &unknown) || errors.As(err, &changed) {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+
+	if base <= 0 {
+		base = defaultRetryBackoff
+	}
+	ceiling := maxRetryDelay
+	if attempt < 16 {
+		ceiling = min(base<<attempt, maxRetryDelay)
+	}
+	delay := time.Duration(rand.Int64N(int64(ceiling) + 1)) // #nosec G404 -- jitter, not security
+	return max(delay, floor), true
+}
+
+// retryAfter parses a Retry-After header given in seconds. PAN-OS doesn't
+// send the HTTP-date form.
+func retryAfter(h string) time.Duration {
+	secs, err := strconv.Atoi(strings.TrimSpace(h))
+	if err != nil || secs < 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
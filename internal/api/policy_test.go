@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newPolicyClient(t *testing.T, policy RequestPolicy, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewTLSServer(handler)
+	t.Cleanup(srv.Close)
+	c, err := NewClient(strings.TrimPrefix(srv.URL, "https://"), "K", ClientOptions{Insecure: true, AllowWrite: true, Policy: policy})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+const okResponse = `<response status="success"><result/></response>`
+
+func TestPolicy_MaxConcurrent(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	c := newPolicyClient(t, RequestPolicy{MaxConcurrent: 3}, func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		inFlight.Add(-1)
+		fmt.Fprint(w, okResponse)
+	})
+
+	var wg sync.WaitGroup
+	for range 12 {
+		wg.Go(func() {
+			if _, err := c.Op(context.Background(), "<show><system><info/></system></show>", ""); err != nil {
+				t.Errorf("Op: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+	if got := peak.Load(); got > 3 || got == 0 {
+		t.Errorf("peak concurrency = %d, want 1..3", got)
+	}
+}
+
+func TestPolicy_Rate(t *testing.T) {
+	c := newPolicyClient(t, RequestPolicy{Rate: 50, Burst: 1}, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, okResponse)
+	})
+	start := time.Now()
+	for range 6 {
+		if _, err := c.Get(context.Background(), "/config", ""); err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+	}
+	// The first request spends the burst; five more at 50/s take 100ms.
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("6 requests at 50/s took %s, want >= 100ms", elapsed)
+	}
+}
+
+func TestPolicy_RetriesIdempotentRequests(t *testing.T) {
+	var calls atomic.Int32
+	c := newPolicyClient(t, RequestPolicy{Retries: 2, RetryBackoff: time.Millisecond}, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, okResponse)
+	})
+	if _, err := c.Get(context.Background(), "/config", ""); err != nil {
+		t.Fatalf("Get after two 503s: %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("calls = %d, want 3", got)
+	}
+}
+
+func TestPolicy_GivesUpAfterRetries(t *testing.T) {
+	var calls atomic.Int32
+	c := newPolicyClient(t, RequestPolicy{Retries: 2, RetryBackoff: time.Millisecond}, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	_, err := c.Get(context.Background(), "/config", "")
+	var status *HTTPStatusError
+	if !errors.As(err, &status) || status.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("err = %v, want HTTP 429", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("calls = %d, want 1 + 2 retries", got)
+	}
+}
+
+func TestPolicy_NeverRetriesWrites(t *testing.T) {
+	var calls atomic.Int32
+	c := newPolicyClient(t, RequestPolicy{Retries: 3, RetryBackoff: time.Millisecond}, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	if _, err := c.Commit(context.Background(), "<commit/>", ""); err == nil {
+		t.Fatal("Commit: expected an error")
+	}
+	if _, err := c.Op(context.Background(), "<request><restart><system/></restart></request>", ""); err == nil {
+		t.Fatal("Op: expected an error")
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("calls = %d, want one per request", got)
+	}
+}
+
+func TestPolicy_XMLErrorWithServerStatusIsAnAnswer(t *testing.T) {
+	var calls atomic.Int32
+	c := newPolicyClient(t, RequestPolicy{Retries: 2, RetryBackoff: time.Millisecond}, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `<response status="error"><msg><line>bad xpath</line></msg></response>`)
+	})
+	resp, err := c.Get(context.Background(), "/bad", "")
+	if err != nil || resp.IsSuccess() || calls.Load() != 1 {
+		t.Errorf("resp = %+v, err = %v, calls = %d; want the device's error answer once", resp, err, calls.Load())
+	}
+}
+
+func TestIdempotent(t *testing.T) {
+	tests := []struct {
+		params url.Values
+		want   bool
+	}{
+		{url.Values{"type": {"config"}, "action": {"get"}}, true},
+		{url.Values{"type": {"config"}, "action": {"show"}}, true},
+		{url.Values{"type": {"config"}, "action": {"set"}}, false},
+		{url.Values{"type": {"log"}, "log-type": {"traffic"}}, true},
+		{url.Values{"type": {"op"}, "cmd": {"<show><jobs><all/></jobs></show>"}}, true},
+		{url.Values{"type": {"op"}, "cmd": {"<show/>"}}, true},
+		{url.Values{"type": {"op"}, "cmd": {"<request><license><info></info></license></request>"}}, true},
+		{url.Values{"type": {"op"}, "cmd": {"<request><license><fetch></fetch></license></request>"}}, false},
+		{url.Values{"type": {"op"}, "cmd": {"<validate><full/></validate>"}}, false},
+		{url.Values{"type": {"op"}, "cmd": {"<showx/>"}}, false},
+		{url.Values{"type": {"commit"}, "cmd": {"<commit/>"}}, false},
+	}
+	for _, tt := range tests {
+		if got := idempotent(tt.params); got != tt.want {
+			t.Errorf("idempotent(%v) = %v, want %v", tt.params, got, tt.want)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	ctx := context.Background()
+	transient := &transportError{errors.New("connection reset")}
+	if d, ok := retryDelay(ctx, transient, 3, 10*time.Millisecond); !ok || d > 80*time.Millisecond {
+		t.Errorf("transport error: delay %s, ok %v; want <= 80ms", d, ok)
+	}
+	pinned := &transportError{fmt.Errorf("executing request: %w", &UnknownHostError{Host: "fw"})}
+	if _, ok := retryDelay(ctx, pinned, 0, 0); ok {
+		t.Error("an untrusted certificate should not be retried")
+	}
+	if _, ok := retryDelay(ctx, &HTTPStatusError{StatusCode: http.StatusNotFound}, 0, 0); ok {
+		t.Error("a 404 should not be retried")
+	}
+	if d, ok := retryDelay(ctx, &HTTPStatusError{StatusCode: 503, RetryAfter: 2 * time.Second}, 0, time.Millisecond); !ok || d < 2*time.Second {
+		t.Errorf("Retry-After: delay %s, ok %v; want >= 2s", d, ok)
+	}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, ok := retryDelay(cancelled, transient, 0, 0); ok {
+		t.Error("a cancelled request should not be retried")
+	}
+}
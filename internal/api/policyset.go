@@ -0,0 +1,73 @@
+package api
+
+import (
+	"github.com/jp2195/pyre/internal/models"
+	"github.com/jp2195/pyre/internal/pancfg"
+)
+
+// PolicySetFromConfig reads the rules and objects of vsys and shared from
+// a <config> document, such as a backup, as the Get methods read them from
+// a device. A config holds no hit counts; they are left zero.
+func PolicySetFromConfig(root *pancfg.Node, vsys string) models.PolicySet {
+	return models.PolicySet{
+		Security:      rulesFromConfig(root, vsys, "security", parseSecurityRuleEntries, convertSecurityRuleEntry),
+		NAT:           rulesFromConfig(root, vsys, "nat", parseNATRuleEntries, convertNATRuleEntry),
+		Addresses:     objectsFromConfig(root, vsys, "address", parseAddressEntries, convertAddressEntry),
+		AddressGroups: objectsFromConfig(root, vsys, "address-group", parseEntries[addressGroupEntry], convertAddressGroupEntry),
+		Services:      objectsFromConfig(root, vsys, "service", parseServiceEntries, convertServiceEntry),
+		ServiceGroups: objectsFromConfig(root, vsys, "service-group", parseEntries[serviceGroupEntry], convertServiceGroupEntry),
+		ExternalLists: objectsFromConfig(root, vsys, "external-list", parseEntries[externalListEntry], convertExternalListEntry),
+		Regions:       objectsFromConfig(root, vsys, "region", parseEntries[regionEntry], convertRegionEntry),
+	}
+}
+
+// configEntries parses the entries at the first of xpaths that has any.
+func configEntries[T any](root *pancfg.Node, xpaths []string, parse func([]byte) []T) []T {
+	for _, xpath := range xpaths {
+		nodes, err := pancfg.Select(root, xpath)
+		if err != nil || len(nodes) == 0 {
+			continue
+		}
+		if entries := parse(nodes[0].Marshal()); len(entries) > 0 {
+			return entries
+		}
+	}
+	return nil
+}
+
+// rulesFromConfig reads one policy kind's pre, local and post rules, in
+// evaluation order with 1-based positions, as fetchRulebase does.
+func rulesFromConfig[TEntry, TModel any](root *pancfg.Node, vsys, kind string, parse func([]byte) []TEntry,
+	convert func(TEntry, int, models.RuleBase) TModel) []TModel {
+	rules := []TModel{}
+	for _, group := range []struct {
+		location string
+		base     models.RuleBase
+	}{
+		{"pre-rulebase", models.RuleBasePre},
+		{"rulebase", models.RuleBaseLocal},
+		{"post-rulebase", models.RuleBasePost},
+	} {
+		for _, e := range configEntries(root, RulebasePaths(vsys, group.location, kind), parse) {
+			rules = append(rules, convert(e, len(rules)+1, group.base))
+		}
+	}
+	return rules
+}
+
+// objectsFromConfig reads the entries of element from vsys, then shared,
+// as fetchScopedObjects does.
+func objectsFromConfig[E, O any](root *pancfg.Node, vsys, element string, parse func([]byte) []E, convert func(E) (O, bool)) []O {
+	out := []O{}
+	for _, xpath := range []string{
+		VsysXPath(vsys) + "/" + element,
+		"/config/shared/" + element,
+	} {
+		for _, e := range configEntries(root, []string{xpath}, parse) {
+			if o, ok := convert(e); ok {
+				out = append(out, o)
+			}
+		}
+	}
+	return out
+}
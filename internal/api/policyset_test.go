@@ -0,0 +1,72 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/jp2195/pyre/internal/models"
+	"github.com/jp2195/pyre/internal/pancfg"
+)
+
+func TestPolicySetFromConfig(t *testing.T) {
+	const doc = `<config>
+  <devices><entry name="localhost.localdomain"><vsys><entry name="vsys1">
+    <pre-rulebase><security><rules>
+      <entry name="pre-deny"><from><member>any</member></from><to><member>any</member></to><source><member>any</member></source><destination><member>any</member></destination><action>deny</action></entry>
+    </rules></security></pre-rulebase>
+    <rulebase>
+      <security><rules>
+        <entry name="allow-web"><from><member>trust</member></from><to><member>untrust</member></to><source><member>lan</member></source><destination><member>any</member></destination><application><member>web-browsing</member></application><service><member>application-default</member></service><action>allow</action></entry>
+        <entry name="allow-dns"><from><member>trust</member></from><to><member>untrust</member></to><source><member>lan</member></source><destination><member>any</member></destination><application><member>dns</member></application><service><member>application-default</member></service><action>allow</action></entry>
+      </rules></security>
+      <nat><rules>
+        <entry name="egress"><from><member>trust</member></from><to><member>untrust</member></to><source><member>lan</member></source><destination><member>any</member></destination><service>any</service></entry>
+      </rules></nat>
+    </rulebase>
+    <address><entry name="lan"><ip-netmask>10.0.0.0/8</ip-netmask></entry></address>
+    <address-group><entry name="servers"><static><member>lan</member></static></entry></address-group>
+    <service><entry name="tcp-8443"><protocol><tcp><port>8443</port></tcp></protocol></entry></service>
+  </entry></vsys></entry></devices>
+  <shared>
+    <address><entry name="dns-1"><ip-netmask>192.0.2.53</ip-netmask></entry></address>
+  </shared>
+</config>`
+	root, err := pancfg.Parse(doc)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	set := PolicySetFromConfig(root, DefaultVsys)
+
+	type rule struct {
+		name string
+		pos  int
+		base models.RuleBase
+	}
+	var rules []rule
+	for _, r := range set.Security {
+		rules = append(rules, rule{r.Name, r.Position, r.RuleBase})
+	}
+	want := []rule{{"pre-deny", 1, models.RuleBasePre}, {"allow-web", 2, models.RuleBaseLocal}, {"allow-dns", 3, models.RuleBaseLocal}}
+	if len(rules) != len(want) {
+		t.Fatalf("security rules = %+v, want %+v", rules, want)
+	}
+	for i := range want {
+		if rules[i] != want[i] {
+			t.Errorf("security rule %d = %+v, want %+v", i, rules[i], want[i])
+		}
+	}
+	if len(set.NAT) != 1 || set.NAT[0].Name != "egress" {
+		t.Errorf("NAT rules = %+v", set.NAT)
+	}
+	if len(set.Addresses) != 2 || set.Addresses[0].Name != "lan" || set.Addresses[1].Name != "dns-1" {
+		t.Errorf("addresses = %+v, want lan then shared dns-1", set.Addresses)
+	}
+	if len(set.AddressGroups) != 1 || set.AddressGroups[0].Name != "servers" {
+		t.Errorf("address groups = %+v", set.AddressGroups)
+	}
+	if len(set.Services) != 1 || set.Services[0].Name != "tcp-8443" {
+		t.Errorf("services = %+v", set.Services)
+	}
+	if len(set.ServiceGroups) != 0 || len(set.ExternalLists) != 0 || len(set.Regions) != 0 {
+		t.Errorf("absent kinds should be empty: %+v", set)
+	}
+}
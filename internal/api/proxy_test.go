@@ -0,0 +1,200 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// pipe copies between a and b until either side closes.
+func pipe(a, b net.Conn) {
+	go func() { _, _ = io.Copy(a, b); _ = a.Close() }()
+	_, _ = io.Copy(b, a)
+	_ = b.Close()
+}
+
+// connectProxy is an HTTP CONNECT proxy requiring basic auth user:pass.
+func connectProxy(t *testing.T, tunnels *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "CONNECT only", http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Proxy-Authorization") != "Basic dXNlcjpwYXNz" {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		conn, _, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			_ = upstream.Close()
+			return
+		}
+		tunnels.Add(1)
+		_, _ = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		pipe(conn, upstream)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// socks5Proxy is a minimal RFC 1928 proxy requiring username/password
+// authentication (RFC 1929) as user:pass. It returns the proxy's address.
+func socks5Proxy(t *testing.T, tunnels *atomic.Int32) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSOCKS5(conn, tunnels)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func serveSOCKS5(conn net.Conn, tunnels *atomic.Int32) {
+	r := bufio.NewReader(conn)
+	read := func(n int) []byte {
+		b := make([]byte, n)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil
+		}
+		return b
+	}
+	// Greeting: version, method count, methods. Insist on user/pass.
+	hdr := read(2)
+	if hdr == nil || read(int(hdr[1])) == nil {
+		_ = conn.Close()
+		return
+	}
+	_, _ = conn.Write([]byte{5, 2})
+	// Sub-negotiation: version, user, password.
+	ver := read(2)
+	if ver == nil {
+		_ = conn.Close()
+		return
+	}
+	user := string(read(int(ver[1])))
+	plen := read(1)
+	if plen == nil {
+		_ = conn.Close()
+		return
+	}
+	pass := string(read(int(plen[0])))
+	if user != "user" || pass != "pass" {
+		_, _ = conn.Write([]byte{1, 1})
+		_ = conn.Close()
+		return
+	}
+	_, _ = conn.Write([]byte{1, 0})
+	// Request: version, CONNECT, reserved, address type, address, port.
+	req := read(4)
+	if req == nil {
+		_ = conn.Close()
+		return
+	}
+	var host string
+	switch req[3] {
+	case 1:
+		host = net.IP(read(4)).String()
+	case 3:
+		n := read(1)
+		host = string(read(int(n[0])))
+	default:
+		_ = conn.Close()
+		return
+	}
+	port := binary.BigEndian.Uint16(read(2))
+	upstream, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port))))
+	if err != nil {
+		_, _ = conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		_ = conn.Close()
+		return
+	}
+	tunnels.Add(1)
+	_, _ = conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+	pipe(&bufferedConn{Conn: conn, r: r}, upstream)
+}
+
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) { return c.r.Read(p) }
+
+func TestNewClient_Proxy(t *testing.T) {
+	_, device := newPinnedServer(t)
+
+	var httpTunnels, socksTunnels atomic.Int32
+	httpProxy := connectProxy(t, &httpTunnels)
+	socksAddr := socks5Proxy(t, &socksTunnels)
+
+	tests := []struct {
+		name    string
+		proxy   string
+		tunnels *atomic.Int32
+	}{
+		{"http CONNECT", "http://user:pass@" + strings.TrimPrefix(httpProxy.URL, "http://"), &httpTunnels},
+		{"socks5", "socks5://user:pass@" + socksAddr, &socksTunnels},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewClient(device, "K", ClientOptions{Insecure: true, Proxy: tt.proxy})
+			if err != nil {
+				t.Fatalf("NewClient: %v", err)
+			}
+			defer func() { _ = c.Close() }()
+			if _, err := c.request(context.Background(), opParams(), ""); err != nil {
+				t.Fatalf("request through proxy: %v", err)
+			}
+			if tt.tunnels.Load() == 0 {
+				t.Error("the request did not go through the proxy")
+			}
+		})
+	}
+
+	t.Run("wrong credentials", func(t *testing.T) {
+		c, err := NewClient(device, "K", ClientOptions{Insecure: true, Proxy: "socks5://user:wrong@" + socksAddr})
+		if err != nil {
+			t.Fatalf("NewClient: %v", err)
+		}
+		defer func() { _ = c.Close() }()
+		if _, err := c.request(context.Background(), opParams(), ""); err == nil {
+			t.Error("a proxy rejecting the credentials should fail the request")
+		}
+	})
+}
+
+func TestNewTransport_InvalidProxy(t *testing.T) {
+	for _, proxy := range []string{"ftp://proxy:21", "socks5://", "http://user:secret@%zz"} {
+		_, err := NewTransport(ClientOptions{Proxy: proxy})
+		if err == nil {
+			t.Errorf("Proxy %q: expected an error", proxy)
+			continue
+		}
+		if strings.Contains(err.Error(), "secret") {
+			t.Errorf("Proxy %q: error leaks the password: %v", proxy, err)
+		}
+	}
+}
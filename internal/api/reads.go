@@ -0,0 +1,52 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Reads runs several reads of a device concurrently and gathers their
+// errors, for Collect functions that assemble one snapshot from many
+// calls. The zero value is ready to use.
+type Reads struct {
+	mu   sync.Mutex
+	wg   sync.WaitGroup
+	errs map[string]error
+	all  []error
+}
+
+// Go runs fetch in its own goroutine. An error it returns is recorded
+// under name.
+func (r *Reads) Go(name string, fetch func() error) {
+	r.wg.Go(func() {
+		if err := fetch(); err != nil {
+			r.mu.Lock()
+			if r.errs == nil {
+				r.errs = map[string]error{}
+			}
+			r.errs[name] = err
+			r.all = append(r.all, fmt.Errorf("%s: %w", name, err))
+			r.mu.Unlock()
+		}
+	})
+}
+
+// Set stores a read's result under the lock the reads share.
+func (r *Reads) Set(apply func()) {
+	r.mu.Lock()
+	apply()
+	r.mu.Unlock()
+}
+
+// Wait waits for every read and returns their errors joined, each
+// prefixed by its read's name.
+func (r *Reads) Wait() error {
+	r.wg.Wait()
+	return errors.Join(r.all...)
+}
+
+// Errors returns the failed reads' errors by name. Call it after Wait.
+func (r *Reads) Errors() map[string]error {
+	return r.errs
+}
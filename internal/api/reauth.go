@@ -0,0 +1,104 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+)
+
+// ErrUnauthorized reports that the device rejected the API key: it
+// expired (PAN-OS API key lifetime), was revoked, or its admin was removed
+// or had the password changed. Check with errors.Is.
+var ErrUnauthorized = errors.New("API key rejected (expired or revoked)")
+
+// codeUnauthorized is the response code PAN-OS sends with a rejected key,
+// alongside HTTP 403.
+const codeUnauthorized = "403"
+
+// ReauthFunc obtains a new API key after the device rejected the current
+// one, typically by asking the user to log in again. It blocks until the
+// key is available and returns "" or an error to give up. The client runs
+// at most one at a time, however many requests are waiting on it, and
+// does not run it again for a key it gave up on.
+type ReauthFunc func() (string, error)
+
+// SetReauth installs fn to run when the device rejects the API key. Until
+// it is set, such requests fail with ErrUnauthorized straight away.
+func (c *Client) SetReauth(fn ReauthFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reauth = fn
+}
+
+func (c *Client) key() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.apiKey
+}
+
+// reauthenticate returns the key to retry with after staleKey was
+// rejected. The first request to get here starts the ReauthFunc; the rest
+// wait for it, so a dashboard's worth of failed panels leads to one login.
+// A request that failed with a key already replaced retries at once; one
+// whose key the user gave up on fails without asking again.
+func (c *Client) reauthenticate(ctx context.Context, staleKey string) (string, error) {
+	c.mu.Lock()
+	if c.apiKey != staleKey {
+		key := c.apiKey
+		c.mu.Unlock()
+		return key, nil
+	}
+	if c.reauth == nil || c.abandoned == staleKey {
+		c.mu.Unlock()
+		return "", ErrUnauthorized
+	}
+	wait := c.reauthWait
+	if wait == nil {
+		wait = make(chan struct{})
+		c.reauthWait = wait
+		go c.runReauth(c.reauth, wait)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-wait:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	if key := c.key(); key != staleKey {
+		return key, nil
+	}
+	return "", ErrUnauthorized
+}
+
+func (c *Client) runReauth(fn ReauthFunc, wait chan struct{}) {
+	key, err := fn()
+	c.mu.Lock()
+	if err == nil && key != "" {
+		c.apiKey = key
+	} else {
+		c.abandoned = c.apiKey
+	}
+	c.reauthWait = nil
+	c.mu.Unlock()
+	close(wait)
+}
+
+// authMessage is the device's reason for rejecting a key, prefixed so it
+// reads as an authentication failure. PAN-OS puts it in <result><msg>
+// ("Invalid Credential") rather than the usual <msg><line>.
+func authMessage(resp *XMLResponse) string {
+	detail := resp.Msg.Line
+	if detail == "" && len(resp.Result.Inner) > 0 {
+		var r struct {
+			Msg string `xml:"msg"`
+		}
+		if decodeXML(bytes.NewReader(WrapInner(resp.Result.Inner)), &r) == nil {
+			detail = r.Msg
+		}
+	}
+	if detail == "" {
+		return ""
+	}
+	return ErrUnauthorized.Error() + ": " + SanitizeForDisplay(detail)
+}
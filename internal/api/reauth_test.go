@@ -0,0 +1,96 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/auth"
+	"github.com/jp2195/pyre/internal/testutil"
+)
+
+// newRevokedClient returns a client whose API key the mock has revoked.
+func newRevokedClient(t *testing.T) (*api.Client, *testutil.MockPANOS) {
+	t.Helper()
+	mock := testutil.NewMockPANOS()
+	t.Cleanup(mock.Close)
+	client, err := api.NewClient(mock.Host(), "old-key", api.ClientOptions{Insecure: true})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	mock.Device.RevokeKeys()
+	return client, mock
+}
+
+func TestClient_RejectedKeyIsUnauthorized(t *testing.T) {
+	client, _ := newRevokedClient(t)
+
+	_, err := client.GetSystemInfo(context.Background(), "")
+	if !errors.Is(err, api.ErrUnauthorized) {
+		t.Fatalf("err = %v; want ErrUnauthorized", err)
+	}
+	if err.Error() != "API key rejected (expired or revoked): Invalid Credential" {
+		t.Errorf("err = %q; want the device's reason", err)
+	}
+}
+
+func TestCheckResponse_Unauthorized(t *testing.T) {
+	err := api.CheckResponse(&api.XMLResponse{Status: "error", Code: "403"})
+	if !errors.Is(err, api.ErrUnauthorized) {
+		t.Errorf("code 403 should be ErrUnauthorized, got %v", err)
+	}
+	err = api.CheckResponse(&api.XMLResponse{Status: "error", Code: "7"})
+	if errors.Is(err, api.ErrUnauthorized) {
+		t.Errorf("code 7 is not an authentication failure: %v", err)
+	}
+}
+
+func TestClient_ReauthRetriesPausedRequests(t *testing.T) {
+	client, mock := newRevokedClient(t)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	client.SetReauth(func() (string, error) {
+		calls.Add(1)
+		<-release
+		res, err := auth.GenerateAPIKey(context.Background(), mock.Host(), "admin", "admin", api.ClientOptions{Insecure: true})
+		if err != nil {
+			return "", err
+		}
+		return res.APIKey, res.Error
+	})
+
+	const requests = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, requests)
+	for range requests {
+		wg.Go(func() {
+			_, err := client.GetSystemInfo(context.Background(), "")
+			errs <- err
+		})
+	}
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("request after re-login: %v", err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("re-login ran %d times; want once for all paused requests", n)
+	}
+}
+
+func TestClient_ReauthGivenUp(t *testing.T) {
+	client, _ := newRevokedClient(t)
+	client.SetReauth(func() (string, error) { return "", nil })
+
+	if _, err := client.GetSystemInfo(context.Background(), ""); !errors.Is(err, api.ErrUnauthorized) {
+		t.Errorf("err = %v; want ErrUnauthorized after giving up", err)
+	}
+}
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PAN-OS 10.0 and later also serve policies and objects over a REST API
+// (/restapi/v10.2/Policies/SecurityRules, ...) that answers in JSON and
+// takes the config location as a query parameter instead of an xpath. The
+// client reads rules and objects from it when the device has one, and
+// falls back to the XML API when it doesn't or a REST request fails; the
+// models are the same either way.
+//
+// The REST API reads the candidate config, the XML reads the running one.
+// So that both backends answer alike, REST is only used while the
+// candidate has no uncommitted changes.
+//
+// REST requests go through the same pipeline as XML ones (limits,
+// retries, cache, recent calls) as type=rest requests, with the response's
+// JSON result in XMLResponse.Result.Inner.
+
+// restMinMajor is the first PAN-OS major release whose REST API pyre uses.
+const restMinMajor = 10
+
+// errNotREST is a response to a REST request that isn't REST API JSON,
+// such as a login page from a device without the API.
+var errNotREST = errors.New("not a REST API response")
+
+// restState records whether the client's device has a usable REST API.
+type restState struct {
+	mu      sync.Mutex
+	checked bool
+	prefix  string // "/restapi/v10.2"; empty if the API isn't used
+}
+
+// restPrefix returns the REST API path prefix to use for a request to
+// target, finding the device's version on first use. Only a firewall
+// queried directly is read over REST: the API can't route a request to a
+// Panorama-managed device, and Panorama's locations are device groups.
+func (c *Client) restPrefix(ctx context.Context, target string) (string, bool) {
+	if target != "" {
+		return "", false
+	}
+	c.rest.mu.Lock()
+	checked, prefix := c.rest.checked, c.rest.prefix
+	c.rest.mu.Unlock()
+	if checked {
+		return prefix, prefix != ""
+	}
+
+	// Fetched without the lock, so that concurrent first reads don't queue
+	// behind one request; the first answer to arrive is kept.
+	info, err := c.GetSystemInfo(ctx, "")
+	if err != nil {
+		// Ask again next time; this fetch uses the XML API.
+		return "", false
+	}
+	if !IsPanoramaModel(info.Model) {
+		prefix = restPrefixFor(info.Version)
+	}
+	c.rest.mu.Lock()
+	defer c.rest.mu.Unlock()
+	if !c.rest.checked {
+		c.rest.checked, c.rest.prefix = true, prefix
+		debugf("[API Request] PAN-OS %q: REST API prefix %q", info.Version, prefix)
+	}
+	return c.rest.prefix, c.rest.prefix != ""
+}
+
+// candidateIsRunning reports whether the candidate config has no
+// uncommitted changes, so that the REST API reads what the XML API does.
+// An answer it can't read counts as changes.
+func (c *Client) candidateIsRunning(ctx context.Context) bool {
+	resp, err := c.Op(ctx, checkPendingChangesCmd, "")
+	if err == nil {
+		err = CheckResponse(resp)
+	}
+	if err != nil {
+		if !errors.Is(err, ErrNotCached) {
+			debugf("[API Request] checking pending changes: %v", err)
+		}
+		return false
+	}
+	return strings.TrimSpace(InnerText(resp.Result.Inner)) == "no"
+}
+
+// restPrefixFor returns the REST API path prefix for a PAN-OS version
+// ("10.2.4-h4" is /restapi/v10.2), or "" for one without the API.
+func restPrefixFor(version string) string {
+	major, rest, ok := strings.Cut(version, ".")
+	if !ok {
+		return ""
+	}
+	minor, _, _ := strings.Cut(rest, ".")
+	maj, err := strconv.Atoi(major)
+	if err != nil || maj < restMinMajor {
+		return ""
+	}
+	if _, err := strconv.Atoi(minor); err != nil {
+		return ""
+	}
+	return fmt.Sprintf("/restapi/v%d.%s", maj, minor)
+}
+
+// restFailed decides what a failed REST request says about the device. An
+// HTTP error status other than overload, or an answer that isn't REST
+// JSON, means the API is missing or closed to this admin, so the client
+// stops using it. Anything else fails just this request.
+func (c *Client) restFailed(err error) {
+	var status *HTTPStatusError
+	permanent := errors.Is(err, errNotREST) ||
+		errors.As(err, &status) && status.StatusCode < 500 && status.StatusCode != http.StatusTooManyRequests
+	if !permanent {
+		return
+	}
+	log.Printf("[API Warning] REST API unavailable, using the XML API: %v", err)
+	c.rest.mu.Lock()
+	defer c.rest.mu.Unlock()
+	c.rest.prefix = ""
+}
+
+// restParams returns the request for a REST resource ("Policies/NatRules")
+// at a location: "vsys" (the client's vsys) or "shared".
+func (c *Client) restParams(prefix, resource, location string) url.Values {
+	params := url.Values{
+		"type":     {"rest"},
+		"path":     {prefix + "/" + resource},
+		"location": {location},
+	}
+	if location == "vsys" {
+		params.Set("vsys", c.vsys)
+	}
+	return params
+}
+
+// restURL returns the URL a type=rest request is sent to.
+func (c *Client) restURL(params url.Values) string {
+	query := url.Values{}
+	for k, v := range params {
+		if k != "type" && k != "path" {
+			query[k] = v
+		}
+	}
+	return strings.TrimSuffix(c.baseURL, "/api/") + params.Get("path") + "?" + query.Encode()
+}
+
+// restEnvelope is the JSON wrapper of every REST API response.
+type restEnvelope struct {
+	Status  string          `json:"@status"`
+	Code    string          `json:"@code"`
+	Message string          `json:"message"`
+	Result  json.RawMessage `json:"result"`
+}
+
+// decodeREST reads a REST API response. An error status without a JSON
+// answer is returned as *HTTPStatusError; a JSON error is returned as an
+// unsuccessful response, as the XML API's are.
+func decodeREST(statusCode int, body []byte) (*XMLResponse, error) {
+	var env restEnvelope
+	decodeErr := json.Unmarshal(body, &env)
+	if decodeErr != nil || env.Status == "" {
+		if statusCode >= 400 {
+			return nil, &HTTPStatusError{StatusCode: statusCode}
+		}
+		return nil, fmt.Errorf("parsing response: %w", errNotREST)
+	}
+	resp := &XMLResponse{Status: env.Status, Code: env.Code}
+	resp.Result.Inner = env.Result
+	resp.Msg.Line = env.Message
+	return resp, nil
+}
+
+// restEntries fetches resource's entries at location over the REST API.
+func restEntries[T any](c *Client, ctx context.Context, prefix, resource, location string) ([]T, error) {
+	resp, err := c.request(ctx, c.restParams(prefix, resource, location), "")
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckResponse(resp); err != nil {
+		return nil, err
+	}
+	var result struct {
+		Entry []T `json:"entry"`
+	}
+	if len(bytes.TrimSpace(resp.Result.Inner)) > 0 {
+		if err := json.Unmarshal(resp.Result.Inner, &result); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", resource, err)
+		}
+	}
+	return result.Entry, nil
+}
+
+// viaREST fetches resource's entries at location over the REST API if the
+// client reads target that way and the candidate config matches the
+// running one. It reports false if not, or the request failed, and the
+// caller should use the XML API.
+func viaREST[T any](c *Client, ctx context.Context, target, resource, location string) ([]T, bool) {
+	prefix, ok := c.restPrefix(ctx, target)
+	if !ok || !c.candidateIsRunning(ctx) {
+		return nil, false
+	}
+	entries, err := restEntries[T](c, ctx, prefix, resource, location)
+	if err != nil {
+		if !errors.Is(err, ErrNotCached) {
+			log.Printf("[API Warning] REST %s (%s) failed, using the XML API: %v", resource, location, err)
+			c.restFailed(err)
+		}
+		return nil, false
+	}
+	return entries, true
+}
@@ -0,0 +1,307 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jp2195/pyre/internal/models"
+)
+
+func TestRestPrefixFor(t *testing.T) {
+	tests := map[string]string{
+		"10.2.4-h4": "/restapi/v10.2",
+		"11.1.0":    "/restapi/v11.1",
+		"10.0.0-b1": "/restapi/v10.0",
+		"9.1.16":    "",
+		"":          "",
+		"garbage":   "",
+		"10.x.1":    "",
+	}
+	for version, want := range tests {
+		if got := restPrefixFor(version); got != want {
+			t.Errorf("restPrefixFor(%q) = %q, want %q", version, got, want)
+		}
+	}
+}
+
+// restDevice serves a firewall running version over both APIs and records
+// the paths asked for. Unrecognised REST resources are 404s. The candidate
+// config has uncommitted changes if pending is set.
+type restDevice struct {
+	mu      sync.Mutex
+	paths   []string
+	pending bool
+}
+
+func (d *restDevice) handler(model, version string, rest map[string]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		d.mu.Lock()
+		d.paths = append(d.paths, r.URL.Path+"?"+q.Get("location")+q.Get("xpath"))
+		d.mu.Unlock()
+
+		if strings.HasPrefix(r.URL.Path, "/restapi/") {
+			body, ok := rest[r.URL.Path+"?"+r.URL.RawQuery]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write([]byte(body))
+			return
+		}
+		switch {
+		case q.Get("cmd") == checkPendingChangesCmd:
+			answer := "no"
+			if d.pending {
+				answer = "yes"
+			}
+			_, _ = w.Write([]byte(`<response status="success"><result>` + answer + `</result></response>`))
+		case strings.Contains(q.Get("cmd"), "<system><info>"):
+			_, _ = w.Write([]byte(`<response status="success"><result><system><hostname>fw1</hostname>` +
+				`<model>` + model + `</model><sw-version>` + version + `</sw-version></system></result></response>`))
+		case strings.HasSuffix(q.Get("xpath"), "/vsys/entry[@name='vsys1']/rulebase/security/rules"):
+			_, _ = w.Write([]byte(`<response status="success"><result><rules>` +
+				`<entry name="xml-rule"><action>allow</action></entry></rules></result></response>`))
+		case strings.HasSuffix(q.Get("xpath"), "/address"):
+			_, _ = w.Write([]byte(`<response status="success"><result><address>` +
+				`<entry name="xml-host"><ip-netmask>10.9.9.9/32</ip-netmask></entry></address></result></response>`))
+		default:
+			_, _ = w.Write([]byte(`<response status="success"><result/></response>`))
+		}
+	}
+}
+
+func (d *restDevice) asked(prefix string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n := 0
+	for _, p := range d.paths {
+		if strings.HasPrefix(p, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+const restSecurityRules = `{"@status":"success","@code":"19","result":{"@total-count":"2","@count":"2","entry":[
+	{"@name":"allow-web","@location":"vsys","@vsys":"vsys1",
+	 "from":{"member":["trust"]},"to":{"member":["untrust"]},
+	 "source":{"member":["any"]},"destination":{"member":["any"]},
+	 "application":{"member":["web-browsing","ssl"]},"service":{"member":["application-default"]},
+	 "action":"allow","log-end":"yes","profile-setting":{"group":{"member":["strict"]}}},
+	{"@name":"block-rest","@location":"vsys","@vsys":"vsys1","disabled":"yes","rule-type":"interzone","action":"deny"}
+]}}`
+
+func TestGetSecurityPolicies_UsesREST(t *testing.T) {
+	dev := &restDevice{}
+	c := newTestClient(t, dev.handler("PA-440", "10.2.4-h4", map[string]string{
+		"/restapi/v10.2/Policies/SecurityRules?location=vsys&vsys=vsys1": restSecurityRules,
+	}))
+
+	rules, err := c.GetSecurityPolicies(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("got %d rules, want the 2 from REST: %+v", len(rules), rules)
+	}
+	web, block := rules[0], rules[1]
+	if web.Name != "allow-web" || web.Position != 1 || web.RuleBase != models.RuleBaseLocal ||
+		web.Action != "allow" || !web.LogEnd || web.Profile != "strict" ||
+		strings.Join(web.Applications, ",") != "web-browsing,ssl" || strings.Join(web.SourceZones, ",") != "trust" {
+		t.Errorf("allow-web = %+v", web)
+	}
+	if block.Name != "block-rest" || !block.Disabled || block.RuleType != models.RuleTypeInterzone || block.Position != 2 {
+		t.Errorf("block-rest = %+v", block)
+	}
+	if n := dev.asked("/api/?/config/devices/entry[@name='localhost.localdomain']/vsys/entry[@name='vsys1']/rulebase/"); n != 0 {
+		t.Errorf("local rulebase also read over XML %d times", n)
+	}
+
+	// The version is looked up once per client.
+	if _, err := c.GetSecurityPolicies(context.Background(), ""); err != nil {
+		t.Fatal(err)
+	}
+	calls := 0
+	for _, call := range c.Calls() {
+		if strings.Contains(call.Path, "<system><info>") {
+			calls++
+		}
+	}
+	if calls != 1 {
+		t.Errorf("system info asked %d times, want 1", calls)
+	}
+}
+
+func TestGetAddresses_UsesREST(t *testing.T) {
+	dev := &restDevice{}
+	c := newTestClient(t, dev.handler("PA-3220", "11.1.2", map[string]string{
+		"/restapi/v11.1/Objects/Addresses?location=vsys&vsys=vsys1": `{"@status":"success","@code":"19","result":{"@total-count":"1","@count":"1","entry":[` +
+			`{"@name":"web","@location":"vsys","@vsys":"vsys1","ip-netmask":"10.0.0.0/24","tag":{"member":["dmz"]}}]}}`,
+		"/restapi/v11.1/Objects/Addresses?location=shared": `{"@status":"success","@code":"19","result":{"@total-count":"1","@count":"1","entry":[` +
+			`{"@name":"partner","@location":"shared","fqdn":"vpn.example.com"}]}}`,
+	}))
+
+	got, err := c.GetAddresses(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.AddressObject{
+		{Name: "web", Type: "ip-netmask", Value: "10.0.0.0/24", Tags: []string{"dmz"}},
+		{Name: "partner", Type: "fqdn", Value: "vpn.example.com"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i].Name != want[i].Name || got[i].Type != want[i].Type || got[i].Value != want[i].Value ||
+			strings.Join(got[i].Tags, ",") != strings.Join(want[i].Tags, ",") {
+			t.Errorf("got[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if n := dev.asked("/api/?/config"); n != 0 {
+		t.Errorf("objects also read over XML %d times", n)
+	}
+}
+
+func TestREST_ReadsClientVsys(t *testing.T) {
+	dev := &restDevice{}
+	c := newTestClient(t, dev.handler("PA-3220", "11.1.2", map[string]string{
+		"/restapi/v11.1/Objects/Addresses?location=vsys&vsys=vsys2": `{"@status":"success","@code":"19","result":{"@total-count":"1","@count":"1","entry":[` +
+			`{"@name":"web2","@location":"vsys","@vsys":"vsys2","ip-netmask":"10.2.0.0/24"}]}}`,
+		"/restapi/v11.1/Objects/Addresses?location=shared": `{"@status":"success","@code":"19","result":{"@total-count":"0","@count":"0"}}`,
+	}))
+	c.vsys = "vsys2"
+
+	got, err := c.GetAddresses(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Name != "web2" {
+		t.Fatalf("addresses = %+v, want vsys2's web2", got)
+	}
+}
+
+func TestREST_NotUsedWithPendingChanges(t *testing.T) {
+	// REST reads the candidate config; with uncommitted changes it would
+	// not match the running config the XML API reads.
+	dev := &restDevice{pending: true}
+	c := newTestClient(t, dev.handler("PA-440", "10.2.4-h4", map[string]string{
+		"/restapi/v10.2/Policies/SecurityRules?location=vsys&vsys=vsys1": restSecurityRules,
+	}))
+
+	rules, err := c.GetSecurityPolicies(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 1 || rules[0].Name != "xml-rule" {
+		t.Fatalf("rules = %+v, want the running rulebase from XML", rules)
+	}
+	if n := dev.asked("/restapi/"); n != 0 {
+		t.Errorf("REST asked %d times, want 0", n)
+	}
+}
+
+func TestREST_FallsBackToXML(t *testing.T) {
+	// No REST resources: every REST request is a 404, as on a device
+	// whose admin role has the REST API disabled.
+	dev := &restDevice{}
+	c := newTestClient(t, dev.handler("PA-440", "10.1.0", nil))
+
+	for range 2 {
+		rules, err := c.GetSecurityPolicies(context.Background(), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rules) != 1 || rules[0].Name != "xml-rule" {
+			t.Fatalf("rules = %+v, want the XML rulebase", rules)
+		}
+	}
+	if n := dev.asked("/restapi/"); n != 1 {
+		t.Errorf("REST asked %d times, want 1: a 404 should stop the client using it", n)
+	}
+}
+
+func TestREST_ErrorResponseFailsOnlyThatRequest(t *testing.T) {
+	dev := &restDevice{}
+	c := newTestClient(t, dev.handler("PA-440", "10.1.0", map[string]string{
+		"/restapi/v10.1/Objects/Addresses?location=vsys&vsys=vsys1": `{"@status":"error","@code":"3","message":"Invalid Query Parameter: location"}`,
+		"/restapi/v10.1/Objects/Addresses?location=shared":          `{"@status":"success","@code":"19","result":{"@total-count":"0","@count":"0"}}`,
+	}))
+
+	for range 2 {
+		got, err := c.GetAddresses(context.Background(), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 || got[0].Name != "xml-host" {
+			t.Fatalf("addresses = %+v, want vsys1's from XML and none shared", got)
+		}
+	}
+	// A PAN-OS error fails only that request; REST stays in use.
+	if n := dev.asked("/restapi/"); n != 4 {
+		t.Errorf("REST asked %d times, want 4", n)
+	}
+}
+
+func TestREST_NotUsedForPanoramaOrTargets(t *testing.T) {
+	for _, tc := range []struct{ name, model, target string }{
+		{"panorama", "Panorama", ""},
+		{"managed device", "PA-440", "012345678901"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dev := &restDevice{}
+			c := newTestClient(t, dev.handler(tc.model, "11.0.0", nil))
+			if _, err := c.GetSecurityPolicies(context.Background(), tc.target); err != nil {
+				t.Fatal(err)
+			}
+			if n := dev.asked("/restapi/"); n != 0 {
+				t.Errorf("REST asked %d times, want 0", n)
+			}
+		})
+	}
+}
+
+func TestREST_RecordThenReplay(t *testing.T) {
+	dev := &restDevice{}
+	srv := httptest.NewTLSServer(dev.handler("PA-440", "10.2.4", map[string]string{
+		"/restapi/v10.2/Policies/SecurityRules?location=vsys&vsys=vsys1": restSecurityRules,
+		"/restapi/v10.2/Objects/Addresses?location=vsys&vsys=vsys1": `{"@status":"success","@code":"19","result":{"@total-count":"1","@count":"1","entry":[` +
+			`{"@name":"web","ip-netmask":"10.0.0.0/24"}]}}`,
+		"/restapi/v10.2/Objects/Addresses?location=shared": `{"@status":"success","@code":"19","result":{"@total-count":"0","@count":"0"}}`,
+	}))
+	path := filepath.Join(t.TempDir(), "rest.jsonl")
+	ctx := context.Background()
+
+	rec, err := NewClient(strings.TrimPrefix(srv.URL, "https://"), "K", ClientOptions{Insecure: true, RecordPath: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rec.GetSecurityPolicies(ctx, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rec.GetAddresses(ctx, ""); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	// The two REST requests share a query; the replay tells them apart by
+	// path.
+	rep, err := NewClient("unreachable.invalid", "", ClientOptions{ReplayPath: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules, err := rep.GetSecurityPolicies(ctx, "")
+	if err != nil || len(rules) != 2 {
+		t.Fatalf("replayed rules = %+v, %v; want the 2 from REST", rules, err)
+	}
+	addrs, err := rep.GetAddresses(ctx, "")
+	if err != nil || len(addrs) != 1 || addrs[0].Name != "web" {
+		t.Fatalf("replayed addresses = %+v, %v; want web", addrs, err)
+	}
+}
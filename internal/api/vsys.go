@@ -0,0 +1,22 @@
+package api
+
+import "regexp"
+
+// DefaultVsys is the virtual system read unless ClientOptions.Vsys names
+// another: the only one on a firewall without multi-vsys.
+const DefaultVsys = "vsys1"
+
+// vsysName matches a virtual system's name. Names are checked before they
+// go into an xpath.
+var vsysName = regexp.MustCompile(`^vsys[0-9]+$`)
+
+// Vsys returns the virtual system the client reads policies and objects
+// from.
+func (c *Client) Vsys() string {
+	return c.vsys
+}
+
+// VsysXPath returns the xpath of vsys on a firewall.
+func VsysXPath(vsys string) string {
+	return "/config/devices/entry[@name='localhost.localdomain']/vsys/entry[@name='" + vsys + "']"
+}
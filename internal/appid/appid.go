@@ -0,0 +1,151 @@
+// Package appid helps move port-based security rules to App-ID: for each
+// rule that allows any application, it reads the applications the Policy
+// Optimizer saw match the rule and proposes the rule that allows just
+// those.
+package appid
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/audit"
+	"github.com/jp2195/pyre/internal/models"
+	"github.com/jp2195/pyre/internal/pancfg"
+)
+
+// DefaultDays is how far back applications are looked up unless Options
+// says otherwise: the Policy Optimizer's own default.
+const DefaultDays = 30
+
+// Options tunes the suggestions.
+type Options struct {
+	// Days is how many days of traffic to look at.
+	Days int
+}
+
+// unidentified are the App-IDs that name no application: traffic the
+// firewall could not, or did not yet, identify. A rule can't usefully
+// allow them, so they are reported rather than suggested.
+var unidentified = map[string]bool{
+	"incomplete":        true,
+	"insufficient-data": true,
+	"non-syn-tcp":       true,
+	"not-applicable":    true,
+	"unknown-tcp":       true,
+	"unknown-udp":       true,
+	"unknown-p2p":       true,
+}
+
+// Suggestion is the proposed App-ID replacement for one port-based rule.
+type Suggestion struct {
+	Rule models.SecurityRule
+	Seen []models.AppSeen // every application seen, most traffic first
+
+	// Applications are the identified applications seen, most traffic
+	// first: what the rule should allow instead of any.
+	Applications []string
+	// Unidentified are the applications seen that App-ID could not name.
+	// Their traffic needs a closer look, or an application override,
+	// before the rule is changed.
+	Unidentified []string
+	// Services is the rule's service after the change: application-default
+	// in place of any, or the rule's own ports kept.
+	Services []string
+	// Commands are the configure-mode CLI commands that make the change.
+	// They are empty when nothing was identified.
+	Commands []string
+}
+
+// Candidates returns the rules worth migrating: enabled allow rules in the
+// device's own rulebase that match any application. Rules pushed from
+// Panorama are left out; they must be changed on Panorama.
+func Candidates(rules []models.SecurityRule) []models.SecurityRule {
+	var out []models.SecurityRule
+	for _, r := range rules {
+		if r.Disabled || r.Action != "allow" || r.RuleBase != models.RuleBaseLocal || !audit.IsAny(r.Applications) {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// Collect reads the security rules of the device target behind c (or c's
+// own device for ""), and the applications seen on each candidate, and
+// returns a suggestion for every candidate.
+func Collect(ctx context.Context, c *api.Client, target string, opts Options) ([]Suggestion, error) {
+	if opts.Days <= 0 {
+		opts.Days = DefaultDays
+	}
+	rules, err := c.GetSecurityPolicies(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	candidates := Candidates(rules)
+	names := make([]string, len(candidates))
+	for i, r := range candidates {
+		names[i] = r.Name
+	}
+	seen, err := c.GetAppsSeen(ctx, names, opts.Days, target)
+	if err != nil {
+		return nil, err
+	}
+	return Suggest(candidates, seen), nil
+}
+
+// Suggest proposes a replacement for each rule from the applications seen
+// on it, keyed by rule name. The busiest rules come first.
+func Suggest(rules []models.SecurityRule, seen map[string][]models.AppSeen) []Suggestion {
+	out := make([]Suggestion, 0, len(rules))
+	for _, r := range rules {
+		s := Suggestion{Rule: r, Seen: seen[r.Name]}
+		s.Rule.AppsSeen = len(s.Seen)
+		for _, a := range s.Seen {
+			if unidentified[a.Name] {
+				s.Unidentified = append(s.Unidentified, a.Name)
+			} else {
+				s.Applications = append(s.Applications, a.Name)
+			}
+		}
+		s.Services = r.Services
+		if audit.IsAny(r.Services) {
+			s.Services = []string{"application-default"}
+		}
+		if len(s.Applications) > 0 {
+			s.Commands = commands(r, s.Applications, s.Services)
+		}
+		out = append(out, s)
+	}
+	slices.SortStableFunc(out, func(a, b Suggestion) int { return cmp.Compare(b.Rule.HitCount, a.Rule.HitCount) })
+	return out
+}
+
+// commands are the CLI commands that replace r's applications, and its
+// service when that changes. A set adds to a member list, so the old
+// members are deleted first.
+func commands(r models.SecurityRule, apps, services []string) []string {
+	rule := "rulebase security rules " + pancfg.QuoteWord(r.Name)
+	cmds := []string{
+		"delete " + rule + " application",
+		"set " + rule + " application " + members(apps),
+	}
+	if !slices.Equal(services, r.Services) {
+		cmds = append(cmds, "delete "+rule+" service", "set "+rule+" service "+members(services))
+	}
+	return cmds
+}
+
+// members spells a member list the way the CLI takes it.
+func members(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = pancfg.QuoteWord(v)
+	}
+	if len(quoted) == 1 {
+		return quoted[0]
+	}
+	return "[ " + strings.Join(quoted, " ") + " ]"
+}
@@ -0,0 +1,117 @@
+package appid
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/models"
+	"github.com/jp2195/pyre/internal/testutil"
+)
+
+func TestCandidates(t *testing.T) {
+	rules := []models.SecurityRule{
+		{Name: "port-based", Action: "allow", RuleBase: models.RuleBaseLocal, Applications: []string{"any"}},
+		{Name: "app-based", Action: "allow", RuleBase: models.RuleBaseLocal, Applications: []string{"ssl"}},
+		{Name: "off", Action: "allow", RuleBase: models.RuleBaseLocal, Disabled: true},
+		{Name: "block", Action: "deny", RuleBase: models.RuleBaseLocal},
+		{Name: "pushed", Action: "allow", RuleBase: models.RuleBasePre},
+	}
+	got := Candidates(rules)
+	if len(got) != 1 || got[0].Name != "port-based" {
+		t.Errorf("candidates = %+v, want port-based only", got)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	rules := []models.SecurityRule{
+		{Name: "quiet", HitCount: 0, Services: []string{"any"}},
+		{Name: "legacy erp", HitCount: 900, Services: []string{"tcp-8443"}},
+		{Name: "outbound", HitCount: 50, Services: []string{"any"}},
+	}
+	seen := map[string][]models.AppSeen{
+		"legacy erp": {{Name: "ssl", Bytes: 900}, {Name: "unknown-tcp", Bytes: 40}},
+		"outbound":   {{Name: "web-browsing", Bytes: 70}, {Name: "dns", Bytes: 10}},
+	}
+	got := Suggest(rules, seen)
+	if len(got) != 3 || got[0].Rule.Name != "legacy erp" || got[2].Rule.Name != "quiet" {
+		t.Fatalf("suggestions not busiest first: %+v", got)
+	}
+
+	erp := got[0]
+	if strings.Join(erp.Applications, ",") != "ssl" || strings.Join(erp.Unidentified, ",") != "unknown-tcp" || erp.Rule.AppsSeen != 2 {
+		t.Errorf("legacy erp = %+v", erp)
+	}
+	wantERP := []string{
+		`delete rulebase security rules "legacy erp" application`,
+		`set rulebase security rules "legacy erp" application ssl`,
+	}
+	if strings.Join(erp.Commands, "\n") != strings.Join(wantERP, "\n") {
+		t.Errorf("a rule on specific ports should keep them:\n%s", strings.Join(erp.Commands, "\n"))
+	}
+
+	wantOutbound := []string{
+		"delete rulebase security rules outbound application",
+		"set rulebase security rules outbound application [ web-browsing dns ]",
+		"delete rulebase security rules outbound service",
+		"set rulebase security rules outbound service application-default",
+	}
+	if strings.Join(got[1].Commands, "\n") != strings.Join(wantOutbound, "\n") {
+		t.Errorf("a rule on any service should move to application-default:\n%s", strings.Join(got[1].Commands, "\n"))
+	}
+
+	if got[2].Commands != nil {
+		t.Errorf("a rule with no traffic should have no commands: %v", got[2].Commands)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	mock := testutil.NewMockPanorama()
+	defer mock.Close()
+	client, err := api.NewClient(mock.Host(), "test-api-key", api.ClientOptions{Insecure: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Collect(context.Background(), client, "007200001001", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Rule.Name != "legacy-erp" {
+		t.Fatalf("suggestions = %+v, want legacy-erp", got)
+	}
+	if strings.Join(got[0].Applications, ",") != "ssl,web-browsing" || strings.Join(got[0].Services, ",") != "tcp-8443" {
+		t.Errorf("legacy-erp = %+v", got[0])
+	}
+}
+
+func TestExport(t *testing.T) {
+	suggestions := Suggest([]models.SecurityRule{{Name: "a|b", Services: []string{"any"}}, {Name: "idle"}},
+		map[string][]models.AppSeen{"a|b": {{Name: "ssl", Bytes: 10, LastSeen: time.Unix(1737456005, 0)}}})
+	dir := filepath.Join(t.TempDir(), "appid")
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	path, err := Export(dir, "fw/edge", suggestions, 30, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(path) != "fw_edge-20260310-120000.md" {
+		t.Errorf("path = %s", path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+	data, _ := os.ReadFile(path)
+	for _, want := range []string{"# App-ID migration: fw/edge", `## a\|b`, "| ssl | 10 | 2025-01-21 |",
+		`set rulebase security rules "a|b" application ssl`, "## idle", "No applications seen in the last 30 days"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("export missing %q:\n%s", want, data)
+		}
+	}
+}
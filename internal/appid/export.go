@@ -0,0 +1,82 @@
+package appid
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jp2195/pyre/internal/devicefile"
+	"github.com/jp2195/pyre/internal/models"
+)
+
+// DefaultDir returns ~/.pyre/appid, where the TUI exports suggestions.
+func DefaultDir() (string, error) {
+	return devicefile.Dir("appid")
+}
+
+// Export writes the suggestions for device as Markdown to a new file under
+// dir, named for the device and time, and returns its path.
+func Export(dir, device string, suggestions []Suggestion, days int, now time.Time) (string, error) {
+	return devicefile.Write(dir, devicefile.Name(device, now, ".md"), func(w io.Writer) error {
+		return WriteMarkdown(w, device, suggestions, days, now)
+	})
+}
+
+// WriteMarkdown writes the suggestions for device, made at when from days
+// days of traffic, as a Markdown document for review: per rule, the
+// applications seen and the commands that would make the change.
+func WriteMarkdown(w io.Writer, device string, suggestions []Suggestion, days int, when time.Time) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "# App-ID migration: %s\n\n", device)
+	fmt.Fprintf(b, "Generated: %s  \nTraffic: last %d days  \nPort-based rules: %d\n\n",
+		when.Format("2006-01-02 15:04 MST"), days, len(suggestions))
+	fmt.Fprintln(b, "Review each change before applying it: a rule only allows the applications")
+	fmt.Fprintln(b, "seen in the period above, so traffic that is rare or seasonal may be missing.")
+	if len(suggestions) == 0 {
+		fmt.Fprintln(b, "\nNo enabled allow rule matches any application.")
+	}
+	for _, s := range suggestions {
+		fmt.Fprintf(b, "\n## %s\n\n", devicefile.MarkdownCell(s.Rule.Name))
+		fmt.Fprintf(b, "Hits: %d  \nService: %s\n\n", s.Rule.HitCount, strings.Join(orAny(s.Rule.Services), ", "))
+		if len(s.Seen) == 0 {
+			fmt.Fprintf(b, "No applications seen in the last %d days. If the rule is no longer needed, disable it.\n", days)
+			continue
+		}
+		fmt.Fprintln(b, "| Application | Bytes | Last seen |")
+		fmt.Fprintln(b, "| --- | --- | --- |")
+		for _, a := range s.Seen {
+			fmt.Fprintf(b, "| %s | %d | %s |\n", devicefile.MarkdownCell(a.Name), a.Bytes, lastSeen(a))
+		}
+		if len(s.Unidentified) > 0 {
+			fmt.Fprintf(b, "\nUnidentified traffic (%s) is not carried over; find out what it is before the change.\n",
+				strings.Join(s.Unidentified, ", "))
+		}
+		if len(s.Commands) == 0 {
+			continue
+		}
+		fmt.Fprintf(b, "\nSuggested applications: %s  \nSuggested service: %s\n\n",
+			devicefile.MarkdownCell(strings.Join(s.Applications, ", ")), devicefile.MarkdownCell(strings.Join(s.Services, ", ")))
+		fmt.Fprintln(b, "```")
+		for _, c := range s.Commands {
+			fmt.Fprintln(b, c)
+		}
+		fmt.Fprintln(b, "```")
+	}
+	return b.Flush()
+}
+
+func lastSeen(a models.AppSeen) string {
+	if a.LastSeen.IsZero() {
+		return "-"
+	}
+	return a.LastSeen.UTC().Format("2006-01-02")
+}
+
+func orAny(members []string) []string {
+	if len(members) == 0 {
+		return []string{"any"}
+	}
+	return members
+}
@@ -0,0 +1,337 @@
+// Package audit checks a device's security rules and certificates against
+// best practices, in the manner of Palo Alto Networks' Best Practice
+// Assessment, and scores the result.
+package audit
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/models"
+)
+
+// Severity is how much a failed check weakens the device's posture.
+type Severity int
+
+const (
+	SeverityLow Severity = iota + 1
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityCritical:
+		return "critical"
+	case SeverityHigh:
+		return "high"
+	case SeverityMedium:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// weight is the severity's share of the score: each step up counts double.
+func (s Severity) weight() float64 {
+	return math.Exp2(float64(s - 1))
+}
+
+// DefaultExpiryDays is how soon a certificate must expire to be flagged,
+// unless Options says otherwise.
+const DefaultExpiryDays = 30
+
+// Options tunes the checks.
+type Options struct {
+	// ExpiryDays flags certificates expiring in fewer days.
+	ExpiryDays int
+}
+
+// Input is what the checks look at.
+type Input struct {
+	Rules        []models.SecurityRule
+	DefaultRules []models.SecurityRule // intrazone-default and interzone-default
+	Certificates []models.Certificate
+}
+
+// Finding is one object failing one check.
+type Finding struct {
+	Check    string
+	Title    string
+	Severity Severity
+	Object   string
+	Detail   string
+}
+
+// CheckResult is how one check fared across the objects it covers.
+type CheckResult struct {
+	ID       string
+	Title    string
+	Severity Severity
+	Fix      string
+	Checked  int
+	Failed   int
+}
+
+// Result is an audit of one device.
+type Result struct {
+	// Score is 0 to 100: each check's pass rate, weighted by severity.
+	// Checks that cover nothing on the device don't count.
+	Score    int
+	Checks   []CheckResult
+	Findings []Finding // most severe first
+}
+
+// Check returns the result of the check id, and false if there is none.
+func (r Result) Check(id string) (CheckResult, bool) {
+	i := slices.IndexFunc(r.Checks, func(c CheckResult) bool { return c.ID == id })
+	if i < 0 {
+		return CheckResult{}, false
+	}
+	return r.Checks[i], true
+}
+
+// Counts returns the number of findings of each severity.
+func (r Result) Counts() map[Severity]int {
+	counts := map[Severity]int{}
+	for _, f := range r.Findings {
+		counts[f.Severity]++
+	}
+	return counts
+}
+
+// Collect reads what the checks need from the device target behind c (or
+// c's own device for ""), concurrently. Reads that fail are left out and
+// returned joined.
+func Collect(ctx context.Context, c *api.Client, target string) (Input, error) {
+	var (
+		in Input
+		rd api.Reads
+	)
+	rd.Go("rules", func() error {
+		rules, err := c.GetSecurityPolicies(ctx, target)
+		if err == nil {
+			rd.Set(func() { in.Rules = rules })
+		}
+		return err
+	})
+	rd.Go("default rules", func() error {
+		rules, err := c.GetDefaultSecurityRules(ctx, target)
+		if err == nil {
+			rd.Set(func() { in.DefaultRules = rules })
+		}
+		return err
+	})
+	rd.Go("certificates", func() error {
+		certs, err := c.GetCertificates(ctx, target)
+		if err == nil {
+			rd.Set(func() { in.Certificates = certs })
+		}
+		return err
+	})
+	return in, rd.Wait()
+}
+
+// Run checks in and scores the device.
+func Run(in Input, opts Options) Result {
+	if opts.ExpiryDays <= 0 {
+		opts.ExpiryDays = DefaultExpiryDays
+	}
+	var res Result
+	record := func(id, title, fix string, sev Severity, checked int, found []Finding) {
+		res.Checks = append(res.Checks, CheckResult{ID: id, Title: title, Severity: sev, Fix: fix, Checked: checked, Failed: len(found)})
+		res.Findings = append(res.Findings, found...)
+	}
+
+	for _, rc := range ruleChecks {
+		rules := in.Rules
+		if rc.defaults {
+			rules = append(slices.Clip(rules), in.DefaultRules...)
+		}
+		checked := 0
+		var found []Finding
+		for _, r := range rules {
+			if r.Disabled || !rc.applies(r) {
+				continue
+			}
+			checked++
+			if detail, failed := rc.fails(r); failed {
+				found = append(found, Finding{Check: rc.id, Title: rc.title, Severity: rc.severity, Object: r.Name, Detail: detail})
+			}
+		}
+		record(rc.id, rc.title, rc.fix, rc.severity, checked, found)
+	}
+
+	var expired, expiring []Finding
+	for _, c := range in.Certificates {
+		switch {
+		case c.DaysLeft < 0:
+			expired = append(expired, Finding{Check: "certificate-expired", Title: "Expired certificates", Severity: SeverityCritical,
+				Object: c.Name, Detail: fmt.Sprintf("expired %d days ago (%s)", -c.DaysLeft, orDash(c.Subject))})
+		case c.DaysLeft < opts.ExpiryDays:
+			expiring = append(expiring, Finding{Check: "certificate-expiring", Title: "Certificates expiring soon", Severity: SeverityHigh,
+				Object: c.Name, Detail: fmt.Sprintf("expires in %d days (%s)", c.DaysLeft, orDash(c.Subject))})
+		}
+	}
+	record("certificate-expired", "Expired certificates", "Renew the certificate, or remove it if nothing uses it.",
+		SeverityCritical, len(in.Certificates), expired)
+	record("certificate-expiring", "Certificates expiring soon", "Renew the certificate before it expires.",
+		SeverityHigh, len(in.Certificates), expiring)
+
+	slices.SortStableFunc(res.Findings, func(a, b Finding) int { return cmp.Compare(b.Severity, a.Severity) })
+	res.Score = score(res.Checks)
+	return res
+}
+
+// score weights each check's pass rate by its severity.
+func score(checks []CheckResult) int {
+	var got, total float64
+	for _, c := range checks {
+		if c.Checked == 0 {
+			continue
+		}
+		w := c.Severity.weight()
+		total += w
+		got += w * float64(c.Checked-c.Failed) / float64(c.Checked)
+	}
+	if total == 0 {
+		return 100
+	}
+	return int(math.Round(got / total * 100))
+}
+
+// ruleCheck is one check of each enabled security rule it applies to.
+type ruleCheck struct {
+	id, title, fix string
+	severity       Severity
+	// defaults also checks the predefined intrazone/interzone rules.
+	defaults bool
+	applies  func(models.SecurityRule) bool
+	// fails reports whether r fails the check, and says how.
+	fails func(r models.SecurityRule) (string, bool)
+}
+
+var ruleChecks = []ruleCheck{
+	{
+		id: "allow-any-any", title: "Allow rules matching any traffic", severity: SeverityCritical,
+		fix:     "Narrow the rule to the sources, destinations, and applications it exists for.",
+		applies: allows,
+		fails: func(r models.SecurityRule) (string, bool) {
+			return "any source, destination, application, and service",
+				IsAny(r.Sources) && IsAny(r.Destinations) && IsAny(r.Applications) && IsAny(r.Services)
+		},
+	},
+	{
+		id: "allow-any-address", title: "Allow rules from any source to any destination", severity: SeverityMedium,
+		fix:     "Limit the source or destination to the addresses the rule is for.",
+		applies: allows,
+		fails: func(r models.SecurityRule) (string, bool) {
+			return "source and destination are any", IsAny(r.Sources) && IsAny(r.Destinations)
+		},
+	},
+	{
+		id: "allow-any-application", title: "Allow rules for any application", severity: SeverityHigh,
+		fix:     "Name the applications the rule allows, so App-ID enforces them.",
+		applies: allows,
+		fails: func(r models.SecurityRule) (string, bool) {
+			return "application is any", IsAny(r.Applications)
+		},
+	},
+	{
+		id: "allow-any-service", title: "Allow rules on any service", severity: SeverityMedium,
+		fix:     "Use application-default, or the specific ports the application needs.",
+		applies: allows,
+		fails: func(r models.SecurityRule) (string, bool) {
+			return "service is any", IsAny(r.Services)
+		},
+	},
+	{
+		id: "port-based-application", title: "Applications allowed off their default ports", severity: SeverityMedium,
+		fix: "Set the service to application-default so the applications are only allowed on their standard ports.",
+		applies: func(r models.SecurityRule) bool {
+			return allows(r) && !IsAny(r.Applications)
+		},
+		fails: func(r models.SecurityRule) (string, bool) {
+			return "service " + strings.Join(r.Services, ", ") + " for " + strings.Join(r.Applications, ", "),
+				!IsAny(r.Services) && !slices.Equal(r.Services, []string{"application-default"})
+		},
+	},
+	{
+		id: "no-security-profile", title: "Allow rules without security profiles", severity: SeverityHigh,
+		fix:     "Attach a security profile group with antivirus, anti-spyware, vulnerability, URL filtering, file blocking, and WildFire profiles.",
+		applies: allows, defaults: true,
+		fails: func(r models.SecurityRule) (string, bool) {
+			return "no profile group or profiles", !hasProfiles(r)
+		},
+	},
+	{
+		id: "intrazone-default-allow", title: "Intrazone traffic allowed by default", severity: SeverityMedium,
+		fix: "Override intrazone-default to deny and allow the intrazone traffic you need with explicit rules, or at least log it and attach profiles.",
+		applies: func(r models.SecurityRule) bool {
+			return r.Name == "intrazone-default" && r.RuleType == models.RuleTypeIntrazone
+		},
+		defaults: true,
+		fails: func(r models.SecurityRule) (string, bool) {
+			return "the predefined rule allows all traffic within a zone", r.Action == "allow"
+		},
+	},
+	{
+		id: "log-end-off", title: "Rules that don't log at session end", severity: SeverityMedium,
+		fix:      "Enable Log at Session End so the traffic the rule matches is recorded.",
+		applies:  func(models.SecurityRule) bool { return true },
+		defaults: true,
+		fails: func(r models.SecurityRule) (string, bool) {
+			return "log at session end is off", !r.LogEnd
+		},
+	},
+	{
+		id: "no-log-forwarding", title: "Rules without log forwarding", severity: SeverityLow,
+		fix:      "Attach a log forwarding profile so the rule's logs reach Panorama, syslog, or your SIEM.",
+		applies:  func(models.SecurityRule) bool { return true },
+		defaults: true,
+		fails: func(r models.SecurityRule) (string, bool) {
+			return "no log forwarding profile", r.LogForwarding == ""
+		},
+	},
+}
+
+// allows reports whether r allows traffic.
+func allows(r models.SecurityRule) bool {
+	return r.Action == "allow"
+}
+
+// Fails reports whether r is an enabled rule that the rule check id
+// applies to and fails. It is false for an id that names no rule check.
+func Fails(id string, r models.SecurityRule) bool {
+	i := slices.IndexFunc(ruleChecks, func(rc ruleCheck) bool { return rc.id == id })
+	if i < 0 || r.Disabled || !ruleChecks[i].applies(r) {
+		return false
+	}
+	_, failed := ruleChecks[i].fails(r)
+	return failed
+}
+
+// IsAny reports whether a rule field matches anything.
+func IsAny(members []string) bool {
+	return len(members) == 0 || (len(members) == 1 && members[0] == "any")
+}
+
+// hasProfiles reports whether r attaches a profile group or any profile.
+func hasProfiles(r models.SecurityRule) bool {
+	return r.Profile != "" || r.AntivirusProfile != "" || r.VulnerabilityProfile != "" ||
+		r.SpywareProfile != "" || r.URLFilteringProfile != "" || r.FileBlockingProfile != "" ||
+		r.WildFireProfile != ""
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
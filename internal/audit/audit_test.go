@@ -0,0 +1,143 @@
+package audit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/models"
+	"github.com/jp2195/pyre/internal/testutil"
+)
+
+func TestCollect(t *testing.T) {
+	mock := testutil.NewMockPANOS()
+	defer mock.Close()
+	client, err := api.NewClient(mock.Host(), "test-api-key", api.ClientOptions{Insecure: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	in, err := Collect(context.Background(), client, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(in.Rules) == 0 || len(in.DefaultRules) != 2 {
+		t.Errorf("rules = %d, default rules = %d", len(in.Rules), len(in.DefaultRules))
+	}
+	res := Run(in, Options{})
+	// intrazone-default allows without profiles or log forwarding.
+	for _, id := range []string{"intrazone-default-allow", "no-security-profile", "no-log-forwarding"} {
+		if c, _ := res.Check(id); c.Failed == 0 {
+			t.Errorf("%s: %+v, want failures", id, c)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	good := models.SecurityRule{Name: "web", Action: "allow", Sources: []string{"10.0.0.0/8"}, Destinations: []string{"any"},
+		Applications: []string{"ssl"}, Services: []string{"application-default"}, Profile: "strict", LogEnd: true, LogForwarding: "siem"}
+	in := Input{
+		Rules: []models.SecurityRule{
+			good,
+			{Name: "wide-open", Action: "allow", LogEnd: true},
+			{Name: "ssh-any-port", Action: "allow", Sources: []string{"10.0.0.0/8"}, Applications: []string{"ssh"},
+				Services: []string{"tcp-2222"}, Profile: "strict", LogEnd: true, LogForwarding: "siem"},
+			{Name: "old", Action: "allow", Disabled: true},
+			{Name: "block", Action: "deny", LogEnd: true, LogForwarding: "siem"},
+		},
+		DefaultRules: []models.SecurityRule{
+			{Name: "intrazone-default", RuleType: models.RuleTypeIntrazone, Action: "allow"},
+			{Name: "interzone-default", RuleType: models.RuleTypeInterzone, Action: "deny", LogEnd: true, LogForwarding: "siem"},
+		},
+		Certificates: []models.Certificate{{Name: "old-ca", DaysLeft: -3}, {Name: "web", DaysLeft: 10}, {Name: "root", DaysLeft: 900}},
+	}
+	res := Run(in, Options{})
+
+	got := map[string][]string{}
+	for _, f := range res.Findings {
+		got[f.Check] = append(got[f.Check], f.Object)
+	}
+	want := map[string][]string{
+		"allow-any-any":           {"wide-open"},
+		"allow-any-address":       {"wide-open"},
+		"allow-any-application":   {"wide-open"},
+		"allow-any-service":       {"wide-open"},
+		"port-based-application":  {"ssh-any-port"},
+		"no-security-profile":     {"wide-open", "intrazone-default"},
+		"intrazone-default-allow": {"intrazone-default"},
+		"log-end-off":             {"intrazone-default"},
+		"no-log-forwarding":       {"wide-open", "intrazone-default"},
+		"certificate-expired":     {"old-ca"},
+		"certificate-expiring":    {"web"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("findings = %v, want %v", got, want)
+	}
+	for id, objects := range want {
+		if strings.Join(got[id], ",") != strings.Join(objects, ",") {
+			t.Errorf("%s = %v, want %v", id, got[id], objects)
+		}
+	}
+
+	for i := 1; i < len(res.Findings); i++ {
+		if res.Findings[i].Severity > res.Findings[i-1].Severity {
+			t.Fatalf("findings not sorted by severity: %+v", res.Findings)
+		}
+	}
+	if c, _ := res.Check("no-security-profile"); c.Checked != 4 {
+		t.Errorf("no-security-profile checked %d rules, want 4 (disabled rules skipped)", c.Checked)
+	}
+	if counts := res.Counts(); counts[SeverityCritical] != 2 {
+		t.Errorf("critical findings = %d, want 2", counts[SeverityCritical])
+	}
+	if res.Score <= 0 || res.Score >= 100 {
+		t.Errorf("score = %d, want between 0 and 100", res.Score)
+	}
+}
+
+func TestScore(t *testing.T) {
+	if got := Run(Input{}, Options{}).Score; got != 100 {
+		t.Errorf("empty device scored %d, want 100", got)
+	}
+	checks := []CheckResult{
+		{Severity: SeverityCritical, Checked: 2, Failed: 2},
+		{Severity: SeverityLow, Checked: 4},
+		{Severity: SeverityHigh}, // covers nothing
+	}
+	// 1 of 9 weighted points: critical weighs 8, low 1.
+	if got := score(checks); got != 11 {
+		t.Errorf("score = %d, want 11", got)
+	}
+}
+
+func TestExport(t *testing.T) {
+	res := Run(Input{Rules: []models.SecurityRule{{Name: "a|b", Action: "allow"}}}, Options{})
+	dir := filepath.Join(t.TempDir(), "audits")
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	path, err := Export(dir, "fw/edge", res, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(path) != "fw_edge-20260310-120000.md" {
+		t.Errorf("path = %s", path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+	data, _ := os.ReadFile(path)
+	for _, want := range []string{"# Best-practice audit: fw/edge", "Score: **", `| critical | Allow rules matching any traffic | a\|b |`, "## Remediation"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("export missing %q:\n%s", want, data)
+		}
+	}
+	if _, err := Export(dir, "fw/edge", res, now); err == nil {
+		t.Error("second export at the same time overwrote the first")
+	}
+}
@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jp2195/pyre/internal/devicefile"
+)
+
+// DefaultDir returns ~/.pyre/audits, where the TUI exports audits.
+func DefaultDir() (string, error) {
+	return devicefile.Dir("audits")
+}
+
+// Export writes r for device as Markdown to a new file under dir, named
+// for the device and time, and returns its path.
+func Export(dir, device string, r Result, now time.Time) (string, error) {
+	return devicefile.Write(dir, devicefile.Name(device, now, ".md"), func(w io.Writer) error {
+		return WriteMarkdown(w, device, r, now)
+	})
+}
+
+// WriteMarkdown writes r for device, audited at when, as a Markdown
+// document: the score, each check's pass rate, and every finding.
+func WriteMarkdown(w io.Writer, device string, r Result, when time.Time) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "# Best-practice audit: %s\n\n", device)
+	fmt.Fprintf(b, "Audited: %s  \nScore: **%d/100**\n\n", when.Format("2006-01-02 15:04 MST"), r.Score)
+	writeBody(b, r)
+	return b.Flush()
+}
+
+// writeBody writes r's checks, findings, and their fixes.
+func writeBody(w io.Writer, r Result) {
+	fmt.Fprint(w, "## Checks\n\n")
+	fmt.Fprintln(w, "| Check | Severity | Failed | Checked |")
+	fmt.Fprintln(w, "| --- | --- | --- | --- |")
+	for _, c := range r.Checks {
+		if c.Checked == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "| %s | %s | %d | %d |\n", devicefile.MarkdownCell(c.Title), c.Severity, c.Failed, c.Checked)
+	}
+
+	fmt.Fprint(w, "\n## Findings\n\n")
+	if len(r.Findings) == 0 {
+		fmt.Fprintln(w, "No findings.")
+		return
+	}
+	fmt.Fprintln(w, "| Severity | Check | Object | Detail |")
+	fmt.Fprintln(w, "| --- | --- | --- | --- |")
+	for _, f := range r.Findings {
+		fmt.Fprintf(w, "| %s | %s | %s | %s |\n", f.Severity, devicefile.MarkdownCell(f.Title), devicefile.MarkdownCell(f.Object), devicefile.MarkdownCell(f.Detail))
+	}
+
+	fmt.Fprint(w, "\n## Remediation\n\n")
+	for _, c := range r.Checks {
+		if c.Failed > 0 {
+			fmt.Fprintf(w, "- **%s:** %s\n", c.Title, c.Fix)
+		}
+	}
+}
@@ -2,8 +2,10 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"net/url"
 	"os"
 	"regexp"
 	"strings"
@@ -22,6 +24,7 @@ type Session struct {
 	ActiveFirewall string
 	Connections    map[string]*Connection
 	Config         *config.Config
+	reauth         chan *Connection // nil until EnableReauth
 }
 
 type Connection struct {
@@ -38,7 +41,8 @@ type Connection struct {
 	mu             sync.RWMutex
 	IsPanorama     bool
 	ManagedDevices []models.ManagedDevice
-	TargetSerial   string // Current target device serial (empty = Panorama itself)
+	TargetSerial   string      // Current target device serial (empty = Panorama itself)
+	reauthDone     chan string // non-nil while requests wait for FinishReauth
 }
 
 // SetPanoramaInfo records whether this connection is a Panorama.
@@ -102,6 +106,76 @@ func (s *Session) SetActiveFirewall(name string) bool {
 	return false
 }
 
+// ClientOptions returns the API client options conn configures. A pinned
+// connection gets the known_hosts store, read fresh so fingerprints
+// trusted since the last connect are seen.
+func ClientOptions(conn *config.ConnectionConfig) (api.ClientOptions, error) {
+	proxy, err := proxyURL(conn.Proxy)
+	if err != nil {
+		return api.ClientOptions{}, err
+	}
+	opts := api.ClientOptions{
+		Proxy:          proxy,
+		Insecure:       conn.Insecure,
+		CACertPath:     conn.CACertPath,
+		ClientCertPath: conn.ClientCertPath,
+		ClientKeyPath:  conn.ClientKeyPath,
+		AllowWrite:     conn.AllowWrite,
+		Vsys:           conn.Vsys,
+		Policy:         requestPolicy(conn.Limits),
+	}
+	if conn.Pin && !conn.Insecure {
+		path, err := config.KnownHostsPath()
+		if err != nil {
+			return opts, err
+		}
+		if opts.KnownHosts, err = api.LoadKnownHosts(path); err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+// requestPolicy overlays a connection's limits on api.DefaultRequestPolicy.
+func requestPolicy(l config.LimitsConfig) api.RequestPolicy {
+	p := api.DefaultRequestPolicy
+	if l.MaxConcurrent > 0 {
+		p.MaxConcurrent = l.MaxConcurrent
+	}
+	if l.Rate > 0 {
+		p.Rate = l.Rate
+		p.Burst = l.Burst
+	}
+	switch {
+	case l.Retries > 0:
+		p.Retries = l.Retries
+	case l.Retries < 0:
+		p.Retries = 0
+	}
+	return p
+}
+
+// proxyURL completes a connection's proxy URL with PYRE_PROXY_PASSWORD.
+// Like every other credential, a proxy password is refused in the config
+// file.
+func proxyURL(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		// api.NewTransport reports unparseable URLs.
+		return raw, nil
+	}
+	if _, set := u.User.Password(); set {
+		return "", errors.New("proxy: remove the password from ~/.pyre.yaml and set PYRE_PROXY_PASSWORD instead")
+	}
+	if password := os.Getenv("PYRE_PROXY_PASSWORD"); password != "" {
+		u.User = url.UserPassword(u.User.Username(), password)
+	}
+	return u.String(), nil
+}
+
 // AddConnection creates a new PAN-OS XML API connection for the given host.
 // It returns an error if the underlying API client cannot be constructed
 // (for example, a user-supplied CA bundle that cannot be loaded).
@@ -109,10 +183,11 @@ func (s *Session) AddConnection(host string, connConfig *config.ConnectionConfig
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	client, err := api.NewClient(host, apiKey, api.ClientOptions{
-		Insecure:   connConfig.Insecure,
-		CACertPath: connConfig.CACertPath,
-	})
+	opts, err := ClientOptions(connConfig)
+	if err != nil {
+		return nil, err
+	}
+	client, err := api.NewClient(host, apiKey, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -123,6 +198,9 @@ func (s *Session) AddConnection(host string, connConfig *config.ConnectionConfig
 		Client:    client,
 		Connected: true,
 	}
+	if s.reauth != nil {
+		client.SetReauth(s.reauthFunc(conn))
+	}
 	s.Connections[host] = conn
 
 	if s.ActiveFirewall == "" {
@@ -140,6 +218,8 @@ func (s *Session) RemoveConnection(host string) {
 	// caller might still hold. Credentials are never persisted anywhere,
 	// so this in-memory copy is the only one.
 	if conn, ok := s.Connections[host]; ok {
+		// Release requests still waiting on a re-login; they fail.
+		conn.FinishReauth("")
 		conn.APIKey = ""
 		if conn.Config != nil {
 			conn.Config.APIKey = ""
@@ -214,9 +294,15 @@ func ResolveCredentials(cfg *config.Config, flags config.CLIFlags) (*Credentials
 		creds.Insecure = true
 	}
 
-	// Config file defaults (if not set by flags or env)
+	// Config file defaults (if not set by flags or env). -c names the
+	// connection; otherwise the default is used.
 	if creds.Host == "" {
-		if host, conn, ok := cfg.GetDefaultConnection(); ok {
+		host, conn, ok := cfg.GetDefaultConnection()
+		if flags.Connection != "" {
+			host = flags.Connection
+			conn, ok = cfg.GetConnection(host)
+		}
+		if ok {
 			creds.Host = host
 			// Use config insecure if not already set by flags or env
 			if !creds.Insecure && conn.Insecure {
@@ -227,15 +313,26 @@ func ResolveCredentials(cfg *config.Config, flags config.CLIFlags) (*Credentials
 
 	// Host-based API key resolution order (documented in CLAUDE.md):
 	//   1. PYRE_<HOST>_API_KEY environment variable.
-	//   2. Fall through to PromptForPassword=true so the TUI prompts.
+	//   2. The connection's api_key_command, if configured.
+	//   3. Fall through to PromptForPassword=true so the TUI prompts.
 	// pyre does not persist credentials. Users manage them via env vars,
-	// CLI flags, or the interactive login flow (session-only).
+	// CLI flags, a credential helper, or the interactive login flow
+	// (session-only).
 	if creds.Host != "" && creds.APIKey == "" {
 		envName := normalizeHostForEnv(creds.Host)
 		if envKey := os.Getenv("PYRE_" + envName + "_API_KEY"); envKey != "" {
 			creds.APIKey = envKey
 		}
 	}
+	if creds.Host != "" && creds.APIKey == "" {
+		if conn, ok := cfg.GetConnection(creds.Host); ok && conn.APIKeyCommand != "" {
+			key, err := CommandAPIKey(context.Background(), creds.Host, conn.APIKeyCommand)
+			if err != nil {
+				return nil, err
+			}
+			creds.APIKey = key
+		}
+	}
 
 	// If we have host but no API key, signal that we need to prompt for password
 	if creds.Host != "" && creds.APIKey == "" {
@@ -266,6 +363,16 @@ func (c *Credentials) NeedsInteractiveAuth() bool {
 	return c.Host == "" || c.APIKey == ""
 }
 
+// EnvAPIKey returns the API key the environment supplies for host, in the
+// order ResolveCredentials applies: PYRE_API_KEY, then PYRE_<HOST>_API_KEY.
+// Headless commands use it where the TUI would prompt for a password.
+func EnvAPIKey(host string) string {
+	if key := os.Getenv("PYRE_API_KEY"); key != "" {
+		return key
+	}
+	return os.Getenv("PYRE_" + normalizeHostForEnv(host) + "_API_KEY")
+}
+
 // normalizeHostForEnv converts a connection host into an env-var-safe
 // suffix. Strips any :port (including bracketed IPv6 forms) and
 // replaces ".", "-", and ":" with "_" before uppercasing.
@@ -280,8 +387,9 @@ func normalizeHostForEnv(host string) string {
 	return strings.ToUpper(r.Replace(host))
 }
 
-// validateSerial checks if the serial number has a valid format.
-func validateSerial(serial string) error {
+// ValidateSerial checks if the serial number has a valid format. An empty
+// serial (no target) is valid.
+func ValidateSerial(serial string) error {
 	if serial == "" {
 		return nil
 	}
@@ -299,22 +407,24 @@ func validateSerial(serial string) error {
 // each API call that needs it (see Target()). The underlying *api.Client
 // holds no target state, which eliminates races when concurrent fetches
 // use different targets.
+//
+// Switching targets drops the client's response cache.
 func (c *Connection) SetTarget(device *models.ManagedDevice) error {
-	if device == nil {
-		c.mu.Lock()
-		c.TargetSerial = ""
-		c.mu.Unlock()
-		return nil
-	}
-
-	// Validate serial number format
-	if err := validateSerial(device.Serial); err != nil {
-		return err
+	serial := ""
+	if device != nil {
+		// Validate serial number format
+		if err := ValidateSerial(device.Serial); err != nil {
+			return err
+		}
+		serial = device.Serial
 	}
 
 	c.mu.Lock()
-	c.TargetSerial = device.Serial
+	c.TargetSerial = serial
 	c.mu.Unlock()
+	if c.Client != nil {
+		c.Client.InvalidateCache()
+	}
 	return nil
 }
 
@@ -3,6 +3,7 @@ package auth
 import (
 	"testing"
 
+	"github.com/jp2195/pyre/internal/api"
 	"github.com/jp2195/pyre/internal/config"
 )
 
@@ -46,3 +47,28 @@ func TestResolveCredentials_HostPortEnvVar(t *testing.T) {
 		t.Error("PromptForPassword should be false when env-var resolves the key")
 	}
 }
+
+func TestEnvAPIKey(t *testing.T) {
+	t.Setenv("PYRE_API_KEY", "")
+	t.Setenv("PYRE_FW1_EXAMPLE_COM_API_KEY", "per-host")
+	if got := EnvAPIKey("fw1.example.com:8443"); got != "per-host" {
+		t.Errorf("EnvAPIKey = %q, want per-host", got)
+	}
+	t.Setenv("PYRE_API_KEY", "global")
+	if got := EnvAPIKey("fw1.example.com"); got != "global" {
+		t.Errorf("EnvAPIKey = %q, want PYRE_API_KEY to win", got)
+	}
+	if got := EnvAPIKey("fw2.example.com"); got != "global" {
+		t.Errorf("EnvAPIKey(fw2) = %q, want global", got)
+	}
+}
+
+func TestRequestPolicy(t *testing.T) {
+	if got := requestPolicy(config.LimitsConfig{}); got != api.DefaultRequestPolicy {
+		t.Errorf("no limits = %+v, want the defaults", got)
+	}
+	got := requestPolicy(config.LimitsConfig{MaxConcurrent: 2, Rate: 5, Burst: 10, Retries: -1})
+	if got.MaxConcurrent != 2 || got.Rate != 5 || got.Burst != 10 || got.Retries != 0 {
+		t.Errorf("requestPolicy = %+v", got)
+	}
+}
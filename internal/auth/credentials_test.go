@@ -1,6 +1,7 @@
 package auth_test
 
 import (
+	"runtime"
 	"strings"
 	"testing"
 
@@ -147,3 +148,29 @@ func TestResolveCredentials_RejectsMalformedHost(t *testing.T) {
 		t.Errorf("err = %v, want to mention 'invalid host'", err)
 	}
 }
+
+// TestResolveCredentials_KeyCommand asserts that a connection's
+// api_key_command supplies the key when no flag or env var does.
+func TestResolveCredentials_KeyCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("helper command is sh syntax")
+	}
+	host := "helper.example.com"
+	t.Setenv("PYRE_API_KEY", "")
+	cfg := &config.Config{
+		Default: host,
+		Connections: map[string]config.ConnectionConfig{
+			host: {APIKeyCommand: "echo helper-key"},
+		},
+	}
+
+	creds := mustResolve(t, cfg, config.CLIFlags{})
+	if creds.APIKey != "helper-key" || creds.PromptForPassword {
+		t.Errorf("APIKey = %q, PromptForPassword = %v; want the helper's key", creds.APIKey, creds.PromptForPassword)
+	}
+
+	t.Setenv("PYRE_HELPER_EXAMPLE_COM_API_KEY", "env-key")
+	if creds := mustResolve(t, cfg, config.CLIFlags{}); creds.APIKey != "env-key" {
+		t.Errorf("APIKey = %q; the per-host env var should win over the helper", creds.APIKey)
+	}
+}
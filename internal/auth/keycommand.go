@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// KeyCommandTimeout bounds how long an api_key_command may run. Helpers
+// that unlock a vault may prompt (1Password, Vault's OIDC login), so this
+// is generous, but a hung helper must not hang pyre.
+const KeyCommandTimeout = 60 * time.Second
+
+// maxKeyCommandStderr caps how much of a failing helper's stderr is
+// quoted in the error.
+const maxKeyCommandStderr = 512
+
+// keyCommandCache holds the keys api_key_command helpers returned, keyed
+// by host and command, so each helper runs at most once per process. Like
+// every other credential, the keys are never written anywhere.
+var keyCommandCache = struct {
+	mu   sync.Mutex
+	keys map[string]string
+}{keys: make(map[string]string)}
+
+// CommandAPIKey runs command, the api_key_command configured for host,
+// and returns the API key it prints: the first line of its stdout. The
+// command runs through the shell (sh -c, or cmd /C on Windows) with
+// PYRE_HOST set to host, so one helper can serve several connections:
+//
+//	api_key_command: pass show "pyre/$PYRE_HOST"
+//
+// A successful result is cached for the rest of the session. On failure
+// the error quotes the helper's stderr.
+func CommandAPIKey(ctx context.Context, host, command string) (string, error) {
+	cacheKey := host + "\x00" + command
+	keyCommandCache.mu.Lock()
+	key, ok := keyCommandCache.keys[cacheKey]
+	keyCommandCache.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	key, err := runKeyCommand(ctx, host, command)
+	if err != nil {
+		return "", fmt.Errorf("api_key_command for %s: %w", host, err)
+	}
+
+	keyCommandCache.mu.Lock()
+	keyCommandCache.keys[cacheKey] = key
+	keyCommandCache.mu.Unlock()
+	return key, nil
+}
+
+func runKeyCommand(ctx context.Context, host, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, KeyCommandTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command) // #nosec G204 -- the command is the user's own config
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command) // #nosec G204 -- the command is the user's own config
+	}
+	cmd.Env = append(os.Environ(), "PYRE_HOST="+host)
+	// Stdin stays empty: inside the TUI, Bubble Tea owns the terminal.
+	// Helpers that need a passphrase ask through their own agent
+	// (gpg-agent's pinentry, the 1Password app).
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "", errors.New("timed out")
+	}
+	if err != nil {
+		if msg := helperStderr(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+
+	key, _, _ := strings.Cut(stdout.String(), "\n")
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", errors.New("printed no key")
+	}
+	return key, nil
+}
+
+// helperStderr condenses a helper's stderr to one line for an error
+// message.
+func helperStderr(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > maxKeyCommandStderr {
+		s = s[:maxKeyCommandStderr] + "..."
+	}
+	return s
+}
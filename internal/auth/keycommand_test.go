@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func skipOnWindows(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("helper commands in these tests are sh syntax")
+	}
+}
+
+func TestCommandAPIKey_FirstLineWithHost(t *testing.T) {
+	skipOnWindows(t)
+	key, err := CommandAPIKey(context.Background(), "fw1.example.com", `printf 'key-for-%s\nsecond line\n' "$PYRE_HOST"`)
+	if err != nil {
+		t.Fatalf("CommandAPIKey: %v", err)
+	}
+	if key != "key-for-fw1.example.com" {
+		t.Errorf("key = %q", key)
+	}
+}
+
+func TestCommandAPIKey_CachesPerSession(t *testing.T) {
+	skipOnWindows(t)
+	count := filepath.Join(t.TempDir(), "runs")
+	command := "echo run >> " + count + "; echo cached-key"
+	for range 3 {
+		if key, err := CommandAPIKey(context.Background(), "fw2.example.com", command); err != nil || key != "cached-key" {
+			t.Fatalf("CommandAPIKey = %q, %v", key, err)
+		}
+	}
+	runs, err := os.ReadFile(count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(runs), "run"); n != 1 {
+		t.Errorf("helper ran %d times; want once per session", n)
+	}
+}
+
+func TestCommandAPIKey_Errors(t *testing.T) {
+	skipOnWindows(t)
+	tests := []struct {
+		name    string
+		command string
+		want    string
+	}{
+		{"stderr", "echo 'vault: permission denied' >&2; exit 2", "vault: permission denied"},
+		{"no output", "true", "printed no key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CommandAPIKey(context.Background(), "fw3.example.com", tt.command)
+			if err == nil || !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), "fw3.example.com") {
+				t.Errorf("err = %v; want it to name the host and mention %q", err, tt.want)
+			}
+		})
+	}
+
+	// A failure is not cached: the next attempt runs the helper again.
+	if key, err := CommandAPIKey(context.Background(), "fw3.example.com", "true"); err == nil {
+		t.Errorf("failed helper was cached as %q", key)
+	}
+}
+
+func TestCommandAPIKey_Timeout(t *testing.T) {
+	skipOnWindows(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := CommandAPIKey(ctx, "fw4.example.com", "exec sleep 10")
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("err = %v; want a timeout", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("a hung helper should be killed at the deadline")
+	}
+}
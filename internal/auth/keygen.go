@@ -39,8 +39,12 @@ const maxKeygenResponseSize = 1 << 20
 // GenerateAPIKey performs the PAN-OS keygen exchange for host using the
 // supplied credentials. TLS behavior is governed by opts exactly as in
 // api.NewClient: verified by default, custom CA via opts.CACertPath
-// (fail-closed), or opts.Insecure to skip verification.
+// (fail-closed), a pinned fingerprint via opts.KnownHosts, or opts.Insecure
+// to skip verification.
 func GenerateAPIKey(ctx context.Context, host, username, password string, opts api.ClientOptions) (*KeygenResult, error) {
+	if opts.KnownHost == "" {
+		opts.KnownHost = host
+	}
 	tr, err := api.NewTransport(opts)
 	if err != nil {
 		return nil, fmt.Errorf("configuring keygen TLS: %w", err)
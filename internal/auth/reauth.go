@@ -0,0 +1,64 @@
+package auth
+
+// EnableReauth turns on re-login for connections added from now on: when
+// a device rejects a connection's API key, its requests pause and the
+// connection is sent on the returned channel. The receiver asks the user
+// to log in again and calls FinishReauth with the new key, or with "" to
+// give up, upon which the paused requests are retried or fail.
+//
+// Without EnableReauth, a rejected key fails requests straight away,
+// which suits callers with nobody to ask.
+func (s *Session) EnableReauth() <-chan *Connection {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.reauth == nil {
+		// One connection can wait in the channel while the receiver is
+		// busy; the rest wait in reauthFunc.
+		s.reauth = make(chan *Connection, 1)
+	}
+	return s.reauth
+}
+
+// reauthFunc is the client's ReauthFunc for conn: it hands conn to the
+// session's receiver and blocks until FinishReauth. A FinishReauth that
+// comes first, as when the connection is removed, also ends the wait for
+// the receiver, so a receiver that has stopped listening blocks nothing.
+func (s *Session) reauthFunc(conn *Connection) func() (string, error) {
+	return func() (string, error) {
+		done := make(chan string, 1)
+		conn.mu.Lock()
+		conn.reauthDone = done
+		conn.mu.Unlock()
+		select {
+		case s.reauth <- conn:
+		case key := <-done:
+			return key, nil
+		}
+		return <-done, nil
+	}
+}
+
+// ReauthPending reports whether the connection's requests are paused
+// waiting for FinishReauth.
+func (c *Connection) ReauthPending() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reauthDone != nil
+}
+
+// FinishReauth ends a pending re-login. A non-empty apiKey replaces the
+// connection's key and the paused requests are retried with it; "" gives
+// up and they fail with the original authentication error. It does
+// nothing when no re-login is pending.
+func (c *Connection) FinishReauth(apiKey string) {
+	c.mu.Lock()
+	done := c.reauthDone
+	c.reauthDone = nil
+	if done != nil && apiKey != "" {
+		c.APIKey = apiKey
+	}
+	c.mu.Unlock()
+	if done != nil {
+		done <- apiKey
+	}
+}
@@ -0,0 +1,117 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/auth"
+	"github.com/jp2195/pyre/internal/config"
+	"github.com/jp2195/pyre/internal/testutil"
+)
+
+func TestSession_ReauthSwapsKeyAndRetries(t *testing.T) {
+	mock := testutil.NewMockPANOS()
+	defer mock.Close()
+
+	session := auth.NewSession(config.DefaultConfig())
+	requests := session.EnableReauth()
+	conn, err := session.AddConnection(mock.Host(), &config.ConnectionConfig{Insecure: true}, "old-key")
+	if err != nil {
+		t.Fatalf("AddConnection: %v", err)
+	}
+	mock.Device.RevokeKeys()
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := conn.Client.GetSystemInfo(context.Background(), "")
+		result <- err
+	}()
+
+	select {
+	case got := <-requests:
+		if got != conn || !conn.ReauthPending() {
+			t.Fatalf("got %v, pending %v; want the connection awaiting re-login", got, conn.ReauthPending())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("rejected key did not ask for a re-login")
+	}
+
+	res, err := auth.GenerateAPIKey(context.Background(), mock.Host(), "admin", "admin", api.ClientOptions{Insecure: true})
+	if err != nil || res.Error != nil {
+		t.Fatalf("keygen: %v %v", err, res.Error)
+	}
+	conn.FinishReauth(res.APIKey)
+
+	if err := <-result; err != nil {
+		t.Errorf("paused request should succeed with the new key: %v", err)
+	}
+	if conn.APIKey != res.APIKey || conn.ReauthPending() {
+		t.Errorf("APIKey = %q, pending = %v; want the new key swapped in", conn.APIKey, conn.ReauthPending())
+	}
+}
+
+func TestSession_RemoveConnectionReleasesPausedRequests(t *testing.T) {
+	mock := testutil.NewMockPANOS()
+	defer mock.Close()
+
+	session := auth.NewSession(config.DefaultConfig())
+	requests := session.EnableReauth()
+	conn, err := session.AddConnection(mock.Host(), &config.ConnectionConfig{Insecure: true}, "old-key")
+	if err != nil {
+		t.Fatalf("AddConnection: %v", err)
+	}
+	mock.Device.RevokeKeys()
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := conn.Client.GetSystemInfo(context.Background(), "")
+		result <- err
+	}()
+	<-requests
+	session.RemoveConnection(mock.Host())
+
+	if err := <-result; !errors.Is(err, api.ErrUnauthorized) {
+		t.Errorf("err = %v; want ErrUnauthorized once the connection is gone", err)
+	}
+}
+
+func TestSession_RemoveConnectionReleasesUnreceivedReauth(t *testing.T) {
+	session := auth.NewSession(config.DefaultConfig())
+	session.EnableReauth() // nobody receives
+	var conns []*auth.Connection
+	var results []chan error
+	for range 2 {
+		mock := testutil.NewMockPANOS()
+		defer mock.Close()
+		conn, err := session.AddConnection(mock.Host(), &config.ConnectionConfig{Insecure: true}, "old-key")
+		if err != nil {
+			t.Fatalf("AddConnection: %v", err)
+		}
+		mock.Device.RevokeKeys()
+		result := make(chan error, 1)
+		go func() {
+			_, err := conn.Client.GetSystemInfo(context.Background(), "")
+			result <- err
+		}()
+		conns = append(conns, conn)
+		results = append(results, result)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !conns[0].ReauthPending() || !conns[1].ReauthPending() {
+		if time.Now().After(deadline) {
+			t.Fatal("requests never paused for a re-login")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for _, conn := range conns {
+		session.RemoveConnection(conn.Host)
+	}
+	for _, result := range results {
+		if err := <-result; !errors.Is(err, api.ErrUnauthorized) {
+			t.Errorf("err = %v; want ErrUnauthorized once the connection is gone", err)
+		}
+	}
+}
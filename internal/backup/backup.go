@@ -0,0 +1,242 @@
+// Package backup keeps point-in-time copies of device running configs on
+// local disk, one directory per device, so configs can be compared over time
+// without Panorama.
+package backup
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jp2195/pyre/internal/config"
+	"github.com/jp2195/pyre/internal/devicefile"
+	"github.com/jp2195/pyre/internal/models"
+	"github.com/jp2195/pyre/internal/pancfg"
+)
+
+// nameLayout is the file name of a version, minus the extension. It is UTC
+// and sorts lexically in time order.
+const nameLayout = "20060102T150405Z"
+
+const ext = ".xml"
+
+// Retention bounds how many versions Prune keeps for a device. The newest
+// version is always kept, whatever the policy.
+type Retention struct {
+	Keep   int           // versions to keep; 0 for no limit
+	MaxAge time.Duration // drop versions older than this; 0 for no limit
+}
+
+// Version is one stored config.
+type Version struct {
+	Device string
+	Time   time.Time
+	Path   string
+	Size   int64
+}
+
+// Name identifies the version within its device, e.g. "20250121T090000Z".
+func (v Version) Name() string {
+	return v.Time.UTC().Format(nameLayout)
+}
+
+// Store is a directory of per-device version histories.
+type Store struct {
+	dir string
+}
+
+// DefaultDir returns ~/.pyre/backups.
+func DefaultDir() (string, error) {
+	return devicefile.Dir("backups")
+}
+
+// NewStore returns a store rooted at dir. Nothing is created until the first
+// Save.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// FromSettings returns the store and retention policy the config describes.
+func FromSettings(cfg config.BackupSettings) (*Store, Retention, error) {
+	dir := cfg.Dir
+	if dir == "" {
+		var err error
+		if dir, err = DefaultDir(); err != nil {
+			return nil, Retention{}, err
+		}
+	}
+	r := Retention{Keep: cfg.Keep, MaxAge: time.Duration(cfg.MaxAgeDays) * 24 * time.Hour}
+	return NewStore(dir), r, nil
+}
+
+// Dir returns the store's root directory.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// deviceDir maps a device name (see devicefile.Device) to its directory.
+func (s *Store) deviceDir(device string) string {
+	return filepath.Join(s.dir, devicefile.SafeName(device))
+}
+
+// Save stores data, a running config, as the device's version at time at,
+// which is truncated to the second.
+func (s *Store) Save(device string, data []byte, at time.Time) (Version, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return Version{}, errors.New("empty config")
+	}
+	dir := s.deviceDir(device)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return Version{}, fmt.Errorf("create backup directory: %w", err)
+	}
+	v := Version{Device: device, Time: at.UTC().Truncate(time.Second), Size: int64(len(data))}
+	v.Path = filepath.Join(dir, v.Name()+ext)
+
+	// Write to a temp file and rename so a version is never half-written.
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return Version{}, fmt.Errorf("write backup: %w", err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // gone after a successful rename
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close() //nolint:errcheck // write error takes precedence
+		return Version{}, fmt.Errorf("write backup: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return Version{}, fmt.Errorf("write backup: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), v.Path); err != nil {
+		return Version{}, fmt.Errorf("write backup: %w", err)
+	}
+	return v, nil
+}
+
+// List returns the device's versions, newest first. A device with no
+// backups has none.
+func (s *Store) List(device string) ([]Version, error) {
+	entries, err := os.ReadDir(s.deviceDir(device))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var versions []Version
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ext)
+		if !ok || e.IsDir() {
+			continue
+		}
+		t, err := time.Parse(nameLayout, name)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		versions = append(versions, Version{
+			Device: device,
+			Time:   t,
+			Path:   filepath.Join(s.deviceDir(device), e.Name()),
+			Size:   info.Size(),
+		})
+	}
+	slices.SortFunc(versions, func(a, b Version) int { return b.Time.Compare(a.Time) })
+	return versions, nil
+}
+
+// Devices lists the device directories in the store, sorted. The names are
+// the file-safe forms, which List and Save accept in place of the original.
+func (s *Store) Devices() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var devices []string
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+			devices = append(devices, e.Name())
+		}
+	}
+	return devices, nil
+}
+
+// Find returns the device's version with the given name.
+func (s *Store) Find(device, name string) (Version, error) {
+	versions, err := s.List(device)
+	if err != nil {
+		return Version{}, err
+	}
+	for _, v := range versions {
+		if v.Name() == name {
+			return v, nil
+		}
+	}
+	return Version{}, fmt.Errorf("no backup %q for %s", name, device)
+}
+
+// Read returns a version's config.
+func (s *Store) Read(v Version) ([]byte, error) {
+	return os.ReadFile(v.Path) // #nosec G304 -- Path comes from List/Save under the store root
+}
+
+// Prune deletes the device's versions that fall outside r, returning what it
+// removed.
+func (s *Store) Prune(device string, r Retention, now time.Time) ([]Version, error) {
+	versions, err := s.List(device)
+	if err != nil {
+		return nil, err
+	}
+	var removed []Version
+	for i, v := range versions {
+		if i == 0 {
+			continue
+		}
+		tooMany := r.Keep > 0 && i >= r.Keep
+		tooOld := r.MaxAge > 0 && now.Sub(v.Time) > r.MaxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := os.Remove(v.Path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, v)
+	}
+	return removed, nil
+}
+
+// Diff compares two versions, old first, and returns one hunk per changed
+// object (see pancfg.Diff).
+func (s *Store) Diff(older, newer Version) ([]models.ConfigHunk, error) {
+	a, err := s.Parse(older)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.Parse(newer)
+	if err != nil {
+		return nil, err
+	}
+	return pancfg.Diff(a, b), nil
+}
+
+// Parse reads version v and parses it into a config tree.
+func (s *Store) Parse(v Version) (*pancfg.Node, error) {
+	data, err := s.Read(v)
+	if err != nil {
+		return nil, err
+	}
+	root, err := pancfg.Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("backup %s: %w", v.Name(), err)
+	}
+	return root, nil
+}
@@ -0,0 +1,150 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jp2195/pyre/internal/config"
+	"github.com/jp2195/pyre/internal/devicefile"
+)
+
+const (
+	configV1 = `<config><devices><entry name="localhost.localdomain"><vsys><entry name="vsys1"><address>` +
+		`<entry name="web"><ip-netmask>10.0.0.1/32</ip-netmask></entry>` +
+		`</address></entry></vsys></entry></devices></config>`
+	configV2 = `<config><devices><entry name="localhost.localdomain"><vsys><entry name="vsys1"><address>` +
+		`<entry name="web"><ip-netmask>10.0.0.2/32</ip-netmask></entry>` +
+		`<entry name="db"><ip-netmask>10.0.1.5/32</ip-netmask></entry>` +
+		`</address></entry></vsys></entry></devices></config>`
+)
+
+var t0 = time.Date(2025, 1, 21, 9, 0, 0, 0, time.UTC)
+
+func TestStore_SaveListRead(t *testing.T) {
+	s := NewStore(t.TempDir())
+	if versions, err := s.List("fw1"); err != nil || versions != nil {
+		t.Fatalf("List before any backup = %v, %v; want none", versions, err)
+	}
+
+	if _, err := s.Save("fw1", []byte(configV1), t0); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	v2, err := s.Save("fw1", []byte(configV2), t0.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if v2.Name() != "20250121T100000Z" {
+		t.Errorf("Name() = %q", v2.Name())
+	}
+	if info, err := os.Stat(v2.Path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("backup file mode = %v, %v; want 0600", info.Mode().Perm(), err)
+	}
+
+	versions, err := s.List("fw1")
+	if err != nil || len(versions) != 2 {
+		t.Fatalf("List = %v, %v; want 2 versions", versions, err)
+	}
+	if !versions[0].Time.Equal(v2.Time) {
+		t.Errorf("List not newest first: %v", versions)
+	}
+	data, err := s.Read(versions[1])
+	if err != nil || string(data) != configV1 {
+		t.Errorf("Read oldest = %q, %v", data, err)
+	}
+
+	if _, err := s.Find("fw1", "20250121T090000Z"); err != nil {
+		t.Errorf("Find: %v", err)
+	}
+	if _, err := s.Find("fw1", "20250101T000000Z"); err == nil {
+		t.Error("Find of a missing version should fail")
+	}
+}
+
+func TestStore_DeviceDirIsFileSafe(t *testing.T) {
+	s := NewStore(t.TempDir())
+	v, err := s.Save(devicefile.Device("[2001:db8::1]:8443", "007951000123456"), []byte(configV1), t0)
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	dir := filepath.Base(filepath.Dir(v.Path))
+	if strings.ContainsAny(dir, ":[]/") {
+		t.Errorf("device directory %q is not file-name safe", dir)
+	}
+	if versions, _ := s.List(v.Device); len(versions) != 1 {
+		t.Errorf("List(%q) = %v", v.Device, versions)
+	}
+	devices, err := s.Devices()
+	if err != nil || len(devices) != 1 || devices[0] != dir {
+		t.Fatalf("Devices() = %v, %v; want [%s]", devices, err, dir)
+	}
+	if versions, _ := s.List(devices[0]); len(versions) != 1 {
+		t.Errorf("List of the file-safe name = %v; want the same history", versions)
+	}
+}
+
+func TestStore_SaveRejectsEmpty(t *testing.T) {
+	s := NewStore(t.TempDir())
+	if _, err := s.Save("fw1", []byte("  \n"), t0); err == nil {
+		t.Error("expected an empty config to be rejected")
+	}
+}
+
+func TestStore_Prune(t *testing.T) {
+	s := NewStore(t.TempDir())
+	for i := range 5 {
+		if _, err := s.Save("fw1", []byte(configV1), t0.Add(time.Duration(i)*24*time.Hour)); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+	now := t0.Add(4 * 24 * time.Hour)
+
+	removed, err := s.Prune("fw1", Retention{Keep: 4}, now)
+	if err != nil || len(removed) != 1 || !removed[0].Time.Equal(t0) {
+		t.Fatalf("Prune(Keep 4) removed %v, %v; want the oldest", removed, err)
+	}
+
+	removed, err = s.Prune("fw1", Retention{MaxAge: 36 * time.Hour}, now)
+	if err != nil || len(removed) != 2 {
+		t.Fatalf("Prune(MaxAge 36h) removed %v, %v; want 2", removed, err)
+	}
+
+	// The newest version survives even when everything is too old.
+	if _, err := s.Prune("fw1", Retention{MaxAge: time.Minute}, now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if versions, _ := s.List("fw1"); len(versions) != 1 || !versions[0].Time.Equal(now) {
+		t.Errorf("after pruning everything old, versions = %v; want only the newest", versions)
+	}
+}
+
+func TestStore_Diff(t *testing.T) {
+	s := NewStore(t.TempDir())
+	old, _ := s.Save("fw1", []byte(configV1), t0)
+	cur, _ := s.Save("fw1", []byte(configV2), t0.Add(time.Hour))
+
+	hunks, err := s.Diff(old, cur)
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	kinds := map[string]string{}
+	for _, h := range hunks {
+		kinds[h.XPath[strings.LastIndex(h.XPath, "/")+1:]] = h.Kind
+	}
+	if kinds["entry[@name='web']"] != "modified" || kinds["entry[@name='db']"] != "added" || len(hunks) != 2 {
+		t.Errorf("hunks = %v", kinds)
+	}
+}
+
+func TestFromSettings(t *testing.T) {
+	dir := t.TempDir()
+	s, r, err := FromSettings(config.BackupSettings{Dir: dir, Keep: 10, MaxAgeDays: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Dir() != dir || r.Keep != 10 || r.MaxAge != 7*24*time.Hour {
+		t.Errorf("FromSettings = %q, %+v", s.Dir(), r)
+	}
+}
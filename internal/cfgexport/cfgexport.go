@@ -0,0 +1,450 @@
+// Package cfgexport renders rules and objects from a firewall's running
+// config, together with the objects they depend on, as PAN-OS CLI set
+// commands or as an XML fragment for load config partial, so policy can be
+// carried to another firewall without retyping it.
+package cfgexport
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/pancfg"
+)
+
+// Kind is the type of a selected rule or object.
+type Kind int
+
+const (
+	KindSecurityRule Kind = iota + 1
+	KindNATRule
+	KindAddress
+	KindService
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindSecurityRule:
+		return "security rule"
+	case KindNATRule:
+		return "NAT rule"
+	case KindAddress:
+		return "address"
+	case KindService:
+		return "service"
+	}
+	return "unknown"
+}
+
+// Item names one selected rule or object.
+type Item struct {
+	Kind Kind
+	Name string
+}
+
+// Format is the form an export is written in.
+type Format int
+
+const (
+	FormatSet Format = iota // CLI set commands
+	FormatXML               // a <config> fragment for load config partial
+)
+
+func (f Format) String() string {
+	if f == FormatXML {
+		return "XML"
+	}
+	return "set commands"
+}
+
+// ext is the file extension for an export in f.
+func (f Format) ext() string {
+	if f == FormatXML {
+		return ".xml"
+	}
+	return ".set"
+}
+
+// objectKinds are the object types an export carries along, in the order
+// they load: each refers only to kinds before it (or, for groups, to other
+// entries of its own kind, which are ordered members first).
+var objectKinds = []string{
+	"tag",
+	"address",
+	"region",
+	"address-group",
+	"external-list",
+	"service",
+	"service-group",
+	"application",
+	"application-filter",
+	"application-group",
+	"schedule",
+}
+
+// skipRefs are elements whose members never name an object an export
+// carries: zones, users, URL categories, HIP profiles, and security
+// profiles, which the target firewall must already have.
+var skipRefs = map[string]bool{
+	"from":              true,
+	"to":                true,
+	"source-user":       true,
+	"category":          true,
+	"source-hip":        true,
+	"destination-hip":   true,
+	"hip-profiles":      true,
+	"profile-setting":   true,
+	"description":       true,
+	"to-interface":      true,
+	"interface":         true,
+	"interface-address": true,
+}
+
+// refLeaves are text elements that name a single object, such as a NAT
+// rule's service or a rule's schedule.
+var refLeaves = map[string]bool{
+	"service":            true,
+	"schedule":           true,
+	"translated-address": true,
+	"group-tag":          true,
+}
+
+// step is one element on the way from <config> to a container.
+type step struct {
+	tag  string
+	name string
+}
+
+// vsysSteps lead to vsys.
+func vsysSteps(vsys string) []step {
+	return []step{{"devices", ""}, {"entry", "localhost.localdomain"}, {"vsys", ""}, {"entry", vsys}}
+}
+
+// scopes are where an object is looked for, the vsys first: where both
+// hold a name, the vsys object is the one rules resolve to.
+func (b *builder) scopes() [][]step {
+	return [][]step{vsysSteps(b.vsys), {{"shared", ""}}}
+}
+
+func xpathOf(steps []step) string {
+	var b strings.Builder
+	b.WriteString("/config")
+	for _, s := range steps {
+		b.WriteString("/" + s.tag)
+		if s.name != "" {
+			b.WriteString("[@name='" + s.name + "']")
+		}
+	}
+	return b.String()
+}
+
+// Bundle is a selection resolved against a config: the selected entries
+// and everything they depend on, in the order they must load.
+type Bundle struct {
+	root       *pancfg.Node
+	paths      [][]*pancfg.Node // from root down to each entry, in load order
+	containers []string         // xpath of each container, in load order
+}
+
+// Len is the number of entries in the bundle, dependencies included.
+func (b *Bundle) Len() int {
+	return len(b.paths)
+}
+
+// SetCommands renders the bundle as CLI set commands, one per line, to be
+// pasted in configuration mode. Commands for a vsys name it, so they load
+// into the same vsys on the target. It fails if an entry holds a value
+// that no CLI quoting carries (see pancfg.Quotable), which the XML format
+// keeps.
+func (b *Bundle) SetCommands() ([]string, error) {
+	var out []string
+	for _, path := range b.paths {
+		entry := path[len(path)-1]
+		if !quotable(entry) {
+			return nil, fmt.Errorf("%s %q has a line break or both kinds of quote in a value, "+
+				"which a set command cannot carry; export as XML instead", path[len(path)-2].XMLName.Local, entry.Name())
+		}
+		for _, l := range pancfg.VsysSetLines(path) {
+			out = append(out, l.Text)
+		}
+	}
+	return out, nil
+}
+
+// quotable reports whether every name and value under n can be written in
+// a set command.
+func quotable(n *pancfg.Node) bool {
+	if !pancfg.Quotable(n.Name()) || !pancfg.Quotable(n.Text) {
+		return false
+	}
+	for _, c := range n.Children {
+		if !quotable(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// XML renders the bundle as a <config> document holding only the exported
+// entries, headed by the load config partial commands that merge it, part
+// by part, once imported to the firewall as file.
+func (b *Bundle) XML(file string) []string {
+	out := []string{
+		"<!--",
+		"  Import this file to the firewall as a named configuration snapshot,",
+		"  then merge it into the candidate configuration in this order:",
+		"",
+	}
+	for _, xpath := range b.containers {
+		// from-xpath is relative to the file's <config>; to-xpath is absolute.
+		cmd := fmt.Sprintf("  load config partial from %s from-xpath %s to-xpath %s mode merge",
+			file, strings.TrimPrefix(xpath, "/config/"), xpath)
+		out = append(out, strings.ReplaceAll(cmd, "--", "- -")) // "--" would end the comment
+	}
+	out = append(out, "-->")
+	for _, l := range pancfg.XMLLines(b.root, 0) {
+		out = append(out, strings.Repeat("  ", l.Depth)+l.Text)
+	}
+	return out
+}
+
+// Collect reads the running config of target and resolves items against
+// it, in the client's vsys.
+func Collect(ctx context.Context, c *api.Client, target string, items []Item) (*Bundle, error) {
+	doc, err := c.GetRunningConfig(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	root, err := pancfg.Parse(string(doc))
+	if err != nil {
+		return nil, err
+	}
+	return Build(root, c.Vsys(), items)
+}
+
+// placed is an entry found in the source config, bound for a container of
+// the bundle.
+type placed struct {
+	rank      int // load stage: an objectKinds index, then security and NAT rules
+	container []step
+	entry     *pancfg.Node
+}
+
+type builder struct {
+	root   *pancfg.Node
+	vsys   string
+	seen   map[string]bool
+	placed []placed
+}
+
+// Build resolves items against root, a <config> document, and gathers the
+// objects they refer to, recursively. A selected item that root lacks is
+// an error; a referenced name that matches no object is taken to be
+// predefined (an application, "any", service-http) and left out.
+//
+// Objects keep their scope, vsys or shared. Rules from any rulebase,
+// including rules pushed from Panorama, are exported to the local
+// rulebase of vsys, in rulebase order.
+func Build(root *pancfg.Node, vsys string, items []Item) (*Bundle, error) {
+	b := &builder{root: root, vsys: vsys, seen: map[string]bool{}}
+	rules := map[Kind][]string{}
+	var missing []string
+	for _, it := range items {
+		switch it.Kind {
+		case KindSecurityRule, KindNATRule:
+			rules[it.Kind] = append(rules[it.Kind], it.Name)
+		case KindAddress, KindService:
+			element := "address"
+			if it.Kind == KindService {
+				element = "service"
+			}
+			if !b.addObject(element, it.Name) {
+				missing = append(missing, it.Kind.String()+" "+it.Name)
+			}
+		}
+	}
+	for i, kind := range []Kind{KindSecurityRule, KindNATRule} {
+		for _, name := range b.addRules(kind, rules[kind], len(objectKinds)+i) {
+			missing = append(missing, kind.String()+" "+name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("not in the running config: %s", strings.Join(missing, ", "))
+	}
+	return b.bundle(), nil
+}
+
+// addRules adds the rules of kind named in names, in rulebase order, and
+// returns the names not found.
+func (b *builder) addRules(kind Kind, names []string, rank int) []string {
+	if len(names) == 0 {
+		return nil
+	}
+	element := "security"
+	if kind == KindNATRule {
+		element = "nat"
+	}
+	wanted := map[string]bool{}
+	for _, n := range names {
+		wanted[n] = true
+	}
+	container := append(vsysSteps(b.vsys), step{"rulebase", ""}, step{element, ""}, step{"rules", ""})
+	for _, location := range []string{"pre-rulebase", "rulebase", "post-rulebase"} {
+		for _, xpath := range api.RulebasePaths(b.vsys, location, element) {
+			nodes, err := pancfg.Select(b.root, xpath)
+			if err != nil || len(nodes) == 0 {
+				continue
+			}
+			for _, e := range nodes[0].Children {
+				if wanted[e.Name()] {
+					delete(wanted, e.Name())
+					b.add(rank, container, e)
+				}
+			}
+			break
+		}
+	}
+	var missing []string
+	for _, n := range names {
+		if wanted[n] {
+			missing = append(missing, n)
+			delete(wanted, n)
+		}
+	}
+	return missing
+}
+
+// addObject adds the object of element named name, looked up in the vsys
+// and then shared, and reports whether there is one.
+func (b *builder) addObject(element, name string) bool {
+	rank := slices.Index(objectKinds, element)
+	for _, scope := range b.scopes() {
+		container := append(slices.Clone(scope), step{element, ""})
+		nodes, err := pancfg.Select(b.root, xpathOf(container))
+		if err != nil || len(nodes) == 0 {
+			continue
+		}
+		for _, e := range nodes[0].Children {
+			if e.XMLName.Local == "entry" && e.Name() == name {
+				b.add(rank, container, e)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// add places entry after the objects it refers to. Each entry is placed
+// once, which also ends reference cycles.
+func (b *builder) add(rank int, container []step, entry *pancfg.Node) {
+	key := xpathOf(container) + "\x00" + entry.Name()
+	if b.seen[key] {
+		return
+	}
+	b.seen[key] = true
+	var refs []string
+	references(entry, &refs)
+	for _, ref := range refs {
+		for _, element := range objectKinds {
+			b.addObject(element, ref)
+		}
+	}
+	b.placed = append(b.placed, placed{rank: rank, container: container, entry: entry})
+}
+
+// references collects the names n may refer to: its members, the text of
+// elements that name one object, and the tags of a dynamic group filter.
+func references(n *pancfg.Node, out *[]string) {
+	for _, c := range n.Children {
+		tag := c.XMLName.Local
+		switch {
+		case skipRefs[tag]:
+		case len(c.Children) > 0:
+			references(c, out)
+		case tag == "member", refLeaves[tag]:
+			if c.Text != "" {
+				*out = append(*out, c.Text)
+			}
+		case tag == "filter":
+			*out = append(*out, filterTags(c.Text)...)
+		}
+	}
+}
+
+// filterTags are the tag names in a dynamic address group's match
+// expression, such as "'web' and ('prod' or dmz)".
+func filterTags(filter string) []string {
+	var tags []string
+	for _, f := range strings.FieldsFunc(filter, func(r rune) bool {
+		return r == '(' || r == ')' || r == ' ' || r == '\t'
+	}) {
+		switch f = strings.Trim(f, `'"`); strings.ToLower(f) {
+		case "", "and", "or", "not":
+		default:
+			tags = append(tags, f)
+		}
+	}
+	return tags
+}
+
+// bundle copies the placed entries, in load order, into a new <config>.
+func (b *builder) bundle() *Bundle {
+	slices.SortStableFunc(b.placed, func(x, y placed) int { return x.rank - y.rank })
+	out := &Bundle{root: &pancfg.Node{XMLName: xml.Name{Local: "config"}}}
+	if v, ok := b.root.Attr("version"); ok {
+		out.root.Attrs = []xml.Attr{{Name: xml.Name{Local: "version"}, Value: v}}
+	}
+	for _, p := range b.placed {
+		path := []*pancfg.Node{out.root}
+		for _, s := range p.container {
+			path = append(path, child(path[len(path)-1], s))
+		}
+		if xpath := xpathOf(p.container); !slices.Contains(out.containers, xpath) {
+			out.containers = append(out.containers, xpath)
+		}
+		entry := portable(p.entry)
+		parent := path[len(path)-1]
+		parent.Children = append(parent.Children, entry)
+		out.paths = append(out.paths, append(path, entry))
+	}
+	return out
+}
+
+// child returns the child of n that s names, creating it if need be.
+func child(n *pancfg.Node, s step) *pancfg.Node {
+	for _, c := range n.Children {
+		if c.XMLName.Local == s.tag && c.Name() == s.name {
+			return c
+		}
+	}
+	c := &pancfg.Node{XMLName: xml.Name{Local: s.tag}}
+	if s.name != "" {
+		c.Attrs = []xml.Attr{{Name: xml.Name{Local: "name"}, Value: s.name}}
+	}
+	n.Children = append(n.Children, c)
+	return c
+}
+
+// portable copies n without the attributes that belong to the source
+// firewall, such as rule UUIDs, keeping only names.
+func portable(n *pancfg.Node) *pancfg.Node {
+	c := n.Clone()
+	var strip func(*pancfg.Node)
+	strip = func(n *pancfg.Node) {
+		var kept []xml.Attr
+		for _, a := range n.Attrs {
+			if a.Name.Local == "name" {
+				kept = append(kept, a)
+			}
+		}
+		n.Attrs = kept
+		for _, ch := range n.Children {
+			strip(ch)
+		}
+	}
+	strip(c)
+	return c
+}
@@ -0,0 +1,246 @@
+package cfgexport
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jp2195/pyre/internal/pancfg"
+)
+
+const testConfig = `<config version="11.1.0">
+  <shared>
+    <address>
+      <entry name="dns"><ip-netmask>10.0.0.53</ip-netmask></entry>
+      <entry name="web-1"><ip-netmask>192.0.2.99</ip-netmask></entry>
+    </address>
+  </shared>
+  <devices><entry name="localhost.localdomain"><vsys><entry name="vsys1">
+    <tag>
+      <entry name="prod web"><color>color1</color></entry>
+      <entry name="unused"/>
+    </tag>
+    <address>
+      <entry name="web-1">
+        <ip-netmask>10.1.1.1</ip-netmask>
+        <description>Say "hi" &amp; &lt;bye&gt;</description>
+        <tag><member>prod web</member></tag>
+      </entry>
+      <entry name="web-2"><fqdn>web2.example.com</fqdn></entry>
+      <entry name="idle"><ip-netmask>10.9.9.9</ip-netmask></entry>
+    </address>
+    <address-group>
+      <entry name="web-all"><static><member>web-inner</member><member>dns</member></static></entry>
+      <entry name="web-inner"><static><member>web-1</member><member>web-2</member></static></entry>
+      <entry name="tagged"><dynamic><filter>'prod web' and not 'unknown'</filter></dynamic></entry>
+    </address-group>
+    <service>
+      <entry name="tcp-8443"><protocol><tcp><port>8443</port></tcp></protocol></entry>
+    </service>
+    <service-group>
+      <entry name="web-ports"><members><member>tcp-8443</member><member>service-https</member></members></entry>
+    </service-group>
+    <rulebase>
+      <security><rules>
+        <entry name="allow web" uuid="1111">
+          <from><member>web-2</member></from>
+          <to><member>dmz</member></to>
+          <source><member>any</member></source>
+          <destination><member>web-all</member><member>tagged</member></destination>
+          <application><member>ssl</member></application>
+          <service><member>web-ports</member></service>
+          <action>allow</action>
+        </entry>
+        <entry name="other" uuid="2222"><action>deny</action></entry>
+      </rules></security>
+      <nat><rules>
+        <entry name="web-in" uuid="3333">
+          <service>tcp-8443</service>
+          <destination><member>web-2</member></destination>
+          <destination-translation><translated-address>web-1</translated-address></destination-translation>
+        </entry>
+      </rules></nat>
+    </rulebase>
+  </entry></vsys></entry></devices>
+</config>`
+
+func mustBuild(t *testing.T, items ...Item) *Bundle {
+	t.Helper()
+	root, err := pancfg.Parse(testConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Build(root, "vsys1", items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestBuild_RuleDependencies(t *testing.T) {
+	b := mustBuild(t, Item{KindNATRule, "web-in"}, Item{KindSecurityRule, "allow web"})
+	got, err := b.SetCommands()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`set vsys vsys1 tag "prod web" color color1`,
+		`set vsys vsys1 address web-1 ip-netmask 10.1.1.1`,
+		`set vsys vsys1 address web-1 description 'Say "hi" & <bye>'`,
+		`set vsys vsys1 address web-1 tag "prod web"`,
+		`set vsys vsys1 address web-2 fqdn web2.example.com`,
+		`set shared address dns ip-netmask 10.0.0.53`,
+		`set vsys vsys1 address-group web-inner static [ web-1 web-2 ]`,
+		`set vsys vsys1 address-group web-all static [ web-inner dns ]`,
+		`set vsys vsys1 address-group tagged dynamic filter "'prod web' and not 'unknown'"`,
+		`set vsys vsys1 service tcp-8443 protocol tcp port 8443`,
+		`set vsys vsys1 service-group web-ports members [ tcp-8443 service-https ]`,
+		`set vsys vsys1 rulebase security rules "allow web" from web-2`,
+		`set vsys vsys1 rulebase security rules "allow web" to dmz`,
+		`set vsys vsys1 rulebase security rules "allow web" source any`,
+		`set vsys vsys1 rulebase security rules "allow web" destination [ web-all tagged ]`,
+		`set vsys vsys1 rulebase security rules "allow web" application ssl`,
+		`set vsys vsys1 rulebase security rules "allow web" service web-ports`,
+		`set vsys vsys1 rulebase security rules "allow web" action allow`,
+		`set vsys vsys1 rulebase nat rules web-in service tcp-8443`,
+		`set vsys vsys1 rulebase nat rules web-in destination web-2`,
+		`set vsys vsys1 rulebase nat rules web-in destination-translation translated-address web-1`,
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("set commands:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if b.Len() != 11 {
+		t.Errorf("Len = %d, want 11", b.Len())
+	}
+}
+
+func TestBuild_XML(t *testing.T) {
+	b := mustBuild(t, Item{KindAddress, "web-1"}, Item{KindService, "tcp-8443"})
+	doc := strings.Join(b.XML("fw.xml"), "\n")
+	for _, want := range []string{
+		"load config partial from fw.xml from-xpath devices/entry[@name='localhost.localdomain']/vsys/entry[@name='vsys1']/tag " +
+			"to-xpath /config/devices/entry[@name='localhost.localdomain']/vsys/entry[@name='vsys1']/tag mode merge",
+		`<description>Say &quot;hi&quot; &amp; &lt;bye&gt;</description>`,
+		`<config version="11.1.0">`,
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("XML lacks %q:\n%s", want, doc)
+		}
+	}
+	if strings.Index(doc, "/tag mode") > strings.Index(doc, "/address mode") {
+		t.Errorf("tags must load before the addresses that use them:\n%s", doc)
+	}
+	if strings.Contains(doc, "idle") || strings.Contains(doc, "unused") || strings.Contains(doc, "192.0.2.99") {
+		t.Errorf("XML holds entries nothing refers to, or the shadowed shared address:\n%s", doc)
+	}
+
+	_, body, _ := strings.Cut(doc, "-->\n")
+	root, err := pancfg.Parse(body)
+	if err != nil {
+		t.Fatalf("fragment does not parse: %v", err)
+	}
+	nodes, err := pancfg.Select(root, "/config/devices/entry/vsys/entry/address/entry")
+	if err != nil || len(nodes) != 1 {
+		t.Fatalf("addresses = %d, %v", len(nodes), err)
+	}
+	if desc := nodes[0].Children[1].Text; desc != `Say "hi" & <bye>` {
+		t.Errorf("description = %q", desc)
+	}
+}
+
+func TestBuild_DropsUUIDs(t *testing.T) {
+	b := mustBuild(t, Item{KindSecurityRule, "other"})
+	if doc := strings.Join(b.XML("x.xml"), "\n"); strings.Contains(doc, "uuid") {
+		t.Errorf("rule keeps its UUID:\n%s", doc)
+	}
+}
+
+func TestBuild_Missing(t *testing.T) {
+	root, err := pancfg.Parse(testConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Build(root, "vsys1", []Item{{KindSecurityRule, "gone"}, {KindAddress, "web-1"}, {KindService, "nope"}})
+	if err == nil || !strings.Contains(err.Error(), "security rule gone") || !strings.Contains(err.Error(), "service nope") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestBuild_OtherVsys(t *testing.T) {
+	root, err := pancfg.Parse(strings.Replace(testConfig, `<entry name="vsys1">`, `<entry name="vsys2">`, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Build(root, "vsys1", []Item{{KindAddress, "web-2"}}); err == nil {
+		t.Error("found vsys2's address in vsys1")
+	}
+	b, err := Build(root, "vsys2", []Item{{KindSecurityRule, "other"}, {KindAddress, "web-2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := b.SetCommands()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"set vsys vsys2 address web-2 fqdn web2.example.com",
+		"set vsys vsys2 rulebase security rules other action deny",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("set commands:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if doc := strings.Join(b.XML("fw.xml"), "\n"); !strings.Contains(doc, "to-xpath /config/devices/entry[@name='localhost.localdomain']/vsys/entry[@name='vsys2']/address ") {
+		t.Errorf("XML does not load into vsys2:\n%s", doc)
+	}
+}
+
+func TestBundle_SetCommandsRejectsUnquotable(t *testing.T) {
+	root, err := pancfg.Parse(strings.Replace(testConfig, "<fqdn>web2.example.com</fqdn>",
+		"<fqdn>web2.example.com</fqdn><description>line one\nline two</description>", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Build(root, "vsys1", []Item{{KindAddress, "web-2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.SetCommands(); err == nil || !strings.Contains(err.Error(), `address "web-2"`) {
+		t.Errorf("err = %v, want the address named", err)
+	}
+	if _, err := Export(t.TempDir(), "fw", b, FormatSet, time.Now()); err == nil {
+		t.Error("Export wrote set commands that lose a line break")
+	}
+}
+
+func TestExport(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "exports")
+	b := mustBuild(t, Item{KindService, "tcp-8443"})
+	now := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	path, err := Export(dir, "fw:443", b, FormatXML, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(path) != "fw_443-20250301-100000.xml" {
+		t.Errorf("path = %s", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "from fw_443-20250301-100000.xml ") {
+		t.Errorf("load commands do not name the file:\n%s", data)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+	if _, err := Export(dir, "fw:443", b, FormatXML, now); err == nil {
+		t.Error("second export at the same second overwrote the first")
+	}
+}
@@ -0,0 +1,37 @@
+package cfgexport
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jp2195/pyre/internal/devicefile"
+)
+
+// DefaultDir returns ~/.pyre/exports, where the TUI writes exports.
+func DefaultDir() (string, error) {
+	return devicefile.Dir("exports")
+}
+
+// Export writes b in format f to a new file under dir, named for the
+// device and time, and returns its path.
+func Export(dir, device string, b *Bundle, f Format, now time.Time) (string, error) {
+	name := devicefile.Name(device, now, f.ext())
+	var lines []string
+	if f == FormatXML {
+		lines = b.XML(name)
+	} else {
+		var err error
+		if lines, err = b.SetCommands(); err != nil {
+			return "", err
+		}
+	}
+	return devicefile.Write(dir, name, func(w io.Writer) error {
+		for _, l := range lines {
+			if _, err := fmt.Fprintln(w, l); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -22,14 +22,36 @@ type Config struct {
 //
 // Credential fields (APIKey, Password) are tagged `yaml:"-"` so they are
 // NEVER round-tripped to ~/.pyre.yaml. pyre does not persist credentials.
-// At runtime they come from CLI flags, environment variables, or the
-// interactive login flow (session-only); at disconnect they are zeroed
-// (see internal/auth).
+// At runtime they come from CLI flags, environment variables, an
+// api_key_command helper, or the interactive login flow (session-only);
+// at disconnect they are zeroed (see internal/auth).
 type ConnectionConfig struct {
 	Username   string `yaml:"username,omitempty"`     // Username for API authentication
 	Type       string `yaml:"type,omitempty"`         // "firewall" (default) or "panorama"
 	Insecure   bool   `yaml:"insecure,omitempty"`     // Skip TLS verification (self-signed certs)
 	CACertPath string `yaml:"ca_cert_path,omitempty"` // Optional PEM-encoded CA bundle for TLS verification
+	AllowWrite bool   `yaml:"allow_write,omitempty"`  // Permit validate/commit; connections are read-only otherwise
+	Vsys       string `yaml:"vsys,omitempty"`         // Virtual system to read policies and objects from (default vsys1)
+
+	// ClientCertPath and ClientKeyPath are a PEM certificate and key for
+	// devices that require mutual TLS.
+	ClientCertPath string `yaml:"client_cert_path,omitempty"`
+	ClientKeyPath  string `yaml:"client_key_path,omitempty"`
+	// Pin trusts the device's certificate by fingerprint, recorded in
+	// ~/.pyre/known_hosts on first connect, instead of by CA.
+	Pin bool `yaml:"pin,omitempty"`
+	// Proxy is an http, https, or socks5 proxy URL to reach the device
+	// through, such as a bastion. The standard proxy environment
+	// variables apply when unset. A password never goes here: it comes
+	// from PYRE_PROXY_PASSWORD.
+	Proxy string `yaml:"proxy,omitempty"`
+	// Limits bounds the load pyre puts on the device.
+	Limits LimitsConfig `yaml:"limits,omitempty"`
+
+	// APIKeyCommand is a shell command that prints the API key, run
+	// instead of prompting for a password (see auth.CommandAPIKey). The
+	// command is stored; the key it prints never is.
+	APIKeyCommand string `yaml:"api_key_command,omitempty"`
 
 	// APIKey is the per-host PAN-OS API key. Never persisted to disk.
 	APIKey string `yaml:"-"`
@@ -38,8 +60,72 @@ type ConnectionConfig struct {
 	Password string `yaml:"-"`
 }
 
+// LimitsConfig caps concurrency and request rate for one connection and
+// sets how often failed reads are retried (see api.RequestPolicy). Zero
+// fields take pyre's defaults.
+type LimitsConfig struct {
+	MaxConcurrent int     `yaml:"max_concurrent,omitempty"` // Requests in flight; default 6
+	Rate          float64 `yaml:"rate,omitempty"`           // Requests per second; default unlimited
+	Burst         int     `yaml:"burst,omitempty"`          // Requests allowed at once above rate; default 1
+	Retries       int     `yaml:"retries,omitempty"`        // Retries of failed reads; default 2, -1 disables
+}
+
 type Settings struct {
-	Theme string `yaml:"theme"`
+	Theme   string          `yaml:"theme"`
+	Backup  BackupSettings  `yaml:"backup,omitempty"`
+	Alerts  AlertSettings   `yaml:"alerts,omitempty"`
+	History HistorySettings `yaml:"history,omitempty"`
+}
+
+// BackupSettings configures the local running-config history (see
+// internal/backup).
+type BackupSettings struct {
+	Dir        string `yaml:"dir,omitempty"`          // Defaults to ~/.pyre/backups
+	Keep       int    `yaml:"keep"`                   // Versions kept per device; 0 keeps all
+	MaxAgeDays int    `yaml:"max_age_days,omitempty"` // Drop versions older than this; 0 keeps all
+}
+
+// DefaultBackupKeep is how many versions per device are kept unless the
+// config says otherwise.
+const DefaultBackupKeep = 30
+
+// AlertSettings configures the threshold rules checked by the TUI and
+// `pyre watch` (see internal/alerts). A zero threshold or false switch
+// turns its rule off.
+type AlertSettings struct {
+	CPUPercent      float64 `yaml:"cpu_percent"`        // Management or dataplane CPU above this
+	SessionPercent  float64 `yaml:"session_percent"`    // Active sessions above this share of the maximum
+	NATPoolPercent  float64 `yaml:"nat_pool_percent"`   // A NAT pool above this utilization
+	CertDays        int     `yaml:"cert_days"`          // A certificate expiring in fewer days
+	TunnelDown      bool    `yaml:"tunnel_down"`        // An IPSec tunnel not up
+	BGPDown         bool    `yaml:"bgp_down"`           // A BGP peer not Established
+	HAChange        bool    `yaml:"ha_change"`          // The local HA state changing
+	IntervalSeconds int     `yaml:"interval_seconds"`   // How often rules are checked
+	Webhook         string  `yaml:"webhook,omitempty"`  // `pyre watch` POSTs events here
+	Disabled        bool    `yaml:"disabled,omitempty"` // Turns every rule off
+}
+
+// DefaultAlertSettings are the rules checked unless the config says
+// otherwise.
+var DefaultAlertSettings = AlertSettings{
+	CPUPercent:      80,
+	SessionPercent:  90,
+	NATPoolPercent:  90,
+	CertDays:        30,
+	TunnelDown:      true,
+	BGPDown:         true,
+	HAChange:        true,
+	IntervalSeconds: 60,
+}
+
+// HistorySettings configures the local metrics history behind the
+// dashboard's trend charts (see internal/history). It is off unless
+// enabled.
+type HistorySettings struct {
+	Enabled         bool   `yaml:"enabled"`
+	Dir             string `yaml:"dir,omitempty"`              // Defaults to ~/.pyre/history
+	IntervalSeconds int    `yaml:"interval_seconds,omitempty"` // How often a sample is taken
+	RetentionDays   int    `yaml:"retention_days,omitempty"`   // Samples older than this are deleted
 }
 
 // ConfigPath returns the path to the config file (~/.pyre.yaml)
@@ -55,7 +141,9 @@ func DefaultConfig() *Config {
 	return &Config{
 		Connections: make(map[string]ConnectionConfig),
 		Settings: Settings{
-			Theme: "default",
+			Theme:  "default",
+			Backup: BackupSettings{Keep: DefaultBackupKeep},
+			Alerts: DefaultAlertSettings,
 		},
 	}
 }
@@ -257,6 +345,7 @@ type CLIFlags struct {
 	Insecure   bool
 	Config     string
 	Connection string // -c flag for selecting a specific connection
+	AllowWrite bool
 }
 
 func (c *Config) ApplyFlags(flags CLIFlags) {
@@ -265,7 +354,8 @@ func (c *Config) ApplyFlags(flags CLIFlags) {
 			c.Connections = make(map[string]ConnectionConfig)
 		}
 		c.Connections[flags.Host] = ConnectionConfig{
-			Insecure: flags.Insecure,
+			Insecure:   flags.Insecure,
+			AllowWrite: flags.AllowWrite,
 		}
 		c.Default = flags.Host
 	}
@@ -108,6 +108,14 @@ func TestConfig_ApplyFlags(t *testing.T) {
 	if !conn.Insecure {
 		t.Error("expected Insecure to be true")
 	}
+	if conn.AllowWrite {
+		t.Error("expected connections to be read-only unless --allow-write is given")
+	}
+
+	cfg.ApplyFlags(CLIFlags{Host: "192.168.1.100", AllowWrite: true})
+	if conn, _ := cfg.GetConnection("192.168.1.100"); !conn.AllowWrite {
+		t.Error("expected AllowWrite to be true")
+	}
 }
 
 func TestLoad_NoConfigFile(t *testing.T) {
@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 )
 
@@ -16,11 +17,15 @@ type State struct {
 
 // ConnectionState holds state for a single connection
 type ConnectionState struct {
-	LastConnected time.Time `json:"last_connected"`
-	LastUser      string    `json:"last_user,omitempty"`
-	ConnectCount  int       `json:"connect_count"`
+	LastConnected  time.Time `json:"last_connected"`
+	LastUser       string    `json:"last_user,omitempty"`
+	ConnectCount   int       `json:"connect_count"`
+	CommandHistory []string  `json:"command_history,omitempty"` // Console commands, oldest first
 }
 
+// MaxCommandHistory caps the console commands remembered per connection.
+const MaxCommandHistory = 100
+
 // StatePath returns the path to the state file (~/.pyre/state.json)
 func StatePath() (string, error) {
 	homeDir, err := os.UserHomeDir()
@@ -30,6 +35,16 @@ func StatePath() (string, error) {
 	return filepath.Join(homeDir, ".pyre", "state.json"), nil
 }
 
+// KnownHostsPath returns the path to the pinned certificate fingerprints
+// (~/.pyre/known_hosts) used by connections with pin: true.
+func KnownHostsPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".pyre", "known_hosts"), nil
+}
+
 // LoadState loads the state from disk, or returns an empty state if not found
 func LoadState() (*State, error) {
 	state := &State{
@@ -139,3 +154,27 @@ func (s *State) DeleteConnection(host string) {
 		delete(s.Connections, host)
 	}
 }
+
+// AddCommand records a console command for a connection. Repeating a
+// command moves it to the end rather than storing it twice.
+func (s *State) AddCommand(host, cmd string) {
+	if s.Connections == nil {
+		s.Connections = make(map[string]ConnectionState)
+	}
+	state := s.Connections[host]
+	history := slices.DeleteFunc(slices.Clone(state.CommandHistory), func(c string) bool { return c == cmd })
+	history = append(history, cmd)
+	if len(history) > MaxCommandHistory {
+		history = history[len(history)-MaxCommandHistory:]
+	}
+	state.CommandHistory = history
+	s.Connections[host] = state
+}
+
+// CommandHistory returns a connection's console commands, oldest first.
+func (s *State) CommandHistory(host string) []string {
+	if s.Connections == nil {
+		return nil
+	}
+	return slices.Clone(s.Connections[host].CommandHistory)
+}
@@ -2,9 +2,11 @@ package config
 
 import (
 	"bytes"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -47,3 +49,26 @@ func TestLoadState_WarnsOnPermissiveMode(t *testing.T) {
 		t.Errorf("unexpected warning for 0600 state file:\n%s", buf.String())
 	}
 }
+
+func TestState_CommandHistory(t *testing.T) {
+	s := &State{}
+	s.AddCommand("fw1", "show clock")
+	s.AddCommand("fw1", "show system info")
+	s.AddCommand("fw1", "show clock")
+	s.AddCommand("fw2", "show jobs all")
+
+	if got := s.CommandHistory("fw1"); !slices.Equal(got, []string{"show system info", "show clock"}) {
+		t.Errorf("fw1 history = %v; a repeat should move to the end", got)
+	}
+	if got := s.CommandHistory("fw2"); len(got) != 1 {
+		t.Errorf("fw2 history = %v", got)
+	}
+
+	for i := range MaxCommandHistory + 5 {
+		s.AddCommand("fw1", fmt.Sprintf("show session id %d", i))
+	}
+	got := s.CommandHistory("fw1")
+	if len(got) != MaxCommandHistory || got[len(got)-1] != fmt.Sprintf("show session id %d", MaxCommandHistory+4) {
+		t.Errorf("history len %d, last %q; want capped at %d keeping the newest", len(got), got[len(got)-1], MaxCommandHistory)
+	}
+}
@@ -0,0 +1,81 @@
+// Package devicefile names and writes the per-device files pyre keeps
+// under ~/.pyre: backups, history, audits, and exports.
+package devicefile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Dir returns ~/.pyre/kind, where pyre keeps files of that kind.
+func Dir(kind string) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".pyre", kind), nil
+}
+
+// Device names the device a connection reads, which its files are kept
+// under: the host, or host and serial for a Panorama-managed firewall
+// reached through it.
+func Device(host, target string) string {
+	if target == "" {
+		return host
+	}
+	return host + "@" + target
+}
+
+// SafeName maps a device name to a file name. Characters that are not
+// safe in a file name on every platform (the ':' of a port, an IPv6
+// address) become '_'.
+func SafeName(device string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9',
+			r == '.', r == '-', r == '_', r == '@':
+			return r
+		}
+		return '_'
+	}, device)
+}
+
+// Name is the file name of an export of device made at now, with
+// extension ext (".md").
+func Name(device string, now time.Time, ext string) string {
+	return SafeName(device) + "-" + now.Format("20060102-150405") + ext
+}
+
+// Write creates the file name under dir, which it creates if need be, and
+// fills it with write. It never overwrites a file; the file is readable by
+// the user alone. It returns the file's path.
+func Write(dir, name string, write func(io.Writer) error) (string, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", err
+	}
+	w := bufio.NewWriter(f)
+	if err := write(w); err != nil {
+		_ = f.Close() //nolint:errcheck // write error takes precedence
+		return "", err
+	}
+	if err := w.Flush(); err != nil {
+		_ = f.Close() //nolint:errcheck // flush error takes precedence
+		return "", err
+	}
+	return path, f.Close()
+}
+
+// MarkdownCell keeps a value from breaking out of a Markdown table cell.
+func MarkdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ", "\r", "").Replace(s)
+}
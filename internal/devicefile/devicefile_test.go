@@ -0,0 +1,57 @@
+package devicefile
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDevice(t *testing.T) {
+	if got := Device("fw1", ""); got != "fw1" {
+		t.Errorf("Device without a target = %q", got)
+	}
+	if got := Device("panorama", "0123"); got != "panorama@0123" {
+		t.Errorf("Device with a target = %q", got)
+	}
+}
+
+func TestName(t *testing.T) {
+	now := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	if got := Name("[2001:db8::1]:443@0123", now, ".md"); got != "_2001_db8__1__443@0123-20250301-100000.md" {
+		t.Errorf("Name = %q", got)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "exports")
+	write := func(w io.Writer) error {
+		_, err := io.WriteString(w, "hello\n")
+		return err
+	}
+	path, err := Write(dir, "fw.md", write)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "hello\n" {
+		t.Fatalf("data = %q, %v", data, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+	if _, err := Write(dir, "fw.md", write); err == nil {
+		t.Error("second write overwrote the first")
+	}
+
+	failed := errors.New("boom")
+	if _, err := Write(dir, "bad.md", func(io.Writer) error { return failed }); !errors.Is(err, failed) {
+		t.Errorf("err = %v, want %v", err, failed)
+	}
+}
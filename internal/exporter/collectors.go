@@ -0,0 +1,213 @@
+package exporter
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jp2195/pyre/internal/api"
+)
+
+// collector reads one group of metrics. minTTL raises the cache TTL for
+// data that changes slowly and is expensive for the device to produce.
+type collector struct {
+	name    string
+	minTTL  time.Duration
+	collect func(ctx context.Context, c *api.Client, target string, set *metricSet) error
+}
+
+var collectors = []collector{
+	{name: "resources", collect: collectResources},
+	{name: "dataplane", collect: collectDataPlane},
+	{name: "sessions", collect: collectSessions},
+	{name: "ha", collect: collectHA},
+	{name: "interfaces", collect: collectInterfaces},
+	{name: "ipsec", collect: collectIPSec},
+	{name: "bgp", collect: collectBGP},
+	{name: "licenses", minTTL: time.Hour, collect: collectLicenses},
+	{name: "certificates", minTTL: time.Hour, collect: collectCertificates},
+	{name: "disk", minTTL: 5 * time.Minute, collect: collectDisk},
+}
+
+// collectInfo adds panos_device_info and reports whether the device
+// answered at all.
+func collectInfo(ctx context.Context, c *api.Client, target string, set *metricSet) (bool, error) {
+	info, err := c.GetSystemInfo(ctx, target)
+	if err != nil {
+		return false, err
+	}
+	set.add("panos_device_info", "Device identity; always 1.", gauge, 1,
+		"hostname", info.Hostname, "model", info.Model, "serial", info.Serial, "sw_version", info.Version)
+	return true, nil
+}
+
+func collectResources(ctx context.Context, c *api.Client, target string, set *metricSet) error {
+	r, err := c.GetSystemResources(ctx, target)
+	if err != nil {
+		return err
+	}
+	set.add("panos_management_cpu_percent", "Management plane CPU utilization.", gauge, r.CPUPercent)
+	set.add("panos_memory_used_percent", "Management plane memory utilization.", gauge, r.MemoryPercent)
+	const loadHelp = "Management plane load average."
+	set.add("panos_load_average", loadHelp, gauge, r.Load1, "period", "1m")
+	set.add("panos_load_average", loadHelp, gauge, r.Load5, "period", "5m")
+	set.add("panos_load_average", loadHelp, gauge, r.Load15, "period", "15m")
+	return nil
+}
+
+func collectDataPlane(ctx context.Context, c *api.Client, target string, set *metricSet) error {
+	cpu, err := c.GetDataPlaneResources(ctx, target)
+	if err != nil {
+		return err
+	}
+	set.add("panos_dataplane_cpu_percent", "Dataplane CPU utilization, averaged over cores.", gauge, cpu)
+	return nil
+}
+
+func collectSessions(ctx context.Context, c *api.Client, target string, set *metricSet) error {
+	s, err := c.GetSessionInfo(ctx, target)
+	if err != nil {
+		return err
+	}
+	set.add("panos_sessions_active", "Active sessions.", gauge, float64(s.ActiveCount))
+	set.add("panos_sessions_max", "Session table capacity.", gauge, float64(s.MaxCount))
+	const protoHelp = "Active sessions by protocol."
+	set.add("panos_sessions_protocol_active", protoHelp, gauge, float64(s.TCPSessions), "protocol", "tcp")
+	set.add("panos_sessions_protocol_active", protoHelp, gauge, float64(s.UDPSessions), "protocol", "udp")
+	set.add("panos_sessions_protocol_active", protoHelp, gauge, float64(s.ICMPSessions), "protocol", "icmp")
+	set.add("panos_sessions_per_second", "New connections per second.", gauge, float64(s.CPS))
+	set.add("panos_throughput_bits_per_second", "Throughput through the dataplane.", gauge, float64(s.ThroughputKbps)*1000)
+	return nil
+}
+
+func collectHA(ctx context.Context, c *api.Client, target string, set *metricSet) error {
+	ha, err := c.GetHAStatus(ctx, target)
+	if err != nil {
+		return err
+	}
+	set.add("panos_ha_enabled", "Whether high availability is enabled.", gauge, boolValue(ha.Enabled))
+	if !ha.Enabled {
+		return nil
+	}
+	set.add("panos_ha_state", "Local HA state; always 1.", gauge, 1, "state", strings.ToLower(ha.State), "mode", ha.Mode)
+	set.add("panos_ha_peer_state", "Peer HA state; always 1.", gauge, 1, "state", strings.ToLower(ha.PeerState))
+	set.add("panos_ha_config_synchronized", "Whether the HA peers' configs are synchronized.", gauge,
+		boolValue(strings.EqualFold(ha.SyncState, "synchronized")))
+	return nil
+}
+
+func collectInterfaces(ctx context.Context, c *api.Client, target string, set *metricSet) error {
+	ifaces, err := c.GetInterfaces(ctx, target)
+	if err != nil {
+		return err
+	}
+	for _, i := range ifaces {
+		labels := []string{"interface", i.Name}
+		set.add("panos_interface_up", "Whether the interface is up.", gauge, boolValue(strings.EqualFold(i.State, "up")),
+			"interface", i.Name, "zone", i.Zone, "type", i.Type)
+		set.add("panos_interface_receive_bytes_total", "Bytes received.", counter, float64(i.BytesIn), labels...)
+		set.add("panos_interface_transmit_bytes_total", "Bytes transmitted.", counter, float64(i.BytesOut), labels...)
+		set.add("panos_interface_receive_packets_total", "Packets received.", counter, float64(i.PacketsIn), labels...)
+		set.add("panos_interface_transmit_packets_total", "Packets transmitted.", counter, float64(i.PacketsOut), labels...)
+		set.add("panos_interface_receive_errors_total", "Receive errors.", counter, float64(i.ErrorsIn), labels...)
+		set.add("panos_interface_transmit_errors_total", "Transmit errors.", counter, float64(i.ErrorsOut), labels...)
+		set.add("panos_interface_receive_drops_total", "Received packets dropped.", counter, float64(i.DropsIn), labels...)
+		set.add("panos_interface_transmit_drops_total", "Transmitted packets dropped.", counter, float64(i.DropsOut), labels...)
+	}
+	return nil
+}
+
+func collectIPSec(ctx context.Context, c *api.Client, target string, set *metricSet) error {
+	tunnels, err := c.GetIPSecTunnels(ctx, target)
+	if err != nil {
+		return err
+	}
+	for _, t := range tunnels {
+		set.add("panos_ipsec_tunnel_up", "Whether the IPSec tunnel is up.", gauge, boolValue(strings.EqualFold(t.State, "up")),
+			"tunnel", t.Name, "gateway", t.Gateway)
+		set.add("panos_ipsec_tunnel_receive_bytes_total", "Bytes received through the tunnel.", counter, float64(t.BytesIn), "tunnel", t.Name)
+		set.add("panos_ipsec_tunnel_transmit_bytes_total", "Bytes sent through the tunnel.", counter, float64(t.BytesOut), "tunnel", t.Name)
+	}
+	return nil
+}
+
+func collectBGP(ctx context.Context, c *api.Client, target string, set *metricSet) error {
+	peers, err := c.GetBGPNeighbors(ctx, target)
+	if err != nil {
+		return err
+	}
+	for _, p := range peers {
+		set.add("panos_bgp_peer_up", "Whether the BGP session is established.", gauge,
+			boolValue(strings.EqualFold(p.State, "established")),
+			"peer", p.PeerAddress, "peer_as", strconv.Itoa(p.PeerAS), "virtual_router", p.VirtualRouter)
+		labels := []string{"peer", p.PeerAddress, "virtual_router", p.VirtualRouter}
+		set.add("panos_bgp_peer_prefixes_received", "Prefixes received from the peer.", gauge, float64(p.PrefixesReceived), labels...)
+		set.add("panos_bgp_peer_prefixes_advertised", "Prefixes advertised to the peer.", gauge, float64(p.PrefixesSent), labels...)
+	}
+	return nil
+}
+
+func collectLicenses(ctx context.Context, c *api.Client, target string, set *metricSet) error {
+	licenses, err := c.GetLicenseInfo(ctx, target)
+	if err != nil {
+		return err
+	}
+	// A feature can hold several licenses, such as a renewal next to the
+	// one it replaces: it has expired only when all of them have, and its
+	// days left are the latest expiry's.
+	type feature struct {
+		expired   bool
+		daysLeft  int
+		perpetual bool
+	}
+	features := make(map[string]*feature)
+	var order []string
+	for _, l := range licenses {
+		f, ok := features[l.Feature]
+		if !ok {
+			f = &feature{expired: true, daysLeft: l.DaysLeft}
+			features[l.Feature] = f
+			order = append(order, l.Feature)
+		}
+		f.expired = f.expired && l.Expired
+		f.daysLeft = max(f.daysLeft, l.DaysLeft)
+		if l.Expires == "" || strings.EqualFold(l.Expires, "never") {
+			f.perpetual = true
+		}
+	}
+	for _, name := range order {
+		f := features[name]
+		set.add("panos_license_expired", "Whether the license has expired.", gauge, boolValue(f.expired), "feature", name)
+		// Perpetual licenses have no days left to count.
+		if f.perpetual {
+			continue
+		}
+		set.add("panos_license_days_left", "Days until the license expires.", gauge, float64(f.daysLeft), "feature", name)
+	}
+	return nil
+}
+
+func collectCertificates(ctx context.Context, c *api.Client, target string, set *metricSet) error {
+	certs, err := c.GetCertificates(ctx, target)
+	if err != nil {
+		return err
+	}
+	for _, cert := range certs {
+		set.add("panos_certificate_days_left", "Days until the certificate expires.", gauge, float64(cert.DaysLeft),
+			"name", cert.Name, "subject", cert.Subject)
+	}
+	return nil
+}
+
+func collectDisk(ctx context.Context, c *api.Client, target string, set *metricSet) error {
+	disks, err := c.GetDiskUsage(ctx, target)
+	if err != nil {
+		return err
+	}
+	for _, d := range disks {
+		set.add("panos_disk_used_percent", "Filesystem utilization.", gauge, d.Percent,
+			"filesystem", d.Filesystem, "mount", d.MountPoint)
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+// Package exporter serves firewall metrics in the Prometheus text format,
+// read with the same api.Client fetchers the dashboards use.
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/auth"
+)
+
+// DefaultCacheTTL is how long a scraped response is reused by later
+// scrapes, unless Options says otherwise.
+const DefaultCacheTTL = 30 * time.Second
+
+// DefaultTimeout bounds one scrape when Prometheus doesn't send its own
+// scrape timeout.
+const DefaultTimeout = 25 * time.Second
+
+// Options configures an Exporter.
+type Options struct {
+	// Hosts are the devices a scrape may target. A scrape without a target
+	// is allowed only when there is exactly one.
+	Hosts []string
+	// Dial returns a client for host. It is called once per host, on its
+	// first scrape; a failed dial is retried on the next scrape.
+	Dial func(host string) (*api.Client, error)
+	// CacheTTL is how long responses are reused across scrapes. Slow-moving
+	// data (licenses, certificates, disks) is reused for longer.
+	CacheTTL time.Duration
+	// Timeout bounds one scrape. Prometheus's scrape timeout, when sent and
+	// shorter, wins.
+	Timeout time.Duration
+}
+
+// Exporter is an http.Handler serving /metrics for the configured hosts.
+type Exporter struct {
+	opts Options
+	mux  *http.ServeMux
+
+	mu      sync.Mutex
+	clients map[string]*api.Client
+	// scrapes serializes scrapes of one device so that concurrent
+	// Prometheus servers share one round of requests through the cache.
+	scrapes map[string]*sync.Mutex
+}
+
+// New returns an Exporter for opts.
+func New(opts Options) *Exporter {
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = DefaultCacheTTL
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	e := &Exporter{
+		opts:    opts,
+		mux:     http.NewServeMux(),
+		clients: make(map[string]*api.Client),
+		scrapes: make(map[string]*sync.Mutex),
+	}
+	e.mux.HandleFunc("GET /metrics", e.serveMetrics)
+	e.mux.HandleFunc("GET /{$}", e.serveIndex)
+	return e
+}
+
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mux.ServeHTTP(w, r)
+}
+
+func (e *Exporter) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	host, serial, err := e.target(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), e.timeout(r))
+	defer cancel()
+	set := e.scrape(ctx, host, serial)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = set.write(w)
+}
+
+// target resolves a scrape's target and serial parameters.
+func (e *Exporter) target(r *http.Request) (host, serial string, err error) {
+	q := r.URL.Query()
+	host, serial = q.Get("target"), q.Get("serial")
+	switch {
+	case host == "" && len(e.opts.Hosts) == 1:
+		host = e.opts.Hosts[0]
+	case host == "":
+		return "", "", errors.New("missing target parameter")
+	case !slices.Contains(e.opts.Hosts, host):
+		return "", "", fmt.Errorf("unknown target %q: not a configured connection", host)
+	}
+	if err := auth.ValidateSerial(serial); err != nil {
+		return "", "", err
+	}
+	return host, serial, nil
+}
+
+// timeout is the scrape's time budget: the configured timeout, or
+// Prometheus's own less half a second when that is shorter.
+func (e *Exporter) timeout(r *http.Request) time.Duration {
+	d := e.opts.Timeout
+	if s, err := strconv.ParseFloat(r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds"), 64); err == nil && s > 1 {
+		d = min(d, time.Duration((s-0.5)*float64(time.Second)))
+	}
+	return d
+}
+
+func (e *Exporter) client(host string) (*api.Client, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if c, ok := e.clients[host]; ok {
+		return c, nil
+	}
+	c, err := e.opts.Dial(host)
+	if err != nil {
+		return nil, err
+	}
+	e.clients[host] = c
+	return c, nil
+}
+
+func (e *Exporter) scrapeLock(host, serial string) *sync.Mutex {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	key := host + "/" + serial
+	l, ok := e.scrapes[key]
+	if !ok {
+		l = &sync.Mutex{}
+		e.scrapes[key] = l
+	}
+	return l
+}
+
+// scrape collects the metrics of host, or of the managed device serial
+// behind it. Failures are reported as metrics, never as an HTTP error, so
+// Prometheus keeps the series it could read.
+func (e *Exporter) scrape(ctx context.Context, host, serial string) *metricSet {
+	start := time.Now()
+	set := newMetricSet()
+	defer func() {
+		set.add("panos_scrape_duration_seconds", "Time taken to scrape the device.", gauge, time.Since(start).Seconds())
+	}()
+
+	client, err := e.client(host)
+	if err != nil {
+		log.Printf("exporter: %s: %v", host, err)
+		set.add("panos_up", "Whether the device answered the scrape.", gauge, 0)
+		return set
+	}
+
+	l := e.scrapeLock(host, serial)
+	l.Lock()
+	defer l.Unlock()
+
+	up, err := collectInfo(api.WithCache(ctx, e.opts.CacheTTL), client, serial, set)
+	if err != nil {
+		log.Printf("exporter: %s: %v", scrapeName(host, serial), err)
+	}
+	set.add("panos_up", "Whether the device answered the scrape.", gauge, boolValue(up))
+	if !up {
+		return set
+	}
+
+	var wg sync.WaitGroup
+	for _, col := range collectors {
+		wg.Go(func() {
+			ttl := max(e.opts.CacheTTL, col.minTTL)
+			err := col.collect(api.WithCache(ctx, ttl), client, serial, set)
+			if err != nil {
+				log.Printf("exporter: %s: %s: %v", scrapeName(host, serial), col.name, err)
+			}
+			set.add("panos_collector_success", "Whether a collector succeeded.", gauge, boolValue(err == nil), "collector", col.name)
+		})
+	}
+	wg.Wait()
+	return set
+}
+
+// scrapeName names a scrape in log messages.
+func scrapeName(host, serial string) string {
+	if serial == "" {
+		return host
+	}
+	return host + " serial " + serial
+}
+
+func (e *Exporter) serveIndex(w http.ResponseWriter, r *http.Request) {
+	var b strings.Builder
+	b.WriteString("<html><head><title>pyre exporter</title></head><body>\n<h1>pyre exporter</h1>\n<ul>\n")
+	for _, host := range e.opts.Hosts {
+		u := "/metrics?target=" + html.EscapeString(url.QueryEscape(host))
+		fmt.Fprintf(&b, "<li><a href=\"%s\">%s</a></li>\n", u, html.EscapeString(host))
+	}
+	b.WriteString("</ul>\n<p>Add <code>&amp;serial=SERIAL</code> to scrape a device managed by a Panorama.</p>\n</body></html>\n")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(b.String()))
+}
@@ -0,0 +1,123 @@
+package exporter
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/testutil"
+)
+
+// newTestExporter serves the mock firewall and returns an exporter for it
+// and the client it scrapes with.
+func newTestExporter(t *testing.T) (*Exporter, *api.Client, string) {
+	t.Helper()
+	mock := testutil.NewMockPANOS()
+	t.Cleanup(mock.Close)
+	client, err := api.NewClient(mock.Host(), "test-api-key", api.ClientOptions{Insecure: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New(Options{
+		Hosts: []string{mock.Host()},
+		Dial:  func(string) (*api.Client, error) { return client, nil },
+	})
+	return e, client, mock.Host()
+}
+
+func get(t *testing.T, h http.Handler, path string) (int, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	body, _ := io.ReadAll(rec.Body)
+	return rec.Code, string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	e, _, host := newTestExporter(t)
+
+	code, body := get(t, e, "/metrics?target="+host)
+	if code != http.StatusOK {
+		t.Fatalf("status %d: %s", code, body)
+	}
+	for _, want := range []string{
+		"# TYPE panos_up gauge\npanos_up 1\n",
+		"panos_device_info{hostname=",
+		"panos_management_cpu_percent ",
+		"panos_dataplane_cpu_percent ",
+		"panos_sessions_active ",
+		"panos_throughput_bits_per_second ",
+		"panos_ha_enabled ",
+		"# TYPE panos_interface_receive_bytes_total counter\n",
+		`panos_interface_up{interface="ethernet1/1"`,
+		"panos_license_expired{feature=",
+		`panos_collector_success{collector="sessions"} 1`,
+		"panos_scrape_duration_seconds ",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics lack %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, `panos_collector_success{collector="resources"} 0`) {
+		t.Errorf("resources collector failed:\n%s", body)
+	}
+}
+
+func TestMetrics_DefaultTarget(t *testing.T) {
+	e, _, _ := newTestExporter(t)
+	if code, body := get(t, e, "/metrics"); code != http.StatusOK || !strings.Contains(body, "panos_up 1") {
+		t.Errorf("scrape with no target and one host = %d:\n%s", code, body)
+	}
+}
+
+func TestMetrics_RejectsUnknownTarget(t *testing.T) {
+	e, client, host := newTestExporter(t)
+	for _, path := range []string{
+		"/metrics?target=evil.example.com",
+		"/metrics?target=" + host + "&serial=not%20a%20serial",
+	} {
+		if code, body := get(t, e, path); code != http.StatusBadRequest {
+			t.Errorf("GET %s = %d, want 400:\n%s", path, code, body)
+		}
+	}
+	if n := len(client.Calls()); n != 0 {
+		t.Errorf("rejected scrapes made %d API calls", n)
+	}
+}
+
+func TestMetrics_CachedBetweenScrapes(t *testing.T) {
+	e, client, host := newTestExporter(t)
+
+	get(t, e, "/metrics?target="+host)
+	first := len(client.Calls())
+	if first == 0 {
+		t.Fatal("first scrape made no API calls")
+	}
+	_, body := get(t, e, "/metrics?target="+host)
+	if n := len(client.Calls()); n != first {
+		t.Errorf("second scrape within the cache TTL made %d more API calls", n-first)
+	}
+	if !strings.Contains(body, "panos_up 1") {
+		t.Errorf("cached scrape:\n%s", body)
+	}
+}
+
+func TestMetricSet_Write(t *testing.T) {
+	set := newMetricSet()
+	set.add("y", "Y.", gauge, 0.5)
+	set.add("x_total", "An \\ example.", counter, 3, "name", "a\"b\nc")
+	set.add("x_total", "An \\ example.", counter, 4, "name", "a\"b\nc") // a repeat is dropped
+
+	var b strings.Builder
+	if err := set.write(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := "# HELP x_total An \\\\ example.\n# TYPE x_total counter\nx_total{name=\"a\\\"b\\nc\"} 3\n" +
+		"# HELP y Y.\n# TYPE y gauge\ny 0.5\n"
+	if b.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,125 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// metricType is a Prometheus metric type.
+type metricType string
+
+const (
+	gauge   metricType = "gauge"
+	counter metricType = "counter"
+)
+
+// family is one metric and its samples.
+type family struct {
+	name    string
+	help    string
+	typ     metricType
+	samples []sample
+	seen    map[string]bool // rendered label sets
+}
+
+type sample struct {
+	labels string // rendered: {a="1",b="2"}, or empty
+	value  float64
+}
+
+// metricSet collects the samples of one scrape. Collectors add to it
+// concurrently; families are written sorted by name, samples in the order
+// added.
+type metricSet struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+func newMetricSet() *metricSet {
+	return &metricSet{families: make(map[string]*family)}
+}
+
+// add records a sample of name with labels given as name/value pairs.
+func (s *metricSet) add(name, help string, typ metricType, value float64, labels ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.families[name]
+	if !ok {
+		f = &family{name: name, help: help, typ: typ, seen: make(map[string]bool)}
+		s.families[name] = f
+	}
+	// Prometheus rejects a whole scrape with a repeated series, so keep
+	// the first of any the device reports twice.
+	rendered := renderLabels(labels)
+	if f.seen[rendered] {
+		return
+	}
+	f.seen[rendered] = true
+	f.samples = append(f.samples, sample{labels: rendered, value: value})
+}
+
+// write renders the set in the Prometheus text exposition format.
+func (s *metricSet) write(w io.Writer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var b strings.Builder
+	for _, name := range slices.Sorted(maps.Keys(s.families)) {
+		f := s.families[name]
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", f.name, escapeHelp(f.help), f.name, f.typ)
+		for _, smp := range f.samples {
+			fmt.Fprintf(&b, "%s%s %s\n", f.name, smp.labels, formatValue(smp.value))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func renderLabels(pairs []string) string {
+	if len(pairs) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `%s="%s"`, pairs[i], escapeLabel(pairs[i+1]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// boolValue is 1 for true and 0 for false.
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,164 @@
+package history
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/models"
+)
+
+// Reading is what Collect read from a device. A nil field was not read.
+type Reading struct {
+	Time         time.Time
+	Resources    *models.Resources
+	DataPlaneCPU *float64
+	Sessions     *models.SessionInfo
+	Interfaces   []models.Interface
+	Tunnels      []models.IPSecTunnel
+	BGPPeers     []models.BGPNeighbor
+}
+
+// Collect reads the device target behind c (or c's own device for "")
+// concurrently. Reads that fail are left out of the reading and returned
+// joined.
+//
+// Interface counters are always read afresh, whatever cache ctx allows:
+// rates come from the difference between two readings, so a cached copy
+// would skew them.
+func Collect(ctx context.Context, c *api.Client, target string) (Reading, error) {
+	var (
+		r  = Reading{Time: time.Now()}
+		rd api.Reads
+	)
+	rd.Go("resources", func() error {
+		res, err := c.GetSystemResources(ctx, target)
+		if err == nil {
+			rd.Set(func() { r.Resources = res })
+		}
+		return err
+	})
+	rd.Go("dataplane", func() error {
+		cpu, err := c.GetDataPlaneResources(ctx, target)
+		if err == nil {
+			rd.Set(func() { r.DataPlaneCPU = &cpu })
+		}
+		return err
+	})
+	rd.Go("sessions", func() error {
+		info, err := c.GetSessionInfo(ctx, target)
+		if err == nil {
+			rd.Set(func() { r.Sessions = info })
+		}
+		return err
+	})
+	rd.Go("interfaces", func() error {
+		ifaces, err := c.GetInterfaces(api.WithCache(ctx, 0), target)
+		if err == nil {
+			rd.Set(func() { r.Interfaces = append([]models.Interface{}, ifaces...) })
+		}
+		return err
+	})
+	rd.Go("ipsec", func() error {
+		tunnels, err := c.GetIPSecTunnels(ctx, target)
+		if err == nil {
+			rd.Set(func() { r.Tunnels = append([]models.IPSecTunnel{}, tunnels...) })
+		}
+		return err
+	})
+	rd.Go("bgp", func() error {
+		peers, err := c.GetBGPNeighbors(ctx, target)
+		if err == nil {
+			rd.Set(func() { r.BGPPeers = append([]models.BGPNeighbor{}, peers...) })
+		}
+		return err
+	})
+	return r, rd.Wait()
+}
+
+// counters are an interface's byte counters at a reading.
+type counters struct {
+	in, out int64
+}
+
+// Sampler turns a device's readings into samples. It keeps the previous
+// reading's interface counters to work out rates, so use one per device.
+type Sampler struct {
+	prev   map[string]counters
+	prevAt time.Time
+}
+
+// maxRateGap is the longest gap between readings a rate is worked out
+// over; across a longer one (pyre was closed) the average would flatten
+// whatever happened.
+const maxRateGap = 15 * time.Minute
+
+// Sample summarizes r. Interface rates need a previous reading, so the
+// first sample, and any after a gap or a counter reset, has none.
+func (s *Sampler) Sample(r Reading) Sample {
+	smp := Sample{Time: r.Time}
+	if r.Resources != nil {
+		cpu, mem := tenths(r.Resources.CPUPercent), tenths(r.Resources.MemoryPercent)
+		smp.ManagementCPU, smp.MemoryPercent = &cpu, &mem
+	}
+	if r.DataPlaneCPU != nil {
+		dp := tenths(*r.DataPlaneCPU)
+		smp.DataPlaneCPU = &dp
+	}
+	if r.Sessions != nil {
+		active, maxCount, kbps, cps := r.Sessions.ActiveCount, r.Sessions.MaxCount, r.Sessions.ThroughputKbps, r.Sessions.CPS
+		smp.Sessions, smp.SessionMax, smp.ThroughputKbps, smp.CPS = &active, &maxCount, &kbps, &cps
+	}
+	if r.Interfaces != nil {
+		now := make(map[string]counters, len(r.Interfaces))
+		secs := r.Time.Sub(s.prevAt).Seconds()
+		fresh := s.prev != nil && secs > 0 && r.Time.Sub(s.prevAt) <= maxRateGap
+		for _, iface := range r.Interfaces {
+			cur := counters{in: iface.BytesIn, out: iface.BytesOut}
+			now[iface.Name] = cur
+			prev, ok := s.prev[iface.Name]
+			if !fresh || !ok || cur.in < prev.in || cur.out < prev.out {
+				continue
+			}
+			rate := Rate{In: int64(float64(cur.in-prev.in) * 8 / secs), Out: int64(float64(cur.out-prev.out) * 8 / secs)}
+			if rate.In > 0 || rate.Out > 0 {
+				if smp.Interfaces == nil {
+					smp.Interfaces = map[string]Rate{}
+				}
+				smp.Interfaces[iface.Name] = rate
+			}
+		}
+		s.prev, s.prevAt = now, r.Time
+	}
+	if r.Tunnels != nil {
+		c := Count{Total: len(r.Tunnels)}
+		for _, t := range r.Tunnels {
+			if strings.EqualFold(t.State, "up") {
+				c.Up++
+			} else {
+				c.Down = append(c.Down, t.Name)
+			}
+		}
+		smp.Tunnels = &c
+	}
+	if r.BGPPeers != nil {
+		c := Count{Total: len(r.BGPPeers)}
+		for _, p := range r.BGPPeers {
+			if strings.EqualFold(p.State, "established") {
+				c.Up++
+			} else {
+				c.Down = append(c.Down, p.PeerAddress)
+			}
+		}
+		smp.BGPPeers = &c
+	}
+	return smp
+}
+
+// tenths rounds a percentage to one decimal: finer is noise, and costs
+// bytes on every line.
+func tenths(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,354 @@
+// Package history keeps sampled device metrics on local disk, one directory
+// per device and one file per day, so dashboards can chart trends across
+// sessions.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jp2195/pyre/internal/config"
+	"github.com/jp2195/pyre/internal/devicefile"
+)
+
+// Sample is one poll of a device. A nil field was not read, or its read
+// failed; charts leave a gap for it.
+type Sample struct {
+	Time           time.Time       `json:"t"`
+	ManagementCPU  *float64        `json:"cpu,omitempty"`
+	DataPlaneCPU   *float64        `json:"dp,omitempty"`
+	MemoryPercent  *float64        `json:"mem,omitempty"`
+	Sessions       *int            `json:"ses,omitempty"`
+	SessionMax     *int            `json:"smax,omitempty"`
+	ThroughputKbps *int64          `json:"kbps,omitempty"`
+	CPS            *int            `json:"cps,omitempty"`
+	Interfaces     map[string]Rate `json:"if,omitempty"` // by interface name; only those with traffic
+	Tunnels        *Count          `json:"tun,omitempty"`
+	BGPPeers       *Count          `json:"bgp,omitempty"`
+}
+
+// Rate is an interface's traffic since the previous sample, in bits per
+// second.
+type Rate struct {
+	In  int64 `json:"i"`
+	Out int64 `json:"o"`
+}
+
+// Count is how many of a set of tunnels or peers were up, and which were
+// not.
+type Count struct {
+	Up    int      `json:"up"`
+	Total int      `json:"n"`
+	Down  []string `json:"down,omitempty"`
+}
+
+// File names: one per UTC day. Days before today are gzipped by Prune.
+const (
+	dayLayout = "2006-01-02"
+	ext       = ".jsonl"
+	gzExt     = ".jsonl.gz"
+)
+
+// DefaultInterval is how often a sample is taken unless the config says
+// otherwise.
+const DefaultInterval = time.Minute
+
+// DefaultRetention is how long samples are kept unless the config says
+// otherwise: long enough for a week's chart.
+const DefaultRetention = 14 * 24 * time.Hour
+
+// minInterval keeps a misconfigured interval from hammering the device.
+const minInterval = 10 * time.Second
+
+// Store is a directory of per-device sample files.
+type Store struct {
+	dir       string
+	retention time.Duration
+
+	mu     sync.Mutex
+	pruned map[string]string // device -> the UTC day it was last pruned
+}
+
+// DefaultDir returns ~/.pyre/history.
+func DefaultDir() (string, error) {
+	return devicefile.Dir("history")
+}
+
+// NewStore returns a store rooted at dir that keeps samples for retention
+// (0 keeps them all). Nothing is created until the first Append.
+func NewStore(dir string, retention time.Duration) *Store {
+	return &Store{dir: dir, retention: retention, pruned: map[string]string{}}
+}
+
+// FromSettings returns the store and sampling interval the config
+// describes, or a nil store if history is off.
+func FromSettings(s config.HistorySettings) (*Store, time.Duration, error) {
+	if !s.Enabled {
+		return nil, 0, nil
+	}
+	if s.IntervalSeconds < 0 || s.RetentionDays < 0 {
+		return nil, 0, errors.New("history: interval and retention must not be negative")
+	}
+	dir := s.Dir
+	if dir == "" {
+		var err error
+		if dir, err = DefaultDir(); err != nil {
+			return nil, 0, err
+		}
+	}
+	interval := DefaultInterval
+	if s.IntervalSeconds > 0 {
+		interval = max(time.Duration(s.IntervalSeconds)*time.Second, minInterval)
+	}
+	retention := DefaultRetention
+	if s.RetentionDays > 0 {
+		retention = time.Duration(s.RetentionDays) * 24 * time.Hour
+	}
+	return NewStore(dir, retention), interval, nil
+}
+
+// Dir returns the store's root directory.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// deviceDir maps a device name (see devicefile.Device) to its directory.
+func (s *Store) deviceDir(device string) string {
+	return filepath.Join(s.dir, devicefile.SafeName(device))
+}
+
+// Append adds smp to the device's history. The first append of each day
+// also prunes the device's older files.
+func (s *Store) Append(device string, smp Sample) error {
+	smp.Time = smp.Time.UTC().Truncate(time.Second)
+	line, err := json.Marshal(smp)
+	if err != nil {
+		return err
+	}
+	dir := s.deviceDir(device)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("create history directory: %w", err)
+	}
+	day := smp.Time.Format(dayLayout)
+	f, err := os.OpenFile(filepath.Join(dir, day+ext), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) // #nosec G304 -- under the store root
+	if err != nil {
+		return fmt.Errorf("write history: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close() //nolint:errcheck // write error takes precedence
+		return fmt.Errorf("write history: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("write history: %w", err)
+	}
+
+	s.mu.Lock()
+	due := s.pruned[device] != day
+	s.pruned[device] = day
+	s.mu.Unlock()
+	if due {
+		return s.Prune(device, smp.Time)
+	}
+	return nil
+}
+
+// dayFile is one day's samples on disk.
+type dayFile struct {
+	day  time.Time
+	path string
+	gz   bool
+}
+
+// files returns the device's day files, oldest first.
+func (s *Store) files(device string) ([]dayFile, error) {
+	dir := s.deviceDir(device)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var files []dayFile
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name, gz := strings.CutSuffix(e.Name(), gzExt)
+		if !gz {
+			var ok bool
+			if name, ok = strings.CutSuffix(e.Name(), ext); !ok {
+				continue
+			}
+		}
+		day, err := time.Parse(dayLayout, name)
+		if err != nil {
+			continue
+		}
+		files = append(files, dayFile{day: day, path: filepath.Join(dir, e.Name()), gz: gz})
+	}
+	slices.SortFunc(files, func(a, b dayFile) int { return a.day.Compare(b.day) })
+	return files, nil
+}
+
+// Read returns the device's samples taken at or after since, oldest first.
+// A device with no history has none. Lines that don't parse, such as one
+// cut short by a crash, are skipped.
+func (s *Store) Read(device string, since time.Time) ([]Sample, error) {
+	files, err := s.files(device)
+	if err != nil {
+		return nil, err
+	}
+	first := since.UTC().Truncate(24 * time.Hour)
+	var samples []Sample
+	for _, f := range files {
+		if f.day.Before(first) {
+			continue
+		}
+		got, err := readFile(f)
+		if err != nil {
+			return samples, err
+		}
+		for _, smp := range got {
+			if !smp.Time.Before(since) {
+				samples = append(samples, smp)
+			}
+		}
+	}
+	slices.SortStableFunc(samples, func(a, b Sample) int { return a.Time.Compare(b.Time) })
+	return samples, nil
+}
+
+func readFile(f dayFile) ([]Sample, error) {
+	file, err := os.Open(f.path) // #nosec G304 -- path comes from files() under the store root
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close() //nolint:errcheck // read-only
+	var r io.Reader = file
+	if f.gz {
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(f.path), err)
+		}
+		defer zr.Close() //nolint:errcheck // read-only
+		r = zr
+	}
+	var samples []Sample
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		var smp Sample
+		if json.Unmarshal(sc.Bytes(), &smp) == nil && !smp.Time.IsZero() {
+			samples = append(samples, smp)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return samples, fmt.Errorf("%s: %w", filepath.Base(f.path), err)
+	}
+	return samples, nil
+}
+
+// Prune deletes the device's days that ended more than the retention
+// before now, and gzips the rest of the days before today.
+func (s *Store) Prune(device string, now time.Time) error {
+	files, err := s.files(device)
+	if err != nil {
+		return err
+	}
+	today := now.UTC().Truncate(24 * time.Hour)
+	for _, f := range files {
+		end := f.day.Add(24 * time.Hour)
+		switch {
+		case s.retention > 0 && now.Sub(end) > s.retention:
+			if err := os.Remove(f.path); err != nil {
+				return err
+			}
+		case !f.gz && f.day.Before(today):
+			if err := compress(f.path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compress moves a day's samples from path into path.gz. The file is
+// first renamed out of the way, so that samples another process appends
+// meanwhile start a new file for the next prune instead of being lost.
+// Its content is then appended to the gzipped day as a new gzip member,
+// which readers take as a continuation: the day may already have been
+// compressed, by this process or by another writing the same history.
+func compress(path string) error {
+	dir := filepath.Dir(path)
+	claim, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("compress history: %w", err)
+	}
+	if err := claim.Close(); err != nil {
+		return fmt.Errorf("compress history: %w", err)
+	}
+	defer os.Remove(claim.Name()) //nolint:errcheck // gone once compressed, or never filled
+	if err := os.Rename(path, claim.Name()); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil // compressed by another process
+		}
+		return fmt.Errorf("compress history: %w", err)
+	}
+
+	// A writer that opened path before the rename may still be adding a
+	// line: read until the file stops growing.
+	gzPath := strings.TrimSuffix(path, ext) + gzExt
+	done := 0
+	for {
+		data, err := os.ReadFile(claim.Name())
+		if err != nil {
+			return fmt.Errorf("compress history: %w", err)
+		}
+		if len(data) == done {
+			return nil
+		}
+		if err := appendMember(gzPath, data[done:]); err != nil {
+			return fmt.Errorf("compress history: %w", err)
+		}
+		done = len(data)
+	}
+}
+
+// appendMember appends data to the gzip file at path as one gzip member,
+// written with a single write so that members from two processes never
+// interleave. data is ended with a newline, so that a torn last line does
+// not run into the next member's first.
+func appendMember(path string, data []byte) error {
+	if data[len(data)-1] != '\n' {
+		data = append(data, '\n')
+	}
+	var b bytes.Buffer
+	zw := gzip.NewWriter(&b)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) // #nosec G304 -- under the store root
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b.Bytes()); err != nil {
+		f.Close() //nolint:errcheck // write error takes precedence
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,241 @@
+package history
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/config"
+	"github.com/jp2195/pyre/internal/models"
+	"github.com/jp2195/pyre/internal/testutil"
+)
+
+var t0 = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func cpu(v float64) Sample {
+	return Sample{ManagementCPU: &v}
+}
+
+func at(s Sample, t time.Time) Sample {
+	s.Time = t
+	return s
+}
+
+func TestStore_AppendAndRead(t *testing.T) {
+	s := NewStore(t.TempDir(), 0)
+	for i := range 3 {
+		if err := s.Append("10.0.0.1:443", at(cpu(float64(10*i)), t0.Add(time.Duration(i)*time.Hour))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Append("10.0.0.1:443@0123", at(cpu(99), t0)); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.Read("10.0.0.1:443", t0.Add(30*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || *got[0].ManagementCPU != 10 || !got[1].Time.Equal(t0.Add(2*time.Hour)) {
+		t.Errorf("read = %+v", got)
+	}
+	if _, err := os.Stat(filepath.Join(s.Dir(), "10.0.0.1_443", "2026-03-10.jsonl")); err != nil {
+		t.Errorf("day file: %v", err)
+	}
+	if got, _ := s.Read("10.0.0.1:443@0123", t0); len(got) != 1 || *got[0].ManagementCPU != 99 {
+		t.Errorf("the Panorama target should have its own history: %+v", got)
+	}
+	if got, err := s.Read("unknown", t0); err != nil || got != nil {
+		t.Errorf("unknown device = %v, %v", got, err)
+	}
+}
+
+func TestStore_PruneCompressesAndExpires(t *testing.T) {
+	s := NewStore(t.TempDir(), 3*24*time.Hour)
+	for day := range 6 {
+		// Write each day straight to its file, as if pyre had run daily.
+		when := t0.AddDate(0, 0, day)
+		s.pruned["fw1"] = when.Format(dayLayout)
+		if err := s.Append("fw1", at(cpu(float64(day)), when)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The first append on a new day prunes.
+	if err := s.Append("fw1", at(cpu(6), t0.AddDate(0, 0, 6))); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, _ := os.ReadDir(filepath.Join(s.Dir(), "fw1"))
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	want := []string{"2026-03-13.jsonl.gz", "2026-03-14.jsonl.gz", "2026-03-15.jsonl.gz", "2026-03-16.jsonl"}
+	if len(names) != len(want) {
+		t.Fatalf("files = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("files = %v, want %v", names, want)
+		}
+	}
+
+	got, err := s.Read("fw1", t0.AddDate(0, 0, 4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || *got[0].ManagementCPU != 4 || *got[2].ManagementCPU != 6 {
+		t.Errorf("read across gzipped and open days = %+v", got)
+	}
+}
+
+func TestStore_SkipsTornLines(t *testing.T) {
+	s := NewStore(t.TempDir(), 0)
+	if err := s.Append("fw1", at(cpu(5), t0)); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(filepath.Join(s.Dir(), "fw1", "2026-03-10.jsonl"), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"t":"2026-03-10T12:01:00Z","cpu":`)
+	f.Close()
+	if got, err := s.Read("fw1", t0); err != nil || len(got) != 1 {
+		t.Errorf("read = %+v, %v; want the one whole sample", got, err)
+	}
+}
+
+func TestFromSettings(t *testing.T) {
+	if s, _, err := FromSettings(config.HistorySettings{}); s != nil || err != nil {
+		t.Errorf("history should be off by default: %v, %v", s, err)
+	}
+	s, interval, err := FromSettings(config.HistorySettings{Enabled: true, Dir: "/tmp/h", IntervalSeconds: 2, RetentionDays: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Dir() != "/tmp/h" || interval != minInterval || s.retention != 7*24*time.Hour {
+		t.Errorf("store %+v, interval %s", s, interval)
+	}
+	if _, _, err := FromSettings(config.HistorySettings{Enabled: true, RetentionDays: -1}); err == nil {
+		t.Error("a negative retention should be rejected")
+	}
+}
+
+func TestSampler_Rates(t *testing.T) {
+	var s Sampler
+	ifaces := func(in, out int64) []models.Interface {
+		return []models.Interface{{Name: "ethernet1/1", BytesIn: in, BytesOut: out}, {Name: "ethernet1/2"}}
+	}
+	first := s.Sample(Reading{Time: t0, Interfaces: ifaces(1000, 1000)})
+	if first.Interfaces != nil {
+		t.Errorf("the first sample has nothing to compare with: %+v", first.Interfaces)
+	}
+	second := s.Sample(Reading{Time: t0.Add(10 * time.Second), Interfaces: ifaces(11000, 3500)})
+	if r := second.Interfaces["ethernet1/1"]; r.In != 8000 || r.Out != 2000 || len(second.Interfaces) != 1 {
+		t.Errorf("rates = %+v", second.Interfaces)
+	}
+	// A counter reset drops that interval rather than charting a spike.
+	if reset := s.Sample(Reading{Time: t0.Add(20 * time.Second), Interfaces: ifaces(10, 10)}); reset.Interfaces != nil {
+		t.Errorf("rates after reset = %+v", reset.Interfaces)
+	}
+	// So does a gap long enough to flatten what happened.
+	if gap := s.Sample(Reading{Time: t0.Add(time.Hour), Interfaces: ifaces(1e9, 1e9)}); gap.Interfaces != nil {
+		t.Errorf("rates across a gap = %+v", gap.Interfaces)
+	}
+}
+
+func TestSampler_Counts(t *testing.T) {
+	var s Sampler
+	smp := s.Sample(Reading{
+		Time:     t0,
+		Tunnels:  []models.IPSecTunnel{{Name: "a", State: "up"}, {Name: "b", State: "down"}},
+		BGPPeers: []models.BGPNeighbor{{PeerAddress: "10.0.0.1", State: "Established"}},
+	})
+	if smp.Tunnels.Up != 1 || smp.Tunnels.Total != 2 || len(smp.Tunnels.Down) != 1 || smp.Tunnels.Down[0] != "b" {
+		t.Errorf("tunnels = %+v", smp.Tunnels)
+	}
+	if smp.BGPPeers.Up != 1 || smp.BGPPeers.Down != nil || smp.ManagementCPU != nil {
+		t.Errorf("sample = %+v", smp)
+	}
+}
+
+func TestSeries(t *testing.T) {
+	samples := []Sample{at(cpu(10), t0), at(cpu(30), t0.Add(time.Minute)), at(Sample{}, t0.Add(2*time.Minute)), at(cpu(50), t0.Add(5*time.Minute))}
+	got := Series(samples, t0, t0.Add(6*time.Minute), 3, func(s Sample) (float64, bool) {
+		if s.ManagementCPU == nil {
+			return 0, false
+		}
+		return *s.ManagementCPU, true
+	})
+	if len(got) != 3 || got[0] != 20 || !math.IsNaN(got[1]) || got[2] != 50 {
+		t.Errorf("series = %v, want [20 NaN 50]", got)
+	}
+}
+
+func TestTopInterfaces(t *testing.T) {
+	samples := []Sample{
+		{Interfaces: map[string]Rate{"e1": {In: 5}, "e2": {In: 100}}},
+		{Interfaces: map[string]Rate{"e3": {Out: 50}, "e1": {In: 1}}},
+	}
+	if got := TopInterfaces(samples, 2); len(got) != 2 || got[0] != "e2" || got[1] != "e3" {
+		t.Errorf("top = %v", got)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	mock := testutil.NewMockPANOS()
+	defer mock.Close()
+	client, err := api.NewClient(mock.Host(), "test-api-key", api.ClientOptions{Insecure: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := Collect(context.Background(), client, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Resources == nil || r.DataPlaneCPU == nil || r.Sessions == nil || r.Interfaces == nil || r.Tunnels == nil || r.BGPPeers == nil {
+		t.Errorf("reading is missing reads: %+v", r)
+	}
+}
+
+func TestStore_AppendToCompressedDay(t *testing.T) {
+	s := NewStore(t.TempDir(), 0)
+	next := t0.AddDate(0, 0, 1)
+	for i, when := range []time.Time{t0, t0.Add(time.Hour), next} {
+		if err := s.Append("fw1", at(cpu(float64(i)), when)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(s.Dir(), "fw1", "2026-03-10.jsonl.gz")); err != nil {
+		t.Fatalf("the first day should be compressed: %v", err)
+	}
+
+	// Another process, such as pyre watch, writes a sample timed before
+	// midnight after the day was compressed.
+	if err := s.Append("fw1", at(cpu(3), t0.Add(2*time.Hour))); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Prune("fw1", next); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(s.Dir(), "fw1", "2026-03-10.jsonl")); !os.IsNotExist(err) {
+		t.Errorf("the late sample's file should be compressed too: %v", err)
+	}
+
+	got, err := s.Read("fw1", t0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cpus []float64
+	for _, smp := range got {
+		cpus = append(cpus, *smp.ManagementCPU)
+	}
+	if !slices.Equal(cpus, []float64{0, 1, 3, 2}) {
+		t.Errorf("read back cpu %v, want [0 1 3 2]", cpus)
+	}
+}
@@ -0,0 +1,61 @@
+package history
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Series averages one value of samples into n equal buckets spanning
+// [from, to). A bucket with no value is NaN, so a chart can leave a gap
+// for the time pyre wasn't sampling. value reports false for a sample
+// that lacks the value.
+func Series(samples []Sample, from, to time.Time, n int, value func(Sample) (float64, bool)) []float64 {
+	span := to.Sub(from)
+	if n <= 0 || span <= 0 {
+		return nil
+	}
+	out := make([]float64, n)
+	counts := make([]int, n)
+	for _, smp := range samples {
+		if smp.Time.Before(from) || !smp.Time.Before(to) {
+			continue
+		}
+		v, ok := value(smp)
+		if !ok {
+			continue
+		}
+		i := min(int(int64(smp.Time.Sub(from))*int64(n)/int64(span)), n-1)
+		out[i] += v
+		counts[i]++
+	}
+	for i, c := range counts {
+		if c == 0 {
+			out[i] = math.NaN()
+		} else {
+			out[i] /= float64(c)
+		}
+	}
+	return out
+}
+
+// TopInterfaces returns up to n interface names with the highest peak
+// traffic (in plus out) across samples, busiest first.
+func TopInterfaces(samples []Sample, n int) []string {
+	peak := map[string]int64{}
+	for _, smp := range samples {
+		for name, r := range smp.Interfaces {
+			peak[name] = max(peak[name], r.In+r.Out)
+		}
+	}
+	names := make([]string, 0, len(peak))
+	for name := range peak {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		return cmp.Or(cmp.Compare(peak[b], peak[a]), strings.Compare(a, b))
+	})
+	return names[:min(n, len(names))]
+}
@@ -0,0 +1,163 @@
+package mockpanos
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// jobStep is how far an active validate or commit job advances each time
+// jobs are polled, so a client sees it run for a few polls before it
+// finishes.
+const jobStep = 40
+
+const jobTimeLayout = "2006/01/02 15:04:05"
+
+// commitJob is a validate or commit job that hasn't finished yet.
+type commitJob struct {
+	commit bool
+	admins []string // partial scope; empty means every admin's changes
+}
+
+// partialAdmins extracts the admin members of a <partial> scope from a
+// commit or validate cmd.
+func partialAdmins(cmd string) []string {
+	var c struct {
+		Admins []string `xml:"partial>admin>member"`
+	}
+	_ = xml.Unmarshal([]byte(cmd), &c) //nolint:errcheck // a malformed scope is treated as a full commit
+	return c.Admins
+}
+
+// handleCommit implements type=commit: it enqueues a commit job, or reports
+// that there is nothing to commit.
+func (s *Server) handleCommit(w http.ResponseWriter, cmd string) {
+	if !strings.HasPrefix(strings.TrimSpace(cmd), "<commit") {
+		writeError(w, "Invalid commit command")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	admins := partialAdmins(cmd)
+	if in, _ := splitEdits(s.edits, admins); len(in) == 0 {
+		writeRaw(w, `<response status="success" code="19"><msg><line>There are no changes to commit.</line></msg></response>`)
+		return
+	}
+	id := s.enqueue("Commit", commitJob{commit: true, admins: admins})
+	writeResult(w, fmt.Sprintf("<msg><line>Commit job enqueued with jobid %d</line></msg><job>%d</job>", id, id))
+}
+
+// handleValidate implements the validate op, which runs as a job like a
+// commit but leaves the device unchanged.
+func (s *Server) handleValidate(w http.ResponseWriter, cmd string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.enqueue("Validate", commitJob{admins: partialAdmins(cmd)})
+	writeResult(w, fmt.Sprintf("<msg><line>Validate job enqueued with jobid %d</line></msg><job>%d</job>", id, id))
+}
+
+// enqueue records a new active job. The caller holds s.mu.
+func (s *Server) enqueue(typ string, cj commitJob) int {
+	s.nextJob++
+	now := time.Now().Format(jobTimeLayout)
+	s.jobs = append(s.jobs, Job{
+		ID: s.nextJob, Type: typ, Status: "ACT", Result: "PEND", Progress: "0",
+		Enqueued: now, Started: now, User: s.ds.Username,
+	})
+	s.active[s.nextJob] = cj
+	return s.nextJob
+}
+
+var jobIDPattern = regexp.MustCompile(`<id>(\d+)</id>`)
+
+// showJobs answers show jobs all / show jobs id, advancing active jobs
+// first.
+func (s *Server) showJobs(cmd string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.jobs {
+		j := &s.jobs[i]
+		cj, ok := s.active[j.ID]
+		if !ok {
+			continue
+		}
+		progress, _ := strconv.Atoi(j.Progress) //nolint:errcheck // always set by enqueue
+		if progress += jobStep; progress < 100 {
+			j.Progress = strconv.Itoa(progress)
+			continue
+		}
+		delete(s.active, j.ID)
+		s.finish(j, cj)
+	}
+
+	jobs := s.jobs
+	if m := jobIDPattern.FindStringSubmatch(cmd); m != nil {
+		id, _ := strconv.Atoi(m[1]) //nolint:errcheck // the pattern only matches digits
+		jobs = nil
+		for _, j := range s.jobs {
+			if j.ID == id {
+				jobs = append(jobs, j)
+			}
+		}
+		if jobs == nil {
+			return ""
+		}
+	}
+	return marshalNamed("job", jobs)
+}
+
+// finish completes j. A successful commit moves the in-scope candidate
+// edits into the running config. The caller holds s.mu.
+func (s *Server) finish(j *Job, cj commitJob) {
+	j.Status = "FIN"
+	j.Progress = "100"
+	j.Finished = time.Now().Format(jobTimeLayout)
+	j.Warnings = s.ds.CommitWarnings
+
+	if len(cj.admins) > 0 {
+		j.Details = append(j.Details, "Partial changes to commit: changes to configuration by administrators: "+
+			strings.Join(cj.admins, ", "))
+	}
+
+	fail := func(lines ...string) {
+		j.Result = "FAIL"
+		j.Details = append(j.Details, lines...)
+		if cj.commit {
+			j.Details = append(j.Details, "Commit failed")
+		} else {
+			j.Details = append(j.Details, "Validation failed")
+		}
+	}
+	if len(s.ds.CommitErrors) > 0 {
+		fail(append([]string{"Validation Error:"}, s.ds.CommitErrors...)...)
+		return
+	}
+	if !cj.commit {
+		j.Result = "OK"
+		j.Details = append(j.Details, "Configuration is valid")
+		return
+	}
+
+	in, out := splitEdits(s.edits, cj.admins)
+	running, err := applyEdits(s.running, in)
+	if err != nil {
+		fail(err.Error())
+		return
+	}
+	// Edits left out of a partial commit may not apply on their own; the
+	// old candidate stands in until they are committed too.
+	if candidate, err := applyEdits(running, out); err == nil {
+		s.candidate = candidate
+	}
+	s.running = running
+	s.edits = out
+	j.Result = "OK"
+	j.Details = append(j.Details, "Configuration committed successfully")
+}
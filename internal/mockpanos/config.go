@@ -0,0 +1,65 @@
+package mockpanos
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/jp2195/pyre/internal/pancfg"
+)
+
+// buildConfigs parses the dataset's running config and derives the
+// candidate by applying CandidateEdits to a copy of it.
+func buildConfigs(ds *Dataset) (running, candidate *pancfg.Node, err error) {
+	running, err = pancfg.Parse(ds.Config)
+	if err != nil {
+		return nil, nil, err
+	}
+	candidate, err = applyEdits(running, ds.CandidateEdits)
+	if err != nil {
+		return nil, nil, err
+	}
+	return running, candidate, nil
+}
+
+// applyEdits returns a copy of base with edits applied in order.
+func applyEdits(base *pancfg.Node, edits []ConfigEdit) (*pancfg.Node, error) {
+	out := base.Clone()
+	for i, e := range edits {
+		if err := applyEdit(out, e); err != nil {
+			return nil, fmt.Errorf("candidate edit %d (%s %s): %w", i+1, e.Action, e.XPath, err)
+		}
+	}
+	return out, nil
+}
+
+func applyEdit(root *pancfg.Node, e ConfigEdit) error {
+	switch e.Action {
+	case "add", "edit":
+		elem, err := pancfg.ParseElement(e.Element)
+		if err != nil {
+			return err
+		}
+		return pancfg.Replace(root, e.XPath, elem)
+	case "delete":
+		removed, err := pancfg.Remove(root, e.XPath)
+		if err == nil && !removed {
+			err = fmt.Errorf("nothing to delete")
+		}
+		return err
+	default:
+		return fmt.Errorf("unknown action %q", e.Action)
+	}
+}
+
+// splitEdits partitions edits into those made by admins (all of them when
+// admins is empty) and the rest, preserving order.
+func splitEdits(edits []ConfigEdit, admins []string) (in, out []ConfigEdit) {
+	for _, e := range edits {
+		if len(admins) == 0 || slices.Contains(admins, e.Admin) {
+			in = append(in, e)
+		} else {
+			out = append(out, e)
+		}
+	}
+	return in, out
+}
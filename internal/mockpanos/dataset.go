@@ -0,0 +1,307 @@
+package mockpanos
+
+import (
+	"fmt"
+	"os"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// Dataset is everything a fake device knows about itself. Operational state
+// (sessions, HA, counters, ...) is modeled field by field; configuration is a
+// single PAN-OS XML document that type=config requests are evaluated against,
+// so rules and objects live exactly where the real device keeps them.
+//
+// Datasets round-trip through YAML (see LoadDataset) so a demo can be tuned
+// without recompiling. Field XML tags match the PAN-OS response schema and
+// are used directly when rendering op responses.
+type Dataset struct {
+	// Panorama switches the device personality: managed-device listing is
+	// only answered when set, and requests carrying a target serial are
+	// answered by Managed.
+	Panorama bool `yaml:"panorama,omitempty"`
+
+	// Credentials accepted by type=keygen, and the key it hands back.
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	APIKey   string `yaml:"api_key"`
+
+	System      SystemInfo  `yaml:"system"`
+	Resources   Resources   `yaml:"resources"`
+	SessionInfo SessionInfo `yaml:"session_info"`
+	HA          HAState     `yaml:"ha"`
+
+	Sessions   []Session   `yaml:"sessions,omitempty"`
+	Interfaces []Interface `yaml:"interfaces,omitempty"`
+	RuleHits   []RuleHit   `yaml:"rule_hits,omitempty"`
+	AppsSeen   []AppSeen   `yaml:"apps_seen,omitempty"`
+	Counters   []Counter   `yaml:"counters,omitempty"`
+	GPUsers    []GPUser    `yaml:"globalprotect_users,omitempty"`
+	Licenses   []License   `yaml:"licenses,omitempty"`
+	Jobs       []Job       `yaml:"jobs,omitempty"`
+	Admins     []Admin     `yaml:"admins,omitempty"`
+
+	TrafficLogs []TrafficLog `yaml:"traffic_logs,omitempty"`
+	ThreatLogs  []ThreatLog  `yaml:"threat_logs,omitempty"`
+	SystemLogs  []SystemLog  `yaml:"system_logs,omitempty"`
+
+	// Devices is the Panorama managed-device inventory.
+	Devices []ManagedDevice `yaml:"devices,omitempty"`
+	// Managed answers requests addressed to a managed device (target=serial).
+	// The system identity is taken from the matching Devices entry, so one
+	// dataset can stand in for the whole fleet.
+	Managed *Dataset `yaml:"managed,omitempty"`
+
+	// Ops are canned answers for op commands the built-in handlers don't
+	// cover. They are consulted first, so they can also override built-ins.
+	Ops []OpResponse `yaml:"ops,omitempty"`
+
+	// Config is the device configuration as a PAN-OS XML document rooted
+	// at <config>. It is the running config.
+	Config string `yaml:"config"`
+
+	// CandidateEdits are uncommitted changes. The candidate config is the
+	// running config with these applied in order, and they double as the
+	// change journal reported by show config list changes.
+	CandidateEdits []ConfigEdit `yaml:"candidate_edits,omitempty"`
+
+	// CommitWarnings are reported by every validate and commit job.
+	// CommitErrors, when set, make those jobs fail with these lines.
+	CommitWarnings []string `yaml:"commit_warnings,omitempty"`
+	CommitErrors   []string `yaml:"commit_errors,omitempty"`
+}
+
+// ConfigEdit is one uncommitted change. Action is "add" or "edit" (Element
+// is placed at XPath, replacing anything there) or "delete".
+type ConfigEdit struct {
+	Admin   string `yaml:"admin" xml:"admin"`
+	Action  string `yaml:"action" xml:"action"`
+	XPath   string `yaml:"xpath" xml:"xpath"`
+	Element string `yaml:"element,omitempty" xml:"-"`
+	Info    string `yaml:"info,omitempty" xml:"info,omitempty"`
+	Time    string `yaml:"time" xml:"time"`
+}
+
+// OpResponse is a canned op command answer. Match is a substring of the
+// XML cmd; Result is the raw XML placed inside <result>.
+type OpResponse struct {
+	Match  string `yaml:"match"`
+	Result string `yaml:"result"`
+}
+
+type SystemInfo struct {
+	Hostname        string `yaml:"hostname" xml:"hostname"`
+	Model           string `yaml:"model" xml:"model"`
+	Serial          string `yaml:"serial" xml:"serial"`
+	Version         string `yaml:"sw_version" xml:"sw-version"`
+	Uptime          string `yaml:"uptime" xml:"uptime"`
+	IPAddress       string `yaml:"ip_address,omitempty" xml:"ip-address,omitempty"`
+	Netmask         string `yaml:"netmask,omitempty" xml:"netmask,omitempty"`
+	DefaultGateway  string `yaml:"default_gateway,omitempty" xml:"default-gateway,omitempty"`
+	MACAddress      string `yaml:"mac_address,omitempty" xml:"mac-address,omitempty"`
+	TimeZone        string `yaml:"time_zone,omitempty" xml:"time-zone,omitempty"`
+	MultiVsys       string `yaml:"multi_vsys,omitempty" xml:"multi-vsys"`
+	OperationalMode string `yaml:"operational_mode,omitempty" xml:"operational-mode"`
+	AppVersion      string `yaml:"app_version,omitempty" xml:"app-version,omitempty"`
+	ThreatVersion   string `yaml:"threat_version,omitempty" xml:"threat-version,omitempty"`
+	AVVersion       string `yaml:"av_version,omitempty" xml:"av-version,omitempty"`
+	WildFireVersion string `yaml:"wildfire_version,omitempty" xml:"wildfire-version,omitempty"`
+}
+
+// Resources feeds the `top` output returned by show system resources.
+type Resources struct {
+	UserCPU  float64 `yaml:"user_cpu"`
+	SysCPU   float64 `yaml:"sys_cpu"`
+	Load1    float64 `yaml:"load1"`
+	Load5    float64 `yaml:"load5"`
+	Load15   float64 `yaml:"load15"`
+	MemTotal int64   `yaml:"mem_total_kib"`
+	MemUsed  int64   `yaml:"mem_used_kib"`
+}
+
+type SessionInfo struct {
+	Active int64 `yaml:"active" xml:"num-active"`
+	Max    int64 `yaml:"max" xml:"num-max"`
+	Kbps   int64 `yaml:"kbps" xml:"kbps"`
+	CPS    int64 `yaml:"cps" xml:"cps"`
+}
+
+type HAState struct {
+	Enabled bool   `yaml:"enabled"`
+	Local   string `yaml:"local"`
+	Peer    string `yaml:"peer"`
+	Sync    string `yaml:"sync"`
+}
+
+type Session struct {
+	ID          int64  `yaml:"id" xml:"idx"`
+	Vsys        string `yaml:"vsys" xml:"vsys"`
+	Application string `yaml:"application" xml:"application"`
+	State       string `yaml:"state" xml:"state"`
+	Type        string `yaml:"type" xml:"type"`
+	Source      string `yaml:"source" xml:"source"`
+	SourcePort  int    `yaml:"sport" xml:"sport"`
+	Dest        string `yaml:"destination" xml:"dst"`
+	DestPort    int    `yaml:"dport" xml:"dport"`
+	From        string `yaml:"from" xml:"from"`
+	To          string `yaml:"to" xml:"to"`
+	NATSource   string `yaml:"nat_source,omitempty" xml:"xsource,omitempty"`
+	NATPort     int    `yaml:"nat_sport,omitempty" xml:"xsport,omitempty"`
+	Protocol    int    `yaml:"proto" xml:"proto"`
+	Rule        string `yaml:"rule" xml:"security-rule"`
+	StartTime   string `yaml:"start_time" xml:"start-time"`
+	Bytes       int64  `yaml:"bytes" xml:"total-byte-count"`
+}
+
+type Interface struct {
+	Name   string `yaml:"name"`
+	Zone   string `yaml:"zone,omitempty"`
+	IP     string `yaml:"ip,omitempty"`
+	State  string `yaml:"state"`
+	Speed  string `yaml:"speed,omitempty"`
+	Duplex string `yaml:"duplex,omitempty"`
+	MAC    string `yaml:"mac,omitempty"`
+}
+
+// RuleHit is the hit-count record for one rule. Rulebase is "security" or
+// "nat"; LastHit is a Unix timestamp (0 = never).
+type RuleHit struct {
+	Rulebase string `yaml:"rulebase"`
+	Name     string `yaml:"name"`
+	Count    int64  `yaml:"count"`
+	LastHit  int64  `yaml:"last_hit"`
+}
+
+// AppSeen is an application the Policy Optimizer saw match a security rule.
+type AppSeen struct {
+	Rule      string `yaml:"rule"`
+	App       string `yaml:"app"`
+	Bytes     int64  `yaml:"bytes"`
+	FirstSeen int64  `yaml:"first_seen"`
+	LastSeen  int64  `yaml:"last_seen"`
+}
+
+type Counter struct {
+	Name     string `yaml:"name" xml:"name"`
+	Value    int64  `yaml:"value" xml:"value"`
+	Rate     int64  `yaml:"rate" xml:"rate"`
+	Aspect   string `yaml:"aspect" xml:"aspect"`
+	Desc     string `yaml:"desc" xml:"desc"`
+	Severity string `yaml:"severity" xml:"severity"`
+}
+
+type GPUser struct {
+	Username  string `yaml:"username" xml:"username"`
+	Domain    string `yaml:"domain" xml:"domain"`
+	Computer  string `yaml:"computer" xml:"computer"`
+	Client    string `yaml:"client" xml:"client"`
+	VirtualIP string `yaml:"virtual_ip" xml:"virtual-ip"`
+	LoginTime string `yaml:"login_time" xml:"login-time"`
+}
+
+type License struct {
+	Feature     string `yaml:"feature" xml:"feature"`
+	Description string `yaml:"description" xml:"description"`
+	Expires     string `yaml:"expires" xml:"expires"`
+	Expired     string `yaml:"expired" xml:"expired"`
+}
+
+type Job struct {
+	ID       int      `yaml:"id" xml:"id"`
+	Type     string   `yaml:"type" xml:"type"`
+	Status   string   `yaml:"status" xml:"status"`
+	Result   string   `yaml:"result" xml:"result"`
+	Progress string   `yaml:"progress" xml:"progress"`
+	Details  []string `yaml:"details,omitempty" xml:"details>line,omitempty"`
+	Warnings []string `yaml:"warnings,omitempty" xml:"warnings>line,omitempty"`
+	Enqueued string   `yaml:"enqueued" xml:"tenq"`
+	Started  string   `yaml:"started" xml:"tdeq"`
+	Finished string   `yaml:"finished,omitempty" xml:"tfin,omitempty"`
+	User     string   `yaml:"user,omitempty" xml:"user,omitempty"`
+}
+
+type Admin struct {
+	Name      string `yaml:"name" xml:"admin"`
+	From      string `yaml:"from" xml:"from"`
+	Type      string `yaml:"type" xml:"type"`
+	StartedAt string `yaml:"session_start" xml:"session-start"`
+	IdleFor   string `yaml:"idle_for" xml:"idle-for"`
+}
+
+type TrafficLog struct {
+	Time       string `yaml:"time" xml:"time_generated"`
+	Subtype    string `yaml:"subtype" xml:"subtype"`
+	Source     string `yaml:"source" xml:"src"`
+	Dest       string `yaml:"destination" xml:"dst"`
+	SourcePort int    `yaml:"sport" xml:"sport"`
+	DestPort   int    `yaml:"dport" xml:"dport"`
+	From       string `yaml:"from" xml:"from"`
+	To         string `yaml:"to" xml:"to"`
+	Rule       string `yaml:"rule" xml:"rule"`
+	App        string `yaml:"app" xml:"app"`
+	Action     string `yaml:"action" xml:"action"`
+	Bytes      int64  `yaml:"bytes" xml:"bytes"`
+	Packets    int64  `yaml:"packets" xml:"packets"`
+	User       string `yaml:"user,omitempty" xml:"srcuser,omitempty"`
+	Protocol   string `yaml:"proto" xml:"proto"`
+	EndReason  string `yaml:"end_reason,omitempty" xml:"session_end_reason,omitempty"`
+}
+
+type ThreatLog struct {
+	Time       string `yaml:"time" xml:"time_generated"`
+	Subtype    string `yaml:"subtype" xml:"subtype"`
+	Source     string `yaml:"source" xml:"src"`
+	Dest       string `yaml:"destination" xml:"dst"`
+	SourcePort int    `yaml:"sport" xml:"sport"`
+	DestPort   int    `yaml:"dport" xml:"dport"`
+	From       string `yaml:"from" xml:"from"`
+	To         string `yaml:"to" xml:"to"`
+	Rule       string `yaml:"rule" xml:"rule"`
+	App        string `yaml:"app" xml:"app"`
+	Action     string `yaml:"action" xml:"action"`
+	ThreatID   string `yaml:"threat_id" xml:"threatid"`
+	Threat     string `yaml:"threat" xml:"threat"`
+	Category   string `yaml:"category" xml:"thr_category"`
+	Severity   string `yaml:"severity" xml:"severity"`
+	Direction  string `yaml:"direction" xml:"direction"`
+}
+
+type SystemLog struct {
+	Time        string `yaml:"time" xml:"time_generated"`
+	Subtype     string `yaml:"subtype" xml:"subtype"`
+	Severity    string `yaml:"severity" xml:"severity"`
+	EventID     string `yaml:"event_id" xml:"eventid"`
+	Description string `yaml:"description" xml:"opaque"`
+}
+
+type ManagedDevice struct {
+	Serial      string `yaml:"serial" xml:"serial"`
+	Hostname    string `yaml:"hostname" xml:"hostname"`
+	IPAddress   string `yaml:"ip_address" xml:"ip-address"`
+	Model       string `yaml:"model" xml:"model"`
+	Version     string `yaml:"sw_version" xml:"sw-version"`
+	HAState     string `yaml:"ha_state,omitempty" xml:"ha>state,omitempty"`
+	Connected   bool   `yaml:"connected" xml:"-"`
+	DeviceGroup string `yaml:"device_group" xml:"device-group"`
+}
+
+// LoadDataset reads a YAML dataset from path. Fields the file leaves out
+// keep the values of base (typically DefaultFirewall or DefaultPanorama),
+// so a dataset only needs to describe what it changes.
+func LoadDataset(path string, base *Dataset) (*Dataset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading dataset: %w", err)
+	}
+	ds := base
+	if ds == nil {
+		ds = &Dataset{}
+	}
+	if err := yaml.Unmarshal(data, ds); err != nil {
+		return nil, fmt.Errorf("parsing dataset %s: %w", path, err)
+	}
+	if _, _, err := buildConfigs(ds); err != nil {
+		return nil, fmt.Errorf("dataset %s: %w", path, err)
+	}
+	return ds, nil
+}
@@ -0,0 +1,235 @@
+package mockpanos
+
+import _ "embed"
+
+var (
+	//go:embed data/firewall.xml
+	firewallConfig string
+	//go:embed data/branch.xml
+	branchConfig string
+	//go:embed data/panorama.xml
+	panoramaConfig string
+)
+
+// Builtin returns DefaultPanorama or DefaultFirewall.
+func Builtin(panorama bool) *Dataset {
+	if panorama {
+		return DefaultPanorama()
+	}
+	return DefaultFirewall()
+}
+
+// DefaultFirewall returns the built-in standalone firewall. Tests assert
+// against its values, so change them with care.
+func DefaultFirewall() *Dataset {
+	return &Dataset{
+		Username: "admin",
+		Password: "admin",
+		APIKey:   "LUFRPT1234567890abcdef==",
+		System: SystemInfo{
+			Hostname:        "mock-firewall",
+			Model:           "PA-VM",
+			Serial:          "007200001234",
+			Version:         "10.2.3",
+			Uptime:          "15 days, 3:42:18",
+			IPAddress:       "192.0.2.10",
+			Netmask:         "255.255.255.0",
+			DefaultGateway:  "192.0.2.1",
+			MACAddress:      "00:1b:17:00:00:10",
+			TimeZone:        "UTC",
+			MultiVsys:       "off",
+			OperationalMode: "normal",
+			AppVersion:      "8700-8040",
+			ThreatVersion:   "8700-8040",
+			AVVersion:       "4612-5131",
+			WildFireVersion: "812345-816001",
+		},
+		Resources: Resources{
+			UserCPU: 5.2, SysCPU: 2.1,
+			Load1: 0.45, Load5: 0.52, Load15: 0.48,
+			MemTotal: 16384000, MemUsed: 12288000,
+		},
+		SessionInfo: SessionInfo{Active: 15432, Max: 262144, Kbps: 524288, CPS: 1250},
+		HA:          HAState{Enabled: true, Local: "active", Peer: "passive", Sync: "synchronized"},
+		Sessions: []Session{
+			{ID: 12345, Vsys: "vsys1", Application: "web-browsing", State: "ACTIVE", Type: "FLOW",
+				Source: "192.168.1.100", SourcePort: 54321, Dest: "8.8.8.8", DestPort: 443, From: "trust", To: "untrust",
+				NATSource: "10.0.0.100", NATPort: 54321, Protocol: 6, Rule: "allow-outbound",
+				StartTime: "Mon Jan 20 14:30:00 2025", Bytes: 1048576},
+			{ID: 12346, Vsys: "vsys1", Application: "ssl", State: "ACTIVE", Type: "FLOW",
+				Source: "192.168.1.101", SourcePort: 54322, Dest: "1.1.1.1", DestPort: 443, From: "trust", To: "untrust",
+				NATSource: "10.0.0.101", NATPort: 54322, Protocol: 6, Rule: "allow-outbound",
+				StartTime: "Mon Jan 20 14:31:00 2025", Bytes: 524288},
+			{ID: 12347, Vsys: "vsys1", Application: "dns", State: "ACTIVE", Type: "FLOW",
+				Source: "192.168.1.102", SourcePort: 54323, Dest: "8.8.4.4", DestPort: 53, From: "trust", To: "untrust",
+				NATSource: "10.0.0.102", NATPort: 54323, Protocol: 17, Rule: "allow-dns",
+				StartTime: "Mon Jan 20 14:32:00 2025", Bytes: 2048},
+		},
+		Interfaces: []Interface{
+			{Name: "ethernet1/1", Zone: "untrust", IP: "203.0.113.1/24", State: "up", Speed: "1000", Duplex: "full", MAC: "00:1b:17:00:01:01"},
+			{Name: "ethernet1/2", Zone: "trust", IP: "192.168.1.1/24", State: "up", Speed: "1000", Duplex: "full", MAC: "00:1b:17:00:01:02"},
+			{Name: "ethernet1/3", Zone: "dmz", IP: "10.0.0.1/24", State: "up", Speed: "1000", Duplex: "full", MAC: "00:1b:17:00:01:03"},
+			{Name: "ethernet1/4", State: "down", MAC: "00:1b:17:00:01:04"},
+		},
+		RuleHits: []RuleHit{
+			{Rulebase: "security", Name: "allow-outbound", Count: 1543289, LastHit: 1737456000},
+			{Rulebase: "security", Name: "allow-dns", Count: 892341, LastHit: 1737455900},
+			{Rulebase: "security", Name: "deny-all", Count: 12453, LastHit: 1737455800},
+			{Rulebase: "security", Name: "deprecated-rule", Count: 0, LastHit: 0},
+		},
+		Counters: []Counter{
+			{Name: "flow_threat_block", Value: 1247, Rate: 2, Aspect: "threat", Desc: "Threats blocked", Severity: "high"},
+			{Name: "flow_threat_alert", Value: 3891, Rate: 5, Aspect: "threat", Desc: "Threats alerted", Severity: "medium"},
+			{Name: "flow_threat_critical", Value: 23, Rate: 0, Aspect: "threat", Desc: "Critical threats", Severity: "critical"},
+			{Name: "flow_threat_high", Value: 156, Rate: 1, Aspect: "threat", Desc: "High severity threats", Severity: "high"},
+		},
+		GPUsers: []GPUser{
+			{Username: "jsmith", Domain: "CORP", Computer: "LAPTOP-001", Client: "GlobalProtect Agent", VirtualIP: "10.100.0.15", LoginTime: "Jan 21 08:30:00"},
+			{Username: "mjones", Domain: "CORP", Computer: "LAPTOP-002", Client: "GlobalProtect Agent", VirtualIP: "10.100.0.16", LoginTime: "Jan 21 09:15:00"},
+			{Username: "agarcia", Domain: "CORP", Computer: "DESKTOP-003", Client: "GlobalProtect Agent", VirtualIP: "10.100.0.17", LoginTime: "Jan 21 07:45:00"},
+		},
+		Licenses: []License{
+			{Feature: "PA-VM", Description: "PA-VM", Expires: "January 01, 2027", Expired: "no"},
+			{Feature: "Threat Prevention", Description: "Threat Prevention", Expires: "January 01, 2027", Expired: "no"},
+			{Feature: "GlobalProtect Gateway", Description: "GlobalProtect Gateway", Expires: "January 01, 2027", Expired: "no"},
+			{Feature: "WildFire License", Description: "WildFire License", Expires: "January 01, 2027", Expired: "no"},
+		},
+		Jobs: []Job{
+			{ID: 412, Type: "Commit", Status: "FIN", Result: "OK", Progress: "100", User: "admin",
+				Enqueued: "2025/01/21 08:02:11", Started: "2025/01/21 08:02:11", Finished: "2025/01/21 08:03:40",
+				Details: []string{"Configuration committed successfully"}},
+			{ID: 411, Type: "Downld", Status: "FIN", Result: "OK", Progress: "100",
+				Enqueued: "2025/01/21 01:00:02", Started: "2025/01/21 01:00:02", Finished: "2025/01/21 01:01:15"},
+			{ID: 410, Type: "Install", Status: "FIN", Result: "FAIL", Progress: "100",
+				Enqueued: "2025/01/20 01:05:00", Started: "2025/01/20 01:05:00", Finished: "2025/01/20 01:05:42",
+				Details: []string{"Failed to install content: disk space"}},
+		},
+		Admins: []Admin{
+			{Name: "admin", From: "192.0.2.50", Type: "Web", StartedAt: "01/21 08:00:03", IdleFor: "00:00:12s"},
+			{Name: "netops", From: "192.0.2.51", Type: "CLI", StartedAt: "01/21 07:41:55", IdleFor: "00:14:02s"},
+		},
+		TrafficLogs: []TrafficLog{
+			{Time: "2025/01/21 09:15:02", Subtype: "end", Source: "192.168.1.100", Dest: "8.8.8.8", SourcePort: 54321, DestPort: 443,
+				From: "trust", To: "untrust", Rule: "allow-outbound", App: "ssl", Action: "allow", Bytes: 48213, Packets: 61,
+				User: "corp\\jsmith", Protocol: "tcp", EndReason: "tcp-fin"},
+			{Time: "2025/01/21 09:14:58", Subtype: "end", Source: "192.168.1.102", Dest: "8.8.4.4", SourcePort: 54323, DestPort: 53,
+				From: "trust", To: "untrust", Rule: "allow-dns", App: "dns", Action: "allow", Bytes: 212, Packets: 2,
+				Protocol: "udp", EndReason: "aged-out"},
+			{Time: "2025/01/21 09:14:40", Subtype: "deny", Source: "198.51.100.23", Dest: "203.0.113.1", SourcePort: 41822, DestPort: 22,
+				From: "untrust", To: "untrust", Rule: "deny-all", App: "ssh", Action: "deny", Bytes: 74, Packets: 1,
+				Protocol: "tcp", EndReason: "policy-deny"},
+		},
+		ThreatLogs: []ThreatLog{
+			{Time: "2025/01/21 09:10:11", Subtype: "vulnerability", Source: "198.51.100.77", Dest: "10.0.0.15", SourcePort: 39201, DestPort: 443,
+				From: "untrust", To: "dmz", Rule: "deny-all", App: "web-browsing", Action: "reset-both",
+				ThreatID: "Apache Log4j Remote Code Execution Vulnerability(91991)", Threat: "Apache Log4j RCE",
+				Category: "code-execution", Severity: "critical", Direction: "client-to-server"},
+			{Time: "2025/01/21 08:55:37", Subtype: "spyware", Source: "192.168.1.140", Dest: "203.0.113.66", SourcePort: 50110, DestPort: 53,
+				From: "trust", To: "untrust", Rule: "allow-dns", App: "dns", Action: "sinkhole",
+				ThreatID: "generic:malicious-domain.example(109001001)", Threat: "Suspicious DNS Query",
+				Category: "dns-c2", Severity: "medium", Direction: "client-to-server"},
+		},
+		SystemLogs: []SystemLog{
+			{Time: "2025/01/21 08:03:40", Subtype: "general", Severity: "informational", EventID: "commit",
+				Description: "Commit job 412 succeeded for user admin"},
+			{Time: "2025/01/21 08:00:03", Subtype: "auth", Severity: "informational", EventID: "auth-success",
+				Description: "User admin logged in via Web from 192.0.2.50"},
+			{Time: "2025/01/20 01:05:42", Subtype: "general", Severity: "high", EventID: "content-install-failed",
+				Description: "Content install job 410 failed"},
+		},
+		Config: firewallConfig,
+		CandidateEdits: []ConfigEdit{
+			{Admin: "netops", Action: "add", Time: "2025/01/21 09:02:17",
+				XPath: vsys1 + "/rulebase/security/rules/entry[@name='allow-partner-ssh']",
+				Element: `<entry name="allow-partner-ssh"><disabled>no</disabled><action>allow</action>` +
+					`<from><member>untrust</member></from><to><member>dmz</member></to>` +
+					`<source><member>partner-vpn</member></source><destination><member>web-servers</member></destination>` +
+					`<application><member>ssh</member></application><service><member>application-default</member></service>` +
+					`<log-end>yes</log-end></entry>`},
+			{Admin: "admin", Action: "edit", Time: "2025/01/21 09:11:40",
+				XPath: vsys1 + "/address/entry[@name='db-primary']",
+				Element: `<entry name="db-primary"><ip-netmask>10.0.0.21/32</ip-netmask>` +
+					`<description>Primary database (migrated)</description><tag><member>prod</member></tag></entry>`},
+			{Admin: "netops", Action: "delete", Time: "2025/01/21 09:12:05",
+				XPath: vsys1 + "/rulebase/security/rules/entry[@name='deprecated-rule']"},
+		},
+		CommitWarnings: []string{
+			"vsys1 (Security Rule 'allow-partner-ssh'): application 'ssh' with service 'application-default' also allows 'ssh-tunnel' traffic",
+		},
+	}
+}
+
+// vsys1 is the XPath of the default vsys, the root of policy and objects.
+const vsys1 = "/config/devices/entry[@name='localhost.localdomain']/vsys/entry[@name='vsys1']"
+
+// DefaultPanorama returns the built-in Panorama with four managed firewalls.
+// Requests targeted at a managed serial are answered by a branch firewall
+// carrying pushed pre- and post-rulebase policy.
+func DefaultPanorama() *Dataset {
+	return &Dataset{
+		Panorama: true,
+		Username: "admin",
+		Password: "admin",
+		APIKey:   "LUFRPT1234567890abcdef==",
+		System: SystemInfo{
+			Hostname:        "mock-panorama",
+			Model:           "Panorama",
+			Serial:          "007200009999",
+			Version:         "10.2.3",
+			Uptime:          "42 days, 6:10:05",
+			IPAddress:       "192.0.2.5",
+			MultiVsys:       "off",
+			OperationalMode: "normal",
+			AppVersion:      "8700-8040",
+		},
+		Resources: Resources{
+			UserCPU: 11.4, SysCPU: 3.0,
+			Load1: 1.12, Load5: 0.98, Load15: 0.87,
+			MemTotal: 32768000, MemUsed: 21299200,
+		},
+		Licenses: []License{
+			{Feature: "Device Management License", Description: "Panorama managing up to 25 devices", Expires: "January 01, 2027", Expired: "no"},
+			{Feature: "Premium", Description: "24x7 phone support", Expires: "January 01, 2027", Expired: "no"},
+		},
+		Devices: []ManagedDevice{
+			{Serial: "007200001001", Hostname: "fw-branch-01", IPAddress: "10.0.1.1", Model: "PA-3260", Version: "10.2.3", HAState: "active", Connected: true, DeviceGroup: "Branch-Offices"},
+			{Serial: "007200001002", Hostname: "fw-branch-02", IPAddress: "10.0.1.2", Model: "PA-3260", Version: "10.2.3", HAState: "passive", Connected: true, DeviceGroup: "Branch-Offices"},
+			{Serial: "007200001003", Hostname: "fw-dc-01", IPAddress: "10.0.2.1", Model: "PA-5260", Version: "10.2.3", HAState: "active", Connected: true, DeviceGroup: "Data-Center"},
+			{Serial: "007200001004", Hostname: "fw-dc-02", IPAddress: "10.0.2.2", Model: "PA-5260", Version: "10.2.3", HAState: "passive", Connected: false, DeviceGroup: "Data-Center"},
+		},
+		Managed: defaultBranch(),
+		Config:  panoramaConfig,
+	}
+}
+
+// defaultBranch is the managed-firewall personality behind DefaultPanorama.
+func defaultBranch() *Dataset {
+	ds := DefaultFirewall()
+	ds.RuleHits = []RuleHit{
+		{Rulebase: "security", Name: "block-known-bad", Count: 3312, LastHit: 1737455000},
+		{Rulebase: "security", Name: "users-to-hq", Count: 288104, LastHit: 1737456010},
+		{Rulebase: "security", Name: "users-to-internet", Count: 5120448, LastHit: 1737456012},
+		{Rulebase: "security", Name: "voice-to-hq", Count: 40211, LastHit: 1737455990},
+		{Rulebase: "security", Name: "legacy-erp", Count: 61877, LastHit: 1737456005},
+		{Rulebase: "security", Name: "temp-vendor-access", Count: 0, LastHit: 0},
+		{Rulebase: "security", Name: "default-deny-log", Count: 9014, LastHit: 1737456001},
+		{Rulebase: "nat", Name: "branch-outbound", Count: 5408552, LastHit: 1737456012},
+	}
+	ds.AppsSeen = []AppSeen{
+		{Rule: "legacy-erp", App: "ssl", Bytes: 48213077504, FirstSeen: 1734864000, LastSeen: 1737456005},
+		{Rule: "legacy-erp", App: "web-browsing", Bytes: 912305152, FirstSeen: 1734870000, LastSeen: 1737455100},
+		{Rule: "legacy-erp", App: "insufficient-data", Bytes: 4410880, FirstSeen: 1734900000, LastSeen: 1737450000},
+	}
+	ds.Interfaces = []Interface{
+		{Name: "ethernet1/1", Zone: "untrust", IP: "198.51.100.2/30", State: "up", Speed: "1000", Duplex: "full", MAC: "00:1b:17:00:02:01"},
+		{Name: "ethernet1/2", Zone: "users", IP: "10.20.0.1/24", State: "up", Speed: "1000", Duplex: "full", MAC: "00:1b:17:00:02:02"},
+		{Name: "ethernet1/3", Zone: "voice", IP: "10.20.10.1/24", State: "up", Speed: "100", Duplex: "full", MAC: "00:1b:17:00:02:03"},
+	}
+	ds.Sessions = nil
+	ds.TrafficLogs = nil
+	ds.ThreatLogs = nil
+	ds.Config = branchConfig
+	ds.CandidateEdits = nil
+	ds.CommitWarnings = nil
+	return ds
+}
@@ -0,0 +1,470 @@
+package mockpanos
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jp2195/pyre/internal/pancfg"
+)
+
+// Server answers the PAN-OS XML API (/api/) from a Dataset. It is safe for
+// concurrent use; pyre fetches several rulebases and panels in parallel.
+type Server struct {
+	ds *Dataset
+
+	// devices holds one firewall personality per managed serial (Panorama
+	// only), built from ds.Managed with the identity of each Devices entry.
+	devices map[string]*Server
+
+	mu sync.Mutex
+	// running answers action=show and show config running; candidate (the
+	// running config plus edits) answers action=get and show config
+	// candidate, as on a real device. A commit replaces both trees rather
+	// than modifying them, so a snapshot taken under mu stays valid.
+	running   *pancfg.Node
+	candidate *pancfg.Node
+	edits     []ConfigEdit // uncommitted changes; starts as ds.CandidateEdits
+	jobs      []Job        // starts as ds.Jobs; validate and commit append
+	active    map[int]commitJob
+	nextJob   int
+	logJobs   map[string]logJob
+
+	// keysRevoked makes the server reject every API key except those
+	// keygen has issued since (see RevokeKeys).
+	keysRevoked bool
+	issuedKeys  map[string]bool
+}
+
+// logJob is an enqueued type=log query awaiting action=get.
+type logJob struct {
+	logType string
+	nlogs   int
+}
+
+// New builds a server for ds. It fails if the dataset's configuration
+// document doesn't parse or a candidate edit doesn't apply.
+func New(ds *Dataset) (*Server, error) {
+	running, candidate, err := buildConfigs(ds)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		ds:        ds,
+		running:   running,
+		candidate: candidate,
+		edits:     ds.CandidateEdits,
+		jobs:      append([]Job(nil), ds.Jobs...),
+		active:    make(map[int]commitJob),
+		nextJob:   1000,
+		logJobs:   make(map[string]logJob),
+	}
+	if ds.Panorama && ds.Managed != nil {
+		s.devices = make(map[string]*Server, len(ds.Devices))
+		for _, d := range ds.Devices {
+			fw := *ds.Managed
+			fw.Panorama = false
+			fw.System.Hostname = d.Hostname
+			fw.System.Serial = d.Serial
+			fw.System.Model = d.Model
+			fw.System.Version = d.Version
+			fw.System.IPAddress = d.IPAddress
+			dev, err := New(&fw)
+			if err != nil {
+				return nil, fmt.Errorf("managed device %s: %w", d.Serial, err)
+			}
+			s.devices[d.Serial] = dev
+		}
+	}
+	return s, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/" && r.URL.Path != "/api" {
+		http.NotFound(w, r)
+		return
+	}
+	// keygen uses POST with a form body; everything else is a GET.
+	if r.Method == http.MethodPost {
+		_ = r.ParseForm() //nolint:errcheck // missing fields surface as an API error below
+	}
+	w.Header().Set("Content-Type", "application/xml")
+
+	if r.FormValue("type") != "keygen" && s.keyRejected(r) {
+		w.WriteHeader(http.StatusForbidden)
+		writeRaw(w, `<response status="error" code="403"><result><msg>Invalid Credential</msg></result></response>`)
+		return
+	}
+
+	if target := r.FormValue("target"); target != "" && s.ds.Panorama {
+		dev, ok := s.devices[target]
+		if !ok {
+			writeError(w, fmt.Sprintf("Device %s is not managed by this Panorama", target))
+			return
+		}
+		dev.ServeHTTP(w, r)
+		return
+	}
+
+	switch r.FormValue("type") {
+	case "keygen":
+		s.handleKeygen(w, r)
+	case "op":
+		s.handleOp(w, r.FormValue("cmd"))
+	case "config":
+		s.handleConfig(w, r.FormValue("action"), r.FormValue("xpath"))
+	case "commit":
+		s.handleCommit(w, r.FormValue("cmd"))
+	case "log":
+		s.handleLog(w, r)
+	default:
+		writeError(w, "Invalid request")
+	}
+}
+
+func (s *Server) handleKeygen(w http.ResponseWriter, r *http.Request) {
+	if r.FormValue("user") != s.ds.Username || r.FormValue("password") != s.ds.Password {
+		writeError(w, "Invalid credentials")
+		return
+	}
+	key := s.ds.APIKey
+	s.mu.Lock()
+	if s.keysRevoked {
+		key = fmt.Sprintf("%s%d", s.ds.APIKey, len(s.issuedKeys)+1)
+		s.issuedKeys[key] = true
+	}
+	s.mu.Unlock()
+	writeResult(w, "<key>"+xmlEscape(key)+"</key>")
+}
+
+// RevokeKeys makes the server answer HTTP 403 to every API key issued so
+// far, as a device does when keys expire or an admin's keys are revoked.
+// Keys that keygen issues afterwards are accepted. Before the first call
+// the server accepts any key.
+func (s *Server) RevokeKeys() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keysRevoked = true
+	s.issuedKeys = make(map[string]bool)
+}
+
+func (s *Server) keyRejected(r *http.Request) bool {
+	key := r.Header.Get("X-PAN-KEY")
+	if key == "" {
+		key = r.FormValue("key")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keysRevoked && !s.issuedKeys[key]
+}
+
+func (s *Server) handleOp(w http.ResponseWriter, cmd string) {
+	for _, op := range s.ds.Ops {
+		if op.Match != "" && strings.Contains(cmd, op.Match) {
+			writeResult(w, op.Result)
+			return
+		}
+	}
+
+	switch {
+	case strings.Contains(cmd, "<show><system><info>"):
+		writeResult(w, marshal(struct {
+			XMLName xml.Name `xml:"system"`
+			SystemInfo
+			DeviceName string `xml:"devicename"`
+		}{SystemInfo: s.ds.System, DeviceName: s.ds.System.Hostname}))
+	case strings.Contains(cmd, "<show><system><resources>"):
+		writeResult(w, s.topOutput())
+	case strings.Contains(cmd, "<show><session><info>"):
+		writeResult(w, marshalBare(s.ds.SessionInfo))
+	case strings.Contains(cmd, "<show><session><all>"):
+		writeResult(w, marshalEntries(s.ds.Sessions))
+	case strings.Contains(cmd, "<show><high-availability><state>"):
+		writeResult(w, s.haState())
+	case strings.Contains(cmd, "<show><interface>"):
+		writeResult(w, s.interfaces())
+	case strings.Contains(cmd, "<show><rule-hit-count>"):
+		writeResult(w, s.ruleHitCount(cmd))
+	case strings.Contains(cmd, "<show><policy-app-details>"):
+		writeResult(w, s.appsSeen(cmd))
+	case strings.Contains(cmd, "<show><counter><global>"):
+		writeResult(w, "<global><counters>"+marshalEntries(s.ds.Counters)+"</counters></global>")
+	case strings.Contains(cmd, "<show><global-protect-gateway>"):
+		writeResult(w, marshalEntries(s.ds.GPUsers))
+	case strings.Contains(cmd, "<request><license><info>"):
+		writeResult(w, "<licenses>"+marshalEntries(s.ds.Licenses)+"</licenses>")
+	case strings.Contains(cmd, "<show><jobs>"):
+		writeResult(w, s.showJobs(cmd))
+	case strings.HasPrefix(cmd, "<validate>"):
+		s.handleValidate(w, cmd)
+	case strings.Contains(cmd, "<show><admins>"):
+		writeResult(w, "<admins>"+marshalEntries(s.ds.Admins)+"</admins>")
+	case strings.Contains(cmd, "<show><config><running>"):
+		running, _, _ := s.snapshot()
+		writeResult(w, string(running.Marshal()))
+	case strings.Contains(cmd, "<show><config><candidate>"):
+		_, candidate, _ := s.snapshot()
+		writeResult(w, string(candidate.Marshal()))
+	case strings.Contains(cmd, "<show><config><diff>"):
+		// As on PAN-OS versions without the command, so clients compare
+		// the running and candidate configs themselves.
+		writeError(w, "show config diff is not supported by the mock device")
+	case strings.Contains(cmd, "<show><config><list><changes>"):
+		_, _, edits := s.snapshot()
+		writeResult(w, "<journal>"+marshalEntries(edits)+"</journal>")
+	case strings.Contains(cmd, "<show><devices><all>"):
+		if !s.ds.Panorama {
+			writeError(w, "Command not available on this device")
+			return
+		}
+		writeResult(w, s.managedDevices())
+	default:
+		writeResult(w, "")
+	}
+}
+
+func (s *Server) handleConfig(w http.ResponseWriter, action, xpath string) {
+	switch action {
+	case "get", "show":
+	default:
+		writeError(w, fmt.Sprintf("Config action %q is not supported by the mock device", action))
+		return
+	}
+	root, candidate, _ := s.snapshot()
+	if action == "get" {
+		root = candidate
+	}
+	nodes, err := pancfg.Select(root, xpath)
+	if err != nil {
+		writeError(w, err.Error())
+		return
+	}
+	var b strings.Builder
+	for _, n := range nodes {
+		b.Write(n.Marshal())
+	}
+	if action == "get" {
+		writeRaw(w, fmt.Sprintf(`<response status="success"><result total-count="%d" count="%d">%s</result></response>`,
+			len(nodes), len(nodes), b.String()))
+		return
+	}
+	writeResult(w, b.String())
+}
+
+// snapshot returns the current configuration state.
+func (s *Server) snapshot() (running, candidate *pancfg.Node, edits []ConfigEdit) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running, s.candidate, s.edits
+}
+
+// handleLog implements the two-step log query: type=log enqueues a job and
+// type=log&action=get returns its (always finished) results.
+func (s *Server) handleLog(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.FormValue("action") != "get" {
+		nlogs, _ := strconv.Atoi(r.FormValue("nlogs")) //nolint:errcheck // 0 means everything
+		s.nextJob++
+		id := strconv.Itoa(s.nextJob)
+		s.logJobs[id] = logJob{logType: r.FormValue("log-type"), nlogs: nlogs}
+		writeResult(w, fmt.Sprintf("<msg><line>query job enqueued with jobid %s</line></msg><job>%s</job>", id, id))
+		return
+	}
+
+	id := r.FormValue("job-id")
+	job, ok := s.logJobs[id]
+	if !ok {
+		writeError(w, "job "+id+" not found")
+		return
+	}
+	delete(s.logJobs, id)
+
+	var entries string
+	var count int
+	switch job.logType {
+	case "traffic":
+		logs := limit(s.ds.TrafficLogs, job.nlogs)
+		entries, count = marshalEntries(logs), len(logs)
+	case "threat":
+		logs := limit(s.ds.ThreatLogs, job.nlogs)
+		entries, count = marshalEntries(logs), len(logs)
+	case "system":
+		logs := limit(s.ds.SystemLogs, job.nlogs)
+		entries, count = marshalEntries(logs), len(logs)
+	}
+	writeResult(w, fmt.Sprintf(`<job><id>%s</id><status>FIN</status></job><log><logs count="%d" progress="100">%s</logs></log>`,
+		id, count, entries))
+}
+
+func (s *Server) topOutput() string {
+	r := s.ds.Resources
+	free := r.MemTotal - r.MemUsed
+	return xmlEscape(fmt.Sprintf(`top - 14:32:18 up %s,  0 users,  load average: %.2f, %.2f, %.2f
+Tasks: 150 total,   1 running, 149 sleeping,   0 stopped,   0 zombie
+%%Cpu(s): %4.1f us, %4.1f sy,  0.0 ni, %4.1f id,  0.3 wa,  0.0 hi,  0.3 si,  0.0 st
+KiB Mem: %d total, %d used, %d free,   256000 buffers
+`, s.ds.System.Uptime, r.Load1, r.Load5, r.Load15, r.UserCPU, r.SysCPU, 100-r.UserCPU-r.SysCPU-0.6,
+		r.MemTotal, r.MemUsed, free))
+}
+
+func (s *Server) haState() string {
+	if !s.ds.HA.Enabled {
+		return "<enabled>no</enabled>"
+	}
+	return fmt.Sprintf("<enabled>yes</enabled><group><local-info><state>%s</state></local-info>"+
+		"<peer-info><state>%s</state></peer-info><running-sync-enabled>yes</running-sync-enabled>"+
+		"<running-sync>%s</running-sync></group>",
+		xmlEscape(s.ds.HA.Local), xmlEscape(s.ds.HA.Peer), xmlEscape(s.ds.HA.Sync))
+}
+
+func (s *Server) interfaces() string {
+	type ifnet struct {
+		Name  string `xml:"name"`
+		Zone  string `xml:"zone"`
+		IP    string `xml:"ip"`
+		State string `xml:"state"`
+		Speed string `xml:"speed"`
+	}
+	type hw struct {
+		Name   string `xml:"name"`
+		State  string `xml:"state"`
+		Speed  string `xml:"speed"`
+		Duplex string `xml:"duplex"`
+		MAC    string `xml:"mac"`
+	}
+	logical := make([]ifnet, 0, len(s.ds.Interfaces))
+	physical := make([]hw, 0, len(s.ds.Interfaces))
+	for _, i := range s.ds.Interfaces {
+		logical = append(logical, ifnet{i.Name, i.Zone, i.IP, i.State, i.Speed})
+		physical = append(physical, hw{i.Name, i.State, i.Speed, i.Duplex, i.MAC})
+	}
+	return "<ifnet>" + marshalEntries(logical) + "</ifnet><hw>" + marshalEntries(physical) + "</hw>"
+}
+
+func (s *Server) ruleHitCount(cmd string) string {
+	type rule struct {
+		Name    string `xml:"name,attr"`
+		Count   int64  `xml:"hit-count"`
+		LastHit int64  `xml:"last-hit-timestamp"`
+	}
+	kind := "security"
+	if strings.Contains(cmd, "<entry name='nat'>") {
+		kind = "nat"
+	}
+	var rules []rule
+	for _, h := range s.ds.RuleHits {
+		if h.Rulebase == kind {
+			rules = append(rules, rule{h.Name, h.Count, h.LastHit})
+		}
+	}
+	return fmt.Sprintf(`<rule-hit-count><vsys><entry name="vsys1"><rule-base><entry name="%s"><rules>%s</rules></entry></rule-base></entry></vsys></rule-hit-count>`,
+		kind, marshalEntries(rules))
+}
+
+// appsSeen answers the Policy Optimizer's apps-seen query for the rules
+// named in cmd.
+func (s *Server) appsSeen(cmd string) string {
+	type app struct {
+		Name      string `xml:"name,attr"`
+		Bytes     int64  `xml:"bytes"`
+		FirstSeen int64  `xml:"first-seen"`
+		LastSeen  int64  `xml:"last-seen"`
+	}
+	type rule struct {
+		Name string `xml:"name,attr"`
+		Apps []app  `xml:"apps-seen>entry"`
+	}
+	var rules []rule
+	index := map[string]int{}
+	for _, a := range s.ds.AppsSeen {
+		if !strings.Contains(cmd, "<member>"+xmlEscape(a.Rule)+"</member>") {
+			continue
+		}
+		i, ok := index[a.Rule]
+		if !ok {
+			i = len(rules)
+			index[a.Rule] = i
+			rules = append(rules, rule{Name: a.Rule})
+		}
+		rules[i].Apps = append(rules[i].Apps, app{a.App, a.Bytes, a.FirstSeen, a.LastSeen})
+	}
+	return "<rules>" + marshalEntries(rules) + "</rules>"
+}
+
+func (s *Server) managedDevices() string {
+	type device struct {
+		Name string `xml:"name,attr"`
+		ManagedDevice
+		Connected string `xml:"connected"`
+	}
+	devices := make([]device, 0, len(s.ds.Devices))
+	for _, d := range s.ds.Devices {
+		connected := "no"
+		if d.Connected {
+			connected = "yes"
+		}
+		devices = append(devices, device{Name: d.Serial, ManagedDevice: d, Connected: connected})
+	}
+	return "<devices>" + marshalEntries(devices) + "</devices>"
+}
+
+func limit[T any](items []T, n int) []T {
+	if n > 0 && n < len(items) {
+		return items[:n]
+	}
+	return items
+}
+
+// marshalNamed renders each item as an element called name.
+func marshalNamed[T any](name string, items []T) string {
+	var b strings.Builder
+	enc := xml.NewEncoder(&b)
+	for _, item := range items {
+		_ = enc.EncodeElement(item, xml.StartElement{Name: xml.Name{Local: name}}) //nolint:errcheck // plain structs always encode
+	}
+	_ = enc.Flush() //nolint:errcheck // strings.Builder writes don't fail
+	return b.String()
+}
+
+// marshalEntries renders each item as an <entry> element.
+func marshalEntries[T any](items []T) string {
+	return marshalNamed("entry", items)
+}
+
+// marshalBare renders v's fields without an enclosing element.
+func marshalBare(v any) string {
+	out := marshalNamed("x", []any{v})
+	out = strings.TrimPrefix(out, "<x>")
+	return strings.TrimSuffix(out, "</x>")
+}
+
+func marshal(v any) string {
+	out, _ := xml.Marshal(v) //nolint:errcheck // plain structs always encode
+	return string(out)
+}
+
+// xmlEscaper escapes text content. Unlike xml.EscapeText it leaves
+// newlines alone so multi-line output (top) reads naturally on the wire.
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func xmlEscape(s string) string {
+	return xmlEscaper.Replace(s)
+}
+
+func writeRaw(w http.ResponseWriter, body string) {
+	_, _ = w.Write([]byte(body)) //nolint:errcheck // client went away; nothing to do
+}
+
+func writeResult(w http.ResponseWriter, inner string) {
+	writeRaw(w, `<response status="success"><result>`+inner+`</result></response>`)
+}
+
+func writeError(w http.ResponseWriter, msg string) {
+	writeRaw(w, `<response status="error"><msg><line>`+xmlEscape(msg)+`</line></msg></response>`)
+}
@@ -0,0 +1,124 @@
+package mockpanos_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/mockpanos"
+	"github.com/jp2195/pyre/internal/models"
+)
+
+func newClient(t *testing.T, ds *mockpanos.Dataset) *api.Client {
+	t.Helper()
+	srv, err := mockpanos.New(ds)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ts := httptest.NewTLSServer(srv)
+	t.Cleanup(ts.Close)
+	c, err := api.NewClient(strings.TrimPrefix(ts.URL, "https://"), ds.APIKey, api.ClientOptions{Insecure: true})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return c
+}
+
+func TestPanorama_TargetedRequestsReachManagedDevice(t *testing.T) {
+	c := newClient(t, mockpanos.DefaultPanorama())
+	ctx := context.Background()
+
+	devices, err := c.GetManagedDevices(ctx)
+	if err != nil {
+		t.Fatalf("GetManagedDevices: %v", err)
+	}
+	if len(devices) != 4 || devices[3].Connected {
+		t.Fatalf("devices = %+v, want 4 with the last disconnected", devices)
+	}
+
+	info, err := c.GetSystemInfo(ctx, devices[0].Serial)
+	if err != nil {
+		t.Fatalf("GetSystemInfo: %v", err)
+	}
+	if info.Hostname != "fw-branch-01" || info.Serial != devices[0].Serial || info.Model != "PA-3260" {
+		t.Errorf("targeted system info = %s/%s/%s, want fw-branch-01/%s/PA-3260",
+			info.Hostname, info.Serial, info.Model, devices[0].Serial)
+	}
+
+	rules, err := c.GetSecurityPolicies(ctx, devices[0].Serial)
+	if err != nil {
+		t.Fatalf("GetSecurityPolicies: %v", err)
+	}
+	if len(rules) != 7 {
+		t.Fatalf("got %d rules, want 7", len(rules))
+	}
+	if rules[0].RuleBase != models.RuleBasePre || rules[6].RuleBase != models.RuleBasePost {
+		t.Errorf("rulebases = %v..%v, want pre..post", rules[0].RuleBase, rules[6].RuleBase)
+	}
+
+	if _, err := c.GetSystemInfo(ctx, "000000000000"); err == nil {
+		t.Error("expected error for an unmanaged serial")
+	}
+}
+
+func TestFirewall_LogQuery(t *testing.T) {
+	c := newClient(t, mockpanos.DefaultFirewall())
+
+	logs, err := c.GetTrafficLogs(context.Background(), "", 2, "")
+	if err != nil {
+		t.Fatalf("GetTrafficLogs: %v", err)
+	}
+	if len(logs) != 2 {
+		t.Fatalf("got %d logs, want 2 (nlogs limit)", len(logs))
+	}
+	if logs[0].Rule != "allow-outbound" || logs[0].DestPort != 443 {
+		t.Errorf("logs[0] = %+v", logs[0])
+	}
+}
+
+func TestLoadDataset_OverlaysBase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lab.yaml")
+	data := `system:
+  hostname: lab-fw
+ops:
+  - match: "<show><clock>"
+    result: "Tue Jan 21 09:00:00 UTC 2025"
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ds, err := mockpanos.LoadDataset(path, mockpanos.DefaultFirewall())
+	if err != nil {
+		t.Fatalf("LoadDataset: %v", err)
+	}
+	if ds.System.Hostname != "lab-fw" {
+		t.Errorf("Hostname = %q, want lab-fw", ds.System.Hostname)
+	}
+	if ds.System.Model != "PA-VM" || len(ds.Interfaces) != 4 {
+		t.Error("fields absent from the file should keep the base values")
+	}
+
+	c := newClient(t, ds)
+	resp, err := c.Op(context.Background(), "<show><clock></clock></show>", "")
+	if err != nil {
+		t.Fatalf("Op: %v", err)
+	}
+	if got := api.InnerText(resp.Result.Inner); got != "Tue Jan 21 09:00:00 UTC 2025" {
+		t.Errorf("canned op result = %q", got)
+	}
+}
+
+func TestLoadDataset_RejectsBadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.yaml")
+	if err := os.WriteFile(path, []byte("config: \"<devices/>\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mockpanos.LoadDataset(path, nil); err == nil {
+		t.Error("expected error for a config document not rooted at <config>")
+	}
+}
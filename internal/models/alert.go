@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// AlertState says what an AlertEvent reports.
+type AlertState string
+
+const (
+	AlertFiring   AlertState = "firing"   // a breach started
+	AlertResolved AlertState = "resolved" // a breach ended
+	AlertChanged  AlertState = "changed"  // a one-off change, such as an HA failover
+)
+
+// AlertEvent is a threshold rule's breach starting or ending, or a change,
+// on one device. It is also the JSON `pyre watch` emits.
+type AlertEvent struct {
+	Time      time.Time  `json:"time"`
+	Device    string     `json:"device"`            // host, or host@serial behind a Panorama
+	Rule      string     `json:"rule"`              // cpu, sessions, tunnel, bgp, ...
+	Subject   string     `json:"subject,omitempty"` // the tunnel, peer, pool, ... breached
+	State     AlertState `json:"state"`
+	Value     float64    `json:"value,omitempty"`
+	Threshold float64    `json:"threshold,omitempty"`
+	Message   string     `json:"message"`
+}
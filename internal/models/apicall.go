@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// APICall is one request pyre sent to a device, as listed by the API call
+// inspector.
+type APICall struct {
+	Time   time.Time // when the request was sent
+	Type   string    // op, config, log, commit, ...
+	Action string    // config action (get, show, ...); empty for others
+	Path   string    // the xpath, op command, or log type asked for
+	Target string    // Panorama-managed device serial; empty for the host itself
+	Status string    // success, error, HTTP 503, failed, ...
+	Err    string    // why the call failed, if it did
+
+	// Wait is how long the client's request limits held the call back
+	// before it was sent; Duration is the round trip to the device,
+	// response body included. A slow call with a long Wait is pyre
+	// queueing; one with a long Duration is the device.
+	Wait     time.Duration
+	Duration time.Duration
+
+	Size int    // response bytes
+	Body string // the response, sanitized and with secrets masked
+	// Truncated reports that Body holds only the start of the response.
+	Truncated bool
+}
@@ -155,6 +155,8 @@ type Job struct {
 	EndTime   time.Time // When job ended
 	Message   string    // Status/result message
 	User      string    // User who initiated the job
+	Details   []string  // Every details line (commit and validate jobs report one per finding)
+	Warnings  []string  // Warning lines; commits can succeed with warnings
 }
 
 // DiskUsage represents filesystem disk usage information
@@ -269,6 +271,23 @@ type PendingChange struct {
 	Time        time.Time // When change was made
 }
 
+// ConfigDiffLine is one line of a config diff hunk
+type ConfigDiffLine struct {
+	Op    byte   // ' ' context, '+' candidate only, '-' running only
+	Depth int    // Indentation level (XML rendering only)
+	Text  string // Line content without indentation
+}
+
+// ConfigHunk is one changed configuration object between the running and
+// candidate configs, rendered both as XML and as set commands
+type ConfigHunk struct {
+	XPath  string           // Location of the changed element
+	Kind   string           // added, removed, modified
+	XML    []ConfigDiffLine // Indented XML rendering
+	Set    []ConfigDiffLine // set-command rendering
+	Admins []string         // Admins whose pending changes touch this hunk
+}
+
 // NATPoolInfo represents NAT IP pool utilization information
 type NATPoolInfo struct {
 	RuleName  string  // NAT rule name
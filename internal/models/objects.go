@@ -19,3 +19,35 @@ type ServiceObject struct {
 	Description string
 	Tags        []string
 }
+
+// AddressGroup is a PAN-OS address group: a static list of addresses and
+// groups, or, when Filter is set, every address whose tags match it.
+type AddressGroup struct {
+	Name        string
+	Members     []string // static groups only
+	Filter      string   // dynamic groups only, e.g. "'web' and 'prod'"
+	Description string
+	Tags        []string
+}
+
+// ServiceGroup is a PAN-OS service group: services and service groups.
+type ServiceGroup struct {
+	Name    string
+	Members []string
+	Tags    []string
+}
+
+// ExternalList is an external dynamic list (EDL): addresses, domains, or
+// URLs the firewall pulls from Source.
+type ExternalList struct {
+	Name   string
+	Type   string // "ip" | "domain" | "url" | "predefined-ip" | "predefined-url"
+	Source string
+}
+
+// Region is a custom region: a named set of addresses usable wherever a
+// country can be, e.g. in a rule's source.
+type Region struct {
+	Name      string
+	Addresses []string
+}
@@ -68,3 +68,25 @@ type SecurityRule struct {
 	LastReset time.Time
 	AppsSeen  int // number of unique apps seen
 }
+
+// AppSeen is one application that matched a rule, as the Policy
+// Optimizer records it.
+type AppSeen struct {
+	Name      string
+	Bytes     int64
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// PolicySet is a device's rules and the objects they name, read from the
+// device or from a saved config, for comparing one with another.
+type PolicySet struct {
+	Security      []SecurityRule
+	NAT           []NATRule
+	Addresses     []AddressObject
+	AddressGroups []AddressGroup
+	Services      []ServiceObject
+	ServiceGroups []ServiceGroup
+	ExternalLists []ExternalList
+	Regions       []Region
+}
@@ -0,0 +1,240 @@
+// Package netmatch parses the address forms PAN-OS accepts — addresses,
+// prefixes, ranges, and wildcard masks — and compares them as sets of
+// addresses.
+package netmatch
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"net/netip"
+	"strings"
+)
+
+// Set is the addresses one address value stands for: either the interval
+// lo..hi (an address, a prefix, or a range) or, for IPv4 wildcard masks,
+// every address that agrees with addr outside the wildcard bits.
+type Set struct {
+	text string
+
+	lo, hi netip.Addr // interval sets
+
+	wildcard   bool
+	addr, mask uint32 // wildcard sets: mask bits may take any value
+}
+
+// Parse reads an address value: "10.0.0.1", "10.0.0.0/24", "2001:db8::/32",
+// "10.0.0.1-10.0.0.99", or an IPv4 address with a dotted mask. A netmask,
+// "10.0.0.0/255.255.255.0", reads as the prefix it spells; any other mask
+// as a wildcard mask, "10.0.1.0/0.0.254.255". It reports false for
+// anything else, including FQDNs and object names. The value of an
+// ip-wildcard object is read with ParseWildcard instead.
+func Parse(value string) (Set, bool) {
+	value = strings.TrimSpace(value)
+	if a, err := netip.ParseAddr(value); err == nil {
+		a = a.Unmap()
+		return Set{text: value, lo: a, hi: a}, true
+	}
+	if p, err := netip.ParsePrefix(value); err == nil {
+		p = p.Masked()
+		return Set{text: value, lo: p.Addr().Unmap(), hi: lastAddr(p)}, true
+	}
+	if lo, hi, ok := strings.Cut(value, "-"); ok {
+		l, errLo := netip.ParseAddr(strings.TrimSpace(lo))
+		h, errHi := netip.ParseAddr(strings.TrimSpace(hi))
+		l, h = l.Unmap(), h.Unmap()
+		if errLo != nil || errHi != nil || l.Is4() != h.Is4() || h.Less(l) {
+			return Set{}, false
+		}
+		return Set{text: value, lo: l, hi: h}, true
+	}
+	a, m, ok := dottedMask(value)
+	if !ok {
+		return Set{}, false
+	}
+	if ones := bits.LeadingZeros32(^m); bits.TrailingZeros32(m) == 32-ones {
+		p := netip.PrefixFrom(fromV4(a), ones).Masked()
+		return Set{text: value, lo: p.Addr(), hi: lastAddr(p)}, true
+	}
+	return Set{text: value, wildcard: true, addr: a &^ m, mask: m}, true
+}
+
+// ParseWildcard reads the value of an ip-wildcard object, an IPv4 address
+// and wildcard mask such as "10.0.1.0/0.0.254.255". Unlike Parse, it reads
+// every mask as a wildcard, so "10.0.0.0/0.0.0.0" is the one address and
+// "10.0.0.0/255.255.255.255" every address.
+func ParseWildcard(value string) (Set, bool) {
+	value = strings.TrimSpace(value)
+	a, m, ok := dottedMask(value)
+	if !ok {
+		return Set{}, false
+	}
+	return Set{text: value, wildcard: true, addr: a &^ m, mask: m}, true
+}
+
+// dottedMask splits an IPv4 address and dotted mask.
+func dottedMask(value string) (addr, mask uint32, ok bool) {
+	a, m, ok := strings.Cut(value, "/")
+	if !ok {
+		return 0, 0, false
+	}
+	aa, errAddr := netip.ParseAddr(a)
+	ma, errMask := netip.ParseAddr(m)
+	if errAddr != nil || errMask != nil || !aa.Is4() || !ma.Is4() {
+		return 0, 0, false
+	}
+	return v4(aa), v4(ma), true
+}
+
+// String is the value the set was parsed from.
+func (s Set) String() string {
+	return s.text
+}
+
+// Relation is how one set of addresses relates to another.
+type Relation int
+
+const (
+	Disjoint Relation = iota // no address in common
+	Overlaps                 // some addresses in common
+	Within                   // every address of the first is in the second
+	Covers                   // every address of the second is in the first
+	Equal
+)
+
+func (r Relation) String() string {
+	switch r {
+	case Equal:
+		return "equals"
+	case Covers:
+		return "covers"
+	case Within:
+		return "within"
+	case Overlaps:
+		return "overlaps"
+	default:
+		return "disjoint"
+	}
+}
+
+// Compare reports how s relates to q.
+func Compare(s, q Set) Relation {
+	if !overlaps(s, q) {
+		return Disjoint
+	}
+	sq, qs := contains(s, q), contains(q, s)
+	switch {
+	case sq && qs:
+		return Equal
+	case sq:
+		return Covers
+	case qs:
+		return Within
+	}
+	return Overlaps
+}
+
+// contains reports whether every address of q is in s.
+func contains(s, q Set) bool {
+	if !s.wildcard {
+		lo, hi, ok := q.bounds()
+		return ok && s.lo.Is4() == lo.Is4() && !lo.Less(s.lo) && !s.hi.Less(hi)
+	}
+	terms, ok := q.terms()
+	if !ok {
+		return false
+	}
+	for _, t := range terms {
+		// t's free bits must be free in s, and t's fixed bits must agree
+		// with s wherever s fixes them.
+		if t.mask&^s.mask != 0 || (t.addr^s.addr)&^s.mask != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// overlaps reports whether s and q have an address in common.
+func overlaps(s, q Set) bool {
+	if !s.wildcard && !q.wildcard {
+		return s.lo.Is4() == q.lo.Is4() && !q.hi.Less(s.lo) && !s.hi.Less(q.lo)
+	}
+	st, ok1 := s.terms()
+	qt, ok2 := q.terms()
+	if !ok1 || !ok2 {
+		return false
+	}
+	for _, a := range st {
+		for _, b := range qt {
+			if (a.addr^b.addr)&^a.mask&^b.mask == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// bounds returns the lowest and highest address of s.
+func (s Set) bounds() (lo, hi netip.Addr, ok bool) {
+	if !s.wildcard {
+		return s.lo, s.hi, true
+	}
+	return fromV4(s.addr), fromV4(s.addr | s.mask), true
+}
+
+// term is an IPv4 wildcard: addr with the mask bits free.
+type term struct {
+	addr, mask uint32
+}
+
+// terms splits an IPv4 set into wildcards whose union it is: itself for a
+// wildcard, the fewest prefixes covering an interval. It reports false for
+// IPv6, which has no wildcard form.
+func (s Set) terms() ([]term, bool) {
+	if s.wildcard {
+		return []term{{s.addr, s.mask}}, true
+	}
+	if !s.lo.Is4() {
+		return nil, false
+	}
+	var out []term
+	lo, hi := uint64(v4(s.lo)), uint64(v4(s.hi))
+	for lo <= hi {
+		// The largest aligned block starting at lo that stays within hi.
+		size := uint64(1) << bits.TrailingZeros64(lo|1<<32)
+		for lo+size-1 > hi {
+			size >>= 1
+		}
+		out = append(out, term{uint32(lo), uint32(size - 1)})
+		lo += size
+	}
+	return out, true
+}
+
+// lastAddr is the highest address in p.
+func lastAddr(p netip.Prefix) netip.Addr {
+	a := p.Addr().Unmap().AsSlice()
+	ones := p.Bits()
+	if p.Addr().Is4In6() {
+		ones -= 96
+	}
+	for i := range a {
+		for b := 0; b < 8; b++ {
+			if i*8+b >= ones {
+				a[i] |= 0x80 >> b
+			}
+		}
+	}
+	last, _ := netip.AddrFromSlice(a)
+	return last
+}
+
+func v4(a netip.Addr) uint32 {
+	b := a.Unmap().As4()
+	return binary.BigEndian.Uint32(b[:])
+}
+
+func fromV4(u uint32) netip.Addr {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], u)
+	return netip.AddrFrom4(b)
+}
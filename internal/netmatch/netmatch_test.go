@@ -0,0 +1,112 @@
+package netmatch
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	for _, v := range []string{"10.0.0.1", "10.0.0.0/24", "2001:db8::/32", "10.0.0.1-10.0.0.99", "10.0.1.0/0.0.254.255", " 10.0.0.1 "} {
+		if _, ok := Parse(v); !ok {
+			t.Errorf("Parse(%q) failed", v)
+		}
+	}
+	for _, v := range []string{"", "web-server", "www.example.com", "10.0.0.9-10.0.0.1", "10.0.0.1-2001:db8::1", "10.0.0.0/33", "2001:db8::/0.0.0.255"} {
+		if _, ok := Parse(v); ok {
+			t.Errorf("Parse(%q) succeeded", v)
+		}
+	}
+}
+
+func TestParse_DottedMasks(t *testing.T) {
+	tests := []struct {
+		value, same string
+		wildcard    bool
+	}{
+		// A netmask reads as the prefix it spells.
+		{"10.0.1.7/255.255.255.0", "10.0.1.0/24", false},
+		{"10.0.0.0/255.255.0.0", "10.0.0.0/16", false},
+		{"10.0.0.1/255.255.255.255", "10.0.0.1", false},
+		{"10.0.0.0/0.0.0.0", "0.0.0.0/0", false},
+		// Any other mask is a wildcard mask.
+		{"10.0.1.0/0.0.0.255", "10.0.1.0/24", true},
+		{"10.0.0.0/0.0.254.255", "10.0.0.0/0.0.254.255", true},
+	}
+	for _, tt := range tests {
+		s, ok := Parse(tt.value)
+		q, _ := Parse(tt.same)
+		if !ok || s.wildcard != tt.wildcard || Compare(s, q) != Equal {
+			t.Errorf("Parse(%q) = %+v, want the same addresses as %s (wildcard %v)", tt.value, s, tt.same, tt.wildcard)
+		}
+	}
+}
+
+func TestParseWildcard(t *testing.T) {
+	tests := []struct {
+		value, q string
+		want     Relation
+	}{
+		{"10.0.1.0/0.0.0.255", "10.0.1.0/24", Equal},
+		{"10.0.0.1/0.0.0.0", "10.0.0.1", Equal},
+		{"10.0.0.0/255.255.255.255", "0.0.0.0/0", Equal},
+		// Even a netmask-shaped mask frees the bits it sets: every
+		// address ending in .0.0, not 10.0.0.0/16.
+		{"10.0.0.0/255.255.0.0", "192.168.0.0", Covers},
+		{"10.0.0.0/255.255.0.0", "10.0.0.1", Disjoint},
+	}
+	for _, tt := range tests {
+		s, ok := ParseWildcard(tt.value)
+		q, _ := Parse(tt.q)
+		if !ok || !s.wildcard {
+			t.Fatalf("ParseWildcard(%q) = %+v, %v", tt.value, s, ok)
+		}
+		if got := Compare(s, q); got != tt.want {
+			t.Errorf("Compare(%s, %s) = %v, want %v", tt.value, tt.q, got, tt.want)
+		}
+	}
+	for _, v := range []string{"10.0.0.0/24", "10.0.0.1", "2001:db8::/0.0.0.255"} {
+		if _, ok := ParseWildcard(v); ok {
+			t.Errorf("ParseWildcard(%q) succeeded", v)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		s, q string
+		want Relation
+	}{
+		{"10.0.0.0/24", "10.0.0.5", Covers},
+		{"10.0.0.5", "10.0.0.0/24", Within},
+		{"10.0.0.0/24", "10.0.0.0/24", Equal},
+		{"10.0.0.0/24", "10.0.0.0-10.0.0.255", Equal},
+		{"10.0.0.0/24", "10.0.1.5", Disjoint},
+		{"10.0.0.0/24", "10.0.0.128-10.0.1.10", Overlaps},
+		{"10.0.0.10-10.0.0.20", "10.0.0.15", Covers},
+		{"10.0.0.10-10.0.0.20", "10.0.0.16/30", Covers},
+		{"10.0.0.10-10.0.0.20", "10.0.0.16/29", Overlaps},
+		{"2001:db8::/32", "2001:db8:1::1", Covers},
+		{"2001:db8::/32", "10.0.0.1", Disjoint},
+		{"::ffff:10.0.0.1", "10.0.0.1", Equal},
+
+		// 10.0.<even>.0/24: the wildcard frees every bit of the third
+		// octet but the lowest, and the whole fourth octet.
+		{"10.0.0.0/0.0.254.255", "10.0.4.7", Covers},
+		{"10.0.0.0/0.0.254.255", "10.0.5.7", Disjoint},
+		{"10.0.0.0/0.0.254.255", "10.0.4.0/24", Covers},
+		{"10.0.0.0/0.0.254.255", "10.0.4.0/23", Overlaps},
+		{"10.0.0.0/0.0.254.255", "10.0.0.0/16", Within},
+		{"10.0.0.0/0.0.254.255", "10.0.3.0-10.0.4.255", Overlaps},
+		{"10.0.0.0/0.0.254.255", "10.0.5.0-10.0.5.255", Disjoint},
+		{"10.0.4.0/24", "10.0.0.0/0.0.254.255", Within},
+		{"10.0.0.0/0.0.255.255", "10.0.0.0/16", Equal},
+		{"10.0.0.0/0.0.254.255", "2001:db8::1", Disjoint},
+	}
+	for _, tt := range tests {
+		s, ok1 := Parse(tt.s)
+		q, ok2 := Parse(tt.q)
+		if !ok1 || !ok2 {
+			t.Fatalf("parse %q / %q", tt.s, tt.q)
+		}
+		if got := Compare(s, q); got != tt.want {
+			t.Errorf("Compare(%s, %s) = %v, want %v", tt.s, tt.q, got, tt.want)
+		}
+	}
+}
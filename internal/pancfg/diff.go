@@ -0,0 +1,243 @@
+package pancfg
+
+import (
+	"encoding/xml"
+	"slices"
+	"strings"
+
+	"github.com/jp2195/pyre/internal/models"
+)
+
+// Hunk kinds.
+const (
+	HunkAdded     = "added"
+	HunkRemoved   = "removed"
+	HunkModified  = "modified"
+	HunkReordered = "reordered"
+)
+
+// journalAttrs are the bookkeeping attributes PAN-OS stamps on candidate
+// elements (who touched them, and when). They are not configuration, so
+// they never count as a difference.
+var journalAttrs = []string{"admin", "dirtyId", "time"}
+
+// maxLCSCells bounds the line diff's DP table. Objects big enough to exceed
+// it (a certificate blob, a huge address group) diff as remove-all/add-all.
+const maxLCSCells = 4 << 20
+
+// Diff compares a running and a candidate configuration and returns one hunk
+// per changed object, in candidate document order. Hunks are cut at the
+// innermost element that holds no named entries of its own — a single rule,
+// address, or interface unit — so they line up with what an admin edited.
+// Journal attributes are stripped from both trees in place.
+func Diff(running, candidate *Node) []models.ConfigHunk {
+	stripJournal(running)
+	stripJournal(candidate)
+	d := differ{}
+	d.walk([]*Node{running}, []*Node{candidate})
+	return d.hunks
+}
+
+type differ struct {
+	hunks []models.ConfigHunk
+}
+
+// walk compares the last elements of two parallel ancestor chains. Either
+// chain may end in nil when the element exists on one side only.
+func (d *differ) walk(rpath, cpath []*Node) {
+	r, c := rpath[len(rpath)-1], cpath[len(cpath)-1]
+	switch {
+	case r == nil:
+		d.add(HunkAdded, cpath, markAll(XMLLines(c, 0), '+'), markAll(SetLines(cpath), '+'))
+		return
+	case c == nil:
+		d.add(HunkRemoved, rpath, markAll(XMLLines(r, 0), '-'), markAll(SetLines(rpath), '-'))
+		return
+	case r.equal(c):
+		return
+	case !r.hasNamedDescendant() && !c.hasNamedDescendant():
+		d.add(HunkModified, cpath,
+			diffLines(XMLLines(r, 0), XMLLines(c, 0)),
+			diffLines(SetLines(rpath), SetLines(cpath)))
+		return
+	}
+
+	rkeys, ckeys := childKeys(r), childKeys(c)
+	rindex := make(map[string]int, len(rkeys))
+	for i, k := range rkeys {
+		rindex[k] = i
+	}
+	inCandidate := make(map[string]bool, len(ckeys))
+	for _, k := range ckeys {
+		inCandidate[k] = true
+	}
+
+	d.reorder(rpath, cpath, rkeys, ckeys, rindex, inCandidate)
+
+	// Merge in candidate order, slotting each removed running child in
+	// after the last matched child that preceded it.
+	next := 0
+	for ci, k := range ckeys {
+		ri, ok := rindex[k]
+		if !ok {
+			d.walk(append(rpath, nil), append(cpath, c.Children[ci]))
+			continue
+		}
+		for ; next < ri; next++ {
+			if !inCandidate[rkeys[next]] {
+				d.walk(append(rpath, r.Children[next]), append(cpath, nil))
+			}
+		}
+		next = max(next, ri+1)
+		d.walk(append(rpath, r.Children[ri]), append(cpath, c.Children[ci]))
+	}
+	for ; next < len(rkeys); next++ {
+		if !inCandidate[rkeys[next]] {
+			d.walk(append(rpath, r.Children[next]), append(cpath, nil))
+		}
+	}
+}
+
+// reorder emits a hunk when the named entries both sides share appear in a
+// different order. Rule order is policy, so a pure move is a real change.
+func (d *differ) reorder(rpath, cpath []*Node, rkeys, ckeys []string, rindex map[string]int, inCandidate map[string]bool) {
+	r, c := rpath[len(rpath)-1], cpath[len(cpath)-1]
+	var before, after []string
+	for i, k := range rkeys {
+		if name := r.Children[i].Name(); name != "" && inCandidate[k] {
+			before = append(before, name)
+		}
+	}
+	for i, k := range ckeys {
+		if _, ok := rindex[k]; ok && c.Children[i].Name() != "" {
+			after = append(after, c.Children[i].Name())
+		}
+	}
+	if slices.Equal(before, after) {
+		return
+	}
+	prefix := setWords(cpath, false)
+	render := func(names []string) (xmlLines, setLines []models.ConfigDiffLine) {
+		for _, name := range names {
+			xmlLines = append(xmlLines, models.ConfigDiffLine{Op: ' ', Text: `<entry name="` + xmlEscaper.Replace(name) + `"/>`})
+			words := append(append([]string{"set"}, prefix...), QuoteWord(name))
+			setLines = append(setLines, models.ConfigDiffLine{Op: ' ', Text: strings.Join(words, " ")})
+		}
+		return xmlLines, setLines
+	}
+	bx, bs := render(before)
+	ax, as := render(after)
+	d.add(HunkReordered, cpath, diffLines(bx, ax), diffLines(bs, as))
+}
+
+// add appends a hunk addressed by path.
+func (d *differ) add(kind string, path []*Node, xmlLines, setLines []models.ConfigDiffLine) {
+	d.hunks = append(d.hunks, models.ConfigHunk{
+		XPath: pathString(path),
+		Kind:  kind,
+		XML:   xmlLines,
+		Set:   setLines,
+	})
+}
+
+// childKeys identifies each child by tag and name. Unnamed siblings that
+// share a tag are told apart by occurrence.
+func childKeys(n *Node) []string {
+	keys := make([]string, len(n.Children))
+	seen := make(map[string]int)
+	for i, c := range n.Children {
+		k := c.XMLName.Local + "\x00" + c.Name()
+		seen[k]++
+		if c.Name() == "" && seen[k] > 1 {
+			k += "\x00" + strings.Repeat("#", seen[k])
+		}
+		keys[i] = k
+	}
+	return keys
+}
+
+// pathString renders an element chain as an XPath in PAN-OS style.
+func pathString(path []*Node) string {
+	var b strings.Builder
+	for _, n := range path {
+		if n == nil {
+			continue
+		}
+		b.WriteString("/" + Step(n))
+	}
+	return b.String()
+}
+
+func stripJournal(n *Node) {
+	n.Attrs = slices.DeleteFunc(n.Attrs, func(a xml.Attr) bool {
+		return slices.Contains(journalAttrs, a.Name.Local)
+	})
+	for _, c := range n.Children {
+		stripJournal(c)
+	}
+}
+
+func markAll(lines []models.ConfigDiffLine, op byte) []models.ConfigDiffLine {
+	for i := range lines {
+		lines[i].Op = op
+	}
+	return lines
+}
+
+// diffLines produces a line diff of a against b using a longest common
+// subsequence, with deletions ordered before insertions at each change.
+func diffLines(a, b []models.ConfigDiffLine) []models.ConfigDiffLine {
+	same := func(x, y models.ConfigDiffLine) bool { return x.Depth == y.Depth && x.Text == y.Text }
+	if len(a)*len(b) > maxLCSCells {
+		return append(markAll(a, '-'), markAll(b, '+')...)
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if same(a[i], b[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	out := make([]models.ConfigDiffLine, 0, max(len(a), len(b)))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case same(a[i], b[j]):
+			l := b[j]
+			l.Op = ' '
+			out = append(out, l)
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			l := a[i]
+			l.Op = '-'
+			out = append(out, l)
+			i++
+		default:
+			l := b[j]
+			l.Op = '+'
+			out = append(out, l)
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		l := a[i]
+		l.Op = '-'
+		out = append(out, l)
+	}
+	for ; j < len(b); j++ {
+		l := b[j]
+		l.Op = '+'
+		out = append(out, l)
+	}
+	return out
+}
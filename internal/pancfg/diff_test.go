@@ -0,0 +1,151 @@
+package pancfg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jp2195/pyre/internal/models"
+)
+
+func mustParse(t *testing.T, doc string) *Node {
+	t.Helper()
+	n, err := Parse(doc)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return n
+}
+
+func lineOps(lines []models.ConfigDiffLine) string {
+	var b strings.Builder
+	for _, l := range lines {
+		b.WriteByte(l.Op)
+	}
+	return b.String()
+}
+
+func TestDiff(t *testing.T) {
+	running := mustParse(t, testConfig)
+	candidate := mustParse(t, strings.NewReplacer(
+		// modify a rule's action
+		`<entry name="allow-web"><action>allow</action></entry>`,
+		`<entry name="allow-web" admin="alice" dirtyId="3"><action>deny</action></entry>`,
+		// remove an address, add a service
+		`<address><entry name="legacy-wildcard"/></address>`, `<address/>`,
+		`<service/>`, `<service><entry name="tcp-8443"><protocol><tcp><port>8443</port></tcp></protocol></entry></service>`,
+	).Replace(testConfig))
+
+	hunks := Diff(running, candidate)
+	if len(hunks) != 3 {
+		for _, h := range hunks {
+			t.Logf("%s %s", h.Kind, h.XPath)
+		}
+		t.Fatalf("got %d hunks, want 3", len(hunks))
+	}
+
+	mod := hunks[0]
+	if mod.Kind != HunkModified || !strings.HasSuffix(mod.XPath, "/rules/entry[@name='allow-web']") {
+		t.Errorf("hunk 0 = %s %s", mod.Kind, mod.XPath)
+	}
+	if got := lineOps(mod.XML); got != " -+ " {
+		t.Errorf("modified XML ops = %q, want %q", got, " -+ ")
+	}
+	if got := mod.Set; len(got) != 2 || got[0].Text != "set rulebase security rules allow-web action allow" ||
+		got[1].Text != "set rulebase security rules allow-web action deny" {
+		t.Errorf("modified set lines = %+v", got)
+	}
+
+	if hunks[1].Kind != HunkRemoved || hunks[1].XPath != "/config/shared/address/entry[@name='legacy-wildcard']" {
+		t.Errorf("hunk 1 = %s %s", hunks[1].Kind, hunks[1].XPath)
+	}
+	if hunks[2].Kind != HunkAdded || lineOps(hunks[2].Set) != "+" ||
+		hunks[2].Set[0].Text != "set shared service tcp-8443 protocol tcp port 8443" {
+		t.Errorf("hunk 2 = %s %+v", hunks[2].Kind, hunks[2].Set)
+	}
+}
+
+func TestDiff_Reorder(t *testing.T) {
+	running := mustParse(t, testConfig)
+	candidate := mustParse(t, strings.NewReplacer(
+		`<entry name="allow-web"><action>allow</action></entry>`, "",
+		`<entry name="deny-all"><action>deny</action></entry>`,
+		`<entry name="deny-all"><action>deny</action></entry><entry name="allow-web"><action>allow</action></entry>`,
+	).Replace(testConfig))
+
+	hunks := Diff(running, candidate)
+	if len(hunks) != 1 || hunks[0].Kind != HunkReordered {
+		t.Fatalf("hunks = %+v, want one reorder", hunks)
+	}
+	if !strings.HasSuffix(hunks[0].XPath, "/security/rules") {
+		t.Errorf("XPath = %s", hunks[0].XPath)
+	}
+}
+
+func TestDiff_Identical(t *testing.T) {
+	if hunks := Diff(mustParse(t, testConfig), mustParse(t, testConfig)); len(hunks) != 0 {
+		t.Errorf("got %d hunks for identical configs", len(hunks))
+	}
+}
+
+func TestSetLines_Members(t *testing.T) {
+	root := mustParse(t, `<config><shared><address-group><entry name="web servers">`+
+		`<static><member>web-1</member><member>web-2</member></static>`+
+		`<description>front end</description></entry></address-group></shared></config>`)
+	entry, err := Select(root, "/config/shared/address-group/entry")
+	if err != nil || len(entry) != 1 {
+		t.Fatalf("Select: %v", err)
+	}
+	path := []*Node{root, root.Children[0], root.Children[0].Children[0], entry[0]}
+	var got []string
+	for _, l := range SetLines(path) {
+		got = append(got, l.Text)
+	}
+	want := []string{
+		`set shared address-group "web servers" static [ web-1 web-2 ]`,
+		`set shared address-group "web servers" description "front end"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("SetLines =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestVsysSetLines(t *testing.T) {
+	root := mustParse(t, `<config><devices><entry name="localhost.localdomain"><vsys><entry name="vsys1">`+
+		`<address><entry name="web"><fqdn>web.example.com</fqdn></entry></address>`+
+		`</entry></vsys></entry></devices></config>`)
+	entry, err := Select(root, "/config/devices/entry/vsys/entry/address/entry")
+	if err != nil || len(entry) != 1 {
+		t.Fatalf("Select: %v", err)
+	}
+	dev := root.Children[0]
+	vsys := dev.Children[0].Children[0].Children[0]
+	path := []*Node{root, dev, dev.Children[0], dev.Children[0].Children[0], vsys, vsys.Children[0], entry[0]}
+	if got := SetLines(path)[0].Text; got != "set address web fqdn web.example.com" {
+		t.Errorf("SetLines = %q", got)
+	}
+	if got := VsysSetLines(path)[0].Text; got != "set vsys vsys1 address web fqdn web.example.com" {
+		t.Errorf("VsysSetLines = %q", got)
+	}
+}
+
+func TestQuoteWord(t *testing.T) {
+	for _, tc := range []struct {
+		in, want string
+		quotable bool
+	}{
+		{"web-1", "web-1", true},
+		{"", `""`, true},
+		{"prod web", `"prod web"`, true},
+		{`Say "hi"`, `'Say "hi"'`, true},
+		{"'a' and 'b'", `"'a' and 'b'"`, true},
+		{"two\nlines", `"two lines"`, false},
+		{`it's "x"`, `"it's 'x'"`, false},
+	} {
+		if got := QuoteWord(tc.in); got != tc.want {
+			t.Errorf("QuoteWord(%q) = %s, want %s", tc.in, got, tc.want)
+		}
+		if got := Quotable(tc.in); got != tc.quotable {
+			t.Errorf("Quotable(%q) = %v, want %v", tc.in, got, tc.quotable)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package pancfg
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+// ParseElement parses a single XML element (a config fragment such as one
+// rule entry).
+func ParseElement(doc string) (*Node, error) {
+	var n Node
+	if err := xml.Unmarshal([]byte(doc), &n); err != nil {
+		return nil, fmt.Errorf("parsing element XML: %w", err)
+	}
+	n.compact()
+	return &n, nil
+}
+
+// Replace puts elem at xpath, replacing whatever is there, the way the XML
+// API's action=edit does. Missing ancestors are created. The final step of
+// xpath must name elem (tag and, if present, name predicate).
+func Replace(root *Node, xpath string, elem *Node) error {
+	steps, err := splitXPath(xpath)
+	if err != nil {
+		return err
+	}
+	last := steps[len(steps)-1]
+	if !last.matches(elem) {
+		return fmt.Errorf("element <%s> does not match xpath %q", elem.XMLName.Local, xpath)
+	}
+	parent, err := ensurePath(root, steps[:len(steps)-1])
+	if err != nil {
+		return err
+	}
+	for i, c := range parent.Children {
+		if last.matches(c) {
+			parent.Children[i] = elem
+			return nil
+		}
+	}
+	parent.Children = append(parent.Children, elem)
+	return nil
+}
+
+// Remove deletes every element xpath selects. It reports whether anything
+// was removed.
+func Remove(root *Node, xpath string) (bool, error) {
+	steps, err := splitXPath(xpath)
+	if err != nil {
+		return false, err
+	}
+	if len(steps) < 2 {
+		return false, fmt.Errorf("xpath %q: cannot remove the root", xpath)
+	}
+	parents, err := Select(root, pathOf(steps[:len(steps)-1]))
+	if err != nil {
+		return false, err
+	}
+	last := steps[len(steps)-1]
+	removed := false
+	for _, p := range parents {
+		kept := p.Children[:0]
+		for _, c := range p.Children {
+			if last.matches(c) {
+				removed = true
+				continue
+			}
+			kept = append(kept, c)
+		}
+		p.Children = kept
+	}
+	return removed, nil
+}
+
+// ensurePath walks steps from root, creating elements that don't exist.
+// Unqualified steps that match several elements resolve to the first.
+func ensurePath(root *Node, steps []xpathStep) (*Node, error) {
+	if !steps[0].matches(root) {
+		return nil, fmt.Errorf("xpath root <%s> does not match <%s>", steps[0].name, root.XMLName.Local)
+	}
+	cur := root
+	for _, step := range steps[1:] {
+		var next *Node
+		for _, c := range cur.Children {
+			if step.matches(c) {
+				next = c
+				break
+			}
+		}
+		if next == nil {
+			if step.name == "*" {
+				return nil, fmt.Errorf("cannot create wildcard step")
+			}
+			next = &Node{XMLName: xml.Name{Local: step.name}}
+			if step.attr != "" {
+				next.Attrs = []xml.Attr{{Name: xml.Name{Local: step.attr}, Value: step.attrValue}}
+			}
+			cur.Children = append(cur.Children, next)
+		}
+		cur = next
+	}
+	return cur, nil
+}
+
+// pathOf renders steps back to an XPath.
+func pathOf(steps []xpathStep) string {
+	var s string
+	for _, st := range steps {
+		s += "/" + st.name
+		if st.attr != "" {
+			s += "[@" + st.attr + "='" + st.attrValue + "']"
+		}
+	}
+	return s
+}
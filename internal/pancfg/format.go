@@ -0,0 +1,165 @@
+package pancfg
+
+import (
+	"strings"
+
+	"github.com/jp2195/pyre/internal/models"
+)
+
+var xmlEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	`"`, "&quot;",
+	"\n", "&#xA;",
+)
+
+// XMLLines renders the subtree rooted at n as indented XML, one element per
+// line, starting at the given depth. Text-only elements stay on one line.
+func XMLLines(n *Node, depth int) []models.ConfigDiffLine {
+	var out []models.ConfigDiffLine
+	appendXML(&out, n, depth)
+	return out
+}
+
+func appendXML(out *[]models.ConfigDiffLine, n *Node, depth int) {
+	var open strings.Builder
+	open.WriteString("<" + n.XMLName.Local)
+	for _, a := range n.Attrs {
+		open.WriteString(" " + a.Name.Local + `="` + xmlEscaper.Replace(a.Value) + `"`)
+	}
+	tag := n.XMLName.Local
+	switch {
+	case len(n.Children) == 0 && n.Text == "":
+		*out = append(*out, models.ConfigDiffLine{Op: ' ', Depth: depth, Text: open.String() + "/>"})
+	case len(n.Children) == 0:
+		*out = append(*out, models.ConfigDiffLine{Op: ' ', Depth: depth,
+			Text: open.String() + ">" + xmlEscaper.Replace(n.Text) + "</" + tag + ">"})
+	default:
+		*out = append(*out, models.ConfigDiffLine{Op: ' ', Depth: depth, Text: open.String() + ">"})
+		for _, c := range n.Children {
+			appendXML(out, c, depth+1)
+		}
+		*out = append(*out, models.ConfigDiffLine{Op: ' ', Depth: depth, Text: "</" + tag + ">"})
+	}
+}
+
+// SetLines renders the subtree rooted at n as PAN-OS set commands. path is
+// the chain of elements from the <config> root down to and including n; it
+// supplies the command prefix. The device and vsys1 wrappers are elided the
+// way the CLI does, so a rule renders as "set rulebase security rules ...".
+func SetLines(path []*Node) []models.ConfigDiffLine {
+	return setLines(path, false)
+}
+
+// VsysSetLines is SetLines keeping the vsys wrapper, so that each command
+// names the vsys it sets, as a firewall with several vsys requires:
+// "set vsys vsys2 rulebase security rules ...".
+func VsysSetLines(path []*Node) []models.ConfigDiffLine {
+	return setLines(path, true)
+}
+
+func setLines(path []*Node, keepVsys bool) []models.ConfigDiffLine {
+	if len(path) == 0 {
+		return nil
+	}
+	var out []models.ConfigDiffLine
+	appendSet(&out, setWords(path, keepVsys), path[len(path)-1])
+	return out
+}
+
+func appendSet(out *[]models.ConfigDiffLine, words []string, n *Node) {
+	emit := func(extra ...string) {
+		all := append(append([]string{"set"}, words...), extra...)
+		*out = append(*out, models.ConfigDiffLine{Op: ' ', Text: strings.Join(all, " ")})
+	}
+	if len(n.Children) == 0 {
+		if n.Text == "" {
+			emit()
+		} else {
+			emit(QuoteWord(n.Text))
+		}
+		return
+	}
+	if members, ok := memberValues(n); ok {
+		if len(members) == 1 {
+			emit(members[0])
+		} else {
+			emit(append(append([]string{"["}, members...), "]")...)
+		}
+		return
+	}
+	for _, c := range n.Children {
+		appendSet(out, append(words[:len(words):len(words)], nodeWords(c)...), c)
+	}
+}
+
+// memberValues returns the values of a <member> list, quoted for the CLI.
+func memberValues(n *Node) ([]string, bool) {
+	values := make([]string, 0, len(n.Children))
+	for _, c := range n.Children {
+		if c.XMLName.Local != "member" || len(c.Children) > 0 {
+			return nil, false
+		}
+		values = append(values, QuoteWord(c.Text))
+	}
+	return values, true
+}
+
+// setWords converts an element path into the CLI words that address it,
+// eliding the vsys1 wrapper unless keepVsys.
+func setWords(path []*Node, keepVsys bool) []string {
+	var words []string
+	for i, n := range path {
+		if i == 0 && n.XMLName.Local == "config" {
+			continue
+		}
+		words = append(words, nodeWords(n)...)
+	}
+	if len(words) >= 2 && words[0] == "devices" {
+		words = words[2:]
+	}
+	if !keepVsys && len(words) >= 2 && words[0] == "vsys" && words[1] == "vsys1" {
+		words = words[2:]
+	}
+	return words
+}
+
+// nodeWords is the CLI spelling of one element: entries are addressed by
+// name alone, other elements by tag (plus name when they carry one).
+func nodeWords(n *Node) []string {
+	name := n.Name()
+	switch {
+	case name == "":
+		return []string{n.XMLName.Local}
+	case n.XMLName.Local == "entry":
+		return []string{QuoteWord(name)}
+	default:
+		return []string{n.XMLName.Local, QuoteWord(name)}
+	}
+}
+
+// QuoteWord quotes a CLI word when it contains whitespace or characters
+// the PAN-OS CLI treats specially, such as a pipe. The CLI reads a quoted
+// word as it is, without escapes, so a word holding a double quote is
+// single-quoted. A word that no quoting carries (see Quotable) loses its
+// line breaks, and its double quotes if it holds both kinds.
+func QuoteWord(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\r\n\"'[];|") {
+		return s
+	}
+	s = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(s)
+	switch {
+	case !strings.Contains(s, `"`):
+		return `"` + s + `"`
+	case !strings.Contains(s, "'"):
+		return "'" + s + "'"
+	}
+	return `"` + strings.ReplaceAll(s, `"`, "'") + `"`
+}
+
+// Quotable reports whether QuoteWord renders s as it is: s has no line
+// break, and not both a double and a single quote.
+func Quotable(s string) bool {
+	return !strings.ContainsAny(s, "\r\n") && !(strings.Contains(s, `"`) && strings.Contains(s, "'"))
+}
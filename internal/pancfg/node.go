@@ -0,0 +1,101 @@
+package pancfg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Node is a generic XML element. PAN-OS configuration is held as Nodes so
+// arbitrary XPaths can be answered and compared without a schema.
+type Node struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Text     string     `xml:",chardata"`
+	Children []*Node    `xml:",any"`
+}
+
+// Parse parses a configuration document. An empty document yields an empty
+// <config> root so callers may omit configuration entirely.
+func Parse(doc string) (*Node, error) {
+	if strings.TrimSpace(doc) == "" {
+		return &Node{XMLName: xml.Name{Local: "config"}}, nil
+	}
+	var root Node
+	if err := xml.Unmarshal([]byte(doc), &root); err != nil {
+		return nil, fmt.Errorf("parsing config XML: %w", err)
+	}
+	if root.XMLName.Local != "config" {
+		return nil, fmt.Errorf("config root is <%s>, want <config>", root.XMLName.Local)
+	}
+	root.compact()
+	return &root, nil
+}
+
+// compact drops the indentation whitespace between child elements so the
+// tree re-encodes without stray text nodes.
+func (n *Node) compact() {
+	if len(n.Children) > 0 && strings.TrimSpace(n.Text) == "" {
+		n.Text = ""
+	}
+	for _, c := range n.Children {
+		c.compact()
+	}
+}
+
+// Attr returns the value of the named attribute.
+func (n *Node) Attr(name string) (string, bool) {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// Name returns the element's name attribute, or "" for unnamed elements.
+func (n *Node) Name() string {
+	v, _ := n.Attr("name")
+	return v
+}
+
+// Marshal renders the node (and its subtree) back to XML.
+func (n *Node) Marshal() []byte {
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+	_ = enc.Encode(n) //nolint:errcheck // a parsed tree always re-encodes
+	return buf.Bytes()
+}
+
+// Clone returns a deep copy of the subtree rooted at n.
+func (n *Node) Clone() *Node {
+	c := &Node{XMLName: n.XMLName, Text: n.Text}
+	if len(n.Attrs) > 0 {
+		c.Attrs = append([]xml.Attr(nil), n.Attrs...)
+	}
+	if len(n.Children) > 0 {
+		c.Children = make([]*Node, len(n.Children))
+		for i, ch := range n.Children {
+			c.Children[i] = ch.Clone()
+		}
+	}
+	return c
+}
+
+// equal reports whether two subtrees are identical, attributes included.
+func (n *Node) equal(o *Node) bool {
+	return bytes.Equal(n.Marshal(), o.Marshal())
+}
+
+// hasNamedDescendant reports whether any element below n carries a name
+// attribute — i.e. whether n is a container of entries rather than a leaf
+// object such as a single rule.
+func (n *Node) hasNamedDescendant() bool {
+	for _, c := range n.Children {
+		if c.Name() != "" || c.hasNamedDescendant() {
+			return true
+		}
+	}
+	return false
+}
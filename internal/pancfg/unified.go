@@ -0,0 +1,159 @@
+package pancfg
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/jp2195/pyre/internal/models"
+)
+
+// TailPrefix starts an XPath that only names the last steps of a path, as
+// ParseUnifiedDiff gives when a hunk's context does not reach <config>.
+const TailPrefix = "…/"
+
+var nameAttrRe = regexp.MustCompile(`\sname="([^"]*)"`)
+
+// ParseUnifiedDiff reads the output of `show config diff`, a unified diff
+// of the running and candidate configs as indented XML, and returns one
+// hunk per "@@" section. A hunk's XPath locates its first changed line,
+// down to the innermost named entry above it, as far as the section's
+// context shows: when the context does not reach <config>, the XPath is a
+// tail that starts with TailPrefix. The device's diff has no set-command
+// rendering, so Set is left empty.
+func ParseUnifiedDiff(text string) []models.ConfigHunk {
+	var (
+		hunks   []models.ConfigHunk
+		section []string
+	)
+	flush := func() {
+		if h, ok := unifiedHunk(section); ok {
+			hunks = append(hunks, h)
+		}
+		section = nil
+	}
+	for _, line := range strings.Split(strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			flush()
+			section = []string{}
+		case section == nil, strings.HasPrefix(line, `\`):
+			// Headers before the first section; "\ No newline at end of file".
+		case line == "":
+			section = append(section, " ")
+		case line[0] == ' ', line[0] == '+', line[0] == '-':
+			section = append(section, line)
+		}
+	}
+	flush()
+	return hunks
+}
+
+// openElement is an element whose start tag a section showed and whose
+// end tag it has not.
+type openElement struct {
+	indent int
+	step   string
+	named  bool
+}
+
+// unifiedHunk turns the lines of one section into a hunk, and reports
+// false for a section that changes nothing.
+func unifiedHunk(section []string) (models.ConfigHunk, bool) {
+	var (
+		h              models.ConfigHunk
+		stack          []openElement
+		reachesRoot    bool
+		added, removed bool
+		indents        []int
+	)
+	minIndent, unit := -1, 0
+	for _, line := range section {
+		text := line[1:]
+		indent := len(text) - len(strings.TrimLeft(text, " \t"))
+		indents = append(indents, indent)
+		if strings.TrimSpace(text) != "" && (minIndent < 0 || indent < minIndent) {
+			minIndent = indent
+		}
+	}
+	for i, line := range section {
+		op, text := line[0], strings.TrimSpace(line[1:])
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indents[i] {
+			stack = stack[:len(stack)-1]
+		}
+		if step, named, ok := startTag(text); ok {
+			if len(stack) == 0 && step == "config" {
+				reachesRoot = true
+			}
+			stack = append(stack, openElement{indent: indents[i], step: step, named: named})
+		}
+		if op != ' ' && h.XPath == "" {
+			h.XPath = objectPath(stack, reachesRoot)
+		}
+		added = added || op == '+'
+		removed = removed || op == '-'
+		if d := indents[i] - minIndent; d > 0 && (unit == 0 || d < unit) {
+			unit = d
+		}
+		h.XML = append(h.XML, models.ConfigDiffLine{Op: op, Text: text})
+	}
+	if !added && !removed {
+		return h, false
+	}
+	for i := range h.XML {
+		if unit > 0 {
+			h.XML[i].Depth = max(indents[i]-minIndent, 0) / unit
+		}
+	}
+	switch {
+	case added && removed:
+		h.Kind = HunkModified
+	case added:
+		h.Kind = HunkAdded
+	default:
+		h.Kind = HunkRemoved
+	}
+	return h, true
+}
+
+// objectPath renders the open elements down to the innermost named one:
+// the object a change belongs to. With none open it is TailPrefix alone.
+func objectPath(stack []openElement, reachesRoot bool) string {
+	end := len(stack)
+	for end > 0 && !stack[end-1].named {
+		end--
+	}
+	if end == 0 {
+		end = len(stack)
+	}
+	steps := make([]string, end)
+	for i, e := range stack[:end] {
+		steps[i] = e.step
+	}
+	if reachesRoot {
+		return "/" + strings.Join(steps, "/")
+	}
+	return TailPrefix + strings.Join(steps, "/")
+}
+
+// startTag returns the location step for a line that opens an element
+// whose content follows on later lines, and whether the element is named.
+func startTag(text string) (step string, named, ok bool) {
+	if !strings.HasPrefix(text, "<") || strings.HasPrefix(text, "</") || strings.HasPrefix(text, "<!") ||
+		strings.HasPrefix(text, "<?") || strings.HasSuffix(text, "/>") || strings.Contains(text, "</") {
+		return "", false, false
+	}
+	tag := strings.TrimPrefix(text, "<")
+	if i := strings.IndexAny(tag, " \t>"); i >= 0 {
+		tag = tag[:i]
+	}
+	m := nameAttrRe.FindStringSubmatch(text)
+	if m == nil {
+		return tag, false, true
+	}
+	name := html.UnescapeString(m[1])
+	if strings.Contains(name, "'") {
+		return tag + `[@name="` + name + `"]`, true, true
+	}
+	return tag + "[@name='" + name + "']", true, true
+}
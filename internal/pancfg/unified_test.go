@@ -0,0 +1,58 @@
+package pancfg
+
+import "testing"
+
+const deviceDiff = `--- running
++++ candidate
+@@ -120,7 +120,7 @@
+         <address>
+           <entry name="db-primary">
+-            <ip-netmask>10.0.0.5</ip-netmask>
++            <ip-netmask>10.0.0.6</ip-netmask>
+             <description>Primary DB</description>
+           </entry>
+           <entry name="db-replica">
+@@ -300,4 +300,10 @@
+             <rules>
++              <entry name="allow-ssh">
++                <action>allow</action>
++              </entry>
+               <entry name="deny-all">
+@@ -400,3 +406,2 @@
+-<entry name="old"/>
+ <tag/>
+`
+
+func TestParseUnifiedDiff(t *testing.T) {
+	hunks := ParseUnifiedDiff(deviceDiff)
+	want := []struct{ kind, xpath, ops string }{
+		{HunkModified, TailPrefix + "address/entry[@name='db-primary']", "  -+   "},
+		{HunkAdded, TailPrefix + "rules/entry[@name='allow-ssh']", " +++ "},
+		{HunkRemoved, TailPrefix, "- "},
+	}
+	if len(hunks) != len(want) {
+		t.Fatalf("got %d hunks, want %d: %+v", len(hunks), len(want), hunks)
+	}
+	for i, w := range want {
+		h := hunks[i]
+		if h.Kind != w.kind || h.XPath != w.xpath || lineOps(h.XML) != w.ops {
+			t.Errorf("hunk %d = %s %s %q, want %s %s %q", i, h.Kind, h.XPath, lineOps(h.XML), w.kind, w.xpath, w.ops)
+		}
+	}
+	if l := hunks[0].XML[2]; l.Depth != 2 || l.Text != "<ip-netmask>10.0.0.5</ip-netmask>" {
+		t.Errorf("line = %+v, want depth 2", l)
+	}
+}
+
+func TestParseUnifiedDiff_FromRoot(t *testing.T) {
+	hunks := ParseUnifiedDiff("@@ -1,4 +1,5 @@\n <config>\n   <shared>\n+    <address/>\n   </shared>\n")
+	if len(hunks) != 1 || hunks[0].XPath != "/config/shared" {
+		t.Fatalf("hunks = %+v", hunks)
+	}
+}
+
+func TestParseUnifiedDiff_NoChanges(t *testing.T) {
+	if hunks := ParseUnifiedDiff(""); len(hunks) != 0 {
+		t.Errorf("hunks = %+v", hunks)
+	}
+}
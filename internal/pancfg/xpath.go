@@ -0,0 +1,186 @@
+package pancfg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// xpathStep is one location step: an element name plus an optional
+// [@attr='value'] predicate.
+type xpathStep struct {
+	name      string
+	attr      string
+	attrValue string
+}
+
+// splitXPath splits an absolute XPath into steps. Slashes inside predicates
+// (entry[@name='ethernet1/1']) do not split.
+func splitXPath(xpath string) ([]xpathStep, error) {
+	if !strings.HasPrefix(xpath, "/") {
+		return nil, fmt.Errorf("xpath %q is not absolute", xpath)
+	}
+	var raw []string
+	var cur strings.Builder
+	var quote rune
+	depth := 0
+	for _, r := range xpath[1:] {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '[':
+			depth++
+		case r == ']':
+			depth--
+		case r == '/' && depth == 0:
+			raw = append(raw, cur.String())
+			cur.Reset()
+			continue
+		}
+		cur.WriteRune(r)
+	}
+	raw = append(raw, cur.String())
+
+	steps := make([]xpathStep, 0, len(raw))
+	for _, s := range raw {
+		step, err := parseStep(s)
+		if err != nil {
+			return nil, fmt.Errorf("xpath %q: %w", xpath, err)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+func parseStep(s string) (xpathStep, error) {
+	name, pred, hasPred := strings.Cut(s, "[")
+	if name == "" {
+		return xpathStep{}, fmt.Errorf("empty step")
+	}
+	step := xpathStep{name: name}
+	if !hasPred {
+		return step, nil
+	}
+	pred, ok := strings.CutSuffix(pred, "]")
+	if !ok || !strings.HasPrefix(pred, "@") {
+		return xpathStep{}, fmt.Errorf("unsupported predicate [%s", pred)
+	}
+	attr, value, ok := strings.Cut(pred[1:], "=")
+	if !ok || len(value) < 2 || (value[0] != '\'' && value[0] != '"') || value[len(value)-1] != value[0] {
+		return xpathStep{}, fmt.Errorf("unsupported predicate [%s]", pred)
+	}
+	step.attr = strings.TrimSpace(attr)
+	step.attrValue = value[1 : len(value)-1]
+	return step, nil
+}
+
+func (s xpathStep) matches(n *Node) bool {
+	if s.name != "*" && n.XMLName.Local != s.name {
+		return false
+	}
+	if s.attr == "" {
+		return true
+	}
+	v, ok := n.Attr(s.attr)
+	return ok && v == s.attrValue
+}
+
+// Select evaluates xpath against the tree rooted at root. A step without a
+// predicate matches every same-named child, mirroring how PAN-OS treats
+// /config/devices/entry/vsys/entry.
+func Select(root *Node, xpath string) ([]*Node, error) {
+	steps, err := splitXPath(xpath)
+	if err != nil {
+		return nil, err
+	}
+	if !steps[0].matches(root) {
+		return nil, nil
+	}
+	current := []*Node{root}
+	for _, step := range steps[1:] {
+		var next []*Node
+		for _, n := range current {
+			for _, c := range n.Children {
+				if step.matches(c) {
+					next = append(next, c)
+				}
+			}
+		}
+		if len(next) == 0 {
+			return nil, nil
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// Overlaps reports whether one XPath addresses an ancestor of (or the same
+// element as) the other. It is how a journal entry's xpath is matched to a
+// diff hunk: an admin who edited .../rules/entry[@name='x']/action touched
+// the hunk for the whole rule, and vice versa. Predicate quoting style is
+// ignored; unparseable paths never overlap. A tail (see TailPrefix)
+// overlaps a path it lines up with at any depth.
+func Overlaps(a, b string) bool {
+	if strings.HasPrefix(b, TailPrefix) {
+		a, b = b, a
+	}
+	if tail, ok := strings.CutPrefix(a, TailPrefix); ok {
+		st, err := splitXPath("/" + tail)
+		if err != nil || tail == "" {
+			return false
+		}
+		sb, err := splitXPath(b)
+		if err != nil {
+			return false
+		}
+		for i := range sb {
+			if stepsOverlap(st, sb[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	sa, err := splitXPath(a)
+	if err != nil {
+		return false
+	}
+	sb, err := splitXPath(b)
+	if err != nil {
+		return false
+	}
+	return stepsOverlap(sa, sb)
+}
+
+// stepsOverlap reports whether sa and sb agree for as many steps as both
+// have.
+func stepsOverlap(sa, sb []xpathStep) bool {
+	for i := range min(len(sa), len(sb)) {
+		x, y := sa[i], sb[i]
+		if x.name != y.name {
+			return false
+		}
+		// An unqualified step (devices/entry) is a wildcard, as in Select.
+		if x.attr != "" && y.attr != "" && (x.attr != y.attr || x.attrValue != y.attrValue) {
+			return false
+		}
+	}
+	return true
+}
+
+// Step renders the location step that addresses n among its siblings:
+// the tag, plus a name predicate for named elements. A name containing a
+// single quote is double-quoted.
+func Step(n *Node) string {
+	name := n.Name()
+	switch {
+	case name == "":
+		return n.XMLName.Local
+	case strings.Contains(name, "'"):
+		return n.XMLName.Local + `[@name="` + name + `"]`
+	default:
+		return n.XMLName.Local + "[@name='" + name + "']"
+	}
+}
@@ -0,0 +1,133 @@
+package pancfg
+
+import "testing"
+
+const testConfig = `<config>
+  <devices>
+    <entry name="localhost.localdomain">
+      <network>
+        <interface>
+          <ethernet>
+            <entry name="ethernet1/1"/>
+            <entry name="ethernet1/2"/>
+          </ethernet>
+        </interface>
+      </network>
+      <vsys>
+        <entry name="vsys1">
+          <rulebase>
+            <security>
+              <rules>
+                <entry name="allow-web"><action>allow</action></entry>
+                <entry name="deny-all"><action>deny</action></entry>
+              </rules>
+            </security>
+            <nat><rules><entry name="outbound-nat"/></rules></nat>
+          </rulebase>
+        </entry>
+      </vsys>
+    </entry>
+  </devices>
+  <shared>
+    <address><entry name="legacy-wildcard"/></address>
+    <service/>
+  </shared>
+</config>`
+
+func TestSelect(t *testing.T) {
+	root, err := Parse(testConfig)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	tests := []struct {
+		xpath string
+		want  int
+	}{
+		{"/config/devices/entry[@name='localhost.localdomain']/vsys/entry[@name='vsys1']/rulebase/security/rules/entry", 2},
+		{"/config/devices/entry/vsys/entry/rulebase/nat/rules/entry", 1},
+		{"/config/devices/entry/network/interface/ethernet/entry[@name='ethernet1/2']", 1},
+		{`/config/shared/address/entry[@name="legacy-wildcard"]`, 1},
+		{"/config/devices/entry/vsys/entry/pre-rulebase/security/rules", 0},
+		{"/config/shared/*", 2},
+		{"/nope", 0},
+	}
+	for _, tt := range tests {
+		nodes, err := Select(root, tt.xpath)
+		if err != nil {
+			t.Errorf("%s: %v", tt.xpath, err)
+			continue
+		}
+		if len(nodes) != tt.want {
+			t.Errorf("%s: got %d nodes, want %d", tt.xpath, len(nodes), tt.want)
+		}
+	}
+}
+
+func TestSplitXPath_Invalid(t *testing.T) {
+	for _, xpath := range []string{
+		"config/shared",
+		"/config//shared",
+		"/config/entry[name='x']",
+		"/config/entry[@name=x]",
+	} {
+		if _, err := splitXPath(xpath); err == nil {
+			t.Errorf("splitXPath(%q): expected error", xpath)
+		}
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	rule := "/config/devices/entry[@name='localhost.localdomain']/vsys/entry[@name='vsys1']/rulebase/security/rules/entry[@name='allow-web']"
+	tests := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{"same", rule, rule, true},
+		{"descendant", rule + "/action", rule, true},
+		{"ancestor", "/config/devices/entry/vsys/entry/rulebase", rule, true},
+		{"quote style", `/config/devices/entry[@name="localhost.localdomain"]/vsys`, rule, true},
+		{"sibling entry", "/config/devices/entry/vsys/entry/rulebase/security/rules/entry[@name='deny-all']", rule, false},
+		{"different branch", "/config/shared/address", rule, false},
+		{"unparseable", "not-a-path", rule, false},
+		{"tail", TailPrefix + "rules/entry[@name='allow-web']", rule, true},
+		{"tail of a descendant", rule + "/action", TailPrefix + "security/rules/entry[@name='allow-web']/action", true},
+		{"tail of a sibling", TailPrefix + "rules/entry[@name='deny-all']", rule, false},
+		{"bare tail", TailPrefix, rule, false},
+	}
+	for _, tt := range tests {
+		if got := Overlaps(tt.a, tt.b); got != tt.want {
+			t.Errorf("%s: Overlaps = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStep(t *testing.T) {
+	root, err := Parse(`<config><shared><address><entry name="web"/><entry name="o'brien"/></address></shared></config>`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	shared := root.Children[0]
+	entries := shared.Children[0].Children
+	for _, tc := range []struct {
+		n    *Node
+		want string
+	}{
+		{shared, "shared"},
+		{entries[0], "entry[@name='web']"},
+		{entries[1], `entry[@name="o'brien"]`},
+	} {
+		if got := Step(tc.n); got != tc.want {
+			t.Errorf("Step = %s, want %s", got, tc.want)
+		}
+		// Every step must address the node it came from.
+		xpath := "/config/shared/address/" + Step(tc.n)
+		if tc.n == shared {
+			xpath = "/config/shared"
+		}
+		if got, err := Select(root, xpath); err != nil || len(got) != 1 || got[0] != tc.n {
+			t.Errorf("Select(%s) = %v, %v", xpath, got, err)
+		}
+	}
+}
@@ -0,0 +1,524 @@
+// Package policydiff compares the rules and objects of two firewalls, or of
+// a firewall and a saved config, entry by entry: what was added, removed,
+// changed field by field, or moved within the rulebase.
+package policydiff
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/models"
+)
+
+// Kind is the kind of rule or object an entry is.
+type Kind int
+
+const (
+	KindSecurityRule Kind = iota
+	KindNATRule
+	KindAddress
+	KindAddressGroup
+	KindService
+	KindServiceGroup
+	KindExternalList
+	KindRegion
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindSecurityRule:
+		return "security rule"
+	case KindNATRule:
+		return "NAT rule"
+	case KindAddress:
+		return "address"
+	case KindAddressGroup:
+		return "address group"
+	case KindService:
+		return "service"
+	case KindServiceGroup:
+		return "service group"
+	case KindExternalList:
+		return "external list"
+	case KindRegion:
+		return "region"
+	}
+	return "unknown"
+}
+
+// Change is how an entry differs between the two sides.
+type Change int
+
+const (
+	Added Change = iota + 1
+	Removed
+	Modified
+	Moved
+)
+
+func (c Change) String() string {
+	switch c {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	case Moved:
+		return "moved"
+	}
+	return "unknown"
+}
+
+// Field is one setting of an entry on each side, rendered as text. A side
+// the entry is missing from has "".
+type Field struct {
+	Name     string
+	Old, New string
+}
+
+// Changed reports whether the two sides differ.
+func (f Field) Changed() bool { return f.Old != f.New }
+
+// Entry is one rule or object that differs between the two sides, matched
+// by name.
+type Entry struct {
+	Kind   Kind
+	Name   string
+	Change Change
+	// Moved is set on a rule whose place relative to the rules both sides
+	// share changed. A rule that also changed otherwise is Modified.
+	Moved bool
+	// OldPos and NewPos are a rule's 1-based positions, 0 on a side it is
+	// missing from and for objects.
+	OldPos, NewPos int
+	// Fields are all of the entry's settings, changed or not.
+	Fields []Field
+}
+
+// ChangedFields are the settings that differ.
+func (e Entry) ChangedFields() []Field {
+	var out []Field
+	for _, f := range e.Fields {
+		if f.Changed() {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// Result is every entry that differs: security rules, then NAT rules, in
+// rulebase order, then objects by kind and name.
+type Result struct {
+	Entries []Entry
+}
+
+// Count is how many entries have change c.
+func (r Result) Count(c Change) int {
+	n := 0
+	for _, e := range r.Entries {
+		if e.Change == c {
+			n++
+		}
+	}
+	return n
+}
+
+// Collect reads the rules and objects Compare looks at from the device
+// target behind c (or c's own device for ""), concurrently. Reads that fail
+// are left out and returned joined.
+func Collect(ctx context.Context, c *api.Client, target string) (models.PolicySet, error) {
+	var (
+		set models.PolicySet
+		rd  api.Reads
+	)
+	rd.Go("security rules", func() error {
+		v, err := c.GetSecurityPolicies(ctx, target)
+		if err == nil {
+			rd.Set(func() { set.Security = v })
+		}
+		return err
+	})
+	rd.Go("NAT rules", func() error {
+		v, err := c.GetNATRules(ctx, target)
+		if err == nil {
+			rd.Set(func() { set.NAT = v })
+		}
+		return err
+	})
+	rd.Go("addresses", func() error {
+		v, err := c.GetAddresses(ctx, target)
+		if err == nil {
+			rd.Set(func() { set.Addresses = v })
+		}
+		return err
+	})
+	rd.Go("address groups", func() error {
+		v, err := c.GetAddressGroups(ctx, target)
+		if err == nil {
+			rd.Set(func() { set.AddressGroups = v })
+		}
+		return err
+	})
+	rd.Go("services", func() error {
+		v, err := c.GetServices(ctx, target)
+		if err == nil {
+			rd.Set(func() { set.Services = v })
+		}
+		return err
+	})
+	rd.Go("service groups", func() error {
+		v, err := c.GetServiceGroups(ctx, target)
+		if err == nil {
+			rd.Set(func() { set.ServiceGroups = v })
+		}
+		return err
+	})
+	rd.Go("external lists", func() error {
+		v, err := c.GetExternalLists(ctx, target)
+		if err == nil {
+			rd.Set(func() { set.ExternalLists = v })
+		}
+		return err
+	})
+	rd.Go("regions", func() error {
+		v, err := c.GetRegions(ctx, target)
+		if err == nil {
+			rd.Set(func() { set.Regions = v })
+		}
+		return err
+	})
+	return set, rd.Wait()
+}
+
+// Compare reports every rule and object that differs between before and
+// after.
+// Entries match by name; where a vsys and shared object share one, the
+// first (the vsys object, which shadows the shared one) is compared.
+// Runtime state — hit counts and positions themselves — is not compared,
+// but a rule that moved relative to the rules around it is.
+func Compare(before, after models.PolicySet) Result {
+	var r Result
+	r.Entries = append(r.Entries, compareKind(KindSecurityRule, before.Security, after.Security, securityRuleSpec)...)
+	r.Entries = append(r.Entries, compareKind(KindNATRule, before.NAT, after.NAT, natRuleSpec)...)
+	r.Entries = append(r.Entries, compareKind(KindAddress, before.Addresses, after.Addresses, addressSpec)...)
+	r.Entries = append(r.Entries, compareKind(KindAddressGroup, before.AddressGroups, after.AddressGroups, addressGroupSpec)...)
+	r.Entries = append(r.Entries, compareKind(KindService, before.Services, after.Services, serviceSpec)...)
+	r.Entries = append(r.Entries, compareKind(KindServiceGroup, before.ServiceGroups, after.ServiceGroups, serviceGroupSpec)...)
+	r.Entries = append(r.Entries, compareKind(KindExternalList, before.ExternalLists, after.ExternalLists, externalListSpec)...)
+	r.Entries = append(r.Entries, compareKind(KindRegion, before.Regions, after.Regions, regionSpec)...)
+	return r
+}
+
+// spec describes how to compare one kind of entry.
+type spec[T any] struct {
+	name   func(T) string
+	fields []field[T]
+	// ordered kinds are rules: their order is policy, so moves count and
+	// entries are listed in rulebase order rather than by name.
+	ordered bool
+}
+
+// field renders one setting of T as text.
+type field[T any] struct {
+	name  string
+	value func(T) string
+}
+
+func compareKind[T any](kind Kind, old, cur []T, s spec[T]) []Entry {
+	oldIndex := indexByName(old, s.name)
+	newIndex := indexByName(cur, s.name)
+
+	var moved map[string]bool
+	if s.ordered {
+		moved = movedNames(old, cur, s.name, oldIndex, newIndex)
+	}
+
+	entry := func(name string, o, n *T) Entry {
+		e := Entry{Kind: kind, Name: name, Moved: moved[name]}
+		if o != nil && s.ordered {
+			e.OldPos = oldIndex[name] + 1
+		}
+		if n != nil && s.ordered {
+			e.NewPos = newIndex[name] + 1
+		}
+		for _, f := range s.fields {
+			var fv Field
+			fv.Name = f.name
+			if o != nil {
+				fv.Old = f.value(*o)
+			}
+			if n != nil {
+				fv.New = f.value(*n)
+			}
+			e.Fields = append(e.Fields, fv)
+		}
+		switch {
+		case o == nil:
+			e.Change = Added
+		case n == nil:
+			e.Change = Removed
+		case len(e.ChangedFields()) > 0:
+			e.Change = Modified
+		case e.Moved:
+			e.Change = Moved
+		}
+		return e
+	}
+
+	var out []Entry
+	emit := func(e Entry) {
+		if e.Change != 0 {
+			out = append(out, e)
+		}
+	}
+	removed := func(i int) {
+		name := s.name(old[i])
+		if _, ok := newIndex[name]; !ok && oldIndex[name] == i {
+			emit(entry(name, &old[i], nil))
+		}
+	}
+
+	if !s.ordered {
+		names := make([]string, 0, len(oldIndex)+len(newIndex))
+		for name := range oldIndex {
+			names = append(names, name)
+		}
+		for name := range newIndex {
+			if _, ok := oldIndex[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			var o, n *T
+			if i, ok := oldIndex[name]; ok {
+				o = &old[i]
+			}
+			if i, ok := newIndex[name]; ok {
+				n = &cur[i]
+			}
+			emit(entry(name, o, n))
+		}
+		return out
+	}
+
+	// Walk cur in order, slotting each removed rule in after the last
+	// shared rule that preceded it on the old side.
+	next := 0
+	for i := range cur {
+		name := s.name(cur[i])
+		if newIndex[name] != i {
+			continue
+		}
+		oi, ok := oldIndex[name]
+		if !ok {
+			emit(entry(name, nil, &cur[i]))
+			continue
+		}
+		for ; next < oi; next++ {
+			removed(next)
+		}
+		next = max(next, oi+1)
+		emit(entry(name, &old[oi], &cur[i]))
+	}
+	for ; next < len(old); next++ {
+		removed(next)
+	}
+	return out
+}
+
+// indexByName maps each name to the index of its first entry.
+func indexByName[T any](items []T, name func(T) string) map[string]int {
+	index := make(map[string]int, len(items))
+	for i, item := range items {
+		if _, ok := index[name(item)]; !ok {
+			index[name(item)] = i
+		}
+	}
+	return index
+}
+
+// movedNames picks the fewest shared rules whose moving explains the new
+// order: every shared rule outside the longest run that keeps its old
+// relative order. Rules added or removed around the others don't move them.
+func movedNames[T any](old, cur []T, name func(T) string, oldIndex, newIndex map[string]int) map[string]bool {
+	var names []string
+	var seq []int // old indexes of the shared rules, in new order
+	for i, item := range cur {
+		n := name(item)
+		if newIndex[n] != i {
+			continue
+		}
+		if oi, ok := oldIndex[n]; ok {
+			names = append(names, n)
+			seq = append(seq, oi)
+		}
+	}
+	keep := longestIncreasing(seq)
+	moved := make(map[string]bool)
+	for i, n := range names {
+		if !keep[i] {
+			moved[n] = true
+		}
+	}
+	return moved
+}
+
+// longestIncreasing marks the members of one longest strictly increasing
+// subsequence of seq.
+func longestIncreasing(seq []int) []bool {
+	// tails[k] is the index in seq of the smallest tail of an increasing
+	// run of length k+1; prev links each element to its predecessor.
+	var tails []int
+	prev := make([]int, len(seq))
+	for i, v := range seq {
+		k, _ := slices.BinarySearchFunc(tails, v, func(t, v int) int { return seq[t] - v })
+		if k > 0 {
+			prev[i] = tails[k-1]
+		} else {
+			prev[i] = -1
+		}
+		if k == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[k] = i
+		}
+	}
+	keep := make([]bool, len(seq))
+	if len(tails) > 0 {
+		for i := tails[len(tails)-1]; i >= 0; i = prev[i] {
+			keep[i] = true
+		}
+	}
+	return keep
+}
+
+// members renders a member list order-insensitively: PAN-OS matches on the
+// set, so a reshuffle is not a change.
+func members(list []string) string {
+	sorted := slices.Clone(list)
+	slices.Sort(sorted)
+	return strings.Join(sorted, ", ")
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+var securityRuleSpec = spec[models.SecurityRule]{
+	name:    func(r models.SecurityRule) string { return r.Name },
+	ordered: true,
+	fields: []field[models.SecurityRule]{
+		{"rulebase", func(r models.SecurityRule) string { return string(r.RuleBase) }},
+		{"disabled", func(r models.SecurityRule) string { return yesNo(r.Disabled) }},
+		{"description", func(r models.SecurityRule) string { return r.Description }},
+		{"tags", func(r models.SecurityRule) string { return members(r.Tags) }},
+		{"type", func(r models.SecurityRule) string { return string(r.RuleType) }},
+		{"source zones", func(r models.SecurityRule) string { return members(r.SourceZones) }},
+		{"sources", func(r models.SecurityRule) string { return members(r.Sources) }},
+		{"negate source", func(r models.SecurityRule) string { return yesNo(r.NegateSource) }},
+		{"source users", func(r models.SecurityRule) string { return members(r.SourceUsers) }},
+		{"destination zones", func(r models.SecurityRule) string { return members(r.DestZones) }},
+		{"destinations", func(r models.SecurityRule) string { return members(r.Destinations) }},
+		{"negate destination", func(r models.SecurityRule) string { return yesNo(r.NegateDest) }},
+		{"applications", func(r models.SecurityRule) string { return members(r.Applications) }},
+		{"services", func(r models.SecurityRule) string { return members(r.Services) }},
+		{"URL categories", func(r models.SecurityRule) string { return members(r.URLCategories) }},
+		{"action", func(r models.SecurityRule) string { return r.Action }},
+		{"profile group", func(r models.SecurityRule) string { return r.Profile }},
+		{"antivirus", func(r models.SecurityRule) string { return r.AntivirusProfile }},
+		{"vulnerability", func(r models.SecurityRule) string { return r.VulnerabilityProfile }},
+		{"anti-spyware", func(r models.SecurityRule) string { return r.SpywareProfile }},
+		{"URL filtering", func(r models.SecurityRule) string { return r.URLFilteringProfile }},
+		{"file blocking", func(r models.SecurityRule) string { return r.FileBlockingProfile }},
+		{"WildFire", func(r models.SecurityRule) string { return r.WildFireProfile }},
+		{"log start", func(r models.SecurityRule) string { return yesNo(r.LogStart) }},
+		{"log end", func(r models.SecurityRule) string { return yesNo(r.LogEnd) }},
+		{"log forwarding", func(r models.SecurityRule) string { return r.LogForwarding }},
+	},
+}
+
+var natRuleSpec = spec[models.NATRule]{
+	name:    func(r models.NATRule) string { return r.Name },
+	ordered: true,
+	fields: []field[models.NATRule]{
+		{"rulebase", func(r models.NATRule) string { return string(r.RuleBase) }},
+		{"disabled", func(r models.NATRule) string { return yesNo(r.Disabled) }},
+		{"description", func(r models.NATRule) string { return r.Description }},
+		{"tags", func(r models.NATRule) string { return members(r.Tags) }},
+		{"NAT type", func(r models.NATRule) string { return r.NATType }},
+		{"source zones", func(r models.NATRule) string { return members(r.SourceZones) }},
+		{"destination zones", func(r models.NATRule) string { return members(r.DestZones) }},
+		{"destination interface", func(r models.NATRule) string { return r.DestInterface }},
+		{"sources", func(r models.NATRule) string { return members(r.Sources) }},
+		{"destinations", func(r models.NATRule) string { return members(r.Destinations) }},
+		{"services", func(r models.NATRule) string { return members(r.Services) }},
+		{"source translation", func(r models.NATRule) string { return string(r.SourceTransType) }},
+		{"translated source", func(r models.NATRule) string { return r.TranslatedSource }},
+		{"interface address", func(r models.NATRule) string { return yesNo(r.SourceInterfaceIP) }},
+		{"translated source port", func(r models.NATRule) string { return r.TranslatedSrcPort }},
+		{"translated destination", func(r models.NATRule) string { return r.TranslatedDest }},
+		{"translated destination port", func(r models.NATRule) string { return r.TranslatedDestPort }},
+		{"active/active binding", func(r models.NATRule) string { return yesNo(r.ActiveActive) }},
+	},
+}
+
+var addressSpec = spec[models.AddressObject]{
+	name: func(a models.AddressObject) string { return a.Name },
+	fields: []field[models.AddressObject]{
+		{"type", func(a models.AddressObject) string { return a.Type }},
+		{"value", func(a models.AddressObject) string { return a.Value }},
+		{"description", func(a models.AddressObject) string { return a.Description }},
+		{"tags", func(a models.AddressObject) string { return members(a.Tags) }},
+	},
+}
+
+var addressGroupSpec = spec[models.AddressGroup]{
+	name: func(g models.AddressGroup) string { return g.Name },
+	fields: []field[models.AddressGroup]{
+		{"members", func(g models.AddressGroup) string { return members(g.Members) }},
+		{"filter", func(g models.AddressGroup) string { return g.Filter }},
+		{"description", func(g models.AddressGroup) string { return g.Description }},
+		{"tags", func(g models.AddressGroup) string { return members(g.Tags) }},
+	},
+}
+
+var serviceSpec = spec[models.ServiceObject]{
+	name: func(s models.ServiceObject) string { return s.Name },
+	fields: []field[models.ServiceObject]{
+		{"protocol", func(s models.ServiceObject) string { return s.Protocol }},
+		{"destination port", func(s models.ServiceObject) string { return s.DestPort }},
+		{"source port", func(s models.ServiceObject) string { return s.SrcPort }},
+		{"description", func(s models.ServiceObject) string { return s.Description }},
+		{"tags", func(s models.ServiceObject) string { return members(s.Tags) }},
+	},
+}
+
+var serviceGroupSpec = spec[models.ServiceGroup]{
+	name: func(g models.ServiceGroup) string { return g.Name },
+	fields: []field[models.ServiceGroup]{
+		{"members", func(g models.ServiceGroup) string { return members(g.Members) }},
+		{"tags", func(g models.ServiceGroup) string { return members(g.Tags) }},
+	},
+}
+
+var externalListSpec = spec[models.ExternalList]{
+	name: func(l models.ExternalList) string { return l.Name },
+	fields: []field[models.ExternalList]{
+		{"type", func(l models.ExternalList) string { return l.Type }},
+		{"source", func(l models.ExternalList) string { return l.Source }},
+	},
+}
+
+var regionSpec = spec[models.Region]{
+	name: func(r models.Region) string { return r.Name },
+	fields: []field[models.Region]{
+		{"addresses", func(r models.Region) string { return members(r.Addresses) }},
+	},
+}
@@ -0,0 +1,121 @@
+package policydiff
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/models"
+	"github.com/jp2195/pyre/internal/testutil"
+)
+
+func rules(names ...string) []models.SecurityRule {
+	var out []models.SecurityRule
+	for i, n := range names {
+		out = append(out, models.SecurityRule{Name: n, Position: i + 1, Action: "allow", Sources: []string{"any"}})
+	}
+	return out
+}
+
+func TestCompare_Rules(t *testing.T) {
+	before := models.PolicySet{Security: rules("a", "b", "c", "d", "gone")}
+	after := models.PolicySet{Security: rules("a", "c", "fresh", "d", "b")}
+	after.Security[3].Action = "deny"           // d modified
+	after.Security[0].HitCount = 99             // runtime state is not a change
+	after.Security[1].Sources = []string{"any"} // same set
+	before.Security[0].Tags = []string{"x", "y"}
+	after.Security[0].Tags = []string{"y", "x"} // member order is not a change
+
+	type row struct {
+		name           string
+		change         Change
+		moved          bool
+		oldPos, newPos int
+	}
+	var got []row
+	for _, e := range Compare(before, after).Entries {
+		if e.Kind != KindSecurityRule {
+			t.Errorf("unexpected kind %v", e.Kind)
+		}
+		got = append(got, row{e.Name, e.Change, e.Moved, e.OldPos, e.NewPos})
+	}
+	want := []row{
+		{"fresh", Added, false, 0, 3},
+		{"d", Modified, false, 4, 4},
+		{"b", Moved, true, 2, 5},
+		{"gone", Removed, false, 5, 0},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("entries:\ngot  %+v\nwant %+v", got, want)
+	}
+
+	res := Compare(before, after)
+	if res.Count(Added) != 1 || res.Count(Removed) != 1 || res.Count(Modified) != 1 || res.Count(Moved) != 1 {
+		t.Errorf("counts = %d/%d/%d/%d", res.Count(Added), res.Count(Removed), res.Count(Modified), res.Count(Moved))
+	}
+	changed := res.Entries[1].ChangedFields()
+	if len(changed) != 1 || changed[0] != (Field{"action", "allow", "deny"}) {
+		t.Errorf("d changed fields = %+v", changed)
+	}
+}
+
+func TestCompare_MovedAndModified(t *testing.T) {
+	before := models.PolicySet{NAT: []models.NATRule{{Name: "x"}, {Name: "y"}, {Name: "z"}}}
+	after := models.PolicySet{NAT: []models.NATRule{{Name: "z", TranslatedDest: "10.0.0.1"}, {Name: "x"}, {Name: "y"}}}
+	res := Compare(before, after)
+	if len(res.Entries) != 1 {
+		t.Fatalf("entries = %+v", res.Entries)
+	}
+	e := res.Entries[0]
+	if e.Name != "z" || e.Change != Modified || !e.Moved || e.OldPos != 3 || e.NewPos != 1 {
+		t.Errorf("entry = %+v", e)
+	}
+}
+
+func TestCompare_Objects(t *testing.T) {
+	before := models.PolicySet{
+		Addresses: []models.AddressObject{
+			{Name: "web", Type: "ip-netmask", Value: "10.0.0.1"},
+			{Name: "db", Type: "ip-netmask", Value: "10.0.0.2"},
+			{Name: "db", Type: "ip-netmask", Value: "192.0.2.2"}, // shared, shadowed
+		},
+		AddressGroups: []models.AddressGroup{{Name: "servers", Members: []string{"web", "db"}}},
+		Regions:       []models.Region{{Name: "hq", Addresses: []string{"10.0.0.0/8"}}},
+	}
+	after := models.PolicySet{
+		Addresses: []models.AddressObject{
+			{Name: "db", Type: "ip-netmask", Value: "10.0.0.2"},
+			{Name: "app", Type: "fqdn", Value: "app.example.com"},
+			{Name: "web", Type: "ip-netmask", Value: "10.0.0.10"},
+		},
+		AddressGroups: []models.AddressGroup{{Name: "servers", Members: []string{"db", "web"}}},
+	}
+	var got []string
+	for _, e := range Compare(before, after).Entries {
+		got = append(got, e.Kind.String()+" "+e.Name+" "+e.Change.String())
+	}
+	want := []string{"address app added", "address web modified", "region hq removed"}
+	if !slices.Equal(got, want) {
+		t.Errorf("entries = %q, want %q", got, want)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	mock := testutil.NewMockPANOS()
+	defer mock.Close()
+	client, err := api.NewClient(mock.Host(), "test-api-key", api.ClientOptions{Insecure: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	set, err := Collect(context.Background(), client, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set.Security) == 0 || len(set.NAT) == 0 || len(set.Addresses) == 0 {
+		t.Errorf("security = %d, NAT = %d, addresses = %d", len(set.Security), len(set.NAT), len(set.Addresses))
+	}
+	if res := Compare(set, set); len(res.Entries) != 0 {
+		t.Errorf("a set compared with itself differs: %+v", res.Entries)
+	}
+}
@@ -0,0 +1,511 @@
+package report
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jp2195/pyre/internal/models"
+)
+
+// busyPercent is the CPU, memory, or session use called out as high, and
+// diskFullPercent the disk use.
+const (
+	busyPercent     = 80
+	diskFullPercent = 90
+)
+
+// level is how much a row or finding needs attention.
+type level int
+
+const (
+	levelOK level = iota
+	levelWarn
+	levelCrit
+)
+
+// Class names the level for the HTML stylesheet.
+func (l level) Class() string {
+	switch l {
+	case levelWarn:
+		return "warn"
+	case levelCrit:
+		return "crit"
+	default:
+		return ""
+	}
+}
+
+// marker prefixes a Markdown line or cell at the level.
+func (l level) marker() string {
+	switch l {
+	case levelWarn:
+		return "⚠ "
+	case levelCrit:
+		return "✖ "
+	default:
+		return ""
+	}
+}
+
+// document is a report laid out for rendering, whatever the format.
+type document struct {
+	Title     string
+	Device    string
+	Generated string
+	Attention []item
+	Sections  []section
+}
+
+// item is one line of the summary.
+type item struct {
+	Level level
+	Text  string
+}
+
+// section is one heading of the document: key/value fields, a table, or
+// both, and the reads that failed for it.
+type section struct {
+	ID       string
+	Title    string
+	Note     string
+	Err      error
+	Fields   []field
+	Header   []string
+	Rows     []row
+	Empty    string // shown when there are no fields or rows
+	Sections []section
+}
+
+type field struct {
+	Label, Value string
+	Level        level
+}
+
+type row struct {
+	Cells []string
+	Level level
+}
+
+// layout arranges r into a document, noting what needs attention.
+func layout(r *Report) document {
+	d := document{
+		Title:     "Health report: " + r.Name(),
+		Device:    r.Host,
+		Generated: r.Generated.Format("2006-01-02 15:04 MST"),
+	}
+	if r.Target != "" {
+		d.Device += " (managed firewall " + r.Target + ")"
+	}
+	flag := func(l level, format string, args ...any) {
+		d.Attention = append(d.Attention, item{Level: l, Text: fmt.Sprintf(format, args...)})
+	}
+	for _, name := range slices.Sorted(maps.Keys(r.Errors)) {
+		flag(levelWarn, "%s could not be read", readNames[name])
+	}
+
+	d.Sections = []section{
+		systemSection(r),
+		contentSection(r),
+		haSection(r, flag),
+		resourcesSection(r, flag),
+		diskSection(r, flag),
+		environmentalsSection(r, flag),
+		licensesSection(r, flag),
+		certificatesSection(r, flag),
+		interfacesSection(r, flag),
+		tunnelsSection(r, flag),
+		bgpSection(r, flag),
+		ospfSection(r, flag),
+		pendingSection(r, flag),
+		hygieneSection(r, flag),
+	}
+	slices.SortStableFunc(d.Attention, func(a, b item) int { return cmp.Compare(b.Level, a.Level) })
+	return d
+}
+
+// readNames describes each read of Collect for the summary.
+var readNames = map[string]string{
+	"system":         "System information",
+	"ha":             "HA status",
+	"resources":      "System resources",
+	"dataplane":      "Dataplane CPU",
+	"sessions":       "Session information",
+	"licenses":       "Licenses",
+	"certificates":   "Certificates",
+	"disk":           "Disk usage",
+	"environmentals": "Environmentals",
+	"interfaces":     "Interfaces",
+	"ipsec":          "IPsec tunnels",
+	"bgp":            "BGP peers",
+	"ospf":           "OSPF neighbors",
+	"pending":        "Pending changes",
+	"rules":          "Security rules",
+}
+
+// flagFunc notes something in the summary.
+type flagFunc func(l level, format string, args ...any)
+
+// errs joins the errors of the named reads.
+func (r *Report) errs(names ...string) error {
+	var errs []error
+	for _, name := range names {
+		if err := r.Errors[name]; err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func systemSection(r *Report) section {
+	s := section{ID: "system", Title: "System", Err: r.errs("system")}
+	si := r.System
+	if si == nil {
+		return s
+	}
+	s.Fields = nonEmpty([]field{
+		{Label: "Hostname", Value: si.Hostname},
+		{Label: "Model", Value: si.Model},
+		{Label: "Serial", Value: si.Serial},
+		{Label: "PAN-OS", Value: si.Version},
+		{Label: "Uptime", Value: si.Uptime},
+		{Label: "Management IP", Value: si.IPAddress},
+		{Label: "Operational mode", Value: si.OperationalMode},
+		{Label: "Multi-vsys", Value: onOff(si.MultiVsys)},
+	})
+	return s
+}
+
+func contentSection(r *Report) section {
+	s := section{ID: "content", Title: "Content versions", Header: []string{"Content", "Version", "Released"}, Err: r.errs("system")}
+	si := r.System
+	if si == nil {
+		return s
+	}
+	for _, c := range [][3]string{
+		{"Applications", si.AppVersion, si.AppReleaseDate},
+		{"Threats", si.ThreatVersion, si.ThreatReleaseDate},
+		{"Antivirus", si.AntivirusVersion, si.AntivirusDate},
+		{"WildFire", si.WildFireVersion, si.WildFireDate},
+		{"URL filtering", si.URLFilteringVersion, ""},
+	} {
+		if c[1] != "" {
+			s.Rows = append(s.Rows, row{Cells: []string{c[0], c[1], orDash(c[2])}})
+		}
+	}
+	s.Empty = "The device reported no content versions."
+	return s
+}
+
+func haSection(r *Report, flag flagFunc) section {
+	s := section{ID: "ha", Title: "High availability", Err: r.errs("ha")}
+	ha := r.HA
+	if ha == nil {
+		return s
+	}
+	if !ha.Enabled {
+		s.Empty = "HA is not enabled."
+		return s
+	}
+	peer := levelOK
+	if ha.PeerState == "" || strings.EqualFold(ha.PeerState, "unknown") || strings.EqualFold(ha.PeerState, "suspended") {
+		peer = levelCrit
+		flag(levelCrit, "HA peer is %s", orDefault(ha.PeerState, "not connected"))
+	}
+	sync := levelOK
+	if ha.SyncState != "" && ha.SyncState != "synchronized" {
+		sync = levelWarn
+		flag(levelWarn, "HA configuration is %s", ha.SyncState)
+	}
+	s.Fields = nonEmpty([]field{
+		{Label: "Mode", Value: ha.Mode},
+		{Label: "Local state", Value: ha.State},
+		{Label: "Peer state", Value: orDefault(ha.PeerState, "not connected"), Level: peer},
+		{Label: "Peer address", Value: ha.PeerIP},
+		{Label: "Config sync", Value: ha.SyncState, Level: sync},
+	})
+	return s
+}
+
+func resourcesSection(r *Report, flag flagFunc) section {
+	s := section{ID: "resources", Title: "Resources", Err: r.errs("resources", "dataplane", "sessions")}
+	percent := func(label string, v float64) {
+		l := levelOK
+		if v > busyPercent {
+			l = levelWarn
+			flag(levelWarn, "%s at %.0f%%", label, v)
+		}
+		s.Fields = append(s.Fields, field{Label: label, Value: fmt.Sprintf("%.0f%%", v), Level: l})
+	}
+	if res := r.Resources; res != nil {
+		percent("Management CPU", res.CPUPercent)
+		percent("Memory", res.MemoryPercent)
+		s.Fields = append(s.Fields, field{Label: "Load average", Value: fmt.Sprintf("%.2f, %.2f, %.2f", res.Load1, res.Load5, res.Load15)})
+	}
+	if r.DataPlaneCPU != nil {
+		percent("Dataplane CPU", *r.DataPlaneCPU)
+	}
+	if ses := r.Sessions; ses != nil {
+		value := strconv.Itoa(ses.ActiveCount)
+		l := levelOK
+		if ses.MaxCount > 0 {
+			used := float64(ses.ActiveCount) / float64(ses.MaxCount) * 100
+			value = fmt.Sprintf("%d of %d (%.0f%%)", ses.ActiveCount, ses.MaxCount, used)
+			if used > busyPercent {
+				l = levelWarn
+				flag(levelWarn, "Session table at %.0f%%", used)
+			}
+		}
+		s.Fields = append(s.Fields,
+			field{Label: "Sessions", Value: value, Level: l},
+			field{Label: "Connections per second", Value: strconv.Itoa(ses.CPS)},
+			field{Label: "Throughput", Value: fmt.Sprintf("%d kbps", ses.ThroughputKbps)},
+		)
+	}
+	return s
+}
+
+func diskSection(r *Report, flag flagFunc) section {
+	s := section{ID: "disk", Title: "Disk usage", Header: []string{"Mount", "Filesystem", "Size", "Used", "Available", "Use"}, Err: r.errs("disk")}
+	for _, d := range r.Disks {
+		l := levelOK
+		if d.Percent >= diskFullPercent {
+			l = levelWarn
+			flag(levelWarn, "Disk %s at %.0f%%", orDefault(d.MountPoint, d.Filesystem), d.Percent)
+		}
+		s.Rows = append(s.Rows, row{Level: l, Cells: []string{orDash(d.MountPoint), d.Filesystem, d.Size, d.Used, d.Available, fmt.Sprintf("%.0f%%", d.Percent)}})
+	}
+	s.Empty = "The device reported no filesystems."
+	return s
+}
+
+func environmentalsSection(r *Report, flag flagFunc) section {
+	s := section{ID: "environmentals", Title: "Environmentals", Header: []string{"Component", "Status", "Value"}, Err: r.errs("environmentals")}
+	for _, e := range r.Environmentals {
+		l := levelOK
+		if e.Alarm {
+			l = levelCrit
+			flag(levelCrit, "%s alarm (%s)", e.Component, orDefault(e.Value, e.Status))
+		}
+		s.Rows = append(s.Rows, row{Level: l, Cells: []string{e.Component, orDash(e.Status), orDash(e.Value)}})
+	}
+	s.Empty = "The device reported no sensors (a VM-Series has none)."
+	return s
+}
+
+func licensesSection(r *Report, flag flagFunc) section {
+	s := section{ID: "licenses", Title: "Licenses", Header: []string{"Feature", "Expires", "Days left"}, Err: r.errs("licenses")}
+	for _, lic := range r.Licenses {
+		l, left := levelOK, "-"
+		perpetual := lic.Expires == "" || strings.EqualFold(lic.Expires, "never")
+		switch {
+		case lic.Expired:
+			l, left = levelCrit, "expired"
+			flag(levelCrit, "License %s has expired", lic.Feature)
+		case perpetual:
+		case lic.DaysLeft < r.Options.ExpiryDays:
+			l, left = levelWarn, strconv.Itoa(lic.DaysLeft)
+			flag(levelWarn, "License %s expires in %d days", lic.Feature, lic.DaysLeft)
+		default:
+			left = strconv.Itoa(lic.DaysLeft)
+		}
+		s.Rows = append(s.Rows, row{Level: l, Cells: []string{lic.Feature, orDefault(lic.Expires, "Never"), left}})
+	}
+	s.Empty = "The device reported no licenses."
+	return s
+}
+
+func certificatesSection(r *Report, flag flagFunc) section {
+	s := section{ID: "certificates", Title: "Certificates", Header: []string{"Name", "Subject", "Issuer", "Expires", "Days left"}, Err: r.errs("certificates")}
+	certs := slices.Clone(r.Certificates)
+	slices.SortStableFunc(certs, func(a, b models.Certificate) int { return cmp.Compare(a.DaysLeft, b.DaysLeft) })
+	for _, c := range certs {
+		l := levelOK
+		switch {
+		case c.DaysLeft < 0:
+			l = levelCrit
+			flag(levelCrit, "Certificate %s has expired", c.Name)
+		case c.DaysLeft < r.Options.ExpiryDays:
+			l = levelWarn
+			flag(levelWarn, "Certificate %s expires in %d days", c.Name, c.DaysLeft)
+		}
+		expires := "-"
+		if !c.NotAfter.IsZero() {
+			expires = c.NotAfter.Format("2006-01-02")
+		}
+		s.Rows = append(s.Rows, row{Level: l, Cells: []string{c.Name, orDash(c.Subject), orDash(c.Issuer), expires, strconv.Itoa(c.DaysLeft)}})
+	}
+	s.Empty = "The device reported no certificates."
+	return s
+}
+
+func interfacesSection(r *Report, flag flagFunc) section {
+	s := section{ID: "interfaces", Title: "Interfaces with errors", Header: []string{"Interface", "State", "Zone", "Errors in", "Errors out", "Drops in", "Drops out"}, Err: r.errs("interfaces")}
+	if r.Interfaces == nil {
+		return s
+	}
+	up := 0
+	for _, iface := range r.Interfaces {
+		if strings.EqualFold(iface.State, "up") {
+			up++
+		}
+		if iface.ErrorsIn == 0 && iface.ErrorsOut == 0 {
+			continue
+		}
+		s.Rows = append(s.Rows, row{Level: levelWarn, Cells: []string{iface.Name, orDash(iface.State), orDash(iface.Zone),
+			count(iface.ErrorsIn), count(iface.ErrorsOut), count(iface.DropsIn), count(iface.DropsOut)}})
+	}
+	if len(s.Rows) > 0 {
+		flag(levelWarn, "%d interface(s) report errors", len(s.Rows))
+	}
+	s.Note = fmt.Sprintf("%d interfaces, %d up.", len(r.Interfaces), up)
+	s.Empty = "No interface reports errors."
+	return s
+}
+
+func tunnelsSection(r *Report, flag flagFunc) section {
+	s := section{ID: "ipsec", Title: "IPsec tunnels down", Header: []string{"Tunnel", "Gateway", "State"}, Err: r.errs("ipsec")}
+	if r.Tunnels == nil {
+		return s
+	}
+	for _, t := range r.Tunnels {
+		if !strings.EqualFold(t.State, "up") {
+			s.Rows = append(s.Rows, row{Level: levelCrit, Cells: []string{t.Name, orDash(t.Gateway), orDash(t.State)}})
+		}
+	}
+	if len(s.Rows) > 0 {
+		flag(levelCrit, "%d of %d IPsec tunnel(s) down", len(s.Rows), len(r.Tunnels))
+	}
+	s.Note = fmt.Sprintf("%d tunnels, %d up.", len(r.Tunnels), len(r.Tunnels)-len(s.Rows))
+	s.Empty = "Every tunnel is up."
+	if len(r.Tunnels) == 0 {
+		s.Note, s.Empty = "", "No IPsec tunnels are configured."
+	}
+	return s
+}
+
+func bgpSection(r *Report, flag flagFunc) section {
+	s := section{ID: "bgp", Title: "BGP peers", Header: []string{"Peer", "AS", "Virtual router", "State", "Prefixes in", "Prefixes out", "Uptime"}, Err: r.errs("bgp")}
+	down := 0
+	for _, p := range r.BGPPeers {
+		l := levelOK
+		if !strings.EqualFold(p.State, "established") {
+			l = levelCrit
+			down++
+		}
+		s.Rows = append(s.Rows, row{Level: l, Cells: []string{p.PeerAddress, strconv.Itoa(p.PeerAS), orDash(p.VirtualRouter), p.State,
+			strconv.Itoa(p.PrefixesReceived), strconv.Itoa(p.PrefixesSent), orDash(p.Uptime)}})
+	}
+	if down > 0 {
+		flag(levelCrit, "%d of %d BGP peer(s) not established", down, len(r.BGPPeers))
+	}
+	s.Empty = "No BGP peers are configured."
+	return s
+}
+
+func ospfSection(r *Report, flag flagFunc) section {
+	s := section{ID: "ospf", Title: "OSPF neighbors", Header: []string{"Neighbor", "Address", "Interface", "Area", "State"}, Err: r.errs("ospf")}
+	down := 0
+	for _, n := range r.OSPFNeighbors {
+		l := levelOK
+		// 2-Way is where a DROther's adjacencies with other DROthers rest.
+		if st := strings.ToLower(n.State); !strings.HasPrefix(st, "full") && !strings.HasPrefix(st, "2way") && !strings.HasPrefix(st, "2-way") {
+			l = levelWarn
+			down++
+		}
+		s.Rows = append(s.Rows, row{Level: l, Cells: []string{n.NeighborID, orDash(n.Address), orDash(n.Interface), orDash(n.Area), n.State}})
+	}
+	if down > 0 {
+		flag(levelWarn, "%d of %d OSPF neighbor(s) not full", down, len(r.OSPFNeighbors))
+	}
+	s.Empty = "No OSPF neighbors."
+	return s
+}
+
+func pendingSection(r *Report, flag flagFunc) section {
+	s := section{ID: "pending", Title: "Pending changes", Header: []string{"Admin", "Type", "Location", "When"}, Err: r.errs("pending")}
+	for _, c := range r.PendingChanges {
+		when := "-"
+		if !c.Time.IsZero() {
+			when = c.Time.Format("2006-01-02 15:04")
+		}
+		s.Rows = append(s.Rows, row{Cells: []string{orDash(c.User), orDash(c.Type), orDash(c.Location), when}})
+	}
+	if len(r.PendingChanges) > 0 {
+		flag(levelWarn, "%d uncommitted change(s)", len(r.PendingChanges))
+	}
+	s.Empty = "The candidate configuration matches the running one."
+	return s
+}
+
+func hygieneSection(r *Report, flag flagFunc) section {
+	s := section{ID: "hygiene", Title: "Rule hygiene", Header: []string{"Check", "Rules"}, Err: r.errs("rules")}
+	if r.Rules == nil {
+		if s.Err == nil {
+			s.Empty = "No security rules."
+		}
+		return s
+	}
+	enabled := 0
+	for _, rule := range r.Rules {
+		if !rule.Disabled {
+			enabled++
+		}
+	}
+	s.Note = fmt.Sprintf("%d security rules, %d enabled.", len(r.Rules), enabled)
+	for _, c := range Hygiene(r.Rules) {
+		l := levelOK
+		if len(c.Rules) > 0 {
+			l = levelWarn
+		}
+		s.Rows = append(s.Rows, row{Level: l, Cells: []string{c.Title, strconv.Itoa(len(c.Rules))}})
+		if len(c.Rules) == 0 {
+			continue
+		}
+		sub := section{ID: "hygiene-" + slug(c.Title), Title: c.Title, Note: c.Why, Header: []string{"#", "Rule", "Action", "Hits"}}
+		for _, rule := range c.Rules {
+			sub.Rows = append(sub.Rows, row{Cells: []string{strconv.Itoa(rule.Position), rule.Name, rule.Action, count(rule.HitCount)}})
+		}
+		s.Sections = append(s.Sections, sub)
+	}
+	if n := len(s.Sections); n > 0 {
+		flag(levelWarn, "%d rule-hygiene check(s) have findings", n)
+	}
+	return s
+}
+
+// nonEmpty drops the fields with no value.
+func nonEmpty(fields []field) []field {
+	return slices.DeleteFunc(fields, func(f field) bool { return f.Value == "" })
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+func orDash(s string) string {
+	return orDefault(s, "-")
+}
+
+func count(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
+
+// slug makes an HTML id out of a title.
+func slug(title string) string {
+	return strings.ReplaceAll(strings.ToLower(title), " ", "-")
+}
@@ -0,0 +1,105 @@
+package report
+
+import (
+	"html/template"
+	"io"
+)
+
+// WriteHTML writes r to w as a single HTML page with its styles inline,
+// so it can be mailed or archived as one file.
+func WriteHTML(w io.Writer, r *Report) error {
+	return htmlTemplate.Execute(w, layout(r))
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #1f2328; margin: 2em auto; max-width: 72em; padding: 0 1em; line-height: 1.4; }
+h1 { border-bottom: 2px solid #d0d7de; padding-bottom: .3em; }
+h2 { border-bottom: 1px solid #d0d7de; padding-bottom: .2em; margin-top: 2em; }
+.meta { color: #59636e; }
+nav ul { columns: 3; padding-left: 1.2em; }
+table { border-collapse: collapse; margin: .5em 0; }
+th, td { border: 1px solid #d0d7de; padding: .25em .6em; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+dl { display: grid; grid-template-columns: max-content auto; gap: .2em 1.5em; }
+dt { font-weight: 600; }
+dd { margin: 0; }
+.warn { background: #fff8c5; }
+.crit { background: #ffebe9; }
+li.warn, li.crit { padding: .1em .4em; list-style-position: inside; }
+.error { border-left: 4px solid #cf222e; padding: .3em .8em; background: #ffebe9; white-space: pre-line; }
+.empty, .note { color: #59636e; }
+@media print { nav { display: none; } h2 { break-after: avoid; } }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="meta">Device: {{.Device}}<br>Generated: {{.Generated}}</p>
+<nav><ul>
+<li><a href="#summary">Summary</a></li>
+{{- range .Sections}}
+<li><a href="#{{.ID}}">{{.Title}}</a></li>
+{{- end}}
+</ul></nav>
+<h2 id="summary">Summary</h2>
+{{- if .Attention}}
+<ul>
+{{- range .Attention}}
+<li class="{{.Level.Class}}">{{.Text}}</li>
+{{- end}}
+</ul>
+{{- else}}
+<p class="empty">Nothing needs attention.</p>
+{{- end}}
+{{- range .Sections}}
+{{template "section" .}}
+{{- end}}
+</body>
+</html>
+{{define "section"}}
+<section id="{{.ID}}">
+<h2>{{.Title}}</h2>
+{{- if .Err}}
+<p class="error">Could not be read: {{.Err}}</p>
+{{- end}}
+{{- if .Note}}
+<p class="note">{{.Note}}</p>
+{{- end}}
+{{- if .Fields}}
+<dl>
+{{- range .Fields}}
+<dt>{{.Label}}</dt><dd class="{{.Level.Class}}">{{.Value}}</dd>
+{{- end}}
+</dl>
+{{- end}}
+{{- if .Rows}}
+<table>
+<tr>{{range .Header}}<th>{{.}}</th>{{end}}</tr>
+{{- range .Rows}}
+<tr class="{{.Level.Class}}">{{range .Cells}}<td>{{.}}</td>{{end}}</tr>
+{{- end}}
+</table>
+{{- else if and (not .Fields) (not .Err) .Empty}}
+<p class="empty">{{.Empty}}</p>
+{{- end}}
+{{- range .Sections}}
+{{template "subsection" .}}
+{{- end}}
+</section>
+{{end}}
+{{define "subsection"}}
+<h3 id="{{.ID}}">{{.Title}}</h3>
+{{- if .Note}}
+<p class="note">{{.Note}}</p>
+{{- end}}
+<table>
+<tr>{{range .Header}}<th>{{.}}</th>{{end}}</tr>
+{{- range .Rows}}
+<tr>{{range .Cells}}<td>{{.}}</td>{{end}}</tr>
+{{- end}}
+</table>
+{{end}}`))
@@ -0,0 +1,70 @@
+package report
+
+import (
+	"strings"
+
+	"github.com/jp2195/pyre/internal/audit"
+	"github.com/jp2195/pyre/internal/models"
+)
+
+// Check is one rule-hygiene check and the rules that fail it.
+type Check struct {
+	Title string
+	Why   string
+	Rules []models.SecurityRule
+}
+
+// hygieneChecks are run against every security rule, in this order.
+var hygieneChecks = []struct {
+	title, why string
+	fails      func(models.SecurityRule) bool
+}{
+	{
+		title: "Unused rules",
+		why:   "Enabled rules with no hits since the counters were last reset; candidates for removal.",
+		fails: func(r models.SecurityRule) bool { return !r.Disabled && r.HitCount == 0 },
+	},
+	{
+		title: "Disabled rules",
+		why:   "Rules left in the rulebase disabled; remove them or record why they are kept.",
+		fails: func(r models.SecurityRule) bool { return r.Disabled },
+	},
+	{
+		title: "Overly broad allow rules",
+		why:   "Allow rules matching any source, destination, application, and service.",
+		fails: func(r models.SecurityRule) bool {
+			return audit.Fails("allow-any-any", r)
+		},
+	},
+	{
+		title: "Allow rules without security profiles",
+		why:   "Allowed traffic is not inspected for threats, malware, or URLs.",
+		fails: func(r models.SecurityRule) bool { return audit.Fails("no-security-profile", r) },
+	},
+	{
+		title: "Rules without session-end logging",
+		why:   "Traffic these rules match leaves no traffic log.",
+		fails: func(r models.SecurityRule) bool { return audit.Fails("log-end-off", r) },
+	},
+	{
+		title: "Rules without a description",
+		why:   "Nothing records why these rules exist.",
+		fails: func(r models.SecurityRule) bool { return !r.Disabled && strings.TrimSpace(r.Description) == "" },
+	},
+}
+
+// Hygiene runs the rule-hygiene checks over rules and returns each with
+// the rules that fail it, in rulebase order. A check every rule passes
+// is returned with no rules.
+func Hygiene(rules []models.SecurityRule) []Check {
+	checks := make([]Check, len(hygieneChecks))
+	for i, hc := range hygieneChecks {
+		checks[i] = Check{Title: hc.title, Why: hc.why}
+		for _, r := range rules {
+			if hc.fails(r) {
+				checks[i].Rules = append(checks[i].Rules, r)
+			}
+		}
+	}
+	return checks
+}
@@ -0,0 +1,67 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMarkdown writes r to w as a Markdown document.
+func WriteMarkdown(w io.Writer, r *Report) error {
+	d := layout(r)
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "# %s\n\n", d.Title)
+	fmt.Fprintf(b, "Device: %s  \nGenerated: %s\n\n", d.Device, d.Generated)
+
+	b.WriteString("## Summary\n\n")
+	if len(d.Attention) == 0 {
+		b.WriteString("Nothing needs attention.\n")
+	}
+	for _, it := range d.Attention {
+		fmt.Fprintf(b, "- %s%s\n", it.Level.marker(), it.Text)
+	}
+	for _, s := range d.Sections {
+		writeMarkdownSection(b, s, 2)
+	}
+	return b.Flush()
+}
+
+func writeMarkdownSection(b *bufio.Writer, s section, depth int) {
+	fmt.Fprintf(b, "\n%s %s\n\n", strings.Repeat("#", depth), s.Title)
+	if s.Err != nil {
+		fmt.Fprintf(b, "> **Could not be read:** %s\n\n", mdEscape(s.Err.Error()))
+	}
+	if s.Note != "" {
+		fmt.Fprintf(b, "%s\n\n", s.Note)
+	}
+	for _, f := range s.Fields {
+		fmt.Fprintf(b, "- **%s:** %s%s\n", f.Label, f.Level.marker(), mdEscape(f.Value))
+	}
+	if len(s.Rows) > 0 {
+		if len(s.Fields) > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(b, "| %s |\n", strings.Join(s.Header, " | "))
+		fmt.Fprintf(b, "|%s\n", strings.Repeat(" --- |", len(s.Header)))
+		for _, r := range s.Rows {
+			cells := make([]string, len(r.Cells))
+			for i, c := range r.Cells {
+				cells[i] = mdEscape(c)
+			}
+			cells[0] = r.Level.marker() + cells[0]
+			fmt.Fprintf(b, "| %s |\n", strings.Join(cells, " | "))
+		}
+	}
+	if len(s.Fields) == 0 && len(s.Rows) == 0 && s.Err == nil && s.Empty != "" {
+		fmt.Fprintf(b, "%s\n", s.Empty)
+	}
+	for _, sub := range s.Sections {
+		writeMarkdownSection(b, sub, depth+1)
+	}
+}
+
+// mdEscape keeps a value from breaking out of its table cell or line.
+func mdEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ", "\r", "").Replace(s)
+}
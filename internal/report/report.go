@@ -0,0 +1,183 @@
+// Package report assembles a device's state, health, and rule hygiene
+// into one self-contained document, in Markdown or HTML, for periodic
+// reviews.
+package report
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/models"
+)
+
+// DefaultExpiryDays is how soon a license or certificate must expire to
+// be called out, unless Options says otherwise.
+const DefaultExpiryDays = 30
+
+// Options tunes what a report calls out.
+type Options struct {
+	// ExpiryDays flags licenses and certificates expiring in fewer days.
+	ExpiryDays int
+}
+
+// Report is everything read from one device. A nil or empty field whose
+// read failed has its error in Errors, under the read's name.
+type Report struct {
+	Host      string
+	Target    string // Panorama-managed firewall serial, or ""
+	Generated time.Time
+	Options   Options
+
+	System         *models.SystemInfo
+	HA             *models.HAStatus
+	Resources      *models.Resources
+	DataPlaneCPU   *float64
+	Sessions       *models.SessionInfo
+	Licenses       []models.LicenseInfo
+	Certificates   []models.Certificate
+	Disks          []models.DiskUsage
+	Environmentals []models.Environmental
+	Interfaces     []models.Interface
+	Tunnels        []models.IPSecTunnel
+	BGPPeers       []models.BGPNeighbor
+	OSPFNeighbors  []models.OSPFNeighbor
+	PendingChanges []models.PendingChange
+	Rules          []models.SecurityRule
+
+	Errors map[string]error
+}
+
+// Collect reads the device target behind c (or c's own device for "")
+// concurrently. Reads that fail are left out of the report, recorded in
+// its Errors, and returned joined; the report is usable either way.
+func Collect(ctx context.Context, c *api.Client, host, target string, opts Options) (*Report, error) {
+	if opts.ExpiryDays <= 0 {
+		opts.ExpiryDays = DefaultExpiryDays
+	}
+	var (
+		r  = &Report{Host: host, Target: target, Generated: time.Now(), Options: opts, Errors: map[string]error{}}
+		rd api.Reads
+	)
+	rd.Go("system", func() error {
+		info, err := c.GetSystemInfo(ctx, target)
+		if err == nil {
+			rd.Set(func() { r.System = info })
+		}
+		return err
+	})
+	rd.Go("ha", func() error {
+		ha, err := c.GetHAStatus(ctx, target)
+		if err == nil {
+			rd.Set(func() { r.HA = ha })
+		}
+		return err
+	})
+	rd.Go("resources", func() error {
+		res, err := c.GetSystemResources(ctx, target)
+		if err == nil {
+			rd.Set(func() { r.Resources = res })
+		}
+		return err
+	})
+	rd.Go("dataplane", func() error {
+		cpu, err := c.GetDataPlaneResources(ctx, target)
+		if err == nil {
+			rd.Set(func() { r.DataPlaneCPU = &cpu })
+		}
+		return err
+	})
+	rd.Go("sessions", func() error {
+		info, err := c.GetSessionInfo(ctx, target)
+		if err == nil {
+			rd.Set(func() { r.Sessions = info })
+		}
+		return err
+	})
+	rd.Go("licenses", func() error {
+		licenses, err := c.GetLicenseInfo(ctx, target)
+		if err == nil {
+			rd.Set(func() { r.Licenses = licenses })
+		}
+		return err
+	})
+	rd.Go("certificates", func() error {
+		certs, err := c.GetCertificates(ctx, target)
+		if err == nil {
+			rd.Set(func() { r.Certificates = certs })
+		}
+		return err
+	})
+	rd.Go("disk", func() error {
+		disks, err := c.GetDiskUsage(ctx, target)
+		if err == nil {
+			rd.Set(func() { r.Disks = disks })
+		}
+		return err
+	})
+	rd.Go("environmentals", func() error {
+		env, err := c.GetEnvironmentals(ctx, target)
+		if err == nil {
+			rd.Set(func() { r.Environmentals = env })
+		}
+		return err
+	})
+	rd.Go("interfaces", func() error {
+		ifaces, err := c.GetInterfaces(ctx, target)
+		if err == nil {
+			rd.Set(func() { r.Interfaces = ifaces })
+		}
+		return err
+	})
+	rd.Go("ipsec", func() error {
+		tunnels, err := c.GetIPSecTunnels(ctx, target)
+		if err == nil {
+			rd.Set(func() { r.Tunnels = tunnels })
+		}
+		return err
+	})
+	rd.Go("bgp", func() error {
+		peers, err := c.GetBGPNeighbors(ctx, target)
+		if err == nil {
+			rd.Set(func() { r.BGPPeers = peers })
+		}
+		return err
+	})
+	rd.Go("ospf", func() error {
+		neighbors, err := c.GetOSPFNeighbors(ctx, target)
+		if err == nil {
+			rd.Set(func() { r.OSPFNeighbors = neighbors })
+		}
+		return err
+	})
+	rd.Go("pending", func() error {
+		changes, err := c.GetPendingChanges(ctx, target)
+		if err == nil {
+			rd.Set(func() { r.PendingChanges = changes })
+		}
+		return err
+	})
+	rd.Go("rules", func() error {
+		rules, err := c.GetSecurityPolicies(ctx, target)
+		if err == nil {
+			rd.Set(func() { r.Rules = rules })
+		}
+		return err
+	})
+	err := rd.Wait()
+	maps.Copy(r.Errors, rd.Errors())
+	return r, err
+}
+
+// Name is how the report refers to its device: the hostname it reports,
+// falling back to the host pyre connected to.
+func (r *Report) Name() string {
+	if r.System != nil && r.System.Hostname != "" {
+		return r.System.Hostname
+	}
+	if r.Target != "" {
+		return r.Host + "/" + r.Target
+	}
+	return r.Host
+}
@@ -0,0 +1,137 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/models"
+	"github.com/jp2195/pyre/internal/testutil"
+)
+
+func TestCollect(t *testing.T) {
+	mock := testutil.NewMockPANOS()
+	defer mock.Close()
+	client, err := api.NewClient(mock.Host(), "test-api-key", api.ClientOptions{Insecure: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := Collect(context.Background(), client, mock.Host(), "", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.System == nil || r.HA == nil || r.Resources == nil || r.Licenses == nil || r.Interfaces == nil || r.Rules == nil {
+		t.Errorf("report is missing reads: %+v", r)
+	}
+	if r.Options.ExpiryDays != DefaultExpiryDays || len(r.Errors) != 0 {
+		t.Errorf("options %+v, errors %v", r.Options, r.Errors)
+	}
+}
+
+func TestHygiene(t *testing.T) {
+	profiled := models.SecurityRule{Name: "web", Action: "allow", Profile: "default", LogEnd: true, Description: "web", HitCount: 10,
+		Sources: []string{"10.0.0.0/8"}}
+	rules := []models.SecurityRule{
+		profiled,
+		{Name: "wide-open", Action: "allow", Sources: []string{"any"}, LogEnd: true, Description: "temp", HitCount: 1},
+		{Name: "old", Action: "allow", Disabled: true},
+		{Name: "block", Action: "deny", HitCount: 5},
+	}
+	got := map[string][]string{}
+	for _, c := range Hygiene(rules) {
+		for _, r := range c.Rules {
+			got[c.Title] = append(got[c.Title], r.Name)
+		}
+	}
+	want := map[string][]string{
+		"Disabled rules":                        {"old"},
+		"Overly broad allow rules":              {"wide-open"},
+		"Allow rules without security profiles": {"wide-open"},
+		"Rules without session-end logging":     {"block"},
+		"Rules without a description":           {"block"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("findings = %v, want %v", got, want)
+	}
+	for title, names := range want {
+		if strings.Join(got[title], ",") != strings.Join(names, ",") {
+			t.Errorf("%s = %v, want %v", title, got[title], names)
+		}
+	}
+}
+
+// needsAttention is a report with something wrong in most sections.
+func needsAttention() *Report {
+	return &Report{
+		Host:      "10.0.0.1",
+		Generated: time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC),
+		Options:   Options{ExpiryDays: 30},
+		System:    &models.SystemInfo{Hostname: "fw-edge", Version: "11.1.2", AppVersion: "8800-9000"},
+		HA:        &models.HAStatus{Enabled: true, State: "active", PeerState: "unknown", SyncState: "synchronized"},
+		Licenses: []models.LicenseInfo{
+			{Feature: "Threat Prevention", Expires: "March 20, 2026", DaysLeft: 10},
+			{Feature: "PA-VM", Expires: "Never"},
+		},
+		Certificates: []models.Certificate{{Name: "old-ca", DaysLeft: -3}},
+		Tunnels:      []models.IPSecTunnel{{Name: "branch-1", State: "up"}, {Name: "branch|2", State: "down"}},
+		Interfaces:   []models.Interface{{Name: "ethernet1/1", State: "up", ErrorsIn: 12}},
+		Rules:        []models.SecurityRule{{Name: "<script>alert(1)</script>", Action: "allow", LogEnd: true, Description: "x", HitCount: 1}},
+		Errors:       map[string]error{"bgp": errors.New("timeout")},
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var b strings.Builder
+	if err := WriteMarkdown(&b, needsAttention()); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"# Health report: fw-edge",
+		"- ✖ HA peer is unknown",
+		"- ✖ Certificate old-ca has expired",
+		"- ✖ 1 of 2 IPsec tunnel(s) down",
+		"- ⚠ License Threat Prevention expires in 10 days",
+		"- ⚠ BGP peers could not be read",
+		"> **Could not be read:** bgp: timeout",
+		`| ✖ branch\|2 | - | down |`,
+		"| ⚠ ethernet1/1 | up | - | 12 | 0 | 0 | 0 |",
+		"| PA-VM | Never | - |",
+		"| Applications | 8800-9000 | - |",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("markdown is missing %q:\n%s", want, out)
+		}
+	}
+	// Critical findings lead the summary.
+	if strings.Index(out, "HA peer is unknown") > strings.Index(out, "expires in 10 days") {
+		t.Errorf("warnings come before critical findings:\n%s", out)
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	var b strings.Builder
+	if err := WriteHTML(&b, needsAttention()); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	if strings.Contains(out, "<script>") {
+		t.Error("a rule name was not escaped")
+	}
+	if strings.Contains(out, "<link") || strings.Contains(out, "src=") {
+		t.Error("the page should not load anything")
+	}
+	for _, want := range []string{
+		"<title>Health report: fw-edge</title>",
+		`<li class="crit">HA peer is unknown</li>`,
+		`<tr class="crit"><td>branch|2</td>`,
+		`<section id="hygiene">`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("html is missing %q", want)
+		}
+	}
+}
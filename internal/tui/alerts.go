@@ -0,0 +1,108 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/jp2195/pyre/internal/alerts"
+	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/devicefile"
+	"github.com/jp2195/pyre/internal/models"
+)
+
+// maxAlertHistory caps the alert events kept for the session.
+const maxAlertHistory = 500
+
+// scheduleAlertCheck asks for the next periodic alert check.
+func (m Model) scheduleAlertCheck() tea.Cmd {
+	if m.alerts == nil {
+		return nil
+	}
+	return tea.Tick(m.alertInterval, func(time.Time) tea.Msg { return AlertTickMsg{} })
+}
+
+// checkAlerts reads what the alert rules need from the active device.
+// Responses younger than half the interval are reused, so a check right
+// after a dashboard refresh costs the device little; an explicit refresh
+// reads afresh.
+func (m Model) checkAlerts() tea.Cmd {
+	conn := m.session.GetActiveConnection()
+	if m.alerts == nil || conn == nil {
+		return nil
+	}
+	target := conn.Target()
+	device := devicefile.Device(conn.Host, target)
+	client := conn.Client
+	rules := m.alerts.Rules()
+	ttl := m.alertInterval / 2
+	if m.bypassCache {
+		ttl = 0
+	}
+	ctx := m.ctx
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(api.WithCache(ctx, ttl), m.alertInterval)
+		defer cancel()
+		s, err := alerts.Collect(ctx, client, target, rules)
+		return AlertsCheckedMsg{Device: device, Snapshot: s, Err: err, At: time.Now()}
+	}
+}
+
+// handleAlertTick runs a check, unless one is still going, and schedules
+// the next.
+func (m Model) handleAlertTick() (tea.Model, tea.Cmd) {
+	next := m.scheduleAlertCheck()
+	if m.alertChecking {
+		return m, next
+	}
+	check := m.checkAlerts()
+	if check == nil {
+		return m, next
+	}
+	m.alertChecking = true
+	return m, tea.Batch(check, next)
+}
+
+// handleAlertsChecked runs the rules over a check's snapshot and records
+// the events it raised.
+func (m Model) handleAlertsChecked(msg AlertsCheckedMsg) (tea.Model, tea.Cmd) {
+	m.alertChecking = false
+	events := m.alerts.Observe(msg.Device, msg.Snapshot, msg.At)
+	if len(events) > 0 {
+		history := make([]models.AlertEvent, 0, min(len(m.alertHistory)+len(events), maxAlertHistory))
+		for i := len(events) - 1; i >= 0; i-- {
+			history = append(history, events[i])
+		}
+		history = append(history, m.alertHistory...)
+		m.alertHistory = history[:min(len(history), maxAlertHistory)]
+		if m.currentView != ViewAlerts {
+			m.alertsUnseen += len(events)
+		}
+	}
+	m.alertsView = m.alertsView.SetAlerts(m.alerts.Active(), m.alertHistory, msg.At, msg.Err)
+	return m, nil
+}
+
+// alertBanner is the footer line for active alerts, or for events not yet
+// seen in the Alerts view, or "" if there are neither.
+func (m Model) alertBanner() string {
+	if m.alerts == nil {
+		return ""
+	}
+	if active := m.alerts.Active(); len(active) > 0 {
+		latest := active[len(active)-1]
+		banner := fmt.Sprintf("▲ %s on %s", latest.Message, latest.Device)
+		if len(active) > 1 {
+			banner += fmt.Sprintf(" (+%d more)", len(active)-1)
+		}
+		return ErrorStyle.Render(banner + " · Ctrl+P Alerts")
+	}
+	if m.alertsUnseen > 0 && len(m.alertHistory) > 0 {
+		latest := m.alertHistory[0]
+		return WarningStyle.Render(fmt.Sprintf("● %d new alert event(s): %s on %s · Ctrl+P Alerts",
+			m.alertsUnseen, latest.Message, latest.Device))
+	}
+	return ""
+}
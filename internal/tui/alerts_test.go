@@ -0,0 +1,79 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jp2195/pyre/internal/alerts"
+	"github.com/jp2195/pyre/internal/config"
+	"github.com/jp2195/pyre/internal/models"
+)
+
+func newAlertingModel(t *testing.T) Model {
+	t.Helper()
+	m := newTestModel(t, ViewDashboard)
+	rules, interval, _, err := alerts.FromSettings(config.DefaultAlertSettings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.alerts = alerts.NewEngine(rules)
+	m.alertInterval = interval
+	m.alertsView = m.alertsView.SetInterval(interval)
+	return m
+}
+
+func TestAlerts_BannerFollowsBreaches(t *testing.T) {
+	m := newAlertingModel(t)
+	if banner := m.alertBanner(); banner != "" {
+		t.Errorf("banner before any check = %q", banner)
+	}
+
+	at := time.Now()
+	updated, _ := m.Update(AlertsCheckedMsg{Device: "fw1", At: at, Snapshot: alerts.Snapshot{
+		Resources: &models.Resources{CPUPercent: 97},
+		Tunnels:   []models.IPSecTunnel{{Name: "to-branch", State: "down"}},
+	}})
+	m = updated.(Model)
+	footer := m.renderFooter()
+	if !strings.Contains(footer, "on fw1 (+1 more)") {
+		t.Errorf("footer should show the active breaches:\n%s", footer)
+	}
+	if m.alertsUnseen != 2 || len(m.alertHistory) != 2 {
+		t.Errorf("unseen = %d, history = %d; want 2 and 2", m.alertsUnseen, len(m.alertHistory))
+	}
+
+	updated, _ = m.Update(AlertsCheckedMsg{Device: "fw1", At: at.Add(time.Minute), Snapshot: alerts.Snapshot{
+		Resources: &models.Resources{CPUPercent: 10},
+		Tunnels:   []models.IPSecTunnel{{Name: "to-branch", State: "up"}},
+	}})
+	m = updated.(Model)
+	if banner := m.alertBanner(); !strings.Contains(banner, "4 new alert event(s): Resolved:") {
+		t.Errorf("after recovery the banner should count unseen events, got %q", banner)
+	}
+	if m.alertHistory[0].State != models.AlertResolved {
+		t.Errorf("history should be newest first: %+v", m.alertHistory[0])
+	}
+
+	// Opening the view marks everything seen.
+	updated, _ = m.Update(SwitchViewMsg{ViewAlerts})
+	m = updated.(Model)
+	if banner := m.alertBanner(); banner != "" {
+		t.Errorf("banner after opening Alerts = %q", banner)
+	}
+	if view := m.alertsView.SetSize(120, 40).View(); !strings.Contains(view, "to-branch") {
+		t.Errorf("history should list the tunnel events:\n%s", view)
+	}
+}
+
+func TestAlerts_TickSkipsWhileChecking(t *testing.T) {
+	m := newAlertingModel(t)
+	m.alertChecking = true
+	updated, cmd := m.Update(AlertTickMsg{})
+	if cmd == nil {
+		t.Fatal("a tick should always schedule the next")
+	}
+	if !updated.(Model).alertChecking {
+		t.Error("a tick during a check should leave it running")
+	}
+}
@@ -11,13 +11,20 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 
+	"github.com/jp2195/pyre/internal/alerts"
 	"github.com/jp2195/pyre/internal/auth"
+	"github.com/jp2195/pyre/internal/backup"
 	"github.com/jp2195/pyre/internal/config"
+	"github.com/jp2195/pyre/internal/history"
+	"github.com/jp2195/pyre/internal/models"
 	"github.com/jp2195/pyre/internal/tui/views"
 )
 
 const errorDismissTimeout = 5 * time.Second
 
+// jobPollInterval is how often a running validate or commit job is polled.
+const jobPollInterval = 2 * time.Second
+
 type ViewState int
 
 const (
@@ -34,6 +41,16 @@ const (
 	ViewGPUsers
 	ViewLogs
 	ViewObjects
+	ViewConfigDiff
+	ViewBackups
+	ViewConfigTree
+	ViewConsole
+	ViewAPICalls
+	ViewAlerts
+	ViewAudit
+	ViewAppID
+	ViewWhereUsed
+	ViewCompare
 	ViewPicker
 	ViewDevicePicker
 	ViewCommandPalette
@@ -77,14 +94,62 @@ type Model struct {
 	gpUsers           views.GPUsersModel
 	logs              views.LogsModel
 	objects           views.ObjectsModel
+	configDiff        views.ConfigDiffModel
+	backups           views.BackupsModel
+	configTree        views.ConfigTreeModel
+	console           views.ConsoleModel
+	apiCalls          views.APICallsModel
+	alertsView        views.AlertsModel
+	auditView         views.AuditModel
+	appIDView         views.AppIDModel
+	whereUsed         views.WhereUsedModel
+	compare           views.CompareModel
 	picker            views.PickerModel
 	devicePicker      views.DevicePickerModel
 	commandPalette    views.CommandPaletteModel
 	previousView      ViewState // Track previous view for Esc to return
 
+	// backupStore holds saved running configs; nil (with backupErr set)
+	// when the backup directory cannot be determined.
+	backupStore     *backup.Store
+	backupRetention backup.Retention
+	backupErr       error
+
+	// alerts checks the active device against settings.alerts every
+	// alertInterval; nil when no rule is on. alertHistory holds the events
+	// raised this session, newest first, and alertsUnseen counts those
+	// raised since the Alerts view was last open.
+	alerts        *alerts.Engine
+	alertInterval time.Duration
+	alertHistory  []models.AlertEvent
+	alertsUnseen  int
+	alertChecking bool
+
+	// history records a sample of the active device every historyInterval
+	// for the dashboard's trend charts; nil unless settings.history is
+	// enabled. historySamplers keeps each device's last interface counters.
+	history         *history.Store
+	historyInterval time.Duration
+	historySamplers map[string]*history.Sampler
+	historySampling bool
+
 	// selectedConnection stores the connection selected from hub before login
 	selectedConnection       string
 	selectedConnectionConfig config.ConnectionConfig
+
+	// reauthRequests delivers connections whose API key a device rejected
+	// (see auth.Session.EnableReauth). reauthQueue holds those waiting for
+	// the user to log in again, the login form showing the first;
+	// reauthReturn is the view to go back to once the queue is empty.
+	reauthRequests <-chan *auth.Connection
+	reauthQueue    []*auth.Connection
+	reauthReturn   ViewState
+
+	// stale marks the cached datasets on screen past their TTL while they
+	// refresh (see cache.go). bypassCache is set on the copy of the model
+	// an explicit refresh builds its fetches from.
+	stale       map[string]staleData
+	bypassCache bool
 }
 
 func NewModel(cfg *config.Config, state *config.State, creds *auth.Credentials, startView ViewState) (Model, error) {
@@ -96,15 +161,16 @@ func NewModel(cfg *config.Config, state *config.State, creds *auth.Credentials,
 	ctx, cancel := context.WithCancel(context.Background())
 
 	m := Model{
-		ctx:         ctx,
-		cancel:      cancel,
-		config:      cfg,
-		state:       state,
-		session:     session,
-		keys:        DefaultKeyMap(),
-		help:        help.New(),
-		spinner:     s,
-		currentView: startView,
+		ctx:            ctx,
+		cancel:         cancel,
+		config:         cfg,
+		state:          state,
+		session:        session,
+		reauthRequests: session.EnableReauth(),
+		keys:           DefaultKeyMap(),
+		help:           help.New(),
+		spinner:        s,
+		currentView:    startView,
 	}
 
 	// If we have full credentials (API key + host), go straight to dashboard
@@ -145,6 +211,32 @@ func NewModel(cfg *config.Config, state *config.State, creds *auth.Credentials,
 	m.gpUsers = views.NewGPUsersModel()
 	m.logs = views.NewLogsModel()
 	m.objects = views.NewObjectsModel()
+	m.configDiff = views.NewConfigDiffModel()
+	m.backups = views.NewBackupsModel()
+	m.backupStore, m.backupRetention, m.backupErr = backup.FromSettings(cfg.Settings.Backup)
+	m.configTree = views.NewConfigTreeModel()
+	m.console = views.NewConsoleModel()
+	m.apiCalls = views.NewAPICallsModel()
+	m.alertsView = views.NewAlertsModel()
+	m.auditView = views.NewAuditModel()
+	m.appIDView = views.NewAppIDModel()
+	m.whereUsed = views.NewWhereUsedModel()
+	m.compare = views.NewCompareModel()
+	if rules, interval, _, err := alerts.FromSettings(cfg.Settings.Alerts); err != nil {
+		m.alertsView = m.alertsView.SetError(err)
+	} else if rules.Any() {
+		m.alerts = alerts.NewEngine(rules)
+		m.alertInterval = interval
+		m.alertsView = m.alertsView.SetInterval(interval)
+	}
+	if store, interval, err := history.FromSettings(cfg.Settings.History); err != nil {
+		m.dashboard = m.dashboard.SetTrendsEnabled(true).SetTrends(nil, time.Time{}, err)
+	} else if store != nil {
+		m.history = store
+		m.historyInterval = interval
+		m.historySamplers = map[string]*history.Sampler{}
+		m.dashboard = m.dashboard.SetTrendsEnabled(true)
+	}
 	m.picker = views.NewPickerModel(session)
 	m.devicePicker = views.NewDevicePickerModel()
 	m.commandPalette = views.NewCommandPaletteModel()
@@ -162,7 +254,7 @@ func (m Model) setError(err error) (Model, tea.Cmd) {
 }
 
 func (m Model) Init() tea.Cmd {
-	cmds := []tea.Cmd{m.spinner.Tick}
+	cmds := []tea.Cmd{m.spinner.Tick, m.waitForReauth(), m.scheduleAlertCheck(), m.checkAlerts(), m.scheduleHistorySample()}
 
 	if m.currentView == ViewDashboard {
 		cmds = append(cmds, m.fetchCurrentDashboardData())
@@ -289,6 +381,9 @@ func (m Model) handleKeyMsg(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
 	case key.Matches(msg, m.keys.Refresh):
 		return m.handleRefresh()
 
+	case key.Matches(msg, m.keys.WhereUsed):
+		return m.openWhereUsed(m.rowLookupAddress())
+
 	// Navigation group keys
 	case key.Matches(msg, m.keys.NavGroup1):
 		return m.handleNavGroupKey(0)
@@ -329,7 +424,7 @@ func (m Model) handleRefresh() (tea.Model, tea.Cmd) {
 			s.loading(&m, true)
 		}
 	}
-	return m, tea.Batch(m.refreshCurrentView(), m.spinner.Tick)
+	return m, tea.Batch(m.uncached().refreshCurrentView(), m.spinner.Tick)
 }
 
 // anyLoading reports whether any visible work is in flight — the condition
@@ -464,6 +559,36 @@ func (m Model) renderContent() string {
 
 	case ViewObjects:
 		content = m.objects.View()
+
+	case ViewConfigDiff:
+		content = m.configDiff.SetWritable(m.writeAllowed()).View()
+
+	case ViewBackups:
+		content = m.backups.View()
+
+	case ViewConfigTree:
+		content = m.configTree.View()
+
+	case ViewConsole:
+		content = m.console.SetWritable(m.writeAllowed()).View()
+
+	case ViewAPICalls:
+		content = m.apiCalls.View()
+
+	case ViewAlerts:
+		content = m.alertsView.View()
+
+	case ViewAudit:
+		content = m.auditView.View()
+
+	case ViewAppID:
+		content = m.appIDView.View()
+
+	case ViewWhereUsed:
+		content = m.whereUsed.View()
+
+	case ViewCompare:
+		content = m.compare.View()
 	}
 
 	if m.showHelp {
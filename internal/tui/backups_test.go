@@ -0,0 +1,100 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"charm.land/bubbles/v2/spinner"
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/jp2195/pyre/internal/backup"
+	"github.com/jp2195/pyre/internal/config"
+	"github.com/jp2195/pyre/internal/testutil"
+)
+
+// newMockConnectedModel returns a sized model connected to a mock firewall.
+func newMockConnectedModel(t *testing.T) Model {
+	t.Helper()
+	mock := testutil.NewMockPANOS()
+	t.Cleanup(mock.Close)
+
+	updated, _ := newTestModel(t, ViewDashboard).Update(tea.WindowSizeMsg{Width: 160, Height: 40})
+	m := updated.(Model)
+	if _, err := m.session.AddConnection(mock.Host(), &config.ConnectionConfig{Insecure: true}, "k"); err != nil {
+		t.Fatalf("AddConnection: %v", err)
+	}
+	return m
+}
+
+// newBackupsTestModel returns a model connected to a mock firewall, with
+// backups stored under a temp directory.
+func newBackupsTestModel(t *testing.T) Model {
+	t.Helper()
+	m := newMockConnectedModel(t)
+	m.backupStore = backup.NewStore(t.TempDir())
+	m.backupRetention = backup.Retention{Keep: 5}
+	return m
+}
+
+// runCmd runs cmd, including each command of a batch, and feeds the
+// resulting messages other than spinner ticks back into m.
+func runCmd(t *testing.T, m Model, cmd tea.Cmd) Model {
+	t.Helper()
+	if cmd == nil {
+		return m
+	}
+	switch msg := cmd().(type) {
+	case tea.BatchMsg:
+		for _, c := range msg {
+			m = runCmd(t, m, c)
+		}
+	case spinner.TickMsg, nil:
+	default:
+		updated, _ := m.Update(msg)
+		m = updated.(Model)
+	}
+	return m
+}
+
+func TestBackups_SaveThenList(t *testing.T) {
+	m := newBackupsTestModel(t)
+
+	updated, cmd := m.Update(SwitchViewMsg{View: ViewBackups})
+	m = updated.(Model)
+	if cmd == nil || !m.backups.IsLoading() {
+		t.Fatal("opening the view should list stored versions")
+	}
+	m = runCmd(t, m, cmd)
+	if !m.backups.HasData() || !strings.Contains(m.renderContent(), "No backups yet") {
+		t.Fatal("expected an empty history")
+	}
+
+	conn := m.session.GetActiveConnection()
+	updated, cmd = m.Update(m.takeBackup(conn)())
+	m = updated.(Model)
+	if cmd == nil {
+		t.Fatal("a saved backup should refresh the list")
+	}
+	m = runCmd(t, m, cmd)
+
+	versions, err := m.backupStore.List(m.backupDevice())
+	if err != nil || len(versions) != 1 {
+		t.Fatalf("stored versions = %v, %v; want 1", versions, err)
+	}
+	view := m.renderContent()
+	if !strings.Contains(view, "Saved "+versions[0].Name()) || !strings.Contains(view, "1 versions") {
+		t.Errorf("view after backup:\n%s", view)
+	}
+}
+
+func TestBackups_ListingForOtherConnectionIgnored(t *testing.T) {
+	m := newBackupsTestModel(t)
+	updated, _ := m.Update(SwitchViewMsg{View: ViewBackups})
+	m = updated.(Model)
+
+	updated, _ = m.Update(BackupsMsg{Device: "other.example.com", Versions: []backup.Version{{}}})
+	m = updated.(Model)
+	if m.backups.HasData() {
+		t.Error("a listing for a connection that is not active should be dropped")
+	}
+}
@@ -0,0 +1,210 @@
+package tui
+
+// cache.go – stale-while-revalidate for the slow, slow-changing datasets.
+//
+// The API client caches responses per connection (api.WithCache). A fetch
+// for one of the datasets below is answered from that cache while it is
+// younger than the dataset's TTL. Past the TTL the cached copy is rendered
+// at once, marked stale with its age in the footer, while a refresh runs
+// in the background. r and the auto-refresh always go to the device.
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/tui/views"
+)
+
+// cacheDataset names a cached dataset and how long its responses are
+// served without going back to the device.
+type cacheDataset struct {
+	name string
+	ttl  time.Duration
+}
+
+// Rulebases and objects only change with a commit, which drops the cache
+// anyway; routing and interface state moves faster.
+var (
+	datasetPolicies   = cacheDataset{"policies", 5 * time.Minute}
+	datasetNAT        = cacheDataset{"NAT rules", 5 * time.Minute}
+	datasetAddresses  = cacheDataset{"addresses", 5 * time.Minute}
+	datasetServices   = cacheDataset{"services", 5 * time.Minute}
+	datasetGroups     = cacheDataset{"object groups", 5 * time.Minute}
+	datasetRoutes     = cacheDataset{"routes", time.Minute}
+	datasetBGP        = cacheDataset{"BGP peers", time.Minute}
+	datasetOSPF       = cacheDataset{"OSPF neighbors", time.Minute}
+	datasetInterfaces = cacheDataset{"interfaces", 30 * time.Second}
+	datasetARP        = cacheDataset{"ARP table", 30 * time.Second}
+
+	viewCacheDatasets = map[ViewState][]cacheDataset{
+		ViewPolicies:    {datasetPolicies},
+		ViewNATPolicies: {datasetNAT},
+		ViewObjects:     {datasetAddresses, datasetServices, datasetGroups},
+		ViewRoutes:      {datasetRoutes, datasetBGP, datasetOSPF},
+		ViewInterfaces:  {datasetInterfaces, datasetARP},
+	}
+	dashboardCacheDatasets = map[views.DashboardType][]cacheDataset{
+		views.DashboardMain:     {datasetInterfaces},
+		views.DashboardNetwork:  {datasetInterfaces, datasetARP, datasetRoutes, datasetBGP, datasetOSPF},
+		views.DashboardSecurity: {datasetPolicies},
+		views.DashboardConfig:   {datasetPolicies},
+	}
+)
+
+// cachedFetchCmd is fetchCmd for a cached dataset. Within the TTL the
+// fetch is served from the client's cache; past it, the cached copy comes
+// back first as a StaleDataMsg whose Refresh fetches from the device. A
+// ttl of 0 skips the cache and refreshes it.
+func cachedFetchCmd[T any](ctx context.Context, ds cacheDataset, ttl time.Duration, fn func(context.Context) (T, error), wrap func(T, error) tea.Msg) tea.Cmd {
+	fresh := fetchCmd(api.WithCache(ctx, ttl), fn, wrap)
+	if ttl <= 0 {
+		return fresh
+	}
+	return func() tea.Msg {
+		var info api.CacheInfo
+		result, err := fn(api.FromCache(ctx, &info))
+		if err != nil || time.Since(info.FetchedAt()) < ttl {
+			return fresh()
+		}
+		return StaleDataMsg{
+			Dataset:   ds.name,
+			Msg:       wrap(result, nil),
+			FetchedAt: info.FetchedAt(),
+			Refresh: func() tea.Msg {
+				result, err := fn(api.WithCache(ctx, 0))
+				if err != nil {
+					return StaleRefreshFailedMsg{Dataset: ds.name, Err: err}
+				}
+				return wrap(result, nil)
+			},
+		}
+	}
+}
+
+// cacheTTL is how long ds may be served from the cache by fetches built
+// from m: 0 once bypassCache has been called.
+func (m Model) cacheTTL(ds cacheDataset) time.Duration {
+	if m.bypassCache {
+		return 0
+	}
+	return ds.ttl
+}
+
+// uncached returns a copy of m whose fetches go to the device, for an
+// explicit or automatic refresh.
+func (m Model) uncached() Model {
+	m.bypassCache = true
+	return m
+}
+
+// staleData is a dataset on screen from the cache past its TTL.
+type staleData struct {
+	fetchedAt time.Time
+	failed    bool // the refresh failed
+}
+
+// handleStaleData renders a stale cached dataset and starts its refresh.
+func (m Model) handleStaleData(msg StaleDataMsg) (tea.Model, tea.Cmd) {
+	updated, cmd := m.handleDataMsg(msg.Msg)
+	m = updated.(Model)
+	m.stale = maps.Clone(m.stale)
+	if m.stale == nil {
+		m.stale = make(map[string]staleData)
+	}
+	m.stale[msg.Dataset] = staleData{fetchedAt: msg.FetchedAt}
+	return m, tea.Batch(cmd, msg.Refresh)
+}
+
+// handleStaleRefreshFailed keeps the cached data up and reports the error.
+func (m Model) handleStaleRefreshFailed(msg StaleRefreshFailedMsg) (tea.Model, tea.Cmd) {
+	if s, ok := m.stale[msg.Dataset]; ok {
+		m.stale = maps.Clone(m.stale)
+		s.failed = true
+		m.stale[msg.Dataset] = s
+	}
+	return m.setError(fmt.Errorf("refreshing %s: %w", msg.Dataset, msg.Err))
+}
+
+// clearStale unmarks the dataset msg delivers, if it is a cached one: the
+// data on screen now came from the device, or failed to.
+func (m Model) clearStale(msg tea.Msg) Model {
+	if len(m.stale) == 0 {
+		return m
+	}
+	var ds cacheDataset
+	switch msg.(type) {
+	case PoliciesMsg:
+		ds = datasetPolicies
+	case NATPoliciesMsg:
+		ds = datasetNAT
+	case AddressesMsg:
+		ds = datasetAddresses
+	case ServicesMsg:
+		ds = datasetServices
+	case ObjectGroupsMsg:
+		ds = datasetGroups
+	case RoutingTableMsg:
+		ds = datasetRoutes
+	case BGPNeighborsMsg:
+		ds = datasetBGP
+	case OSPFNeighborsMsg:
+		ds = datasetOSPF
+	case InterfacesMsg:
+		ds = datasetInterfaces
+	case ARPTableMsg:
+		ds = datasetARP
+	default:
+		return m
+	}
+	if _, ok := m.stale[ds.name]; ok {
+		m.stale = maps.Clone(m.stale)
+		delete(m.stale, ds.name)
+	}
+	return m
+}
+
+// resetStale forgets every stale mark, when the data they describe is
+// about to be replaced wholesale.
+func (m Model) resetStale() Model {
+	m.stale = nil
+	return m
+}
+
+// staleNotice is the footer line for stale cached data in the current
+// view, or "" if it shows none.
+func (m Model) staleNotice() string {
+	datasets := viewCacheDatasets[m.currentView]
+	if m.currentView == ViewDashboard {
+		datasets = dashboardCacheDatasets[m.currentDashboard]
+	}
+
+	var names []string
+	var oldest time.Time
+	failed := false
+	for _, ds := range datasets {
+		s, ok := m.stale[ds.name]
+		if !ok {
+			continue
+		}
+		names = append(names, ds.name)
+		if oldest.IsZero() || s.fetchedAt.Before(oldest) {
+			oldest = s.fetchedAt
+		}
+		failed = failed || s.failed
+	}
+	if len(names) == 0 {
+		return ""
+	}
+
+	notice := fmt.Sprintf("Showing cached %s, fetched %s", strings.Join(names, ", "), views.FormatTimeAgo(oldest))
+	if failed {
+		return notice + " · refresh failed, r to retry"
+	}
+	return notice + " · refreshing…"
+}
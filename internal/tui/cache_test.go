@@ -0,0 +1,104 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jp2195/pyre/internal/auth"
+	"github.com/jp2195/pyre/internal/config"
+	"github.com/jp2195/pyre/internal/testutil"
+)
+
+// newCachedModel returns a model on the objects view, connected to mock,
+// with the address dataset's TTL shrunk so anything cached is stale.
+func newCachedModel(t *testing.T, mock *testutil.MockPANOS) (Model, *auth.Connection) {
+	t.Helper()
+	saved := datasetAddresses
+	datasetAddresses.ttl = time.Nanosecond
+	t.Cleanup(func() { datasetAddresses = saved })
+
+	m := newTestModel(t, ViewObjects)
+	conn, err := m.session.AddConnection(mock.Host(), &config.ConnectionConfig{Insecure: true}, "k")
+	if err != nil {
+		t.Fatalf("AddConnection: %v", err)
+	}
+	return m, conn
+}
+
+func TestCachedFetch_StaleThenRefresh(t *testing.T) {
+	mock := testutil.NewMockPANOS()
+	defer mock.Close()
+	m, conn := newCachedModel(t, mock)
+
+	// Nothing cached: the first fetch goes to the device.
+	msg, ok := m.fetchAddresses(conn)().(AddressesMsg)
+	if !ok || msg.Err != nil || len(msg.Items) == 0 {
+		t.Fatalf("first fetch = %#v, want addresses from the device", msg)
+	}
+	updated, _ := m.Update(msg)
+	m = updated.(Model)
+
+	// Past the TTL: the cached copy comes back first, marked stale.
+	stale, ok := m.fetchAddresses(conn)().(StaleDataMsg)
+	if !ok {
+		t.Fatal("a fetch past the TTL should render the cached copy first")
+	}
+	updated, refresh := m.Update(stale)
+	m = updated.(Model)
+	if refresh == nil {
+		t.Fatal("rendering stale data should start a refresh")
+	}
+	if notice := m.renderFooter(); !strings.Contains(notice, "Showing cached addresses") || !strings.Contains(notice, "refreshing") {
+		t.Errorf("footer should mark the data stale:\n%s", notice)
+	}
+
+	// The refresh brings current data and clears the mark.
+	m = runCmd(t, m, refresh)
+	if strings.Contains(m.renderFooter(), "cached") {
+		t.Errorf("stale mark should clear once the refresh lands:\n%s", m.renderFooter())
+	}
+}
+
+func TestCachedFetch_RefreshFailureKeepsCachedData(t *testing.T) {
+	mock := testutil.NewMockPANOS()
+	m, conn := newCachedModel(t, mock)
+	updated, _ := m.Update(m.fetchAddresses(conn)())
+	m = updated.(Model)
+
+	stale, ok := m.fetchAddresses(conn)().(StaleDataMsg)
+	if !ok {
+		t.Fatal("expected the cached copy")
+	}
+	mock.Close()
+	updated, refresh := m.Update(stale)
+	m = runCmd(t, updated.(Model), refresh)
+
+	footer := m.renderFooter()
+	if !strings.Contains(footer, "refresh failed") || m.err == nil {
+		t.Errorf("a failed refresh should be reported:\n%s", footer)
+	}
+	if !m.objects.HasData() {
+		t.Error("a failed refresh should leave the cached data on screen")
+	}
+}
+
+func TestCachedFetch_RefreshAndTargetSwitchBypassCache(t *testing.T) {
+	mock := testutil.NewMockPANOS()
+	defer mock.Close()
+	m, conn := newCachedModel(t, mock)
+	m.fetchAddresses(conn)()
+
+	// r goes to the device even with a cached copy.
+	if _, ok := m.uncached().fetchAddresses(conn)().(AddressesMsg); !ok {
+		t.Error("an explicit refresh should skip the cached copy")
+	}
+
+	// So does anything after a device switch.
+	if err := conn.SetTarget(nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.fetchAddresses(conn)().(AddressesMsg); !ok {
+		t.Error("switching target should drop the cache")
+	}
+}
@@ -1,14 +1,21 @@
 package tui
 
 import (
+	"cmp"
 	"context"
+	"time"
 
 	tea "charm.land/bubbletea/v2"
 
 	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/appid"
+	"github.com/jp2195/pyre/internal/audit"
 	"github.com/jp2195/pyre/internal/auth"
+	"github.com/jp2195/pyre/internal/cfgexport"
 	"github.com/jp2195/pyre/internal/config"
+	"github.com/jp2195/pyre/internal/devicefile"
 	"github.com/jp2195/pyre/internal/models"
+	"github.com/jp2195/pyre/internal/pancfg"
 	"github.com/jp2195/pyre/internal/tui/views"
 )
 
@@ -28,14 +35,19 @@ func (m Model) doLogin() tea.Cmd {
 	insecure := m.login.Insecure()
 
 	// If this host already has a saved connection config, honor its CA
-	// bundle so interactive login can verify a self-signed firewall cert
-	// without resorting to --insecure.
-	opts := api.ClientOptions{Insecure: insecure}
-	if conn, ok := m.config.GetConnection(host); ok {
-		opts.CACertPath = conn.CACertPath
+	// bundle, client certificate, and pin so interactive login can verify
+	// a self-signed firewall cert without resorting to --insecure.
+	conn, _ := m.config.GetConnection(host)
+	if host == m.selectedConnection {
+		conn = m.selectedConnectionConfig
 	}
+	conn.Insecure = insecure
 
 	return func() tea.Msg {
+		opts, err := auth.ClientOptions(&conn)
+		if err != nil {
+			return LoginErrorMsg{Err: err}
+		}
 		result, err := auth.GenerateAPIKey(ctx, host, username, password, opts)
 		if err != nil {
 			return LoginErrorMsg{Err: err}
@@ -54,6 +66,73 @@ func (m Model) doLogin() tea.Cmd {
 	}
 }
 
+// retryLogin repeats the login the trust prompt interrupted: the saved
+// connection's api_key_command if it has one, otherwise the password on
+// the form.
+func (m Model) retryLogin() tea.Cmd {
+	if m.selectedConnection != "" && m.selectedConnectionConfig.APIKeyCommand != "" && len(m.reauthQueue) == 0 {
+		return m.keyCommandLogin(m.selectedConnection, m.selectedConnectionConfig)
+	}
+	return m.doLogin()
+}
+
+// trustHostKey records host's certificate fingerprint in known_hosts, then
+// runs login.
+func (m Model) trustHostKey(host, fingerprint string, login tea.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		path, err := config.KnownHostsPath()
+		if err != nil {
+			return LoginErrorMsg{Err: err}
+		}
+		known, err := api.LoadKnownHosts(path)
+		if err != nil {
+			return LoginErrorMsg{Err: err}
+		}
+		if err := known.Trust(host, fingerprint); err != nil {
+			return LoginErrorMsg{Err: err}
+		}
+		return login()
+	}
+}
+
+// waitForReauth delivers the next connection whose API key was rejected.
+// It is re-armed each time it fires.
+func (m Model) waitForReauth() tea.Cmd {
+	requests := m.reauthRequests
+	return func() tea.Msg {
+		return ReauthNeededMsg{Conn: <-requests}
+	}
+}
+
+// keyCommandLogin completes a login with the API key host's
+// api_key_command prints, where doLogin would generate one from a password.
+func (m Model) keyCommandLogin(host string, conn config.ConnectionConfig) tea.Cmd {
+	ctx := m.ctx
+	return func() tea.Msg {
+		// Check a pinned certificate before running the helper: the key
+		// it prints is only worth fetching for a device we'll talk to.
+		opts, err := auth.ClientOptions(&conn)
+		if err != nil {
+			return LoginErrorMsg{Err: err}
+		}
+		if opts.KnownHosts != nil {
+			if err := api.CheckHostKey(ctx, host, opts); err != nil {
+				return LoginErrorMsg{Err: err}
+			}
+		}
+		key, err := auth.CommandAPIKey(ctx, host, conn.APIKeyCommand)
+		if err != nil {
+			return LoginErrorMsg{Err: err}
+		}
+		return LoginSuccessMsg{
+			Host:     host,
+			APIKey:   key,
+			Username: conn.Username,
+			Insecure: conn.Insecure,
+		}
+	}
+}
+
 func (m Model) fetchCurrentDashboardData() tea.Cmd {
 	switch m.currentDashboard {
 	case views.DashboardNetwork:
@@ -90,6 +169,7 @@ func (m Model) fetchDashboardData() tea.Cmd {
 		m.fetchEnvironmentals(conn),
 		m.fetchCertificates(conn),
 		m.fetchNATPoolInfo(conn),
+		m.loadTrends(),
 	)
 }
 
@@ -218,7 +298,7 @@ func (m Model) fetchInterfaces() tea.Cmd {
 		return nil
 	}
 	target := conn.Target()
-	return fetchCmd(m.ctx, func(ctx context.Context) ([]models.Interface, error) {
+	return cachedFetchCmd(m.ctx, datasetInterfaces, m.cacheTTL(datasetInterfaces), func(ctx context.Context) ([]models.Interface, error) {
 		return conn.Client.GetInterfaces(ctx, target)
 	}, func(ifaces []models.Interface, err error) tea.Msg {
 		return InterfacesMsg{Interfaces: ifaces, Err: err}
@@ -300,7 +380,7 @@ func (m Model) fetchCertificates(conn *auth.Connection) tea.Cmd {
 
 func (m Model) fetchARPTable(conn *auth.Connection) tea.Cmd {
 	target := conn.Target()
-	return fetchCmd(m.ctx, func(ctx context.Context) ([]models.ARPEntry, error) {
+	return cachedFetchCmd(m.ctx, datasetARP, m.cacheTTL(datasetARP), func(ctx context.Context) ([]models.ARPEntry, error) {
 		return conn.Client.GetARPTable(ctx, target)
 	}, func(entries []models.ARPEntry, err error) tea.Msg {
 		return ARPTableMsg{Entries: entries, Err: err}
@@ -309,7 +389,7 @@ func (m Model) fetchARPTable(conn *auth.Connection) tea.Cmd {
 
 func (m Model) fetchRoutingTable(conn *auth.Connection) tea.Cmd {
 	target := conn.Target()
-	return fetchCmd(m.ctx, func(ctx context.Context) ([]models.RouteEntry, error) {
+	return cachedFetchCmd(m.ctx, datasetRoutes, m.cacheTTL(datasetRoutes), func(ctx context.Context) ([]models.RouteEntry, error) {
 		return conn.Client.GetRoutingTable(ctx, target)
 	}, func(routes []models.RouteEntry, err error) tea.Msg {
 		return RoutingTableMsg{Routes: routes, Err: err}
@@ -318,7 +398,7 @@ func (m Model) fetchRoutingTable(conn *auth.Connection) tea.Cmd {
 
 func (m Model) fetchBGPNeighbors(conn *auth.Connection) tea.Cmd {
 	target := conn.Target()
-	return fetchCmd(m.ctx, func(ctx context.Context) ([]models.BGPNeighbor, error) {
+	return cachedFetchCmd(m.ctx, datasetBGP, m.cacheTTL(datasetBGP), func(ctx context.Context) ([]models.BGPNeighbor, error) {
 		return conn.Client.GetBGPNeighbors(ctx, target)
 	}, func(n []models.BGPNeighbor, err error) tea.Msg {
 		return BGPNeighborsMsg{Neighbors: n, Err: err}
@@ -327,7 +407,7 @@ func (m Model) fetchBGPNeighbors(conn *auth.Connection) tea.Cmd {
 
 func (m Model) fetchOSPFNeighbors(conn *auth.Connection) tea.Cmd {
 	target := conn.Target()
-	return fetchCmd(m.ctx, func(ctx context.Context) ([]models.OSPFNeighbor, error) {
+	return cachedFetchCmd(m.ctx, datasetOSPF, m.cacheTTL(datasetOSPF), func(ctx context.Context) ([]models.OSPFNeighbor, error) {
 		return conn.Client.GetOSPFNeighbors(ctx, target)
 	}, func(n []models.OSPFNeighbor, err error) tea.Msg {
 		return OSPFNeighborsMsg{Neighbors: n, Err: err}
@@ -361,9 +441,216 @@ func (m Model) fetchPendingChanges(conn *auth.Connection) tea.Cmd {
 	})
 }
 
+// startCommitJob enqueues the validate or commit job req asks for.
+func (m Model) startCommitJob(conn *auth.Connection, req views.CommitRequestMsg) tea.Cmd {
+	target := conn.Target()
+	ctx := m.ctx
+	return func() tea.Msg {
+		var id int
+		var err error
+		if req.Commit {
+			id, err = conn.Client.StartCommit(ctx, "", req.Admins, target)
+		} else {
+			id, err = conn.Client.StartValidate(ctx, req.Admins, target)
+		}
+		return CommitJobMsg{Host: conn.Host, Target: target, Commit: req.Commit, Job: models.Job{ID: id}, Err: err}
+	}
+}
+
+// pollCommitJob fetches the job's status after jobPollInterval.
+func (m Model) pollCommitJob(conn *auth.Connection, msg CommitJobMsg) tea.Cmd {
+	ctx := m.ctx
+	return tea.Tick(jobPollInterval, func(time.Time) tea.Msg {
+		job, err := conn.Client.GetJob(ctx, msg.Job.ID, msg.Target)
+		return CommitJobMsg{Host: msg.Host, Target: msg.Target, Commit: msg.Commit, Job: job, Err: err}
+	})
+}
+
+// backupDevice names the backup history of the active connection (and
+// Panorama target); "" when there is no connection.
+func (m Model) backupDevice() string {
+	conn := m.session.GetActiveConnection()
+	if conn == nil {
+		return ""
+	}
+	return devicefile.Device(conn.Host, conn.Target())
+}
+
+// fetchBackups lists the stored versions of the active connection.
+func (m Model) fetchBackups() tea.Cmd {
+	device := m.backupDevice()
+	if device == "" {
+		return nil
+	}
+	store, storeErr := m.backupStore, m.backupErr
+	return func() tea.Msg {
+		if storeErr != nil {
+			return BackupsMsg{Device: device, Err: storeErr}
+		}
+		versions, err := store.List(device)
+		return BackupsMsg{Device: device, Versions: versions, Err: err}
+	}
+}
+
+// takeBackup saves the active connection's running config and applies the
+// retention policy.
+func (m Model) takeBackup(conn *auth.Connection) tea.Cmd {
+	target := conn.Target()
+	device := devicefile.Device(conn.Host, target)
+	store, retention, storeErr := m.backupStore, m.backupRetention, m.backupErr
+	return fetchCmd(m.ctx, func(ctx context.Context) (BackupSavedMsg, error) {
+		msg := BackupSavedMsg{Device: device}
+		if storeErr != nil {
+			return msg, storeErr
+		}
+		doc, err := conn.Client.GetRunningConfig(ctx, target)
+		if err != nil {
+			return msg, err
+		}
+		if msg.Version, err = store.Save(device, doc, time.Now()); err != nil {
+			return msg, err
+		}
+		removed, err := store.Prune(device, retention, time.Now())
+		msg.Pruned = len(removed)
+		return msg, err
+	}, func(msg BackupSavedMsg, err error) tea.Msg {
+		msg.Err = err
+		return msg
+	})
+}
+
+// diffBackups compares two stored versions off the event loop; large
+// configs take a moment to parse.
+func (m Model) diffBackups(req views.BackupDiffRequestMsg) tea.Cmd {
+	store := m.backupStore
+	return func() tea.Msg {
+		hunks, err := store.Diff(req.Older, req.Newer)
+		return BackupDiffMsg{Older: req.Older, Newer: req.Newer, Hunks: hunks, Err: err}
+	}
+}
+
+// fetchConfigTree fetches each branch the config tree asked for. A request
+// from a tree for another device than the active connection is stale.
+func (m Model) fetchConfigTree(req views.ConfigTreeRequestMsg) tea.Cmd {
+	conn := m.session.GetActiveConnection()
+	if conn == nil || req.Device != devicefile.Device(conn.Host, conn.Target()) {
+		return nil
+	}
+	target := conn.Target()
+	cmds := make([]tea.Cmd, 0, len(req.XPaths))
+	for _, xpath := range req.XPaths {
+		cmds = append(cmds, fetchCmd(m.ctx, func(ctx context.Context) (*pancfg.Node, error) {
+			return conn.Client.GetConfigElement(ctx, xpath, req.Candidate, target)
+		}, func(elem *pancfg.Node, err error) tea.Msg {
+			return ConfigTreeMsg{Device: req.Device, Candidate: req.Candidate, XPath: xpath, Element: elem, Err: err}
+		}))
+	}
+	return tea.Batch(cmds...)
+}
+
+// runConsoleCommand runs one command typed into the console.
+func (m Model) runConsoleCommand(conn *auth.Connection, msg views.ConsoleRunMsg) tea.Cmd {
+	target := conn.Target()
+	return fetchCmd(m.ctx, func(ctx context.Context) (string, error) {
+		return conn.Client.RunOpCommand(ctx, msg.Command, target)
+	}, func(result string, err error) tea.Msg {
+		return ConsoleResultMsg{Device: msg.Device, Seq: msg.Seq, Result: result, Err: err}
+	})
+}
+
+// exportConfig writes the items asked for, with the objects they depend
+// on, from the running config to ~/.pyre/exports.
+func (m Model) exportConfig(conn *auth.Connection, view ViewState, req views.ConfigExportRequestMsg) tea.Cmd {
+	target := conn.Target()
+	device := devicefile.Device(conn.Host, target)
+	return fetchCmd(m.ctx, func(ctx context.Context) (string, error) {
+		bundle, err := cfgexport.Collect(ctx, conn.Client, target, req.Items)
+		if err != nil {
+			return "", err
+		}
+		dir, err := cfgexport.DefaultDir()
+		if err != nil {
+			return "", err
+		}
+		return cfgexport.Export(dir, device, bundle, req.Format, time.Now())
+	}, func(path string, err error) tea.Msg {
+		return ConfigExportedMsg{View: view, Path: path, Err: err}
+	})
+}
+
+// fetchAPICalls snapshots the active connection's recent API calls.
+func (m Model) fetchAPICalls() tea.Cmd {
+	conn := m.session.GetActiveConnection()
+	if conn == nil {
+		return func() tea.Msg { return APICallsMsg{} }
+	}
+	return func() tea.Msg {
+		return APICallsMsg{Host: conn.Host, Calls: conn.Client.Calls()}
+	}
+}
+
+// fetchAudit audits the active connection against best practices.
+func (m Model) fetchAudit() tea.Cmd {
+	conn := m.session.GetActiveConnection()
+	if conn == nil {
+		return nil
+	}
+	target := conn.Target()
+	device := devicefile.Device(conn.Host, target)
+	opts := audit.Options{ExpiryDays: cmp.Or(m.config.Settings.Alerts.CertDays, config.DefaultAlertSettings.CertDays)}
+	return fetchCmd(m.ctx, func(ctx context.Context) (audit.Input, error) {
+		return audit.Collect(ctx, conn.Client, target)
+	}, func(in audit.Input, err error) tea.Msg {
+		return AuditMsg{Device: device, Result: audit.Run(in, opts), Err: err}
+	})
+}
+
+// exportAudit writes the audit shown to ~/.pyre/audits.
+func (m Model) exportAudit() tea.Cmd {
+	device, res := m.auditView.Device(), m.auditView.Result()
+	return func() tea.Msg {
+		dir, err := audit.DefaultDir()
+		if err != nil {
+			return AuditExportedMsg{Err: err}
+		}
+		path, err := audit.Export(dir, device, res, time.Now())
+		return AuditExportedMsg{Path: path, Err: err}
+	}
+}
+
+// fetchAppID looks up the applications seen on the active connection's
+// port-based rules.
+func (m Model) fetchAppID() tea.Cmd {
+	conn := m.session.GetActiveConnection()
+	if conn == nil {
+		return nil
+	}
+	target := conn.Target()
+	device := devicefile.Device(conn.Host, target)
+	days := appid.DefaultDays
+	return fetchCmd(m.ctx, func(ctx context.Context) ([]appid.Suggestion, error) {
+		return appid.Collect(ctx, conn.Client, target, appid.Options{Days: days})
+	}, func(suggestions []appid.Suggestion, err error) tea.Msg {
+		return AppIDMsg{Device: device, Suggestions: suggestions, Days: days, Err: err}
+	})
+}
+
+// exportAppID writes the App-ID suggestions shown to ~/.pyre/appid.
+func (m Model) exportAppID() tea.Cmd {
+	device, suggestions, days := m.appIDView.Device(), m.appIDView.Suggestions(), m.appIDView.Days()
+	return func() tea.Msg {
+		dir, err := appid.DefaultDir()
+		if err != nil {
+			return AppIDExportedMsg{Err: err}
+		}
+		path, err := appid.Export(dir, device, suggestions, days, time.Now())
+		return AppIDExportedMsg{Path: path, Err: err}
+	}
+}
+
 func (m Model) fetchAddresses(conn *auth.Connection) tea.Cmd {
 	target := conn.Target()
-	return fetchCmd(m.ctx, func(ctx context.Context) ([]models.AddressObject, error) {
+	return cachedFetchCmd(m.ctx, datasetAddresses, m.cacheTTL(datasetAddresses), func(ctx context.Context) ([]models.AddressObject, error) {
 		return conn.Client.GetAddresses(ctx, target)
 	}, func(items []models.AddressObject, err error) tea.Msg {
 		return AddressesMsg{Items: items, Err: err}
@@ -372,19 +659,65 @@ func (m Model) fetchAddresses(conn *auth.Connection) tea.Cmd {
 
 func (m Model) fetchServices(conn *auth.Connection) tea.Cmd {
 	target := conn.Target()
-	return fetchCmd(m.ctx, func(ctx context.Context) ([]models.ServiceObject, error) {
+	return cachedFetchCmd(m.ctx, datasetServices, m.cacheTTL(datasetServices), func(ctx context.Context) ([]models.ServiceObject, error) {
 		return conn.Client.GetServices(ctx, target)
 	}, func(items []models.ServiceObject, err error) tea.Msg {
 		return ServicesMsg{Items: items, Err: err}
 	})
 }
 
+// fetchObjectGroups reads the groups, EDLs and regions rule members can
+// name.
+func (m Model) fetchObjectGroups(conn *auth.Connection) tea.Cmd {
+	target := conn.Target()
+	return cachedFetchCmd(m.ctx, datasetGroups, m.cacheTTL(datasetGroups), func(ctx context.Context) (views.ObjectGroups, error) {
+		var g views.ObjectGroups
+		var err error
+		if g.AddressGroups, err = conn.Client.GetAddressGroups(ctx, target); err != nil {
+			return g, err
+		}
+		if g.ServiceGroups, err = conn.Client.GetServiceGroups(ctx, target); err != nil {
+			return g, err
+		}
+		if g.ExternalLists, err = conn.Client.GetExternalLists(ctx, target); err != nil {
+			return g, err
+		}
+		g.Regions, err = conn.Client.GetRegions(ctx, target)
+		return g, err
+	}, func(g views.ObjectGroups, err error) tea.Msg {
+		return ObjectGroupsMsg{Groups: g, Err: err}
+	})
+}
+
 func (m Model) fetchObjects() tea.Cmd {
 	conn := m.session.GetActiveConnection()
 	if conn == nil {
 		return nil
 	}
-	return tea.Batch(m.fetchAddresses(conn), m.fetchServices(conn))
+	return tea.Batch(m.fetchAddresses(conn), m.fetchServices(conn), m.fetchObjectGroups(conn))
+}
+
+// fetchRuleObjects fetches the objects the Policies and NAT views resolve
+// rule members against, unless they are loaded already.
+func (m Model) fetchRuleObjects() tea.Cmd {
+	if m.objects.HasData() {
+		return nil
+	}
+	return m.fetchObjects()
+}
+
+func (m Model) fetchConfigDiff() tea.Cmd {
+	conn := m.session.GetActiveConnection()
+	if conn == nil {
+		return nil
+	}
+
+	target := conn.Target()
+	return fetchCmd(m.ctx, func(ctx context.Context) ([]models.ConfigHunk, error) {
+		return conn.Client.GetConfigDiff(ctx, target)
+	}, func(h []models.ConfigHunk, err error) tea.Msg {
+		return ConfigDiffMsg{Hunks: h, Err: err}
+	})
 }
 
 func (m Model) fetchNATPoolInfo(conn *auth.Connection) tea.Cmd {
@@ -403,7 +736,7 @@ func (m Model) fetchPolicies() tea.Cmd {
 	}
 
 	target := conn.Target()
-	return fetchCmd(m.ctx, func(ctx context.Context) ([]models.SecurityRule, error) {
+	return cachedFetchCmd(m.ctx, datasetPolicies, m.cacheTTL(datasetPolicies), func(ctx context.Context) ([]models.SecurityRule, error) {
 		return conn.Client.GetSecurityPolicies(ctx, target)
 	}, func(policies []models.SecurityRule, err error) tea.Msg {
 		return PoliciesMsg{Policies: policies, Err: err}
@@ -417,7 +750,7 @@ func (m Model) fetchNATPolicies() tea.Cmd {
 	}
 
 	target := conn.Target()
-	return fetchCmd(m.ctx, func(ctx context.Context) ([]models.NATRule, error) {
+	return cachedFetchCmd(m.ctx, datasetNAT, m.cacheTTL(datasetNAT), func(ctx context.Context) ([]models.NATRule, error) {
 		return conn.Client.GetNATRules(ctx, target)
 	}, func(rules []models.NATRule, err error) tea.Msg {
 		return NATPoliciesMsg{Rules: rules, Err: err}
@@ -497,9 +830,9 @@ func (m Model) refreshCurrentView() tea.Cmd {
 	case ViewDashboard:
 		return m.fetchCurrentDashboardData()
 	case ViewPolicies:
-		return m.fetchPolicies()
+		return tea.Batch(m.fetchPolicies(), m.fetchObjects())
 	case ViewNATPolicies:
-		return m.fetchNATPolicies()
+		return tea.Batch(m.fetchNATPolicies(), m.fetchObjects())
 	case ViewSessions:
 		return m.fetchSessions()
 	case ViewInterfaces:
@@ -524,6 +857,31 @@ func (m Model) refreshCurrentView() tea.Cmd {
 		return m.fetchLogs()
 	case ViewObjects:
 		return m.fetchObjects()
+	case ViewConfigDiff:
+		return m.fetchConfigDiff()
+	case ViewBackups:
+		return m.fetchBackups()
+	case ViewAPICalls:
+		return m.fetchAPICalls()
+	case ViewAlerts:
+		return m.checkAlerts()
+	case ViewAudit:
+		return m.fetchAudit()
+	case ViewAppID:
+		return m.fetchAppID()
+	case ViewWhereUsed:
+		return tea.Batch(m.fetchPolicies(), m.fetchNATPolicies(), m.fetchObjects())
+	case ViewCompare:
+		if req, ok := m.compare.Comparison(); ok {
+			return m.runCompare(req)
+		}
+		return m.fetchCompareSources()
+	case ViewConfigTree:
+		return m.fetchConfigTree(views.ConfigTreeRequestMsg{
+			Device:    m.configTree.Device(),
+			Candidate: m.configTree.Candidate(),
+			XPaths:    m.configTree.Pending(),
+		})
 	}
 	return nil
 }
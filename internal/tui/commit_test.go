@@ -0,0 +1,89 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/jp2195/pyre/internal/config"
+	"github.com/jp2195/pyre/internal/models"
+	"github.com/jp2195/pyre/internal/tui/views"
+)
+
+// newCommitTestModel returns a model on the config diff view with one
+// connection (never dialled) and a validate job already requested.
+func newCommitTestModel(t *testing.T, allowWrite bool) Model {
+	t.Helper()
+	updated, _ := newTestModel(t, ViewConfigDiff).Update(tea.WindowSizeMsg{Width: 160, Height: 40})
+	m := updated.(Model)
+	m.currentView = ViewConfigDiff
+	if _, err := m.session.AddConnection("fw.test", &config.ConnectionConfig{AllowWrite: allowWrite}, "k"); err != nil {
+		t.Fatalf("AddConnection: %v", err)
+	}
+	// The view only asks for a job when it believes the connection is
+	// writable; force that so the app-level check is what's exercised.
+	m.configDiff, _ = m.configDiff.SetWritable(true).Update(tea.KeyPressMsg{Code: 'v', Text: "v"})
+	if !m.configDiff.JobRunning() {
+		t.Fatal("expected the view to request a validate job")
+	}
+	return m
+}
+
+func TestCommitRequest_ReadOnlyConnectionRefused(t *testing.T) {
+	m := newCommitTestModel(t, false)
+
+	updated, cmd := m.Update(views.CommitRequestMsg{})
+	m = updated.(Model)
+	if cmd != nil {
+		t.Error("a read-only connection must not start a job")
+	}
+	if m.configDiff.JobRunning() {
+		t.Error("job should be marked finished with an error")
+	}
+	if !strings.Contains(m.renderContent(), "read-only") {
+		t.Error("expected the read-only error in the view")
+	}
+}
+
+func TestCommitJob_PollsUntilFinished(t *testing.T) {
+	m := newCommitTestModel(t, true)
+
+	updated, cmd := m.Update(CommitJobMsg{Host: "fw.test", Job: models.Job{ID: 5, Status: "ACT", Progress: 40}})
+	m = updated.(Model)
+	if cmd == nil || !m.configDiff.JobRunning() {
+		t.Fatal("an active job should be polled again")
+	}
+
+	updated, cmd = m.Update(CommitJobMsg{Host: "fw.test", Job: models.Job{ID: 5, Status: "FIN", Result: "OK"}})
+	m = updated.(Model)
+	if cmd != nil {
+		t.Error("a finished validate should not poll or refresh")
+	}
+	if m.configDiff.JobRunning() {
+		t.Error("job should be finished")
+	}
+}
+
+func TestCommitJob_SuccessfulCommitRefreshesDiff(t *testing.T) {
+	m := newCommitTestModel(t, true)
+
+	updated, cmd := m.Update(CommitJobMsg{Host: "fw.test", Commit: true, Job: models.Job{ID: 6, Status: "FIN", Result: "OK"}})
+	m = updated.(Model)
+	if cmd == nil || !m.configDiff.IsLoading() {
+		t.Error("a successful commit should refetch the diff")
+	}
+}
+
+func TestCommitJob_StopsWhenConnectionChanges(t *testing.T) {
+	m := newCommitTestModel(t, true)
+
+	updated, cmd := m.Update(CommitJobMsg{Host: "other.test", Job: models.Job{ID: 7, Status: "ACT"}})
+	m = updated.(Model)
+	if cmd != nil {
+		t.Error("a job on another connection must not be polled through this one")
+	}
+	if !strings.Contains(m.renderContent(), "stopped tracking") {
+		t.Error("expected the view to say the job is no longer tracked")
+	}
+}
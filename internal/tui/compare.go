@@ -0,0 +1,110 @@
+package tui
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/auth"
+	"github.com/jp2195/pyre/internal/backup"
+	"github.com/jp2195/pyre/internal/devicefile"
+	"github.com/jp2195/pyre/internal/models"
+	"github.com/jp2195/pyre/internal/policydiff"
+	"github.com/jp2195/pyre/internal/tui/views"
+)
+
+// fetchCompareSources lists what the Compare view can compare: every
+// connected device, read live, and every saved backup of any device.
+func (m Model) fetchCompareSources() tea.Cmd {
+	var live []views.CompareSource
+	for _, conn := range m.session.ListConnections() {
+		if !conn.Connected {
+			continue
+		}
+		target := conn.Target()
+		live = append(live, views.CompareSource{Device: devicefile.Device(conn.Host, target), Host: conn.Host, Target: target})
+	}
+	slices.SortFunc(live, func(a, b views.CompareSource) int { return cmp.Compare(a.Device, b.Device) })
+
+	store, storeErr := m.backupStore, m.backupErr
+	return func() tea.Msg {
+		if storeErr != nil {
+			return CompareSourcesMsg{Sources: live, Err: storeErr}
+		}
+		sources := live
+		devices, err := store.Devices()
+		for _, device := range devices {
+			versions, listErr := store.List(device)
+			if listErr != nil {
+				err = listErr
+				continue
+			}
+			for _, v := range versions {
+				sources = append(sources, views.CompareSource{Device: device, Version: v})
+			}
+		}
+		return CompareSourcesMsg{Sources: sources, Err: err}
+	}
+}
+
+// runCompare reads both sides of req and compares them. Either side failing
+// fails the comparison: a side read only in part would show everything
+// missing from it as added or removed.
+func (m Model) runCompare(req views.CompareRequestMsg) tea.Cmd {
+	session, store := m.session, m.backupStore
+	return fetchCmd(m.ctx, func(ctx context.Context) (policydiff.Result, error) {
+		before, err := readPolicySet(ctx, session, store, req.Old)
+		if err != nil {
+			return policydiff.Result{}, err
+		}
+		after, err := readPolicySet(ctx, session, store, req.New)
+		if err != nil {
+			return policydiff.Result{}, err
+		}
+		return policydiff.Compare(before, after), nil
+	}, func(res policydiff.Result, err error) tea.Msg {
+		return CompareMsg{Request: req, Result: res, Err: err}
+	})
+}
+
+// backupVsys is the vsys a backup of device is read in: that of the
+// connection it was taken through, which reads every device behind it in
+// the same vsys, or DefaultVsys if there is none. The backup store lists
+// devices by file-safe name, so names are compared that way.
+func backupVsys(session *auth.Session, device string) string {
+	device = devicefile.SafeName(device)
+	for _, conn := range session.ListConnections() {
+		host := devicefile.SafeName(conn.Host)
+		if conn.Client != nil && (device == host || strings.HasPrefix(device, host+"@")) {
+			return conn.Client.Vsys()
+		}
+	}
+	return api.DefaultVsys
+}
+
+// readPolicySet reads a source's rules and objects from its device, or
+// from its backup.
+func readPolicySet(ctx context.Context, session *auth.Session, store *backup.Store, src views.CompareSource) (models.PolicySet, error) {
+	if !src.Live() {
+		root, err := store.Parse(src.Version)
+		if err != nil {
+			return models.PolicySet{}, fmt.Errorf("%s: %w", src.Label(), err)
+		}
+		return api.PolicySetFromConfig(root, backupVsys(session, src.Device)), nil
+	}
+	for _, conn := range session.ListConnections() {
+		if conn.Host == src.Host && conn.Connected {
+			set, err := policydiff.Collect(ctx, conn.Client, src.Target)
+			if err != nil {
+				return set, fmt.Errorf("%s: %w", src.Label(), err)
+			}
+			return set, nil
+		}
+	}
+	return models.PolicySet{}, fmt.Errorf("%s: no longer connected", src.Label())
+}
@@ -0,0 +1,94 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/jp2195/pyre/internal/backup"
+	"github.com/jp2195/pyre/internal/config"
+	"github.com/jp2195/pyre/internal/mockpanos"
+	"github.com/jp2195/pyre/internal/policydiff"
+	"github.com/jp2195/pyre/internal/testutil"
+)
+
+func TestCompare_LiveAgainstBackup(t *testing.T) {
+	m := newBackupsTestModel(t)
+	updated, _ := m.Update(m.takeBackup(m.session.GetActiveConnection())())
+	m = updated.(Model)
+
+	updated, cmd := m.Update(SwitchViewMsg{View: ViewCompare})
+	m = updated.(Model)
+	if cmd == nil || !m.compare.IsLoading() {
+		t.Fatal("opening the view should list the sources")
+	}
+	m = runCmd(t, m, cmd)
+	view := m.renderContent()
+	if !strings.Contains(view, "2 sources") || !strings.Contains(view, "live") || !strings.Contains(view, "snapshot") {
+		t.Fatalf("expected the device and its backup:\n%s", view)
+	}
+
+	// Mark the backup as the old side, then compare the device with it.
+	for _, key := range []tea.KeyPressMsg{{Code: 'j', Text: "j"}, {Code: tea.KeySpace, Text: " "}, {Code: 'k', Text: "k"}, {Code: tea.KeyEnter}} {
+		updated, cmd = m.Update(key)
+		m = updated.(Model)
+	}
+	req, ok := m.compare.Comparison()
+	if !ok || req.Old.Live() || !req.New.Live() {
+		t.Fatalf("comparison = %+v, %v; want the backup against the live device", req, ok)
+	}
+	updated, cmd = m.Update(cmd())
+	m = runCmd(t, updated.(Model), cmd)
+	if m.compare.IsLoading() {
+		t.Fatal("the comparison should have finished")
+	}
+	view = m.renderContent()
+	if strings.Contains(view, "Error") || !strings.Contains(view, "Old: "+req.Old.Label()) {
+		t.Errorf("comparison view:\n%s", view)
+	}
+
+	updated, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+	m = updated.(Model)
+	if _, ok := m.compare.Comparison(); ok {
+		t.Error("esc should return to the sources")
+	}
+}
+
+func TestCompare_BackupReadInConnectionVsys(t *testing.T) {
+	ds := mockpanos.DefaultFirewall()
+	ds.Config = strings.Replace(ds.Config, `<entry name="vsys1">`, `<entry name="vsys2">`, 1)
+	ds.CandidateEdits = nil
+	mock := testutil.NewMockWithDataset(ds)
+	t.Cleanup(mock.Close)
+	m := newTestModel(t, ViewDashboard)
+	m.backupStore = backup.NewStore(t.TempDir())
+	m.backupRetention = backup.Retention{Keep: 5}
+	if _, err := m.session.AddConnection(mock.Host(), &config.ConnectionConfig{Insecure: true, Vsys: "vsys2"}, "k"); err != nil {
+		t.Fatalf("AddConnection: %v", err)
+	}
+	updated, _ := m.Update(m.takeBackup(m.session.GetActiveConnection())())
+	m = updated.(Model)
+
+	srcs, ok := m.fetchCompareSources()().(CompareSourcesMsg)
+	if !ok || srcs.Err != nil || len(srcs.Sources) != 2 {
+		t.Fatalf("sources = %+v", srcs)
+	}
+	ctx := context.Background()
+	live, err := readPolicySet(ctx, m.session, m.backupStore, srcs.Sources[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, err := readPolicySet(ctx, m.session, m.backupStore, srcs.Sources[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Addresses) == 0 || len(saved.Addresses) != len(live.Addresses) {
+		t.Fatalf("backup read %d addresses, the device %d; want vsys2's in both", len(saved.Addresses), len(live.Addresses))
+	}
+	res := policydiff.Compare(saved, live)
+	if n := res.Count(policydiff.Added) + res.Count(policydiff.Removed); n != 0 {
+		t.Errorf("device against its own backup: %d entries added or removed, want 0", n)
+	}
+}
@@ -0,0 +1,49 @@
+package tui
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func TestConfigExport_PoliciesToSetCommands(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	m := newMockConnectedModel(t)
+
+	updated, _ := m.Update(SwitchViewMsg{View: ViewPolicies})
+	rules, err := m.session.GetActiveConnection().Client.GetSecurityPolicies(context.Background(), "")
+	if err != nil || len(rules) == 0 {
+		t.Fatalf("rules = %d, %v", len(rules), err)
+	}
+	updated, _ = updated.(Model).Update(PoliciesMsg{Policies: rules})
+	m = updated.(Model)
+
+	updated, cmd := m.Update(tea.KeyPressMsg{Code: 'e', Text: "e"})
+	m = updated.(Model)
+	if cmd == nil {
+		t.Fatal("e should request an export")
+	}
+	updated, cmd = m.Update(cmd())
+	m = runCmd(t, updated.(Model), cmd)
+
+	view := m.renderContent()
+	if !strings.Contains(view, "Exported to") {
+		t.Fatalf("expected a notice of the export:\n%s", view)
+	}
+	files, err := filepath.Glob(filepath.Join(home, ".pyre", "exports", "*.set"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("exports = %v, %v", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "set vsys vsys1 rulebase security rules ") {
+		t.Errorf("export holds no rule:\n%s", data)
+	}
+}
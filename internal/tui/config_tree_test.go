@@ -0,0 +1,49 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func TestConfigTree_ExpandFetchesFromDevice(t *testing.T) {
+	m := newMockConnectedModel(t)
+	updated, _ := m.Update(SwitchViewMsg{View: ViewConfigTree})
+	m = updated.(Model)
+	if m.configTree.Device() != m.backupDevice() {
+		t.Fatal("opening the view should bind the tree to the active connection")
+	}
+
+	// Walk down to the devices entry and expand it.
+	for range 4 {
+		updated, _ = m.Update(tea.KeyPressMsg{Code: 'j', Text: "j"})
+		m = updated.(Model)
+	}
+	if got := m.configTree.Selected(); got != "/config/devices/entry[@name='localhost.localdomain']" {
+		t.Fatalf("selected %s", got)
+	}
+	updated, cmd := m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	m = updated.(Model)
+	if cmd == nil {
+		t.Fatal("expanding an unloaded branch should request it")
+	}
+	updated, cmd = m.Update(cmd())
+	m = runCmd(t, updated.(Model), cmd)
+
+	view := m.renderContent()
+	if m.configTree.IsLoading() || !strings.Contains(view, "vsys") {
+		t.Errorf("device entry not loaded:\n%s", view)
+	}
+
+	// Refresh re-fetches the opened branch.
+	updated, cmd = m.Update(RefreshMsg{})
+	m = updated.(Model)
+	if !m.configTree.IsLoading() {
+		t.Fatal("refresh should re-fetch the opened branch")
+	}
+	m = runCmd(t, m, cmd)
+	if m.configTree.IsLoading() || !strings.Contains(m.renderContent(), "vsys") {
+		t.Error("refresh should leave the branch loaded")
+	}
+}
@@ -0,0 +1,59 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func TestConsole_RunsShowCommand(t *testing.T) {
+	// Running a command saves the history to ~/.pyre/state.json.
+	t.Setenv("HOME", t.TempDir())
+	m := newMockConnectedModel(t)
+	updated, _ := m.Update(SwitchViewMsg{View: ViewConsole})
+	m = updated.(Model)
+	if m.console.Device() != m.backupDevice() {
+		t.Fatal("opening the view should bind the console to the active connection")
+	}
+
+	for _, r := range "show system info" {
+		updated, _ = m.Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+		m = updated.(Model)
+	}
+	updated, cmd := m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	m = updated.(Model)
+	if cmd == nil {
+		t.Fatal("enter should run the command")
+	}
+	updated, cmd = m.Update(cmd())
+	m = runCmd(t, updated.(Model), cmd)
+
+	if m.console.IsLoading() || !strings.Contains(m.renderContent(), "<hostname>") {
+		t.Errorf("system info not shown:\n%s", m.renderContent())
+	}
+	conn := m.session.GetActiveConnection()
+	if got := m.state.CommandHistory(conn.Host); !slices.Equal(got, []string{"show system info"}) {
+		t.Errorf("history = %v", got)
+	}
+}
+
+func TestConsole_RefusesWritesWhenReadOnly(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	m := newMockConnectedModel(t)
+	updated, _ := m.Update(SwitchViewMsg{View: ViewConsole})
+	m = updated.(Model)
+
+	for _, r := range "request restart system" {
+		updated, _ = m.Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+		m = updated.(Model)
+	}
+	updated, cmd := m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	updated, cmd = updated.(Model).Update(cmd())
+	m = runCmd(t, updated.(Model), cmd)
+
+	if view := m.renderContent(); !strings.Contains(view, "not a read-only command") {
+		t.Errorf("expected the client to refuse the command:\n%s", view)
+	}
+}
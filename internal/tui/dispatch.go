@@ -1,12 +1,19 @@
 package tui
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"slices"
 
 	tea "charm.land/bubbletea/v2"
 
+	"github.com/jp2195/pyre/internal/api"
 	"github.com/jp2195/pyre/internal/auth"
+	"github.com/jp2195/pyre/internal/backup"
 	"github.com/jp2195/pyre/internal/config"
+	"github.com/jp2195/pyre/internal/devicefile"
+	"github.com/jp2195/pyre/internal/models"
 	"github.com/jp2195/pyre/internal/tui/views"
 )
 
@@ -16,6 +23,9 @@ func (m Model) handleDataMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case LoginSuccessMsg, LoginErrorMsg, PanoramaDetectedMsg, ManagedDevicesMsg:
 		return m.handleAuthMsg(msg)
 
+	case ReauthNeededMsg:
+		return m.handleReauthNeeded(msg)
+
 	case SystemInfoMsg, ResourcesMsg, SessionInfoMsg, HAStatusMsg,
 		GlobalProtectMsg, LoggedInAdminsMsg, LicensesMsg, JobsMsg,
 		DiskUsageMsg, EnvironmentalsMsg, CertificatesMsg, NATPoolMsg:
@@ -25,8 +35,34 @@ func (m Model) handleDataMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 		SessionsMsg, SessionDetailMsg, SystemLogsMsg, TrafficLogsMsg,
 		ThreatLogsMsg, ARPTableMsg, RoutingTableMsg, BGPNeighborsMsg,
 		OSPFNeighborsMsg, IPSecTunnelsMsg, GlobalProtectUsersMsg,
-		PendingChangesMsg, AddressesMsg, ServicesMsg:
-		return m.handleViewDataMsg(msg)
+		PendingChangesMsg, ConfigDiffMsg, AddressesMsg, ServicesMsg, ObjectGroupsMsg,
+		BackupsMsg, BackupDiffMsg, ConfigTreeMsg, ConsoleResultMsg, APICallsMsg,
+		AuditMsg, AppIDMsg, CompareSourcesMsg, CompareMsg:
+		return m.clearStale(msg).handleViewDataMsg(msg)
+
+	case AlertTickMsg:
+		return m.handleAlertTick()
+
+	case AlertsCheckedMsg:
+		return m.handleAlertsChecked(msg)
+
+	case HistoryTickMsg:
+		return m.handleHistoryTick()
+
+	case HistorySampledMsg:
+		return m.handleHistorySampled(msg)
+
+	case TrendsMsg:
+		return m.handleTrends(msg)
+
+	case views.TrendRangeMsg:
+		return m, m.loadTrends()
+
+	case StaleDataMsg:
+		return m.handleStaleData(msg)
+
+	case StaleRefreshFailedMsg:
+		return m.handleStaleRefreshFailed(msg)
 
 	case SwitchViewMsg, SwitchDashboardMsg,
 		ShowPickerMsg, ShowConnectionHubMsg, ShowConnectionFormMsg,
@@ -40,6 +76,57 @@ func (m Model) handleDataMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case views.FetchDetailCmd:
 		return m, m.fetchSessionDetail(msg.SessionID)
 
+	case views.CommitRequestMsg:
+		return m.handleCommitRequest(msg)
+
+	case CommitJobMsg:
+		return m.handleCommitJob(msg)
+
+	case views.BackupRequestMsg:
+		return m.handleBackupRequest()
+
+	case BackupSavedMsg:
+		m.backups = m.backups.SetSaved(msg.Version, msg.Pruned, msg.Err)
+		if msg.Err != nil || msg.Device != m.backups.Device() {
+			return m, nil
+		}
+		return m, m.fetchBackups()
+
+	case views.BackupDiffRequestMsg:
+		return m, tea.Batch(m.diffBackups(msg), m.spinner.Tick)
+
+	case views.AuditExportRequestMsg:
+		return m, tea.Batch(m.exportAudit(), m.spinner.Tick)
+
+	case AuditExportedMsg:
+		m.auditView = m.auditView.SetExported(msg.Path, msg.Err)
+		return m, nil
+
+	case views.WhereUsedJumpMsg:
+		return m.handleWhereUsedJump(msg)
+
+	case views.CompareRequestMsg:
+		return m, tea.Batch(m.runCompare(msg), m.spinner.Tick)
+
+	case views.ConfigExportRequestMsg:
+		return m.handleConfigExportRequest(msg)
+
+	case ConfigExportedMsg:
+		return m.setConfigExported(msg.View, msg.Path, msg.Err), nil
+
+	case views.AppIDExportRequestMsg:
+		return m, tea.Batch(m.exportAppID(), m.spinner.Tick)
+
+	case AppIDExportedMsg:
+		m.appIDView = m.appIDView.SetExported(msg.Path, msg.Err)
+		return m, nil
+
+	case views.ConfigTreeRequestMsg:
+		return m, tea.Batch(m.fetchConfigTree(msg), m.spinner.Tick)
+
+	case views.ConsoleRunMsg:
+		return m.handleConsoleRun(msg)
+
 	default:
 		// A message type not registered above would otherwise vanish
 		// silently and look like "the fetch never returned".
@@ -54,6 +141,9 @@ func (m Model) handleAuthMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case LoginSuccessMsg:
+		if len(m.reauthQueue) > 0 {
+			return m.finishReauth(msg)
+		}
 		m.loading = false
 		m.login = m.login.SetSubmitting(false).ClearPassword()
 
@@ -100,6 +190,17 @@ func (m Model) handleAuthMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case LoginErrorMsg:
 		m.loading = false
+		var unknown *api.UnknownHostError
+		if errors.As(msg.Err, &unknown) {
+			m.login = m.login.SetTrustPrompt(unknown.Host, unknown.Fingerprint)
+			break
+		}
+		// Shown on its own, not behind "keygen request failed: Post ...".
+		var changed *api.HostKeyChangedError
+		if errors.As(msg.Err, &changed) {
+			m.login = m.login.SetError(changed)
+			break
+		}
 		m.login = m.login.SetError(msg.Err)
 
 	case PanoramaDetectedMsg:
@@ -127,6 +228,84 @@ func (m Model) handleAuthMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// handleReauthNeeded asks the user to log in again to a connection whose
+// API key was rejected. Connections that fail while the form is up queue
+// behind it.
+func (m Model) handleReauthNeeded(msg ReauthNeededMsg) (tea.Model, tea.Cmd) {
+	// Removed or given up on before this message arrived.
+	if !msg.Conn.ReauthPending() || slices.Contains(m.reauthQueue, msg.Conn) {
+		return m, m.waitForReauth()
+	}
+	if len(m.reauthQueue) == 0 {
+		m.reauthReturn = m.currentView
+		if m.reauthReturn == ViewLogin {
+			m.reauthReturn = ViewDashboard
+		}
+	}
+	m.reauthQueue = append(m.reauthQueue, msg.Conn)
+	if len(m.reauthQueue) == 1 {
+		m.showReauthLogin()
+	}
+	return m, m.waitForReauth()
+}
+
+// showReauthLogin opens the login form for the head of the re-login queue,
+// with the host and last user filled in.
+func (m *Model) showReauthLogin() {
+	conn := m.reauthQueue[0]
+	username := conn.Config.Username
+	if m.state != nil && m.state.Connections[conn.Host].LastUser != "" {
+		username = m.state.Connections[conn.Host].LastUser
+	}
+	m.loading = false
+	m.login = views.NewLoginModel(&auth.Credentials{
+		Host:     conn.Host,
+		Username: username,
+		Insecure: conn.Config.Insecure,
+	}).SetSize(m.width, m.height).SetNotice("API key rejected: log in to resume")
+	m.currentView = ViewLogin
+}
+
+// finishReauth hands the new key to the connection at the head of the
+// re-login queue, which retries its paused requests with it.
+func (m Model) finishReauth(msg LoginSuccessMsg) (tea.Model, tea.Cmd) {
+	conn := m.reauthQueue[0]
+	m.loading = false
+	m.login = m.login.SetSubmitting(false).ClearPassword()
+	if msg.Host != conn.Host {
+		m.login = m.login.SetError(fmt.Errorf("log in to %s to resume, or press esc to give up", conn.Host))
+		return m, nil
+	}
+	conn.FinishReauth(msg.APIKey)
+
+	var cmd tea.Cmd
+	if m.state != nil {
+		m.state.UpdateConnection(conn.Host, msg.Username)
+		cmd = m.saveState()
+	}
+	return m.nextReauth(), cmd
+}
+
+// abandonReauth gives up on the head of the re-login queue; its paused
+// requests fail with the authentication error.
+func (m Model) abandonReauth() Model {
+	m.reauthQueue[0].FinishReauth("")
+	return m.nextReauth()
+}
+
+// nextReauth drops the head of the re-login queue and prompts for the
+// next connection, or returns to the view the user was on.
+func (m Model) nextReauth() Model {
+	m.reauthQueue = m.reauthQueue[1:]
+	if len(m.reauthQueue) > 0 {
+		m.showReauthLogin()
+		return m
+	}
+	m.login = views.NewLoginModel(&auth.Credentials{})
+	m.currentView = m.reauthReturn
+	return m
+}
+
 // handleDashboardDataMsg processes data messages for dashboard panels.
 func (m Model) handleDashboardDataMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
@@ -173,8 +352,10 @@ func (m Model) handleViewDataMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.policies = m.policies.SetPolicies(msg.Policies, msg.Err)
 		m.securityDashboard = m.securityDashboard.SetPolicies(msg.Policies, msg.Err)
 		m.configDashboard = m.configDashboard.SetPolicies(msg.Policies, msg.Err)
+		m.syncWhereUsed()
 	case NATPoliciesMsg:
 		m.natPolicies = m.natPolicies.SetRules(msg.Rules, msg.Err)
+		m.syncWhereUsed()
 	case SessionsMsg:
 		m.sessions = m.sessions.SetSessions(msg.Sessions, msg.Err)
 	case SessionDetailMsg:
@@ -207,15 +388,162 @@ func (m Model) handleViewDataMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.gpUsers = m.gpUsers.SetUsers(msg.Users, msg.Err)
 	case PendingChangesMsg:
 		m.configDashboard = m.configDashboard.SetPendingChanges(msg.Changes, msg.Err)
+	case ConfigDiffMsg:
+		m.configDiff = m.configDiff.SetDiff(msg.Hunks, msg.Err)
+	case BackupsMsg:
+		// A listing for a connection that is no longer active is stale.
+		if msg.Device == m.backupDevice() {
+			m.backups = m.backups.SetDevice(msg.Device).SetVersions(msg.Device, msg.Versions, msg.Err)
+		}
+	case BackupDiffMsg:
+		m.backups = m.backups.SetDiff(msg.Older, msg.Newer, msg.Hunks, msg.Err)
+	case ConfigTreeMsg:
+		m.configTree = m.configTree.SetElement(msg.Device, msg.Candidate, msg.XPath, msg.Element, msg.Err)
+	case ConsoleResultMsg:
+		m.console = m.console.SetResult(msg.Device, msg.Seq, msg.Result, msg.Err)
+	case APICallsMsg:
+		m.apiCalls = m.apiCalls.SetCalls(msg.Host, msg.Calls)
+	case AuditMsg:
+		m.auditView = m.auditView.SetResult(msg.Device, msg.Result, msg.Err)
+	case AppIDMsg:
+		m.appIDView = m.appIDView.SetSuggestions(msg.Device, msg.Suggestions, msg.Days, msg.Err)
+	case CompareSourcesMsg:
+		m.compare = m.compare.SetSources(msg.Sources, msg.Err)
+	case CompareMsg:
+		m.compare = m.compare.SetResult(msg.Request, msg.Result, msg.Err)
 	case AddressesMsg:
 		m.objects = m.objects.SetAddresses(msg.Items, msg.Err)
+		m = m.syncRuleObjects()
 	case ServicesMsg:
 		m.objects = m.objects.SetServices(msg.Items, msg.Err)
+		m = m.syncRuleObjects()
+	case ObjectGroupsMsg:
+		m.objects = m.objects.SetGroups(msg.Groups, msg.Err)
+		m = m.syncRuleObjects()
+		if msg.Err != nil {
+			return m.setError(fmt.Errorf("loading object groups: %w", msg.Err))
+		}
 	}
 
 	return m, nil
 }
 
+// syncRuleObjects hands the objects loaded to the views that resolve rule
+// members against them.
+func (m Model) syncRuleObjects() Model {
+	m.policies = m.policies.SetObjects(m.objects)
+	m.natPolicies = m.natPolicies.SetObjects(m.objects)
+	m.syncWhereUsed()
+	return m
+}
+
+// handleCommitRequest starts a validate or commit job on the active
+// connection. The view only asks when the connection allows writes; this
+// checks again in case the connection changed underneath it.
+func (m Model) handleCommitRequest(msg views.CommitRequestMsg) (tea.Model, tea.Cmd) {
+	conn := m.session.GetActiveConnection()
+	if conn == nil {
+		m.configDiff = m.configDiff.SetJob(models.Job{}, fmt.Errorf("not connected"))
+		return m, nil
+	}
+	if !m.writeAllowed() {
+		m.configDiff = m.configDiff.SetJob(models.Job{}, api.ErrReadOnly)
+		return m, nil
+	}
+	return m, tea.Batch(m.startCommitJob(conn, msg), m.spinner.Tick)
+}
+
+// handleBackupRequest saves the active connection's running config.
+func (m Model) handleBackupRequest() (tea.Model, tea.Cmd) {
+	conn := m.session.GetActiveConnection()
+	if conn == nil {
+		m.backups = m.backups.SetSaved(backup.Version{}, 0, fmt.Errorf("not connected"))
+		return m, nil
+	}
+	return m, tea.Batch(m.takeBackup(conn), m.spinner.Tick)
+}
+
+// handleConfigExportRequest exports the rules or objects the current view
+// asked for from the active connection's running config.
+func (m Model) handleConfigExportRequest(msg views.ConfigExportRequestMsg) (tea.Model, tea.Cmd) {
+	conn := m.session.GetActiveConnection()
+	if conn == nil {
+		return m.setConfigExported(m.currentView, "", fmt.Errorf("not connected")), nil
+	}
+	return m, tea.Batch(m.exportConfig(conn, m.currentView, msg), m.spinner.Tick)
+}
+
+// setConfigExported reports the outcome of a config export to the view
+// that asked for it.
+func (m Model) setConfigExported(view ViewState, path string, err error) Model {
+	switch view {
+	case ViewPolicies:
+		m.policies = m.policies.SetExported(path, err)
+	case ViewNATPolicies:
+		m.natPolicies = m.natPolicies.SetExported(path, err)
+	case ViewObjects:
+		m.objects = m.objects.SetExported(path, err)
+	}
+	return m
+}
+
+// handleConsoleRun records a console command in the connection's history
+// and runs it. Read-only enforcement is left to the client, which refuses
+// anything but show unless the connection allows writes.
+func (m Model) handleConsoleRun(msg views.ConsoleRunMsg) (tea.Model, tea.Cmd) {
+	conn := m.session.GetActiveConnection()
+	if conn == nil || msg.Device != devicefile.Device(conn.Host, conn.Target()) {
+		m.console = m.console.SetResult(msg.Device, msg.Seq, "", fmt.Errorf("not connected"))
+		return m, nil
+	}
+	m.state.AddCommand(conn.Host, msg.Command)
+	return m, tea.Batch(m.runConsoleCommand(conn, msg), m.saveState(), m.spinner.Tick)
+}
+
+// openConsole points the console at the active connection, loading its
+// command history.
+func (m *Model) openConsole() {
+	var history []string
+	if conn := m.session.GetActiveConnection(); conn != nil {
+		history = m.state.CommandHistory(conn.Host)
+	}
+	m.console = m.console.SetDevice(m.backupDevice(), history)
+}
+
+// handleCommitJob records job progress and keeps polling until the job
+// finishes. A successful commit refreshes the diff and pending changes.
+func (m Model) handleCommitJob(msg CommitJobMsg) (tea.Model, tea.Cmd) {
+	m.configDiff = m.configDiff.SetJob(msg.Job, msg.Err)
+	if msg.Err != nil {
+		return m, nil
+	}
+
+	conn := m.session.GetActiveConnection()
+	if msg.Job.Status == "FIN" {
+		if !msg.Commit || msg.Job.Result != "OK" || conn == nil || conn.Host != msg.Host {
+			return m, nil
+		}
+		// The running config changed; nothing cached from before reflects it.
+		conn.Client.InvalidateCache()
+		m.configDiff = m.configDiff.SetLoading(true)
+		return m, tea.Batch(m.fetchConfigDiff(), m.fetchPendingChanges(conn), m.spinner.Tick)
+	}
+
+	if conn == nil || conn.Host != msg.Host || conn.Target() != msg.Target {
+		m.configDiff = m.configDiff.SetJob(msg.Job,
+			fmt.Errorf("job %d is still running on %s; stopped tracking it after the connection changed", msg.Job.ID, msg.Host))
+		return m, nil
+	}
+	return m, m.pollCommitJob(conn, msg)
+}
+
+// writeAllowed reports whether the active connection permits validate and
+// commit.
+func (m Model) writeAllowed() bool {
+	conn := m.session.GetActiveConnection()
+	return conn != nil && conn.Config != nil && conn.Config.AllowWrite
+}
+
 // handleNavigationMsg processes view transitions and UI navigation messages.
 func (m Model) handleNavigationMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
@@ -246,16 +574,7 @@ func (m Model) handleNavigationMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.handleShowConnectionForm(msg)
 
 	case ConnectionSelectedMsg:
-		m.selectedConnection = msg.Host
-		m.selectedConnectionConfig = msg.Config
-		m.login = views.NewLoginModel(&auth.Credentials{
-			Host:     msg.Host,
-			Username: msg.Config.Username,
-			Insecure: msg.Config.Insecure,
-		})
-		m.login = m.login.SetSize(m.width, m.height)
-		m.currentView = ViewLogin
-		return m, nil
+		return m.openLogin(msg.Host, msg.Config)
 
 	case ConnectionFormSubmitMsg:
 		return m.handleConnectionFormSubmit(msg)
@@ -271,7 +590,7 @@ func (m Model) handleNavigationMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case RefreshTickMsg:
-		return m, tea.Batch(m.refreshCurrentView(), m.spinner.Tick)
+		return m, tea.Batch(m.uncached().refreshCurrentView(), m.spinner.Tick)
 	}
 
 	return m, nil
@@ -315,12 +634,12 @@ func (m Model) handleSwitchView(msg SwitchViewMsg) (tea.Model, tea.Cmd) {
 	case ViewPolicies:
 		if !m.policies.HasData() {
 			m.policies = m.policies.SetLoading(true)
-			return m, m.fetchPolicies()
+			return m, tea.Batch(m.fetchPolicies(), m.fetchRuleObjects())
 		}
 	case ViewNATPolicies:
 		if !m.natPolicies.HasData() {
 			m.natPolicies = m.natPolicies.SetLoading(true)
-			return m, m.fetchNATPolicies()
+			return m, tea.Batch(m.fetchNATPolicies(), m.fetchRuleObjects())
 		}
 	case ViewSessions:
 		if !m.sessions.HasData() {
@@ -367,6 +686,53 @@ func (m Model) handleSwitchView(msg SwitchViewMsg) (tea.Model, tea.Cmd) {
 			m.objects = m.objects.SetLoading(true)
 			return m, m.fetchObjects()
 		}
+	case ViewConfigDiff:
+		if !m.configDiff.HasData() {
+			m.configDiff = m.configDiff.SetLoading(true)
+			return m, m.fetchConfigDiff()
+		}
+	case ViewBackups:
+		m.backups = m.backups.SetDevice(m.backupDevice())
+		if !m.backups.HasData() {
+			m.backups = m.backups.SetLoading(true)
+			return m, m.fetchBackups()
+		}
+	case ViewConfigTree:
+		m.configTree = m.configTree.SetDevice(m.backupDevice())
+	case ViewConsole:
+		m.openConsole()
+	case ViewAPICalls:
+		m.apiCalls = m.apiCalls.SetLoading(true)
+		return m, m.fetchAPICalls()
+	case ViewAlerts:
+		m.alertsUnseen = 0
+	case ViewAudit:
+		m.auditView = m.auditView.SetDevice(m.backupDevice())
+		if !m.auditView.HasData() {
+			m.auditView = m.auditView.SetLoading(true)
+			return m, m.fetchAudit()
+		}
+	case ViewAppID:
+		m.appIDView = m.appIDView.SetDevice(m.backupDevice())
+		if !m.appIDView.HasData() {
+			m.appIDView = m.appIDView.SetLoading(true)
+			return m, m.fetchAppID()
+		}
+	case ViewWhereUsed:
+		fetch := m.fetchWhereUsedData()
+		if !m.whereUsed.HasData() {
+			var focus tea.Cmd
+			m.whereUsed, focus = m.whereUsed.SetQuery("")
+			return m, tea.Batch(fetch, focus)
+		}
+		return m, fetch
+	case ViewCompare:
+		// Devices connect and backups are saved while the view is closed, so
+		// the sources are listed afresh unless a comparison is shown.
+		if _, ok := m.compare.Comparison(); !ok {
+			m.compare = m.compare.SetLoading(true)
+			return m, m.fetchCompareSources()
+		}
 	}
 	return m, nil
 }
@@ -399,16 +765,30 @@ func (m Model) handleConnectionFormSubmit(msg ConnectionFormSubmitMsg) (tea.Mode
 		m.connectionHub = m.connectionHub.SetConnections(m.config, m.state)
 	}
 
-	m.selectedConnection = msg.Host
-	m.selectedConnectionConfig = msg.Config
+	updated, loginCmd := m.openLogin(msg.Host, msg.Config)
+	return updated, tea.Batch(saveCmd, loginCmd)
+}
+
+// openLogin shows the login form for a saved or just-entered connection.
+// A connection with an api_key_command logs in with the key it prints
+// instead; if the helper fails, its error is shown on the form and a
+// password can be typed as usual.
+func (m Model) openLogin(host string, conn config.ConnectionConfig) (tea.Model, tea.Cmd) {
+	m.selectedConnection = host
+	m.selectedConnectionConfig = conn
 	m.login = views.NewLoginModel(&auth.Credentials{
-		Host:     msg.Host,
-		Username: msg.Config.Username,
-		Insecure: msg.Config.Insecure,
+		Host:     host,
+		Username: conn.Username,
+		Insecure: conn.Insecure,
 	})
 	m.login = m.login.SetSize(m.width, m.height)
 	m.currentView = ViewLogin
-	return m, saveCmd
+	if conn.APIKeyCommand == "" {
+		return m, nil
+	}
+	m.loading = true
+	m.login = m.login.SetSubmitting(true)
+	return m, tea.Batch(m.keyCommandLogin(host, conn), m.spinner.Tick)
 }
 
 // handleConnectionDeleted removes a connection from config and state.
@@ -37,6 +37,72 @@ func TestDispatch_ServicesMsg_RoutesToObjectsModel(t *testing.T) {
 	}
 }
 
+func TestDispatch_ObjectGroupsMsg_ReachesRuleViews(t *testing.T) {
+	m := newTestModel(t, ViewPolicies)
+	updated, _ := m.Update(AddressesMsg{Items: []models.AddressObject{
+		{Name: "web-01", Type: "ip-netmask", Value: "10.0.1.10/32"},
+	}})
+	updated, _ = updated.(Model).Update(ObjectGroupsMsg{Groups: views.ObjectGroups{
+		AddressGroups: []models.AddressGroup{{Name: "web", Members: []string{"web-01"}}},
+	}})
+	model := updated.(Model)
+
+	if _, ok := model.objects.LookupAddressGroup("web"); !ok {
+		t.Fatal("address group not routed to ObjectsModel")
+	}
+	for name, got := range map[string][]string{
+		"policies": model.policies.Objects().ResolveAddresses([]string{"web"}),
+		"nat":      model.natPolicies.Objects().ResolveAddresses([]string{"web"}),
+	} {
+		if len(got) != 1 || got[0] != "10.0.1.10/32" {
+			t.Errorf("%s resolves web to %v, want [10.0.1.10/32]", name, got)
+		}
+	}
+}
+
+// TestDispatch_WhereUsedFromRowAndJump looks up the selected rule's
+// address with w, and jumps from a hit back to the rule.
+func TestDispatch_WhereUsedFromRowAndJump(t *testing.T) {
+	m := newTestModel(t, ViewPolicies)
+	updated, _ := m.Update(AddressesMsg{Items: []models.AddressObject{
+		{Name: "web-01", Type: "ip-netmask", Value: "10.0.1.10/32"},
+	}})
+	updated, _ = updated.(Model).Update(PoliciesMsg{Policies: []models.SecurityRule{
+		{Name: "first", Sources: []string{"any"}, Destinations: []string{"192.0.2.0/24"}},
+		{Name: "to-web", Sources: []string{"any"}, Destinations: []string{"web-01"}},
+	}})
+	updated, _ = updated.(Model).Update(NATPoliciesMsg{Rules: []models.NATRule{}})
+
+	updated, _ = updated.(Model).Update(tea.KeyPressMsg{Code: 'w', Text: "w"})
+	model := updated.(Model)
+	if model.currentView != ViewWhereUsed || model.whereUsed.Query() != "192.0.2.0/24" {
+		t.Fatalf("view %v, query %q; want Where Used for the first rule's destination", model.currentView, model.whereUsed.Query())
+	}
+
+	updated, _ = model.Update(tea.KeyPressMsg{Code: '/', Text: "/"})
+	for _, r := range "10.0.1.10" {
+		updated, _ = updated.(Model).Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+	}
+	updated, _ = updated.(Model).Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	model = updated.(Model)
+	if hits := model.whereUsed.Hits(); len(hits) != 2 || hits[0].Name != "to-web" {
+		t.Fatalf("hits = %+v, want the rule and the address object", hits)
+	}
+
+	_, cmd := model.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("enter on a hit returned no command")
+	}
+	updated, _ = model.Update(cmd())
+	model = updated.(Model)
+	if model.currentView != ViewPolicies {
+		t.Fatalf("jump landed on %v, want Policies", model.currentView)
+	}
+	if got := model.policies.LookupAddresses(); len(got) != 2 || got[1] != "10.0.1.10/32" {
+		t.Errorf("selected rule addresses = %v, want to-web's", got)
+	}
+}
+
 // TestDispatch_TabOnObjectsView_NavigatesAway pins that Objects no longer
 // swallows Tab. It used to cycle the Address/Service sub-tabs, which made
 // Objects the one view you could not Tab out of even though the footer
@@ -57,3 +123,30 @@ func TestDispatch_TabOnObjectsView_NavigatesAway(t *testing.T) {
 		t.Error("Tab on Objects view should navigate to the next Analyze item")
 	}
 }
+
+func TestDispatch_ConfigDiffMsg_RoutesToConfigDiffModel(t *testing.T) {
+	m := newTestModel(t, ViewDashboard)
+	updated, _ := m.Update(ConfigDiffMsg{Hunks: []models.ConfigHunk{
+		{XPath: "/config/shared/address/entry[@name='a']", Kind: "added", Admins: []string{"admin"}},
+	}})
+	model := updated.(Model)
+
+	if !model.configDiff.HasData() {
+		t.Error("expected ConfigDiffMsg to populate the diff view")
+	}
+}
+
+// TestDispatch_EnterOnConfigDashboard_OpensDiff pins the link from the
+// Config dashboard's pending-changes panel to the diff view.
+func TestDispatch_EnterOnConfigDashboard_OpensDiff(t *testing.T) {
+	m := newTestModel(t, ViewDashboard)
+	m.currentDashboard = views.DashboardConfig
+
+	_, cmd := m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("expected a command from enter on the Config dashboard")
+	}
+	if msg, ok := cmd().(SwitchViewMsg); !ok || msg.View != ViewConfigDiff {
+		t.Errorf("enter produced %#v, want SwitchViewMsg{ViewConfigDiff}", msg)
+	}
+}
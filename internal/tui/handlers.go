@@ -1,6 +1,8 @@
 package tui
 
 import (
+	"fmt"
+
 	"charm.land/bubbles/v2/key"
 	tea "charm.land/bubbletea/v2"
 
@@ -15,6 +17,12 @@ func (m Model) handleLoginKeys(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
 	case msg.String() == "ctrl+c":
 		return m, tea.Quit
 
+	case m.loginTrustPrompting():
+		return m.handleTrustPromptKeys(msg)
+
+	case msg.String() == "esc" && len(m.reauthQueue) > 0:
+		return m.abandonReauth(), nil
+
 	case msg.String() == "esc":
 		// Reset the login form so any typed credentials (password included)
 		// do not linger in the textinput buffers after leaving the view.
@@ -66,6 +74,27 @@ func (m Model) handleLoginKeys(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+func (m Model) loginTrustPrompting() bool {
+	_, _, ok := m.login.TrustPrompt()
+	return ok
+}
+
+// handleTrustPromptKeys answers the first-connect prompt for a pinned
+// connection: y records the fingerprint and retries the login, n or esc
+// leaves the certificate untrusted.
+func (m Model) handleTrustPromptKeys(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	host, fingerprint, _ := m.login.TrustPrompt()
+	switch msg.String() {
+	case "y", "Y":
+		m.loading = true
+		m.login = m.login.ClearTrustPrompt().SetSubmitting(true)
+		return m, tea.Batch(m.trustHostKey(host, fingerprint, m.retryLogin()), m.spinner.Tick)
+	case "n", "N", "esc":
+		m.login = m.login.ClearTrustPrompt().SetError(fmt.Errorf("certificate of %s was not trusted", host))
+	}
+	return m, nil
+}
+
 func (m Model) handlePickerKeys(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
 	pickerKeys := DefaultPickerKeyMap()
 
@@ -83,6 +112,7 @@ func (m Model) handlePickerKeys(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
 		selected := m.picker.Selected()
 		if selected != "" {
 			m.session.SetActiveFirewall(selected)
+			m = m.resetStale()
 			m.currentView = ViewDashboard
 			return m, m.fetchDashboardData()
 		}
@@ -116,6 +146,7 @@ func (m Model) handleDevicePickerKeys(msg tea.KeyPressMsg) (tea.Model, tea.Cmd)
 				m, cmd = m.setError(err)
 				return m, cmd
 			}
+			m = m.resetStale()
 			m.currentView = ViewDashboard
 			return m, tea.Batch(m.fetchCurrentDashboardData(), m.spinner.Tick)
 		}
@@ -261,6 +292,77 @@ func (m Model) buildCommandRegistry() []views.Command {
 			Category:    "Tools",
 			Action:      func() tea.Msg { return SwitchDashboardMsg{views.DashboardConfig} },
 		},
+		{
+			ID:          "tools-diff",
+			Label:       "Config Diff",
+			Description: "Running vs. candidate",
+			Category:    "Tools",
+			Action:      func() tea.Msg { return SwitchViewMsg{ViewConfigDiff} },
+		},
+		{
+			ID:          "tools-backups",
+			Label:       "Config Backups",
+			Description: "Saved running configs and their diffs",
+			Category:    "Tools",
+			Action:      func() tea.Msg { return SwitchViewMsg{ViewBackups} },
+		},
+		{
+			ID:          "tools-tree",
+			Label:       "Config Tree",
+			Description: "Browse any part of the config by xpath",
+			Category:    "Tools",
+			Action:      func() tea.Msg { return SwitchViewMsg{ViewConfigTree} },
+		},
+		{
+			ID:          "tools-console",
+			Label:       "Console",
+			Description: "Run show commands in CLI syntax",
+			Category:    "Tools",
+			Action:      func() tea.Msg { return SwitchViewMsg{ViewConsole} },
+		},
+		{
+			ID:          "tools-calls",
+			Label:       "API Calls",
+			Description: "Recent requests, their timing and responses",
+			Category:    "Tools",
+			Action:      func() tea.Msg { return SwitchViewMsg{ViewAPICalls} },
+		},
+		{
+			ID:          "tools-alerts",
+			Label:       "Alerts",
+			Description: "Active alerts and their history this session",
+			Category:    "Tools",
+			Action:      func() tea.Msg { return SwitchViewMsg{ViewAlerts} },
+		},
+		{
+			ID:          "tools-audit",
+			Label:       "Best-Practice Audit",
+			Description: "Score rules and certificates against best practices",
+			Category:    "Tools",
+			Action:      func() tea.Msg { return SwitchViewMsg{ViewAudit} },
+		},
+		{
+			ID:          "tools-appid",
+			Label:       "App-ID Migration",
+			Description: "Applications seen on port-based rules, and App-ID replacements",
+			Category:    "Tools",
+			Action:      func() tea.Msg { return SwitchViewMsg{ViewAppID} },
+		},
+		{
+			ID:          "tools-whereused",
+			Label:       "Where Used",
+			Description: "Rules, NAT rules and objects that use an IP or subnet",
+			Category:    "Tools",
+			Shortcut:    "w",
+			Action:      func() tea.Msg { return SwitchViewMsg{ViewWhereUsed} },
+		},
+		{
+			ID:          "tools-compare",
+			Label:       "Compare",
+			Description: "Rule and object differences between two devices or backups",
+			Category:    "Tools",
+			Action:      func() tea.Msg { return SwitchViewMsg{ViewCompare} },
+		},
 
 		// Connections
 		{
@@ -453,6 +555,26 @@ func (m Model) currentViewFiltering() bool {
 		return m.logs.IsFilterMode()
 	case ViewObjects:
 		return m.objects.IsFilterMode()
+	case ViewConfigDiff:
+		return m.configDiff.IsFilterMode()
+	case ViewBackups:
+		return m.backups.IsFilterMode()
+	case ViewConfigTree:
+		return m.configTree.IsFilterMode()
+	case ViewConsole:
+		return m.console.IsFilterMode()
+	case ViewAPICalls:
+		return m.apiCalls.IsFilterMode()
+	case ViewAlerts:
+		return m.alertsView.IsFilterMode()
+	case ViewAudit:
+		return m.auditView.IsFilterMode()
+	case ViewAppID:
+		return m.appIDView.IsFilterMode()
+	case ViewWhereUsed:
+		return m.whereUsed.IsFilterMode()
+	case ViewCompare:
+		return m.compare.IsFilterMode()
 	}
 	return false
 }
@@ -464,6 +586,10 @@ func (m Model) handleViewKeys(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
 		if delta, ok := m.dashboardScrollDelta(msg); ok {
 			return m.scrollActiveDashboard(delta), nil
 		}
+		// The Config dashboard's pending-changes panel opens the diff.
+		if m.currentDashboard == views.DashboardConfig && msg.String() == "enter" {
+			return m, func() tea.Msg { return SwitchViewMsg{ViewConfigDiff} }
+		}
 	}
 
 	return m.updateCurrentView(msg)
@@ -477,7 +603,9 @@ func (m Model) updateCurrentView(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch m.currentView {
 	case ViewDashboard:
-		m.dashboard, cmd = m.dashboard.Update(msg)
+		if m.currentDashboard == views.DashboardMain {
+			m.dashboard, cmd = m.dashboard.Update(msg)
+		}
 	case ViewPolicies:
 		m.policies, cmd = m.policies.Update(msg)
 	case ViewNATPolicies:
@@ -496,6 +624,26 @@ func (m Model) updateCurrentView(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.logs, cmd = m.logs.Update(msg)
 	case ViewObjects:
 		m.objects, cmd = m.objects.Update(msg)
+	case ViewConfigDiff:
+		m.configDiff, cmd = m.configDiff.SetWritable(m.writeAllowed()).Update(msg)
+	case ViewBackups:
+		m.backups, cmd = m.backups.Update(msg)
+	case ViewConfigTree:
+		m.configTree, cmd = m.configTree.Update(msg)
+	case ViewConsole:
+		m.console, cmd = m.console.SetWritable(m.writeAllowed()).Update(msg)
+	case ViewAPICalls:
+		m.apiCalls, cmd = m.apiCalls.Update(msg)
+	case ViewAlerts:
+		m.alertsView, cmd = m.alertsView.Update(msg)
+	case ViewAudit:
+		m.auditView, cmd = m.auditView.Update(msg)
+	case ViewAppID:
+		m.appIDView, cmd = m.appIDView.Update(msg)
+	case ViewWhereUsed:
+		m.whereUsed, cmd = m.whereUsed.Update(msg)
+	case ViewCompare:
+		m.compare, cmd = m.compare.Update(msg)
 	}
 
 	return m, cmd
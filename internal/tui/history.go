@@ -0,0 +1,111 @@
+package tui
+
+import (
+	"context"
+	"log"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/devicefile"
+	"github.com/jp2195/pyre/internal/history"
+)
+
+// historyDevice names the history of the active connection (and target),
+// or "" when there is none.
+func (m Model) historyDevice() string {
+	conn := m.session.GetActiveConnection()
+	if conn == nil {
+		return ""
+	}
+	return devicefile.Device(conn.Host, conn.Target())
+}
+
+// scheduleHistorySample asks for the next history sample.
+func (m Model) scheduleHistorySample() tea.Cmd {
+	if m.history == nil {
+		return nil
+	}
+	return tea.Tick(m.historyInterval, func(time.Time) tea.Msg { return HistoryTickMsg{} })
+}
+
+// sampleHistory reads the active device and appends a sample to its
+// history. Responses younger than half the interval are reused, so a
+// sample right after a dashboard refresh costs the device little.
+func (m Model) sampleHistory() tea.Cmd {
+	conn := m.session.GetActiveConnection()
+	if m.history == nil || conn == nil {
+		return nil
+	}
+	device := m.historyDevice()
+	sampler := m.historySamplers[device]
+	if sampler == nil {
+		sampler = &history.Sampler{}
+		m.historySamplers[device] = sampler
+	}
+	store, client, target, interval := m.history, conn.Client, conn.Target(), m.historyInterval
+	ctx := m.ctx
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(api.WithCache(ctx, interval/2), interval)
+		defer cancel()
+		r, err := history.Collect(ctx, client, target)
+		if err != nil {
+			log.Printf("history: %s: %v", device, err)
+		}
+		smp := sampler.Sample(r)
+		return HistorySampledMsg{Device: device, Sample: smp, Err: store.Append(device, smp)}
+	}
+}
+
+// handleHistoryTick takes a sample, unless one is still being taken, and
+// schedules the next.
+func (m Model) handleHistoryTick() (tea.Model, tea.Cmd) {
+	next := m.scheduleHistorySample()
+	if m.historySampling {
+		return m, next
+	}
+	sample := m.sampleHistory()
+	if sample == nil {
+		return m, next
+	}
+	m.historySampling = true
+	return m, tea.Batch(sample, next)
+}
+
+// handleHistorySampled charts a sample just taken, if it is of the device
+// on screen.
+func (m Model) handleHistorySampled(msg HistorySampledMsg) (tea.Model, tea.Cmd) {
+	m.historySampling = false
+	if msg.Err != nil {
+		log.Printf("history: %s: %v", msg.Device, msg.Err)
+	}
+	if msg.Device == m.historyDevice() {
+		m.dashboard = m.dashboard.AddTrendSample(msg.Sample, msg.Err)
+	}
+	return m, nil
+}
+
+// loadTrends reads the active device's samples for the dashboard's range.
+func (m Model) loadTrends() tea.Cmd {
+	device := m.historyDevice()
+	if m.history == nil || device == "" {
+		return nil
+	}
+	store, r := m.history, m.dashboard.TrendRange()
+	return func() tea.Msg {
+		end := time.Now()
+		samples, err := store.Read(device, end.Add(-r.Duration()))
+		return TrendsMsg{Device: device, Range: r, Samples: samples, End: end, Err: err}
+	}
+}
+
+// handleTrends charts loaded samples, unless the device or range has
+// changed since they were asked for.
+func (m Model) handleTrends(msg TrendsMsg) (tea.Model, tea.Cmd) {
+	if msg.Device != m.historyDevice() || msg.Range != m.dashboard.TrendRange() {
+		return m, nil
+	}
+	m.dashboard = m.dashboard.SetTrends(msg.Samples, msg.End, msg.Err)
+	return m, nil
+}
@@ -0,0 +1,57 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jp2195/pyre/internal/config"
+	"github.com/jp2195/pyre/internal/history"
+	"github.com/jp2195/pyre/internal/testutil"
+	"github.com/jp2195/pyre/internal/tui/views"
+)
+
+func TestHistory_SampleAndChart(t *testing.T) {
+	mock := testutil.NewMockPANOS()
+	defer mock.Close()
+	m := newTestModel(t, ViewDashboard)
+	m.history = history.NewStore(t.TempDir(), 0)
+	m.historyInterval = time.Minute
+	m.historySamplers = map[string]*history.Sampler{}
+	m.dashboard = m.dashboard.SetTrendsEnabled(true)
+	if _, err := m.session.AddConnection(mock.Host(), &config.ConnectionConfig{Insecure: true}, "k"); err != nil {
+		t.Fatalf("AddConnection: %v", err)
+	}
+
+	updated, cmd := m.Update(HistoryTickMsg{})
+	m = updated.(Model)
+	if !m.historySampling || cmd == nil {
+		t.Fatal("a tick should start a sample")
+	}
+	msg, ok := m.sampleHistory()().(HistorySampledMsg)
+	if !ok || msg.Err != nil || msg.Sample.ManagementCPU == nil {
+		t.Fatalf("sample = %#v", msg)
+	}
+	updated, _ = m.Update(msg)
+	m = updated.(Model)
+	if m.historySampling {
+		t.Error("the sample has landed")
+	}
+
+	saved, err := m.history.Read(m.historyDevice(), time.Now().Add(-time.Hour))
+	if err != nil || len(saved) != 1 {
+		t.Fatalf("saved samples = %v, %v", saved, err)
+	}
+
+	// Loading the range again finds the sample; a load for a range no
+	// longer shown is dropped.
+	loaded, ok := m.loadTrends()().(TrendsMsg)
+	if !ok || len(loaded.Samples) != 1 {
+		t.Fatalf("load = %#v", loaded)
+	}
+	updated, _ = m.Update(TrendsMsg{Device: loaded.Device, Range: views.TrendWeek, End: loaded.End})
+	m = updated.(Model)
+	if view := m.dashboard.SetSize(160, 80).View(); !strings.Contains(view, "Mgmt") {
+		t.Errorf("a load for another range should not clear the chart:\n%s", view)
+	}
+}
@@ -9,6 +9,7 @@ type KeyMap struct {
 	DevicePicker key.Binding
 	Refresh      key.Binding
 	OpenPalette  key.Binding
+	WhereUsed    key.Binding
 
 	// Navigation groups (1-3 for top-level groups)
 	NavGroup1 key.Binding
@@ -53,6 +54,10 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("ctrl+p"),
 			key.WithHelp("ctrl+p", "search"),
 		),
+		WhereUsed: key.NewBinding(
+			key.WithKeys("w"),
+			key.WithHelp("w", "where used"),
+		),
 
 		// Navigation groups
 		NavGroup1: key.NewBinding(
@@ -125,7 +130,7 @@ func (k KeyMap) ShortHelp() []key.Binding {
 func (k KeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.NavGroup1, k.NavGroup2, k.NavGroup3},
-		{k.Refresh, k.OpenPalette, k.Help, k.Quit},
+		{k.Refresh, k.OpenPalette, k.WhereUsed, k.Help, k.Quit},
 		{k.Up, k.Down, k.PageUp, k.PageDown},
 		{k.Filter, k.Enter, k.Escape},
 	}
@@ -0,0 +1,102 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/jp2195/pyre/internal/config"
+	"github.com/jp2195/pyre/internal/testutil"
+)
+
+// pinnedLogin opens the login form for a pinned connection to mock and
+// submits the mock's password, returning the model once the login (or the
+// handshake that stops it) has come back.
+func pinnedLogin(t *testing.T, mock *testutil.MockPANOS) Model {
+	t.Helper()
+	m := newTestModel(t, ViewConnectionHub)
+	updated, _ := m.Update(ConnectionSelectedMsg{
+		Host:   mock.Host(),
+		Config: config.ConnectionConfig{Username: "admin", Pin: true},
+	})
+	m = typeInto(t, updated.(Model), "admin")
+	updated, cmd := m.handleLoginKeys(tea.KeyPressMsg{Code: tea.KeyEnter})
+	return runCmd(t, updated.(Model), cmd)
+}
+
+func TestPinnedLogin_TrustOnFirstConnect(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	mock := testutil.NewMockPANOS()
+	defer mock.Close()
+
+	m := pinnedLogin(t, mock)
+	host, fingerprint, ok := m.login.TrustPrompt()
+	if !ok || host != mock.Host() || !strings.HasPrefix(fingerprint, "SHA256:") {
+		t.Fatalf("first connect should ask to trust the certificate; prompt = %q %q %v", host, fingerprint, ok)
+	}
+	if !strings.Contains(m.login.View(), "y: trust and connect") {
+		t.Errorf("prompt not rendered:\n%s", m.login.View())
+	}
+
+	updated, cmd := m.handleLoginKeys(tea.KeyPressMsg{Code: 'y', Text: "y"})
+	m = runCmd(t, updated.(Model), cmd)
+	if conn := m.session.GetActiveConnection(); m.currentView != ViewDashboard || conn == nil {
+		t.Fatalf("view = %v; want connected after trusting", m.currentView)
+	}
+	data, err := os.ReadFile(filepath.Join(home, ".pyre", "known_hosts"))
+	if err != nil || !strings.Contains(string(data), fingerprint) {
+		t.Fatalf("known_hosts = %q, %v; want the trusted fingerprint", data, err)
+	}
+
+	// Trusted now: the next login goes straight through.
+	m = pinnedLogin(t, mock)
+	if _, _, ok := m.login.TrustPrompt(); ok || m.currentView != ViewDashboard {
+		t.Errorf("a trusted certificate should not prompt again (view %v)", m.currentView)
+	}
+}
+
+func TestPinnedLogin_Reject(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	mock := testutil.NewMockPANOS()
+	defer mock.Close()
+
+	m := pinnedLogin(t, mock)
+	updated, _ := m.handleLoginKeys(tea.KeyPressMsg{Code: 'n', Text: "n"})
+	m = updated.(Model)
+	if _, _, ok := m.login.TrustPrompt(); ok || m.currentView != ViewLogin {
+		t.Fatal("n should dismiss the prompt and stay on the form")
+	}
+	if !strings.Contains(m.login.View(), "not trusted") {
+		t.Errorf("rejection should be explained:\n%s", m.login.View())
+	}
+	if _, err := os.Stat(filepath.Join(home, ".pyre", "known_hosts")); !os.IsNotExist(err) {
+		t.Errorf("a rejected certificate must not be recorded (stat err %v)", err)
+	}
+}
+
+func TestPinnedLogin_ChangedCertificate(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	mock := testutil.NewMockPANOS()
+	defer mock.Close()
+	stale := "SHA256:" + strings.Repeat("AB:", 31) + "AB"
+	if err := os.MkdirAll(filepath.Join(home, ".pyre"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".pyre", "known_hosts"), []byte(mock.Host()+" "+stale+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m := pinnedLogin(t, mock)
+	if _, _, ok := m.login.TrustPrompt(); ok {
+		t.Fatal("a changed certificate must never be offered for trust")
+	}
+	if m.session.GetActiveConnection() != nil || !strings.Contains(m.login.View(), "WARNING") {
+		t.Errorf("a changed certificate should fail loudly:\n%s", m.login.View())
+	}
+}
@@ -1,10 +1,13 @@
 package tui
 
 import (
+	"runtime"
 	"strings"
 	"testing"
 
 	tea "charm.land/bubbletea/v2"
+
+	"github.com/jp2195/pyre/internal/config"
 )
 
 // typeInto sends each rune of s to the login handler as a key press, the
@@ -175,3 +178,37 @@ func TestPasteIgnoredWhileAuthenticating(t *testing.T) {
 		t.Errorf("paste mutated a frozen field: %q -> %q", before, got)
 	}
 }
+
+// TestConnectionSelected_KeyCommand asserts that picking a connection with
+// an api_key_command connects with the helper's key instead of waiting for
+// a password, and that a failing helper leaves the form usable.
+func TestConnectionSelected_KeyCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("helper command is sh syntax")
+	}
+	t.Setenv("HOME", t.TempDir())
+	m := newTestModel(t, ViewConnectionHub)
+	updated, cmd := m.Update(ConnectionSelectedMsg{
+		Host:   "helper-tui.example.com",
+		Config: config.ConnectionConfig{APIKeyCommand: "echo helper-key"},
+	})
+	m = updated.(Model)
+	if !m.login.Submitting() {
+		t.Fatal("the helper should run as soon as the connection is picked")
+	}
+	m = runCmd(t, m, cmd)
+	conn := m.session.GetActiveConnection()
+	if m.currentView != ViewDashboard || conn == nil || conn.APIKey != "helper-key" {
+		t.Fatalf("view = %v, conn = %+v; want connected with the helper's key", m.currentView, conn)
+	}
+
+	m = newTestModel(t, ViewConnectionHub)
+	updated, cmd = m.Update(ConnectionSelectedMsg{
+		Host:   "helper-fail.example.com",
+		Config: config.ConnectionConfig{APIKeyCommand: "echo locked >&2; exit 1"},
+	})
+	m = runCmd(t, updated.(Model), cmd)
+	if m.currentView != ViewLogin || m.login.Submitting() || !strings.Contains(m.login.View(), "locked") {
+		t.Errorf("a failed helper should show its error on the login form:\n%s", m.login.View())
+	}
+}
@@ -1,8 +1,20 @@
 package tui
 
 import (
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/jp2195/pyre/internal/alerts"
+	"github.com/jp2195/pyre/internal/appid"
+	"github.com/jp2195/pyre/internal/audit"
+	"github.com/jp2195/pyre/internal/auth"
+	"github.com/jp2195/pyre/internal/backup"
 	"github.com/jp2195/pyre/internal/config"
+	"github.com/jp2195/pyre/internal/history"
 	"github.com/jp2195/pyre/internal/models"
+	"github.com/jp2195/pyre/internal/pancfg"
+	"github.com/jp2195/pyre/internal/policydiff"
 	"github.com/jp2195/pyre/internal/tui/views"
 )
 
@@ -90,6 +102,12 @@ type LoginErrorMsg struct {
 	Err error
 }
 
+// ReauthNeededMsg reports that a device rejected Conn's API key. Its
+// requests are paused until the user logs in again or gives up.
+type ReauthNeededMsg struct {
+	Conn *auth.Connection
+}
+
 type RefreshTickMsg struct{}
 
 type ErrorMsg struct {
@@ -99,6 +117,22 @@ type ErrorMsg struct {
 // ErrorDismissMsg is sent after a timeout to clear the error from the footer
 type ErrorDismissMsg struct{}
 
+// StaleDataMsg carries a dataset's cached response, older than its TTL,
+// to render while Refresh fetches a current copy (see cache.go).
+type StaleDataMsg struct {
+	Dataset   string
+	Msg       tea.Msg
+	FetchedAt time.Time
+	Refresh   tea.Cmd
+}
+
+// StaleRefreshFailedMsg reports that the refresh behind a StaleDataMsg
+// failed. The cached data stays on screen.
+type StaleRefreshFailedMsg struct {
+	Dataset string
+	Err     error
+}
+
 type ManagedDevicesMsg struct {
 	Devices []models.ManagedDevice
 	Err     error
@@ -194,6 +228,153 @@ type PendingChangesMsg struct {
 	Err     error
 }
 
+type ConfigDiffMsg struct {
+	Hunks []models.ConfigHunk
+	Err   error
+}
+
+// CommitJobMsg reports a validate or commit job started from the config diff
+// view: once when it is enqueued and again after every poll. Host and Target
+// identify the device the job runs on.
+type CommitJobMsg struct {
+	Host   string
+	Target string
+	Commit bool
+	Job    models.Job
+	Err    error
+}
+
+// BackupsMsg lists the stored versions of Device, newest first.
+type BackupsMsg struct {
+	Device   string
+	Versions []backup.Version
+	Err      error
+}
+
+// BackupSavedMsg reports a backup started from the backups view. Pruned
+// counts the old versions the retention policy removed.
+type BackupSavedMsg struct {
+	Device  string
+	Version backup.Version
+	Pruned  int
+	Err     error
+}
+
+// BackupDiffMsg carries the diff between two stored versions.
+type BackupDiffMsg struct {
+	Older, Newer backup.Version
+	Hunks        []models.ConfigHunk
+	Err          error
+}
+
+// ConfigTreeMsg carries one branch fetched for the config tree.
+type ConfigTreeMsg struct {
+	Device    string
+	Candidate bool
+	XPath     string
+	Element   *pancfg.Node
+	Err       error
+}
+
+// AlertTickMsg asks for the next periodic alert check.
+type AlertTickMsg struct{}
+
+// AlertsCheckedMsg carries what an alert check read from Device at At.
+// Err joins the reads that failed; the rest are in Snapshot.
+type AlertsCheckedMsg struct {
+	Device   string
+	Snapshot alerts.Snapshot
+	Err      error
+	At       time.Time
+}
+
+// HistoryTickMsg asks for the next history sample.
+type HistoryTickMsg struct{}
+
+// HistorySampledMsg carries a sample just taken of Device. Err is why it
+// could not be saved.
+type HistorySampledMsg struct {
+	Device string
+	Sample history.Sample
+	Err    error
+}
+
+// TrendsMsg carries Device's samples for Range, up to End.
+type TrendsMsg struct {
+	Device  string
+	Range   views.TrendRange
+	Samples []history.Sample
+	End     time.Time
+	Err     error
+}
+
+// AuditMsg carries a best-practice audit of Device. Err joins the reads
+// that failed; Result audits the rest.
+type AuditMsg struct {
+	Device string
+	Result audit.Result
+	Err    error
+}
+
+// AuditExportedMsg reports where an audit export was written.
+type AuditExportedMsg struct {
+	Path string
+	Err  error
+}
+
+// ConfigExportedMsg reports where a config export asked for by View was
+// written.
+type ConfigExportedMsg struct {
+	View ViewState
+	Path string
+	Err  error
+}
+
+// AppIDMsg carries the App-ID suggestions for Device's port-based rules,
+// drawn from Days days of traffic.
+type AppIDMsg struct {
+	Device      string
+	Suggestions []appid.Suggestion
+	Days        int
+	Err         error
+}
+
+// AppIDExportedMsg reports where an App-ID export was written.
+type AppIDExportedMsg struct {
+	Path string
+	Err  error
+}
+
+// CompareSourcesMsg carries the sources the Compare view can pick from.
+// Err is set when the backups could not be listed; the live sources are
+// still there.
+type CompareSourcesMsg struct {
+	Sources []views.CompareSource
+	Err     error
+}
+
+// CompareMsg carries the comparison Request asked for.
+type CompareMsg struct {
+	Request views.CompareRequestMsg
+	Result  policydiff.Result
+	Err     error
+}
+
+// APICallsMsg carries a snapshot of a connection's recent API calls,
+// newest first.
+type APICallsMsg struct {
+	Host  string
+	Calls []models.APICall
+}
+
+// ConsoleResultMsg carries the output of one console command.
+type ConsoleResultMsg struct {
+	Device string
+	Seq    int
+	Result string
+	Err    error
+}
+
 type AddressesMsg struct {
 	Items []models.AddressObject
 	Err   error
@@ -204,6 +385,13 @@ type ServicesMsg struct {
 	Err   error
 }
 
+// ObjectGroupsMsg carries the groups, EDLs and regions rule members are
+// resolved against.
+type ObjectGroupsMsg struct {
+	Groups views.ObjectGroups
+	Err    error
+}
+
 type NATPoolMsg struct {
 	Pools []models.NATPoolInfo
 	Err   error
@@ -45,6 +45,16 @@ func TestNavbarGroups_MatchesLegacyLiteral(t *testing.T) {
 			Key:   "3",
 			Items: []views.NavItem{
 				{ID: "config", Label: "Config", Key: "1"},
+				{ID: "diff", Label: "Diff", Key: "2"},
+				{ID: "backups", Label: "Backups", Key: "3"},
+				{ID: "tree", Label: "Tree", Key: "4"},
+				{ID: "console", Label: "Console", Key: "5"},
+				{ID: "calls", Label: "API", Key: "6"},
+				{ID: "alerts", Label: "Alerts", Key: "7"},
+				{ID: "audit", Label: "Audit", Key: "8"},
+				{ID: "appid", Label: "App-ID", Key: "9"},
+				{ID: "whereused", Label: "Where Used", Key: "10"},
+				{ID: "compare", Label: "Compare", Key: "11"},
 			},
 		},
 	}
@@ -73,7 +83,7 @@ func TestNavDefs_Invariants(t *testing.T) {
 			}
 		}
 	}
-	if len(seen) != 14 {
-		t.Errorf("navDefs defines %d items; want 14 (4 monitor + 9 analyze + 1 tools)", len(seen))
+	if len(seen) != 24 {
+		t.Errorf("navDefs defines %d items; want 24 (4 monitor + 9 analyze + 11 tools)", len(seen))
 	}
 }
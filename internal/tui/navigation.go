@@ -75,7 +75,7 @@ var navDefs = []navGroupDef{
 				hasData: func(m *Model) bool { return m.policies.HasData() },
 				fetch: func(m *Model) tea.Cmd {
 					m.policies = m.policies.SetLoading(true)
-					return m.fetchPolicies()
+					return tea.Batch(m.fetchPolicies(), m.fetchRuleObjects())
 				},
 			}},
 			{id: "nat", label: "NAT", navTarget: navTarget{
@@ -83,7 +83,7 @@ var navDefs = []navGroupDef{
 				hasData: func(m *Model) bool { return m.natPolicies.HasData() },
 				fetch: func(m *Model) tea.Cmd {
 					m.natPolicies = m.natPolicies.SetLoading(true)
-					return m.fetchNATPolicies()
+					return tea.Batch(m.fetchNATPolicies(), m.fetchRuleObjects())
 				},
 			}},
 			{id: "objects", label: "Objects", navTarget: navTarget{
@@ -168,6 +168,104 @@ var navDefs = []navGroupDef{
 				hasData:   func(m *Model) bool { return m.configDashboard.HasData() },
 				fetch:     func(m *Model) tea.Cmd { return m.fetchConfigDashboardData() },
 			}},
+			{id: "diff", label: "Diff", navTarget: navTarget{
+				view:    ViewConfigDiff,
+				hasData: func(m *Model) bool { return m.configDiff.HasData() },
+				fetch: func(m *Model) tea.Cmd {
+					m.configDiff = m.configDiff.SetLoading(true)
+					return m.fetchConfigDiff()
+				},
+			}},
+			{id: "backups", label: "Backups", navTarget: navTarget{
+				view: ViewBackups,
+				hasData: func(m *Model) bool {
+					return m.backups.HasData() && m.backups.Device() == m.backupDevice()
+				},
+				fetch: func(m *Model) tea.Cmd {
+					m.backups = m.backups.SetDevice(m.backupDevice()).SetLoading(true)
+					return m.fetchBackups()
+				},
+			}},
+			{id: "tree", label: "Tree", navTarget: navTarget{
+				view: ViewConfigTree,
+				hasData: func(m *Model) bool {
+					return m.configTree.Device() == m.backupDevice()
+				},
+				// The top of the tree needs no fetch; branches load as
+				// they are expanded.
+				fetch: func(m *Model) tea.Cmd {
+					m.configTree = m.configTree.SetDevice(m.backupDevice())
+					return nil
+				},
+			}},
+			{id: "console", label: "Console", navTarget: navTarget{
+				view: ViewConsole,
+				hasData: func(m *Model) bool {
+					return m.console.Device() == m.backupDevice()
+				},
+				// Nothing to fetch: the console only runs what is typed.
+				fetch: func(m *Model) tea.Cmd {
+					m.openConsole()
+					return nil
+				},
+			}},
+			{id: "calls", label: "API", navTarget: navTarget{
+				view: ViewAPICalls,
+				// Always take a fresh snapshot: calls pile up in the
+				// background while other views are open.
+				hasData: func(m *Model) bool { return false },
+				fetch: func(m *Model) tea.Cmd {
+					m.apiCalls = m.apiCalls.SetLoading(true)
+					return m.fetchAPICalls()
+				},
+			}},
+			{id: "alerts", label: "Alerts", navTarget: navTarget{
+				view: ViewAlerts,
+				// The periodic check keeps the view current; opening it
+				// only marks the events as seen.
+				hasData: func(m *Model) bool { return true },
+				fetch: func(m *Model) tea.Cmd {
+					m.alertsUnseen = 0
+					return nil
+				},
+			}},
+			{id: "audit", label: "Audit", navTarget: navTarget{
+				view: ViewAudit,
+				hasData: func(m *Model) bool {
+					return m.auditView.HasData() && m.auditView.Device() == m.backupDevice()
+				},
+				fetch: func(m *Model) tea.Cmd {
+					m.auditView = m.auditView.SetDevice(m.backupDevice()).SetLoading(true)
+					return m.fetchAudit()
+				},
+			}},
+			{id: "appid", label: "App-ID", navTarget: navTarget{
+				view: ViewAppID,
+				hasData: func(m *Model) bool {
+					return m.appIDView.HasData() && m.appIDView.Device() == m.backupDevice()
+				},
+				fetch: func(m *Model) tea.Cmd {
+					m.appIDView = m.appIDView.SetDevice(m.backupDevice()).SetLoading(true)
+					return m.fetchAppID()
+				},
+			}},
+			{id: "whereused", label: "Where Used", navTarget: navTarget{
+				view:    ViewWhereUsed,
+				hasData: func(m *Model) bool { return m.whereUsedHasData() },
+				fetch:   func(m *Model) tea.Cmd { return m.fetchWhereUsedData() },
+			}},
+			{id: "compare", label: "Compare", navTarget: navTarget{
+				view: ViewCompare,
+				// List the sources afresh each time, as handleSwitchView does.
+				hasData: func(m *Model) bool {
+					_, ok := m.compare.Comparison()
+					return ok
+				},
+				fetch: func(m *Model) tea.Cmd {
+					m.compare = m.compare.SetLoading(true)
+					return m.fetchCompareSources()
+				},
+			}},
 		},
 	},
 }
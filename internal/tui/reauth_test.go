@@ -0,0 +1,87 @@
+package tui
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/jp2195/pyre/internal/api"
+	"github.com/jp2195/pyre/internal/auth"
+	"github.com/jp2195/pyre/internal/config"
+	"github.com/jp2195/pyre/internal/testutil"
+)
+
+// newRevokedModel returns a model on the policies view, connected to a
+// mock that has just revoked the connection's API key.
+func newRevokedModel(t *testing.T) (Model, *auth.Connection) {
+	t.Helper()
+	mock := testutil.NewMockPANOS()
+	t.Cleanup(mock.Close)
+	m := newTestModel(t, ViewPolicies)
+	conn, err := m.session.AddConnection(mock.Host(), &config.ConnectionConfig{Insecure: true}, "k")
+	if err != nil {
+		t.Fatalf("AddConnection: %v", err)
+	}
+	m.state.UpdateConnection(mock.Host(), "admin")
+	mock.Device.RevokeKeys()
+	return m, conn
+}
+
+// startFetch runs a fetch in the background, as Bubble Tea would.
+func startFetch(cmd tea.Cmd) <-chan tea.Msg {
+	out := make(chan tea.Msg, 1)
+	go func() { out <- cmd() }()
+	return out
+}
+
+func receive(t *testing.T, ch <-chan tea.Msg) tea.Msg {
+	t.Helper()
+	select {
+	case msg := <-ch:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a message")
+		return nil
+	}
+}
+
+func TestReauth_LoginResumesPausedFetch(t *testing.T) {
+	m, conn := newRevokedModel(t)
+	fetched := startFetch(m.fetchAddresses(conn))
+
+	updated, _ := m.Update(receive(t, startFetch(m.waitForReauth())))
+	m = updated.(Model)
+	if m.currentView != ViewLogin || m.login.Host() != conn.Host || m.login.Username() != "admin" {
+		t.Fatalf("view = %v, host %q, user %q; want the login form pre-filled for the connection",
+			m.currentView, m.login.Host(), m.login.Username())
+	}
+
+	m = typeInto(t, m, "admin")
+	updated, cmd := m.handleLoginKeys(tea.KeyPressMsg{Code: tea.KeyEnter})
+	m = runCmd(t, updated.(Model), cmd)
+
+	if m.currentView != ViewPolicies || conn.APIKey == "k" || len(m.session.ListConnections()) != 1 {
+		t.Fatalf("view = %v, key %q; want back on policies with the new key on the same connection", m.currentView, conn.APIKey)
+	}
+	if msg := receive(t, fetched).(AddressesMsg); msg.Err != nil {
+		t.Errorf("paused fetch should be retried with the new key: %v", msg.Err)
+	}
+}
+
+func TestReauth_EscGivesUp(t *testing.T) {
+	m, conn := newRevokedModel(t)
+	fetched := startFetch(m.fetchAddresses(conn))
+
+	updated, _ := m.Update(receive(t, startFetch(m.waitForReauth())))
+	updated, _ = updated.(Model).handleLoginKeys(tea.KeyPressMsg{Code: tea.KeyEscape})
+	m = updated.(Model)
+
+	if m.currentView != ViewPolicies || conn.ReauthPending() {
+		t.Errorf("view = %v, pending = %v; esc should return to policies and release the fetch", m.currentView, conn.ReauthPending())
+	}
+	if msg := receive(t, fetched).(AddressesMsg); !errors.Is(msg.Err, api.ErrUnauthorized) {
+		t.Errorf("err = %v; want ErrUnauthorized", msg.Err)
+	}
+}
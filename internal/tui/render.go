@@ -102,6 +102,26 @@ func (m Model) currentViewName() string {
 		return "Analyze/Logs"
 	case ViewObjects:
 		return "Analyze/Objects"
+	case ViewConfigDiff:
+		return "Tools/Diff"
+	case ViewBackups:
+		return "Tools/Backups"
+	case ViewConfigTree:
+		return "Tools/Tree"
+	case ViewConsole:
+		return "Tools/Console"
+	case ViewAPICalls:
+		return "Tools/API"
+	case ViewAlerts:
+		return "Tools/Alerts"
+	case ViewAudit:
+		return "Tools/Audit"
+	case ViewAppID:
+		return "Tools/App-ID"
+	case ViewWhereUsed:
+		return "Tools/Where Used"
+	case ViewCompare:
+		return "Tools/Compare"
 	case ViewPicker:
 		return "Connections"
 	case ViewDevicePicker:
@@ -125,6 +145,12 @@ func (m Model) renderFooter() string {
 		errLine := ErrorStyle.Render("Error: " + m.err.Error())
 		sections = append(sections, errLine)
 	}
+	if banner := m.alertBanner(); banner != "" {
+		sections = append(sections, banner)
+	}
+	if notice := m.staleNotice(); notice != "" {
+		sections = append(sections, WarningStyle.Render(notice))
+	}
 
 	// Show navigation hint based on current state
 	// Get active group key for hint
@@ -64,6 +64,10 @@ func TestCurrentViewName_AllViews(t *testing.T) {
 		{ViewGPUsers, views.DashboardMain, "Analyze/GP Users"},
 		{ViewLogs, views.DashboardMain, "Analyze/Logs"},
 		{ViewObjects, views.DashboardMain, "Analyze/Objects"},
+		{ViewConfigDiff, views.DashboardMain, "Tools/Diff"},
+		{ViewBackups, views.DashboardMain, "Tools/Backups"},
+		{ViewConfigTree, views.DashboardMain, "Tools/Tree"},
+		{ViewConsole, views.DashboardMain, "Tools/Console"},
 		{ViewPicker, views.DashboardMain, "Connections"},
 		{ViewDevicePicker, views.DashboardMain, "Connections/Devices"},
 		{ViewCommandPalette, views.DashboardMain, "Commands"},
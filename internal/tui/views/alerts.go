@@ -0,0 +1,189 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/jp2195/pyre/internal/models"
+)
+
+// maxActiveShown is how many active breaches the view lists above the
+// history.
+const maxActiveShown = 5
+
+// AlertsModel is the alert history: the breaches active now, and every
+// breach, recovery, and change seen this session, newest first.
+type AlertsModel struct {
+	TableBase
+	active   []models.AlertEvent
+	history  []models.AlertEvent
+	interval time.Duration
+	checked  time.Time // last check; zero before the first
+	err      error     // the last check's failure, or invalid alert settings
+}
+
+func NewAlertsModel() AlertsModel {
+	return AlertsModel{TableBase: NewTableBase("")}
+}
+
+func (m AlertsModel) SetSize(width, height int) AlertsModel {
+	m.TableBase = m.TableBase.SetSize(width, height)
+	m.EnsureCursorValid(len(m.history))
+	m.EnsureVisible(m.visibleRows())
+	return m
+}
+
+func (m AlertsModel) SetLoading(loading bool) AlertsModel {
+	m.TableBase = m.TableBase.SetLoading(loading)
+	return m
+}
+
+// IsLoading reports whether a check is running.
+func (m AlertsModel) IsLoading() bool {
+	return m.Loading
+}
+
+// SetSpinnerFrame updates the current spinner animation frame.
+func (m AlertsModel) SetSpinnerFrame(frame string) AlertsModel {
+	m.TableBase = m.TableBase.SetSpinnerFrame(frame)
+	return m
+}
+
+// IsFilterMode is always false: the history has no filter input.
+func (m AlertsModel) IsFilterMode() bool {
+	return false
+}
+
+// SetInterval records how often rules are checked, for the banner; 0
+// means alerting is off.
+func (m AlertsModel) SetInterval(d time.Duration) AlertsModel {
+	m.interval = d
+	return m
+}
+
+// SetAlerts replaces the active breaches and the history (newest first)
+// after a check at checked that failed with err, if it did.
+func (m AlertsModel) SetAlerts(active, history []models.AlertEvent, checked time.Time, err error) AlertsModel {
+	m.active = active
+	m.history = history
+	m.checked = checked
+	m.err = err
+	m.Loading = false
+	m.EnsureCursorValid(len(m.history))
+	m.EnsureVisible(m.visibleRows())
+	return m
+}
+
+// SetError shows err, such as invalid alert settings.
+func (m AlertsModel) SetError(err error) AlertsModel {
+	m.err = err
+	m.Loading = false
+	return m
+}
+
+// visibleRows leaves room for the banner, the active breaches, and the
+// table header.
+func (m AlertsModel) visibleRows() int {
+	return m.VisibleRows(11+maxActiveShown, 0)
+}
+
+func (m AlertsModel) Update(msg tea.Msg) (AlertsModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return m, nil
+	}
+	base, handled, cmd := m.HandleNavigation(keyMsg, len(m.history), m.visibleRows())
+	if handled {
+		m.TableBase = base
+	}
+	return m, cmd
+}
+
+func (m AlertsModel) View() string {
+	if m.Width == 0 {
+		return RenderLoadingInline(m.SpinnerFrame, "Loading...")
+	}
+
+	titleStyle := ViewTitleStyle.MarginBottom(1)
+	panelStyle := ViewPanelStyle.Width(m.Width - 4)
+	width := max(m.Width-12, 40)
+
+	var b strings.Builder
+	status := "off"
+	if m.interval > 0 {
+		status = "every " + m.interval.String()
+		if !m.checked.IsZero() {
+			status += ", last " + m.checked.Local().Format("15:04:05")
+		}
+	}
+	info := BannerInfoStyle.Render(fmt.Sprintf(" [%d active | %d events | checks %s | r: check now]",
+		len(m.active), len(m.history), status))
+	b.WriteString(titleStyle.Render("Alerts") + info)
+	b.WriteString("\n")
+
+	if m.err != nil {
+		b.WriteString(ErrorMsgStyle.Render(truncateEllipsis("Error: "+m.err.Error(), width)))
+		b.WriteString("\n")
+	}
+	if m.interval == 0 {
+		b.WriteString(EmptyMsgStyle.Render("Alerting is off: no rules are set under settings.alerts"))
+		return panelStyle.Render(b.String())
+	}
+	if m.Loading && m.checked.IsZero() {
+		b.WriteString(RenderLoadingInline(m.SpinnerFrame, "Checking rules..."))
+		return panelStyle.Render(b.String())
+	}
+
+	if len(m.active) == 0 {
+		b.WriteString(StatusActiveStyle.Render("No active alerts"))
+		b.WriteString("\n")
+	}
+	for i, ev := range m.active {
+		if i == maxActiveShown {
+			b.WriteString(DetailDimStyle.Render(fmt.Sprintf("  … and %d more", len(m.active)-maxActiveShown)))
+			b.WriteString("\n")
+			break
+		}
+		line := fmt.Sprintf("  since %s  %-20s  %s", ev.Time.Local().Format("15:04:05"), truncate(ev.Device, 20), ev.Message)
+		b.WriteString(ErrorMsgStyle.Render(truncateEllipsis(line, width)))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	if len(m.history) == 0 {
+		b.WriteString(EmptyMsgStyle.Render("Nothing has fired this session"))
+		return panelStyle.Render(b.String())
+	}
+
+	header := fmt.Sprintf("  %-8s  %-20s  %-8s  %-11s  %s", "Time", "Device", "State", "Rule", "Message")
+	b.WriteString(TableHeaderStyle.Render(truncateEllipsis(header, width)))
+	b.WriteString("\n")
+
+	visible := m.visibleRows()
+	end := min(m.Offset+visible, len(m.history))
+	for i := m.Offset; i < end; i++ {
+		ev := m.history[i]
+		row := fmt.Sprintf("  %-8s  %-20s  %-8s  %-11s  %s", ev.Time.Local().Format("15:04:05"),
+			truncate(ev.Device, 20), ev.State, ev.Rule, ev.Message)
+		row = truncateEllipsis(row, width)
+		switch {
+		case i == m.Cursor:
+			b.WriteString(TableSelectedRowStyle().Render(lipgloss.NewStyle().Width(width).Render(row)))
+		case ev.State == models.AlertFiring:
+			b.WriteString(ErrorMsgStyle.Render(row))
+		case ev.State == models.AlertChanged:
+			b.WriteString(StatusWarningStyle.Render(row))
+		default:
+			b.WriteString(DetailValueStyle.Render(row))
+		}
+		b.WriteString("\n")
+	}
+	if len(m.history) > visible {
+		b.WriteString(DetailDimStyle.Render(fmt.Sprintf("  Showing %d-%d of %d", m.Offset+1, end, len(m.history))))
+	}
+	return panelStyle.Render(b.String())
+}
@@ -0,0 +1,43 @@
+package views
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jp2195/pyre/internal/models"
+)
+
+func TestAlertsModel_ListsActiveAndHistory(t *testing.T) {
+	InitStyles()
+	now := time.Now()
+	firing := models.AlertEvent{Time: now, Device: "fw1", Rule: "tunnel", Subject: "to-branch",
+		State: models.AlertFiring, Message: "IPSec tunnel to-branch is down"}
+	resolved := models.AlertEvent{Time: now, Device: "fw1", Rule: "cpu", Subject: "management",
+		State: models.AlertResolved, Message: "Resolved: Management CPU at 91%"}
+
+	m := NewAlertsModel().SetInterval(time.Minute).SetSize(160, 40).
+		SetAlerts([]models.AlertEvent{firing}, []models.AlertEvent{resolved, firing}, now, nil)
+	view := stripANSI(m.View())
+	if !strings.Contains(view, "1 active | 2 events | checks every 1m0s") {
+		t.Errorf("banner missing counts:\n%s", view)
+	}
+	if strings.Count(view, "IPSec tunnel to-branch is down") != 2 || !strings.Contains(view, "resolved") {
+		t.Errorf("the breach should be listed as active and in the history:\n%s", view)
+	}
+
+	m = m.SetAlerts(nil, nil, now, errors.New("ipsec: timeout"))
+	view = stripANSI(m.View())
+	if !strings.Contains(view, "No active alerts") || !strings.Contains(view, "ipsec: timeout") {
+		t.Errorf("a failed check should show its error:\n%s", view)
+	}
+}
+
+func TestAlertsModel_Off(t *testing.T) {
+	InitStyles()
+	view := stripANSI(NewAlertsModel().SetSize(120, 30).View())
+	if !strings.Contains(view, "Alerting is off") {
+		t.Errorf("with no rules the view should say alerting is off:\n%s", view)
+	}
+}
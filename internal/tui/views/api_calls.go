@@ -0,0 +1,360 @@
+package views
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/jp2195/pyre/internal/models"
+	"github.com/jp2195/pyre/internal/pancfg"
+)
+
+// SlowCallThreshold is the round trip past which the inspector counts a
+// call as slow.
+const SlowCallThreshold = time.Second
+
+// maxSlowGroups is how many slow requests the summary lists.
+const maxSlowGroups = 3
+
+// APICallsModel is the API call inspector: the active connection's recent
+// requests with their timing, a summary of the slow ones, and the response
+// to any of them.
+type APICallsModel struct {
+	TableBase
+	host   string
+	calls  []models.APICall
+	loaded bool
+
+	showBody bool
+	call     models.APICall // the call whose response is shown
+	body     []consoleLine
+	scroll   int // first response line shown
+}
+
+func NewAPICallsModel() APICallsModel {
+	return APICallsModel{TableBase: NewTableBase("")}
+}
+
+func (m APICallsModel) SetSize(width, height int) APICallsModel {
+	m.TableBase = m.TableBase.SetSize(width, height)
+	m.EnsureCursorValid(len(m.calls))
+	m.EnsureVisible(m.visibleRows())
+	return m
+}
+
+func (m APICallsModel) SetLoading(loading bool) APICallsModel {
+	m.TableBase = m.TableBase.SetLoading(loading)
+	return m
+}
+
+// IsLoading reports whether a snapshot of the calls is being taken.
+func (m APICallsModel) IsLoading() bool {
+	return m.Loading
+}
+
+// SetSpinnerFrame updates the current spinner animation frame.
+func (m APICallsModel) SetSpinnerFrame(frame string) APICallsModel {
+	m.TableBase = m.TableBase.SetSpinnerFrame(frame)
+	return m
+}
+
+// HasData returns true once a snapshot has been loaded.
+func (m APICallsModel) HasData() bool {
+	return m.loaded
+}
+
+// IsFilterMode is always false: the inspector has no filter input.
+func (m APICallsModel) IsFilterMode() bool {
+	return false
+}
+
+// SetCalls replaces the list with a snapshot of host's recent calls,
+// newest first. The cursor keeps its row unless the host changed.
+func (m APICallsModel) SetCalls(host string, calls []models.APICall) APICallsModel {
+	if host != m.host {
+		m.ResetPosition()
+		m.showBody = false
+	}
+	m.host = host
+	m.calls = calls
+	m.loaded = true
+	m.Loading = false
+	m.EnsureCursorValid(len(m.calls))
+	m.EnsureVisible(m.visibleRows())
+	return m
+}
+
+// visibleRows leaves room for the banner, the slow-call summary, and the
+// table header.
+func (m APICallsModel) visibleRows() int {
+	return m.VisibleRows(12+maxSlowGroups, 0)
+}
+
+// bodyRows is the height of the response pane.
+func (m APICallsModel) bodyRows() int {
+	return max(m.Height-8, 1)
+}
+
+func (m APICallsModel) Update(msg tea.Msg) (APICallsModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return m, nil
+	}
+	if m.showBody {
+		m.updateBody(keyMsg)
+		return m, nil
+	}
+
+	if keyMsg.String() == "enter" && m.Cursor < len(m.calls) {
+		m.call = m.calls[m.Cursor]
+		m.body = formatResponse(m.call.Body)
+		m.scroll = 0
+		m.showBody = true
+		return m, nil
+	}
+	base, handled, cmd := m.HandleNavigation(keyMsg, len(m.calls), m.visibleRows())
+	if handled {
+		m.TableBase = base
+	}
+	return m, cmd
+}
+
+func (m *APICallsModel) updateBody(key tea.KeyPressMsg) {
+	last := max(len(m.body)-m.bodyRows(), 0)
+	switch key.String() {
+	case "esc", "enter":
+		m.showBody = false
+	case "j", "down":
+		m.scroll++
+	case "k", "up":
+		m.scroll--
+	case "ctrl+d", "pgdown":
+		m.scroll += m.bodyRows() / 2
+	case "ctrl+u", "pgup":
+		m.scroll -= m.bodyRows() / 2
+	case "g", "home":
+		m.scroll = 0
+	case "G", "end":
+		m.scroll = last
+	}
+	m.scroll = max(0, min(m.scroll, last))
+}
+
+// formatResponse renders a response body as indented XML or JSON (REST
+// API calls), or as plain lines if it is neither.
+func formatResponse(body string) []consoleLine {
+	if strings.TrimSpace(body) == "" {
+		return []consoleLine{{kind: consoleText, text: "(empty response)"}}
+	}
+	var lines []consoleLine
+	if root, err := pancfg.ParseElement(body); err == nil {
+		for _, l := range pancfg.XMLLines(root, 0) {
+			lines = append(lines, consoleLine{kind: consoleXML, depth: l.Depth, text: l.Text})
+		}
+		return lines
+	}
+	var indented bytes.Buffer
+	if json.Indent(&indented, []byte(body), "", "  ") == nil {
+		body = indented.String()
+	}
+	for l := range strings.SplitSeq(strings.TrimRight(body, "\n"), "\n") {
+		lines = append(lines, consoleLine{kind: consoleText, text: l})
+	}
+	return lines
+}
+
+// slowGroup aggregates the calls for one request (type and path). Its
+// times run from when a call was queued to when its response arrived.
+type slowGroup struct {
+	label          string
+	count, slow    int
+	longest, total time.Duration
+	waited         time.Duration
+}
+
+// callLabel names a call's request: its type, action, and path.
+func callLabel(c models.APICall) string {
+	return strings.Join(slices.DeleteFunc([]string{c.Type, c.Action, c.Path}, func(s string) bool { return s == "" }), " ")
+}
+
+// callStats summarizes calls: the failures, the median and longest round
+// trip, and the requests with a slow call, slowest first.
+func callStats(calls []models.APICall) (failed int, median, longest time.Duration, slow []slowGroup) {
+	if len(calls) == 0 {
+		return 0, 0, 0, nil
+	}
+	durations := make([]time.Duration, 0, len(calls))
+	groups := make(map[string]*slowGroup)
+	for _, c := range calls {
+		if c.Err != "" {
+			failed++
+		}
+		durations = append(durations, c.Duration)
+		label := callLabel(c)
+		g := groups[label]
+		if g == nil {
+			g = &slowGroup{label: label}
+			groups[label] = g
+		}
+		elapsed := c.Wait + c.Duration
+		g.count++
+		g.total += elapsed
+		g.waited += c.Wait
+		g.longest = max(g.longest, elapsed)
+		if elapsed >= SlowCallThreshold {
+			g.slow++
+		}
+	}
+	slices.Sort(durations)
+	median, longest = durations[len(durations)/2], durations[len(durations)-1]
+
+	for _, g := range groups {
+		if g.slow > 0 {
+			slow = append(slow, *g)
+		}
+	}
+	slices.SortFunc(slow, func(a, b slowGroup) int {
+		return cmp.Or(cmp.Compare(b.longest, a.longest), strings.Compare(a.label, b.label))
+	})
+	return failed, median, longest, slow
+}
+
+// blame says where a slow request's time went: waiting behind pyre's own
+// request limits, or on the device.
+func (g slowGroup) blame() string {
+	if g.waited > g.total-g.waited {
+		return "queued in pyre"
+	}
+	return "device"
+}
+
+// formatLatency renders a duration as "850ms" or "2.4s".
+func formatLatency(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
+
+func (m APICallsModel) View() string {
+	if m.Width == 0 {
+		return RenderLoadingInline(m.SpinnerFrame, "Loading...")
+	}
+	if m.showBody {
+		return m.viewBody()
+	}
+
+	titleStyle := ViewTitleStyle.MarginBottom(1)
+	panelStyle := ViewPanelStyle.Width(m.Width - 4)
+
+	var b strings.Builder
+	info := BannerInfoStyle.Render(fmt.Sprintf(" [%s | %d calls | enter: response | r: refresh]", m.host, len(m.calls)))
+	b.WriteString(titleStyle.Render("API Calls") + info)
+	b.WriteString("\n")
+
+	if m.Loading || !m.loaded {
+		b.WriteString(RenderLoadingInline(m.SpinnerFrame, "Loading calls..."))
+		return panelStyle.Render(b.String())
+	}
+	if len(m.calls) == 0 {
+		b.WriteString(EmptyMsgStyle.Render("No API calls yet on this connection"))
+		return panelStyle.Render(b.String())
+	}
+
+	width := max(m.Width-12, 40)
+	failed, median, longest, slow := callStats(m.calls)
+	summary := fmt.Sprintf("Median %s · slowest %s · %d failed", formatLatency(median), formatLatency(longest), failed)
+	b.WriteString(DetailValueStyle.Render(summary))
+	b.WriteString("\n")
+	if len(slow) == 0 {
+		b.WriteString(DetailDimStyle.Render(fmt.Sprintf("No calls over %s", formatLatency(SlowCallThreshold))))
+		b.WriteString("\n")
+	}
+	for i, g := range slow {
+		if i == maxSlowGroups {
+			b.WriteString(DetailDimStyle.Render(fmt.Sprintf("  … and %d more slow requests", len(slow)-maxSlowGroups)))
+			b.WriteString("\n")
+			break
+		}
+		line := fmt.Sprintf("  %6s max  %6s avg  %d/%d slow  %-14s  %s", formatLatency(g.longest),
+			formatLatency(g.total/time.Duration(g.count)), g.slow, g.count, g.blame(), g.label)
+		b.WriteString(StatusWarningStyle.Render(truncateEllipsis(line, width)))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	header := fmt.Sprintf("  %-8s  %-6s  %-12s  %-12s  %7s  %7s  %8s  %s",
+		"Sent", "Type", "Target", "Status", "Queued", "Took", "Size", "Request")
+	b.WriteString(TableHeaderStyle.Render(truncateEllipsis(header, width)))
+	b.WriteString("\n")
+
+	visible := m.visibleRows()
+	end := min(m.Offset+visible, len(m.calls))
+	for i := m.Offset; i < end; i++ {
+		c := m.calls[i]
+		target := c.Target
+		if target == "" {
+			target = "-"
+		}
+		row := fmt.Sprintf("  %-8s  %-6s  %-12s  %-12s  %7s  %7s  %8s  %s",
+			c.Time.Local().Format("15:04:05"), c.Type, truncate(target, 12), truncate(c.Status, 12),
+			formatLatency(c.Wait), formatLatency(c.Duration), formatBytes(int64(c.Size)),
+			strings.TrimSpace(c.Action+" "+c.Path))
+		row = truncateEllipsis(row, width)
+		switch {
+		case i == m.Cursor:
+			b.WriteString(TableSelectedRowStyle().Render(lipgloss.NewStyle().Width(width).Render(row)))
+		case c.Err != "":
+			b.WriteString(ErrorMsgStyle.Render(row))
+		case c.Duration+c.Wait >= SlowCallThreshold:
+			b.WriteString(StatusWarningStyle.Render(row))
+		default:
+			b.WriteString(DetailValueStyle.Render(row))
+		}
+		b.WriteString("\n")
+	}
+	if len(m.calls) > visible {
+		b.WriteString(DetailDimStyle.Render(fmt.Sprintf("  Showing %d-%d of %d", m.Offset+1, end, len(m.calls))))
+	}
+	return panelStyle.Render(b.String())
+}
+
+func (m APICallsModel) viewBody() string {
+	titleStyle := ViewTitleStyle.MarginBottom(1)
+	panelStyle := ViewPanelStyle.Width(m.Width - 4)
+	width := max(m.Width-12, 20)
+	c := m.call
+
+	var b strings.Builder
+	info := BannerInfoStyle.Render(fmt.Sprintf(" [%s | queued %s, took %s | %s | j/k: scroll | esc: back]",
+		c.Status, formatLatency(c.Wait), formatLatency(c.Duration), formatBytes(int64(c.Size))))
+	b.WriteString(titleStyle.Render("Response") + info)
+	b.WriteString("\n")
+	b.WriteString(DetailDimStyle.Render(truncateEllipsis(callLabel(c), width)))
+	b.WriteString("\n")
+	if c.Err != "" {
+		b.WriteString(ErrorMsgStyle.Render(truncateEllipsis("Error: "+c.Err, width)))
+		b.WriteString("\n")
+	}
+	if c.Truncated {
+		b.WriteString(StatusWarningStyle.Render(fmt.Sprintf("Showing the first %s of the response", formatBytes(int64(len(c.Body))))))
+		b.WriteString("\n")
+	}
+
+	end := min(m.scroll+m.bodyRows(), len(m.body))
+	for _, l := range m.body[m.scroll:end] {
+		b.WriteString(renderConsoleLine(l, width))
+		b.WriteString("\n")
+	}
+	if len(m.body) > m.bodyRows() {
+		b.WriteString(DetailDimStyle.Render(fmt.Sprintf("  Lines %d-%d of %d", m.scroll+1, end, len(m.body))))
+	}
+	return panelStyle.Render(b.String())
+}
@@ -0,0 +1,66 @@
+package views
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/jp2195/pyre/internal/models"
+)
+
+func testCalls() []models.APICall {
+	now := time.Now()
+	return []models.APICall{
+		{Time: now, Type: "op", Path: "<show><system><info/></system></show>", Status: "success",
+			Duration: 80 * time.Millisecond, Size: 64,
+			Body: `<response status="success"><result><system><hostname>fw1</hostname></system></result></response>`},
+		{Time: now.Add(-time.Second), Type: "config", Action: "get", Path: "/config/shared/address", Status: "success",
+			Duration: 3 * time.Second, Size: 2048},
+		{Time: now.Add(-2 * time.Second), Type: "op", Path: "<show><session><all/></session></show>", Status: "success",
+			Wait: 4 * time.Second, Duration: 200 * time.Millisecond},
+		{Time: now.Add(-3 * time.Second), Type: "op", Path: "<show><clock/></show>", Status: "HTTP 503", Err: "service unavailable"},
+	}
+}
+
+func TestAPICallsModel_SummarizesSlowCalls(t *testing.T) {
+	InitStyles()
+	m := NewAPICallsModel().SetSize(160, 40).SetCalls("fw1", testCalls())
+
+	view := stripANSI(m.View())
+	if !strings.Contains(view, "slowest 3.0s · 1 failed") {
+		t.Errorf("summary missing:\n%s", view)
+	}
+	queued := strings.Index(view, "4.2s max    4.2s avg  1/1 slow  queued in pyre  op <show><session><all/></session></show>")
+	device := strings.Index(view, "3.0s max    3.0s avg  1/1 slow  device          config get /config/shared/address")
+	if device < 0 || queued < 0 || device < queued {
+		t.Errorf("slow requests should be listed slowest first with their cause:\n%s", view)
+	}
+	if strings.Count(view, "<show><system><info/></system></show>") != 1 {
+		t.Errorf("a fast call should only appear in the table:\n%s", view)
+	}
+}
+
+func TestAPICallsModel_ShowsResponse(t *testing.T) {
+	InitStyles()
+	m := NewAPICallsModel().SetSize(160, 40).SetCalls("fw1", testCalls())
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	view := stripANSI(m.View())
+	if !strings.Contains(view, "Response") || !strings.Contains(view, "<hostname>fw1</hostname>") {
+		t.Errorf("enter should show the pretty-printed response:\n%s", view)
+	}
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+	if view := stripANSI(m.View()); !strings.Contains(view, "API Calls") {
+		t.Errorf("esc should return to the list:\n%s", view)
+	}
+
+	// A new snapshot of the same host keeps the cursor.
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'j', Text: "j"})
+	m = m.SetCalls("fw1", testCalls())
+	if m.Cursor != 1 {
+		t.Errorf("Cursor = %d after a refresh, want 1", m.Cursor)
+	}
+}
@@ -0,0 +1,284 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/jp2195/pyre/internal/appid"
+)
+
+// AppIDExportRequestMsg asks the app to export the suggestions shown as
+// Markdown.
+type AppIDExportRequestMsg struct{}
+
+// maxSeenShown is how many of the selected rule's applications are listed
+// under the table.
+const maxSeenShown = 5
+
+// AppIDModel is the App-ID migration assistant: the active connection's
+// port-based rules, the applications seen on each, and the App-ID rule
+// that would replace it.
+type AppIDModel struct {
+	TableBase
+	device      string
+	suggestions []appid.Suggestion
+	days        int
+	loaded      bool
+
+	exporting bool
+	notice    string
+	warn      bool // notice is a problem rather than a confirmation
+}
+
+func NewAppIDModel() AppIDModel {
+	return AppIDModel{TableBase: NewTableBase(""), days: appid.DefaultDays}
+}
+
+func (m AppIDModel) SetSize(width, height int) AppIDModel {
+	m.TableBase = m.TableBase.SetSize(width, height)
+	m.EnsureCursorValid(len(m.suggestions))
+	m.EnsureVisible(m.visibleRows())
+	return m
+}
+
+func (m AppIDModel) SetLoading(loading bool) AppIDModel {
+	m.TableBase = m.TableBase.SetLoading(loading)
+	return m
+}
+
+// IsLoading reports whether a lookup or export is in flight.
+func (m AppIDModel) IsLoading() bool {
+	return m.Loading || m.exporting
+}
+
+// SetSpinnerFrame updates the current spinner animation frame.
+func (m AppIDModel) SetSpinnerFrame(frame string) AppIDModel {
+	m.TableBase = m.TableBase.SetSpinnerFrame(frame)
+	return m
+}
+
+// HasData returns true once a lookup has finished.
+func (m AppIDModel) HasData() bool {
+	return m.loaded
+}
+
+// IsFilterMode is always false: the rules have no filter input.
+func (m AppIDModel) IsFilterMode() bool {
+	return false
+}
+
+// Device is the device the suggestions shown are for (see devicefile.Device).
+func (m AppIDModel) Device() string {
+	return m.device
+}
+
+// Suggestions are the suggestions shown.
+func (m AppIDModel) Suggestions() []appid.Suggestion {
+	return m.suggestions
+}
+
+// Days is how many days of traffic the suggestions are drawn from.
+func (m AppIDModel) Days() int {
+	return m.days
+}
+
+// SetDevice switches to another device, clearing the previous device's
+// suggestions.
+func (m AppIDModel) SetDevice(device string) AppIDModel {
+	if device == m.device {
+		return m
+	}
+	m.device = device
+	m.suggestions = nil
+	m.loaded = false
+	m.notice = ""
+	m.Err = nil
+	m.ResetPosition()
+	return m
+}
+
+// SetSuggestions shows the suggestions for device, drawn from days days
+// of traffic. Results for a device the view has since moved away from
+// are dropped.
+func (m AppIDModel) SetSuggestions(device string, suggestions []appid.Suggestion, days int, err error) AppIDModel {
+	if device != m.device {
+		return m
+	}
+	m.suggestions = suggestions
+	m.days = days
+	m.Err = err
+	m.Loading = false
+	m.loaded = true
+	m.EnsureCursorValid(len(m.suggestions))
+	m.EnsureVisible(m.visibleRows())
+	return m
+}
+
+// SetExporting marks an export as in flight.
+func (m AppIDModel) SetExporting() AppIDModel {
+	m.exporting = true
+	m.notice = ""
+	return m
+}
+
+// SetExported reports the outcome of an export.
+func (m AppIDModel) SetExported(path string, err error) AppIDModel {
+	m.exporting = false
+	if err != nil {
+		m.notice, m.warn = "Export failed: "+err.Error(), true
+		return m
+	}
+	m.notice, m.warn = "Exported to "+path, false
+	return m
+}
+
+// visibleRows leaves room for the banner, notices, table header, and the
+// selected rule's applications and commands.
+func (m AppIDModel) visibleRows() int {
+	return m.VisibleRows(21+maxSeenShown, 0)
+}
+
+func (m AppIDModel) Update(msg tea.Msg) (AppIDModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return m, nil
+	}
+	if keyMsg.String() == "e" {
+		if m.exporting || !m.loaded || m.Err != nil {
+			return m, nil
+		}
+		m = m.SetExporting()
+		return m, func() tea.Msg { return AppIDExportRequestMsg{} }
+	}
+	base, handled, cmd := m.HandleNavigation(keyMsg, len(m.suggestions), m.visibleRows())
+	if handled {
+		m.TableBase = base
+	}
+	return m, cmd
+}
+
+func (m AppIDModel) View() string {
+	if m.Width == 0 {
+		return RenderLoadingInline(m.SpinnerFrame, "Loading...")
+	}
+
+	titleStyle := ViewTitleStyle.MarginBottom(1)
+	panelStyle := ViewPanelStyle.Width(m.Width - 4)
+	width := max(m.Width-12, 40)
+
+	var b strings.Builder
+	if !m.loaded {
+		b.WriteString(titleStyle.Render("App-ID Migration"))
+		b.WriteString("\n")
+		if m.Loading {
+			b.WriteString(RenderLoadingInline(m.SpinnerFrame, "Reading the applications seen on port-based rules..."))
+		} else {
+			b.WriteString(EmptyMsgStyle.Render("Nothing read yet: press r"))
+		}
+		return panelStyle.Render(b.String())
+	}
+
+	ready := 0
+	for _, s := range m.suggestions {
+		if len(s.Commands) > 0 {
+			ready++
+		}
+	}
+	info := BannerInfoStyle.Render(fmt.Sprintf(" [%d port-based rules | %d with suggestions | last %d days | e: export | r: refresh]",
+		len(m.suggestions), ready, m.days))
+	b.WriteString(titleStyle.Render("App-ID Migration") + info)
+	b.WriteString("\n")
+
+	if m.Err != nil {
+		b.WriteString(ErrorMsgStyle.Render(truncateEllipsis("Error: "+m.Err.Error(), width)))
+		return panelStyle.Render(b.String())
+	}
+	switch {
+	case m.exporting:
+		b.WriteString(RenderLoadingInline(m.SpinnerFrame, "Exporting..."))
+		b.WriteString("\n")
+	case m.notice != "" && m.warn:
+		b.WriteString(ErrorMsgStyle.Render(truncateEllipsis(m.notice, width)))
+		b.WriteString("\n")
+	case m.notice != "":
+		b.WriteString(StatusActiveStyle.Render(truncateEllipsis(m.notice, width)))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	if len(m.suggestions) == 0 {
+		b.WriteString(StatusActiveStyle.Render("No port-based rules: every enabled allow rule names its applications"))
+		return panelStyle.Render(b.String())
+	}
+
+	header := fmt.Sprintf("  %-24s  %10s  %-16s  %5s  %s", "Rule", "Hits", "Service", "Seen", "Suggested applications")
+	b.WriteString(TableHeaderStyle.Render(truncateEllipsis(header, width)))
+	b.WriteString("\n")
+
+	visible := m.visibleRows()
+	end := min(m.Offset+visible, len(m.suggestions))
+	for i := m.Offset; i < end; i++ {
+		s := m.suggestions[i]
+		suggested := strings.Join(s.Applications, ", ")
+		if suggested == "" {
+			suggested = "-"
+		}
+		row := fmt.Sprintf("  %-24s  %10d  %-16s  %5d  %s", truncate(s.Rule.Name, 24), s.Rule.HitCount,
+			truncate(strings.Join(s.Rule.Services, ","), 16), len(s.Seen), suggested)
+		row = truncateEllipsis(row, width)
+		switch {
+		case i == m.Cursor:
+			b.WriteString(TableSelectedRowStyle().Render(lipgloss.NewStyle().Width(width).Render(row)))
+		case len(s.Seen) == 0:
+			b.WriteString(DetailDimStyle.Render(row))
+		default:
+			b.WriteString(DetailValueStyle.Render(row))
+		}
+		b.WriteString("\n")
+	}
+	if len(m.suggestions) > visible {
+		b.WriteString(DetailDimStyle.Render(fmt.Sprintf("  Showing %d-%d of %d", m.Offset+1, end, len(m.suggestions))))
+		b.WriteString("\n")
+	}
+
+	if m.Cursor < len(m.suggestions) {
+		b.WriteString("\n")
+		b.WriteString(m.renderDetail(m.suggestions[m.Cursor], width))
+	}
+	return panelStyle.Render(b.String())
+}
+
+// renderDetail lists the applications seen on s's rule and the commands
+// that would migrate it.
+func (m AppIDModel) renderDetail(s appid.Suggestion, width int) string {
+	var b strings.Builder
+	if len(s.Seen) == 0 {
+		b.WriteString(DetailDimStyle.Render(fmt.Sprintf("No applications seen in the last %d days: if the rule is no longer needed, disable it", m.days)))
+		return b.String()
+	}
+	for i, a := range s.Seen {
+		if i == maxSeenShown {
+			b.WriteString(DetailDimStyle.Render(fmt.Sprintf("  … and %d more", len(s.Seen)-maxSeenShown)))
+			b.WriteString("\n")
+			break
+		}
+		last := "-"
+		if !a.LastSeen.IsZero() {
+			last = a.LastSeen.Local().Format("2006-01-02")
+		}
+		b.WriteString(DetailValueStyle.Render(truncateEllipsis(fmt.Sprintf("  %-24s  %10s  last seen %s", truncate(a.Name, 24), formatBytes(a.Bytes), last), width)))
+		b.WriteString("\n")
+	}
+	if len(s.Unidentified) > 0 {
+		b.WriteString(StatusWarningStyle.Render(truncateEllipsis("Unidentified traffic is not carried over: "+strings.Join(s.Unidentified, ", "), width)))
+		b.WriteString("\n")
+	}
+	for _, c := range s.Commands {
+		b.WriteString(DetailDimStyle.Render(truncateEllipsis(c, width)))
+		b.WriteString("\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,65 @@
+package views
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/jp2195/pyre/internal/appid"
+	"github.com/jp2195/pyre/internal/models"
+)
+
+func TestAppIDModel_ShowsSuggestions(t *testing.T) {
+	InitStyles()
+	suggestions := appid.Suggest([]models.SecurityRule{
+		{Name: "legacy-erp", HitCount: 61877, Services: []string{"tcp-8443"}},
+		{Name: "idle", Services: []string{"any"}},
+	}, map[string][]models.AppSeen{
+		"legacy-erp": {{Name: "ssl", Bytes: 4096, LastSeen: time.Now()}, {Name: "insufficient-data", Bytes: 10}},
+	})
+
+	m := NewAppIDModel().SetSize(160, 40).SetDevice("fw1").SetSuggestions("fw1", suggestions, 30, nil)
+	view := stripANSI(m.View())
+	for _, want := range []string{"2 port-based rules | 1 with suggestions | last 30 days", "legacy-erp",
+		"Unidentified traffic is not carried over: insufficient-data", "set rulebase security rules legacy-erp application ssl"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'j', Text: "j"})
+	if view := stripANSI(m.View()); !strings.Contains(view, "No applications seen in the last 30 days") {
+		t.Errorf("a rule with no traffic should say so:\n%s", view)
+	}
+
+	if stale := m.SetSuggestions("fw2", nil, 30, nil); len(stale.Suggestions()) != 2 {
+		t.Error("suggestions for another device replaced those shown")
+	}
+}
+
+func TestAppIDModel_Export(t *testing.T) {
+	InitStyles()
+	m := NewAppIDModel().SetSize(160, 40).SetDevice("fw1").SetSuggestions("fw1", nil, 30, errors.New("timeout"))
+	if _, cmd := m.Update(tea.KeyPressMsg{Code: 'e', Text: "e"}); cmd != nil {
+		t.Error("a failed lookup should not be exported")
+	}
+	if view := stripANSI(m.View()); !strings.Contains(view, "Error: timeout") {
+		t.Errorf("the lookup error should be shown:\n%s", view)
+	}
+
+	m = m.SetSuggestions("fw1", nil, 30, nil)
+	m, cmd := m.Update(tea.KeyPressMsg{Code: 'e', Text: "e"})
+	if cmd == nil {
+		t.Fatal("e should request an export")
+	}
+	if _, ok := cmd().(AppIDExportRequestMsg); !ok {
+		t.Errorf("e sent %T, want AppIDExportRequestMsg", cmd())
+	}
+	m = m.SetExported("", errors.New("disk full"))
+	if view := stripANSI(m.View()); !strings.Contains(view, "Export failed: disk full") {
+		t.Errorf("export failure not shown:\n%s", view)
+	}
+}
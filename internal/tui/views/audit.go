@@ -0,0 +1,245 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/jp2195/pyre/internal/audit"
+)
+
+// AuditExportRequestMsg asks the app to export the audit shown as Markdown.
+type AuditExportRequestMsg struct{}
+
+// AuditModel shows a best-practice audit of the active connection: the
+// score, the findings most severe first, and how to fix the one selected.
+type AuditModel struct {
+	TableBase
+	device string
+	result audit.Result
+	loaded bool
+
+	exporting bool
+	notice    string
+	warn      bool // notice is a problem rather than a confirmation
+}
+
+func NewAuditModel() AuditModel {
+	return AuditModel{TableBase: NewTableBase("")}
+}
+
+func (m AuditModel) SetSize(width, height int) AuditModel {
+	m.TableBase = m.TableBase.SetSize(width, height)
+	m.EnsureCursorValid(len(m.result.Findings))
+	m.EnsureVisible(m.visibleRows())
+	return m
+}
+
+func (m AuditModel) SetLoading(loading bool) AuditModel {
+	m.TableBase = m.TableBase.SetLoading(loading)
+	return m
+}
+
+// IsLoading reports whether an audit or export is in flight.
+func (m AuditModel) IsLoading() bool {
+	return m.Loading || m.exporting
+}
+
+// SetSpinnerFrame updates the current spinner animation frame.
+func (m AuditModel) SetSpinnerFrame(frame string) AuditModel {
+	m.TableBase = m.TableBase.SetSpinnerFrame(frame)
+	return m
+}
+
+// HasData returns true once an audit has finished.
+func (m AuditModel) HasData() bool {
+	return m.loaded
+}
+
+// IsFilterMode is always false: the findings have no filter input.
+func (m AuditModel) IsFilterMode() bool {
+	return false
+}
+
+// Device is the device the audit shown is of (see devicefile.Device).
+func (m AuditModel) Device() string {
+	return m.device
+}
+
+// Result is the audit shown.
+func (m AuditModel) Result() audit.Result {
+	return m.result
+}
+
+// SetDevice switches to another device, clearing the previous device's
+// audit.
+func (m AuditModel) SetDevice(device string) AuditModel {
+	if device == m.device {
+		return m
+	}
+	m.device = device
+	m.result = audit.Result{}
+	m.loaded = false
+	m.notice = ""
+	m.Err = nil
+	m.ResetPosition()
+	return m
+}
+
+// SetResult shows the audit of device. err joins the reads that failed;
+// the audit covers the rest. Results for a device the view has since
+// moved away from are dropped.
+func (m AuditModel) SetResult(device string, res audit.Result, err error) AuditModel {
+	if device != m.device {
+		return m
+	}
+	m.result = res
+	m.Err = err
+	m.Loading = false
+	m.loaded = true
+	m.EnsureCursorValid(len(m.result.Findings))
+	m.EnsureVisible(m.visibleRows())
+	return m
+}
+
+// SetExporting marks an export as in flight.
+func (m AuditModel) SetExporting() AuditModel {
+	m.exporting = true
+	m.notice = ""
+	return m
+}
+
+// SetExported reports the outcome of an export.
+func (m AuditModel) SetExported(path string, err error) AuditModel {
+	m.exporting = false
+	if err != nil {
+		m.notice, m.warn = "Export failed: "+err.Error(), true
+		return m
+	}
+	m.notice, m.warn = "Exported to "+path, false
+	return m
+}
+
+// visibleRows leaves room for the banner, notices, table header, and the
+// selected finding's detail.
+func (m AuditModel) visibleRows() int {
+	return m.VisibleRows(15, 0)
+}
+
+func (m AuditModel) Update(msg tea.Msg) (AuditModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return m, nil
+	}
+	if keyMsg.String() == "e" {
+		if m.exporting || !m.loaded {
+			return m, nil
+		}
+		m = m.SetExporting()
+		return m, func() tea.Msg { return AuditExportRequestMsg{} }
+	}
+	base, handled, cmd := m.HandleNavigation(keyMsg, len(m.result.Findings), m.visibleRows())
+	if handled {
+		m.TableBase = base
+	}
+	return m, cmd
+}
+
+// severityStyle colors a finding by how severe it is.
+func severityStyle(s audit.Severity) lipgloss.Style {
+	switch s {
+	case audit.SeverityCritical, audit.SeverityHigh:
+		return ErrorMsgStyle
+	case audit.SeverityMedium:
+		return StatusWarningStyle
+	default:
+		return DetailValueStyle
+	}
+}
+
+func (m AuditModel) View() string {
+	if m.Width == 0 {
+		return RenderLoadingInline(m.SpinnerFrame, "Loading...")
+	}
+
+	titleStyle := ViewTitleStyle.MarginBottom(1)
+	panelStyle := ViewPanelStyle.Width(m.Width - 4)
+	width := max(m.Width-12, 40)
+
+	var b strings.Builder
+	if !m.loaded {
+		b.WriteString(titleStyle.Render("Best-Practice Audit"))
+		b.WriteString("\n")
+		if m.Loading {
+			b.WriteString(RenderLoadingInline(m.SpinnerFrame, "Auditing rules and certificates..."))
+		} else {
+			b.WriteString(EmptyMsgStyle.Render("No audit yet: press r to run one"))
+		}
+		return panelStyle.Render(b.String())
+	}
+
+	counts := m.result.Counts()
+	info := BannerInfoStyle.Render(fmt.Sprintf(" [score %d/100 | %d critical | %d high | %d medium | %d low | e: export | r: re-run]",
+		m.result.Score, counts[audit.SeverityCritical], counts[audit.SeverityHigh], counts[audit.SeverityMedium], counts[audit.SeverityLow]))
+	b.WriteString(titleStyle.Render("Best-Practice Audit") + info)
+	b.WriteString("\n")
+
+	if m.Err != nil {
+		msg := "Incomplete, some reads failed: " + strings.ReplaceAll(m.Err.Error(), "\n", "; ")
+		b.WriteString(ErrorMsgStyle.Render(truncateEllipsis(msg, width)))
+		b.WriteString("\n")
+	}
+	switch {
+	case m.exporting:
+		b.WriteString(RenderLoadingInline(m.SpinnerFrame, "Exporting..."))
+		b.WriteString("\n")
+	case m.notice != "" && m.warn:
+		b.WriteString(ErrorMsgStyle.Render(truncateEllipsis(m.notice, width)))
+		b.WriteString("\n")
+	case m.notice != "":
+		b.WriteString(StatusActiveStyle.Render(truncateEllipsis(m.notice, width)))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	findings := m.result.Findings
+	if len(findings) == 0 {
+		b.WriteString(StatusActiveStyle.Render("No findings: every check passed"))
+		return panelStyle.Render(b.String())
+	}
+
+	header := fmt.Sprintf("  %-8s  %-44s  %-24s  %s", "Severity", "Check", "Object", "Detail")
+	b.WriteString(TableHeaderStyle.Render(truncateEllipsis(header, width)))
+	b.WriteString("\n")
+
+	visible := m.visibleRows()
+	end := min(m.Offset+visible, len(findings))
+	for i := m.Offset; i < end; i++ {
+		f := findings[i]
+		row := fmt.Sprintf("  %-8s  %-44s  %-24s  %s", f.Severity, truncate(f.Title, 44), truncate(f.Object, 24), f.Detail)
+		row = truncateEllipsis(row, width)
+		if i == m.Cursor {
+			b.WriteString(TableSelectedRowStyle().Render(lipgloss.NewStyle().Width(width).Render(row)))
+		} else {
+			b.WriteString(severityStyle(f.Severity).Render(row))
+		}
+		b.WriteString("\n")
+	}
+	if len(findings) > visible {
+		b.WriteString(DetailDimStyle.Render(fmt.Sprintf("  Showing %d-%d of %d", m.Offset+1, end, len(findings))))
+		b.WriteString("\n")
+	}
+
+	if m.Cursor < len(findings) {
+		f := findings[m.Cursor]
+		b.WriteString("\n")
+		b.WriteString(DetailValueStyle.Render(truncateEllipsis(f.Object+": "+f.Detail, width)))
+		if c, ok := m.result.Check(f.Check); ok {
+			b.WriteString("\n")
+			b.WriteString(DetailDimStyle.Render(truncateEllipsis("Fix: "+c.Fix, width)))
+		}
+	}
+	return panelStyle.Render(b.String())
+}
@@ -0,0 +1,63 @@
+package views
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/jp2195/pyre/internal/audit"
+	"github.com/jp2195/pyre/internal/models"
+)
+
+func TestAuditModel_ShowsScoreAndFindings(t *testing.T) {
+	InitStyles()
+	res := audit.Run(audit.Input{
+		Rules:        []models.SecurityRule{{Name: "wide-open", Action: "allow", LogEnd: true}},
+		Certificates: []models.Certificate{{Name: "web", DaysLeft: 10}},
+	}, audit.Options{})
+
+	m := NewAuditModel().SetSize(200, 40).SetDevice("fw1").SetResult("fw1", res, nil)
+	view := stripANSI(m.View())
+	if !strings.Contains(view, "score ") || !strings.Contains(view, "1 critical") {
+		t.Errorf("banner missing score or counts:\n%s", view)
+	}
+	if !strings.Contains(view, "Allow rules matching any traffic") || !strings.Contains(view, "Fix: Narrow the rule") {
+		t.Errorf("the most severe finding should be selected, with its fix:\n%s", view)
+	}
+
+	if stale := m.SetResult("fw2", audit.Result{}, nil); len(stale.Result().Findings) != len(res.Findings) {
+		t.Error("a result for another device replaced the audit shown")
+	}
+
+	m = m.SetResult("fw1", audit.Result{Score: 100}, errors.New("certificates: timeout"))
+	view = stripANSI(m.View())
+	if !strings.Contains(view, "No findings") || !strings.Contains(view, "certificates: timeout") {
+		t.Errorf("an incomplete audit should say which reads failed:\n%s", view)
+	}
+}
+
+func TestAuditModel_Export(t *testing.T) {
+	InitStyles()
+	m := NewAuditModel().SetSize(160, 40)
+	if _, cmd := m.Update(tea.KeyPressMsg{Code: 'e', Text: "e"}); cmd != nil {
+		t.Error("export before any audit should do nothing")
+	}
+
+	m = m.SetDevice("fw1").SetResult("fw1", audit.Result{Score: 100}, nil)
+	m, cmd := m.Update(tea.KeyPressMsg{Code: 'e', Text: "e"})
+	if cmd == nil {
+		t.Fatal("e should request an export")
+	}
+	if _, ok := cmd().(AuditExportRequestMsg); !ok {
+		t.Errorf("e sent %T, want AuditExportRequestMsg", cmd())
+	}
+	if !m.IsLoading() {
+		t.Error("the view should show the export in flight")
+	}
+	m = m.SetExported("/home/u/.pyre/audits/fw1.md", nil)
+	if view := stripANSI(m.View()); !strings.Contains(view, "Exported to /home/u/.pyre/audits/fw1.md") {
+		t.Errorf("export path not shown:\n%s", view)
+	}
+}
@@ -0,0 +1,345 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/jp2195/pyre/internal/backup"
+	"github.com/jp2195/pyre/internal/models"
+)
+
+// BackupRequestMsg asks the app to save the active connection's running
+// config as a new version.
+type BackupRequestMsg struct{}
+
+// BackupDiffRequestMsg asks the app to diff two stored versions.
+type BackupDiffRequestMsg struct {
+	Older, Newer backup.Version
+}
+
+// BackupsModel lists the stored running-config versions of the active
+// connection and shows the diff between two of them.
+type BackupsModel struct {
+	TableBase
+	device   string
+	versions []backup.Version
+	loaded   bool
+
+	marked string // Name() of the version chosen as the diff base
+	saving bool
+	notice string
+	warn   bool // notice is a problem rather than a confirmation
+
+	showDiff bool
+	diffing  BackupDiffRequestMsg // the versions the diff compares
+	diff     ConfigDiffModel
+}
+
+func NewBackupsModel() BackupsModel {
+	return BackupsModel{
+		TableBase: NewTableBase(""),
+		diff:      NewVersionDiffModel(),
+	}
+}
+
+func (m BackupsModel) SetSize(width, height int) BackupsModel {
+	m.TableBase = m.TableBase.SetSize(width, height)
+	m.diff = m.diff.SetSize(width, height)
+	m.EnsureCursorValid(len(m.versions))
+	m.EnsureVisible(m.visibleRows())
+	return m
+}
+
+func (m BackupsModel) SetLoading(loading bool) BackupsModel {
+	m.TableBase = m.TableBase.SetLoading(loading)
+	return m
+}
+
+// IsLoading reports whether a listing, backup, or diff is in flight.
+func (m BackupsModel) IsLoading() bool {
+	return m.Loading || m.saving || (m.showDiff && m.diff.IsLoading())
+}
+
+// SetSpinnerFrame updates the current spinner animation frame.
+func (m BackupsModel) SetSpinnerFrame(frame string) BackupsModel {
+	m.TableBase = m.TableBase.SetSpinnerFrame(frame)
+	m.diff = m.diff.SetSpinnerFrame(frame)
+	return m
+}
+
+// HasData returns true once the version list has been loaded.
+func (m BackupsModel) HasData() bool {
+	return m.loaded
+}
+
+// IsFilterMode returns true while the diff's filter input is focused.
+func (m BackupsModel) IsFilterMode() bool {
+	return m.showDiff && m.diff.IsFilterMode()
+}
+
+// Device is the backup history the view shows (see devicefile.Device).
+func (m BackupsModel) Device() string {
+	return m.device
+}
+
+// SetDevice switches to another device's history, clearing what was shown
+// for the previous one.
+func (m BackupsModel) SetDevice(device string) BackupsModel {
+	if device == m.device {
+		return m
+	}
+	m.device = device
+	m.versions = nil
+	m.loaded = false
+	m.marked = ""
+	m.notice = ""
+	m.showDiff = false
+	m.ResetPosition()
+	return m
+}
+
+// SetVersions replaces the version list for device. Results for a device
+// the view has since moved away from are dropped.
+func (m BackupsModel) SetVersions(device string, versions []backup.Version, err error) BackupsModel {
+	if device != m.device {
+		return m
+	}
+	m.versions = versions
+	m.Err = err
+	m.Loading = false
+	m.loaded = true
+	if m.marked != "" && m.find(m.marked) < 0 {
+		m.marked = ""
+	}
+	m.EnsureCursorValid(len(m.versions))
+	m.EnsureVisible(m.visibleRows())
+	return m
+}
+
+// SetSaving marks a backup as in flight.
+func (m BackupsModel) SetSaving() BackupsModel {
+	m.saving = true
+	m.notice = ""
+	return m
+}
+
+// SetSaved reports the outcome of a backup. The caller refreshes the list.
+func (m BackupsModel) SetSaved(v backup.Version, pruned int, err error) BackupsModel {
+	m.saving = false
+	if err != nil {
+		m.notice, m.warn = "Backup failed: "+err.Error(), true
+		return m
+	}
+	m.notice, m.warn = fmt.Sprintf("Saved %s (%s)", v.Name(), formatBytes(v.Size)), false
+	if pruned > 0 {
+		m.notice += fmt.Sprintf(", pruned %d old version(s)", pruned)
+	}
+	m.Cursor, m.Offset = 0, 0
+	return m
+}
+
+// SetDiff shows the result of a BackupDiffRequestMsg, unless the view has
+// since left that diff.
+func (m BackupsModel) SetDiff(older, newer backup.Version, hunks []models.ConfigHunk, err error) BackupsModel {
+	if !m.showDiff || m.diffing.Older.Path != older.Path || m.diffing.Newer.Path != newer.Path {
+		return m
+	}
+	if hunks == nil && err == nil {
+		hunks = []models.ConfigHunk{}
+	}
+	m.diff = m.diff.SetDiff(hunks, err)
+	return m
+}
+
+func (m BackupsModel) find(name string) int {
+	for i, v := range m.versions {
+		if v.Name() == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m BackupsModel) visibleRows() int {
+	return m.VisibleRows(9, 0)
+}
+
+func (m BackupsModel) Update(msg tea.Msg) (BackupsModel, tea.Cmd) {
+	if m.showDiff {
+		if key, ok := msg.(tea.KeyPressMsg); ok && key.String() == "esc" &&
+			!m.diff.IsFilterMode() && !m.diff.IsFiltered() {
+			m.showDiff = false
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.diff, cmd = m.diff.Update(msg)
+		return m, cmd
+	}
+
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return m, nil
+	}
+	switch keyMsg.String() {
+	case "b":
+		if m.saving {
+			return m, nil
+		}
+		m = m.SetSaving()
+		return m, func() tea.Msg { return BackupRequestMsg{} }
+	case "space", " ":
+		if m.Cursor < len(m.versions) {
+			name := m.versions[m.Cursor].Name()
+			if m.marked == name {
+				m.marked = ""
+			} else {
+				m.marked = name
+			}
+		}
+		return m, nil
+	case "enter":
+		return m.startDiff()
+	case "esc":
+		if m.marked != "" || m.notice != "" {
+			m.marked = ""
+			m.notice = ""
+		}
+		return m, nil
+	}
+
+	base, handled, cmd := m.HandleNavigation(keyMsg, len(m.versions), m.visibleRows())
+	if handled {
+		m.TableBase = base
+	}
+	return m, cmd
+}
+
+// startDiff compares the version under the cursor with the marked one, or
+// with the version before it when nothing is marked.
+func (m BackupsModel) startDiff() (BackupsModel, tea.Cmd) {
+	if m.Cursor >= len(m.versions) {
+		return m, nil
+	}
+	other := m.Cursor + 1
+	if m.marked != "" {
+		other = m.find(m.marked)
+	}
+	if other < 0 || other == m.Cursor {
+		m.notice, m.warn = "Mark a different version with space to compare against", true
+		return m, nil
+	}
+	if other >= len(m.versions) {
+		m.notice, m.warn = "This is the oldest version; mark another with space to compare", true
+		return m, nil
+	}
+	older, newer := m.versions[other], m.versions[m.Cursor]
+	if older.Time.After(newer.Time) {
+		older, newer = newer, older
+	}
+
+	m.notice = ""
+	m.showDiff = true
+	m.diffing = BackupDiffRequestMsg{Older: older, Newer: newer}
+	m.diff = NewVersionDiffModel().
+		SetSize(m.Width, m.Height).
+		SetSpinnerFrame(m.SpinnerFrame).
+		SetTitle(fmt.Sprintf("%s → %s", versionLabel(older), versionLabel(newer))).
+		SetLoading(true)
+	req := m.diffing
+	return m, func() tea.Msg { return req }
+}
+
+func versionLabel(v backup.Version) string {
+	return v.Time.Local().Format("2006-01-02 15:04:05")
+}
+
+func (m BackupsModel) View() string {
+	if m.Width == 0 {
+		return RenderLoadingInline(m.SpinnerFrame, "Loading...")
+	}
+	if m.showDiff {
+		return m.diff.View()
+	}
+
+	titleStyle := ViewTitleStyle.MarginBottom(1)
+	panelStyle := ViewPanelStyle.Width(m.Width - 4)
+
+	var b strings.Builder
+	info := BannerInfoStyle.Render(fmt.Sprintf(" [%s | %d versions | b: back up now | space: mark | enter: diff]",
+		m.device, len(m.versions)))
+	b.WriteString(titleStyle.Render("Config Backups") + info)
+	b.WriteString("\n")
+
+	if m.saving {
+		b.WriteString(RenderLoadingInline(m.SpinnerFrame, "Saving running config..."))
+		b.WriteString("\n\n")
+	} else if m.notice != "" {
+		style := StatusActiveStyle
+		if m.warn {
+			style = StatusWarningStyle
+		}
+		b.WriteString(style.Render(m.notice))
+		b.WriteString("\n\n")
+	}
+
+	if m.Err != nil {
+		b.WriteString(ErrorMsgStyle.Render("Error: " + m.Err.Error()))
+		return panelStyle.Render(b.String())
+	}
+	if m.Loading || !m.loaded {
+		b.WriteString(RenderLoadingInline(m.SpinnerFrame, "Loading backups..."))
+		return panelStyle.Render(b.String())
+	}
+	if len(m.versions) == 0 {
+		b.WriteString(EmptyMsgStyle.Render("No backups yet — press b to save the running config"))
+		return panelStyle.Render(b.String())
+	}
+
+	width := max(m.Width-12, 40)
+	header := fmt.Sprintf("  %-20s  %-18s  %10s  %s", "Saved", "Version", "Size", "Age")
+	b.WriteString(TableHeaderStyle.Render(header))
+	b.WriteString("\n")
+
+	visible := m.visibleRows()
+	end := min(m.Offset+visible, len(m.versions))
+	now := time.Now()
+	for i := m.Offset; i < end; i++ {
+		v := m.versions[i]
+		mark := " "
+		if v.Name() == m.marked {
+			mark = "●"
+		}
+		row := fmt.Sprintf("%s %-20s  %-18s  %10s  %s", mark, versionLabel(v), v.Name(),
+			formatBytes(v.Size), formatAge(now.Sub(v.Time)))
+		if i == m.Cursor {
+			b.WriteString(TableSelectedRowStyle().Render(lipgloss.NewStyle().Width(width).Render(row)))
+		} else if v.Name() == m.marked {
+			b.WriteString(StatusWarningStyle.Render(row))
+		} else {
+			b.WriteString(DetailValueStyle.Render(row))
+		}
+		b.WriteString("\n")
+	}
+	if len(m.versions) > visible {
+		b.WriteString(DetailDimStyle.Render(fmt.Sprintf("  Showing %d-%d of %d", m.Offset+1, end, len(m.versions))))
+	}
+	return panelStyle.Render(b.String())
+}
+
+// formatAge renders how long ago something happened, e.g. "3h ago".
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
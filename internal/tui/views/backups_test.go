@@ -0,0 +1,132 @@
+package views
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/jp2195/pyre/internal/backup"
+	"github.com/jp2195/pyre/internal/models"
+)
+
+var backupT0 = time.Date(2025, 1, 21, 9, 0, 0, 0, time.UTC)
+
+// testBackups returns a backups view holding three versions, newest first.
+func testBackups() BackupsModel {
+	InitStyles()
+	var versions []backup.Version
+	for i := 2; i >= 0; i-- {
+		at := backupT0.Add(time.Duration(i) * time.Hour)
+		versions = append(versions, backup.Version{
+			Device: "fw1", Time: at, Path: "/b/fw1/" + at.Format("150405") + ".xml", Size: 4096,
+		})
+	}
+	return NewBackupsModel().SetSize(120, 40).SetDevice("fw1").SetVersions("fw1", versions, nil)
+}
+
+func diffRequest(t *testing.T, cmd tea.Cmd) BackupDiffRequestMsg {
+	t.Helper()
+	if cmd == nil {
+		t.Fatal("expected a diff request")
+	}
+	req, ok := cmd().(BackupDiffRequestMsg)
+	if !ok {
+		t.Fatalf("cmd produced %T, want BackupDiffRequestMsg", cmd())
+	}
+	return req
+}
+
+func TestBackupsModel_DiffAgainstPreviousVersion(t *testing.T) {
+	m := testBackups()
+
+	m, cmd := m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	req := diffRequest(t, cmd)
+	if !req.Older.Time.Equal(backupT0.Add(time.Hour)) || !req.Newer.Time.Equal(backupT0.Add(2*time.Hour)) {
+		t.Errorf("diff = %v → %v; want the newest against the one before it", req.Older.Time, req.Newer.Time)
+	}
+
+	m = m.SetDiff(req.Older, req.Newer, []models.ConfigHunk{{
+		XPath: "/config/shared/address/entry[@name='web']", Kind: "modified",
+	}}, nil)
+	if !strings.Contains(stripANSI(m.View()), "entry[@name='web']") {
+		t.Error("diff not shown")
+	}
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+	if !strings.Contains(stripANSI(m.View()), "Config Backups") {
+		t.Error("esc should return to the version list")
+	}
+}
+
+func TestBackupsModel_DiffMarkedVersion(t *testing.T) {
+	m := testBackups()
+
+	// Mark the newest, move to the oldest, and diff: the older one is
+	// always the base, whichever end was marked.
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeySpace, Text: " "})
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'G', Text: "G"})
+	_, cmd := m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	req := diffRequest(t, cmd)
+	if !req.Older.Time.Equal(backupT0) || !req.Newer.Time.Equal(backupT0.Add(2*time.Hour)) {
+		t.Errorf("diff = %v → %v; want oldest → newest", req.Older.Time, req.Newer.Time)
+	}
+}
+
+func TestBackupsModel_OldestWithoutMarkExplains(t *testing.T) {
+	m := testBackups()
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'G', Text: "G"})
+	m, cmd := m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if cmd != nil {
+		t.Error("the oldest version has nothing before it to diff against")
+	}
+	if !strings.Contains(stripANSI(m.View()), "oldest version") {
+		t.Error("expected a hint to mark another version")
+	}
+}
+
+func TestBackupsModel_StaleDiffIgnored(t *testing.T) {
+	m := testBackups()
+	m, cmd := m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	req := diffRequest(t, cmd)
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+
+	m = m.SetDiff(req.Older, req.Newer, []models.ConfigHunk{}, nil)
+	if strings.Contains(stripANSI(m.View()), "No differences") {
+		t.Error("a diff arriving after esc should not reopen it")
+	}
+}
+
+func TestBackupsModel_BackUpNow(t *testing.T) {
+	m := testBackups()
+	m, cmd := m.Update(tea.KeyPressMsg{Code: 'b', Text: "b"})
+	if cmd == nil {
+		t.Fatal("b should request a backup")
+	}
+	if _, ok := cmd().(BackupRequestMsg); !ok {
+		t.Fatalf("cmd produced %T, want BackupRequestMsg", cmd())
+	}
+	if !m.IsLoading() {
+		t.Error("view should show the backup in progress")
+	}
+	if _, cmd := m.Update(tea.KeyPressMsg{Code: 'b', Text: "b"}); cmd != nil {
+		t.Error("a second b while saving should be ignored")
+	}
+
+	m = m.SetSaved(backup.Version{Time: backupT0.Add(3 * time.Hour), Size: 2048}, 1, nil)
+	view := stripANSI(m.View())
+	if !strings.Contains(view, "Saved 20250121T120000Z") || !strings.Contains(view, "pruned 1") {
+		t.Errorf("saved notice missing:\n%s", view)
+	}
+}
+
+func TestBackupsModel_SetDeviceClears(t *testing.T) {
+	m := testBackups().SetDevice("fw2")
+	if m.HasData() {
+		t.Error("switching device should drop the old list")
+	}
+	if m = m.SetVersions("fw1", []backup.Version{{Device: "fw1"}}, nil); m.HasData() {
+		t.Error("a listing for the previous device should be ignored")
+	}
+}
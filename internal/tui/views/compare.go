@@ -0,0 +1,531 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/jp2195/pyre/internal/backup"
+	"github.com/jp2195/pyre/internal/policydiff"
+	"github.com/jp2195/pyre/internal/tui/theme"
+)
+
+// CompareSource is one side of a comparison: a connected device read live,
+// or a saved backup of one.
+type CompareSource struct {
+	Device string // see devicefile.Device
+	// Host and Target name the connection and the device behind it that a
+	// live source is read from.
+	Host, Target string
+	// Version is the backup a snapshot source is read from; zero for live.
+	Version backup.Version
+}
+
+// Live reports whether the source is read from the device rather than a
+// backup.
+func (s CompareSource) Live() bool {
+	return s.Version.Path == ""
+}
+
+// Label names the source, e.g. "fw1 (live)" or "fw1 @ 2025-01-21 09:00:00".
+func (s CompareSource) Label() string {
+	if s.Live() {
+		return s.Device + " (live)"
+	}
+	return s.Device + " @ " + versionLabel(s.Version)
+}
+
+// key identifies the source among the others listed.
+func (s CompareSource) key() string {
+	if s.Live() {
+		return "live:" + s.Device
+	}
+	return s.Version.Path
+}
+
+// CompareRequestMsg asks the app to read both sides and compare them.
+type CompareRequestMsg struct {
+	Old, New CompareSource
+}
+
+// CompareModel compares the rules and objects of two sources — two
+// firewalls, an HA pair, or a firewall and one of its backups — and shows
+// what differs, field by field, side by side.
+type CompareModel struct {
+	TableBase // the differences; its filter narrows them
+	picker    TableBase
+
+	sources []CompareSource
+	loaded  bool
+	marked  string // key() of the source chosen as the old side
+	notice  string
+
+	showResult bool
+	request    CompareRequestMsg
+	result     policydiff.Result
+	resultErr  error
+	comparing  bool
+	visible    []int // indexes into result.Entries that match the filter
+}
+
+func NewCompareModel() CompareModel {
+	return CompareModel{
+		TableBase: NewTableBase("Filter by name, kind, change or field..."),
+		picker:    NewTableBase(""),
+	}
+}
+
+func (m CompareModel) SetSize(width, height int) CompareModel {
+	m.TableBase = m.TableBase.SetSize(width, height)
+	m.picker = m.picker.SetSize(width, height)
+	m.picker.EnsureCursorValid(len(m.sources))
+	m.picker.EnsureVisible(m.pickerRows())
+	m.EnsureCursorValid(len(m.visible))
+	m.EnsureVisible(m.resultRows())
+	return m
+}
+
+// SetLoading marks a refresh in flight: of the comparison shown, or else of
+// the source list.
+func (m CompareModel) SetLoading(loading bool) CompareModel {
+	if m.showResult {
+		m.comparing = loading
+		return m
+	}
+	m.picker = m.picker.SetLoading(loading)
+	return m
+}
+
+// IsLoading reports whether the source list or a comparison is in flight.
+func (m CompareModel) IsLoading() bool {
+	return m.picker.Loading || m.comparing
+}
+
+// SetSpinnerFrame updates the current spinner animation frame.
+func (m CompareModel) SetSpinnerFrame(frame string) CompareModel {
+	m.TableBase = m.TableBase.SetSpinnerFrame(frame)
+	m.picker = m.picker.SetSpinnerFrame(frame)
+	return m
+}
+
+// HasData returns true once the sources have been listed.
+func (m CompareModel) HasData() bool {
+	return m.loaded
+}
+
+// IsFilterMode returns true while the differences' filter is focused.
+func (m CompareModel) IsFilterMode() bool {
+	return m.showResult && m.FilterMode
+}
+
+// Comparison is the comparison shown, if any, so a refresh can run it
+// again.
+func (m CompareModel) Comparison() (CompareRequestMsg, bool) {
+	return m.request, m.showResult
+}
+
+// SetSources replaces the sources to pick from. The marked source stays
+// marked if it is still listed.
+func (m CompareModel) SetSources(sources []CompareSource, err error) CompareModel {
+	m.sources = sources
+	m.picker.Err = err
+	m.picker.Loading = false
+	m.loaded = true
+	if m.marked != "" && m.findSource(m.marked) < 0 {
+		m.marked = ""
+	}
+	m.picker.EnsureCursorValid(len(m.sources))
+	m.picker.EnsureVisible(m.pickerRows())
+	return m
+}
+
+// SetComparing shows req as in flight.
+func (m CompareModel) SetComparing(req CompareRequestMsg) CompareModel {
+	m.showResult = true
+	m.request = req
+	m.comparing = true
+	m.result = policydiff.Result{}
+	m.resultErr = nil
+	m.visible = nil
+	m.ResetPosition()
+	return m
+}
+
+// SetResult shows the outcome of a CompareRequestMsg, unless the view has
+// since moved on to another comparison.
+func (m CompareModel) SetResult(req CompareRequestMsg, res policydiff.Result, err error) CompareModel {
+	if !m.showResult || req.Old.key() != m.request.Old.key() || req.New.key() != m.request.New.key() {
+		return m
+	}
+	m.comparing = false
+	m.result = res
+	m.resultErr = err
+	m = m.applyFilter()
+	return m
+}
+
+func (m CompareModel) findSource(key string) int {
+	for i, s := range m.sources {
+		if s.key() == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// applyFilter recomputes the differences that match the filter.
+func (m CompareModel) applyFilter() CompareModel {
+	filter := strings.ToLower(m.FilterValue())
+	var visible []int
+	for i, e := range m.result.Entries {
+		if filter == "" || strings.Contains(entrySearchText(e), filter) {
+			visible = append(visible, i)
+		}
+	}
+	m.visible = visible
+	m.EnsureCursorValid(len(m.visible))
+	m.EnsureVisible(m.resultRows())
+	return m
+}
+
+func entrySearchText(e policydiff.Entry) string {
+	parts := []string{e.Name, e.Kind.String(), e.Change.String()}
+	for _, f := range e.ChangedFields() {
+		parts = append(parts, f.Name)
+	}
+	return strings.ToLower(strings.Join(parts, " "))
+}
+
+func (m CompareModel) pickerRows() int {
+	return m.picker.VisibleRows(9, 0)
+}
+
+// resultRows gives the differences half the room, the selected one's
+// fields the rest; expanded, the fields take nearly all of it.
+func (m CompareModel) resultRows() int {
+	if m.Expanded {
+		return 3
+	}
+	return max(m.VisibleRows(14, 0)/2, 3)
+}
+
+func (m CompareModel) Update(msg tea.Msg) (CompareModel, tea.Cmd) {
+	if m.showResult {
+		return m.updateResult(msg)
+	}
+
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return m, nil
+	}
+	switch keyMsg.String() {
+	case "space", " ":
+		if m.picker.Cursor < len(m.sources) {
+			key := m.sources[m.picker.Cursor].key()
+			if m.marked == key {
+				m.marked = ""
+			} else {
+				m.marked = key
+			}
+			m.notice = ""
+		}
+		return m, nil
+	case "enter":
+		return m.startCompare()
+	case "esc":
+		m.marked = ""
+		m.notice = ""
+		return m, nil
+	case "/":
+		return m, nil
+	}
+	base, handled, cmd := m.picker.HandleNavigation(keyMsg, len(m.sources), m.pickerRows())
+	if handled {
+		m.picker = base
+	}
+	return m, cmd
+}
+
+// startCompare compares the marked source, as the old side, with the one
+// under the cursor.
+func (m CompareModel) startCompare() (CompareModel, tea.Cmd) {
+	if m.picker.Cursor >= len(m.sources) {
+		return m, nil
+	}
+	old := m.findSource(m.marked)
+	if old < 0 || old == m.picker.Cursor {
+		m.notice = "Mark the old side with space, then press enter on the new side"
+		return m, nil
+	}
+	m.notice = ""
+	req := CompareRequestMsg{Old: m.sources[old], New: m.sources[m.picker.Cursor]}
+	m = m.SetComparing(req)
+	return m, func() tea.Msg { return req }
+}
+
+func (m CompareModel) updateResult(msg tea.Msg) (CompareModel, tea.Cmd) {
+	if m.FilterMode {
+		base, _, cmd := m.HandleFilterMode(msg)
+		m.TableBase = base
+		m = m.applyFilter()
+		return m, cmd
+	}
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return m, nil
+	}
+	if keyMsg.String() == "esc" {
+		if m.HandleClearFilter() {
+			m = m.applyFilter()
+			return m, nil
+		}
+		m.showResult = false
+		m.comparing = false
+		return m, nil
+	}
+	base, handled, cmd := m.HandleNavigation(keyMsg, len(m.visible), m.resultRows())
+	if handled {
+		m.TableBase = base
+		m.EnsureVisible(m.resultRows()) // expanding shrinks the list
+	}
+	return m, cmd
+}
+
+func (m CompareModel) View() string {
+	if m.Width == 0 {
+		return RenderLoadingInline(m.SpinnerFrame, "Loading...")
+	}
+	if m.showResult {
+		return m.viewResult()
+	}
+
+	titleStyle := ViewTitleStyle.MarginBottom(1)
+	panelStyle := ViewPanelStyle.Width(m.Width - 4)
+	width := max(m.Width-12, 40)
+
+	var b strings.Builder
+	info := BannerInfoStyle.Render(fmt.Sprintf(" [%d sources | space: mark old side | enter: compare with marked]", len(m.sources)))
+	b.WriteString(titleStyle.Render("Compare") + info)
+	b.WriteString("\n")
+	if m.notice != "" {
+		b.WriteString(StatusWarningStyle.Render(truncateEllipsis(m.notice, width)))
+		b.WriteString("\n\n")
+	}
+
+	if m.picker.Err != nil {
+		b.WriteString(ErrorMsgStyle.Render(truncateEllipsis("Backups unavailable: "+m.picker.Err.Error(), width)))
+		b.WriteString("\n\n")
+	}
+	if m.picker.Loading || !m.loaded {
+		b.WriteString(RenderLoadingInline(m.SpinnerFrame, "Listing devices and backups..."))
+		return panelStyle.Render(b.String())
+	}
+	if len(m.sources) == 0 {
+		b.WriteString(EmptyMsgStyle.Render("Nothing to compare: connect to a device or save a backup first"))
+		return panelStyle.Render(b.String())
+	}
+
+	header := fmt.Sprintf("  %-5s  %-8s  %-40s  %-20s  %s", "Side", "Source", "Device", "Saved", "Age")
+	b.WriteString(TableHeaderStyle.Render(truncateEllipsis(header, width)))
+	b.WriteString("\n")
+
+	visible := m.pickerRows()
+	end := min(m.picker.Offset+visible, len(m.sources))
+	now := time.Now()
+	for i := m.picker.Offset; i < end; i++ {
+		s := m.sources[i]
+		side, kind, saved, age := "", "live", "now", ""
+		if s.key() == m.marked {
+			side = "old"
+		}
+		if !s.Live() {
+			kind, saved, age = "snapshot", versionLabel(s.Version), formatAge(now.Sub(s.Version.Time))
+		}
+		row := fmt.Sprintf("  %-5s  %-8s  %-40s  %-20s  %s", side, kind, truncateEllipsis(s.Device, 40), saved, age)
+		row = truncateEllipsis(row, width)
+		switch {
+		case i == m.picker.Cursor:
+			b.WriteString(TableSelectedRowStyle().Render(lipgloss.NewStyle().Width(width).Render(row)))
+		case s.key() == m.marked:
+			b.WriteString(StatusWarningStyle.Render(row))
+		default:
+			b.WriteString(DetailValueStyle.Render(row))
+		}
+		b.WriteString("\n")
+	}
+	if len(m.sources) > visible {
+		b.WriteString(DetailDimStyle.Render(fmt.Sprintf("  Showing %d-%d of %d", m.picker.Offset+1, end, len(m.sources))))
+	}
+	return panelStyle.Render(b.String())
+}
+
+func (m CompareModel) viewResult() string {
+	titleStyle := ViewTitleStyle.MarginBottom(1)
+	panelStyle := ViewPanelStyle.Width(m.Width - 4)
+	width := max(m.Width-12, 40)
+
+	var b strings.Builder
+	info := ""
+	if !m.comparing && m.resultErr == nil {
+		r := m.result
+		info = BannerInfoStyle.Render(fmt.Sprintf(" [%d added | %d removed | %d modified | %d moved | /: filter | enter: all fields | esc: back]",
+			r.Count(policydiff.Added), r.Count(policydiff.Removed), r.Count(policydiff.Modified), r.Count(policydiff.Moved)))
+	}
+	b.WriteString(titleStyle.Render("Compare") + info)
+	b.WriteString("\n")
+	b.WriteString(DetailLabelStyle.Render("Old: ") + DetailValueStyle.Render(m.request.Old.Label()) +
+		DetailLabelStyle.Render("   New: ") + DetailValueStyle.Render(m.request.New.Label()))
+	b.WriteString("\n")
+
+	if m.FilterMode {
+		b.WriteString(FilterBorderStyle.Render(m.Filter.View()))
+		b.WriteString("\n")
+	} else if m.IsFiltered() {
+		b.WriteString(FilterActiveStyle.Render(fmt.Sprintf("Filtered: \"%s\"", m.FilterValue())))
+		b.WriteString(FilterClearHintStyle.Render(" (esc to clear)"))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	switch {
+	case m.comparing:
+		b.WriteString(RenderLoadingInline(m.SpinnerFrame, "Reading and comparing rules and objects..."))
+		return panelStyle.Render(b.String())
+	case m.resultErr != nil:
+		b.WriteString(ErrorMsgStyle.Render(truncateEllipsis("Error: "+strings.ReplaceAll(m.resultErr.Error(), "\n", "; "), width)))
+		return panelStyle.Render(b.String())
+	case len(m.result.Entries) == 0:
+		b.WriteString(StatusActiveStyle.Render("No differences: the rules and objects match"))
+		return panelStyle.Render(b.String())
+	case len(m.visible) == 0:
+		b.WriteString(EmptyMsgStyle.Render("No differences match the current filter"))
+		return panelStyle.Render(b.String())
+	}
+
+	header := fmt.Sprintf("  %-8s  %-13s  %-32s  %-9s  %s", "Change", "Kind", "Name", "Position", "Fields")
+	b.WriteString(TableHeaderStyle.Render(truncateEllipsis(header, width)))
+	b.WriteString("\n")
+
+	rows := m.resultRows()
+	end := min(m.Offset+rows, len(m.visible))
+	for i := m.Offset; i < end; i++ {
+		e := m.result.Entries[m.visible[i]]
+		var fields []string
+		if e.Change == policydiff.Modified {
+			for _, f := range e.ChangedFields() {
+				fields = append(fields, f.Name)
+			}
+		}
+		row := fmt.Sprintf("  %-8s  %-13s  %-32s  %-9s  %s", e.Change, e.Kind, truncateEllipsis(e.Name, 32),
+			entryPosition(e), strings.Join(fields, ", "))
+		row = truncateEllipsis(row, width)
+		if i == m.Cursor {
+			b.WriteString(TableSelectedRowStyle().Render(lipgloss.NewStyle().Width(width).Render(row)))
+		} else {
+			b.WriteString(changeStyle(e.Change).Render(row))
+		}
+		b.WriteString("\n")
+	}
+	if len(m.visible) > rows {
+		b.WriteString(DetailDimStyle.Render(fmt.Sprintf("  Showing %d-%d of %d", m.Offset+1, end, len(m.visible))))
+		b.WriteString("\n")
+	}
+
+	if m.Cursor < len(m.visible) {
+		b.WriteString("\n")
+		room := max(m.Height-14-rows, 4)
+		b.WriteString(m.renderFields(m.result.Entries[m.visible[m.Cursor]], width, room))
+	}
+	return panelStyle.Render(b.String())
+}
+
+// renderFields lays the entry's fields out side by side, old on the left:
+// the changed ones, or when expanded every one set on either side. At most
+// room lines.
+func (m CompareModel) renderFields(e policydiff.Entry, width, room int) string {
+	fields := e.ChangedFields()
+	if m.Expanded {
+		fields = nil
+		for _, f := range e.Fields {
+			if f.Old != "" || f.New != "" {
+				fields = append(fields, f)
+			}
+		}
+	}
+	if e.Change == policydiff.Moved && !m.Expanded {
+		return DetailDimStyle.Render(truncateEllipsis(
+			fmt.Sprintf("%s moved from position %d to %d relative to the rules around it; its settings are unchanged", e.Name, e.OldPos, e.NewPos), width))
+	}
+
+	const nameW = 24
+	colW := max((width-nameW-6)/2, 10)
+	c := theme.Colors()
+	oldStyle := lipgloss.NewStyle().Foreground(c.Error)
+	newStyle := lipgloss.NewStyle().Foreground(c.Success)
+
+	var lines []string
+	lines = append(lines, TableHeaderStyle.Render(truncateEllipsis(fmt.Sprintf("  %-*s  %-*s  %s", nameW, "Field", colW, "Old", "New"), width)))
+	for _, f := range fields {
+		left, right := wrapText(f.Old, colW), wrapText(f.New, colW)
+		n := max(len(left), len(right), 1)
+		for i := range n {
+			name, l, r := "", "", ""
+			if i == 0 {
+				name = f.Name
+			}
+			if i < len(left) {
+				l = left[i]
+			}
+			if i < len(right) {
+				r = right[i]
+			}
+			cells := DetailLabelStyle.Render(fmt.Sprintf("  %-*s  ", nameW, truncateEllipsis(name, nameW)))
+			l, r = fmt.Sprintf("%-*s", colW, truncateEllipsis(l, colW)), truncateEllipsis(r, colW)
+			if f.Changed() {
+				cells += oldStyle.Render(l) + "  " + newStyle.Render(r)
+			} else {
+				cells += DetailDimStyle.Render(l + "  " + r)
+			}
+			lines = append(lines, cells)
+		}
+	}
+	if e.Moved && e.Change == policydiff.Modified {
+		lines = append(lines, DetailDimStyle.Render(fmt.Sprintf("  Also moved from position %d to %d relative to the rules around it", e.OldPos, e.NewPos)))
+	}
+	if len(lines) > room {
+		more := len(lines) - room + 1
+		lines = append(lines[:room-1], DetailDimStyle.Render(fmt.Sprintf("  … %d more lines", more)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// entryPosition renders a rule's move, e.g. "3 → 7", or "" for objects.
+func entryPosition(e policydiff.Entry) string {
+	switch {
+	case e.OldPos == 0 && e.NewPos == 0:
+		return ""
+	case e.OldPos == 0:
+		return fmt.Sprintf("→ %d", e.NewPos)
+	case e.NewPos == 0:
+		return fmt.Sprintf("%d →", e.OldPos)
+	case e.OldPos == e.NewPos:
+		return fmt.Sprint(e.NewPos)
+	}
+	return fmt.Sprintf("%d → %d", e.OldPos, e.NewPos)
+}
+
+func changeStyle(c policydiff.Change) lipgloss.Style {
+	switch c {
+	case policydiff.Added:
+		return StatusActiveStyle
+	case policydiff.Removed:
+		return ErrorMsgStyle
+	case policydiff.Moved:
+		return StatusWarningStyle
+	default:
+		return DetailValueStyle
+	}
+}
@@ -0,0 +1,89 @@
+package views
+
+import (
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/jp2195/pyre/internal/backup"
+	"github.com/jp2195/pyre/internal/models"
+	"github.com/jp2195/pyre/internal/policydiff"
+)
+
+func TestCompareModel_PickAndShow(t *testing.T) {
+	InitStyles()
+	sources := []CompareSource{
+		{Device: "fw1", Host: "fw1"},
+		{Device: "fw2", Host: "fw2"},
+		{Device: "fw1", Version: backup.Version{Device: "fw1", Time: backupT0, Path: "/b/fw1/1.xml"}},
+	}
+	m := NewCompareModel().SetSize(160, 40).SetSources(sources, nil)
+
+	m, cmd := m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if cmd != nil || !strings.Contains(stripANSI(m.View()), "Mark the old side") {
+		t.Fatal("enter with nothing marked should explain how to pick")
+	}
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeySpace, Text: " "})
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'j', Text: "j"})
+	m, cmd = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("enter on a second source should compare")
+	}
+	req, ok := cmd().(CompareRequestMsg)
+	if !ok || req.Old.Host != "fw1" || req.New.Host != "fw2" {
+		t.Fatalf("request = %#v", req)
+	}
+	if !m.IsLoading() {
+		t.Error("the comparison should show as in flight")
+	}
+
+	before := models.PolicySet{Security: []models.SecurityRule{
+		{Name: "allow-web", Action: "allow", Applications: []string{"web-browsing"}, LogForwarding: "default"},
+		{Name: "allow-dns", Action: "allow"},
+	}}
+	after := models.PolicySet{
+		Security: []models.SecurityRule{
+			{Name: "allow-web", Action: "allow", Applications: []string{"web-browsing", "ssl"}, LogForwarding: "default"},
+		},
+		Addresses: []models.AddressObject{{Name: "web-01", Type: "ip-netmask", Value: "10.0.1.10"}},
+	}
+	m = m.SetResult(req, policydiff.Compare(before, after), nil)
+	view := stripANSI(m.View())
+	for _, want := range []string{
+		"1 added | 1 removed | 1 modified | 0 moved",
+		"Old: fw1 (live)   New: fw2 (live)",
+		"modified  security rule  allow-web", "applications",
+		"web-browsing", "ssl",
+		"removed   security rule  allow-dns",
+		"added     address        web-01",
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "log forwarding") {
+		t.Error("unchanged fields should be hidden until enter")
+	}
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if !strings.Contains(stripANSI(m.View()), "log forwarding") {
+		t.Error("enter should show all fields")
+	}
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: '/', Text: "/"})
+	for _, r := range "address" {
+		m, _ = m.Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+	}
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	view = stripANSI(m.View())
+	if strings.Contains(view, "allow-dns") || !strings.Contains(view, "web-01") {
+		t.Errorf("filter should keep only addresses:\n%s", view)
+	}
+
+	// A result for another comparison is stale.
+	m = m.SetResult(CompareRequestMsg{Old: sources[2], New: sources[0]}, policydiff.Result{}, nil)
+	if !strings.Contains(stripANSI(m.View()), "web-01") {
+		t.Error("a stale result replaced the one shown")
+	}
+}
@@ -0,0 +1,246 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/jp2195/pyre/internal/models"
+	"github.com/jp2195/pyre/internal/tui/theme"
+)
+
+// CommitRequestMsg asks the app to start a validate or commit job. A
+// non-empty Admins scopes it to those administrators' changes (a partial
+// commit).
+type CommitRequestMsg struct {
+	Commit bool
+	Admins []string
+}
+
+// commitConfirmWord must be typed to confirm a commit.
+const commitConfirmWord = "commit"
+
+// maxJobLines caps the details and warnings shown for a finished job.
+const maxJobLines = 8
+
+type commitPhase int
+
+const (
+	commitIdle    commitPhase = iota
+	commitConfirm             // typing the confirmation word
+	commitRunning             // job requested or in progress
+	commitDone                // job finished, or failed to start
+)
+
+// commitPanel is the validate/commit state of the config diff view.
+type commitPanel struct {
+	writable bool
+	phase    commitPhase
+	commit   bool     // a commit job rather than a validate
+	admins   []string // partial scope; empty for everything
+	confirm  textinput.Model
+	job      models.Job
+	err      error
+	notice   string
+}
+
+func newCommitPanel() commitPanel {
+	ti := textinput.New()
+	ti.Placeholder = commitConfirmWord
+	ti.CharLimit = 16
+	ti.SetWidth(20)
+	return commitPanel{confirm: ti}
+}
+
+// SetWritable records whether the active connection permits writes.
+func (m ConfigDiffModel) SetWritable(writable bool) ConfigDiffModel {
+	m.commit.writable = writable
+	return m
+}
+
+// SetJob reports progress of the job started by the last CommitRequestMsg.
+// err means the job could not be started or polled.
+func (m ConfigDiffModel) SetJob(job models.Job, err error) ConfigDiffModel {
+	if m.commit.phase != commitRunning {
+		return m
+	}
+	m.commit.job = job
+	m.commit.err = err
+	if err != nil || job.Status == "FIN" {
+		m.commit.phase = commitDone
+	}
+	return m
+}
+
+// JobRunning reports whether a validate or commit job is in flight.
+func (m ConfigDiffModel) JobRunning() bool {
+	return m.commit.phase == commitRunning
+}
+
+// commitScope is the admin filter as a partial-commit scope.
+func (m ConfigDiffModel) commitScope() []string {
+	if m.admin == "" {
+		return nil
+	}
+	return []string{m.admin}
+}
+
+// updateCommitKeys handles v, c, and esc on a finished job. It reports
+// whether it consumed the key.
+func (m ConfigDiffModel) updateCommitKeys(key string) (ConfigDiffModel, tea.Cmd, bool) {
+	switch key {
+	case "v", "c":
+		m.commit.notice = ""
+		if !m.commit.writable {
+			m.commit.notice = "Read-only connection: set allow_write: true for it in ~/.pyre.yaml to validate or commit"
+			return m, nil, true
+		}
+		if m.commit.phase == commitRunning {
+			m.commit.notice = "A job is already running"
+			return m, nil, true
+		}
+		m.commit.admins = m.commitScope()
+		if key == "v" {
+			return m.startJob(false)
+		}
+		m.commit.phase = commitConfirm
+		m.commit.confirm.SetValue("")
+		cmd := m.commit.confirm.Focus()
+		return m, cmd, true
+	case "esc":
+		if m.commit.phase == commitDone || m.commit.notice != "" {
+			m.commit.phase = commitIdle
+			m.commit.notice = ""
+			return m, nil, true
+		}
+	}
+	return m, nil, false
+}
+
+// updateConfirm handles input while the commit confirmation is open.
+func (m ConfigDiffModel) updateConfirm(msg tea.Msg) (ConfigDiffModel, tea.Cmd) {
+	if key, ok := msg.(tea.KeyPressMsg); ok {
+		switch key.String() {
+		case "esc":
+			m.commit.confirm.Blur()
+			m.commit.phase = commitIdle
+			return m, nil
+		case "enter":
+			if strings.TrimSpace(m.commit.confirm.Value()) != commitConfirmWord {
+				m.commit.notice = fmt.Sprintf("Type %q to confirm, or esc to cancel", commitConfirmWord)
+				return m, nil
+			}
+			m.commit.confirm.Blur()
+			m.commit.notice = ""
+			m, cmd, _ := m.startJob(true)
+			return m, cmd
+		}
+	}
+	var cmd tea.Cmd
+	m.commit.confirm, cmd = m.commit.confirm.Update(msg)
+	return m, cmd
+}
+
+func (m ConfigDiffModel) startJob(commit bool) (ConfigDiffModel, tea.Cmd, bool) {
+	m.commit.phase = commitRunning
+	m.commit.commit = commit
+	m.commit.job = models.Job{}
+	m.commit.err = nil
+	req := CommitRequestMsg{Commit: commit, Admins: m.commit.admins}
+	return m, func() tea.Msg { return req }, true
+}
+
+// renderCommitPanel renders the confirmation prompt, job progress, or job
+// result; "" when there is nothing to show.
+func (m ConfigDiffModel) renderCommitPanel() string {
+	p := m.commit
+	var b strings.Builder
+
+	what := "all changes"
+	if len(p.admins) > 0 {
+		what = "changes by " + strings.Join(p.admins, ", ") + " (partial)"
+	}
+	verb := "Validate"
+	if p.commit {
+		verb = "Commit"
+	}
+
+	switch p.phase {
+	case commitConfirm:
+		b.WriteString(StatusWarningStyle.Render("Commit " + what + " to the running config?"))
+		b.WriteString("\n")
+		b.WriteString(DetailDimStyle.Render(fmt.Sprintf("Type %q and press enter to confirm, esc to cancel: ", commitConfirmWord)))
+		b.WriteString(p.confirm.View())
+		b.WriteString("\n")
+	case commitRunning:
+		label := verb + " " + what
+		if p.job.ID != 0 {
+			label += fmt.Sprintf(" — job %d", p.job.ID)
+		}
+		b.WriteString(RenderLoadingInline(m.SpinnerFrame, label+"..."))
+		b.WriteString("  ")
+		b.WriteString(renderBar(float64(p.job.Progress), 20, theme.Colors().Primary))
+		b.WriteString(DetailDimStyle.Render(fmt.Sprintf(" %d%%", p.job.Progress)))
+		b.WriteString("\n")
+	case commitDone:
+		b.WriteString(m.renderJobResult(verb, what))
+	}
+
+	if p.notice != "" {
+		b.WriteString(StatusWarningStyle.Render(p.notice))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func (m ConfigDiffModel) renderJobResult(verb, what string) string {
+	p := m.commit
+	var b strings.Builder
+	switch {
+	case p.err != nil:
+		b.WriteString(ErrorMsgStyle.Render(verb + " " + what + ": " + p.err.Error()))
+		b.WriteString("\n")
+	case p.job.Result == "OK":
+		head := verb + " succeeded"
+		if len(p.job.Warnings) > 0 {
+			head += fmt.Sprintf(" with %d warning(s)", len(p.job.Warnings))
+		}
+		b.WriteString(StatusActiveStyle.Render(fmt.Sprintf("%s — job %d", head, p.job.ID)))
+		b.WriteString("\n")
+	default:
+		b.WriteString(ErrorMsgStyle.Render(fmt.Sprintf("%s failed — job %d", verb, p.job.ID)))
+		b.WriteString("\n")
+	}
+
+	width := max(m.Width-14, 20)
+	lines := 0
+	for _, l := range p.job.Warnings {
+		if lines == maxJobLines {
+			break
+		}
+		b.WriteString(StatusWarningStyle.Render("  ⚠ " + truncateEllipsis(l, width)))
+		b.WriteString("\n")
+		lines++
+	}
+	detailStyle := DetailDimStyle
+	if p.job.Result != "OK" {
+		detailStyle = ErrorMsgStyle
+	}
+	for _, l := range p.job.Details {
+		if lines == maxJobLines {
+			break
+		}
+		b.WriteString(detailStyle.Render("  " + truncateEllipsis(l, width)))
+		b.WriteString("\n")
+		lines++
+	}
+	if more := len(p.job.Warnings) + len(p.job.Details) - lines; more > 0 {
+		b.WriteString(DetailDimStyle.Render(fmt.Sprintf("  … %d more line(s)", more)))
+		b.WriteString("\n")
+	}
+	b.WriteString(FilterClearHintStyle.Render("esc: dismiss"))
+	b.WriteString("\n")
+	return b.String()
+}
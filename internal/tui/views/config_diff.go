@@ -0,0 +1,560 @@
+package views
+
+import (
+	"fmt"
+	"image/color"
+	"slices"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/jp2195/pyre/internal/models"
+	"github.com/jp2195/pyre/internal/tui/theme"
+)
+
+// DiffFormat selects how hunk bodies are rendered.
+type DiffFormat int
+
+const (
+	DiffFormatXML DiffFormat = iota
+	DiffFormatSet
+)
+
+// diffRow is one screen row: a hunk header (line == -1) or one line of an
+// expanded hunk body.
+type diffRow struct {
+	hunk int
+	line int
+}
+
+// ConfigDiffModel shows the running vs. candidate config diff as a list of
+// collapsible hunks. The cursor moves over rows rather than hunks so an
+// expanded hunk taller than the screen can still be read end to end.
+type ConfigDiffModel struct {
+	TableBase
+	hunks []models.ConfigHunk
+	err   error
+
+	format   DiffFormat
+	admin    string // "" shows every admin's changes
+	expanded map[string]bool
+
+	visible []int // indices into hunks passing the admin and text filters
+	rows    []diffRow
+
+	commit commitPanel
+
+	// compare is set for a diff between two stored versions rather than
+	// running vs. candidate: there is nothing to commit and no journal.
+	compare bool
+	title   string
+}
+
+func NewConfigDiffModel() ConfigDiffModel {
+	return ConfigDiffModel{
+		TableBase: NewTableBase("Filter by xpath or admin..."),
+		expanded:  make(map[string]bool),
+		commit:    newCommitPanel(),
+		title:     "Running vs. Candidate",
+	}
+}
+
+// NewVersionDiffModel returns a diff view for comparing two saved configs
+// (see BackupsModel). Commit keys and the admin filter are off.
+func NewVersionDiffModel() ConfigDiffModel {
+	m := NewConfigDiffModel()
+	m.compare = true
+	m.title = "Compare"
+	return m
+}
+
+// SetTitle sets the banner title, e.g. the names of the versions compared.
+func (m ConfigDiffModel) SetTitle(title string) ConfigDiffModel {
+	m.title = title
+	return m
+}
+
+func (m ConfigDiffModel) SetSize(width, height int) ConfigDiffModel {
+	m.TableBase = m.TableBase.SetSize(width, height)
+	m.EnsureCursorValid(len(m.rows))
+	m.EnsureVisible(m.visibleRows())
+	return m
+}
+
+func (m ConfigDiffModel) SetLoading(loading bool) ConfigDiffModel {
+	m.TableBase = m.TableBase.SetLoading(loading)
+	return m
+}
+
+// IsLoading reports whether a fetch is in flight for this view.
+func (m ConfigDiffModel) IsLoading() bool {
+	return m.Loading
+}
+
+// SetSpinnerFrame updates the current spinner animation frame.
+func (m ConfigDiffModel) SetSpinnerFrame(frame string) ConfigDiffModel {
+	m.TableBase = m.TableBase.SetSpinnerFrame(frame)
+	return m
+}
+
+// HasData returns true once a diff (or an error) has been loaded.
+func (m ConfigDiffModel) HasData() bool {
+	return m.hunks != nil || m.err != nil
+}
+
+// IsFilterMode returns true while the filter or commit confirmation input
+// is focused.
+func (m ConfigDiffModel) IsFilterMode() bool {
+	return m.FilterMode || m.commit.phase == commitConfirm
+}
+
+// SetDiff replaces the hunks. Expansion state survives a refresh for hunks
+// that are still present; an admin filter naming someone with no remaining
+// changes is dropped.
+func (m ConfigDiffModel) SetDiff(hunks []models.ConfigHunk, err error) ConfigDiffModel {
+	m.hunks = hunks
+	m.err = err
+	m.Loading = false
+	if m.admin != "" && !slices.Contains(m.Admins(), m.admin) {
+		m.admin = ""
+	}
+	m.rebuild()
+	return m
+}
+
+// SetAdmin restricts the view to hunks touched by admin ("" for all).
+func (m ConfigDiffModel) SetAdmin(admin string) ConfigDiffModel {
+	m.admin = admin
+	m.ResetPosition()
+	m.rebuild()
+	return m
+}
+
+// Admins lists, in first-seen order, every admin with a change in the diff.
+func (m ConfigDiffModel) Admins() []string {
+	var admins []string
+	for _, h := range m.hunks {
+		for _, a := range h.Admins {
+			if !slices.Contains(admins, a) {
+				admins = append(admins, a)
+			}
+		}
+	}
+	return admins
+}
+
+// rebuild recomputes the filtered hunk list and the flattened rows, then
+// clamps the cursor.
+func (m *ConfigDiffModel) rebuild() {
+	query := strings.ToLower(m.FilterValue())
+	m.visible = nil
+	for i, h := range m.hunks {
+		if m.admin != "" && !slices.Contains(h.Admins, m.admin) {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(h.XPath+" "+strings.Join(h.Admins, " ")), query) {
+			continue
+		}
+		m.visible = append(m.visible, i)
+	}
+
+	m.rows = nil
+	for _, i := range m.visible {
+		m.rows = append(m.rows, diffRow{hunk: i, line: -1})
+		if m.expanded[m.hunks[i].XPath] {
+			for l := range m.bodyLines(m.hunks[i]) {
+				m.rows = append(m.rows, diffRow{hunk: i, line: l})
+			}
+		}
+	}
+	m.EnsureCursorValid(len(m.rows))
+	m.EnsureVisible(m.visibleRows())
+}
+
+// bodyLines is h in the current format. Hunks from the device's own diff
+// have no set rendering and stay in XML.
+func (m ConfigDiffModel) bodyLines(h models.ConfigHunk) []models.ConfigDiffLine {
+	if m.format == DiffFormatSet && len(h.Set) > 0 {
+		return h.Set
+	}
+	return h.XML
+}
+
+// visibleRows leaves room for the title, summary, and help lines, plus the
+// commit panel when it is showing.
+func (m ConfigDiffModel) visibleRows() int {
+	overhead := 8
+	if panel := m.renderCommitPanel(); panel != "" {
+		overhead += strings.Count(panel, "\n") + 1
+	}
+	return m.VisibleRows(overhead, 0)
+}
+
+func (m ConfigDiffModel) Update(msg tea.Msg) (ConfigDiffModel, tea.Cmd) {
+	if m.commit.phase == commitConfirm {
+		return m.updateConfirm(msg)
+	}
+	if m.FilterMode {
+		base, exited, cmd := m.HandleFilterMode(msg)
+		m.TableBase = base
+		if exited {
+			m.rebuild()
+		}
+		return m, cmd
+	}
+
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return m, nil
+	}
+	if !m.compare {
+		if nm, cmd, handled := m.updateCommitKeys(keyMsg.String()); handled {
+			return nm, cmd
+		}
+	}
+
+	switch keyMsg.String() {
+	case "enter", "space", " ":
+		m.toggle()
+		return m, nil
+	case "e":
+		m.toggleAll()
+		return m, nil
+	case "f":
+		if m.format == DiffFormatXML {
+			m.format = DiffFormatSet
+		} else {
+			m.format = DiffFormatXML
+		}
+		m.rebuild()
+		return m, nil
+	case "a":
+		if m.compare {
+			break
+		}
+		m.cycleAdmin()
+		return m, nil
+	case "]":
+		m.jumpHunk(1)
+		return m, nil
+	case "[":
+		m.jumpHunk(-1)
+		return m, nil
+	case "esc":
+		if m.HandleClearFilter() {
+			m.rebuild()
+		}
+		return m, nil
+	}
+
+	base, handled, cmd := m.HandleNavigation(keyMsg, len(m.rows), m.visibleRows())
+	if handled {
+		m.TableBase = base
+	}
+	return m, cmd
+}
+
+// toggle expands or collapses the hunk under the cursor, leaving the cursor
+// on its header.
+func (m *ConfigDiffModel) toggle() {
+	if m.Cursor >= len(m.rows) {
+		return
+	}
+	h := m.rows[m.Cursor].hunk
+	xpath := m.hunks[h].XPath
+	m.expanded[xpath] = !m.expanded[xpath]
+	m.rebuild()
+	m.Cursor = slices.Index(m.rows, diffRow{hunk: h, line: -1})
+	m.EnsureVisible(m.visibleRows())
+}
+
+// toggleAll expands every visible hunk, or collapses them all if they are
+// already expanded.
+func (m *ConfigDiffModel) toggleAll() {
+	allOpen := true
+	for _, i := range m.visible {
+		if !m.expanded[m.hunks[i].XPath] {
+			allOpen = false
+			break
+		}
+	}
+	for _, i := range m.visible {
+		m.expanded[m.hunks[i].XPath] = !allOpen
+	}
+	m.ResetPosition()
+	m.rebuild()
+}
+
+// cycleAdmin steps the admin filter through all → each admin → all.
+func (m *ConfigDiffModel) cycleAdmin() {
+	admins := m.Admins()
+	if len(admins) == 0 {
+		return
+	}
+	next := slices.Index(admins, m.admin) + 1
+	if m.admin == "" {
+		next = 0
+	}
+	if next >= len(admins) {
+		m.admin = ""
+	} else {
+		m.admin = admins[next]
+	}
+	m.ResetPosition()
+	m.rebuild()
+}
+
+// jumpHunk moves the cursor to the next (dir > 0) or previous hunk header.
+func (m *ConfigDiffModel) jumpHunk(dir int) {
+	for i := m.Cursor + dir; i >= 0 && i < len(m.rows); i += dir {
+		if m.rows[i].line == -1 {
+			m.Cursor = i
+			m.EnsureVisible(m.visibleRows())
+			return
+		}
+	}
+}
+
+func (m ConfigDiffModel) View() string {
+	if m.Width == 0 {
+		return RenderLoadingInline(m.SpinnerFrame, "Loading...")
+	}
+
+	titleStyle := ViewTitleStyle.MarginBottom(1)
+	panelStyle := ViewPanelStyle.Width(m.Width - 4)
+
+	var b strings.Builder
+	format := "XML"
+	if m.format == DiffFormatSet {
+		format = "set"
+	}
+	admin := m.admin
+	if admin == "" {
+		admin = "all"
+	}
+	var info string
+	if m.compare {
+		info = BannerInfoStyle.Render(fmt.Sprintf(" [%d changes | Format: %s | f: format | e: expand all | esc: back]",
+			len(m.visible), format))
+	} else {
+		keys := "f: format | a: admin | e: expand all"
+		if m.commit.writable {
+			keys += " | v: validate | c: commit"
+		}
+		info = BannerInfoStyle.Render(fmt.Sprintf(" [%d changes | Format: %s | Admin: %s | %s]",
+			len(m.visible), format, admin, keys))
+	}
+	b.WriteString(titleStyle.Render(m.title) + info)
+	b.WriteString("\n")
+
+	if m.FilterMode {
+		b.WriteString(FilterBorderStyle.Render(m.Filter.View()))
+		b.WriteString("\n\n")
+	} else if m.IsFiltered() {
+		b.WriteString(FilterActiveStyle.Render(fmt.Sprintf("Filtered: \"%s\"", m.FilterValue())))
+		b.WriteString(FilterClearHintStyle.Render(" (esc to clear)"))
+		b.WriteString("\n\n")
+	}
+
+	if panel := m.renderCommitPanel(); panel != "" {
+		b.WriteString(panel)
+		b.WriteString("\n")
+	}
+
+	if m.err != nil {
+		b.WriteString(ErrorMsgStyle.Render("Error: " + m.err.Error()))
+		return panelStyle.Render(b.String())
+	}
+	if m.Loading || m.hunks == nil {
+		loading := "Comparing running and candidate config..."
+		if m.compare {
+			loading = "Comparing configs..."
+		}
+		b.WriteString(RenderLoadingInline(m.SpinnerFrame, loading))
+		return panelStyle.Render(b.String())
+	}
+	if len(m.hunks) == 0 {
+		empty := "No uncommitted changes — candidate matches running config"
+		if m.compare {
+			empty = "No differences — the two configs match"
+		}
+		b.WriteString(EmptyMsgStyle.Render(empty))
+		return panelStyle.Render(b.String())
+	}
+	if len(m.rows) == 0 {
+		b.WriteString(EmptyMsgStyle.Render("No changes match the current filter"))
+		return panelStyle.Render(b.String())
+	}
+
+	b.WriteString(m.summary())
+	b.WriteString("\n\n")
+
+	width := max(m.Width-12, 20)
+	visible := m.visibleRows()
+	end := min(m.Offset+visible, len(m.rows))
+	for i := m.Offset; i < end; i++ {
+		row := m.rows[i]
+		h := m.hunks[row.hunk]
+		var line string
+		if row.line == -1 {
+			line = m.renderHeader(h, width)
+		} else {
+			line = m.renderLine(m.bodyLines(h)[row.line], width)
+		}
+		if i == m.Cursor {
+			line = TableSelectedRowStyle().Render(lipgloss.NewStyle().Width(width).Render(line))
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	if len(m.rows) > visible {
+		b.WriteString(DetailDimStyle.Render(fmt.Sprintf("  Showing %d-%d of %d lines", m.Offset+1, end, len(m.rows))))
+	}
+
+	return panelStyle.Render(b.String())
+}
+
+// summary counts the visible hunks by kind.
+func (m ConfigDiffModel) summary() string {
+	counts := make(map[string]int)
+	for _, i := range m.visible {
+		counts[m.hunks[i].Kind]++
+	}
+	var parts []string
+	for _, kind := range []string{"added", "modified", "removed", "reordered"} {
+		if n := counts[kind]; n > 0 {
+			parts = append(parts, hunkKindStyle(kind).Render(fmt.Sprintf("%d %s", n, kind)))
+		}
+	}
+	return strings.Join(parts, DetailDimStyle.Render(", "))
+}
+
+func (m ConfigDiffModel) renderHeader(h models.ConfigHunk, width int) string {
+	marker := "▸"
+	if m.expanded[h.XPath] {
+		marker = "▾"
+	}
+	kind := hunkKindStyle(h.Kind).Render(fmt.Sprintf("%-9s", h.Kind))
+	admins := ""
+	if len(h.Admins) > 0 {
+		admins = "  " + DetailDimStyle.Render("by "+strings.Join(h.Admins, ", "))
+	}
+	room := width - 14 - lipgloss.Width(admins)
+	return marker + " " + kind + " " + DetailValueStyle.Render(truncateLeft(h.XPath, room)) + admins
+}
+
+func (m ConfigDiffModel) renderLine(l models.ConfigDiffLine, width int) string {
+	c := theme.Colors()
+	opStyle := DetailDimStyle
+	textColor := c.TextMuted
+	switch l.Op {
+	case '+':
+		opStyle = lipgloss.NewStyle().Foreground(c.Success)
+		textColor = c.Success
+	case '-':
+		opStyle = lipgloss.NewStyle().Foreground(c.Error)
+		textColor = c.Error
+	}
+	indent := strings.Repeat("  ", l.Depth)
+	text := truncateEllipsis(l.Text, max(width-4-len(indent), 10))
+	var body string
+	if m.format == DiffFormatSet {
+		body = highlightSet(text, textColor)
+	} else {
+		body = highlightXML(text, textColor)
+	}
+	return "  " + opStyle.Render(string(l.Op)) + " " + indent + body
+}
+
+func hunkKindStyle(kind string) lipgloss.Style {
+	switch kind {
+	case "added":
+		return StatusActiveStyle
+	case "removed":
+		return ErrorMsgStyle
+	case "reordered":
+		return StatusWarningStyle
+	default:
+		return TagStyle
+	}
+}
+
+// truncateLeft keeps the tail of s, which for an XPath is the part that
+// names the object.
+func truncateLeft(s string, maxLen int) string {
+	r := []rune(s)
+	if maxLen < 4 || len(r) <= maxLen {
+		return s
+	}
+	return "…" + string(r[len(r)-maxLen+1:])
+}
+
+// highlightXML colours one line of indented XML: tag names in the primary
+// colour, attribute values in the accent colour, and element text in the
+// diff colour for the line.
+func highlightXML(s string, textColor color.Color) string {
+	c := theme.Colors()
+	tagStyle := lipgloss.NewStyle().Foreground(c.Primary)
+	attrStyle := lipgloss.NewStyle().Foreground(c.Accent)
+	textStyle := lipgloss.NewStyle().Foreground(textColor)
+
+	var b strings.Builder
+	for s != "" {
+		if s[0] != '<' {
+			i := strings.IndexByte(s, '<')
+			if i < 0 {
+				i = len(s)
+			}
+			b.WriteString(textStyle.Render(s[:i]))
+			s = s[i:]
+			continue
+		}
+		end := strings.IndexByte(s, '>')
+		if end < 0 {
+			end = len(s) - 1
+		}
+		tag := s[:end+1]
+		s = s[end+1:]
+		// Split the tag into its name and any quoted attribute values.
+		for tag != "" {
+			q := strings.IndexByte(tag, '"')
+			if q < 0 {
+				b.WriteString(tagStyle.Render(tag))
+				break
+			}
+			closing := strings.IndexByte(tag[q+1:], '"')
+			if closing < 0 {
+				b.WriteString(tagStyle.Render(tag))
+				break
+			}
+			b.WriteString(tagStyle.Render(tag[:q]))
+			b.WriteString(attrStyle.Render(tag[q : q+closing+2]))
+			tag = tag[q+closing+2:]
+		}
+	}
+	return b.String()
+}
+
+// highlightSet colours one set command: the verb in the primary colour,
+// quoted words and list brackets in the accent colour, the rest in the diff
+// colour for the line.
+func highlightSet(s string, textColor color.Color) string {
+	c := theme.Colors()
+	verbStyle := lipgloss.NewStyle().Foreground(c.Primary).Bold(true)
+	accent := lipgloss.NewStyle().Foreground(c.Accent)
+	textStyle := lipgloss.NewStyle().Foreground(textColor)
+
+	verb, rest, _ := strings.Cut(s, " ")
+	var b strings.Builder
+	b.WriteString(verbStyle.Render(verb))
+	for _, word := range strings.Split(rest, " ") {
+		b.WriteString(" ")
+		if word == "[" || word == "]" || strings.HasPrefix(word, `"`) || strings.HasSuffix(word, `"`) {
+			b.WriteString(accent.Render(word))
+		} else {
+			b.WriteString(textStyle.Render(word))
+		}
+	}
+	return b.String()
+}
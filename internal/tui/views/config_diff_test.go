@@ -0,0 +1,199 @@
+package views
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/jp2195/pyre/internal/models"
+)
+
+func testConfigDiff() ConfigDiffModel {
+	InitStyles()
+	m := NewConfigDiffModel().SetSize(120, 40)
+	return m.SetDiff([]models.ConfigHunk{
+		{
+			XPath:  "/config/shared/address/entry[@name='db-primary']",
+			Kind:   "modified",
+			Admins: []string{"admin"},
+			XML: []models.ConfigDiffLine{
+				{Op: ' ', Text: `<entry name="db-primary">`},
+				{Op: '-', Depth: 1, Text: "<ip-netmask>10.0.0.20/32</ip-netmask>"},
+				{Op: '+', Depth: 1, Text: "<ip-netmask>10.0.0.21/32</ip-netmask>"},
+				{Op: ' ', Text: "</entry>"},
+			},
+			Set: []models.ConfigDiffLine{
+				{Op: '-', Text: "set shared address db-primary ip-netmask 10.0.0.20/32"},
+				{Op: '+', Text: "set shared address db-primary ip-netmask 10.0.0.21/32"},
+			},
+		},
+		{
+			XPath:  "/config/shared/rulebase/security/rules/entry[@name='allow-partner-ssh']",
+			Kind:   "added",
+			Admins: []string{"netops"},
+			XML:    []models.ConfigDiffLine{{Op: '+', Text: `<entry name="allow-partner-ssh"/>`}},
+			Set:    []models.ConfigDiffLine{{Op: '+', Text: "set rulebase security rules allow-partner-ssh"}},
+		},
+	}, nil)
+}
+
+func TestConfigDiffModel_ToggleExpandsHunk(t *testing.T) {
+	m := testConfigDiff()
+	if len(m.rows) != 2 {
+		t.Fatalf("collapsed rows = %d, want 2", len(m.rows))
+	}
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if len(m.rows) != 6 {
+		t.Fatalf("rows after expanding first hunk = %d, want 6", len(m.rows))
+	}
+	if !strings.Contains(stripANSI(m.View()), "10.0.0.21/32") {
+		t.Error("expanded hunk body not rendered")
+	}
+
+	// ] skips over the body to the next header.
+	m, _ = m.Update(tea.KeyPressMsg{Code: ']', Text: "]"})
+	if m.Cursor != 5 {
+		t.Errorf("cursor after ] = %d, want 5", m.Cursor)
+	}
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: '[', Text: "["})
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if len(m.rows) != 2 {
+		t.Errorf("rows after collapsing = %d, want 2", len(m.rows))
+	}
+}
+
+func TestConfigDiffModel_FormatToggle(t *testing.T) {
+	m := testConfigDiff()
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'e', Text: "e"})
+	if len(m.rows) != 2+4+1 {
+		t.Fatalf("XML rows = %d, want 7", len(m.rows))
+	}
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'f', Text: "f"})
+	if len(m.rows) != 2+2+1 {
+		t.Errorf("set rows = %d, want 5", len(m.rows))
+	}
+	if !strings.Contains(stripANSI(m.View()), "db-primary ip-netmask") {
+		t.Error("set format not rendered")
+	}
+}
+
+func TestConfigDiffModel_AdminFilter(t *testing.T) {
+	m := testConfigDiff()
+	if got := m.Admins(); len(got) != 2 {
+		t.Fatalf("Admins() = %v", got)
+	}
+
+	want := []string{"admin", "netops", ""}
+	for _, w := range want {
+		m, _ = m.Update(tea.KeyPressMsg{Code: 'a', Text: "a"})
+		if m.admin != w {
+			t.Fatalf("admin = %q, want %q", m.admin, w)
+		}
+	}
+
+	m = m.SetAdmin("netops")
+	if len(m.visible) != 1 || m.hunks[m.visible[0]].Kind != "added" {
+		t.Errorf("netops filter shows %v", m.visible)
+	}
+}
+
+func TestConfigDiffModel_SetDiffDropsStaleAdmin(t *testing.T) {
+	m := testConfigDiff().SetAdmin("netops")
+	m = m.SetDiff([]models.ConfigHunk{}, nil)
+	if m.admin != "" {
+		t.Errorf("admin = %q, want cleared", m.admin)
+	}
+	if !strings.Contains(m.View(), "No uncommitted changes") {
+		t.Error("expected empty-diff message")
+	}
+}
+
+func TestConfigDiffModel_Error(t *testing.T) {
+	m := NewConfigDiffModel().SetSize(120, 40)
+	m = m.SetDiff(nil, errors.New("access denied"))
+	if !m.HasData() {
+		t.Error("HasData should be true after an error")
+	}
+	if !strings.Contains(m.View(), "access denied") {
+		t.Error("error not rendered")
+	}
+}
+
+func typeText(m ConfigDiffModel, s string) ConfigDiffModel {
+	for _, r := range s {
+		m, _ = m.Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+	}
+	return m
+}
+
+func TestConfigDiffModel_ReadOnlyRefusesJobs(t *testing.T) {
+	m := testConfigDiff()
+	for _, key := range []rune{'v', 'c'} {
+		var cmd tea.Cmd
+		m, cmd = m.Update(tea.KeyPressMsg{Code: key, Text: string(key)})
+		if cmd != nil || m.JobRunning() || m.IsFilterMode() {
+			t.Errorf("%c on a read-only connection started something", key)
+		}
+	}
+	if !strings.Contains(stripANSI(m.View()), "allow_write") {
+		t.Error("expected a hint about allow_write")
+	}
+}
+
+func TestConfigDiffModel_CommitNeedsTypedConfirmation(t *testing.T) {
+	m := testConfigDiff().SetWritable(true).SetAdmin("netops")
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'c', Text: "c"})
+	if !m.IsFilterMode() {
+		t.Fatal("c should open the confirmation input")
+	}
+
+	m = typeText(m, "yes")
+	m, cmd := m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if cmd != nil || m.JobRunning() {
+		t.Fatal("the wrong word must not start a commit")
+	}
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'c', Text: "c"})
+	m = typeText(m, "commit")
+	m, cmd = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("expected a commit request")
+	}
+	req, ok := cmd().(CommitRequestMsg)
+	if !ok || !req.Commit || len(req.Admins) != 1 || req.Admins[0] != "netops" {
+		t.Fatalf("request = %#v, want a partial commit for netops", req)
+	}
+	if !m.JobRunning() || m.IsFilterMode() {
+		t.Error("expected a running job and the input closed")
+	}
+
+	m = m.SetJob(models.Job{ID: 9, Status: "FIN", Result: "OK", Warnings: []string{"shadowed rule"}}, nil)
+	view := stripANSI(m.View())
+	if !strings.Contains(view, "Commit succeeded with 1 warning(s)") || !strings.Contains(view, "shadowed rule") {
+		t.Errorf("result not rendered:\n%s", view)
+	}
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+	if strings.Contains(stripANSI(m.View()), "Commit succeeded") {
+		t.Error("esc should dismiss the job result")
+	}
+}
+
+func TestConfigDiffModel_FailedValidateShowsErrors(t *testing.T) {
+	m := testConfigDiff().SetWritable(true)
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'v', Text: "v"})
+	m = m.SetJob(models.Job{ID: 3, Status: "FIN", Result: "FAIL",
+		Details: []string{"Validation Error:", "rule 'x' references unknown zone 'dmz2'", "Validation failed"}}, nil)
+
+	view := stripANSI(m.View())
+	if !strings.Contains(view, "Validate failed") || !strings.Contains(view, "unknown zone 'dmz2'") {
+		t.Errorf("failure not rendered:\n%s", view)
+	}
+}
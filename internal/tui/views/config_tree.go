@@ -0,0 +1,545 @@
+package views
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/jp2195/pyre/internal/pancfg"
+	"github.com/jp2195/pyre/internal/tui/theme"
+)
+
+// ConfigTreeRequestMsg asks the app to fetch the config elements at XPaths
+// for the tree browser. Device and Candidate identify the tree that asked
+// so a reply for a tree since replaced can be dropped.
+type ConfigTreeRequestMsg struct {
+	Device    string
+	Candidate bool
+	XPaths    []string
+}
+
+// treeNode is one element of the browsed config. A fetchable node is
+// loaded on its own by xpath when first expanded; the API has no depth
+// limit, so each fetch returns the whole subtree, of which one level is
+// kept and the rest left to be fetched when the user goes deeper. Unnamed
+// siblings that share a tag cannot be addressed on their own and are kept
+// inline with their subtree.
+type treeNode struct {
+	elem      *pancfg.Node // attributes and text; children are held below
+	xpath     string
+	parent    *treeNode
+	children  []*treeNode
+	fetchable bool
+	leaf      bool
+	loaded    bool
+	loading   bool
+	expanded  bool
+	err       error
+}
+
+// treeRow is one screen row of the flattened tree.
+type treeRow struct {
+	node  *treeNode
+	depth int
+}
+
+// ConfigTreeModel browses the device configuration as an expandable tree,
+// covering the parts of the config pyre has no dedicated view for.
+type ConfigTreeModel struct {
+	TableBase
+	device    string
+	candidate bool
+	root      *treeNode
+	rows      []treeRow
+	matches   int
+	notice    string
+}
+
+func NewConfigTreeModel() ConfigTreeModel {
+	m := ConfigTreeModel{TableBase: NewTableBase("Search loaded nodes...")}
+	m.reset()
+	return m
+}
+
+// reset replaces the tree with the top of a PAN-OS config. Fetching
+// /config itself would pull the entire configuration, so the first levels
+// are seeded and each branch is fetched when expanded.
+func (m *ConfigTreeModel) reset() {
+	root := &treeNode{elem: element("config", ""), xpath: "/config", loaded: true, expanded: true}
+	root.add(element("mgt-config", ""), true)
+	root.add(element("shared", ""), true)
+	devices := root.add(element("devices", ""), false)
+	devices.loaded = true
+	devices.expanded = true
+	devices.add(element("entry", "localhost.localdomain"), true)
+
+	m.root = root
+	m.ResetPosition()
+	m.rebuild()
+}
+
+func element(tag, name string) *pancfg.Node {
+	n := &pancfg.Node{}
+	n.XMLName.Local = tag
+	if name != "" {
+		n.Attrs = []xml.Attr{{Name: xml.Name{Local: "name"}, Value: name}}
+	}
+	return n
+}
+
+// add appends a child built from elem, which is not yet loaded.
+func (n *treeNode) add(elem *pancfg.Node, fetchable bool) *treeNode {
+	c := &treeNode{
+		elem:      &pancfg.Node{XMLName: elem.XMLName, Attrs: elem.Attrs, Text: elem.Text},
+		xpath:     n.xpath + "/" + pancfg.Step(elem),
+		parent:    n,
+		fetchable: fetchable,
+	}
+	n.children = append(n.children, c)
+	return c
+}
+
+// setChildren replaces n's children with those of elem. Children that are
+// addressable by xpath are left unloaded unless they were loaded before, in
+// which case they are updated from elem's subtree; the rest always keep
+// their subtree. Expansion survives for children that are still present,
+// so a refresh does not collapse what the user opened.
+func (n *treeNode) setChildren(elem *pancfg.Node) {
+	old := make(map[string]*treeNode, len(n.children))
+	for i, c := range n.children {
+		old[n.childKey(i)] = c
+	}
+	tags := make(map[string]int)
+	for _, c := range elem.Children {
+		tags[c.XMLName.Local]++
+	}
+
+	n.children = nil
+	for _, e := range elem.Children {
+		fetchable := e.Name() != "" || tags[e.XMLName.Local] == 1
+		c := n.add(e, fetchable)
+		c.leaf = len(e.Children) == 0
+		if c.leaf {
+			continue
+		}
+		prev, seen := old[n.childKey(len(n.children)-1)]
+		if seen {
+			c.expanded = prev.expanded
+		}
+		if !fetchable || (seen && prev.loaded) {
+			if seen {
+				c.children = prev.children
+				for _, gc := range c.children {
+					gc.parent = c
+				}
+			}
+			c.setChildren(e)
+			c.loaded = true
+		}
+	}
+}
+
+// childKey identifies the i-th child across refreshes: its xpath, plus its
+// position among siblings with the same xpath.
+func (n *treeNode) childKey(i int) string {
+	c := n.children[i]
+	seen := 0
+	for _, s := range n.children[:i] {
+		if s.xpath == c.xpath {
+			seen++
+		}
+	}
+	return c.xpath + "#" + strconv.Itoa(seen)
+}
+
+// walk calls fn for n and every loaded descendant.
+func (n *treeNode) walk(fn func(*treeNode)) {
+	fn(n)
+	for _, c := range n.children {
+		c.walk(fn)
+	}
+}
+
+func (m ConfigTreeModel) find(xpath string) *treeNode {
+	var found *treeNode
+	m.root.walk(func(n *treeNode) {
+		if found == nil && n.fetchable && n.xpath == xpath {
+			found = n
+		}
+	})
+	return found
+}
+
+func (m ConfigTreeModel) SetSize(width, height int) ConfigTreeModel {
+	m.TableBase = m.TableBase.SetSize(width, height)
+	m.EnsureCursorValid(len(m.rows))
+	m.EnsureVisible(m.visibleRows())
+	return m
+}
+
+// SetLoading(true) marks the outermost fetched branches for a refresh;
+// their replies update the branches fetched inside them too. Pending lists
+// them. SetLoading(false) abandons all fetches in flight.
+func (m ConfigTreeModel) SetLoading(loading bool) ConfigTreeModel {
+	var mark func(n *treeNode)
+	mark = func(n *treeNode) {
+		if loading && n.fetchable && n.loaded {
+			n.loading = true
+			return
+		}
+		n.loading = false
+		for _, c := range n.children {
+			mark(c)
+		}
+	}
+	mark(m.root)
+	return m
+}
+
+// Pending returns the xpaths of the branches being fetched.
+func (m ConfigTreeModel) Pending() []string {
+	var xpaths []string
+	m.root.walk(func(n *treeNode) {
+		if n.loading {
+			xpaths = append(xpaths, n.xpath)
+		}
+	})
+	return xpaths
+}
+
+// IsLoading reports whether any branch is being fetched.
+func (m ConfigTreeModel) IsLoading() bool {
+	return len(m.Pending()) > 0
+}
+
+// SetSpinnerFrame updates the current spinner animation frame.
+func (m ConfigTreeModel) SetSpinnerFrame(frame string) ConfigTreeModel {
+	m.TableBase = m.TableBase.SetSpinnerFrame(frame)
+	return m
+}
+
+// HasData reports whether the tree belongs to a device; the top levels
+// need no fetch.
+func (m ConfigTreeModel) HasData() bool {
+	return m.device != ""
+}
+
+// IsFilterMode returns true while the search input is focused.
+func (m ConfigTreeModel) IsFilterMode() bool {
+	return m.FilterMode
+}
+
+// Device is the device whose config the tree shows.
+func (m ConfigTreeModel) Device() string {
+	return m.device
+}
+
+// Candidate reports whether the tree shows the candidate config rather
+// than the running one.
+func (m ConfigTreeModel) Candidate() bool {
+	return m.candidate
+}
+
+// SetDevice switches to another device's config, starting a fresh tree.
+func (m ConfigTreeModel) SetDevice(device string) ConfigTreeModel {
+	if device == m.device {
+		return m
+	}
+	m.device = device
+	m.notice = ""
+	m.reset()
+	return m
+}
+
+// SetElement fills in the branch at xpath with a fetched element. Replies
+// for another device or config source, or for a branch no longer in the
+// tree, are dropped.
+func (m ConfigTreeModel) SetElement(device string, candidate bool, xpath string, elem *pancfg.Node, err error) ConfigTreeModel {
+	if device != m.device || candidate != m.candidate {
+		return m
+	}
+	n := m.find(xpath)
+	if n == nil {
+		return m
+	}
+	n.loading = false
+	n.err = err
+	if err == nil {
+		n.elem = &pancfg.Node{XMLName: elem.XMLName, Attrs: elem.Attrs, Text: elem.Text}
+		n.setChildren(elem)
+		n.loaded = true
+		n.leaf = len(n.children) == 0
+	}
+	m.rebuild()
+	return m
+}
+
+// rebuild flattens the tree into rows. While searching, every loaded node
+// that matches is shown along with its ancestors, expanded or not.
+func (m *ConfigTreeModel) rebuild() {
+	query := strings.ToLower(m.FilterValue())
+	m.rows = nil
+	m.matches = 0
+	var visit func(n *treeNode, depth int) bool
+	visit = func(n *treeNode, depth int) bool {
+		if query == "" {
+			m.rows = append(m.rows, treeRow{node: n, depth: depth})
+			if n.expanded {
+				for _, c := range n.children {
+					visit(c, depth+1)
+				}
+			}
+			return true
+		}
+		at := len(m.rows)
+		m.rows = append(m.rows, treeRow{node: n, depth: depth})
+		hit := nodeMatches(n, query)
+		if hit {
+			m.matches++
+		}
+		for _, c := range n.children {
+			if visit(c, depth+1) {
+				hit = true
+			}
+		}
+		if !hit {
+			m.rows = m.rows[:at]
+		}
+		return hit
+	}
+	visit(m.root, 0)
+	m.EnsureCursorValid(len(m.rows))
+	m.EnsureVisible(m.visibleRows())
+}
+
+func nodeMatches(n *treeNode, query string) bool {
+	if strings.Contains(strings.ToLower(n.elem.XMLName.Local), query) ||
+		strings.Contains(strings.ToLower(n.elem.Text), query) {
+		return true
+	}
+	for _, a := range n.elem.Attrs {
+		if strings.Contains(strings.ToLower(a.Value), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// Selected returns the xpath of the node under the cursor.
+func (m ConfigTreeModel) Selected() string {
+	if m.Cursor >= len(m.rows) {
+		return ""
+	}
+	return m.rows[m.Cursor].node.xpath
+}
+
+// visibleRows leaves room for the title, search, notice, and xpath footer.
+func (m ConfigTreeModel) visibleRows() int {
+	overhead := 9
+	if m.FilterMode || m.IsFiltered() {
+		overhead += 2
+	}
+	return m.VisibleRows(overhead, 0)
+}
+
+func (m ConfigTreeModel) Update(msg tea.Msg) (ConfigTreeModel, tea.Cmd) {
+	if m.FilterMode {
+		before := m.FilterValue()
+		base, exited, cmd := m.HandleFilterMode(msg)
+		m.TableBase = base
+		if m.FilterValue() != before {
+			m.ResetPosition()
+		}
+		if exited || m.FilterValue() != before {
+			m.rebuild()
+		}
+		return m, cmd
+	}
+
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return m, nil
+	}
+	switch keyMsg.String() {
+	case "enter", "space", " ", "l", "right":
+		return m.toggle()
+	case "h", "left":
+		m.collapseOrParent()
+		return m, nil
+	case "y":
+		xpath := m.Selected()
+		if xpath == "" {
+			return m, nil
+		}
+		m.notice = "Copied " + xpath
+		return m, tea.SetClipboard(xpath)
+	case "t":
+		m.candidate = !m.candidate
+		m.notice = ""
+		m.reset()
+		return m, nil
+	case "esc":
+		if m.HandleClearFilter() {
+			m.rebuild()
+		} else {
+			m.notice = ""
+		}
+		return m, nil
+	}
+
+	base, handled, cmd := m.HandleNavigation(keyMsg, len(m.rows), m.visibleRows())
+	if handled {
+		m.TableBase = base
+	}
+	return m, cmd
+}
+
+// toggle expands or collapses the node under the cursor, fetching it first
+// if it has not been loaded.
+func (m ConfigTreeModel) toggle() (ConfigTreeModel, tea.Cmd) {
+	if m.Cursor >= len(m.rows) {
+		return m, nil
+	}
+	n := m.rows[m.Cursor].node
+	if n.leaf || n.loading {
+		return m, nil
+	}
+	if n.loaded {
+		n.expanded = !n.expanded
+		m.rebuild()
+		return m, nil
+	}
+	n.expanded = true
+	n.loading = true
+	n.err = nil
+	m.rebuild()
+	req := ConfigTreeRequestMsg{Device: m.device, Candidate: m.candidate, XPaths: []string{n.xpath}}
+	return m, func() tea.Msg { return req }
+}
+
+// collapseOrParent collapses the node under the cursor, or moves to its
+// parent when it is already collapsed.
+func (m *ConfigTreeModel) collapseOrParent() {
+	if m.Cursor >= len(m.rows) {
+		return
+	}
+	n := m.rows[m.Cursor].node
+	if n.expanded && !n.leaf {
+		n.expanded = false
+		m.rebuild()
+		return
+	}
+	for i := m.Cursor - 1; i >= 0; i-- {
+		if m.rows[i].node == n.parent {
+			m.Cursor = i
+			m.EnsureVisible(m.visibleRows())
+			return
+		}
+	}
+}
+
+func (m ConfigTreeModel) View() string {
+	if m.Width == 0 {
+		return RenderLoadingInline(m.SpinnerFrame, "Loading...")
+	}
+
+	titleStyle := ViewTitleStyle.MarginBottom(1)
+	panelStyle := ViewPanelStyle.Width(m.Width - 4)
+
+	source := "running"
+	if m.candidate {
+		source = "candidate"
+	}
+	var b strings.Builder
+	info := BannerInfoStyle.Render(fmt.Sprintf(" [%s | enter: expand | y: copy xpath | t: running/candidate | /: search]", source))
+	b.WriteString(titleStyle.Render("Config Tree") + info)
+	b.WriteString("\n")
+
+	if m.FilterMode {
+		b.WriteString(FilterBorderStyle.Render(m.Filter.View()))
+		b.WriteString("\n\n")
+	} else if m.IsFiltered() {
+		b.WriteString(FilterActiveStyle.Render(fmt.Sprintf("Search: \"%s\" — %d match(es) in loaded nodes", m.FilterValue(), m.matches)))
+		b.WriteString(FilterClearHintStyle.Render(" (esc to clear)"))
+		b.WriteString("\n\n")
+	}
+
+	if len(m.rows) == 0 {
+		b.WriteString(EmptyMsgStyle.Render("No loaded nodes match — expand branches to search deeper"))
+		b.WriteString("\n")
+	}
+
+	width := max(m.Width-12, 20)
+	visible := m.visibleRows()
+	end := min(m.Offset+visible, len(m.rows))
+	for i := m.Offset; i < end; i++ {
+		line := m.renderRow(m.rows[i], width)
+		if i == m.Cursor {
+			line = TableSelectedRowStyle().Render(lipgloss.NewStyle().Width(width).Render(line))
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	if len(m.rows) > visible {
+		b.WriteString(DetailDimStyle.Render(fmt.Sprintf("  Showing %d-%d of %d nodes", m.Offset+1, end, len(m.rows))))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	if m.notice != "" {
+		b.WriteString(StatusActiveStyle.Render(truncateLeft(m.notice, width)))
+	} else {
+		b.WriteString(DetailLabelStyle.Render("XPath: ") + DetailValueStyle.Render(truncateLeft(m.Selected(), width-7)))
+	}
+	return panelStyle.Render(b.String())
+}
+
+func (m ConfigTreeModel) renderRow(row treeRow, width int) string {
+	c := theme.Colors()
+	n := row.node
+	marker := "▸"
+	switch {
+	case n.leaf:
+		marker = "•"
+	case n.loading:
+		marker = m.SpinnerFrame
+	case n.expanded && n.loaded:
+		marker = "▾"
+	}
+	indent := strings.Repeat("  ", row.depth)
+	room := max(width-len(indent)-2, 20)
+
+	// Tag, name, and text take what they need; other attributes (uuid,
+	// and in the candidate the journal's admin and time) get the rest.
+	name := truncateEllipsis(n.elem.Name(), room/2)
+	label := n.elem.XMLName.Local
+	if name != "" {
+		label += " " + name
+	}
+	text := truncateEllipsis(strings.TrimSpace(n.elem.Text), max(room-len(label)-2, 8))
+	line := lipgloss.NewStyle().Foreground(c.Primary).Render(n.elem.XMLName.Local)
+	if name != "" {
+		line += " " + lipgloss.NewStyle().Foreground(c.Accent).Render(name)
+	}
+	if text != "" {
+		line += DetailDimStyle.Render(": ") + DetailValueStyle.Render(text)
+		label += ": " + text
+	}
+	var attrs []string
+	for _, a := range n.elem.Attrs {
+		if a.Name.Local != "name" {
+			attrs = append(attrs, a.Name.Local+"="+strconv.Quote(a.Value))
+		}
+	}
+	if rest := room - lipgloss.Width(label) - 2; len(attrs) > 0 && rest > 10 {
+		line += "  " + DetailDimStyle.Render(truncateEllipsis(strings.Join(attrs, " "), rest))
+	}
+	if n.err != nil {
+		line += "  " + ErrorMsgStyle.Render(truncateEllipsis(n.err.Error(), max(width/2, 20)))
+	}
+	return indent + DetailDimStyle.Render(marker) + " " + line
+}
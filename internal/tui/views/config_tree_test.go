@@ -0,0 +1,181 @@
+package views
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/jp2195/pyre/internal/pancfg"
+)
+
+func testTree() ConfigTreeModel {
+	InitStyles()
+	return NewConfigTreeModel().SetSize(140, 40).SetDevice("fw1")
+}
+
+func parseElem(t *testing.T, doc string) *pancfg.Node {
+	t.Helper()
+	n, err := pancfg.ParseElement(doc)
+	if err != nil {
+		t.Fatalf("ParseElement: %v", err)
+	}
+	return n
+}
+
+// cursorTo moves the cursor to the row whose xpath is xpath.
+func cursorTo(t *testing.T, m ConfigTreeModel, xpath string) ConfigTreeModel {
+	t.Helper()
+	for i, r := range m.rows {
+		if r.node.xpath == xpath {
+			m.Cursor = i
+			return m
+		}
+	}
+	t.Fatalf("no row for %s", xpath)
+	return m
+}
+
+// expand presses enter on xpath and returns the fetch it requests.
+func expand(t *testing.T, m ConfigTreeModel, xpath string) (ConfigTreeModel, ConfigTreeRequestMsg) {
+	t.Helper()
+	m, cmd := cursorTo(t, m, xpath).Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatalf("expanding %s should fetch it", xpath)
+	}
+	req, ok := cmd().(ConfigTreeRequestMsg)
+	if !ok {
+		t.Fatalf("cmd produced %T, want ConfigTreeRequestMsg", cmd())
+	}
+	return m, req
+}
+
+const treeShared = `<shared><address>` +
+	`<entry name="web"><ip-netmask>10.0.0.1/32</ip-netmask></entry>` +
+	`<entry name="db"><ip-netmask>10.0.1.5/32</ip-netmask><description>primary</description></entry>` +
+	`</address><service/></shared>`
+
+func TestConfigTreeModel_LazyExpand(t *testing.T) {
+	m, req := expand(t, testTree(), "/config/shared")
+	if req.Device != "fw1" || req.Candidate || !slices.Equal(req.XPaths, []string{"/config/shared"}) {
+		t.Fatalf("request = %+v", req)
+	}
+	if !m.IsLoading() {
+		t.Error("the branch should show as loading")
+	}
+
+	m = m.SetElement("fw1", false, "/config/shared", parseElem(t, treeShared), nil)
+	view := stripANSI(m.View())
+	if !strings.Contains(view, "address") || !strings.Contains(view, "service") {
+		t.Fatalf("children of shared not shown:\n%s", view)
+	}
+	if strings.Contains(view, "web") {
+		t.Error("grandchildren should wait for their own fetch")
+	}
+
+	m, req = expand(t, m, "/config/shared/address")
+	if req.XPaths[0] != "/config/shared/address" {
+		t.Fatalf("request = %+v", req)
+	}
+	m = m.SetElement("fw1", false, "/config/shared/address",
+		parseElem(t, `<address><entry name="web"><ip-netmask>10.0.0.1/32</ip-netmask></entry></address>`), nil)
+	if !strings.Contains(stripANSI(m.View()), "entry web") {
+		t.Error("named entries should be shown after expanding")
+	}
+	if _, req = expand(t, m, "/config/shared/address/entry[@name='web']"); req.XPaths[0] != "/config/shared/address/entry[@name='web']" {
+		t.Errorf("entry request = %+v", req)
+	}
+}
+
+func TestConfigTreeModel_UnaddressableSiblingsInline(t *testing.T) {
+	m, _ := expand(t, testTree(), "/config/mgt-config")
+	m = m.SetElement("fw1", false, "/config/mgt-config", parseElem(t,
+		`<mgt-config><rule><to>a</to></rule><rule><to>b</to></rule></mgt-config>`), nil)
+
+	m = cursorTo(t, m, "/config/mgt-config/rule")
+	m, cmd := m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if cmd != nil {
+		t.Error("an unnamed repeated element cannot be fetched on its own; its subtree is already loaded")
+	}
+	if !strings.Contains(stripANSI(m.View()), "to: a") {
+		t.Error("inline subtree should expand without a fetch")
+	}
+}
+
+func TestConfigTreeModel_RefreshKeepsExpansion(t *testing.T) {
+	m, _ := expand(t, testTree(), "/config/shared")
+	m = m.SetElement("fw1", false, "/config/shared", parseElem(t, treeShared), nil)
+	m, _ = expand(t, m, "/config/shared/address")
+	m = m.SetElement("fw1", false, "/config/shared/address", parseElem(t, strings.TrimSuffix(
+		strings.TrimPrefix(treeShared, "<shared>"), "<service/></shared>")), nil)
+
+	m = m.SetLoading(true)
+	if got := m.Pending(); !slices.Equal(got, []string{"/config/shared"}) {
+		t.Fatalf("Pending = %v; want only the outermost fetched branch", got)
+	}
+
+	// The reply for shared carries the whole subtree, updating the loaded
+	// address branch in place.
+	updated := strings.Replace(treeShared, `<entry name="web">`, `<entry name="app">`, 1)
+	m = m.SetElement("fw1", false, "/config/shared", parseElem(t, updated), nil)
+	view := stripANSI(m.View())
+	if !strings.Contains(view, "entry app") || strings.Contains(view, "entry web") {
+		t.Errorf("refresh should update the expanded address branch:\n%s", view)
+	}
+	if m.IsLoading() {
+		t.Error("nothing should be left loading")
+	}
+}
+
+func TestConfigTreeModel_SearchLoadedNodes(t *testing.T) {
+	m, _ := expand(t, testTree(), "/config/shared")
+	m = m.SetElement("fw1", false, "/config/shared", parseElem(t, treeShared), nil)
+	m, _ = expand(t, m, "/config/shared/address")
+	m = m.SetElement("fw1", false, "/config/shared/address", parseElem(t,
+		`<address><entry name="web"/><entry name="db"/></address>`), nil)
+	// Collapse address: search still finds what was loaded under it.
+	m, _ = cursorTo(t, m, "/config/shared/address").Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: '/', Text: "/"})
+	for _, r := range "db" {
+		m, _ = m.Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+	}
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if got := m.Selected(); got != "/config" {
+		t.Errorf("first row = %s; want the root as an ancestor of the match", got)
+	}
+	view := stripANSI(m.View())
+	if !strings.Contains(view, "entry db") || strings.Contains(view, "entry web") || !strings.Contains(view, "1 match") {
+		t.Errorf("search view:\n%s", view)
+	}
+}
+
+func TestConfigTreeModel_CopyXPath(t *testing.T) {
+	m := cursorTo(t, testTree(), "/config/devices/entry[@name='localhost.localdomain']")
+	if !strings.Contains(stripANSI(m.View()), "XPath: /config/devices/entry[@name='localhost.localdomain']") {
+		t.Error("the selected xpath should be shown")
+	}
+	m, cmd := m.Update(tea.KeyPressMsg{Code: 'y', Text: "y"})
+	if cmd == nil {
+		t.Fatal("y should copy to the clipboard")
+	}
+	if !strings.Contains(stripANSI(m.View()), "Copied /config/devices/entry") {
+		t.Error("expected a copied notice")
+	}
+}
+
+func TestConfigTreeModel_ToggleSourceDropsStaleReplies(t *testing.T) {
+	m, _ := expand(t, testTree(), "/config/shared")
+	m, _ = m.Update(tea.KeyPressMsg{Code: 't', Text: "t"})
+	if !m.Candidate() || m.IsLoading() {
+		t.Fatal("t should switch to a fresh candidate tree")
+	}
+	m = m.SetElement("fw1", false, "/config/shared", parseElem(t, treeShared), nil)
+	if strings.Contains(stripANSI(m.View()), "address") {
+		t.Error("a running-config reply should not fill the candidate tree")
+	}
+	if m = m.SetElement("fw2", true, "/config/shared", parseElem(t, treeShared), nil); strings.Contains(stripANSI(m.View()), "address") {
+		t.Error("a reply for another device should be dropped")
+	}
+}
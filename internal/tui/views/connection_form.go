@@ -33,7 +33,8 @@ const (
 // ConnectionFormModel is the model for the connection form view
 type ConnectionFormModel struct {
 	mode          FormMode
-	editingHost   string // Original host when editing (for detecting changes)
+	editingHost   string                  // Original host when editing (for detecting changes)
+	base          config.ConnectionConfig // Edited connection; keeps fields the form doesn't show
 	hostInput     textinput.Model
 	usernameInput textinput.Model
 	connType      string // "firewall" or "panorama"
@@ -70,6 +71,7 @@ func NewEditConnectionForm(host string, conn config.ConnectionConfig) Connection
 	m := newBaseForm()
 	m.mode = FormModeEdit
 	m.editingHost = host
+	m.base = conn
 	m.hostInput.SetValue(host)
 	m.usernameInput.SetValue(conn.Username)
 	m.connType = conn.Type
@@ -235,13 +237,15 @@ func (m ConnectionFormModel) CanSubmit() bool {
 	return m.Host() != "" && validateHost(m.Host()) == ""
 }
 
-// GetConfig returns the connection config from form values
+// GetConfig returns the connection config from form values. Settings the
+// form has no field for (ca_cert_path, allow_write) carry over unchanged
+// when editing.
 func (m ConnectionFormModel) GetConfig() config.ConnectionConfig {
-	return config.ConnectionConfig{
-		Username: m.Username(),
-		Type:     m.connType,
-		Insecure: m.insecure,
-	}
+	c := m.base
+	c.Username = m.Username()
+	c.Type = m.connType
+	c.Insecure = m.insecure
+	return c
 }
 
 // Update handles input updates
@@ -282,7 +282,7 @@ func (m ConnectionHubModel) renderEntry(entry ConnectionEntry, selected bool) st
 	// Format last connected time
 	lastConnected := "Never connected"
 	if !entry.LastConnected.IsZero() {
-		lastConnected = formatTimeAgo(entry.LastConnected)
+		lastConnected = FormatTimeAgo(entry.LastConnected)
 	}
 
 	// Build the line - host is the primary identifier; type tag is rendered
@@ -0,0 +1,501 @@
+package views
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/jp2195/pyre/internal/pancfg"
+	"github.com/jp2195/pyre/internal/tui/theme"
+)
+
+// ConsoleRunMsg asks the app to run an op command typed in the console.
+// Seq identifies the transcript entry awaiting the result.
+type ConsoleRunMsg struct {
+	Device  string
+	Seq     int
+	Command string
+}
+
+// maxConsoleEntries bounds the transcript; older commands scroll away.
+const maxConsoleEntries = 50
+
+type consoleLineKind int
+
+const (
+	consoleText consoleLineKind = iota
+	consoleXML
+	consoleHeader
+	consoleRow
+)
+
+type consoleLine struct {
+	kind  consoleLineKind
+	depth int
+	text  string
+}
+
+// consoleEntry is one command and its output.
+type consoleEntry struct {
+	seq     int
+	command string
+	running bool
+	err     error
+	table   []consoleLine // nil when the result is not tabular
+	xml     []consoleLine
+}
+
+// ConsoleModel is a REPL for op commands in PAN-OS CLI syntax. The typed
+// command is translated to the XML op form by the API client, which also
+// holds read-only connections to show commands.
+type ConsoleModel struct {
+	input    textinput.Model
+	device   string
+	writable bool
+	entries  []consoleEntry
+	nextSeq  int
+	asXML    bool // show results as XML even when they would fit a table
+
+	history []string // oldest first
+	histPos int      // index into history while browsing; len(history) otherwise
+	draft   string   // what was typed before browsing history
+
+	scroll       int // lines scrolled up from the bottom
+	width        int
+	height       int
+	spinnerFrame string
+}
+
+func NewConsoleModel() ConsoleModel {
+	ti := textinput.New()
+	ti.Placeholder = "show system info"
+	ti.Prompt = "> "
+	ti.CharLimit = 512
+	ti.Focus()
+	return ConsoleModel{input: ti}
+}
+
+func (m ConsoleModel) SetSize(width, height int) ConsoleModel {
+	m.width = width
+	m.height = height
+	m.input.SetWidth(max(width-12, 20))
+	return m
+}
+
+// SetSpinnerFrame updates the current spinner animation frame.
+func (m ConsoleModel) SetSpinnerFrame(frame string) ConsoleModel {
+	m.spinnerFrame = frame
+	return m
+}
+
+// IsLoading reports whether any command is still running.
+func (m ConsoleModel) IsLoading() bool {
+	return slices.ContainsFunc(m.entries, func(e consoleEntry) bool { return e.running })
+}
+
+// IsFilterMode returns true while the command input is focused, so that
+// typed keys reach the console instead of the global bindings.
+func (m ConsoleModel) IsFilterMode() bool {
+	return m.input.Focused()
+}
+
+// HasData reports whether the console belongs to a device.
+func (m ConsoleModel) HasData() bool {
+	return m.device != ""
+}
+
+// Device is the device commands run on.
+func (m ConsoleModel) Device() string {
+	return m.device
+}
+
+// SetDevice switches to another device, clearing the transcript and
+// loading that connection's command history.
+func (m ConsoleModel) SetDevice(device string, history []string) ConsoleModel {
+	if device == m.device {
+		return m
+	}
+	m.device = device
+	m.entries = nil
+	m.scroll = 0
+	m.history = history
+	m.histPos = len(history)
+	m.draft = ""
+	return m
+}
+
+// SetWritable records whether the connection allows commands other than
+// show. The API client enforces it; the console only reports it.
+func (m ConsoleModel) SetWritable(writable bool) ConsoleModel {
+	m.writable = writable
+	return m
+}
+
+// SetResult fills in the output of the command with the given seq. Results
+// for another device are dropped.
+func (m ConsoleModel) SetResult(device string, seq int, result string, err error) ConsoleModel {
+	if device != m.device {
+		return m
+	}
+	for i := range m.entries {
+		if m.entries[i].seq != seq {
+			continue
+		}
+		e := &m.entries[i]
+		e.running = false
+		e.err = err
+		if err == nil {
+			e.table, e.xml = formatOpResult(result)
+		}
+	}
+	return m
+}
+
+func (m ConsoleModel) Update(msg tea.Msg) (ConsoleModel, tea.Cmd) {
+	if key, ok := msg.(tea.KeyPressMsg); ok {
+		switch key.String() {
+		case "ctrl+l":
+			m.entries = nil
+			m.scroll = 0
+			return m, nil
+		case "ctrl+t":
+			m.asXML = !m.asXML
+			return m, nil
+		case "pgup":
+			m.scrollBy(m.outputRows() / 2)
+			return m, nil
+		case "pgdown":
+			m.scrollBy(-m.outputRows() / 2)
+			return m, nil
+		}
+		if !m.input.Focused() {
+			return m.updateBrowse(key)
+		}
+		switch key.String() {
+		case "enter":
+			return m.submit()
+		case "esc":
+			m.input.Blur()
+			return m, nil
+		case "up":
+			m.recall(-1)
+			return m, nil
+		case "down":
+			m.recall(1)
+			return m, nil
+		}
+	}
+	if !m.input.Focused() {
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// updateBrowse handles keys while the input is blurred: scrolling the
+// transcript, or returning to the input.
+func (m ConsoleModel) updateBrowse(key tea.KeyPressMsg) (ConsoleModel, tea.Cmd) {
+	switch key.String() {
+	case "i", "enter":
+		return m, m.input.Focus()
+	case "ctrl+u":
+		m.scrollBy(m.outputRows() / 2)
+	case "ctrl+d":
+		m.scrollBy(-m.outputRows() / 2)
+	case "k", "up":
+		m.scrollBy(1)
+	case "j", "down":
+		m.scrollBy(-1)
+	case "g", "home":
+		m.scrollBy(len(m.lines()))
+	case "G", "end":
+		m.scroll = 0
+	}
+	return m, nil
+}
+
+func (m *ConsoleModel) scrollBy(n int) {
+	m.scroll = max(0, min(m.scroll+n, len(m.lines())-m.outputRows()))
+}
+
+// recall steps through the command history: dir -1 is older, 1 newer.
+// Stepping past the newest restores what was being typed.
+func (m *ConsoleModel) recall(dir int) {
+	pos := m.histPos + dir
+	if pos < 0 || pos > len(m.history) {
+		return
+	}
+	if m.histPos == len(m.history) {
+		m.draft = m.input.Value()
+	}
+	m.histPos = pos
+	if pos == len(m.history) {
+		m.input.SetValue(m.draft)
+	} else {
+		m.input.SetValue(m.history[pos])
+	}
+	m.input.CursorEnd()
+}
+
+// submit starts the typed command and adds it to the history.
+func (m ConsoleModel) submit() (ConsoleModel, tea.Cmd) {
+	command := strings.TrimSpace(m.input.Value())
+	if command == "" || m.device == "" {
+		return m, nil
+	}
+	m.input.SetValue("")
+	m.history = append(slices.DeleteFunc(m.history, func(c string) bool { return c == command }), command)
+	m.histPos = len(m.history)
+	m.draft = ""
+	m.scroll = 0
+
+	m.nextSeq++
+	m.entries = append(m.entries, consoleEntry{seq: m.nextSeq, command: command, running: true})
+	if len(m.entries) > maxConsoleEntries {
+		m.entries = m.entries[len(m.entries)-maxConsoleEntries:]
+	}
+	req := ConsoleRunMsg{Device: m.device, Seq: m.nextSeq, Command: command}
+	return m, func() tea.Msg { return req }
+}
+
+// outputRows is the transcript height: the panel less the title, input,
+// and footer lines.
+func (m ConsoleModel) outputRows() int {
+	return max(m.height-8, 1)
+}
+
+// lines flattens the transcript: each command's prompt line, its output,
+// and a blank separator.
+func (m ConsoleModel) lines() []string {
+	c := theme.Colors()
+	prompt := lipgloss.NewStyle().Foreground(c.Primary).Bold(true)
+	width := max(m.width-12, 20)
+
+	var out []string
+	for _, e := range m.entries {
+		out = append(out, prompt.Render(m.device+"> ")+DetailValueStyle.Render(e.command))
+		switch {
+		case e.running:
+			out = append(out, RenderLoadingInline(m.spinnerFrame, "Running..."))
+		case e.err != nil:
+			out = append(out, ErrorMsgStyle.Render("Error: "+e.err.Error()))
+		default:
+			body := e.xml
+			if e.table != nil && !m.asXML {
+				body = e.table
+			}
+			for _, l := range body {
+				out = append(out, renderConsoleLine(l, width))
+			}
+		}
+		out = append(out, "")
+	}
+	return out
+}
+
+func renderConsoleLine(l consoleLine, width int) string {
+	c := theme.Colors()
+	indent := strings.Repeat("  ", l.depth)
+	text := truncateEllipsis(l.text, max(width-len(indent), 10))
+	switch l.kind {
+	case consoleXML:
+		return indent + highlightXML(text, c.Text)
+	case consoleHeader:
+		return TableHeaderStyle.Render(text)
+	case consoleRow:
+		return DetailValueStyle.Render(text)
+	default:
+		return indent + DetailValueStyle.Render(text)
+	}
+}
+
+func (m ConsoleModel) View() string {
+	if m.width == 0 {
+		return RenderLoadingInline(m.spinnerFrame, "Loading...")
+	}
+
+	titleStyle := ViewTitleStyle.MarginBottom(1)
+	panelStyle := ViewPanelStyle.Width(m.width - 4)
+
+	mode := "read-only: show commands"
+	if m.writable {
+		mode = "writes enabled"
+	}
+	format := "table"
+	if m.asXML {
+		format = "XML"
+	}
+	var b strings.Builder
+	info := BannerInfoStyle.Render(fmt.Sprintf(" [%s | %s | Output: %s | ↑↓: history | ctrl+t: table/XML | ctrl+l: clear]",
+		m.device, mode, format))
+	b.WriteString(titleStyle.Render("Console") + info)
+	b.WriteString("\n")
+
+	rows := m.outputRows()
+	lines := m.lines()
+	if len(lines) == 0 {
+		lines = []string{EmptyMsgStyle.Render("Type an op command in CLI syntax, e.g. show system info, and press enter. " +
+			"Quote values that look like keywords: show user group name \"domain users\".")}
+	}
+	end := max(len(lines)-m.scroll, 0)
+	start := max(end-rows, 0)
+	for _, l := range lines[start:end] {
+		b.WriteString(l)
+		b.WriteString("\n")
+	}
+	for range rows - (end - start) {
+		b.WriteString("\n")
+	}
+
+	if m.scroll > 0 {
+		b.WriteString(DetailDimStyle.Render(fmt.Sprintf("  ↑ scrolled %d lines (G or end: back to bottom)", m.scroll)))
+	}
+	b.WriteString("\n")
+	if m.input.Focused() {
+		b.WriteString(FilterBorderStyle.Render(m.input.View()))
+	} else {
+		b.WriteString(DetailDimStyle.Render("  i or enter: type a command | j/k: scroll"))
+	}
+	return panelStyle.Render(b.String())
+}
+
+// formatOpResult renders an op result as XML lines and, when it holds a
+// list of flat records (interfaces, sessions, jobs, ...), as a table too.
+// Results that are plain text (show clock) become text lines.
+func formatOpResult(inner string) (table, xmlLines []consoleLine) {
+	if strings.TrimSpace(inner) == "" {
+		return nil, []consoleLine{{kind: consoleText, text: "(no output)"}}
+	}
+	result, err := pancfg.ParseElement("<result>" + inner + "</result>")
+	if err != nil || len(result.Children) == 0 {
+		text := inner
+		if err == nil {
+			text = result.Text
+		}
+		for l := range strings.SplitSeq(strings.TrimRight(text, "\n"), "\n") {
+			xmlLines = append(xmlLines, consoleLine{kind: consoleText, text: l})
+		}
+		return nil, xmlLines
+	}
+
+	for _, c := range result.Children {
+		for _, l := range pancfg.XMLLines(c, 0) {
+			xmlLines = append(xmlLines, consoleLine{kind: consoleXML, depth: l.Depth, text: l.Text})
+		}
+	}
+	// Descend through single wrappers (<ifnet><entry>...) to the list.
+	n := result
+	for !isTabular(n) && len(n.Children) == 1 {
+		n = n.Children[0]
+	}
+	if isTabular(n) {
+		table = tableLines(n)
+	}
+	return table, xmlLines
+}
+
+// isTabular reports whether n's children are records of one kind, each
+// holding only leaf fields. A single record reads better as XML.
+func isTabular(n *pancfg.Node) bool {
+	if len(n.Children) < 2 {
+		return false
+	}
+	fields := false
+	for _, rec := range n.Children {
+		if rec.XMLName.Local != n.Children[0].XMLName.Local {
+			return false
+		}
+		for _, f := range rec.Children {
+			if !isLeafField(f) {
+				return false
+			}
+			fields = true
+		}
+	}
+	return fields
+}
+
+// isLeafField accepts a text element, or a list of <member> values.
+func isLeafField(f *pancfg.Node) bool {
+	for _, c := range f.Children {
+		if c.XMLName.Local != "member" || len(c.Children) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// tableLines lays out n's records as columns: the name attribute when
+// records have one, then each field in first-seen order.
+func tableLines(n *pancfg.Node) []consoleLine {
+	var cols []string
+	named := false
+	for _, rec := range n.Children {
+		if rec.Name() != "" {
+			named = true
+		}
+		for _, f := range rec.Children {
+			if !slices.Contains(cols, f.XMLName.Local) {
+				cols = append(cols, f.XMLName.Local)
+			}
+		}
+	}
+	if named {
+		cols = append([]string{"name"}, cols...)
+	}
+
+	const maxCol = 32
+	cells := make([][]string, len(n.Children))
+	widths := make([]int, len(cols))
+	for i, c := range cols {
+		widths[i] = len(c)
+	}
+	for r, rec := range n.Children {
+		row := make([]string, len(cols))
+		for i, col := range cols {
+			if named && i == 0 {
+				row[i] = rec.Name()
+				continue
+			}
+			for _, f := range rec.Children {
+				if f.XMLName.Local == col {
+					row[i] = fieldValue(f)
+					break
+				}
+			}
+		}
+		for i, v := range row {
+			widths[i] = min(max(widths[i], len(v)), maxCol)
+		}
+		cells[r] = row
+	}
+
+	format := func(row []string) string {
+		parts := make([]string, len(row))
+		for i, v := range row {
+			parts[i] = fmt.Sprintf("%-*s", widths[i], truncateEllipsis(v, widths[i]))
+		}
+		return strings.TrimRight(strings.Join(parts, "  "), " ")
+	}
+	lines := []consoleLine{{kind: consoleHeader, text: format(cols)}}
+	for _, row := range cells {
+		lines = append(lines, consoleLine{kind: consoleRow, text: format(row)})
+	}
+	return lines
+}
+
+func fieldValue(f *pancfg.Node) string {
+	if len(f.Children) == 0 {
+		return strings.TrimSpace(f.Text)
+	}
+	members := make([]string, len(f.Children))
+	for i, c := range f.Children {
+		members[i] = c.Text
+	}
+	return strings.Join(members, ", ")
+}
@@ -0,0 +1,142 @@
+package views
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func testConsole(history ...string) ConsoleModel {
+	InitStyles()
+	return NewConsoleModel().SetSize(140, 40).SetDevice("fw1", history)
+}
+
+func typeCommand(m ConsoleModel, s string) ConsoleModel {
+	for _, r := range s {
+		m, _ = m.Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+	}
+	return m
+}
+
+func runCommand(t *testing.T, m ConsoleModel, s string) (ConsoleModel, ConsoleRunMsg) {
+	t.Helper()
+	m, cmd := typeCommand(m, s).Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatalf("enter should run %q", s)
+	}
+	req, ok := cmd().(ConsoleRunMsg)
+	if !ok {
+		t.Fatalf("cmd produced %T, want ConsoleRunMsg", cmd())
+	}
+	return m, req
+}
+
+const ifnetResult = `<ifnet>` +
+	`<entry><name>ethernet1/1</name><zone>untrust</zone><ip>203.0.113.2/24</ip></entry>` +
+	`<entry><name>ethernet1/2</name><zone>trust</zone><ip>10.0.0.1/24</ip></entry>` +
+	`</ifnet>`
+
+func TestConsoleModel_RunShowsTable(t *testing.T) {
+	m, req := runCommand(t, testConsole(), "show interface logical")
+	if req.Device != "fw1" || req.Command != "show interface logical" {
+		t.Fatalf("request = %+v", req)
+	}
+	if !m.IsLoading() || m.input.Value() != "" {
+		t.Error("the command should be running with the input cleared")
+	}
+
+	m = m.SetResult("fw1", req.Seq, ifnetResult, nil)
+	view := stripANSI(m.View())
+	if !strings.Contains(view, "fw1> show interface logical") {
+		t.Errorf("prompt line missing:\n%s", view)
+	}
+	if !strings.Contains(view, "name         zone     ip") || !strings.Contains(view, "ethernet1/2  trust    10.0.0.1/24") {
+		t.Errorf("expected a table:\n%s", view)
+	}
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: 't', Mod: tea.ModCtrl})
+	if view := stripANSI(m.View()); !strings.Contains(view, "<zone>trust</zone>") {
+		t.Errorf("ctrl+t should switch to XML:\n%s", view)
+	}
+}
+
+func TestConsoleModel_ErrorAndStaleResults(t *testing.T) {
+	m, req := runCommand(t, testConsole(), "request restart system")
+	if m = m.SetResult("fw2", req.Seq, "<ok/>", nil); !m.IsLoading() {
+		t.Error("a result for another device should be dropped")
+	}
+	m = m.SetResult("fw1", req.Seq, "", errors.New("read-only"))
+	if view := stripANSI(m.View()); !strings.Contains(view, "Error: read-only") {
+		t.Errorf("error not shown:\n%s", view)
+	}
+}
+
+func TestConsoleModel_History(t *testing.T) {
+	m := testConsole("show clock", "show system info")
+	m = typeCommand(m, "show j")
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyUp})
+	if got := m.input.Value(); got != "show system info" {
+		t.Fatalf("up = %q, want the newest command", got)
+	}
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyUp})
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyUp})
+	if got := m.input.Value(); got != "show clock" {
+		t.Fatalf("up past the oldest = %q, want it to stay on the oldest", got)
+	}
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyDown})
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyDown})
+	if got := m.input.Value(); got != "show j" {
+		t.Fatalf("down past the newest = %q, want the draft back", got)
+	}
+
+	// Re-running an old command moves it to the end of the history.
+	m.input.SetValue("")
+	m, _ = runCommand(t, m, "show clock")
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyUp})
+	if got := m.input.Value(); got != "show clock" {
+		t.Errorf("up after running = %q", got)
+	}
+}
+
+func TestConsoleModel_EscLeavesInput(t *testing.T) {
+	m := testConsole()
+	if !m.IsFilterMode() {
+		t.Fatal("the input should start focused")
+	}
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+	if m.IsFilterMode() {
+		t.Fatal("esc should release the keyboard to the global bindings")
+	}
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'i', Text: "i"})
+	if !m.IsFilterMode() {
+		t.Error("i should return to the input")
+	}
+}
+
+func TestFormatOpResult(t *testing.T) {
+	table, lines := formatOpResult("Sat Oct 18 10:00:00 UTC 2026\n")
+	if table != nil || len(lines) != 1 || lines[0].text != "Sat Oct 18 10:00:00 UTC 2026" {
+		t.Errorf("text result = %v, %v", table, lines)
+	}
+
+	table, lines = formatOpResult(`<system><hostname>fw1</hostname><sw-version>11.1.0</sw-version></system>`)
+	if table != nil {
+		t.Error("a single record is not a table")
+	}
+	if len(lines) != 4 || lines[1].text != "<hostname>fw1</hostname>" || lines[1].depth != 1 {
+		t.Errorf("xml lines = %v", lines)
+	}
+
+	table, _ = formatOpResult(`<result><job><id>1</id><type>Commit</type></job><job><id>2</id><type>AutoCom</type></job></result>`)
+	if len(table) != 3 || !strings.HasPrefix(table[0].text, "id") {
+		t.Errorf("jobs table = %v", table)
+	}
+
+	table, _ = formatOpResult(`<entry name="a"><member>x</member></entry><entry name="b"><to><member>y</member><member>z</member></to></entry>`)
+	if len(table) != 3 || !strings.Contains(table[2].text, "y, z") {
+		t.Errorf("member lists should join into one cell: %v", table)
+	}
+}
@@ -1,9 +1,12 @@
 package views
 
 import (
+	"time"
+
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 
+	"github.com/jp2195/pyre/internal/history"
 	"github.com/jp2195/pyre/internal/models"
 )
 
@@ -65,6 +68,13 @@ type DashboardModel struct {
 	envErr      error
 	certErr     error
 	natPoolErr  error
+
+	// Trends panel, charted from the local history (see dashboard_trends.go).
+	trendsOn   bool
+	trendRange TrendRange
+	trends     []history.Sample
+	trendsEnd  time.Time
+	trendsErr  error
 }
 
 func NewDashboardModel() DashboardModel {
@@ -168,7 +178,7 @@ func (m DashboardModel) SetNATPoolInfo(pools []models.NATPoolInfo, err error) Da
 }
 
 func (m DashboardModel) Update(msg tea.Msg) (DashboardModel, tea.Cmd) {
-	return m, nil
+	return m.updateTrends(msg)
 }
 
 // HasData reports whether every panel that can show a loading placeholder has
@@ -225,6 +235,9 @@ func (m DashboardModel) content() string {
 		m.renderResourcesCompact(leftColWidth),
 		m.renderSessionsCompact(leftColWidth),
 	}
+	if m.trendsOn {
+		leftPanels = append(leftPanels, m.renderTrends(leftColWidth))
+	}
 
 	// Add disk usage panel to left column (health metric)
 	if len(m.diskUsage) > 0 {
@@ -292,6 +305,9 @@ func (m DashboardModel) renderSingleColumn(width int) string {
 		m.renderResourcesCompact(width),
 		m.renderSessionsCompact(width),
 	}
+	if m.trendsOn {
+		panels = append(panels, m.renderTrends(width))
+	}
 
 	// Disk usage (health)
 	if len(m.diskUsage) > 0 {
@@ -277,6 +277,9 @@ func (m ConfigDashboardModel) renderPendingChanges(width int) string {
 		}
 	}
 
+	b.WriteString("\n\n")
+	b.WriteString(dimStyle().Render("enter: view diff"))
+
 	return panelStyle().Width(width).Render(b.String())
 }
 
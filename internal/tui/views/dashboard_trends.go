@@ -0,0 +1,265 @@
+package views
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/jp2195/pyre/internal/history"
+	"github.com/jp2195/pyre/internal/tui/theme"
+)
+
+// TrendRange is how far back the Overview's trend charts reach.
+type TrendRange int
+
+const (
+	TrendHour TrendRange = iota
+	TrendDay
+	TrendWeek
+)
+
+// Duration is the span the range covers.
+func (r TrendRange) Duration() time.Duration {
+	switch r {
+	case TrendDay:
+		return 24 * time.Hour
+	case TrendWeek:
+		return 7 * 24 * time.Hour
+	default:
+		return time.Hour
+	}
+}
+
+func (r TrendRange) String() string {
+	switch r {
+	case TrendDay:
+		return "24h"
+	case TrendWeek:
+		return "7d"
+	default:
+		return "1h"
+	}
+}
+
+// TrendRangeMsg asks the app to load the samples for another range.
+type TrendRangeMsg struct {
+	Range TrendRange
+}
+
+// maxTrendInterfaces is how many of the busiest interfaces get a chart.
+const maxTrendInterfaces = 3
+
+// SetTrendsEnabled shows the Trends panel, which is only useful when a
+// history is being recorded.
+func (m DashboardModel) SetTrendsEnabled(on bool) DashboardModel {
+	m.trendsOn = on
+	return m
+}
+
+// TrendRange returns the range the charts show.
+func (m DashboardModel) TrendRange() TrendRange {
+	return m.trendRange
+}
+
+// SetTrends replaces the charted samples, oldest first, with those loaded
+// for the current range up to end.
+func (m DashboardModel) SetTrends(samples []history.Sample, end time.Time, err error) DashboardModel {
+	m.trends = samples
+	m.trendsEnd = end
+	m.trendsErr = err
+	return m
+}
+
+// AddTrendSample charts a sample just taken, dropping those that have
+// fallen out of the range. err is why it could not be saved, if it
+// couldn't.
+func (m DashboardModel) AddTrendSample(smp history.Sample, err error) DashboardModel {
+	from := smp.Time.Add(-m.trendRange.Duration())
+	kept := make([]history.Sample, 0, len(m.trends)+1)
+	for _, s := range m.trends {
+		if !s.Time.Before(from) {
+			kept = append(kept, s)
+		}
+	}
+	m.trends = append(kept, smp)
+	m.trendsEnd = smp.Time
+	m.trendsErr = err
+	return m
+}
+
+// updateTrends handles the Trends panel's key: t cycles the range.
+func (m DashboardModel) updateTrends(msg tea.Msg) (DashboardModel, tea.Cmd) {
+	key, ok := msg.(tea.KeyPressMsg)
+	if !ok || !m.trendsOn || key.String() != "t" {
+		return m, nil
+	}
+	m.trendRange = (m.trendRange + 1) % (TrendWeek + 1)
+	r := m.trendRange
+	return m, func() tea.Msg { return TrendRangeMsg{Range: r} }
+}
+
+// trendLine is one chart in the Trends panel.
+type trendLine struct {
+	label  string
+	value  func(history.Sample) (float64, bool)
+	top    float64 // the value at full height; 0 scales to the series' peak
+	format func(float64) string
+}
+
+func percentOf(get func(history.Sample) *float64) func(history.Sample) (float64, bool) {
+	return func(s history.Sample) (float64, bool) {
+		if v := get(s); v != nil {
+			return *v, true
+		}
+		return 0, false
+	}
+}
+
+func formatPercent(v float64) string { return fmt.Sprintf("%.0f%%", v) }
+
+func (m DashboardModel) renderTrends(width int) string {
+	var b strings.Builder
+	b.WriteString(titleStyle().Render("Trends"))
+	b.WriteString(dimStyle().Render(fmt.Sprintf("  last %s · t: range", m.trendRange)))
+	b.WriteString("\n")
+
+	if m.trendsErr != nil {
+		b.WriteString(errorStyle().Render(truncateEllipsis("Error: "+m.trendsErr.Error(), width-4)))
+		b.WriteString("\n")
+	}
+	if len(m.trends) == 0 {
+		b.WriteString(dimStyle().Render("No history for this range yet"))
+		return panelStyle().Width(width).Render(b.String())
+	}
+
+	lines := []trendLine{
+		{label: "Mgmt", top: 100, format: formatPercent,
+			value: percentOf(func(s history.Sample) *float64 { return s.ManagementCPU })},
+		{label: "DP", top: 100, format: formatPercent,
+			value: percentOf(func(s history.Sample) *float64 { return s.DataPlaneCPU })},
+		{label: "Mem", top: 100, format: formatPercent,
+			value: percentOf(func(s history.Sample) *float64 { return s.MemoryPercent })},
+		{label: "Sess", format: func(v float64) string { return formatNumberWithCommas(int64(v)) },
+			value: func(s history.Sample) (float64, bool) {
+				if s.Sessions == nil {
+					return 0, false
+				}
+				return float64(*s.Sessions), true
+			}},
+		{label: "Thru", format: func(v float64) string { return formatThroughput(int64(v)) },
+			value: func(s history.Sample) (float64, bool) {
+				if s.ThroughputKbps == nil {
+					return 0, false
+				}
+				return float64(*s.ThroughputKbps), true
+			}},
+		{label: "Tun", format: func(v float64) string { return fmt.Sprintf("%.0f up", v) },
+			value: func(s history.Sample) (float64, bool) {
+				if s.Tunnels == nil || s.Tunnels.Total == 0 {
+					return 0, false
+				}
+				return float64(s.Tunnels.Up), true
+			}},
+		{label: "BGP", format: func(v float64) string { return fmt.Sprintf("%.0f up", v) },
+			value: func(s history.Sample) (float64, bool) {
+				if s.BGPPeers == nil || s.BGPPeers.Total == 0 {
+					return 0, false
+				}
+				return float64(s.BGPPeers.Up), true
+			}},
+	}
+	for _, name := range history.TopInterfaces(m.trends, maxTrendInterfaces) {
+		lines = append(lines, trendLine{label: name, format: func(v float64) string { return formatThroughput(int64(v / 1000)) },
+			value: func(s history.Sample) (float64, bool) {
+				r, ok := s.Interfaces[name]
+				return float64(r.In + r.Out), ok
+			}})
+	}
+
+	const labelWidth, valueWidth = 12, 10
+	chartWidth := max(width-labelWidth-valueWidth-6, 10)
+	end := m.trendsEnd.Add(time.Second) // the newest sample falls inside the last bucket
+	from := end.Add(-m.trendRange.Duration())
+	c := theme.Colors()
+	var rows []string
+	for _, l := range lines {
+		series := history.Series(m.trends, from, end, chartWidth, l.value)
+		latest, ok := latestValue(m.trends, l.value)
+		if !ok {
+			continue
+		}
+		top := l.top
+		if top == 0 {
+			top = peak(series)
+		}
+		col := c.Success
+		if l.top == 100 && latest > 80 {
+			col = c.Error
+		} else if l.top == 100 && latest > 60 {
+			col = c.Warning
+		}
+		row := labelStyle().Render(fmt.Sprintf("%-*s", labelWidth, truncateEllipsis(l.label, labelWidth))) +
+			renderSparkline(series, top, col) +
+			valueStyle().Render(fmt.Sprintf(" %*s", valueWidth, l.format(latest)))
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		b.WriteString(dimStyle().Render("No history for this range yet"))
+		return panelStyle().Width(width).Render(b.String())
+	}
+	b.WriteString(strings.Join(rows, "\n"))
+	if first := m.trends[0].Time; first.After(from.Add(m.trendRange.Duration() / 10)) {
+		b.WriteString("\n")
+		b.WriteString(dimStyle().Render("Recorded since " + first.Local().Format("Jan 2 15:04")))
+	}
+	return panelStyle().Width(width).Render(b.String())
+}
+
+// latestValue returns the newest sample's value, and false if no sample
+// has one.
+func latestValue(samples []history.Sample, value func(history.Sample) (float64, bool)) (float64, bool) {
+	for i := len(samples) - 1; i >= 0; i-- {
+		if v, ok := value(samples[i]); ok {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// peak returns the largest value of a series, ignoring gaps.
+func peak(series []float64) float64 {
+	var p float64
+	for _, v := range series {
+		if !math.IsNaN(v) {
+			p = max(p, v)
+		}
+	}
+	return p
+}
+
+// sparkBlocks are the eighths a sparkline cell can fill.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// renderSparkline draws one cell per value, scaled so top fills a cell. A
+// NaN value, a gap in the samples, is left blank.
+func renderSparkline(values []float64, top float64, c color.Color) string {
+	style := lipgloss.NewStyle().Foreground(c)
+	var b strings.Builder
+	for _, v := range values {
+		if math.IsNaN(v) {
+			b.WriteString(" ")
+			continue
+		}
+		i := 0
+		if top > 0 {
+			i = min(max(int(v/top*float64(len(sparkBlocks)-1)+0.5), 0), len(sparkBlocks)-1)
+		}
+		b.WriteString(string(sparkBlocks[i]))
+	}
+	return style.Render(b.String())
+}
@@ -0,0 +1,72 @@
+package views
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/jp2195/pyre/internal/history"
+)
+
+func trendSamples(end time.Time) []history.Sample {
+	var samples []history.Sample
+	for i := range 30 {
+		cpu, sessions := float64(20+2*i), 1000*i
+		samples = append(samples, history.Sample{
+			Time:          end.Add(time.Duration(i-29) * time.Minute),
+			ManagementCPU: &cpu,
+			Sessions:      &sessions,
+			Tunnels:       &history.Count{Up: 2, Total: 3, Down: []string{"to-branch"}},
+			Interfaces:    map[string]history.Rate{"ethernet1/1": {In: 4_000_000, Out: 1_000_000}},
+		})
+	}
+	return samples
+}
+
+func TestDashboardModel_Trends(t *testing.T) {
+	InitStyles()
+	end := time.Now()
+	m := NewDashboardModel().SetSize(160, 80).SetTrendsEnabled(true).SetTrends(trendSamples(end), end, nil)
+
+	view := stripANSI(m.content())
+	for _, want := range []string{"Trends", "last 1h · t: range", "Mgmt", "78%", "Sess", "29,000", "Tun", "2 up", "ethernet1/1"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("trends panel missing %q:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "DP ") {
+		t.Errorf("a value never sampled should get no chart:\n%s", view)
+	}
+	if !strings.Contains(view, "Recorded since") {
+		t.Errorf("half an hour of samples should say when recording began:\n%s", view)
+	}
+
+	m, cmd := m.Update(tea.KeyPressMsg{Code: 't', Text: "t"})
+	if m.TrendRange() != TrendDay || cmd == nil {
+		t.Fatalf("t should move to the next range, got %s", m.TrendRange())
+	}
+	if msg, ok := cmd().(TrendRangeMsg); !ok || msg.Range != TrendDay {
+		t.Errorf("t should ask for the day's samples, got %#v", msg)
+	}
+
+	// A new sample joins the chart; ones out of range drop off.
+	m = NewDashboardModel().SetTrendsEnabled(true).SetTrends(trendSamples(end), end, nil)
+	cpu := 5.0
+	m = m.AddTrendSample(history.Sample{Time: end.Add(45 * time.Minute), ManagementCPU: &cpu}, nil)
+	if len(m.trends) != 17 {
+		t.Errorf("after 45 minutes %d samples remain in the hour, want 17", len(m.trends))
+	}
+}
+
+func TestDashboardModel_TrendsOff(t *testing.T) {
+	InitStyles()
+	m := NewDashboardModel().SetSize(160, 80)
+	if strings.Contains(stripANSI(m.content()), "Trends") {
+		t.Error("the Trends panel should be hidden unless history is on")
+	}
+	if _, cmd := m.Update(tea.KeyPressMsg{Code: 't', Text: "t"}); cmd != nil {
+		t.Error("t should do nothing without history")
+	}
+}
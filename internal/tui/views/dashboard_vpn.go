@@ -339,7 +339,7 @@ func (m VPNDashboardModel) renderGlobalProtectUsers(width int) string {
 		if user.Duration != "" {
 			b.WriteString(dimStyle().Render(user.Duration))
 		} else if !user.LoginTime.IsZero() {
-			b.WriteString(dimStyle().Render(formatTimeAgo(user.LoginTime)))
+			b.WriteString(dimStyle().Render(FormatTimeAgo(user.LoginTime)))
 		}
 
 		if i < maxShow-1 {
@@ -0,0 +1,82 @@
+package views
+
+import (
+	"maps"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/jp2195/pyre/internal/cfgexport"
+)
+
+// ConfigExportRequestMsg asks the app to export Items, with the objects
+// they depend on, from the active connection's running config.
+type ConfigExportRequestMsg struct {
+	Items  []cfgexport.Item
+	Format cfgexport.Format
+}
+
+// exportKeyHint is the banner hint for views that export rows as config.
+const exportKeyHint = "space: mark | e/E: export set/XML"
+
+// exportFormat maps an export key to its format: e for set commands, E
+// for an XML fragment.
+func exportFormat(key string) cfgexport.Format {
+	if key == "E" {
+		return cfgexport.FormatXML
+	}
+	return cfgexport.FormatSet
+}
+
+// toggleMark returns marks with key flipped. The map is copied, so earlier
+// copies of a model keep their own marks.
+func toggleMark[K comparable](marks map[K]bool, key K) map[K]bool {
+	marks = maps.Clone(marks)
+	if marks == nil {
+		marks = map[K]bool{}
+	}
+	if marks[key] {
+		delete(marks, key)
+	} else {
+		marks[key] = true
+	}
+	return marks
+}
+
+// exportStatus is the state of a view's config export: in flight, or the
+// outcome of the last one.
+type exportStatus struct {
+	exporting bool
+	notice    string
+	warn      bool // notice is a problem rather than a confirmation
+}
+
+// start marks an export of items in format as in flight and returns the
+// command that requests it.
+func (s *exportStatus) start(items []cfgexport.Item, format cfgexport.Format) tea.Cmd {
+	s.exporting = true
+	s.notice = ""
+	return func() tea.Msg { return ConfigExportRequestMsg{Items: items, Format: format} }
+}
+
+// done records the outcome of an export.
+func (s *exportStatus) done(path string, err error) {
+	s.exporting = false
+	if err != nil {
+		s.notice, s.warn = "Export failed: "+err.Error(), true
+		return
+	}
+	s.notice, s.warn = "Exported to "+path, false
+}
+
+// render is the status line, with its newline, or "" when there is none.
+func (s exportStatus) render(spinnerFrame string, width int) string {
+	switch {
+	case s.exporting:
+		return RenderLoadingInline(spinnerFrame, "Exporting...") + "\n"
+	case s.notice != "" && s.warn:
+		return ErrorMsgStyle.Render(truncateEllipsis(s.notice, width)) + "\n"
+	case s.notice != "":
+		return StatusActiveStyle.Render(truncateEllipsis(s.notice, width)) + "\n"
+	}
+	return ""
+}
@@ -101,6 +101,58 @@ func formatAddresses(addrs []string, negate bool, valueStyle, dimStyle lipgloss.
 	return result
 }
 
+// maxMemberLines caps the lines one expanded rule field takes in a detail
+// panel.
+const maxMemberLines = 12
+
+// formatMembers renders a rule field's expanded members one per line,
+// group members indented under their group, each with what it stands for.
+// Before any objects are loaded it falls back to the names on one line.
+func formatMembers(names []string, members []ResolvedMember, negate bool, loaded bool) []string {
+	if !loaded || len(names) == 0 || (len(names) == 1 && names[0] == "any") {
+		return []string{formatAddresses(names, negate, DetailValueStyle, DetailDimStyle)}
+	}
+	var lines []string
+	for i, mem := range members {
+		if i == maxMemberLines {
+			lines = append(lines, DetailDimStyle.Render(fmt.Sprintf("… and %d more", len(members)-i)))
+			break
+		}
+		line := strings.Repeat("  ", mem.Depth) + DetailValueStyle.Render(mem.Name)
+		if note := memberNote(mem); note != "" {
+			line += " " + DetailDimStyle.Render(note)
+		}
+		lines = append(lines, line)
+	}
+	if negate {
+		lines[0] = DetailValueStyle.Render("NOT ") + lines[0]
+	}
+	return lines
+}
+
+// memberNote is what a resolved member stands for, as shown after its name.
+func memberNote(m ResolvedMember) string {
+	switch m.Kind {
+	case MemberAddress, MemberService:
+		if m.Value == m.Name {
+			return ""
+		}
+		return m.Value
+	case MemberAddressGroup:
+		if m.Value != "" {
+			return "(dynamic group: " + m.Value + ")"
+		}
+		return "(group)"
+	case MemberServiceGroup:
+		return "(group)"
+	case MemberExternalList:
+		return "(EDL, " + m.Value + ")"
+	case MemberRegion:
+		return "(region: " + m.Value + ")"
+	}
+	return ""
+}
+
 // formatHitCount formats a hit count for compact table display.
 func formatHitCount(count int64) string {
 	if count == 0 {
@@ -118,10 +170,10 @@ func formatHitCount(count int64) string {
 	return strconv.FormatInt(count, 10)
 }
 
-// formatTimeAgo formats a time as a human-readable relative duration.
+// FormatTimeAgo formats a time as a human-readable relative duration.
 // It is the single canonical "time ago" formatter used across views
 // (policy hit counts, dashboard login times, connection hub, etc.).
-func formatTimeAgo(t time.Time) string {
+func FormatTimeAgo(t time.Time) string {
 	if t.IsZero() {
 		return "never"
 	}
@@ -240,6 +292,20 @@ func (dr *DetailRenderer) FieldStyled(label, styledValue string) *DetailRenderer
 	return dr
 }
 
+// FieldLines writes a label with pre-styled value lines, the lines after
+// the first aligned under it.
+func (dr *DetailRenderer) FieldLines(label string, lines []string) *DetailRenderer {
+	indent := strings.Repeat(" ", dr.labelWidth+1)
+	for i, line := range lines {
+		if i == 0 {
+			dr.b.WriteString(dr.labelStyle.Render(label) + " " + line + "\n")
+			continue
+		}
+		dr.b.WriteString(indent + line + "\n")
+	}
+	return dr
+}
+
 // FieldDim writes a label with a dimmed value.
 func (dr *DetailRenderer) FieldDim(label, value string) *DetailRenderer {
 	dr.b.WriteString(dr.labelStyle.Render(label) + " " + dr.dimStyle.Render(value) + "\n")
@@ -57,6 +57,16 @@ func (m GPUsersModel) HasData() bool {
 	return m.list.HasData()
 }
 
+// LookupAddresses are the selected user's addresses, for a where-used
+// lookup.
+func (m GPUsersModel) LookupAddresses() []string {
+	u, ok := m.list.Selected()
+	if !ok {
+		return nil
+	}
+	return []string{u.VirtualIP, u.ClientIP}
+}
+
 // IsFilterMode returns true while the filter text input is focused.
 func (m GPUsersModel) IsFilterMode() bool {
 	return m.list.IsFilterMode()
@@ -117,7 +127,7 @@ func formatGPUserHeader(width int) string {
 func formatGPUserRow(u models.GlobalProtectUser, width int) string {
 	duration := u.Duration
 	if duration == "" && !u.LoginTime.IsZero() {
-		duration = formatTimeAgo(u.LoginTime)
+		duration = FormatTimeAgo(u.LoginTime)
 	}
 	traffic := formatBytes(u.BytesIn + u.BytesOut)
 
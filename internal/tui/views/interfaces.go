@@ -66,6 +66,16 @@ func (m InterfacesModel) HasData() bool {
 	return m.list.HasData()
 }
 
+// LookupAddresses is the selected interface's address, for a where-used
+// lookup.
+func (m InterfacesModel) LookupAddresses() []string {
+	i, ok := m.list.Selected()
+	if !ok {
+		return nil
+	}
+	return []string{i.IP}
+}
+
 // IsFilterMode returns true while the filter text input is focused.
 func (m InterfacesModel) IsFilterMode() bool {
 	return m.list.IsFilterMode()
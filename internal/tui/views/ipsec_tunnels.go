@@ -61,6 +61,16 @@ func (m IPSecTunnelsModel) HasData() bool {
 	return m.list.HasData()
 }
 
+// LookupAddresses are the selected tunnel's addresses, for a where-used
+// lookup.
+func (m IPSecTunnelsModel) LookupAddresses() []string {
+	t, ok := m.list.Selected()
+	if !ok {
+		return nil
+	}
+	return []string{t.Gateway, t.RemoteIP, t.LocalIP}
+}
+
 // IsFilterMode returns true while the filter text input is focused.
 func (m IPSecTunnelsModel) IsFilterMode() bool {
 	return m.list.IsFilterMode()
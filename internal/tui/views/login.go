@@ -37,6 +37,13 @@ type LoginModel struct {
 	// spinner is the frame rendered next to the in-flight message, supplied
 	// by the parent model so it animates with the app-wide spinner tick.
 	spinner string
+	// notice explains why the form is shown, above the idle help (a
+	// rejected API key asks the user to log in again).
+	notice string
+	// trustHost and trustFingerprint are set while the user is asked
+	// whether to trust a pinned connection's certificate on first connect.
+	trustHost        string
+	trustFingerprint string
 }
 
 func NewLoginModel(creds *auth.Credentials) LoginModel {
@@ -125,6 +132,35 @@ func (m LoginModel) SetSubmitting(v bool) LoginModel {
 	return m
 }
 
+// SetNotice sets a one-line explanation shown above the help text.
+func (m LoginModel) SetNotice(notice string) LoginModel {
+	m.notice = notice
+	return m
+}
+
+// SetTrustPrompt asks the user to accept or reject host's certificate,
+// recorded in known_hosts under fingerprint if they accept.
+func (m LoginModel) SetTrustPrompt(host, fingerprint string) LoginModel {
+	m.trustHost = host
+	m.trustFingerprint = fingerprint
+	m.submitting = false
+	m.err = nil
+	return m
+}
+
+// ClearTrustPrompt dismisses the trust prompt.
+func (m LoginModel) ClearTrustPrompt() LoginModel {
+	m.trustHost = ""
+	m.trustFingerprint = ""
+	return m
+}
+
+// TrustPrompt returns the host and fingerprint awaiting the user's
+// decision, and whether the prompt is showing.
+func (m LoginModel) TrustPrompt() (host, fingerprint string, ok bool) {
+	return m.trustHost, m.trustFingerprint, m.trustHost != ""
+}
+
 // Submitting reports whether a keygen request is currently in flight.
 func (m LoginModel) Submitting() bool {
 	return m.submitting
@@ -299,8 +335,18 @@ func (m LoginModel) View() string {
 		status = StatusWarningStyle.Render(spin+" Authenticating…") + "\n" +
 			helpStyle.MarginTop(0).Render("Approve the MFA prompt if one was sent.") + "\n" +
 			helpStyle.MarginTop(0).Render("Enter is ignored · Esc cancels")
+	case m.trustHost != "":
+		// The fingerprint wraps onto two lines, so this state is one row
+		// taller than the region; it only follows a submit, never
+		// interrupts typing.
+		status = StatusWarningStyle.Render("Unknown certificate for "+m.trustHost+". Check its fingerprint:") + "\n" +
+			m.trustFingerprint + "\n" +
+			helpStyle.MarginTop(0).Render("y: trust and connect  n/Esc: reject")
 	case m.err != nil:
 		status = ErrorMsgStyle.Bold(true).Render("Error: " + m.err.Error())
+	case m.notice != "":
+		status = StatusWarningStyle.Render(m.notice) + "\n" +
+			helpStyle.MarginTop(0).Render("Enter: log in  Esc: give up  Ctrl+C: quit")
 	default:
 		status = helpStyle.MarginTop(0).Render("Tab: next  Space: toggle  Enter: connect  Ctrl+C: quit")
 	}
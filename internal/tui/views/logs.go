@@ -70,6 +70,24 @@ func (m LogsModel) HasData() bool {
 	return m.systemLogs != nil || m.trafficLogs != nil || m.threatLogs != nil
 }
 
+// LookupAddresses are the selected traffic or threat log's addresses, for a
+// where-used lookup.
+func (m LogsModel) LookupAddresses() []string {
+	switch m.activeLogType {
+	case models.LogTypeTraffic:
+		if m.Cursor < len(m.filteredTraffic) {
+			l := m.filteredTraffic[m.Cursor]
+			return []string{l.SourceIP, l.DestIP, l.NATSourceIP, l.NATDestIP}
+		}
+	case models.LogTypeThreat:
+		if m.Cursor < len(m.filteredThreat) {
+			l := m.filteredThreat[m.Cursor]
+			return []string{l.SourceIP, l.DestIP, l.NATSourceIP, l.NATDestIP}
+		}
+	}
+	return nil
+}
+
 func (m LogsModel) SetSystemLogs(logs []models.SystemLogEntry, err error) LogsModel {
 	m.systemLogs = logs
 	m.Err = err
@@ -6,11 +6,15 @@ import (
 
 	tea "charm.land/bubbletea/v2"
 
+	"github.com/jp2195/pyre/internal/cfgexport"
 	"github.com/jp2195/pyre/internal/models"
 )
 
 type NATPoliciesModel struct {
 	list RuleListModel[models.NATRule]
+
+	objects  ObjectsModel // resolves the rules' addresses and services
+	resolved bool         // the table shows resolved values, not names
 }
 
 func NewNATPoliciesModel() NATPoliciesModel {
@@ -25,11 +29,41 @@ func NewNATPoliciesModel() NATPoliciesModel {
 		MatchFilter:       matchNATRule,
 		CompareItems:      compareNATRule,
 		FormatHeaderRow:   formatNATHeader,
-		FormatRow:         formatNATRow,
-		RenderDetail:      renderNATDetail,
 		IsDisabled:        func(r models.NATRule) bool { return r.Disabled },
+		KeyHint:           "v: names/values | " + exportKeyHint,
+		ExportItem: func(r models.NATRule) cfgexport.Item {
+			return cfgexport.Item{Kind: cfgexport.KindNATRule, Name: r.Name}
+		},
 	}
-	return NATPoliciesModel{list: NewRuleListModel(config)}
+	return NATPoliciesModel{list: NewRuleListModel(config)}.rebind()
+}
+
+// SetObjects resolves the rules' addresses and services against objects,
+// in the detail panel and, when toggled, the table.
+func (m NATPoliciesModel) SetObjects(objects ObjectsModel) NATPoliciesModel {
+	m.objects = objects
+	return m.rebind()
+}
+
+// Objects are the objects the rules are resolved against.
+func (m NATPoliciesModel) Objects() ObjectsModel {
+	return m.objects
+}
+
+// Resolved reports whether the table shows resolved values.
+func (m NATPoliciesModel) Resolved() bool {
+	return m.resolved
+}
+
+// rebind points the table and detail renderers at the current objects and
+// toggle.
+func (m NATPoliciesModel) rebind() NATPoliciesModel {
+	x, resolved := m.objects, m.resolved
+	m.list = m.list.SetRenderers(
+		func(r models.NATRule, width int) string { return formatNATRow(r, width, x, resolved) },
+		func(r models.NATRule, width int) string { return renderNATDetail(r, width, x) },
+	)
+	return m
 }
 
 func (m NATPoliciesModel) SetSize(width, height int) NATPoliciesModel {
@@ -61,7 +95,46 @@ func (m NATPoliciesModel) SetRules(rules []models.NATRule, err error) NATPolicie
 	return m
 }
 
+// SetExported reports the outcome of a config export.
+func (m NATPoliciesModel) SetExported(path string, err error) NATPoliciesModel {
+	m.list = m.list.SetExported(path, err)
+	return m
+}
+
+// Rules are the rules loaded, in rulebase order.
+func (m NATPoliciesModel) Rules() []models.NATRule {
+	return m.list.Items()
+}
+
+// Select moves the cursor to the rule named name and opens its detail. It
+// reports false when no rule has that name.
+func (m NATPoliciesModel) Select(name string) (NATPoliciesModel, bool) {
+	var ok bool
+	m.list, ok = m.list.SelectWhere(func(r models.NATRule) bool { return r.Name == name })
+	return m, ok
+}
+
+// LookupAddresses are the selected rule's original and translated
+// addresses, resolved, for a where-used lookup.
+func (m NATPoliciesModel) LookupAddresses() []string {
+	r, ok := m.list.Selected()
+	if !ok {
+		return nil
+	}
+	out := append(m.objects.ResolveAddresses(r.Sources), m.objects.ResolveAddresses(r.Destinations)...)
+	for _, t := range []string{resolveJoined(m.objects, r.TranslatedSource, r.SourceInterfaceIP), resolveJoined(m.objects, r.TranslatedDest, false)} {
+		if t != "" {
+			out = append(out, strings.Split(t, ", ")...)
+		}
+	}
+	return out
+}
+
 func (m NATPoliciesModel) Update(msg tea.Msg) (NATPoliciesModel, tea.Cmd) {
+	if key, ok := msg.(tea.KeyPressMsg); ok && key.String() == "v" && !m.list.IsFilterMode() {
+		m.resolved = !m.resolved
+		return m.rebind(), nil
+	}
 	var cmd tea.Cmd
 	m.list, cmd = m.list.Update(msg)
 	return m, cmd
@@ -119,7 +192,15 @@ func formatNATHeader(width int) string {
 		"#", "Name", "Zones", "Src NAT", "Hits")
 }
 
-func formatNATRow(r models.NATRule, width int) string {
+// formatNATRow renders r's table row. With resolved, its service and
+// translated addresses are shown as the values x resolves them to.
+func formatNATRow(r models.NATRule, width int, x ObjectsModel, resolved bool) string {
+	services := r.Services
+	if resolved {
+		services = x.ResolveServices(services)
+		r.TranslatedSource = resolveJoined(x, r.TranslatedSource, r.SourceInterfaceIP)
+		r.TranslatedDest = resolveJoined(x, r.TranslatedDest, false)
+	}
 	base := formatRuleBase(r.RuleBase)
 	srcZone := formatZoneCompact(r.SourceZones)
 	dstZone := formatZoneCompact(r.DestZones)
@@ -127,11 +208,11 @@ func formatNATRow(r models.NATRule, width int) string {
 	srcNAT := formatSourceNAT(r)
 	dstNAT := formatDestNAT(r)
 	hits := formatHitCount(r.HitCount)
-	lastHit := formatTimeAgo(r.LastHit)
+	lastHit := FormatTimeAgo(r.LastHit)
 
 	service := "any"
-	if len(r.Services) > 0 && r.Services[0] != "any" {
-		service = formatListCompact(r.Services, 14)
+	if len(services) > 0 && services[0] != "any" {
+		service = formatListCompact(services, 14)
 	}
 
 	name := r.Name
@@ -162,6 +243,15 @@ func formatNATRow(r models.NATRule, width int) string {
 		truncateEllipsis(srcNAT, 14), hits)
 }
 
+// resolveJoined resolves a translated address, which holds one or more
+// comma-separated members, or an interface when ifaceIP is set.
+func resolveJoined(x ObjectsModel, members string, ifaceIP bool) string {
+	if members == "" || ifaceIP {
+		return members
+	}
+	return strings.Join(x.ResolveAddresses(strings.Split(members, ", ")), ", ")
+}
+
 func formatSourceNAT(r models.NATRule) string {
 	switch r.SourceTransType {
 	case models.SourceTransDynamicIPPort:
@@ -189,7 +279,9 @@ func formatDestNAT(r models.NATRule) string {
 	return result
 }
 
-func renderNATDetail(r models.NATRule, width int) string {
+// renderNATDetail renders r's detail panel, its addresses and services
+// expanded by x.
+func renderNATDetail(r models.NATRule, width int, x ObjectsModel) string {
 	dr := NewDetailRenderer(width, 18)
 
 	title := r.Name
@@ -203,16 +295,28 @@ func renderNATDetail(r models.NATRule, width int) string {
 
 	dr.Section("Original Packet (Match)")
 	dr.Field("Source Zones:", formatListFull(r.SourceZones))
-	dr.Field("Source Addresses:", formatListFull(r.Sources))
+	loaded := x.HasObjects()
+	dr.FieldLines("Source Addresses:", formatMembers(r.Sources, x.ExpandAddresses(r.Sources), false, loaded))
 	dr.Field("Dest Zones:", formatListFull(r.DestZones))
-	dr.Field("Dest Addresses:", formatListFull(r.Destinations))
-	dr.Field("Service:", formatListFull(r.Services))
+	dr.FieldLines("Dest Addresses:", formatMembers(r.Destinations, x.ExpandAddresses(r.Destinations), false, loaded))
+	dr.FieldLines("Service:", formatMembers(r.Services, x.ExpandServices(r.Services), false, loaded))
 	if r.DestInterface != "" && r.DestInterface != "any" {
 		dr.Field("Dest Interface:", r.DestInterface)
 	}
 
 	dr.Section("Translated Packet")
 
+	// translated writes a translated address: one or more members, or an
+	// interface.
+	translated := func(label, members string, ifaceIP bool) {
+		if ifaceIP {
+			dr.Field(label, members)
+			return
+		}
+		names := strings.Split(members, ", ")
+		dr.FieldLines(label, formatMembers(names, x.ExpandAddresses(names), false, loaded))
+	}
+
 	switch r.SourceTransType {
 	case models.SourceTransDynamicIPPort:
 		transType := "Dynamic IP and Port"
@@ -220,19 +324,19 @@ func renderNATDetail(r models.NATRule, width int) string {
 			transType += " (Interface)"
 		}
 		dr.Field("Source Translation:", transType)
-		dr.Field("  Translated To:", r.TranslatedSource)
+		translated("  Translated To:", r.TranslatedSource, r.SourceInterfaceIP)
 	case models.SourceTransDynamicIP:
 		dr.Field("Source Translation:", "Dynamic IP")
-		dr.Field("  Translated To:", r.TranslatedSource)
+		translated("  Translated To:", r.TranslatedSource, false)
 	case models.SourceTransStaticIP:
 		dr.Field("Source Translation:", "Static IP")
-		dr.Field("  Translated To:", r.TranslatedSource)
+		translated("  Translated To:", r.TranslatedSource, false)
 	default:
 		dr.FieldDim("Source Translation:", "None")
 	}
 
 	if r.TranslatedDest != "" {
-		dr.Field("Dest Translation:", r.TranslatedDest)
+		translated("Dest Translation:", r.TranslatedDest, false)
 		dr.FieldIf("  Translated Port:", r.TranslatedDestPort)
 	} else {
 		dr.FieldDim("Dest Translation:", "None")
@@ -142,3 +142,25 @@ func TestNATPoliciesModel_SetSpinnerFrame_ReachesList(t *testing.T) {
 		t.Errorf("list.SpinnerFrame = %q, want ◢", m.list.SpinnerFrame)
 	}
 }
+
+func TestNATPoliciesModel_ResolvedTranslation(t *testing.T) {
+	m := NewNATPoliciesModel().SetSize(200, 60)
+	m = m.SetRules([]models.NATRule{{
+		Name: "outbound", Position: 1, Sources: []string{"web"},
+		SourceTransType: models.SourceTransDynamicIPPort, TranslatedSource: "web-01, web-02",
+	}}, nil)
+	m = m.SetObjects(testObjects())
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	view := stripANSI(m.View())
+	for _, want := range []string{"web (group)", "web-02 10.0.1.11/32"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("detail missing %q:\n%s", want, view)
+		}
+	}
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'v', Text: "v"})
+	if row := formatNATRow(m.list.Filtered()[0], 188, m.objects, m.Resolved()); !strings.Contains(row, "DIPP:10.0.1.10") {
+		t.Errorf("resolved row = %q", row)
+	}
+}
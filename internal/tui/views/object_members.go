@@ -0,0 +1,233 @@
+package views
+
+import (
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/jp2195/pyre/internal/models"
+)
+
+// ObjectGroups are the objects a rule member can name besides addresses
+// and services.
+type ObjectGroups struct {
+	AddressGroups []models.AddressGroup
+	ServiceGroups []models.ServiceGroup
+	ExternalLists []models.ExternalList
+	Regions       []models.Region
+}
+
+// MemberKind is what a rule member turned out to name.
+type MemberKind int
+
+const (
+	MemberUnresolved   MemberKind = iota // a name no loaded object has
+	MemberLiteral                        // written into the rule: an address, "any", "application-default"
+	MemberAddress                        // an address object
+	MemberAddressGroup                   // a static or dynamic address group
+	MemberService                        // a service object, or a predefined service
+	MemberServiceGroup                   // a service group
+	MemberExternalList                   // an external dynamic list
+	MemberRegion                         // a custom region, or a country
+)
+
+// lookupByName returns the first of items named name. Lists put vsys
+// objects before shared ones, so a vsys object shadows the shared object
+// of the same name, as on the device.
+func lookupByName[T any](items []T, name string, nameOf func(T) string) (T, bool) {
+	for _, item := range items {
+		if nameOf(item) == name {
+			return item, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// ResolvedMember is one line of an expanded rule member: the member
+// itself at Depth 0, and the members of a group one deeper per level.
+type ResolvedMember struct {
+	Depth int
+	Name  string
+	Kind  MemberKind
+	// Value is what the member stands for: an address or proto/port for
+	// objects, the filter of a dynamic group, the source of an EDL.
+	Value string
+}
+
+// predefinedServices are the services every PAN-OS device has.
+var predefinedServices = map[string]string{
+	"service-http":  "tcp/80,8080",
+	"service-https": "tcp/443",
+}
+
+// ExpandAddresses resolves address members: objects to their values, and
+// groups to their members, recursively. A group that contains itself is
+// expanded once.
+func (m ObjectsModel) ExpandAddresses(names []string) []ResolvedMember {
+	var out []ResolvedMember
+	for _, name := range names {
+		out = m.expandAddress(out, name, 0, nil)
+	}
+	return out
+}
+
+func (m ObjectsModel) expandAddress(out []ResolvedMember, name string, depth int, path []string) []ResolvedMember {
+	if a, ok := m.LookupAddress(name); ok {
+		return append(out, ResolvedMember{Depth: depth, Name: name, Kind: MemberAddress, Value: a.Value})
+	}
+	if g, ok := m.LookupAddressGroup(name); ok {
+		out = append(out, ResolvedMember{Depth: depth, Name: name, Kind: MemberAddressGroup, Value: g.Filter})
+		if slices.Contains(path, name) {
+			return out
+		}
+		path = append(path, name)
+		for _, member := range g.Members {
+			out = m.expandAddress(out, member, depth+1, path)
+		}
+		return out
+	}
+	if l, ok := m.LookupExternalList(name); ok {
+		return append(out, ResolvedMember{Depth: depth, Name: name, Kind: MemberExternalList, Value: l.Type + ": " + l.Source})
+	}
+	if r, ok := m.LookupRegion(name); ok {
+		return append(out, ResolvedMember{Depth: depth, Name: name, Kind: MemberRegion, Value: strings.Join(r.Addresses, ", ")})
+	}
+	switch {
+	case name == "any" || isAddressLiteral(name):
+		return append(out, ResolvedMember{Depth: depth, Name: name, Kind: MemberLiteral, Value: name})
+	case isCountryCode(name):
+		return append(out, ResolvedMember{Depth: depth, Name: name, Kind: MemberRegion, Value: "country"})
+	}
+	return append(out, ResolvedMember{Depth: depth, Name: name, Kind: MemberUnresolved})
+}
+
+// ExpandServices resolves service members: objects to proto/port, and
+// groups to their members, recursively.
+func (m ObjectsModel) ExpandServices(names []string) []ResolvedMember {
+	var out []ResolvedMember
+	for _, name := range names {
+		out = m.expandService(out, name, 0, nil)
+	}
+	return out
+}
+
+func (m ObjectsModel) expandService(out []ResolvedMember, name string, depth int, path []string) []ResolvedMember {
+	if s, ok := m.LookupService(name); ok {
+		return append(out, ResolvedMember{Depth: depth, Name: name, Kind: MemberService, Value: serviceValue(s)})
+	}
+	if g, ok := m.LookupServiceGroup(name); ok {
+		out = append(out, ResolvedMember{Depth: depth, Name: name, Kind: MemberServiceGroup})
+		if slices.Contains(path, name) {
+			return out
+		}
+		path = append(path, name)
+		for _, member := range g.Members {
+			out = m.expandService(out, member, depth+1, path)
+		}
+		return out
+	}
+	if v, ok := predefinedServices[name]; ok {
+		return append(out, ResolvedMember{Depth: depth, Name: name, Kind: MemberService, Value: v})
+	}
+	if name == "any" || name == "application-default" {
+		return append(out, ResolvedMember{Depth: depth, Name: name, Kind: MemberLiteral, Value: name})
+	}
+	return append(out, ResolvedMember{Depth: depth, Name: name, Kind: MemberUnresolved})
+}
+
+// ResolveAddresses flattens address members to the values they stand for,
+// each once, for a table cell. Groups give way to their members; names
+// that resolve to nothing more concrete are kept as they are.
+func (m ObjectsModel) ResolveAddresses(names []string) []string {
+	return flatten(m.ExpandAddresses(names))
+}
+
+// ResolveServices flattens service members to proto/port, each once.
+func (m ObjectsModel) ResolveServices(names []string) []string {
+	return flatten(m.ExpandServices(names))
+}
+
+func flatten(members []ResolvedMember) []string {
+	var out []string
+	for _, rm := range members {
+		var v string
+		switch rm.Kind {
+		case MemberAddressGroup:
+			if rm.Value == "" {
+				continue // its members follow
+			}
+			v = rm.Name // a dynamic group's members aren't known here
+		case MemberServiceGroup:
+			continue
+		case MemberExternalList:
+			v = "EDL " + rm.Name
+		case MemberRegion:
+			v = "region " + rm.Name
+		case MemberUnresolved:
+			v = rm.Name
+		default:
+			v = rm.Value
+		}
+		if !slices.Contains(out, v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// serviceValue spells a service as proto/port, with its source ports.
+func serviceValue(s models.ServiceObject) string {
+	v := s.Protocol + "/" + s.DestPort
+	if s.SrcPort != "" {
+		v += " from " + s.SrcPort
+	}
+	return v
+}
+
+// isAddressLiteral reports whether a member is an address written into
+// the rule rather than an object name: an IP, a prefix, a range, or a
+// wildcard mask.
+func isAddressLiteral(s string) bool {
+	if _, err := netip.ParseAddr(s); err == nil {
+		return true
+	}
+	if _, err := netip.ParsePrefix(s); err == nil {
+		return true
+	}
+	if lo, hi, ok := strings.Cut(s, "-"); ok {
+		_, errLo := netip.ParseAddr(lo)
+		_, errHi := netip.ParseAddr(hi)
+		return errLo == nil && errHi == nil
+	}
+	if addr, mask, ok := strings.Cut(s, "/"); ok {
+		_, errAddr := netip.ParseAddr(addr)
+		_, errMask := netip.ParseAddr(mask)
+		return errAddr == nil && errMask == nil
+	}
+	return false
+}
+
+// countryCodes are the ISO 3166-1 codes of the countries PAN-OS predefines
+// as regions.
+var countryCodes = func() map[string]bool {
+	const codes = "AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ BA BB BD BE BF BG BH BI BJ BL BM BN BO BQ BR BS " +
+		"BT BV BW BY BZ CA CC CD CF CG CH CI CK CL CM CN CO CR CU CV CW CX CY CZ DE DJ DK DM DO DZ EC EE EG EH ER " +
+		"ES ET FI FJ FK FM FO FR GA GB GD GE GF GG GH GI GL GM GN GP GQ GR GS GT GU GW GY HK HM HN HR HT HU ID IE " +
+		"IL IM IN IO IQ IR IS IT JE JM JO JP KE KG KH KI KM KN KP KR KW KY KZ LA LB LC LI LK LR LS LT LU LV LY MA " +
+		"MC MD ME MF MG MH MK ML MM MN MO MP MQ MR MS MT MU MV MW MX MY MZ NA NC NE NF NG NI NL NO NP NR NU NZ OM " +
+		"PA PE PF PG PH PK PL PM PN PR PS PT PW PY QA RE RO RS RU RW SA SB SC SD SE SG SH SI SJ SK SL SM SN SO SR " +
+		"SS ST SV SX SY SZ TC TD TF TG TH TJ TK TL TM TN TO TR TT TV TW TZ UA UG UM US UY UZ VA VC VE VG VI VN VU " +
+		"WF WS YE YT ZA ZM ZW"
+	m := map[string]bool{}
+	for _, c := range strings.Fields(codes) {
+		m[c] = true
+	}
+	return m
+}()
+
+// isCountryCode reports whether a member names a country, such as "US".
+// Only a name that no configured object has is taken for one.
+func isCountryCode(s string) bool {
+	return countryCodes[s]
+}
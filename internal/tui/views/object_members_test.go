@@ -0,0 +1,160 @@
+package views
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/jp2195/pyre/internal/models"
+)
+
+// testObjects is an ObjectsModel with one of each kind of object loaded.
+func testObjects() ObjectsModel {
+	return NewObjectsModel().
+		SetAddresses([]models.AddressObject{
+			{Name: "web-01", Type: "ip-netmask", Value: "10.0.1.10/32"},
+			{Name: "web-02", Type: "ip-netmask", Value: "10.0.1.11/32"},
+			{Name: "web-01", Type: "ip-netmask", Value: "192.0.2.1/32"}, // shared, shadowed
+		}, nil).
+		SetServices([]models.ServiceObject{
+			{Name: "tcp-8443", Protocol: "tcp", DestPort: "8443"},
+			{Name: "udp-sip", Protocol: "udp", DestPort: "5060", SrcPort: "5060"},
+		}, nil).
+		SetGroups(ObjectGroups{
+			AddressGroups: []models.AddressGroup{
+				{Name: "web", Members: []string{"web-01", "web-02"}},
+				{Name: "all", Members: []string{"web", "all", "10.9.0.0/16"}},
+				{Name: "tagged", Filter: "'prod'"},
+			},
+			ServiceGroups: []models.ServiceGroup{{Name: "apps", Members: []string{"tcp-8443", "service-https"}}},
+			ExternalLists: []models.ExternalList{{Name: "blocked", Type: "ip", Source: "https://feed.example/ips"}},
+			Regions:       []models.Region{{Name: "hq", Addresses: []string{"10.0.0.0/8"}}},
+		}, nil)
+}
+
+func TestObjectsModel_ExpandAddresses(t *testing.T) {
+	m := testObjects()
+
+	got := m.ExpandAddresses([]string{"all", "blocked", "hq", "US", "tagged", "mystery"})
+	want := []ResolvedMember{
+		{Depth: 0, Name: "all", Kind: MemberAddressGroup},
+		{Depth: 1, Name: "web", Kind: MemberAddressGroup},
+		{Depth: 2, Name: "web-01", Kind: MemberAddress, Value: "10.0.1.10/32"},
+		{Depth: 2, Name: "web-02", Kind: MemberAddress, Value: "10.0.1.11/32"},
+		{Depth: 1, Name: "all", Kind: MemberAddressGroup}, // contains itself: not expanded again
+		{Depth: 1, Name: "10.9.0.0/16", Kind: MemberLiteral, Value: "10.9.0.0/16"},
+		{Depth: 0, Name: "blocked", Kind: MemberExternalList, Value: "ip: https://feed.example/ips"},
+		{Depth: 0, Name: "hq", Kind: MemberRegion, Value: "10.0.0.0/8"},
+		{Depth: 0, Name: "US", Kind: MemberRegion, Value: "country"},
+		{Depth: 0, Name: "tagged", Kind: MemberAddressGroup, Value: "'prod'"},
+		{Depth: 0, Name: "mystery", Kind: MemberUnresolved},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ExpandAddresses =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestObjectsModel_ExpandServices(t *testing.T) {
+	m := testObjects()
+
+	got := m.ExpandServices([]string{"apps", "udp-sip", "application-default"})
+	want := []ResolvedMember{
+		{Depth: 0, Name: "apps", Kind: MemberServiceGroup},
+		{Depth: 1, Name: "tcp-8443", Kind: MemberService, Value: "tcp/8443"},
+		{Depth: 1, Name: "service-https", Kind: MemberService, Value: "tcp/443"},
+		{Depth: 0, Name: "udp-sip", Kind: MemberService, Value: "udp/5060 from 5060"},
+		{Depth: 0, Name: "application-default", Kind: MemberLiteral, Value: "application-default"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ExpandServices =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestObjectsModel_Resolve(t *testing.T) {
+	m := testObjects()
+
+	if got, want := m.ResolveAddresses([]string{"web", "web-01", "blocked", "hq", "tagged"}),
+		[]string{"10.0.1.10/32", "10.0.1.11/32", "EDL blocked", "region hq", "tagged"}; !slices.Equal(got, want) {
+		t.Errorf("ResolveAddresses = %v, want %v", got, want)
+	}
+	if got, want := m.ResolveServices([]string{"apps"}), []string{"tcp/8443", "tcp/443"}; !slices.Equal(got, want) {
+		t.Errorf("ResolveServices = %v, want %v", got, want)
+	}
+}
+
+func TestObjectsModel_NothingLoaded(t *testing.T) {
+	m := NewObjectsModel()
+	if m.HasObjects() {
+		t.Error("new ObjectsModel should have no objects")
+	}
+	got := m.ExpandAddresses([]string{"web", "10.0.0.1", "US"})
+	if got[0].Kind != MemberUnresolved || got[1].Kind != MemberLiteral || got[2].Kind != MemberRegion {
+		t.Errorf("ExpandAddresses with nothing loaded = %+v", got)
+	}
+}
+
+func TestObjectsModel_ConfiguredNameIsNotACountry(t *testing.T) {
+	// "DE" and "FR" are country codes, but here also an address and a
+	// group; "XX" is two capitals but no country.
+	m := NewObjectsModel().
+		SetAddresses([]models.AddressObject{{Name: "DE", Type: "ip-netmask", Value: "10.49.0.0/16"}}, nil).
+		SetGroups(ObjectGroups{AddressGroups: []models.AddressGroup{{Name: "FR", Members: []string{"DE"}}}}, nil)
+
+	got := m.ExpandAddresses([]string{"DE", "FR", "XX", "CH"})
+	want := []ResolvedMember{
+		{Depth: 0, Name: "DE", Kind: MemberAddress, Value: "10.49.0.0/16"},
+		{Depth: 0, Name: "FR", Kind: MemberAddressGroup},
+		{Depth: 1, Name: "DE", Kind: MemberAddress, Value: "10.49.0.0/16"},
+		{Depth: 0, Name: "XX", Kind: MemberUnresolved},
+		{Depth: 0, Name: "CH", Kind: MemberRegion, Value: "country"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ExpandAddresses =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestIsAddressLiteral(t *testing.T) {
+	for s, want := range map[string]bool{
+		"10.0.0.1":             true,
+		"10.0.0.0/24":          true,
+		"10.0.0.1-10.0.0.9":    true,
+		"10.0.0.0/0.0.255.255": true,
+		"2001:db8::/32":        true,
+		"web-servers":          false,
+		"10.0.0.1-web":         false,
+		"any":                  false,
+	} {
+		if got := isAddressLiteral(s); got != want {
+			t.Errorf("isAddressLiteral(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
+
+func TestObjectsModel_LookupGroups(t *testing.T) {
+	m := testObjects()
+
+	if a, ok := m.LookupAddress("web-01"); !ok || a.Value != "10.0.1.10/32" {
+		t.Errorf("LookupAddress(web-01) = %+v, %v; want the vsys1 object", a, ok)
+	}
+	if g, ok := m.LookupAddressGroup("web"); !ok || len(g.Members) != 2 {
+		t.Errorf("LookupAddressGroup(web) = %+v, %v", g, ok)
+	}
+	if g, ok := m.LookupServiceGroup("apps"); !ok || len(g.Members) != 2 {
+		t.Errorf("LookupServiceGroup(apps) = %+v, %v", g, ok)
+	}
+	if l, ok := m.LookupExternalList("blocked"); !ok || l.Type != "ip" {
+		t.Errorf("LookupExternalList(blocked) = %+v, %v", l, ok)
+	}
+	if r, ok := m.LookupRegion("hq"); !ok || len(r.Addresses) != 1 {
+		t.Errorf("LookupRegion(hq) = %+v, %v", r, ok)
+	}
+	if _, ok := m.LookupServiceGroup("web"); ok {
+		t.Error("LookupServiceGroup(web) found an address group")
+	}
+
+	// A failed groups fetch keeps the groups already known.
+	m = m.SetGroups(ObjectGroups{}, errors.New("timeout"))
+	if _, ok := m.LookupAddressGroup("web"); !ok {
+		t.Error("SetGroups with an error dropped the known groups")
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	tea "charm.land/bubbletea/v2"
 
+	"github.com/jp2195/pyre/internal/cfgexport"
 	"github.com/jp2195/pyre/internal/models"
 )
 
@@ -61,6 +62,13 @@ type ObjectsModel struct {
 	width        int
 	height       int
 	spinnerFrame string
+
+	// groups are the groups, EDLs and regions loaded with the objects
+	// above, which rule members are resolved against (see SetGroups).
+	groups ObjectGroups
+
+	marks  map[cfgexport.Item]bool // objects of either tab marked for export
+	export exportStatus
 }
 
 // NewObjectsModel returns an ObjectsModel with the Address tab selected.
@@ -125,6 +133,93 @@ func (m ObjectsModel) SetSpinnerFrame(frame string) ObjectsModel {
 	return m
 }
 
+// SetExported reports the outcome of a config export.
+func (m ObjectsModel) SetExported(path string, err error) ObjectsModel {
+	m.export.done(path, err)
+	return m
+}
+
+// Marked returns the objects marked for export: addresses, then services,
+// each in load order.
+func (m ObjectsModel) Marked() []cfgexport.Item {
+	var out []cfgexport.Item
+	for _, a := range m.addressTab.addresses {
+		if it := addressItem(a); m.marks[it] {
+			out = append(out, it)
+		}
+	}
+	for _, s := range m.serviceTab.services {
+		if it := serviceItem(s); m.marks[it] {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+func addressItem(a models.AddressObject) cfgexport.Item {
+	return cfgexport.Item{Kind: cfgexport.KindAddress, Name: a.Name}
+}
+
+func serviceItem(s models.ServiceObject) cfgexport.Item {
+	return cfgexport.Item{Kind: cfgexport.KindService, Name: s.Name}
+}
+
+// selectedItem is the object under the cursor of the active tab.
+func (m ObjectsModel) selectedItem() (cfgexport.Item, bool) {
+	switch m.tab {
+	case ObjectsTabAddress:
+		if t := m.addressTab; t.Cursor < len(t.filtered) {
+			return addressItem(t.filtered[t.Cursor]), true
+		}
+	case ObjectsTabService:
+		if t := m.serviceTab; t.Cursor < len(t.filtered) {
+			return serviceItem(t.filtered[t.Cursor]), true
+		}
+	}
+	return cfgexport.Item{}, false
+}
+
+// toggleMarkSelected marks or unmarks the object under the cursor and moves
+// to the next.
+func (m ObjectsModel) toggleMarkSelected() ObjectsModel {
+	it, ok := m.selectedItem()
+	if !ok {
+		return m
+	}
+	m.marks = toggleMark(m.marks, it)
+	switch m.tab {
+	case ObjectsTabAddress:
+		if m.addressTab.Cursor < len(m.addressTab.filtered)-1 {
+			m.addressTab.Cursor++
+			m.addressTab.EnsureVisible(m.addressTab.VisibleRows(8, 14))
+		}
+	case ObjectsTabService:
+		if m.serviceTab.Cursor < len(m.serviceTab.filtered)-1 {
+			m.serviceTab.Cursor++
+			m.serviceTab.EnsureVisible(m.serviceTab.VisibleRows(8, 14))
+		}
+	}
+	return m
+}
+
+// startExport requests an export of the marked objects, or of the one
+// under the cursor when none are marked.
+func (m ObjectsModel) startExport(key string) (ObjectsModel, tea.Cmd) {
+	if m.export.exporting || m.IsLoading() {
+		return m, nil
+	}
+	items := m.Marked()
+	if len(items) == 0 {
+		it, ok := m.selectedItem()
+		if !ok {
+			return m, nil
+		}
+		items = []cfgexport.Item{it}
+	}
+	cmd := m.export.start(items, exportFormat(key))
+	return m, cmd
+}
+
 // SetAddresses replaces the address tab's data and refreshes its filter/sort.
 func (m ObjectsModel) SetAddresses(addresses []models.AddressObject, err error) ObjectsModel {
 	m.addressTab.addresses = addresses
@@ -147,24 +242,83 @@ func (m ObjectsModel) SetServices(services []models.ServiceObject, err error) Ob
 	return m
 }
 
-// LookupAddress is the Phase 2 hook: returns the matching address object by name.
-func (m ObjectsModel) LookupAddress(name string) (models.AddressObject, bool) {
-	for _, a := range m.addressTab.addresses {
-		if a.Name == name {
-			return a, true
-		}
+// SetGroups replaces the groups, EDLs and regions rule members are
+// resolved against. They have no tab of their own. A failed fetch keeps
+// the previous ones: rules still resolve against what is known.
+func (m ObjectsModel) SetGroups(groups ObjectGroups, err error) ObjectsModel {
+	if err != nil {
+		return m
 	}
-	return models.AddressObject{}, false
+	m.groups = groups
+	return m
 }
 
-// LookupService is the Phase 2 hook: returns the matching service object by name.
+// HasObjects reports whether any objects, groups, EDLs or regions are
+// loaded to resolve rule members against.
+func (m ObjectsModel) HasObjects() bool {
+	g := m.groups
+	return len(m.addressTab.addresses) > 0 || len(m.serviceTab.services) > 0 || len(g.AddressGroups) > 0 ||
+		len(g.ServiceGroups) > 0 || len(g.ExternalLists) > 0 || len(g.Regions) > 0
+}
+
+// LookupAddress returns the address object named name.
+func (m ObjectsModel) LookupAddress(name string) (models.AddressObject, bool) {
+	return lookupByName(m.addressTab.addresses, name, func(a models.AddressObject) string { return a.Name })
+}
+
+// LookupService returns the service object named name.
 func (m ObjectsModel) LookupService(name string) (models.ServiceObject, bool) {
-	for _, s := range m.serviceTab.services {
-		if s.Name == name {
-			return s, true
-		}
+	return lookupByName(m.serviceTab.services, name, func(s models.ServiceObject) string { return s.Name })
+}
+
+// LookupAddressGroup returns the address group named name.
+func (m ObjectsModel) LookupAddressGroup(name string) (models.AddressGroup, bool) {
+	return lookupByName(m.groups.AddressGroups, name, func(g models.AddressGroup) string { return g.Name })
+}
+
+// LookupServiceGroup returns the service group named name.
+func (m ObjectsModel) LookupServiceGroup(name string) (models.ServiceGroup, bool) {
+	return lookupByName(m.groups.ServiceGroups, name, func(g models.ServiceGroup) string { return g.Name })
+}
+
+// LookupExternalList returns the external dynamic list named name.
+func (m ObjectsModel) LookupExternalList(name string) (models.ExternalList, bool) {
+	return lookupByName(m.groups.ExternalLists, name, func(l models.ExternalList) string { return l.Name })
+}
+
+// LookupRegion returns the custom region named name.
+func (m ObjectsModel) LookupRegion(name string) (models.Region, bool) {
+	return lookupByName(m.groups.Regions, name, func(r models.Region) string { return r.Name })
+}
+
+// SelectAddress switches to the Address tab, moves the cursor to the
+// address object named name and opens its detail, clearing the filter if
+// it hides the object. It reports false when no object has that name.
+func (m ObjectsModel) SelectAddress(name string) (ObjectsModel, bool) {
+	match := func(a models.AddressObject) bool { return a.Name == name }
+	t := &m.addressTab
+	if !slices.ContainsFunc(t.addresses, match) {
+		return m, false
+	}
+	if !slices.ContainsFunc(t.filtered, match) {
+		t.Filter.SetValue("")
+		t.applyFilter()
+	}
+	m.tab = ObjectsTabAddress
+	t.Cursor = slices.IndexFunc(t.filtered, match)
+	t.Expanded = true
+	t.EnsureVisible(t.VisibleRows(8, 14))
+	return m, true
+}
+
+// LookupAddresses is the selected address object's value, for a where-used
+// lookup.
+func (m ObjectsModel) LookupAddresses() []string {
+	t := m.addressTab
+	if m.tab != ObjectsTabAddress || t.Cursor >= len(t.filtered) {
+		return nil
 	}
-	return models.ServiceObject{}, false
+	return []string{t.filtered[t.Cursor].Value}
 }
 
 func (t *objectsAddressTab) applyFilter() {
@@ -328,6 +482,10 @@ func (m ObjectsModel) Update(msg tea.Msg) (ObjectsModel, tea.Cmd) {
 			m.serviceTab.Offset = 0
 		}
 		return m, nil
+	case "space", " ":
+		return m.toggleMarkSelected(), nil
+	case "e", "E":
+		return m.startExport(key.String())
 	case "esc":
 		switch m.tab {
 		case ObjectsTabAddress:
@@ -336,6 +494,7 @@ func (m ObjectsModel) Update(msg tea.Msg) (ObjectsModel, tea.Cmd) {
 			}
 			if m.addressTab.HandleClearFilter() {
 				m.addressTab.applyFilter()
+				return m, nil
 			}
 		case ObjectsTabService:
 			if m.serviceTab.HandleCollapseIfExpanded() {
@@ -343,8 +502,10 @@ func (m ObjectsModel) Update(msg tea.Msg) (ObjectsModel, tea.Cmd) {
 			}
 			if m.serviceTab.HandleClearFilter() {
 				m.serviceTab.applyFilter()
+				return m, nil
 			}
 		}
+		m.marks = nil
 		return m, nil
 	}
 
@@ -401,6 +562,9 @@ func (m ObjectsModel) View() string {
 	b.WriteString("  ")
 	b.WriteString(m.renderTabIndicator())
 	b.WriteString("\n")
+	if status := m.export.render(m.spinnerFrame, m.width-12); status != "" {
+		b.WriteString(status + "\n")
+	}
 
 	switch m.tab {
 	case ObjectsTabAddress:
@@ -422,7 +586,11 @@ func (m ObjectsModel) renderTabIndicator() string {
 		addr = StatusMutedStyle.Render(addr)
 		svc = StatusActiveStyle.Render("[Service]")
 	}
-	hint := BannerInfoStyle.Render("  ([/] or a/s to switch)")
+	marked := ""
+	if n := len(m.Marked()); n > 0 {
+		marked = fmt.Sprintf(" | %d marked", n)
+	}
+	hint := BannerInfoStyle.Render("  ([/] or a/s to switch" + marked + " | " + exportKeyHint + ")")
 	return addr + "  " + svc + hint
 }
 
@@ -522,6 +690,8 @@ func (m ObjectsModel) renderAddressTable() string {
 		)
 		if i == t.Cursor {
 			b.WriteString(selectedStyle.Render(row))
+		} else if m.marks[addressItem(a)] {
+			b.WriteString(StatusWarningStyle.Render(row))
 		} else {
 			b.WriteString(DetailValueStyle.Render(row))
 		}
@@ -589,6 +759,8 @@ func (m ObjectsModel) renderServiceTable() string {
 		)
 		if i == t.Cursor {
 			b.WriteString(selectedStyle.Render(row))
+		} else if m.marks[serviceItem(s)] {
+			b.WriteString(StatusWarningStyle.Render(row))
 		} else {
 			b.WriteString(DetailValueStyle.Render(row))
 		}
@@ -1,12 +1,14 @@
 package views
 
 import (
+	"errors"
 	"strings"
 	"testing"
 	"unicode/utf8"
 
 	tea "charm.land/bubbletea/v2"
 
+	"github.com/jp2195/pyre/internal/cfgexport"
 	"github.com/jp2195/pyre/internal/models"
 )
 
@@ -274,3 +276,36 @@ func TestObjects_BracketKeysSwitchSubTabs(t *testing.T) {
 		t.Error("'[' should move back to the Service sub-tab")
 	}
 }
+
+func TestObjectsModel_MarkAcrossTabsAndExport(t *testing.T) {
+	m := NewObjectsModel().SetSize(160, 40)
+	m = m.SetAddresses([]models.AddressObject{{Name: "web-01"}, {Name: "web-02"}}, nil)
+	m = m.SetServices([]models.ServiceObject{{Name: "tcp-443"}}, nil)
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'j', Text: "j"})
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeySpace, Text: " "})
+	m, _ = m.Update(tea.KeyPressMsg{Code: 's', Text: "s"})
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeySpace, Text: " "})
+	if !strings.Contains(m.View(), "2 marked") {
+		t.Errorf("banner should count marks on both tabs:\n%s", m.View())
+	}
+
+	m, cmd := m.Update(tea.KeyPressMsg{Code: 'E', Text: "E"})
+	if cmd == nil {
+		t.Fatal("E should request an export")
+	}
+	req := cmd().(ConfigExportRequestMsg)
+	want := []cfgexport.Item{{Kind: cfgexport.KindAddress, Name: "web-02"}, {Kind: cfgexport.KindService, Name: "tcp-443"}}
+	if req.Format != cfgexport.FormatXML || len(req.Items) != 2 || req.Items[0] != want[0] || req.Items[1] != want[1] {
+		t.Errorf("request = %+v, want XML of %+v", req, want)
+	}
+
+	m = m.SetExported("", errors.New("boom"))
+	if !strings.Contains(m.View(), "Export failed: boom") {
+		t.Errorf("expected the failure:\n%s", m.View())
+	}
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+	if len(m.Marked()) != 0 {
+		t.Error("esc should clear the marks")
+	}
+}
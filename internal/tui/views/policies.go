@@ -6,11 +6,15 @@ import (
 
 	tea "charm.land/bubbletea/v2"
 
+	"github.com/jp2195/pyre/internal/cfgexport"
 	"github.com/jp2195/pyre/internal/models"
 )
 
 type PoliciesModel struct {
 	list RuleListModel[models.SecurityRule]
+
+	objects  ObjectsModel // resolves the rules' addresses and services
+	resolved bool         // the table shows resolved values, not names
 }
 
 func NewPoliciesModel() PoliciesModel {
@@ -25,11 +29,41 @@ func NewPoliciesModel() PoliciesModel {
 		MatchFilter:       matchSecurityRule,
 		CompareItems:      compareSecurityRule,
 		FormatHeaderRow:   formatSecurityHeader,
-		FormatRow:         formatSecurityRow,
-		RenderDetail:      renderSecurityDetail,
 		IsDisabled:        func(r models.SecurityRule) bool { return r.Disabled },
+		KeyHint:           "v: names/values | " + exportKeyHint,
+		ExportItem: func(p models.SecurityRule) cfgexport.Item {
+			return cfgexport.Item{Kind: cfgexport.KindSecurityRule, Name: p.Name}
+		},
 	}
-	return PoliciesModel{list: NewRuleListModel(config)}
+	return PoliciesModel{list: NewRuleListModel(config)}.rebind()
+}
+
+// SetObjects resolves the rules' addresses and services against objects,
+// in the detail panel and, when toggled, the table.
+func (m PoliciesModel) SetObjects(objects ObjectsModel) PoliciesModel {
+	m.objects = objects
+	return m.rebind()
+}
+
+// Objects are the objects the rules are resolved against.
+func (m PoliciesModel) Objects() ObjectsModel {
+	return m.objects
+}
+
+// Resolved reports whether the table shows resolved values.
+func (m PoliciesModel) Resolved() bool {
+	return m.resolved
+}
+
+// rebind points the table and detail renderers at the current objects and
+// toggle.
+func (m PoliciesModel) rebind() PoliciesModel {
+	x, resolved := m.objects, m.resolved
+	m.list = m.list.SetRenderers(
+		func(p models.SecurityRule, width int) string { return formatSecurityRow(p, width, x, resolved) },
+		func(p models.SecurityRule, width int) string { return renderSecurityDetail(p, width, x) },
+	)
+	return m
 }
 
 func (m PoliciesModel) SetSize(width, height int) PoliciesModel {
@@ -61,7 +95,40 @@ func (m PoliciesModel) SetPolicies(policies []models.SecurityRule, err error) Po
 	return m
 }
 
+// SetExported reports the outcome of a config export.
+func (m PoliciesModel) SetExported(path string, err error) PoliciesModel {
+	m.list = m.list.SetExported(path, err)
+	return m
+}
+
+// Rules are the rules loaded, in rulebase order.
+func (m PoliciesModel) Rules() []models.SecurityRule {
+	return m.list.Items()
+}
+
+// Select moves the cursor to the rule named name and opens its detail. It
+// reports false when no rule has that name.
+func (m PoliciesModel) Select(name string) (PoliciesModel, bool) {
+	var ok bool
+	m.list, ok = m.list.SelectWhere(func(p models.SecurityRule) bool { return p.Name == name })
+	return m, ok
+}
+
+// LookupAddresses are the selected rule's source and destination
+// addresses, resolved, for a where-used lookup.
+func (m PoliciesModel) LookupAddresses() []string {
+	p, ok := m.list.Selected()
+	if !ok {
+		return nil
+	}
+	return append(m.objects.ResolveAddresses(p.Sources), m.objects.ResolveAddresses(p.Destinations)...)
+}
+
 func (m PoliciesModel) Update(msg tea.Msg) (PoliciesModel, tea.Cmd) {
+	if key, ok := msg.(tea.KeyPressMsg); ok && key.String() == "v" && !m.list.IsFilterMode() {
+		m.resolved = !m.resolved
+		return m.rebind(), nil
+	}
 	var cmd tea.Cmd
 	m.list, cmd = m.list.Update(msg)
 	return m, cmd
@@ -106,7 +173,10 @@ func compareSecurityRule(a, b models.SecurityRule, sortIdx int) bool {
 }
 
 func formatSecurityHeader(width int) string {
-	if width >= 150 {
+	if width >= 170 {
+		return fmt.Sprintf("%-4s %-5s %-24s %-8s %-20s %-18s %-18s %-18s %-16s %-10s %-10s",
+			"#", "Base", "Name", "Action", "Source → Dest Zone", "Source", "Destination", "Application", "Service", "Hits", "Last Hit")
+	} else if width >= 150 {
 		return fmt.Sprintf("%-4s %-5s %-24s %-8s %-20s %-18s %-16s %-10s %-10s",
 			"#", "Base", "Name", "Action", "Source → Dest Zone", "Application", "Service", "Hits", "Last Hit")
 	} else if width >= 120 {
@@ -120,7 +190,9 @@ func formatSecurityHeader(width int) string {
 		"#", "Name", "Action", "Zones", "Hits")
 }
 
-func formatSecurityRow(p models.SecurityRule, width int) string {
+// formatSecurityRow renders p's table row. With resolved, its addresses
+// and services are shown as the values x resolves them to.
+func formatSecurityRow(p models.SecurityRule, width int, x ObjectsModel, resolved bool) string {
 	action := strings.ToUpper(p.Action)
 	if len(action) > 8 {
 		action = action[:7] + "…"
@@ -131,16 +203,33 @@ func formatSecurityRow(p models.SecurityRule, width int) string {
 	dstZone := formatZoneCompact(p.DestZones)
 	zones := srcZone + "→" + dstZone
 	apps := formatListCompact(p.Applications, 14)
-	services := formatListCompact(p.Services, 14)
+	sources, destinations, serviceList := p.Sources, p.Destinations, p.Services
+	if resolved {
+		sources, destinations, serviceList = x.ResolveAddresses(sources), x.ResolveAddresses(destinations), x.ResolveServices(serviceList)
+	}
+	src := formatListCompact(sources, 18)
+	if p.NegateSource {
+		src = "!" + src
+	}
+	dst := formatListCompact(destinations, 18)
+	if p.NegateDest {
+		dst = "!" + dst
+	}
+	services := formatListCompact(serviceList, 14)
 	hits := formatHitCount(p.HitCount)
-	lastHit := formatTimeAgo(p.LastHit)
+	lastHit := FormatTimeAgo(p.LastHit)
 
 	name := p.Name
 	if len(p.Tags) > 0 {
 		name = name + " •"
 	}
 
-	if width >= 150 {
+	if width >= 170 {
+		return fmt.Sprintf("%-4d %-5s %-24s %-8s %-20s %-18s %-18s %-18s %-16s %-10s %-10s",
+			p.Position, base, truncateEllipsis(name, 24), action,
+			truncateEllipsis(zones, 20), truncateEllipsis(src, 18), truncateEllipsis(dst, 18),
+			truncateEllipsis(apps, 18), truncateEllipsis(services, 16), hits, lastHit)
+	} else if width >= 150 {
 		return fmt.Sprintf("%-4d %-5s %-24s %-8s %-20s %-18s %-16s %-10s %-10s",
 			p.Position, base, truncateEllipsis(name, 24), action,
 			truncateEllipsis(zones, 20), truncateEllipsis(apps, 18),
@@ -159,7 +248,9 @@ func formatSecurityRow(p models.SecurityRule, width int) string {
 		truncateEllipsis(zones, 14), hits)
 }
 
-func renderSecurityDetail(p models.SecurityRule, width int) string {
+// renderSecurityDetail renders p's detail panel, its addresses and
+// services expanded by x.
+func renderSecurityDetail(p models.SecurityRule, width int, x ObjectsModel) string {
 	dr := NewDetailRenderer(width, 16)
 
 	title := p.Name
@@ -174,17 +265,18 @@ func renderSecurityDetail(p models.SecurityRule, width int) string {
 	// Source/Destination Section
 	dr.Section("Traffic Match")
 	dr.Field("Source Zones:", formatListFull(p.SourceZones))
-	dr.FieldStyled("Source Addr:", formatAddresses(p.Sources, p.NegateSource, DetailValueStyle, DetailDimStyle))
+	loaded := x.HasObjects()
+	dr.FieldLines("Source Addr:", formatMembers(p.Sources, x.ExpandAddresses(p.Sources), p.NegateSource, loaded))
 	if len(p.SourceUsers) > 0 && (len(p.SourceUsers) != 1 || p.SourceUsers[0] != "any") {
 		dr.Field("Source Users:", formatListFull(p.SourceUsers))
 	}
 	dr.Field("Dest Zones:", formatListFull(p.DestZones))
-	dr.FieldStyled("Dest Addr:", formatAddresses(p.Destinations, p.NegateDest, DetailValueStyle, DetailDimStyle))
+	dr.FieldLines("Dest Addr:", formatMembers(p.Destinations, x.ExpandAddresses(p.Destinations), p.NegateDest, loaded))
 
 	// Application/Service Section
 	dr.Section("Application/Service")
 	dr.Field("Applications:", formatListFull(p.Applications))
-	dr.Field("Services:", formatListFull(p.Services))
+	dr.FieldLines("Services:", formatMembers(p.Services, x.ExpandServices(p.Services), false, loaded))
 	if len(p.URLCategories) > 0 && (len(p.URLCategories) != 1 || p.URLCategories[0] != "any") {
 		dr.Field("URL Categories:", formatListFull(p.URLCategories))
 	}
@@ -212,3 +212,51 @@ func TestPoliciesModel_SetSpinnerFrame_ReachesList(t *testing.T) {
 		t.Errorf("list.SpinnerFrame = %q, want ◢", m.list.SpinnerFrame)
 	}
 }
+
+func TestPoliciesModel_ResolvedDetailAndToggle(t *testing.T) {
+	m := NewPoliciesModel().SetSize(200, 60)
+	m = m.SetPolicies([]models.SecurityRule{{
+		Name: "web-in", Action: "allow", Position: 1,
+		Sources: []string{"blocked"}, NegateSource: true,
+		Destinations: []string{"web"}, Services: []string{"apps"},
+	}}, nil)
+	m = m.SetObjects(testObjects())
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	view := stripANSI(m.View())
+	for _, want := range []string{
+		"NOT blocked (EDL, ip: https://feed.example/ips)",
+		"web (group)",
+		"  web-01 10.0.1.10/32",
+		"  tcp-8443 tcp/8443",
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("detail missing %q:\n%s", want, view)
+		}
+	}
+	row := formatSecurityRow(m.list.Filtered()[0], 188, m.objects, false)
+	if !strings.Contains(row, "!blocked") || strings.Contains(row, "10.0.1.10/32") {
+		t.Errorf("names row = %q", row)
+	}
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'v', Text: "v"})
+	if !m.Resolved() {
+		t.Fatal("v did not switch the table to resolved values")
+	}
+	row = formatSecurityRow(m.list.Filtered()[0], 188, m.objects, m.Resolved())
+	if !strings.Contains(row, "!EDL blocked") || !strings.Contains(row, "10.0.1.10/32+1") {
+		t.Errorf("resolved row = %q", row)
+	}
+	if view := stripANSI(m.View()); !strings.Contains(view, "10.0.1.10/32+1") {
+		t.Errorf("table not showing resolved values:\n%s", view)
+	}
+}
+
+func TestPoliciesModel_DetailBeforeObjectsLoad(t *testing.T) {
+	m := NewPoliciesModel().SetSize(200, 60)
+	m = m.SetPolicies([]models.SecurityRule{{Name: "r", Action: "allow", Sources: []string{"a", "b"}}}, nil)
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if view := stripANSI(m.View()); !strings.Contains(view, "a, b") {
+		t.Errorf("expected names on one line before objects load:\n%s", view)
+	}
+}
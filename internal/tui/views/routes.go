@@ -102,6 +102,16 @@ func (m RoutesModel) HasData() bool {
 	return m.routes != nil || m.routeErr != nil
 }
 
+// LookupAddresses are the selected route's destination and next hop, for a
+// where-used lookup.
+func (m RoutesModel) LookupAddresses() []string {
+	if m.activeTab != RoutesTabRoutes || m.Cursor >= len(m.filtered) {
+		return nil
+	}
+	r := m.filtered[m.Cursor]
+	return []string{r.Destination, r.Nexthop}
+}
+
 func (m RoutesModel) SetRoutes(routes []models.RouteEntry, err error) RoutesModel {
 	m.routes = routes
 	m.routeErr = err
@@ -6,6 +6,8 @@ import (
 	"strings"
 
 	tea "charm.land/bubbletea/v2"
+
+	"github.com/jp2195/pyre/internal/cfgexport"
 )
 
 // RuleListConfig defines the type-specific behavior for a RuleListModel.
@@ -24,6 +26,8 @@ type RuleListConfig[T any] struct {
 	RenderDetail      func(item T, width int) string  // Renders the detail panel
 	IsDisabled        func(item T) bool               // Optional: returns true if item should render as disabled
 	StyleRow          func(item T, width int) string  // Optional: renders a non-selected row with custom styling (replaces FormatRow + normal/disabled styling)
+	KeyHint           string                          // Optional: a view-specific key for the banner, e.g. "v: values"
+	ExportItem        func(item T) cfgexport.Item     // Optional: enables marking rows (space) and exporting them as config (e/E)
 }
 
 // RuleListModel provides a generic, filterable, sortable list with detail expansion.
@@ -35,6 +39,9 @@ type RuleListModel[T any] struct {
 	items    []T
 	filtered []T
 	sortBy   int
+
+	marks  map[string]bool // names of the items marked for export
+	export exportStatus
 }
 
 // NewRuleListModel creates a new rule list with the given config.
@@ -80,6 +87,40 @@ func (m RuleListModel[T]) SetItems(items []T, err error) RuleListModel[T] {
 	return m
 }
 
+// SetExported reports the outcome of an export.
+func (m RuleListModel[T]) SetExported(path string, err error) RuleListModel[T] {
+	m.export.done(path, err)
+	return m
+}
+
+// Marked returns the items marked for export, in list order. Marks are
+// kept by name, so they survive a refresh.
+func (m RuleListModel[T]) Marked() []T {
+	if m.config.ExportItem == nil {
+		return nil
+	}
+	var out []T
+	for _, item := range m.items {
+		if m.marks[m.config.ExportItem(item).Name] {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// isMarked reports whether item is marked for export.
+func (m RuleListModel[T]) isMarked(item T) bool {
+	return m.config.ExportItem != nil && m.marks[m.config.ExportItem(item).Name]
+}
+
+// SetRenderers replaces the row and detail renderers, for views whose rows
+// depend on more than the item (see PoliciesModel.SetObjects).
+func (m RuleListModel[T]) SetRenderers(formatRow, renderDetail func(item T, width int) string) RuleListModel[T] {
+	m.config.FormatRow = formatRow
+	m.config.RenderDetail = renderDetail
+	return m
+}
+
 // Items returns the full (unfiltered) items slice.
 func (m RuleListModel[T]) Items() []T {
 	return m.items
@@ -90,6 +131,32 @@ func (m RuleListModel[T]) Filtered() []T {
 	return m.filtered
 }
 
+// Selected returns the item under the cursor.
+func (m RuleListModel[T]) Selected() (T, bool) {
+	if m.Cursor < 0 || m.Cursor >= len(m.filtered) {
+		var zero T
+		return zero, false
+	}
+	return m.filtered[m.Cursor], true
+}
+
+// SelectWhere moves the cursor to the first item match accepts and expands
+// its detail, clearing the filter if it hides that item. It reports false,
+// leaving the list as it was, when no item matches.
+func (m RuleListModel[T]) SelectWhere(match func(T) bool) (RuleListModel[T], bool) {
+	if !slices.ContainsFunc(m.items, match) {
+		return m, false
+	}
+	if !slices.ContainsFunc(m.filtered, match) {
+		m.Filter.SetValue("")
+		m.applyFilter()
+	}
+	m.Cursor = slices.IndexFunc(m.filtered, match)
+	m.Expanded = true
+	m.EnsureVisible(m.visibleRows())
+	return m, true
+}
+
 func (m *RuleListModel[T]) applyFilter() {
 	if m.FilterValue() == "" {
 		m.filtered = make([]T, len(m.items))
@@ -164,8 +231,27 @@ func (m RuleListModel[T]) Update(msg tea.Msg) (RuleListModel[T], tea.Cmd) {
 			}
 			if m.HandleClearFilter() {
 				m.applyFilter()
+				return m, nil
+			}
+			m.marks = nil
+			return m, nil
+		case "space", " ":
+			if m.config.ExportItem == nil {
+				break
+			}
+			if item, ok := m.Selected(); ok {
+				m.marks = toggleMark(m.marks, m.config.ExportItem(item).Name)
+				if m.Cursor < len(m.filtered)-1 {
+					m.Cursor++
+					m.EnsureVisible(m.visibleRows())
+				}
 			}
 			return m, nil
+		case "e", "E":
+			if m.config.ExportItem == nil {
+				break
+			}
+			return m.startExport(msg.String())
 		case "s":
 			m.cycleSort()
 			m.Cursor = 0
@@ -189,6 +275,28 @@ func (m RuleListModel[T]) Update(msg tea.Msg) (RuleListModel[T], tea.Cmd) {
 	return m, nil
 }
 
+// startExport requests an export of the marked items, or of the item under
+// the cursor when none are marked.
+func (m RuleListModel[T]) startExport(key string) (RuleListModel[T], tea.Cmd) {
+	if m.export.exporting || m.Loading || m.Err != nil {
+		return m, nil
+	}
+	selected := m.Marked()
+	if len(selected) == 0 {
+		item, ok := m.Selected()
+		if !ok {
+			return m, nil
+		}
+		selected = []T{item}
+	}
+	items := make([]cfgexport.Item, len(selected))
+	for i, item := range selected {
+		items[i] = m.config.ExportItem(item)
+	}
+	cmd := m.export.start(items, exportFormat(key))
+	return m, cmd
+}
+
 func (m RuleListModel[T]) updateFilter(msg tea.Msg) (RuleListModel[T], tea.Cmd) {
 	base, exited, cmd := m.HandleFilterMode(msg)
 	m.TableBase = base
@@ -213,7 +321,14 @@ func (m RuleListModel[T]) View() string {
 	if noun == "" {
 		noun = "rules"
 	}
-	sortInfo := BannerInfoStyle.Render(fmt.Sprintf(" [%d %s | Sort: %s | s: change | S: dir | /: filter | enter: details]", len(m.filtered), noun, m.sortLabel()))
+	hint := ""
+	if m.config.KeyHint != "" {
+		hint = " | " + m.config.KeyHint
+	}
+	if n := len(m.Marked()); n > 0 {
+		hint = fmt.Sprintf(" | %d marked%s", n, hint)
+	}
+	sortInfo := BannerInfoStyle.Render(fmt.Sprintf(" [%d %s | Sort: %s | s: change | S: dir | /: filter | enter: details%s]", len(m.filtered), noun, m.sortLabel(), hint))
 	b.WriteString(titleStyle.Render(title) + sortInfo)
 	b.WriteString("\n")
 
@@ -231,6 +346,9 @@ func (m RuleListModel[T]) View() string {
 		b.WriteString(ErrorMsgStyle.Render("Error: " + m.Err.Error()))
 		return panelStyle.Render(b.String())
 	}
+	if status := m.export.render(m.SpinnerFrame, m.contentWidth()); status != "" {
+		b.WriteString(status + "\n")
+	}
 
 	if m.Loading || m.items == nil {
 		b.WriteString(RenderLoadingInline(m.SpinnerFrame, m.config.LoadingMsg))
@@ -268,6 +386,7 @@ func (m RuleListModel[T]) renderTable() string {
 	selectedStyle := TableSelectedRowStyle().Bold(true)
 	normalStyle := DetailValueStyle
 	disabledStyle := TableDisabledRowStyle()
+	markedStyle := StatusWarningStyle
 	dimStyle := DetailDimStyle
 
 	availableWidth := m.contentWidth()
@@ -292,6 +411,8 @@ func (m RuleListModel[T]) renderTable() string {
 
 		if isSelected {
 			b.WriteString(selectedStyle.Render(row))
+		} else if m.isMarked(item) {
+			b.WriteString(markedStyle.Render(row))
 		} else if m.config.StyleRow != nil {
 			b.WriteString(m.config.StyleRow(item, availableWidth))
 		} else if m.config.IsDisabled != nil && m.config.IsDisabled(item) {
@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	tea "charm.land/bubbletea/v2"
+
+	"github.com/jp2195/pyre/internal/cfgexport"
 )
 
 type rlItem struct {
@@ -178,3 +180,79 @@ func TestRuleList_ColumnsDoNotShiftWithCursor(t *testing.T) {
 		}
 	}
 }
+
+func TestRuleListModel_SelectWhere(t *testing.T) {
+	m := NewRuleListModel(testRuleListConfig()).SetSize(160, 40).
+		SetItems([]rlItem{{Name: "a"}, {Name: "b"}, {Name: "c"}}, nil)
+	m.Filter.SetValue("a")
+	m.applyFilter()
+
+	m, ok := m.SelectWhere(func(it rlItem) bool { return it.Name == "c" })
+	if !ok {
+		t.Fatal("item c not found")
+	}
+	if it, _ := m.Selected(); it.Name != "c" || !m.Expanded || m.IsFiltered() {
+		t.Errorf("selected %q, expanded %v, filtered %v", it.Name, m.Expanded, m.IsFiltered())
+	}
+	if _, ok := m.SelectWhere(func(it rlItem) bool { return it.Name == "missing" }); ok {
+		t.Error("a missing item should not be selected")
+	}
+}
+
+func TestRuleListModel_MarkAndExport(t *testing.T) {
+	cfg := testRuleListConfig()
+	cfg.ExportItem = func(it rlItem) cfgexport.Item {
+		return cfgexport.Item{Kind: cfgexport.KindSecurityRule, Name: it.Name}
+	}
+	m := NewRuleListModel(cfg).SetSize(160, 40).
+		SetItems([]rlItem{{Name: "a"}, {Name: "b"}, {Name: "c"}}, nil)
+
+	// Nothing marked: the row under the cursor (c, sorted descending) is exported.
+	m, cmd := m.Update(tea.KeyPressMsg{Code: 'e', Text: "e"})
+	if cmd == nil {
+		t.Fatal("e should request an export")
+	}
+	req := cmd().(ConfigExportRequestMsg)
+	if len(req.Items) != 1 || req.Items[0].Name != "c" || req.Format != cfgexport.FormatSet {
+		t.Errorf("request = %+v", req)
+	}
+	if !strings.Contains(m.View(), "Exporting...") {
+		t.Error("the export should show as in flight")
+	}
+	m = m.SetExported("/tmp/x.set", nil)
+
+	// Space marks and moves on; marks are exported in list order.
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'j', Text: "j"})
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeySpace, Text: " "})
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'k', Text: "k"})
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'k', Text: "k"})
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeySpace, Text: " "})
+	if it, _ := m.Selected(); it.Name != "b" {
+		t.Errorf("cursor on %q after marking, want b", it.Name)
+	}
+	view := m.View()
+	if !strings.Contains(view, "2 marked") || !strings.Contains(view, "Exported to /tmp/x.set") {
+		t.Errorf("banner and notice:\n%s", view)
+	}
+	_, cmd = m.Update(tea.KeyPressMsg{Code: 'E', Text: "E"})
+	req = cmd().(ConfigExportRequestMsg)
+	if len(req.Items) != 2 || req.Items[0].Name != "b" || req.Items[1].Name != "c" || req.Format != cfgexport.FormatXML {
+		t.Errorf("request = %+v", req)
+	}
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+	if len(m.Marked()) != 0 {
+		t.Error("esc should clear the marks")
+	}
+}
+
+func TestRuleListModel_NoExportWithoutExportItem(t *testing.T) {
+	m := NewRuleListModel(testRuleListConfig()).SetSize(160, 40).
+		SetItems([]rlItem{{Name: "a"}}, nil)
+	if _, cmd := m.Update(tea.KeyPressMsg{Code: 'e', Text: "e"}); cmd != nil {
+		t.Error("a list without ExportItem should not export")
+	}
+	if m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeySpace, Text: " "}); len(m.Marked()) != 0 {
+		t.Error("a list without ExportItem should not mark")
+	}
+}
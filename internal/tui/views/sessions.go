@@ -71,6 +71,16 @@ func (m SessionsModel) HasData() bool {
 	return m.list.HasData()
 }
 
+// LookupAddresses are the selected session's addresses, for a where-used
+// lookup.
+func (m SessionsModel) LookupAddresses() []string {
+	s, ok := m.list.Selected()
+	if !ok {
+		return nil
+	}
+	return []string{s.SourceIP, s.DestIP, s.NATSourceIP}
+}
+
 // IsFilterMode returns true while the filter text input is focused.
 func (m SessionsModel) IsFilterMode() bool {
 	return m.list.IsFilterMode()
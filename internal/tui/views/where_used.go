@@ -0,0 +1,396 @@
+package views
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/jp2195/pyre/internal/models"
+	"github.com/jp2195/pyre/internal/netmatch"
+)
+
+// UsageKind is what a where-used hit is in.
+type UsageKind int
+
+const (
+	UsageSecurityRule UsageKind = iota
+	UsageNATRule
+	UsageAddress
+	UsageAddressGroup
+	UsageRegion
+)
+
+func (k UsageKind) String() string {
+	switch k {
+	case UsageSecurityRule:
+		return "security rule"
+	case UsageNATRule:
+		return "NAT rule"
+	case UsageAddress:
+		return "address"
+	case UsageAddressGroup:
+		return "address group"
+	default:
+		return "region"
+	}
+}
+
+// UsageHit is one place an address value that meets the query is used.
+type UsageHit struct {
+	Kind  UsageKind
+	Name  string // the rule or object
+	Field string // where in it: "source", "translated destination", "member"...
+	// Via are the objects and groups between the field and the value,
+	// outermost first; empty when the value is written in directly.
+	Via      []string
+	Value    string
+	Relation netmatch.Relation // how Value relates to the query
+	Negated  bool              // the rule field is negated
+}
+
+// WhereUsedJumpMsg asks the app to show the rule or object a hit is in.
+type WhereUsedJumpMsg struct {
+	Kind UsageKind
+	Name string
+}
+
+// FindUsages lists every rule field and object whose addresses cover,
+// fall within, or overlap q: security rules and NAT rules in rulebase
+// order, then address objects, address groups and regions by name.
+// Members are resolved through x; "any", FQDNs, EDLs and countries match
+// nothing, since their addresses are not known here.
+func FindUsages(q netmatch.Set, rules []models.SecurityRule, nat []models.NATRule, x ObjectsModel) []UsageHit {
+	var hits []UsageHit
+	add := func(kind UsageKind, name, field string, negated bool, members []string) {
+		for _, m := range x.matchAddresses(members, q) {
+			hits = append(hits, UsageHit{Kind: kind, Name: name, Field: field, Via: m.via,
+				Value: m.value, Relation: m.relation, Negated: negated})
+		}
+	}
+	for _, r := range rules {
+		add(UsageSecurityRule, r.Name, "source", r.NegateSource, r.Sources)
+		add(UsageSecurityRule, r.Name, "destination", r.NegateDest, r.Destinations)
+	}
+	for _, r := range nat {
+		add(UsageNATRule, r.Name, "source", false, r.Sources)
+		add(UsageNATRule, r.Name, "destination", false, r.Destinations)
+		if r.TranslatedSource != "" && !r.SourceInterfaceIP {
+			add(UsageNATRule, r.Name, "translated source", false, strings.Split(r.TranslatedSource, ", "))
+		}
+		if r.TranslatedDest != "" {
+			add(UsageNATRule, r.Name, "translated destination", false, strings.Split(r.TranslatedDest, ", "))
+		}
+	}
+
+	// An object's own name heads each path; drop it, as it is the hit.
+	object := func(kind UsageKind, name, field string) {
+		for _, m := range x.matchAddresses([]string{name}, q) {
+			hits = append(hits, UsageHit{Kind: kind, Name: name, Field: field, Via: m.via[1:],
+				Value: m.value, Relation: m.relation})
+		}
+	}
+	for _, name := range sortedNames(x.Addresses(), func(a models.AddressObject) string { return a.Name }) {
+		object(UsageAddress, name, "value")
+	}
+	for _, name := range sortedNames(x.groups.AddressGroups, func(g models.AddressGroup) string { return g.Name }) {
+		object(UsageAddressGroup, name, "member")
+	}
+	for _, name := range sortedNames(x.groups.Regions, func(r models.Region) string { return r.Name }) {
+		object(UsageRegion, name, "address")
+	}
+	return hits
+}
+
+// sortedNames returns the names of items, sorted, each once.
+func sortedNames[T any](items []T, name func(T) string) []string {
+	names := make([]string, len(items))
+	for i, item := range items {
+		names[i] = name(item)
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+type addressMatch struct {
+	via      []string
+	value    string
+	relation netmatch.Relation
+}
+
+// matchAddresses expands address members and returns the values among them
+// that meet q, each with the names leading to it.
+func (x ObjectsModel) matchAddresses(members []string, q netmatch.Set) []addressMatch {
+	var out []addressMatch
+	var path []string
+	for _, m := range x.ExpandAddresses(members) {
+		path = append(path[:m.Depth], m.Name)
+		var values []string
+		parse := netmatch.Parse
+		switch m.Kind {
+		case MemberAddress, MemberLiteral:
+			values = []string{m.Value}
+			if a, _ := x.LookupAddress(m.Name); m.Kind == MemberAddress && a.Type == "ip-wildcard" {
+				parse = netmatch.ParseWildcard
+			}
+		case MemberRegion:
+			region, _ := x.LookupRegion(m.Name)
+			values = region.Addresses
+		}
+		for _, v := range values {
+			s, ok := parse(v)
+			if !ok {
+				continue
+			}
+			rel := netmatch.Compare(s, q)
+			if rel == netmatch.Disjoint {
+				continue
+			}
+			via := path
+			if m.Kind == MemberLiteral {
+				via = path[:m.Depth]
+			}
+			out = append(out, addressMatch{via: slices.Clone(via), value: v, relation: rel})
+		}
+	}
+	return out
+}
+
+// WhereUsedModel is the reverse lookup: every rule and object whose
+// addresses meet an IP, prefix, range or wildcard. The table's filter input
+// is the query.
+type WhereUsedModel struct {
+	TableBase
+	query   string
+	invalid bool // the query is not an address
+
+	rules   []models.SecurityRule
+	nat     []models.NATRule
+	objects ObjectsModel
+	hits    []UsageHit
+
+	notice string
+}
+
+func NewWhereUsedModel() WhereUsedModel {
+	base := NewTableBase("10.1.2.3, 10.0.0.0/8, 10.0.0.1-10.0.0.9, 10.0.0.0/0.0.255.0")
+	base.Filter.Prompt = "Address: "
+	return WhereUsedModel{TableBase: base}
+}
+
+func (m WhereUsedModel) SetSize(width, height int) WhereUsedModel {
+	m.TableBase = m.TableBase.SetSize(width, height)
+	m.Filter.SetWidth(max(width-24, 20))
+	m.EnsureCursorValid(len(m.hits))
+	m.EnsureVisible(m.visibleRows())
+	return m
+}
+
+func (m WhereUsedModel) SetLoading(loading bool) WhereUsedModel {
+	m.TableBase = m.TableBase.SetLoading(loading)
+	return m
+}
+
+// SetSpinnerFrame updates the current spinner animation frame.
+func (m WhereUsedModel) SetSpinnerFrame(frame string) WhereUsedModel {
+	m.TableBase = m.TableBase.SetSpinnerFrame(frame)
+	return m
+}
+
+// IsLoading reports whether rules or objects are still being read.
+func (m WhereUsedModel) IsLoading() bool {
+	return m.Loading
+}
+
+// HasData reports whether a lookup has been made.
+func (m WhereUsedModel) HasData() bool {
+	return m.query != ""
+}
+
+// IsFilterMode returns true while the query input is focused.
+func (m WhereUsedModel) IsFilterMode() bool {
+	return m.FilterMode
+}
+
+// Query is the address looked up.
+func (m WhereUsedModel) Query() string {
+	return m.query
+}
+
+// Hits are the results of the lookup.
+func (m WhereUsedModel) Hits() []UsageHit {
+	return m.hits
+}
+
+// SetData replaces the rules searched and the objects members resolve
+// through, re-running the lookup.
+func (m WhereUsedModel) SetData(rules []models.SecurityRule, nat []models.NATRule, x ObjectsModel) WhereUsedModel {
+	m.rules, m.nat, m.objects = rules, nat, x
+	return m.search()
+}
+
+// SetQuery looks up query. An empty query focuses the input instead.
+func (m WhereUsedModel) SetQuery(query string) (WhereUsedModel, tea.Cmd) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		m.FilterMode = true
+		m.Filter.SetValue("")
+		return m, m.Filter.Focus()
+	}
+	m.FilterMode = false
+	m.Filter.Blur()
+	m.Filter.SetValue(query)
+	m.query = query
+	m.ResetPosition()
+	return m.search(), nil
+}
+
+// search re-runs the lookup against the rules and objects held.
+func (m WhereUsedModel) search() WhereUsedModel {
+	m.hits = nil
+	m.notice = ""
+	if m.query == "" {
+		return m
+	}
+	q, ok := netmatch.Parse(m.query)
+	m.invalid = !ok
+	if ok {
+		m.hits = FindUsages(q, m.rules, m.nat, m.objects)
+	}
+	m.EnsureCursorValid(len(m.hits))
+	m.EnsureVisible(m.visibleRows())
+	return m
+}
+
+// visibleRows leaves room for the banner, query, table header, and the
+// selected hit's path.
+func (m WhereUsedModel) visibleRows() int {
+	return m.VisibleRows(14, 0)
+}
+
+func (m WhereUsedModel) Update(msg tea.Msg) (WhereUsedModel, tea.Cmd) {
+	if m.FilterMode {
+		base, exited, cmd := m.HandleFilterMode(msg)
+		m.TableBase = base
+		if key, ok := msg.(tea.KeyPressMsg); ok && exited && key.String() == "enter" {
+			m.query = strings.TrimSpace(m.Filter.Value())
+			m = m.search()
+		}
+		return m, cmd
+	}
+	key, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return m, nil
+	}
+	if key.String() == "/" {
+		m.Filter.SetValue("") // a new lookup, not an edit of the last
+	}
+	if key.String() == "enter" {
+		if m.Cursor >= len(m.hits) {
+			return m, nil
+		}
+		h := m.hits[m.Cursor]
+		if h.Kind == UsageAddressGroup || h.Kind == UsageRegion {
+			m.notice = "Groups and regions have no view of their own: look up a member instead"
+			return m, nil
+		}
+		jump := WhereUsedJumpMsg{Kind: h.Kind, Name: h.Name}
+		return m, func() tea.Msg { return jump }
+	}
+	base, handled, cmd := m.HandleNavigation(key, len(m.hits), m.visibleRows())
+	if handled {
+		m.TableBase = base
+		m.notice = ""
+	}
+	return m, cmd
+}
+
+func (m WhereUsedModel) View() string {
+	if m.Width == 0 {
+		return RenderLoadingInline(m.SpinnerFrame, "Loading...")
+	}
+
+	titleStyle := ViewTitleStyle.MarginBottom(1)
+	panelStyle := ViewPanelStyle.Width(m.Width - 4)
+	width := max(m.Width-12, 40)
+
+	var b strings.Builder
+	title := titleStyle.Render("Where Used")
+	if m.query != "" && !m.invalid {
+		title += BannerInfoStyle.Render(fmt.Sprintf(" [%d hits | /: new lookup | enter: jump]", len(m.hits)))
+	}
+	b.WriteString(title)
+	b.WriteString("\n")
+	if m.FilterMode || m.query == "" {
+		b.WriteString(m.Filter.View())
+	} else {
+		b.WriteString(DetailLabelStyle.Render("Address: ") + DetailValueStyle.Render(m.query))
+	}
+	b.WriteString("\n")
+	if m.Loading {
+		b.WriteString(RenderLoadingInline(m.SpinnerFrame, "Reading policies and objects..."))
+		b.WriteString("\n")
+	}
+	if m.notice != "" {
+		b.WriteString(StatusWarningStyle.Render(truncateEllipsis(m.notice, width)))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	switch {
+	case m.query == "":
+		b.WriteString(EmptyMsgStyle.Render("Type an IP, prefix, range or wildcard mask and press enter"))
+		return panelStyle.Render(b.String())
+	case m.invalid:
+		b.WriteString(ErrorMsgStyle.Render(truncateEllipsis(fmt.Sprintf("%q is not an IP, prefix, range or wildcard mask", m.query), width)))
+		return panelStyle.Render(b.String())
+	case len(m.hits) == 0:
+		b.WriteString(EmptyMsgStyle.Render("No rule or object uses " + m.query))
+		return panelStyle.Render(b.String())
+	}
+
+	header := fmt.Sprintf("  %-13s  %-24s  %-22s  %-8s  %s", "Kind", "Name", "Field", "Match", "Value")
+	b.WriteString(TableHeaderStyle.Render(truncateEllipsis(header, width)))
+	b.WriteString("\n")
+
+	visible := m.visibleRows()
+	end := min(m.Offset+visible, len(m.hits))
+	for i := m.Offset; i < end; i++ {
+		h := m.hits[i]
+		field := h.Field
+		if h.Negated {
+			field = "!" + field
+		}
+		row := fmt.Sprintf("  %-13s  %-24s  %-22s  %-8s  %s", h.Kind, truncateEllipsis(h.Name, 24),
+			truncateEllipsis(field, 22), h.Relation, h.Value)
+		row = truncateEllipsis(row, width)
+		if i == m.Cursor {
+			b.WriteString(TableSelectedRowStyle().Render(lipgloss.NewStyle().Width(width).Render(row)))
+		} else {
+			b.WriteString(DetailValueStyle.Render(row))
+		}
+		b.WriteString("\n")
+	}
+	if len(m.hits) > visible {
+		b.WriteString(DetailDimStyle.Render(fmt.Sprintf("  Showing %d-%d of %d", m.Offset+1, end, len(m.hits))))
+		b.WriteString("\n")
+	}
+	if m.Cursor < len(m.hits) {
+		b.WriteString("\n")
+		b.WriteString(DetailDimStyle.Render(truncateEllipsis(usagePath(m.hits[m.Cursor], m.query), width)))
+	}
+	return panelStyle.Render(b.String())
+}
+
+// usagePath spells out how a hit reaches its value, e.g.
+// "allow-web › destination › web-servers › web-1 = 10.0.0.0/24 (covers 10.0.0.5)".
+func usagePath(h UsageHit, query string) string {
+	field := h.Field
+	if h.Negated {
+		field = "not " + field
+	}
+	parts := append([]string{h.Name, field}, h.Via...)
+	return fmt.Sprintf("%s = %s (%s %s)", strings.Join(parts, " › "), h.Value, h.Relation, query)
+}
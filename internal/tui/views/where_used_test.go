@@ -0,0 +1,118 @@
+package views
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/jp2195/pyre/internal/models"
+	"github.com/jp2195/pyre/internal/netmatch"
+)
+
+func TestFindUsages(t *testing.T) {
+	rules := []models.SecurityRule{
+		{Name: "to-web", Sources: []string{"any"}, Destinations: []string{"web"}},
+		{Name: "not-hq", Sources: []string{"hq"}, NegateSource: true, Destinations: []string{"any"}},
+		{Name: "elsewhere", Sources: []string{"192.168.0.0/16"}, Destinations: []string{"blocked", "US"}},
+	}
+	nat := []models.NATRule{
+		{Name: "web-dnat", Destinations: []string{"203.0.113.5"}, TranslatedDest: "web-01"},
+		{Name: "egress", Sources: []string{"10.0.1.0/0.0.254.255"}, TranslatedSource: "ethernet1/1", SourceInterfaceIP: true},
+	}
+	q, _ := netmatch.Parse("10.0.1.10")
+	hits := FindUsages(q, rules, nat, testObjects())
+
+	type hit struct {
+		kind        UsageKind
+		name, field string
+		via         string
+		value       string
+		rel         netmatch.Relation
+		negated     bool
+	}
+	var got []hit
+	for _, h := range hits {
+		got = append(got, hit{h.Kind, h.Name, h.Field, strings.Join(h.Via, ">"), h.Value, h.Relation, h.Negated})
+	}
+	want := []hit{
+		{UsageSecurityRule, "to-web", "destination", "web>web-01", "10.0.1.10/32", netmatch.Equal, false},
+		{UsageSecurityRule, "not-hq", "source", "hq", "10.0.0.0/8", netmatch.Covers, true},
+		{UsageNATRule, "web-dnat", "translated destination", "web-01", "10.0.1.10/32", netmatch.Equal, false},
+		{UsageNATRule, "egress", "source", "", "10.0.1.0/0.0.254.255", netmatch.Covers, false},
+		{UsageAddress, "web-01", "value", "", "10.0.1.10/32", netmatch.Equal, false},
+		{UsageAddressGroup, "all", "member", "web>web-01", "10.0.1.10/32", netmatch.Equal, false},
+		{UsageAddressGroup, "web", "member", "web-01", "10.0.1.10/32", netmatch.Equal, false},
+		{UsageRegion, "hq", "address", "", "10.0.0.0/8", netmatch.Covers, false},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("FindUsages:\ngot  %+v\nwant %+v", got, want)
+	}
+
+	// A subnet query finds the hosts within it.
+	q, _ = netmatch.Parse("10.0.1.0/24")
+	var within []string
+	for _, h := range FindUsages(q, nil, nil, testObjects()) {
+		if h.Kind == UsageAddress {
+			within = append(within, h.Name+" "+h.Relation.String())
+		}
+	}
+	if !slices.Equal(within, []string{"web-01 within", "web-02 within"}) {
+		t.Errorf("addresses within 10.0.1.0/24 = %v", within)
+	}
+}
+
+func TestFindUsages_WildcardObject(t *testing.T) {
+	objects := NewObjectsModel().SetAddresses([]models.AddressObject{
+		{Name: "host-wc", Type: "ip-wildcard", Value: "10.0.1.10/0.0.0.0"},
+		{Name: "host-mask", Type: "ip-netmask", Value: "10.0.1.10/255.255.255.255"},
+	}, nil)
+	q, _ := netmatch.Parse("10.0.1.10")
+	var got []string
+	for _, h := range FindUsages(q, nil, nil, objects) {
+		got = append(got, h.Name+" "+h.Relation.String())
+	}
+	// The wildcard object's all-zero mask fixes every bit: it is the one
+	// address, not 0.0.0.0/0.
+	if !slices.Equal(got, []string{"host-mask equals", "host-wc equals"}) {
+		t.Errorf("usages = %v", got)
+	}
+}
+
+func TestWhereUsedModel_QueryAndJump(t *testing.T) {
+	InitStyles()
+	rules := []models.SecurityRule{{Name: "to-web", Destinations: []string{"web"}}}
+	m := NewWhereUsedModel().SetSize(160, 40).SetData(rules, nil, testObjects())
+
+	m, _ = m.SetQuery("")
+	if !m.IsFilterMode() {
+		t.Fatal("an empty query should focus the input")
+	}
+	for _, r := range "10.0.1.11" {
+		m, _ = m.Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+	}
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if m.IsFilterMode() || m.Query() != "10.0.1.11" || len(m.Hits()) == 0 {
+		t.Fatalf("query %q, %d hits, filtering %v", m.Query(), len(m.Hits()), m.IsFilterMode())
+	}
+	view := stripANSI(m.View())
+	for _, want := range []string{"Where Used", "hits", "to-web", "to-web › destination › web › web-02 = 10.0.1.11/32 (equals 10.0.1.11)"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+
+	_, cmd := m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("enter on a rule hit should jump")
+	}
+	if got, ok := cmd().(WhereUsedJumpMsg); !ok || got.Kind != UsageSecurityRule || got.Name != "to-web" {
+		t.Errorf("jump = %#v", got)
+	}
+
+	m, _ = m.SetQuery("web-01")
+	if view := stripANSI(m.View()); !strings.Contains(view, "is not an IP") {
+		t.Errorf("an object name should be rejected:\n%s", view)
+	}
+}
@@ -5,9 +5,9 @@ package tui
 //
 // Each viewSlot encodes all three fan-out roles for one sub-view model:
 //   resize    – always non-nil; called for every slot during handleWindowSize.
-//   spinner   – non-nil for the 14 views that display a spinner frame
-//               (9 table views + 5 dashboards).
-//   loading   – non-nil for the 9 refreshable views; called with true on refresh.
+//   spinner   – non-nil for the 23 views that display a spinner frame
+//               (17 table views + 5 dashboards + the console).
+//   loading   – non-nil for the 17 refreshable views; called with true on refresh.
 //   refreshFor – the ViewState that triggers a refresh for this slot; 0 when the
 //                slot is not refreshable.
 //
@@ -29,7 +29,7 @@ type viewSlot struct {
 }
 
 // viewSlots returns the canonical ordered registration table.
-// All 21 sub-view fields appear here exactly once.
+// All 30 sub-view fields appear here exactly once.
 func viewSlots() []viewSlot {
 	return []viewSlot{
 		// --- Navbar (width-only resize; no spinner; not refreshable) ---
@@ -221,6 +221,135 @@ func viewSlots() []viewSlot {
 			isLoading:  func(m *Model) bool { return m.objects.IsLoading() },
 			refreshFor: ViewObjects,
 		},
+		{
+			resize: func(m *Model, w, h, contentH int) {
+				m.configDiff = m.configDiff.SetSize(w, contentH)
+			},
+			spinner: func(m *Model, frame string) {
+				m.configDiff = m.configDiff.SetSpinnerFrame(frame)
+			},
+			loading: func(m *Model, v bool) {
+				m.configDiff = m.configDiff.SetLoading(v)
+			},
+			// A running commit job animates the spinner too.
+			isLoading:  func(m *Model) bool { return m.configDiff.IsLoading() || m.configDiff.JobRunning() },
+			refreshFor: ViewConfigDiff,
+		},
+		{
+			resize: func(m *Model, w, h, contentH int) {
+				m.backups = m.backups.SetSize(w, contentH)
+			},
+			spinner: func(m *Model, frame string) {
+				m.backups = m.backups.SetSpinnerFrame(frame)
+			},
+			loading: func(m *Model, v bool) {
+				m.backups = m.backups.SetLoading(v)
+			},
+			isLoading:  func(m *Model) bool { return m.backups.IsLoading() },
+			refreshFor: ViewBackups,
+		},
+		{
+			resize: func(m *Model, w, h, contentH int) {
+				m.configTree = m.configTree.SetSize(w, contentH)
+			},
+			spinner: func(m *Model, frame string) {
+				m.configTree = m.configTree.SetSpinnerFrame(frame)
+			},
+			// Refreshing re-fetches every branch that has been opened.
+			loading: func(m *Model, v bool) {
+				m.configTree = m.configTree.SetLoading(v)
+			},
+			isLoading:  func(m *Model) bool { return m.configTree.IsLoading() },
+			refreshFor: ViewConfigTree,
+		},
+		{
+			resize: func(m *Model, w, h, contentH int) {
+				m.console = m.console.SetSize(w, contentH)
+			},
+			spinner: func(m *Model, frame string) {
+				m.console = m.console.SetSpinnerFrame(frame)
+			},
+			// Not refreshable: re-running a command is up to the user.
+			isLoading: func(m *Model) bool { return m.console.IsLoading() },
+		},
+		{
+			resize: func(m *Model, w, h, contentH int) {
+				m.apiCalls = m.apiCalls.SetSize(w, contentH)
+			},
+			spinner: func(m *Model, frame string) {
+				m.apiCalls = m.apiCalls.SetSpinnerFrame(frame)
+			},
+			loading: func(m *Model, v bool) {
+				m.apiCalls = m.apiCalls.SetLoading(v)
+			},
+			isLoading:  func(m *Model) bool { return m.apiCalls.IsLoading() },
+			refreshFor: ViewAPICalls,
+		},
+		{
+			resize: func(m *Model, w, h, contentH int) {
+				m.alertsView = m.alertsView.SetSize(w, contentH)
+			},
+			spinner: func(m *Model, frame string) {
+				m.alertsView = m.alertsView.SetSpinnerFrame(frame)
+			},
+			// With alerting off or no device there is no check to wait for.
+			loading: func(m *Model, v bool) {
+				m.alertsView = m.alertsView.SetLoading(v && m.checkAlerts() != nil)
+			},
+			isLoading:  func(m *Model) bool { return m.alertsView.IsLoading() },
+			refreshFor: ViewAlerts,
+		},
+		{
+			resize: func(m *Model, w, h, contentH int) {
+				m.auditView = m.auditView.SetSize(w, contentH)
+			},
+			spinner: func(m *Model, frame string) {
+				m.auditView = m.auditView.SetSpinnerFrame(frame)
+			},
+			loading:    func(m *Model, v bool) { m.auditView = m.auditView.SetLoading(v) },
+			isLoading:  func(m *Model) bool { return m.auditView.IsLoading() },
+			refreshFor: ViewAudit,
+		},
+		{
+			resize: func(m *Model, w, h, contentH int) {
+				m.appIDView = m.appIDView.SetSize(w, contentH)
+			},
+			spinner: func(m *Model, frame string) {
+				m.appIDView = m.appIDView.SetSpinnerFrame(frame)
+			},
+			loading:    func(m *Model, v bool) { m.appIDView = m.appIDView.SetLoading(v) },
+			isLoading:  func(m *Model) bool { return m.appIDView.IsLoading() },
+			refreshFor: ViewAppID,
+		},
+		{
+			resize: func(m *Model, w, h, contentH int) {
+				m.whereUsed = m.whereUsed.SetSize(w, contentH)
+			},
+			spinner: func(m *Model, frame string) {
+				m.whereUsed = m.whereUsed.SetSpinnerFrame(frame)
+			},
+			// The lookup searches what Policies, NAT and Objects hold, so a
+			// refresh reloads all three.
+			loading: func(m *Model, v bool) {
+				m.policies = m.policies.SetLoading(v)
+				m.natPolicies = m.natPolicies.SetLoading(v)
+				m.objects = m.objects.SetLoading(v)
+				m.whereUsed = m.whereUsed.SetLoading(v)
+			},
+			isLoading:  func(m *Model) bool { return m.whereUsed.IsLoading() },
+			refreshFor: ViewWhereUsed,
+		},
+		{
+			resize: func(m *Model, w, h, contentH int) {
+				m.compare = m.compare.SetSize(w, contentH)
+			},
+			spinner: func(m *Model, frame string) {
+				m.compare = m.compare.SetSpinnerFrame(frame)
+			},
+			loading:    func(m *Model, v bool) { m.compare = m.compare.SetLoading(v) },
+			isLoading:  func(m *Model) bool { return m.compare.IsLoading() },
+			refreshFor: ViewCompare,
+		},
 
 		// --- Picker views (contentHeight; no spinner; not refreshable) ---
 		{
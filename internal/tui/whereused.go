@@ -0,0 +1,107 @@
+package tui
+
+import (
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/jp2195/pyre/internal/netmatch"
+	"github.com/jp2195/pyre/internal/tui/views"
+)
+
+// whereUsedHasData reports whether everything the lookup searches is
+// loaded: security rules, NAT rules, and objects.
+func (m Model) whereUsedHasData() bool {
+	return m.policies.HasData() && m.natPolicies.HasData() && m.objects.HasData()
+}
+
+// fetchWhereUsedData fetches whatever the lookup searches that is not yet
+// loaded, marking the views that own it as loading.
+func (m *Model) fetchWhereUsedData() tea.Cmd {
+	var cmds []tea.Cmd
+	if !m.policies.HasData() {
+		m.policies = m.policies.SetLoading(true)
+		cmds = append(cmds, m.fetchPolicies())
+	}
+	if !m.natPolicies.HasData() {
+		m.natPolicies = m.natPolicies.SetLoading(true)
+		cmds = append(cmds, m.fetchNATPolicies())
+	}
+	if !m.objects.HasData() {
+		m.objects = m.objects.SetLoading(true)
+		cmds = append(cmds, m.fetchObjects())
+	}
+	m.syncWhereUsed()
+	if len(cmds) == 0 {
+		return nil
+	}
+	return tea.Batch(append(cmds, m.spinner.Tick)...)
+}
+
+// syncWhereUsed hands the lookup the rules and objects the Policies, NAT
+// and Objects views hold, re-running it.
+func (m *Model) syncWhereUsed() {
+	m.whereUsed = m.whereUsed.SetData(m.policies.Rules(), m.natPolicies.Rules(), m.objects).
+		SetLoading(m.policies.IsLoading() || m.natPolicies.IsLoading() || m.objects.IsLoading())
+}
+
+// openWhereUsed switches to the Where Used view and looks up query; an
+// empty query leaves the input focused for one to be typed.
+func (m Model) openWhereUsed(query string) (tea.Model, tea.Cmd) {
+	model, fetch := m.handleSwitchView(SwitchViewMsg{ViewWhereUsed})
+	m = model.(Model)
+	var cmd tea.Cmd
+	m.whereUsed, cmd = m.whereUsed.SetQuery(query)
+	return m, tea.Batch(fetch, cmd)
+}
+
+// rowLookupAddress is the first address in the current view's selected row,
+// or "" when the view has no row or the row no address.
+func (m Model) rowLookupAddress() string {
+	var candidates []string
+	switch m.currentView {
+	case ViewPolicies:
+		candidates = m.policies.LookupAddresses()
+	case ViewNATPolicies:
+		candidates = m.natPolicies.LookupAddresses()
+	case ViewObjects:
+		candidates = m.objects.LookupAddresses()
+	case ViewSessions:
+		candidates = m.sessions.LookupAddresses()
+	case ViewInterfaces:
+		candidates = m.interfaces.LookupAddresses()
+	case ViewRoutes:
+		candidates = m.routes.LookupAddresses()
+	case ViewIPSecTunnels:
+		candidates = m.ipsecTunnels.LookupAddresses()
+	case ViewGPUsers:
+		candidates = m.gpUsers.LookupAddresses()
+	case ViewLogs:
+		candidates = m.logs.LookupAddresses()
+	}
+	for _, c := range candidates {
+		if _, ok := netmatch.Parse(c); ok {
+			return c
+		}
+	}
+	return ""
+}
+
+// handleWhereUsedJump shows the rule or address object a lookup hit is in.
+func (m Model) handleWhereUsedJump(msg views.WhereUsedJumpMsg) (tea.Model, tea.Cmd) {
+	var view ViewState
+	var found bool
+	switch msg.Kind {
+	case views.UsageSecurityRule:
+		view = ViewPolicies
+		m.policies, found = m.policies.Select(msg.Name)
+	case views.UsageNATRule:
+		view = ViewNATPolicies
+		m.natPolicies, found = m.natPolicies.Select(msg.Name)
+	case views.UsageAddress:
+		view = ViewObjects
+		m.objects, found = m.objects.SelectAddress(msg.Name)
+	}
+	if !found {
+		return m, nil
+	}
+	return m.handleSwitchView(SwitchViewMsg{view})
+}